@@ -0,0 +1,137 @@
+package resolvedb
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/resolvedb/resolvedb-go/uqrp"
+)
+
+// Access is a set of operation kinds granted by an ACL rule.
+type Access int
+
+// Access kinds.
+const (
+	AccessRead      Access = 1 << iota // Get, List, Count, Query and other reads
+	AccessWrite                        // Set, Delete, Patch, transactions and other writes
+	AccessReadWrite = AccessRead | AccessWrite
+)
+
+// String returns the access name.
+func (a Access) String() string {
+	switch a {
+	case 0:
+		return "none"
+	case AccessRead:
+		return "read"
+	case AccessWrite:
+		return "write"
+	case AccessReadWrite:
+		return "read_write"
+	default:
+		return fmt.Sprintf("Access(%d)", int(a))
+	}
+}
+
+// MarshalText encodes the access as its name.
+func (a Access) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// operationAccess returns the access an operation needs: AccessRead for
+// the operations routed as reads, AccessWrite for all others.
+func operationAccess(operation string) Access {
+	if uqrp.Operation(operation).IsRead() {
+		return AccessRead
+	}
+	return AccessWrite
+}
+
+// ACLRule grants access to the keys matching its patterns. Patterns use
+// path.Match syntax, e.g. "billing-*"; an empty pattern matches anything.
+type ACLRule struct {
+	Namespace string `json:"namespace,omitempty"` // Namespace pattern
+	Resource  string `json:"resource,omitempty"`  // Resource pattern
+	Key       string `json:"key,omitempty"`       // Key pattern
+	Access    Access `json:"access"`              // Access granted
+}
+
+// matches reports whether r covers the namespace, resource and key.
+func (r ACLRule) matches(namespace, resource, key string) bool {
+	return matchPattern(r.Namespace, namespace) &&
+		matchPattern(r.Resource, resource) &&
+		matchPattern(r.Key, key)
+}
+
+// matchPattern reports whether name matches pattern. Malformed patterns
+// match nothing.
+func matchPattern(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, err := path.Match(pattern, name)
+	return ok && err == nil
+}
+
+// WithLocalACL restricts the resources and keys this client may read or
+// write to those granted by rules. Anything no rule grants fails before a
+// query is sent, with an *ACLError. This guards against code touching
+// another team's resources in a shared namespace; it is not a security
+// boundary, which the server's API key permissions remain.
+//
+// Example:
+//
+//	client, err := resolvedb.New(
+//	    resolvedb.WithAPIKey(key),
+//	    resolvedb.WithLocalACL(
+//	        resolvedb.ACLRule{Resource: "billing-*", Access: resolvedb.AccessReadWrite},
+//	        resolvedb.ACLRule{Resource: "config", Access: resolvedb.AccessRead},
+//	    ),
+//	)
+func WithLocalACL(rules ...ACLRule) Option {
+	return func(c *clientConfig) {
+		c.acl = append([]ACLRule{}, rules...)
+	}
+}
+
+// ACLError reports an operation denied by the client's local ACL. It
+// matches ErrACLDenied.
+type ACLError struct {
+	Op        string // Operation (e.g., "get", "put")
+	Namespace string
+	Resource  string
+	Key       string
+	Access    Access // Access the operation needs
+}
+
+func (e *ACLError) Error() string {
+	target := e.Namespace + "/" + e.Resource
+	if e.Key != "" {
+		target += "/" + e.Key
+	}
+	return fmt.Sprintf("resolvedb: %s %s: %s access denied by local ACL", e.Op, target, e.Access)
+}
+
+// Unwrap returns ErrACLDenied.
+func (e *ACLError) Unwrap() error {
+	return ErrACLDenied
+}
+
+// checkACL returns an *ACLError if the local ACL, when configured, does
+// not grant operation on resource and key.
+func (c *Client) checkACL(operation, resource, key string, reqConfig *requestConfig) error {
+	if c.config.acl == nil {
+		return nil
+	}
+	namespace := c.namespace(reqConfig)
+	if namespace == "" {
+		namespace = c.config.defaultNamespace
+	}
+	need := operationAccess(operation)
+	for _, r := range c.config.acl {
+		if r.Access&need == need && r.matches(namespace, resource, key) {
+			return nil
+		}
+	}
+	return &ACLError{Op: operation, Namespace: namespace, Resource: resource, Key: key, Access: need}
+}
@@ -0,0 +1,65 @@
+package resolvedb
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCachedAuthTokens bounds the number of cached auth tokens.
+const maxCachedAuthTokens = 4096
+
+// authTokenCache reuses signed auth tokens within their validity period.
+type authTokenCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	clock   Clock
+	entries map[string]authTokenEntry
+}
+
+type authTokenEntry struct {
+	token     string
+	expiresAt time.Time
+}
+
+// newAuthTokenCache creates a token cache. Returns nil if ttl disables caching.
+func newAuthTokenCache(ttl time.Duration, clock Clock) *authTokenCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &authTokenCache{
+		ttl:     ttl,
+		clock:   clock,
+		entries: make(map[string]authTokenEntry),
+	}
+}
+
+// get returns a cached token for the operation, resource, key and
+// namespace, calling sign to create one if none is cached or the cached
+// token has expired.
+func (a *authTokenCache) get(operation, resource, key, namespace string, sign func() string) string {
+	cacheKey := operation + "|" + resource + "|" + key + "|" + namespace
+	now := a.clock.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if entry, ok := a.entries[cacheKey]; ok && now.Before(entry.expiresAt) {
+		return entry.token
+	}
+
+	// Evict expired entries at capacity; reset if still full
+	if len(a.entries) >= maxCachedAuthTokens {
+		for k, entry := range a.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(a.entries, k)
+			}
+		}
+		if len(a.entries) >= maxCachedAuthTokens {
+			a.entries = make(map[string]authTokenEntry)
+		}
+	}
+
+	token := sign()
+	a.entries[cacheKey] = authTokenEntry{token: token, expiresAt: now.Add(a.ttl)}
+	return token
+}
@@ -0,0 +1,104 @@
+package resolvedb
+
+import (
+	"context"
+	"sync"
+)
+
+// Batch collects independent operations that Do executes concurrently.
+// A Batch is not safe for concurrent use while operations are being added.
+//
+// Example:
+//
+//	var weather Weather
+//	results, err := client.Batch().
+//	    Get("weather", "paris", &weather).
+//	    Set("audit", "last-run", time.Now()).
+//	    Delete("cache", "stale").
+//	    Do(ctx)
+type Batch struct {
+	client *Client
+	ops    []batchOp
+}
+
+// batchOp is a single queued batch operation.
+type batchOp struct {
+	op       string
+	resource string
+	key      string
+	dst      any
+	data     any
+	opts     []RequestOption
+}
+
+// BatchResult is the outcome of one batch operation.
+type BatchResult struct {
+	Op       string       // Operation: "get", "set" or "delete"
+	Resource string       // Resource name
+	Key      string       // Key name
+	Write    *WriteResult // Write receipt for set and delete operations
+	Err      error        // Operation error, nil on success
+}
+
+// Batch returns a new empty batch bound to the client.
+func (c *Client) Batch() *Batch {
+	return &Batch{client: c}
+}
+
+// Get queues a read that unmarshals into dst.
+func (b *Batch) Get(resource, key string, dst any, opts ...RequestOption) *Batch {
+	b.ops = append(b.ops, batchOp{op: "get", resource: resource, key: key, dst: dst, opts: opts})
+	return b
+}
+
+// Set queues a write of data.
+func (b *Batch) Set(resource, key string, data any, opts ...RequestOption) *Batch {
+	b.ops = append(b.ops, batchOp{op: "set", resource: resource, key: key, data: data, opts: opts})
+	return b
+}
+
+// Delete queues a deletion.
+func (b *Batch) Delete(resource, key string, opts ...RequestOption) *Batch {
+	b.ops = append(b.ops, batchOp{op: "delete", resource: resource, key: key, opts: opts})
+	return b
+}
+
+// Len returns the number of queued operations.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Do executes all queued operations concurrently and waits for them to finish.
+// Results are returned in the order the operations were added. The returned
+// error is the first failed operation's error in that order, or nil if all
+// operations succeeded; operations are independent, so one failure does not
+// cancel the others.
+func (b *Batch) Do(ctx context.Context) ([]BatchResult, error) {
+	results := make([]BatchResult, len(b.ops))
+
+	var wg sync.WaitGroup
+	for i, op := range b.ops {
+		results[i] = BatchResult{Op: op.op, Resource: op.resource, Key: op.key}
+
+		wg.Add(1)
+		go func(res *BatchResult, op batchOp) {
+			defer wg.Done()
+			switch op.op {
+			case "get":
+				res.Err = b.client.Get(ctx, op.resource, op.key, op.dst, op.opts...)
+			case "set":
+				res.Write, res.Err = b.client.SetWithResult(ctx, op.resource, op.key, op.data, op.opts...)
+			case "delete":
+				res.Write, res.Err = b.client.DeleteWithResult(ctx, op.resource, op.key, op.opts...)
+			}
+		}(&results[i], op)
+	}
+	wg.Wait()
+
+	for _, res := range results {
+		if res.Err != nil {
+			return results, res.Err
+		}
+	}
+	return results, nil
+}
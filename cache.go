@@ -1,16 +1,19 @@
 package resolvedb
 
 import (
+	"cmp"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // CacheConfig configures response caching.
 type CacheConfig struct {
-	Enabled    bool          // Enable caching
-	MaxEntries int           // Maximum cache entries (0 = unlimited)
-	DefaultTTL time.Duration // Default TTL if not specified in response
+	Enabled    bool          `json:"enabled"`     // Enable caching
+	MaxEntries int           `json:"max_entries"` // Maximum cache entries (0 = unlimited); least recently used entries are evicted first
+	DefaultTTL time.Duration `json:"default_ttl"` // Default TTL if not specified in response
 }
 
 // DefaultCacheConfig returns the default cache configuration.
@@ -30,25 +33,43 @@ type Cache interface {
 	Clear()
 }
 
+// prefixCache is implemented by caches that can drop every entry whose key
+// starts with a prefix, so writes can drop the parameterized and projected
+// variants of a response along with it.
+type prefixCache interface {
+	DeletePrefix(prefix string)
+}
+
+// pinningCache is implemented by caches that can keep entries from being
+// evicted for space (see WithPin).
+type pinningCache interface {
+	SetPinned(key string, resp *Response, ttl time.Duration)
+}
+
 // memoryCache is an in-memory cache implementation.
 type memoryCache struct {
 	mu         sync.RWMutex
 	entries    map[string]*cacheEntry
 	maxEntries int
 	defaultTTL time.Duration
+	clock      Clock
+	uses       atomic.Uint64 // Use counter, for least-recently-used eviction
 }
 
 type cacheEntry struct {
 	response  *Response
 	expiresAt time.Time
+	pinned    bool
+	lastUsed  atomic.Uint64
 }
 
 // newMemoryCache creates a new in-memory cache.
-func newMemoryCache(config CacheConfig) *memoryCache {
+func newMemoryCache(config CacheConfig, clock Clock) *memoryCache {
 	return &memoryCache{
 		entries:    make(map[string]*cacheEntry),
 		maxEntries: config.MaxEntries,
 		defaultTTL: config.DefaultTTL,
+		clock:      clock,
 	}
 }
 
@@ -62,36 +83,80 @@ func (c *memoryCache) Get(key string) (*Response, bool) {
 		return nil, false
 	}
 
-	if time.Now().After(entry.expiresAt) {
+	if c.clock.Now().After(entry.expiresAt) {
 		c.Delete(key)
 		return nil, false
 	}
 
+	entry.lastUsed.Store(c.uses.Add(1))
 	return entry.response, true
 }
 
-// Set stores a response in the cache.
+// Set stores a response in the cache for ttl, or for the default TTL if
+// ttl is 0.
 func (c *memoryCache) Set(key string, resp *Response, ttl time.Duration) {
-	if ttl == 0 {
-		ttl = c.defaultTTL
-	}
+	c.set(key, resp, ttl, false)
+}
 
-	// Use response TTL if available and shorter
-	if resp.TTL > 0 && resp.TTL < ttl {
-		ttl = resp.TTL
-	}
+// SetPinned stores a response like Set, but never evicts it for space.
+func (c *memoryCache) SetPinned(key string, resp *Response, ttl time.Duration) {
+	c.set(key, resp, ttl, true)
+}
+
+func (c *memoryCache) set(key string, resp *Response, ttl time.Duration, pinned bool) {
+	key = normalizeKey(key)
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Simple eviction: remove expired entries if at capacity
-	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+	if ttl == 0 {
+		ttl = c.defaultTTL
+	}
+
+	// At capacity, drop expired entries, then the least recently used
+	if _, ok := c.entries[key]; !ok && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
 		c.evictExpired()
+		if len(c.entries) >= c.maxEntries {
+			c.evictLeastRecentlyUsed()
+		}
 	}
 
-	c.entries[normalizeKey(key)] = &cacheEntry{
+	entry := &cacheEntry{
 		response:  resp,
-		expiresAt: time.Now().Add(ttl),
+		expiresAt: c.clock.Now().Add(ttl),
+		pinned:    pinned,
+	}
+	entry.lastUsed.Store(c.uses.Add(1))
+	c.entries[key] = entry
+}
+
+// setLimits changes the entry limit and default TTL, evicting expired
+// entries, then the least recently used, down to a lower limit. Entries
+// already cached keep their expiry.
+func (c *memoryCache) setLimits(config CacheConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxEntries = config.MaxEntries
+	c.defaultTTL = config.DefaultTTL
+	if c.maxEntries <= 0 || len(c.entries) <= c.maxEntries {
+		return
+	}
+	c.evictExpired()
+	excess := len(c.entries) - c.maxEntries
+	if excess <= 0 {
+		return
+	}
+	var unpinned []string
+	for key, entry := range c.entries {
+		if !entry.pinned {
+			unpinned = append(unpinned, key)
+		}
+	}
+	slices.SortFunc(unpinned, func(a, b string) int {
+		return cmp.Compare(c.entries[a].lastUsed.Load(), c.entries[b].lastUsed.Load())
+	})
+	for _, key := range unpinned[:min(excess, len(unpinned))] {
+		delete(c.entries, key)
 	}
 }
 
@@ -102,6 +167,18 @@ func (c *memoryCache) Delete(key string) {
 	c.mu.Unlock()
 }
 
+// DeletePrefix removes the cached responses whose keys start with prefix.
+func (c *memoryCache) DeletePrefix(prefix string) {
+	prefix = normalizeKey(prefix)
+	c.mu.Lock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+	c.mu.Unlock()
+}
+
 // Clear removes all cached responses.
 func (c *memoryCache) Clear() {
 	c.mu.Lock()
@@ -109,9 +186,17 @@ func (c *memoryCache) Clear() {
 	c.mu.Unlock()
 }
 
+// Len returns the number of cached entries, including expired entries not
+// yet evicted.
+func (c *memoryCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
 // evictExpired removes expired entries. Must be called with lock held.
 func (c *memoryCache) evictExpired() {
-	now := time.Now()
+	now := c.clock.Now()
 	for key, entry := range c.entries {
 		if now.After(entry.expiresAt) {
 			delete(c.entries, key)
@@ -119,6 +204,25 @@ func (c *memoryCache) evictExpired() {
 	}
 }
 
+// evictLeastRecentlyUsed removes the least recently used entry that is
+// not pinned, if any. Must be called with lock held.
+func (c *memoryCache) evictLeastRecentlyUsed() {
+	var oldestKey string
+	var oldest uint64
+	found := false
+	for key, entry := range c.entries {
+		if entry.pinned {
+			continue
+		}
+		if used := entry.lastUsed.Load(); !found || used < oldest {
+			oldestKey, oldest, found = key, used, true
+		}
+	}
+	if found {
+		delete(c.entries, oldestKey)
+	}
+}
+
 // normalizeKey normalizes a cache key for consistent lookups.
 // Per security review: lowercase before hashing to prevent cache poisoning.
 func normalizeKey(key string) string {
@@ -0,0 +1,186 @@
+package resolvedb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go/rdbtypes"
+)
+
+// CacheEntryVersion is the envelope version written by MarshalCacheEntry.
+// It changes only when an entry can no longer be read by older releases;
+// fields added in the meantime are ignored by readers that do not know
+// them.
+const CacheEntryVersion = 1
+
+// ErrCacheEntryVersion is returned by UnmarshalCacheEntry for an entry
+// written with a newer envelope version. Caches should treat it as a miss.
+var ErrCacheEntryVersion = errors.New("resolvedb: unsupported cache entry version")
+
+// CacheEntry is a cached response and the time it expires, the unit
+// stored by Cache implementations backed by external stores.
+type CacheEntry struct {
+	Response  *Response
+	ExpiresAt time.Time
+}
+
+// cacheEnvelope is the stored form of a CacheEntry. Times are Unix
+// seconds and durations are seconds, with nanosecond precision.
+type cacheEnvelope struct {
+	Version   int            `json:"v"`
+	ExpiresAt rdbtypes.Time  `json:"exp"`
+	Response  cachedResponse `json:"r"`
+}
+
+// cachedResponse is the stored form of a Response. Per-query metadata
+// (rate-limit state and attempt counts) describes the query that filled
+// the cache, not later reads, so it is not stored.
+type cachedResponse struct {
+	Version          string              `json:"version,omitempty"`
+	Status           string              `json:"status,omitempty"`
+	Type             string              `json:"type,omitempty"`
+	Encoding         string              `json:"encoding,omitempty"`
+	Format           string              `json:"format,omitempty"`
+	TTL              rdbtypes.Duration   `json:"ttl,omitempty"`
+	Data             []byte              `json:"data,omitempty"`
+	Error            string              `json:"error,omitempty"`
+	Chunks           int                 `json:"chunks,omitempty"`
+	ChunkID          int                 `json:"chunk_id,omitempty"`
+	Hash             string              `json:"hash,omitempty"`
+	Timestamp        rdbtypes.Time       `json:"timestamp"`
+	Expires          rdbtypes.Time       `json:"expires"`
+	Records          [][]byte            `json:"records,omitempty"`
+	RecordTTLs       []rdbtypes.Duration `json:"record_ttls,omitempty"`
+	Manifest         *cachedManifest     `json:"manifest,omitempty"`
+	Transport        string              `json:"transport,omitempty"`
+	Region           string              `json:"region,omitempty"`
+	ConsistencyToken string              `json:"consistency_token,omitempty"`
+	ChangeCursor     string              `json:"change_cursor,omitempty"`
+	CanonicalName    string              `json:"canonical_name,omitempty"`
+	Source           string              `json:"source,omitempty"`
+	Prefetch         []cachedHint        `json:"prefetch,omitempty"`
+}
+
+// cachedHint is the stored form of a PrefetchHint.
+type cachedHint struct {
+	Resource string `json:"r,omitempty"`
+	Key      string `json:"k"`
+}
+
+// cachedManifest is the stored form of a ChunkManifest.
+type cachedManifest struct {
+	Chunks int      `json:"n"`
+	Hashes []string `json:"hashes"`
+	Root   string   `json:"root"`
+}
+
+// MarshalCacheEntry encodes a cache entry in a versioned JSON envelope,
+// for Cache implementations that store responses outside the process,
+// such as in Redis or on disk. UnmarshalCacheEntry reverses it.
+//
+// Example:
+//
+//	func (c *redisCache) Set(key string, resp *resolvedb.Response, ttl time.Duration) {
+//	    data, err := resolvedb.MarshalCacheEntry(resolvedb.CacheEntry{Response: resp, ExpiresAt: time.Now().Add(ttl)})
+//	    if err == nil {
+//	        c.rdb.Set(ctx, key, data, ttl)
+//	    }
+//	}
+func MarshalCacheEntry(e CacheEntry) ([]byte, error) {
+	if e.Response == nil {
+		return nil, fmt.Errorf("resolvedb: marshal cache entry: nil response")
+	}
+	r := e.Response
+	env := cacheEnvelope{
+		Version:   CacheEntryVersion,
+		ExpiresAt: rdbtypes.NewTime(e.ExpiresAt),
+		Response: cachedResponse{
+			Version:          r.Version,
+			Status:           r.Status,
+			Type:             r.Type,
+			Encoding:         r.Encoding,
+			Format:           r.Format,
+			TTL:              rdbtypes.Duration(r.TTL),
+			Data:             r.Data,
+			Error:            r.Error,
+			Chunks:           r.Chunks,
+			ChunkID:          r.ChunkID,
+			Hash:             r.Hash,
+			Timestamp:        rdbtypes.NewTime(r.Timestamp),
+			Expires:          rdbtypes.NewTime(r.Expires),
+			Records:          r.Records,
+			Transport:        r.Meta.Transport,
+			Region:           r.Meta.Region,
+			ConsistencyToken: r.Meta.ConsistencyToken,
+			ChangeCursor:     r.Meta.ChangeCursor,
+			CanonicalName:    r.Meta.CanonicalName,
+			Source:           string(r.Meta.Source),
+		},
+	}
+	for _, ttl := range r.RecordTTLs {
+		env.Response.RecordTTLs = append(env.Response.RecordTTLs, rdbtypes.Duration(ttl))
+	}
+	if m := r.Manifest; m != nil {
+		env.Response.Manifest = &cachedManifest{Chunks: m.Chunks, Hashes: m.Hashes, Root: m.Root}
+	}
+	for _, h := range r.Prefetch {
+		env.Response.Prefetch = append(env.Response.Prefetch, cachedHint{Resource: h.Resource, Key: h.Key})
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("resolvedb: marshal cache entry: %w", err)
+	}
+	return data, nil
+}
+
+// UnmarshalCacheEntry decodes a cache entry encoded by MarshalCacheEntry.
+// Entries from newer releases decode as long as their envelope version is
+// supported; others fail with ErrCacheEntryVersion. Callers should check
+// ExpiresAt, as an external store may keep entries past their expiry.
+func UnmarshalCacheEntry(data []byte) (CacheEntry, error) {
+	var env cacheEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return CacheEntry{}, &DecodeError{Format: "json", Type: "cache entry", Err: err}
+	}
+	if env.Version < 1 || env.Version > CacheEntryVersion {
+		return CacheEntry{}, fmt.Errorf("%w: %d", ErrCacheEntryVersion, env.Version)
+	}
+
+	c := env.Response
+	r := &Response{
+		Version:   c.Version,
+		Status:    c.Status,
+		Type:      c.Type,
+		Encoding:  c.Encoding,
+		Format:    c.Format,
+		TTL:       c.TTL.Std(),
+		Data:      c.Data,
+		Error:     c.Error,
+		Chunks:    c.Chunks,
+		ChunkID:   c.ChunkID,
+		Hash:      c.Hash,
+		Timestamp: c.Timestamp.Time,
+		Expires:   c.Expires.Time,
+		Records:   c.Records,
+		Meta: ResponseMeta{
+			Transport:        c.Transport,
+			Region:           c.Region,
+			ConsistencyToken: c.ConsistencyToken,
+			ChangeCursor:     c.ChangeCursor,
+			CanonicalName:    c.CanonicalName,
+			Source:           ResponseSource(c.Source),
+		},
+	}
+	for _, ttl := range c.RecordTTLs {
+		r.RecordTTLs = append(r.RecordTTLs, ttl.Std())
+	}
+	if m := c.Manifest; m != nil {
+		r.Manifest = &ChunkManifest{Chunks: m.Chunks, Hashes: m.Hashes, Root: m.Root}
+	}
+	for _, h := range c.Prefetch {
+		r.Prefetch = append(r.Prefetch, PrefetchHint{Resource: h.Resource, Key: h.Key})
+	}
+	return CacheEntry{Response: r, ExpiresAt: env.ExpiresAt.Time}, nil
+}
@@ -0,0 +1,65 @@
+package resolvedb
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is a panic recovered from a user-supplied callback, such as
+// an informer handler, progress function or service hook.
+type PanicError struct {
+	Callback string // Callback that panicked, e.g. "informer OnAdd"
+	Value    any    // Value passed to panic
+	Stack    []byte // Stack trace of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("resolvedb: %s callback panicked: %v", e.Callback, e.Value)
+}
+
+// panicReporter is implemented by clients that record callback panics.
+type panicReporter interface {
+	reportPanic(*PanicError)
+}
+
+// SafeCall runs the callback fn, recovering a panic so a buggy hook cannot
+// take down the caller. A recovered panic is returned as a *PanicError
+// and, when owner is a *Client, *ReadOnlyClient or *Outbox, logged with
+// the client's logger and counted in Stats.CallbackPanics. Service packages
+// pass the client they were created with as owner.
+//
+// Example:
+//
+//	if err := resolvedb.SafeCall(client, "audit hook", func() { hook(ev) }); err != nil {
+//	    // the hook panicked; ev was still processed
+//	}
+func SafeCall(owner any, name string, fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			perr := &PanicError{Callback: name, Value: r, Stack: debug.Stack()}
+			if rep, ok := owner.(panicReporter); ok {
+				rep.reportPanic(perr)
+			}
+			err = perr
+		}
+	}()
+	fn()
+	return nil
+}
+
+// reportPanic logs and counts a recovered callback panic.
+func (c *Client) reportPanic(err *PanicError) {
+	c.stats.panics.Add(1)
+	c.logger().Error("resolvedb: callback panicked",
+		"callback", err.Callback, "panic", fmt.Sprint(err.Value), "stack", string(err.Stack))
+}
+
+// reportPanic logs and counts a recovered callback panic.
+func (r *ReadOnlyClient) reportPanic(err *PanicError) {
+	r.c.reportPanic(err)
+}
+
+// reportPanic logs and counts a recovered callback panic.
+func (o *Outbox) reportPanic(err *PanicError) {
+	o.client.reportPanic(err)
+}
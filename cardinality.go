@@ -0,0 +1,127 @@
+package resolvedb
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultCardinalityWindow is the window over which distinct keys are
+// counted when the cache has no default TTL.
+const defaultCardinalityWindow = 5 * time.Minute
+
+// CardinalityAction selects what happens when a resource has more
+// distinct keys cached than WithCacheKeyLimit allows.
+type CardinalityAction int
+
+const (
+	// CardinalityWarn reports a WarningCacheCardinality and keeps
+	// caching.
+	CardinalityWarn CardinalityAction = iota
+
+	// CardinalityRefuse reports a WarningCacheCardinality and stops
+	// caching further keys of the resource until the window ends. Keys
+	// already counted are still cached.
+	CardinalityRefuse
+)
+
+// WithCacheKeyLimit guards the cache against resources whose keys explode
+// in number, such as per-coordinate or per-IP lookups cached by accident,
+// which would otherwise evict everything else. Distinct keys are counted
+// per resource over a window of the cache's DefaultTTL (5 minutes if
+// unset); once a resource exceeds maxKeys in a window, action applies.
+// Tracking holds at most maxKeys keys per resource. Stats().CacheRefused
+// counts responses left uncached.
+//
+// Example:
+//
+//	client, err := resolvedb.New(
+//	    resolvedb.WithCacheKeyLimit(500, resolvedb.CardinalityRefuse),
+//	)
+func WithCacheKeyLimit(maxKeys int, action CardinalityAction) Option {
+	return func(c *clientConfig) {
+		c.cacheKeyLimit = maxKeys
+		c.cardinalityAction = action
+	}
+}
+
+// cardinalityGuard counts the distinct keys cached per resource.
+type cardinalityGuard struct {
+	max    int
+	action CardinalityAction
+	window time.Duration
+	clock  Clock
+
+	mu        sync.Mutex
+	resources map[string]*resourceKeys
+}
+
+// resourceKeys are the keys of a resource cached in the current window.
+type resourceKeys struct {
+	start time.Time
+	keys  map[string]struct{}
+	over  bool // More than max keys were seen this window
+}
+
+// newCardinalityGuard returns the guard configured for a client, or nil.
+func newCardinalityGuard(config *clientConfig) *cardinalityGuard {
+	if config.cacheKeyLimit <= 0 || !config.cacheConfig.Enabled {
+		return nil
+	}
+	window := config.cacheConfig.DefaultTTL
+	if window <= 0 {
+		window = defaultCardinalityWindow
+	}
+	return &cardinalityGuard{
+		max:       config.cacheKeyLimit,
+		action:    config.cardinalityAction,
+		window:    window,
+		clock:     config.clock,
+		resources: make(map[string]*resourceKeys),
+	}
+}
+
+// admit records that key of resource is about to be cached and reports
+// whether it may be, and whether the resource just went over the limit.
+func (g *cardinalityGuard) admit(resource, key string) (ok, exceeded bool) {
+	now := g.clock.Now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	rk := g.resources[resource]
+	if rk == nil || now.Sub(rk.start) >= g.window {
+		rk = &resourceKeys{start: now, keys: make(map[string]struct{})}
+		g.resources[resource] = rk
+	}
+	if _, seen := rk.keys[key]; seen {
+		return true, false
+	}
+	if len(rk.keys) < g.max {
+		rk.keys[key] = struct{}{}
+		return true, false
+	}
+	exceeded = !rk.over
+	rk.over = true
+	return g.action != CardinalityRefuse, exceeded
+}
+
+// admitToCache applies the cache key limit to a response about to be
+// cached, reporting whether to cache it.
+func (c *Client) admitToCache(resource, key string) bool {
+	if c.cardinality == nil {
+		return true
+	}
+	ok, exceeded := c.cardinality.admit(resource, key)
+	if exceeded {
+		c.warn(Warning{
+			Code:     WarningCacheCardinality,
+			Resource: resource,
+			Message:  fmt.Sprintf("more than %d distinct keys of %s cached within %s", c.cardinality.max, resource, c.cardinality.window),
+		})
+	}
+	if !ok {
+		c.stats.cacheRefused.Add(1)
+	}
+	return ok
+}
@@ -0,0 +1,95 @@
+package resolvedb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// Change log operations, as reported in the op field of changes entries.
+const (
+	changeAdded    = "add"
+	changeModified = "mod"
+	changeRemoved  = "del"
+)
+
+// errResync is returned by changesSince when the changes cannot be read
+// from the cursor and the key list must be read in full instead.
+var errResync = errors.New("resolvedb: change log needs resync")
+
+// keyChange is an entry of a changes response: the metadata of a key and
+// how it changed.
+type keyChange struct {
+	KeyInfo
+	Op string
+}
+
+// UnmarshalJSON decodes a change entry, a KeyInfo object with an op field.
+func (k *keyChange) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Op string `json:"op"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	k.Op = raw.Op
+	return k.KeyInfo.UnmarshalJSON(data)
+}
+
+// changesSince reads the changes to a resource's keys since cursor, which
+// a previous list or changes response reported as Meta.ChangeCursor.
+func (c *Client) changesSince(ctx context.Context, resource, cursor string, reqConfig *requestConfig) (*Response, []keyChange, error) {
+	reqConfig.params = []string{PrefixCursor + cursor}
+
+	queryName := c.buildQueryName("changes", resource, "", reqConfig)
+
+	resp, err := c.query(ctx, "changes", resource, "", queryName, reqConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := resp.ToError(); err != nil {
+		return nil, nil, c.queryError(err, "changes", resource, "", reqConfig, resp)
+	}
+	// Change logs too long for one answer are cheaper to replace by a list
+	if resp.Chunks > 1 {
+		return nil, nil, errResync
+	}
+
+	var changes []keyChange
+	if err := resp.Unmarshal(&changes); err != nil {
+		return nil, nil, err
+	}
+	return resp, changes, nil
+}
+
+// needsResync reports whether err from changesSince means the cursor
+// cannot be used: the server does not support change cursors, has expired
+// the cursor, or rejected it. Transport failures do not.
+func needsResync(err error) bool {
+	var rerr *Error
+	return errors.Is(err, errResync) || errors.As(err, &rerr)
+}
+
+// applyChanges updates known with changes and returns the events they
+// cause.
+func applyChanges(known map[string]KeyInfo, changes []keyChange) []ResourceEvent {
+	var events []ResourceEvent
+	for _, ch := range changes {
+		prev, ok := known[ch.Key]
+		switch {
+		case ch.Op == changeRemoved:
+			if ok {
+				events = append(events, ResourceEvent{Type: KeyRemoved, Key: ch.Key, Info: prev})
+				delete(known, ch.Key)
+			}
+		case !ok:
+			events = append(events, ResourceEvent{Type: KeyAdded, Key: ch.Key, Info: ch.KeyInfo})
+			known[ch.Key] = ch.KeyInfo
+		default:
+			events = append(events, ResourceEvent{Type: KeyModified, Key: ch.Key, Info: ch.KeyInfo})
+			known[ch.Key] = ch.KeyInfo
+		}
+	}
+	return events
+}
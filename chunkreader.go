@@ -0,0 +1,291 @@
+package resolvedb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+)
+
+// chunkResult is a fetched chunk, or the error fetching it.
+type chunkResult struct {
+	data []byte
+	err  error
+}
+
+// chunkReader reads the payload of a chunked value in order. Chunks are
+// fetched ahead of the reader, with at most WithChunkConcurrency of them
+// fetched or waiting to be read at a time, and the payload hash is
+// computed as they are read, so memory is bounded by the chunks in flight
+// rather than the size of the value.
+type chunkReader struct {
+	resource string
+	key      string
+	want     string // Manifest hash
+	results  []chan chunkResult
+	slots    semaphore
+	progress *chunkProgress
+	cancel   context.CancelFunc
+
+	next int    // Index of the next chunk to read
+	buf  []byte // Unread part of the current chunk
+	hash hash.Hash
+	err  error // Sticky; io.EOF once the hash matched
+}
+
+// openChunks starts fetching the chunks listed in a manifest and returns
+// a reader over their payload. The reader must be closed.
+func (c *Client) openChunks(ctx context.Context, resource, key string, manifest []byte, reqConfig *requestConfig) (*chunkReader, error) {
+	var m chunkManifest
+	if err := json.Unmarshal(manifest, &m); err != nil {
+		return nil, &DecodeError{Format: "json", Type: "chunk manifest", Err: err}
+	}
+	if m.Chunks < 1 {
+		return nil, &ProtocolError{Err: fmt.Errorf("chunk manifest of %s/%s lists %d chunks", resource, key, m.Chunks)}
+	}
+	limits := c.config.responseLimits
+	if m.Chunks > limits.MaxChunks {
+		return nil, &LimitError{Limit: LimitChunks, Resource: resource, Key: key, Size: int64(m.Chunks), Max: int64(limits.MaxChunks)}
+	}
+
+	bgCtx, done, ok := c.background(ctx)
+	if !ok {
+		return nil, ErrClosed
+	}
+	ctx, cancel := context.WithCancel(bgCtx)
+
+	// Chunks hold encoded bytes, so field projection does not apply
+	chunkConfig := *reqConfig
+	chunkConfig.fields = nil
+
+	r := &chunkReader{
+		resource: resource,
+		key:      key,
+		want:     m.Hash,
+		results:  make([]chan chunkResult, m.Chunks),
+		slots:    newSemaphore(c.config.chunkConcurrency),
+		progress: newChunkProgress(c, m.Chunks, reqConfig.chunkProgress),
+		cancel:   cancel,
+		hash:     sha256.New(),
+	}
+	for i := range r.results {
+		r.results[i] = make(chan chunkResult, 1)
+	}
+
+	// Slots are taken in chunk order and given back as the reader moves
+	// past each chunk, so fetching never runs further ahead of it
+	go func() {
+		defer done()
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		for i := range r.results {
+			if err := r.slots.acquire(ctx); err != nil {
+				r.results[i] <- chunkResult{err: err}
+				return
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				data, err := c.readChunk(ctx, chunksResource, chunkKey(resource, key, i), &chunkConfig)
+				if err != nil {
+					err = fmt.Errorf("read chunk %d of %d: %w", i+1, m.Chunks, err)
+				}
+				r.results[i] <- chunkResult{data: data, err: err}
+			}(i)
+		}
+	}()
+	return r, nil
+}
+
+// Read implements io.Reader. Once every chunk is read it returns io.EOF,
+// or a ProtocolError if the payload does not match the manifest hash.
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.next == len(r.results) {
+			r.cancel()
+			r.err = io.EOF
+			if hex.EncodeToString(r.hash.Sum(nil)[:8]) != r.want {
+				r.err = &ProtocolError{Err: fmt.Errorf("chunks of %s/%s do not match their manifest; the value may be mid-update", r.resource, r.key)}
+			}
+			continue
+		}
+
+		res := <-r.results[r.next]
+		if res.err != nil {
+			r.cancel()
+			r.err = res.err
+			continue
+		}
+		r.slots.release()
+		r.next++
+		r.hash.Write(res.data)
+		r.progress.add(len(res.data))
+		r.buf = res.data
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// Close stops fetching chunks.
+func (r *chunkReader) Close() error {
+	r.cancel()
+	return nil
+}
+
+// readChunks fetches and reassembles the chunks listed in a manifest.
+func (c *Client) readChunks(ctx context.Context, resource, key string, manifest []byte, reqConfig *requestConfig) ([]byte, error) {
+	r, err := c.openChunks(ctx, resource, key, manifest, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	max := c.config.responseLimits.MaxBlobBytes
+	payload, err := io.ReadAll(io.LimitReader(r, int64(max)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > max {
+		return nil, &LimitError{Limit: LimitBlobBytes, Resource: resource, Key: key, Size: int64(len(payload)), Max: int64(max)}
+	}
+	return payload, nil
+}
+
+// OpenReader retrieves a value written with WithAutoCodec and returns a
+// reader over its decoded bytes. Unlike Get, chunked values are streamed:
+// chunks are fetched ahead of the reader, at most WithChunkConcurrency at
+// a time, inflated as they are read, and checked against the manifest
+// hash when the last one is read, so the final Read returns an error
+// instead of io.EOF if they do not match. ResponseLimits.MaxBlobBytes does
+// not apply. Values that are not chunked are decoded as Get does.
+//
+// Close the reader when done with it; closing it early stops fetching.
+//
+// Example:
+//
+//	r, err := client.OpenReader(ctx, "archives", "2024")
+//	if err != nil {
+//	    return err
+//	}
+//	defer r.Close()
+//	_, err = io.Copy(f, r)
+func (c *Client) OpenReader(ctx context.Context, resource, key string, opts ...RequestOption) (io.ReadCloser, error) {
+	reqConfig := newRequestConfig(ctx, opts)
+	resp, err := c.get(ctx, resource, key, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+	codec, manifest, _ := splitCodec(resp.Data)
+	if !c.config.autoCodec || !encodedFormat(resp.Format) || codec != CodecChunked {
+		if c.config.autoCodec {
+			if resp, err = c.decodeAuto(ctx, resource, key, resp, reqConfig); err != nil {
+				return nil, err
+			}
+		}
+		if resp.Data == nil {
+			return nil, ErrNotFound
+		}
+		return io.NopCloser(bytes.NewReader(resp.Data)), nil
+	}
+
+	chunks, err := c.openChunks(ctx, resource, key, manifest, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.inflateChunks(ctx, resource, key, chunks, reqConfig)
+	if err != nil {
+		chunks.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// inflateChunks wraps the payload of a chunked value in a reader that
+// reverses its compression, if any.
+func (c *Client) inflateChunks(ctx context.Context, resource, key string, chunks *chunkReader, reqConfig *requestConfig) (io.ReadCloser, error) {
+	payload := bufio.NewReader(chunks)
+	header, err := payload.Peek(codecHeaderSize)
+	if err == io.EOF {
+		return &bufferedChunks{Reader: payload, chunks: chunks}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	codec, _, _ := splitCodec(header)
+	switch codec {
+	case CodecCompressed:
+		payload.Discard(codecHeaderSize)
+		return &inflateReader{chunks: chunks, payload: payload, inflater: flate.NewReader(payload)}, nil
+	case CodecDictionary:
+		if resource == dictionariesResource {
+			return nil, &ProtocolError{Err: fmt.Errorf("dictionary %s is itself dictionary-compressed", key)}
+		}
+		header := make([]byte, codecHeaderSize+dictionaryIDSize)
+		if _, err := io.ReadFull(payload, header); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = fmt.Errorf("dictionary-compressed value too short")
+			}
+			return nil, &DecodeError{Format: "deflate", Type: "[]byte", Err: err}
+		}
+		dict, err := c.dictionary(ctx, hex.EncodeToString(header[codecHeaderSize:]), reqConfig)
+		if err != nil {
+			return nil, err
+		}
+		return &inflateReader{chunks: chunks, payload: payload, inflater: flate.NewReaderDict(payload, dict)}, nil
+	}
+	return &bufferedChunks{Reader: payload, chunks: chunks}, nil
+}
+
+// bufferedChunks reads a chunk payload through the buffer used to peek at
+// its codec header.
+type bufferedChunks struct {
+	*bufio.Reader
+	chunks *chunkReader
+}
+
+func (b *bufferedChunks) Close() error {
+	return b.chunks.Close()
+}
+
+// inflateReader inflates a compressed chunk payload. Deflate data ends
+// on its own, so at the end the rest of the payload is drained to reach
+// the hash check.
+type inflateReader struct {
+	chunks   *chunkReader
+	payload  *bufio.Reader
+	inflater io.ReadCloser
+}
+
+func (r *inflateReader) Read(p []byte) (int, error) {
+	n, err := r.inflater.Read(p)
+	switch {
+	case err == io.EOF:
+		if _, err := io.Copy(io.Discard, r.payload); err != nil {
+			return n, err
+		}
+		return n, io.EOF
+	case err != nil:
+		if _, ok := err.(flate.CorruptInputError); ok {
+			err = &DecodeError{Format: "deflate", Type: "[]byte", Err: err}
+		}
+		return n, err
+	}
+	return n, nil
+}
+
+func (r *inflateReader) Close() error {
+	r.inflater.Close()
+	return r.chunks.Close()
+}
@@ -5,20 +5,55 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"io"
+	"log/slog"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/resolvedb/resolvedb-go/security"
 	"github.com/resolvedb/resolvedb-go/transport"
+	"github.com/resolvedb/resolvedb-go/uqrp"
 )
 
 // Client is a ResolveDB client.
 // It is safe for concurrent use from multiple goroutines.
 type Client struct {
-	config    *clientConfig
-	transport transport.Transport
-	cache     Cache
+	config     *clientConfig
+	transport  transport.Transport
+	shared     bool // Transport owned by another client, see ClientPool
+	cache      Cache
+	cacheScope string // Separates this client's entries in a shared cache, see ClientPool
+	authTokens *authTokenCache
+	inflight   semaphore
+	stats      *clientStats
+	regions    *regionRouter
+	session    *sessionTokens
+	dicts      sync.Map // Dictionary ID -> []byte
+	writeBacks sync.Map // Migrated documents being written back, see writeBack
+	names      *uqrp.Builder
+	parser     *uqrp.Parser               // Reads back names, see replayable
+	live       atomic.Pointer[liveConfig] // Settings Update can change
+	updateMu   sync.Mutex                 // Serializes Update
+
+	prefetching chan struct{}     // Prefetch slots, see maxPrefetchInFlight
+	cardinality *cardinalityGuard // Nil without WithCacheKeyLimit
+	warned      sync.Map          // Warnings reported, see warn
+	warnedCount atomic.Int64      // Entries in warned
+
+	lifetime  context.Context         // Cancelled by Close, with cause ErrClosed
+	shutdown  context.CancelCauseFunc // Cancels lifetime
+	base      *Client                 // Client this one was derived from, see ClientPool
+	bgMu      sync.Mutex              // Guards closed and bg.Add
+	closed    bool
+	bg        sync.WaitGroup // Background goroutines started by the client
+	closeOnce sync.Once
+	closeErr  error
 }
 
 // New creates a new ResolveDB client with the given options.
@@ -46,41 +81,68 @@ func New(opts ...Option) (*Client, error) {
 
 	// Set up transport
 	var t transport.Transport
-	if len(config.transports) > 0 {
+	var regions *regionRouter
+	if len(config.regions) > 0 {
+		regionTransports := make([]transport.Transport, len(config.regions))
+		for i, r := range config.regions {
+			regionTransports[i] = r.Transport
+		}
+		t = transport.NewMulti(regionTransports...)
+		regions = newRegionRouter(config.regions, config.readPreference, config.regionStickiness, config.clock)
+	} else if len(config.transports) > 0 {
 		if len(config.transports) == 1 {
 			t = config.transports[0]
 		} else {
-			t = transport.NewMulti(config.transports...)
+			t = transport.NewMultiWithOptions(config.transports,
+				transport.WithFailoverStickiness(config.failoverStickiness))
 		}
 	} else {
 		// Default to DoH with configured options
-		dohOpts := []transport.DoHOption{
-			transport.WithDoHURL(config.baseURL + "/dns-query"),
-		}
-		if config.httpClient != nil {
-			dohOpts = append(dohOpts, transport.WithDoHClient(config.httpClient))
-		} else if config.timeout > 0 {
-			// Create HTTP client with configured timeout
-			dohOpts = append(dohOpts, transport.WithDoHClient(&http.Client{
-				Timeout: config.timeout,
-			}))
+		if t = newDoH(config, config.baseURL+"/dns-query"); t == nil {
+			return nil, fmt.Errorf("invalid configuration: %w: DoH is not included in this build; configure WithTransports", ErrUnknownTransport)
 		}
-		t = transport.NewDoH(dohOpts...)
+	}
+
+	if config.logger == nil {
+		config.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	var session *sessionTokens
+	if config.session {
+		session = newSessionTokens(config.clock)
 	}
 
 	// Set up cache
 	var cache Cache
 	if config.cacheConfig.Enabled {
-		cache = newMemoryCache(config.cacheConfig)
+		cache = newMemoryCache(config.cacheConfig, config.clock)
 	} else {
 		cache = noopCache{}
 	}
 
-	return &Client{
-		config:    config,
-		transport: t,
-		cache:     cache,
-	}, nil
+	lifetime, shutdown := context.WithCancelCause(context.Background())
+	c := &Client{
+		lifetime:   lifetime,
+		shutdown:   shutdown,
+		config:     config,
+		transport:  t,
+		cache:      cache,
+		authTokens: newAuthTokenCache(config.authTokenTTL, config.clock),
+		inflight:   newSemaphore(config.maxConcurrency),
+		stats:      newClientStats(config.clock, latencyWindowSize(config)),
+		regions:    regions,
+		session:    session,
+		names:      uqrp.NewBuilder(config.layout()),
+		parser:     uqrp.NewParser(config.layout()),
+
+		prefetching: make(chan struct{}, maxPrefetchInFlight),
+		cardinality: newCardinalityGuard(config),
+	}
+	c.live.Store(&liveConfig{retry: config.retryConfig, logger: config.logger, cache: config.cacheConfig})
+	if config.versionCheck {
+		c.startVersionCheck()
+	}
+	return c, nil
 }
 
 // MustNew creates a new ResolveDB client with the given options.
@@ -96,15 +158,44 @@ func MustNew(opts ...Option) *Client {
 
 // validateConfig validates the client configuration.
 func validateConfig(config *clientConfig) error {
-	if config.version == "" {
-		return fmt.Errorf("version cannot be empty")
-	}
-	if config.tld == "" {
-		return fmt.Errorf("TLD cannot be empty")
+	if err := config.layout().Validate(); err != nil {
+		return err
 	}
 	if config.timeout < 0 {
 		return fmt.Errorf("timeout cannot be negative")
 	}
+	if config.attemptTimeout < 0 {
+		return fmt.Errorf("attempt timeout cannot be negative")
+	}
+	if config.authTokenTTL < 0 {
+		return fmt.Errorf("auth token TTL cannot be negative")
+	}
+	if config.maxConcurrency < 0 {
+		return fmt.Errorf("max concurrency cannot be negative")
+	}
+	if config.chunkConcurrency < 0 {
+		return fmt.Errorf("chunk concurrency cannot be negative")
+	}
+	if config.chunkRetries < 0 {
+		return fmt.Errorf("chunk retries cannot be negative")
+	}
+	if len(config.regions) > 0 && len(config.transports) > 0 {
+		return fmt.Errorf("regions and transports are mutually exclusive")
+	}
+	for _, r := range config.regions {
+		if r.Name == "" || r.Transport == nil {
+			return fmt.Errorf("regions require a name and a transport")
+		}
+	}
+	if config.regionStickiness < 0 {
+		return fmt.Errorf("region stickiness cannot be negative")
+	}
+	if config.clock == nil {
+		return fmt.Errorf("clock cannot be nil")
+	}
+	if config.rand == nil {
+		return fmt.Errorf("rand source cannot be nil")
+	}
 	return nil
 }
 
@@ -115,47 +206,213 @@ func validateConfig(config *clientConfig) error {
 //	var weather Weather
 //	err := client.Get(ctx, "weather", "quebec", &weather)
 func (c *Client) Get(ctx context.Context, resource, key string, dst any, opts ...RequestOption) error {
-	resp, err := c.GetRaw(ctx, resource, key, opts...)
+	reqConfig := newRequestConfig(ctx, opts)
+	err := c.getInto(ctx, resource, key, dst, reqConfig)
+	if err != nil && reqConfig.fallback != nil {
+		return c.useFallback(resource, key, dst, err, reqConfig.fallback)
+	}
+	return err
+}
+
+// getInto reads resource/key and decodes it into dst.
+func (c *Client) getInto(ctx context.Context, resource, key string, dst any, reqConfig *requestConfig) error {
+	resp, err := c.get(ctx, resource, key, reqConfig)
 	if err != nil {
 		return err
 	}
-	return resp.Unmarshal(dst)
+	// Decoding copies what dst keeps, so the response can be pooled again,
+	// unless dst decodes itself and may hold on to it
+	if _, ok := dst.(ResponseUnmarshaler); !ok {
+		defer resp.Release()
+	}
+	if c.config.autoCodec {
+		if resp, err = c.decodeAuto(ctx, resource, key, resp, reqConfig); err != nil {
+			return err
+		}
+	}
+	resp, migrated, err := c.migrate(resp, resource, key)
+	if err != nil {
+		return err
+	}
+	if migrated && c.config.migrators[resource].writeBack {
+		c.writeBack(resource, key, resp.Data, reqConfig)
+	}
+	return c.decode(resp, resource, key, dst)
+}
+
+// GetStream retrieves a value and returns a JSON decoder over its data.
+// It suits large assembled values that are better decoded incrementally.
+//
+// Example:
+//
+//	dec, err := client.GetStream(ctx, "events", "2024-01")
+//	if err != nil {
+//	    return err
+//	}
+//	if _, err := dec.Token(); err != nil { // opening '['
+//	    return err
+//	}
+//	for dec.More() {
+//	    var ev Event
+//	    if err := dec.Decode(&ev); err != nil {
+//	        return err
+//	    }
+//	    handle(ev)
+//	}
+func (c *Client) GetStream(ctx context.Context, resource, key string, opts ...RequestOption) (*json.Decoder, error) {
+	reqConfig := newRequestConfig(ctx, opts)
+	resp, err := c.get(ctx, resource, key, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+	if c.config.autoCodec {
+		if resp, err = c.decodeAuto(ctx, resource, key, resp, reqConfig); err != nil {
+			return nil, err
+		}
+	}
+	if resp.Data == nil {
+		return nil, ErrNotFound
+	}
+	return resp.Decoder(), nil
 }
 
 // GetRaw retrieves raw response data for a resource and key.
 func (c *Client) GetRaw(ctx context.Context, resource, key string, opts ...RequestOption) (*Response, error) {
-	reqConfig := &requestConfig{}
-	for _, opt := range opts {
-		opt(reqConfig)
+	reqConfig := newRequestConfig(ctx, opts)
+	return c.getRaw(ctx, resource, key, reqConfig)
+}
+
+// unmarshal upgrades resp with the resource's migrator, if any, and decodes
+// it into dst, leniently if configured.
+func (c *Client) unmarshal(resp *Response, resource, key string, dst any) error {
+	resp, _, err := c.migrate(resp, resource, key)
+	if err != nil {
+		return err
 	}
+	return c.decode(resp, resource, key, dst)
+}
 
-	// Build query name
-	queryName := c.buildQueryName("get", resource, key, reqConfig)
+// decode unmarshals resp into dst, logging unmatched fields with lenient
+// decoding.
+func (c *Client) decode(resp *Response, resource, key string, dst any) error {
+	if !c.config.lenientDecoding {
+		return resp.Unmarshal(dst)
+	}
+	unmatched, err := resp.UnmarshalLenient(dst)
+	if len(unmatched) > 0 {
+		c.logger().Warn("resolvedb: unmatched response fields",
+			"resource", resource, "key", key, "type", fmt.Sprintf("%T", dst), "fields", unmatched)
+	}
+	return err
+}
+
+// get executes a cached read query and converts error statuses to errors.
+func (c *Client) get(ctx context.Context, resource, key string, reqConfig *requestConfig) (*Response, error) {
+	resp, err := c.getRaw(ctx, resource, key, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.ToError(); err != nil {
+		return nil, c.queryError(err, "get", resource, key, reqConfig, resp)
+	}
+	return resp, nil
+}
+
+// getRaw executes a cached read query for a resource and key.
+func (c *Client) getRaw(ctx context.Context, resource, key string, reqConfig *requestConfig) (*Response, error) {
+	if err := c.validateFields(reqConfig.fields); err != nil {
+		return nil, err
+	}
+
+	// Denied reads must fail even when cached
+	if err := c.checkACL("get", resource, key, reqConfig); err != nil {
+		return nil, err
+	}
+	if err := c.checkKeys(resource, key, reqConfig); err != nil {
+		return nil, err
+	}
 
 	// Check cache
-	cacheKey := buildCacheKey("get", resource, key, c.config.namespace, c.config.version)
-	if !reqConfig.skipCache {
-		if cached, ok := c.cache.Get(cacheKey); ok {
+	cacheKey := c.getCacheKey(resource, key, reqConfig)
+	token := c.consistencyToken(resource, key, reqConfig)
+	if !reqConfig.skipCache && !reqConfig.refresh && token == "" {
+		if cached, ok := c.cache.Get(cacheKey); ok && !c.isStale(cached, reqConfig) {
+			c.stats.cacheHits.Add(1)
 			return cached, nil
 		}
+		c.stats.cacheMisses.Add(1)
 	}
 
+	// Build query name, requesting data at least as fresh as the token
+	if token != "" {
+		params := make([]string, 0, len(reqConfig.params)+1)
+		params = append(params, reqConfig.params...)
+		reqConfig.params = append(params, PrefixCST+token)
+	}
+	queryName := c.buildQueryName("get", resource, key, reqConfig)
+
 	// Execute query with retry
-	resp, err := doWithRetry(ctx, c.config.retryConfig, func() (*Response, error) {
-		return c.executeQuery(ctx, queryName, reqConfig)
-	})
+	resp, err := c.query(ctx, "get", resource, key, queryName, reqConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	// Cache successful responses
-	if resp.IsSuccess() && !reqConfig.skipCache {
-		c.cache.Set(cacheKey, resp, resp.TTL)
+	if reqConfig.requireAuthoritative && (resp.Meta.Source == SourceReplica || resp.Meta.Source == SourceCache) {
+		err := fmt.Errorf("%w: answered by %s", ErrNotAuthoritative, resp.Meta.Source)
+		return nil, c.queryError(err, "get", resource, key, reqConfig, resp)
+	}
+
+	// Cache successful responses, and warm the cache with the keys they hint at
+	if resp.IsSuccess() && !reqConfig.skipCache && c.config.cacheConfig.Enabled {
+		resp.retain()
+		c.cacheResponse(cacheKey, resource, key, resp, reqConfig)
+		c.prefetch(resource, resp, reqConfig)
+	}
+
+	if c.isStale(resp, reqConfig) {
+		err := fmt.Errorf("%w: age %s exceeds %s", ErrStale, resp.ageAt(c.config.clock.Now()).Round(time.Second), reqConfig.maxAge)
+		return nil, c.queryError(err, "get", resource, key, reqConfig, resp)
 	}
 
 	return resp, nil
 }
 
+// cacheResponse caches resp, the value of resource/key, under cacheKey for
+// its TTL, or for the request's WithCacheTTL, pinning it for WithPin.
+func (c *Client) cacheResponse(cacheKey, resource, key string, resp *Response, reqConfig *requestConfig) {
+	if !c.admitToCache(resource, key) {
+		return
+	}
+	ttl := resp.TTL
+	if reqConfig.cacheTTL > 0 {
+		ttl = reqConfig.cacheTTL
+	}
+	if p, ok := c.cache.(pinningCache); ok && reqConfig.pin {
+		p.SetPinned(cacheKey, resp, ttl)
+		return
+	}
+	c.cache.Set(cacheKey, resp, ttl)
+}
+
+// validateFields checks that projected field names fit in a DNS label.
+func (c *Client) validateFields(fields []string) error {
+	for _, f := range fields {
+		if f == "" {
+			return fmt.Errorf("field name cannot be empty")
+		}
+		if len(PrefixFields)+len(c.encodeParamValue([]byte(f))) > 63 {
+			return fmt.Errorf("field name %q is too long", f)
+		}
+	}
+	return nil
+}
+
+// isStale reports whether resp is older than the request's max age on the
+// client clock.
+func (c *Client) isStale(resp *Response, reqConfig *requestConfig) bool {
+	return reqConfig.maxAge > 0 && resp.ageAt(c.config.clock.Now()) > reqConfig.maxAge
+}
+
 // Set stores data for a resource and key.
 //
 // Example:
@@ -164,290 +421,838 @@ func (c *Client) GetRaw(ctx context.Context, resource, key string, opts ...Reque
 //	    resolvedb.WithTTL(24*time.Hour),
 //	)
 func (c *Client) Set(ctx context.Context, resource, key string, data any, opts ...RequestOption) error {
-	if c.config.apiKey == "" {
-		return ErrUnauthorized
-	}
+	_, err := c.SetWithResult(ctx, resource, key, data, opts...)
+	return err
+}
 
-	reqConfig := &requestConfig{}
-	for _, opt := range opts {
-		opt(reqConfig)
+// SetWithResult stores data for a resource and key and returns a receipt
+// describing what the server stored.
+//
+// Example:
+//
+//	result, err := client.SetWithResult(ctx, "config", "settings", myConfig)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	log.Printf("stored hash=%s ttl=%s", result.Hash, result.TTL)
+func (c *Client) SetWithResult(ctx context.Context, resource, key string, data any, opts ...RequestOption) (*WriteResult, error) {
+	reqConfig := newRequestConfig(ctx, opts)
+	if reqConfig.format != "" || reqConfig.encoding != "" {
+		raw, err := c.marshalFormatted(resource, key, data, reqConfig)
+		if err != nil {
+			return nil, err
+		}
+		return c.put(ctx, resource, key, raw, reqConfig)
+	}
+	if c.config.autoCodec {
+		return c.setAuto(ctx, resource, key, data, reqConfig)
 	}
 
-	// Security check: authenticated requests require encrypted transport
-	if c.config.enforceSecurity && !c.transport.IsEncrypted() {
-		return ErrEncryptedTransportRequired
+	// Encode and validate data
+	raw, err := c.marshalValue(resource, key, data)
+	if err != nil {
+		return nil, err
 	}
 
-	// Encode data
-	encoded, err := encodeJSON(data)
+	return c.put(ctx, resource, key, raw, reqConfig)
+}
+
+// marshalValue validates data against the schema registered for resource,
+// if any, and encodes it as JSON with compact field names.
+func (c *Client) marshalValue(resource, key string, data any) ([]byte, error) {
+	if schema := c.config.schemas[resource]; schema != nil {
+		if err := schema.Validate(data); err != nil {
+			if verr, ok := err.(*ValidationError); ok {
+				verr.Resource, verr.Key = resource, key
+			}
+			return nil, err
+		}
+	}
+	raw, err := marshalJSON(data)
 	if err != nil {
-		return fmt.Errorf("encode data: %w", err)
+		return nil, fmt.Errorf("encode data: json marshal: %w", err)
 	}
+	return raw, nil
+}
+
+// put writes data for a resource and key.
+func (c *Client) put(ctx context.Context, resource, key string, data []byte, reqConfig *requestConfig) (*WriteResult, error) {
+	return c.write(ctx, "put", resource, key, data, reqConfig)
+}
 
-	// Build query name
-	queryName := c.buildQueryNameWithData("put", resource, key, encoded, reqConfig)
+// write executes a write operation carrying data.
+func (c *Client) write(ctx context.Context, operation, resource, key string, data []byte, reqConfig *requestConfig) (*WriteResult, error) {
+	if !c.authenticated(reqConfig) {
+		return nil, ErrUnauthorized
+	}
+
+	// Security check: authenticated requests require encrypted transport
+	if err := c.checkTransportSecurity(reqConfig); err != nil {
+		return nil, err
+	}
+
+	// Build query name; data travels in a single label
+	queryName := c.buildQueryNameWithData(operation, resource, key, data, reqConfig)
+	if err := checkWriteName(queryName); err != nil {
+		return nil, c.queryError(err, operation, resource, key, reqConfig, nil)
+	}
 
 	// Execute query
-	resp, err := doWithRetry(ctx, c.config.retryConfig, func() (*Response, error) {
-		return c.executeQuery(ctx, queryName, reqConfig)
-	})
+	resp, err := c.query(ctx, operation, resource, key, queryName, reqConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := resp.ToError(); err != nil {
-		return err
+		return nil, c.queryError(err, operation, resource, key, reqConfig, resp)
 	}
 
 	// Invalidate cache
-	cacheKey := buildCacheKey("get", resource, key, c.config.namespace, c.config.version)
-	c.cache.Delete(cacheKey)
+	c.invalidate(resource, key, reqConfig, resp)
 
-	return nil
+	return newWriteResult(resp), nil
 }
 
 // Delete removes data for a resource and key.
 func (c *Client) Delete(ctx context.Context, resource, key string, opts ...RequestOption) error {
-	if c.config.apiKey == "" {
-		return ErrUnauthorized
+	_, err := c.DeleteWithResult(ctx, resource, key, opts...)
+	return err
+}
+
+// DeleteWithResult removes data for a resource and key and returns a receipt
+// describing the deletion. With WithIgnoreNotFound, a missing key yields a
+// result with Deleted set to false instead of ErrNotFound. With
+// WithAutoCodec, deleting a chunked value deletes its chunks too, and so
+// does deleting a value written with SetEncrypted with WithEncrypt.
+func (c *Client) DeleteWithResult(ctx context.Context, resource, key string, opts ...RequestOption) (*WriteResult, error) {
+	reqConfig := newRequestConfig(ctx, opts)
+	if !c.config.autoCodec && !reqConfig.encrypt {
+		return c.deleteKey(ctx, resource, key, reqConfig)
 	}
 
-	reqConfig := &requestConfig{}
-	for _, opt := range opts {
-		opt(reqConfig)
+	// Chunked values are deleted manifest first, so readers never see a
+	// manifest whose chunks are gone
+	chunks, err := c.storedChunks(ctx, resource, key, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.deleteKey(ctx, resource, key, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.deleteChunks(ctx, resource, key, chunks, reqConfig); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// deleteKey deletes a single key.
+func (c *Client) deleteKey(ctx context.Context, resource, key string, reqConfig *requestConfig) (*WriteResult, error) {
+	if !c.authenticated(reqConfig) {
+		return nil, ErrUnauthorized
 	}
 
 	// Security check
-	if c.config.enforceSecurity && !c.transport.IsEncrypted() {
-		return ErrEncryptedTransportRequired
+	if err := c.checkTransportSecurity(reqConfig); err != nil {
+		return nil, err
 	}
 
 	queryName := c.buildQueryName("delete", resource, key, reqConfig)
 
-	resp, err := doWithRetry(ctx, c.config.retryConfig, func() (*Response, error) {
-		return c.executeQuery(ctx, queryName, reqConfig)
-	})
+	resp, err := c.query(ctx, "delete", resource, key, queryName, reqConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := resp.ToError(); err != nil {
-		return err
+	err = resp.ToError()
+	if err != nil && !(reqConfig.ignoreNotFound && IsNotFound(err)) {
+		return nil, c.queryError(err, "delete", resource, key, reqConfig, resp)
 	}
 
 	// Invalidate cache
-	cacheKey := buildCacheKey("get", resource, key, c.config.namespace, c.config.version)
-	c.cache.Delete(cacheKey)
+	c.invalidate(resource, key, reqConfig, resp)
 
-	return nil
+	if err != nil {
+		// Key was already gone
+		return &WriteResult{}, nil
+	}
+
+	result := newWriteResult(resp)
+	result.Deleted = true
+	return result, nil
 }
 
-// List retrieves a list of keys for a resource.
+// List retrieves a list of keys for a resource. Lists the server splits
+// into chunks are read in full; if a chunk cannot be read, the keys read so
+// far are returned with a *PartialResultError.
 func (c *Client) List(ctx context.Context, resource string, opts ...RequestOption) ([]string, error) {
-	reqConfig := &requestConfig{}
-	for _, opt := range opts {
-		opt(reqConfig)
-	}
+	reqConfig := newRequestConfig(ctx, opts)
 
 	queryName := c.buildQueryName("list", resource, "", reqConfig)
 
-	resp, err := doWithRetry(ctx, c.config.retryConfig, func() (*Response, error) {
-		return c.executeQuery(ctx, queryName, reqConfig)
-	})
+	resp, err := c.query(ctx, "list", resource, "", queryName, reqConfig)
 	if err != nil {
 		return nil, err
 	}
 
 	if err := resp.ToError(); err != nil {
+		return nil, c.queryError(err, "list", resource, "", reqConfig, resp)
+	}
+
+	return listEntries[string](ctx, c, resource, reqConfig, resp)
+}
+
+// ListResources retrieves the names of all resources in the namespace.
+func (c *Client) ListResources(ctx context.Context, opts ...RequestOption) ([]string, error) {
+	reqConfig := newRequestConfig(ctx, opts)
+
+	queryName := c.buildQueryName("resources", "", "", reqConfig)
+
+	resp, err := c.query(ctx, "resources", "", "", queryName, reqConfig)
+	if err != nil {
 		return nil, err
 	}
 
-	var keys []string
-	if err := resp.Unmarshal(&keys); err != nil {
+	if err := resp.ToError(); err != nil {
+		return nil, c.queryError(err, "resources", "", "", reqConfig, resp)
+	}
+
+	var resources []string
+	if err := resp.Unmarshal(&resources); err != nil {
 		return nil, err
 	}
 
-	return keys, nil
+	return resources, nil
+}
+
+// Count returns the number of keys stored for a resource.
+func (c *Client) Count(ctx context.Context, resource string, opts ...RequestOption) (int, error) {
+	reqConfig := newRequestConfig(ctx, opts)
+
+	queryName := c.buildQueryName("count", resource, "", reqConfig)
+
+	resp, err := c.query(ctx, "count", resource, "", queryName, reqConfig)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := resp.ToError(); err != nil {
+		return 0, c.queryError(err, "count", resource, "", reqConfig, resp)
+	}
+
+	var count int
+	if err := resp.Unmarshal(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Expiry returns the time the server deletes a key set with WithExpiry.
+// The zero time means the key does not expire.
+func (c *Client) Expiry(ctx context.Context, resource, key string, opts ...RequestOption) (time.Time, error) {
+	resp, err := c.get(ctx, resource, key, newRequestConfig(ctx, opts))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return resp.Expires, nil
 }
 
 // GetEncrypted retrieves and decrypts data.
+// With WithDecryptedCache, the decrypted response is cached so repeated
+// reads skip decryption.
 func (c *Client) GetEncrypted(ctx context.Context, resource, key string, dst any, opts ...RequestOption) error {
-	if c.config.encryptionKey == nil {
-		return fmt.Errorf("encryption key not configured")
+	encryptionKey, err := c.encryptionKey(ctx)
+	if err != nil {
+		return err
 	}
 
-	opts = append(opts, WithEncrypt())
-	resp, err := c.GetRaw(ctx, resource, key, opts...)
+	opts = append(opts[:len(opts):len(opts)], WithEncrypt())
+	reqConfig := newRequestConfig(ctx, opts)
+
+	memoize := c.config.cacheDecrypted && !reqConfig.skipCache && !reqConfig.noDecryptedCache
+	cacheKey := c.scopeCacheKey(buildCacheKey("decrypted", resource, key, c.namespace(reqConfig), c.config.version), reqConfig)
+	if memoize && !reqConfig.refresh && c.consistencyToken(resource, key, reqConfig) == "" {
+		if cached, ok := c.cache.Get(cacheKey); ok && !c.isStale(cached, reqConfig) {
+			return c.unmarshal(cached, resource, key, dst)
+		}
+	}
+
+	resp, err := c.get(ctx, resource, key, reqConfig)
 	if err != nil {
 		return err
 	}
 
-	// Decrypt data
-	decrypted, err := decrypt(resp.Data, c.config.encryptionKey)
+	// Decrypt data. A ciphertext too large for one write is stored
+	// chunked; a manifest never authenticates, so it is only read as one
+	// when decryption fails.
+	decrypted, err := decrypt(resp.Data, encryptionKey)
+	if codec, manifest, _ := splitCodec(resp.Data); err != nil && codec == CodecChunked && encodedFormat(resp.Format) {
+		ciphertext, cerr := c.readChunks(ctx, resource, key, manifest, reqConfig)
+		if cerr != nil {
+			return cerr
+		}
+		decrypted, err = decrypt(ciphertext, encryptionKey)
+	}
 	if err != nil {
 		return fmt.Errorf("decrypt: %w", err)
 	}
+	// SetEncrypted encrypts the base64 encoding of the value
+	if decrypted, err = decodeBase64(string(decrypted)); err != nil {
+		return fmt.Errorf("decrypt: decode plaintext: %w", err)
+	}
 
 	// Create new response with decrypted data
 	decryptedResp := *resp
 	decryptedResp.Data = decrypted
-	return decryptedResp.Unmarshal(dst)
+	decryptedResp.Records = nil
+	decryptedResp.RecordTTLs = nil
+	decryptedResp.Manifest = nil
+
+	if memoize {
+		c.cacheResponse(cacheKey, resource, key, &decryptedResp, reqConfig)
+	}
+
+	return c.unmarshal(&decryptedResp, resource, key, dst)
 }
 
-// SetEncrypted encrypts and stores data.
+// SetEncrypted encrypts and stores data. A ciphertext too large for a
+// single write is split into chunks as with WithAutoCodec, whether or not
+// the client uses it; pass WithEncrypt to Delete to remove the chunks of
+// such a value along with it.
 func (c *Client) SetEncrypted(ctx context.Context, resource, key string, data any, opts ...RequestOption) error {
-	if c.config.encryptionKey == nil {
-		return fmt.Errorf("encryption key not configured")
+	encryptionKey, err := c.encryptionKey(ctx)
+	if err != nil {
+		return err
 	}
 
-	// Encode data
-	encoded, err := encodeJSON(data)
+	// Encode and validate data
+	raw, err := c.marshalValue(resource, key, data)
 	if err != nil {
-		return fmt.Errorf("encode data: %w", err)
+		return err
 	}
 
 	// Encrypt
-	encrypted, err := encrypt([]byte(encoded), c.config.encryptionKey)
+	encrypted, err := encrypt([]byte(encodeBase64(raw)), encryptionKey)
 	if err != nil {
 		return fmt.Errorf("encrypt: %w", err)
 	}
 
 	// Store encrypted data
-	opts = append(opts, WithEncrypt())
-	reqConfig := &requestConfig{}
-	for _, opt := range opts {
-		opt(reqConfig)
+	opts = append(opts[:len(opts):len(opts)], WithEncrypt())
+	reqConfig := newRequestConfig(ctx, opts)
+	if limit := c.maxPayloadBytes(resource, key, reqConfig); len(encrypted) > limit {
+		_, err = c.putChunked(ctx, resource, key, encrypted, limit, reqConfig)
+		return err
 	}
+	_, err = c.put(ctx, resource, key, encrypted, reqConfig)
+	return err
+}
 
-	if c.config.enforceSecurity && !c.transport.IsEncrypted() {
-		return ErrEncryptedTransportRequired
-	}
+// Close stops the client's background goroutines (watches, heartbeats,
+// informers and transport probes), waits for them to exit and releases
+// the transports. Queries issued after Close fail with ErrClosed. Close
+// is safe to call more than once; later calls return the first result.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		c.bgMu.Lock()
+		c.closed = true
+		c.bgMu.Unlock()
+
+		c.shutdown(ErrClosed)
+		c.bg.Wait()
+		if !c.shared {
+			c.closeErr = c.transport.Close()
+		}
+	})
+	return c.closeErr
+}
 
-	queryName := c.buildQueryNameWithData("put", resource, key, encodeBase64(encrypted), reqConfig)
+// isClosed reports whether Close has been called on c or on the client
+// it was derived from.
+func (c *Client) isClosed() bool {
+	return c.lifetime.Err() != nil || (c.base != nil && c.base.isClosed())
+}
 
-	resp, err := doWithRetry(ctx, c.config.retryConfig, func() (*Response, error) {
-		return c.executeQuery(ctx, queryName, reqConfig)
-	})
-	if err != nil {
-		return err
+// background derives a context for a goroutine started by the client. The
+// context is cancelled when ctx is done or the client is closed, and Close
+// waits until done is called. It returns ok == false without starting
+// anything if the client is already closed.
+func (c *Client) background(ctx context.Context) (bgCtx context.Context, done func(), ok bool) {
+	c.bgMu.Lock()
+	defer c.bgMu.Unlock()
+	if c.closed {
+		return nil, nil, false
 	}
+	c.bg.Add(1)
 
-	return resp.ToError()
+	bgCtx, cancel := c.withLifetime(ctx)
+	return bgCtx, func() {
+		cancel()
+		c.bg.Done()
+	}, true
 }
 
-// Close releases resources held by the client.
-func (c *Client) Close() error {
-	return c.transport.Close()
+// withLifetime returns a copy of ctx that is also cancelled when the
+// client, or the client it was derived from, is closed, with cause
+// ErrClosed. Unlike background, Close does not wait for its user, so it
+// suits loops run on the caller's goroutine, whose callbacks may close
+// the client themselves.
+func (c *Client) withLifetime(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	var stops []func() bool
+	for o := c; o != nil; o = o.base {
+		lifetime := o.lifetime
+		stops = append(stops, context.AfterFunc(lifetime, func() {
+			cancel(context.Cause(lifetime))
+		}))
+	}
+	return ctx, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancel(nil)
+	}
 }
 
-// buildQueryName builds the FQDN for a query.
-// Format: <operation>.<params>.<resource>.<namespace>.<version>.resolvedb.<tld>
-func (c *Client) buildQueryName(operation, resource, key string, reqConfig *requestConfig) string {
-	parts := []string{operation}
+// getCacheKey returns the cache key of a get. Gets with parameters, a
+// field projection or credentials are cached apart from the plain value,
+// under keys that extend its key with a dot.
+func (c *Client) getCacheKey(resource, key string, reqConfig *requestConfig) string {
+	cacheKey := buildCacheKey("get", resource, key, c.namespace(reqConfig), c.config.version)
+	if len(reqConfig.params) > 0 {
+		cacheKey += "." + normalizeKey(strings.Join(reqConfig.params, "."))
+	}
+	if len(reqConfig.fields) > 0 {
+		cacheKey += ".fields=" + strings.Join(reqConfig.fields, ",")
+	}
+	return c.scopeCacheKey(cacheKey, reqConfig)
+}
 
-	// Add key if present
-	if key != "" {
-		parts = append(parts, sanitizeLabel(key))
+// scopeCacheKey extends cacheKey with a fingerprint of the credentials
+// sent with the request, and of the tenant for clients of a ClientPool,
+// so a response fetched with one user's or tenant's credentials is never
+// served to a request without them or with others.
+func (c *Client) scopeCacheKey(cacheKey string, reqConfig *requestConfig) string {
+	creds := [...]string{c.cacheScope, reqConfig.nbaToken, reqConfig.ctpToken, reqConfig.bdtToken, reqConfig.readToken, reqConfig.authToken}
+	if creds == [len(creds)]string{} {
+		return cacheKey
 	}
+	sum := sha256.Sum256([]byte(strings.Join(creds[:], "|")))
+	return cacheKey + ".cred=" + hex.EncodeToString(sum[:16])
+}
 
-	// Add resource
-	parts = append(parts, sanitizeLabel(resource))
+// invalidate runs after every successful write, whatever its operation
+// (put, patch, merge, delete, encrypted puts and each key of a
+// transaction). It drops the cached responses for the resource and key:
+// the plain value, its parameterized, projected and credential-scoped
+// variants, and any decrypted copy. With regions configured, it also keeps
+// reads of the key on the primary region, and with session consistency it
+// remembers the write's consistency token. New write paths must call it.
+func (c *Client) invalidate(resource, key string, reqConfig *requestConfig, resp *Response) {
+	ns := c.namespace(reqConfig)
+	if c.session != nil && resp.Meta.ConsistencyToken != "" {
+		c.session.record(ns, resource, key, resp.Meta.ConsistencyToken)
+	}
+	getKey := buildCacheKey("get", resource, key, ns, c.config.version)
+	c.cache.Delete(getKey)
+	decryptedKey := buildCacheKey("decrypted", resource, key, ns, c.config.version)
+	c.cache.Delete(decryptedKey)
+	if pc, ok := c.cache.(prefixCache); ok {
+		pc.DeletePrefix(getKey + ".")
+		pc.DeletePrefix(decryptedKey + ".")
+	}
+	if c.regions != nil {
+		c.regions.pin(ns, resource, key)
+	}
+}
 
-	// Add namespace if configured
-	if c.config.namespace != "" {
-		parts = append(parts, sanitizeLabel(c.config.namespace))
-	} else {
-		parts = append(parts, "public")
+// namespace returns the namespace for a request: the request override if
+// set, otherwise the client's namespace.
+func (c *Client) namespace(reqConfig *requestConfig) string {
+	if reqConfig.namespace != "" {
+		return reqConfig.namespace
 	}
+	return c.config.namespace
+}
 
-	// Add version
-	parts = append(parts, c.config.version)
+// checkKeys returns an error wrapping ErrInvalidKey if strict keys are
+// enabled and the resource, key or namespace would be altered by
+// sanitizing.
+func (c *Client) checkKeys(resource, key string, reqConfig *requestConfig) error {
+	if !c.config.strictKeys {
+		return nil
+	}
+	for _, f := range [...]struct{ kind, value string }{
+		{"resource", resource},
+		{"key", key},
+		{"namespace", c.namespace(reqConfig)},
+	} {
+		if s := uqrp.Sanitize(f.value); s != f.value {
+			return fmt.Errorf("%w: %s %q would be sent as %q", ErrInvalidKey, f.kind, f.value, s)
+		}
+	}
+	return nil
+}
+
+// buildQueryName builds the FQDN for a query.
+// Format: <operation>.<tokens>.<auth>.<params>.<key>.<resource>.<namespace>.<version>.resolvedb.<tld>
+// The location labels and apex are configurable via WithLabelOrder, WithApexLabels
+// and WithZone.
+func (c *Client) buildQueryName(operation, resource, key string, reqConfig *requestConfig) string {
+	q := c.nameQuery(operation, resource, key, reqConfig)
+	// Add security tokens if present
+	for _, t := range [...]string{reqConfig.nbaToken, reqConfig.ctpToken, reqConfig.bdtToken, reqConfig.readToken} {
+		if t != "" {
+			q.Tokens = append(q.Tokens, t)
+		}
+	}
+	return c.names.Build(q)
+}
 
-	// Add TLD
-	parts = append(parts, "resolvedb", c.config.tld)
+// buildQueryNameWithData builds the FQDN for a write query with data.
+// Format: <operation>.<auth>.<params>.b64-<data>.<key>.<resource>.<namespace>.<version>.resolvedb.<tld>
+// With LabelEncodingBase32, the data label is b32-<data>. With
+// WithPayloadSigner, the params end with the payload signature labels.
+func (c *Client) buildQueryNameWithData(operation, resource, key string, data []byte, reqConfig *requestConfig) string {
+	q := c.nameQuery(operation, resource, key, reqConfig)
+	q.Data = data
+	if q.Data == nil {
+		q.Data = []byte{}
+	}
+	if s := c.config.payloadSigner; s != nil {
+		sig := s.Sign(security.PayloadMessage(operation, resource, key, q.Namespace, q.Data))
+		q.Params = append(q.Params[:len(q.Params):len(q.Params)], security.PayloadSignatureLabels(s.Algorithm(), sig)...)
+	}
+	return c.names.Build(q)
+}
 
+// nameQuery returns the query name content shared by reads and writes:
+// the operation, signed auth token, parameters and location.
+func (c *Client) nameQuery(operation, resource, key string, reqConfig *requestConfig) uqrp.Query {
+	q := uqrp.Query{
+		Operation: uqrp.Operation(operation),
+		Params:    c.params(reqConfig),
+		Key:       key,
+		Resource:  resource,
+		Namespace: c.namespace(reqConfig),
+	}
 	// Add signed auth token if present (HMAC-signed, not raw API key)
-	if c.config.apiKey != "" {
+	if reqConfig.authToken != "" {
+		q.Auth = reqConfig.authToken
+	} else if c.config.apiKey != "" {
 		// Generate time-limited HMAC signature instead of exposing raw API key
 		// Format: auth-<signature>-t-<timestamp>
-		authToken := c.generateAuthToken(operation, resource, key)
-		newParts := []string{parts[0], authToken}
-		newParts = append(newParts, parts[1:]...)
-		parts = newParts
+		q.Auth = c.generateAuthToken(operation, resource, key, q.Namespace)
 	}
+	return q
+}
 
-	// Add security tokens if present
-	if reqConfig.bdtToken != "" {
-		parts = insertAfter(parts, 0, reqConfig.bdtToken)
+// params returns the request's operation parameters and idempotency key
+// as labels.
+func (c *Client) params(reqConfig *requestConfig) []string {
+	expiresAt := c.expiresAt(reqConfig)
+	if reqConfig.idempotencyKey == "" && expiresAt.IsZero() && len(reqConfig.fields) == 0 &&
+		reqConfig.format == "" && reqConfig.encoding == "" && !reqConfig.noResolverCache && reqConfig.ifMatch == "" {
+		return reqConfig.params
+	}
+	params := append([]string(nil), reqConfig.params...)
+	if reqConfig.idempotencyKey != "" {
+		params = append(params, PrefixIdem+reqConfig.idempotencyKey)
+	}
+	if reqConfig.ifMatch != "" {
+		params = append(params, PrefixIfMatch+reqConfig.ifMatch)
+	}
+	if !expiresAt.IsZero() {
+		params = append(params, PrefixExp+strconv.FormatInt(expiresAt.Unix(), 10))
+	}
+	for _, f := range reqConfig.fields {
+		params = append(params, PrefixFields+c.encodeParamValue([]byte(f)))
+	}
+	if reqConfig.format != "" {
+		params = append(params, PrefixFormat+string(reqConfig.format))
 	}
-	if reqConfig.ctpToken != "" {
-		parts = insertAfter(parts, 0, reqConfig.ctpToken)
+	if reqConfig.encoding != "" {
+		params = append(params, PrefixEncoding+string(reqConfig.encoding))
 	}
-	if reqConfig.nbaToken != "" {
-		parts = insertAfter(parts, 0, reqConfig.nbaToken)
+	if reqConfig.noResolverCache {
+		params = append(params, PrefixNonce+c.nonce())
 	}
+	return params
+}
 
-	return strings.Join(parts, ".")
+// expiresAt returns when the key a write sets expires, reading the client
+// clock for WithExpiry, or the zero time if it does not expire.
+func (c *Client) expiresAt(reqConfig *requestConfig) time.Time {
+	if reqConfig.expiresAt.IsZero() && reqConfig.expiry != 0 {
+		return c.config.clock.Now().Add(reqConfig.expiry)
+	}
+	return reqConfig.expiresAt
 }
 
-// buildQueryNameWithData builds the FQDN for a write query with data.
-func (c *Client) buildQueryNameWithData(operation, resource, key, data string, reqConfig *requestConfig) string {
-	parts := []string{operation}
+// nonce returns a random label value that makes a query name unique, so
+// resolvers cannot answer it from their caches.
+func (c *Client) nonce() string {
+	b := make([]byte, 8)
+	if _, err := io.ReadFull(c.config.rand, b); err != nil {
+		// Fall back to the clock, which is unique enough to miss caches
+		return strconv.FormatInt(c.config.clock.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
 
-	// Add encoded data
-	parts = append(parts, PrefixBase64+data)
+// authQuery executes an uncached query that requires authentication and
+// returns the response if it indicates success.
+func (c *Client) authQuery(ctx context.Context, operation, resource, key string, opts []RequestOption) (*Response, error) {
+	reqConfig := newRequestConfig(ctx, opts)
+	if !c.authenticated(reqConfig) {
+		return nil, ErrUnauthorized
+	}
 
-	// Add key
-	parts = append(parts, sanitizeLabel(key))
+	if err := c.checkTransportSecurity(reqConfig); err != nil {
+		return nil, err
+	}
 
-	// Add resource
-	parts = append(parts, sanitizeLabel(resource))
+	queryName := c.buildQueryName(operation, resource, key, reqConfig)
 
-	// Add namespace
-	if c.config.namespace != "" {
-		parts = append(parts, sanitizeLabel(c.config.namespace))
-	} else {
-		parts = append(parts, "public")
+	resp, err := c.query(ctx, operation, resource, key, queryName, reqConfig)
+	if err != nil {
+		return nil, err
 	}
 
-	// Add version
-	parts = append(parts, c.config.version)
+	if err := resp.ToError(); err != nil {
+		return nil, c.queryError(err, operation, resource, key, reqConfig, resp)
+	}
+	return resp, nil
+}
 
-	// Add TLD
-	parts = append(parts, "resolvedb", c.config.tld)
+// query executes a query with retries. Transport failures are returned as
+// a *QueryError; error statuses are left in the response for the caller.
+func (c *Client) query(ctx context.Context, operation, resource, key, queryName string, reqConfig *requestConfig) (*Response, error) {
+	if c.isClosed() {
+		return nil, ErrClosed
+	}
+	// Transactions are checked per operation by Commit
+	if operation != "txn" {
+		if err := c.checkACL(operation, resource, key, reqConfig); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.checkKeys(resource, key, reqConfig); err != nil {
+		return nil, err
+	}
+	c.recordSize(resource, SizeQueryName, len(queryName))
+	ctx, observed := c.observeQuery(ctx, operation, resource, key, queryName, reqConfig)
+	attempts := 0
+	var resp *Response
+	var err error
+	c.withProfilerLabels(ctx, func(ctx context.Context) {
+		r := c.newRetryer()
+		resp, err = doWithRetry(ctx, r, func() (*Response, error) {
+			attempts++
+			attemptCtx, cancel := r.attemptContext(ctx)
+			defer cancel()
+			resp, err := c.executeQuery(attemptCtx, operation, resource, key, queryName, reqConfig)
+			return resp, attemptError(ctx, attemptCtx, err)
+		})
+	}, ProfileLabelOperation, operation, ProfileLabelResource, resource)
+	if err != nil {
+		qerr := c.newQueryError(err, operation, resource, key, reqConfig)
+		qerr.Attempts = attempts
+		if ctx.Err() != nil {
+			qerr.Cause = context.Cause(ctx)
+		}
+		observed(nil, qerr)
+		return nil, qerr
+	}
+	resp.Meta.Attempts = attempts
+	observed(resp, nil)
+	return resp, nil
+}
 
-	// Add signed auth token (HMAC-signed, not raw API key)
-	if c.config.apiKey != "" {
-		authToken := c.generateAuthToken(operation, resource, key)
-		newParts := []string{parts[0], authToken}
-		newParts = append(newParts, parts[1:]...)
-		parts = newParts
+// queryError annotates err with the query that produced resp.
+func (c *Client) queryError(err error, operation, resource, key string, reqConfig *requestConfig, resp *Response) error {
+	qerr := c.newQueryError(err, operation, resource, key, reqConfig)
+	if resp != nil {
+		qerr.Attempts = resp.Meta.Attempts
+		if resp.Meta.Transport != "" {
+			qerr.Transport = resp.Meta.Transport
+		}
 	}
+	return qerr
+}
 
-	return strings.Join(parts, ".")
+// newQueryError creates a QueryError for a request.
+func (c *Client) newQueryError(err error, operation, resource, key string, reqConfig *requestConfig) *QueryError {
+	qerr := &QueryError{
+		Op:        operation,
+		Resource:  resource,
+		Key:       key,
+		Namespace: c.namespace(reqConfig),
+		Err:       err,
+	}
+	if qerr.Namespace == "" {
+		qerr.Namespace = c.config.defaultNamespace
+	}
+	if t, terr := c.selectTransport(reqConfig); terr == nil {
+		qerr.Transport = t.Name()
+	}
+	return qerr
 }
 
 // executeQuery sends a DNS query and parses the response.
-func (c *Client) executeQuery(ctx context.Context, queryName string, reqConfig *requestConfig) (*Response, error) {
+func (c *Client) executeQuery(ctx context.Context, operation, resource, key, queryName string, reqConfig *requestConfig) (*Response, error) {
 	// Create transport request
+	wireName := c.mapName(queryName)
 	req := &transport.Request{
-		Name:   queryName,
+		Name:   wireName,
 		Type:   transport.TypeTXT,
-		Labels: strings.Split(queryName, "."),
+		Labels: strings.Split(wireName, "."),
+		Rand:   c.config.rand,
+
+		Validation: c.config.answerValidation,
+		MaxCNAMEs:  c.config.maxCNAMEs,
+		Replayable: c.replayable(queryName),
 	}
 
-	// Execute query
-	transportResp, err := c.transport.Query(ctx, req)
+	if c.regions != nil && reqConfig.transportName == "" {
+		return c.executeRegional(ctx, operation, resource, key, req, reqConfig)
+	}
+
+	t, err := c.selectTransport(reqConfig)
 	if err != nil {
-		return nil, fmt.Errorf("transport query: %w", err)
+		return nil, err
+	}
+	return c.send(ctx, t, resource, req)
+}
+
+// replayable reports whether the query named queryName is safe to send
+// twice: it only reads, or it carries an idempotency key the server
+// deduplicates on. Only parameter labels are checked, so a key or
+// resource that happens to start with the idempotency prefix does not
+// count.
+func (c *Client) replayable(queryName string) bool {
+	q, err := c.parser.Parse(queryName)
+	if err != nil {
+		return false
+	}
+	if q.Operation.IsRead() {
+		return true
+	}
+	for _, p := range q.Params {
+		if strings.HasPrefix(strings.ToLower(p), uqrp.PrefixIdem) {
+			return true
+		}
+	}
+	return false
+}
+
+// executeRegional sends a query to the regions chosen by the read
+// preference, trying each in turn until one answers.
+func (c *Client) executeRegional(ctx context.Context, operation, resource, key string, req *transport.Request, reqConfig *requestConfig) (*Response, error) {
+	var lastErr error
+	for _, rs := range c.regions.route(operation, c.namespace(reqConfig), resource, key) {
+		start := c.config.clock.Now()
+		resp, err := c.send(ctx, rs.Transport, resource, req)
+		// A failure caused by the caller's context says nothing about the region
+		if err == nil || ctx.Err() == nil {
+			c.regions.observe(rs, c.config.clock.Now().Sub(start), err)
+		}
+		if err == nil {
+			resp.Meta.Region = rs.Name
+			return resp, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// send executes a query on t and parses the response.
+func (c *Client) send(ctx context.Context, t transport.Transport, resource string, req *transport.Request) (*Response, error) {
+	var resp *Response
+	var err error
+	c.withProfilerLabels(ctx, func(ctx context.Context) {
+		resp, err = c.sendQuery(ctx, t, resource, req)
+	}, ProfileLabelTransport, t.Name())
+	return resp, err
+}
+
+// sendQuery executes a query on t and parses the response.
+func (c *Client) sendQuery(ctx context.Context, t transport.Transport, resource string, req *transport.Request) (*Response, error) {
+	// Wait for an in-flight slot
+	if err := c.inflight.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	// Execute query, bounded by the adaptive timeout once it is known
+	queryCtx, cancel := ctx, context.CancelFunc(func() {})
+	if timeout := c.attemptTimeout(t.Name()); timeout > 0 {
+		queryCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	start := c.config.clock.Now()
+	transportResp, err := t.Query(queryCtx, req)
+	elapsed := c.config.clock.Now().Sub(start)
+	cancel()
+	c.inflight.release()
+	timedOut := err != nil && ctx.Err() == nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded)
+	if err == nil || timedOut {
+		// Timed-out queries count at their timeout, so slowdowns raise it
+		c.stats.recordLatency(t.Name(), elapsed)
+	}
+	if err != nil {
+		c.stats.recordQuery(t.Name(), t.IsEncrypted(), err)
+		return nil, attemptError(ctx, queryCtx, newTransportError(t.Name(), err, c.config.clock.Now()))
+	}
+
+	// An incomplete answer must never be parsed as a complete one
+	if transportResp.Truncated {
+		err := &ProtocolError{Err: ErrTruncated}
+		c.stats.recordQuery(t.Name(), t.IsEncrypted(), err)
+		return nil, err
+	}
+
+	// Verify chunked answers before parsing them
+	manifest := newChunkManifest(transportResp.Manifest)
+	if manifest != nil {
+		if err := manifest.Verify(transportResp.Records); err != nil {
+			c.stats.recordQuery(t.Name(), t.IsEncrypted(), err)
+			return nil, err
+		}
 	}
 
 	// Parse UQRP response
-	resp, err := ParseResponse(string(transportResp.Data))
+	c.recordSize(resource, SizeResponse, len(transportResp.Data))
+	if max := c.config.responseLimits.MaxResponseBytes; len(transportResp.Data) > max {
+		err := &LimitError{Limit: LimitResponseBytes, Resource: resource, Size: int64(len(transportResp.Data)), Max: int64(max)}
+		c.stats.recordQuery(t.Name(), t.IsEncrypted(), err)
+		return nil, err
+	}
+	resp := c.newResponse()
+	err = parseResponse(resp, string(transportResp.Data), c.config.compactResources[resource])
+	c.stats.recordQuery(t.Name(), t.IsEncrypted(), err)
 	if err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+		resp.Release()
+		return nil, err
+	}
+	resp.Records = transportResp.Records
+	resp.Manifest = manifest
+	if len(transportResp.RecordTTLs) > 0 {
+		resp.RecordTTLs = make([]time.Duration, len(transportResp.RecordTTLs))
+		for i, ttl := range transportResp.RecordTTLs {
+			resp.RecordTTLs[i] = time.Duration(ttl) * time.Second
+		}
+	}
+	resp.Meta.Transport = t.Name()
+	resp.Meta.CanonicalName = c.unmapName(transportResp.CanonicalName)
+	if resp.Meta.Source == "" && transportResp.Authoritative {
+		resp.Meta.Source = SourceAuthoritative
 	}
 
+	c.responseWarnings(resource, resp, time.Duration(transportResp.TTL)*time.Second)
+
 	// Override TTL from DNS if not set in response
 	if resp.TTL == 0 && transportResp.TTL > 0 {
 		resp.TTL = time.Duration(transportResp.TTL) * time.Second
@@ -456,15 +1261,66 @@ func (c *Client) executeQuery(ctx context.Context, queryName string, reqConfig *
 	return resp, nil
 }
 
+// selectTransport returns the transport for a request: the configured
+// transport pinned with WithTransportName, or the client transport.
+func (c *Client) selectTransport(reqConfig *requestConfig) (transport.Transport, error) {
+	name := reqConfig.transportName
+	if name == "" || c.transport.Name() == name {
+		return c.transport, nil
+	}
+	if m, ok := c.transport.(*transport.Multi); ok {
+		for _, t := range m.Transports() {
+			if t.Name() == name {
+				return t, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownTransport, name)
+}
+
+// checkTransportSecurity returns ErrEncryptedTransportRequired if security
+// is enforced and the request's transport is not encrypted.
+func (c *Client) checkTransportSecurity(reqConfig *requestConfig) error {
+	t, err := c.selectTransport(reqConfig)
+	if err != nil {
+		return err
+	}
+	if c.config.enforceSecurity && !t.IsEncrypted() {
+		return ErrEncryptedTransportRequired
+	}
+	return nil
+}
+
+// authenticated reports whether a request carries credentials for
+// operations that require them: the client's API key or a pre-issued auth
+// token.
+func (c *Client) authenticated(reqConfig *requestConfig) bool {
+	return c.config.apiKey != "" || reqConfig.authToken != ""
+}
+
 // generateAuthToken creates a time-limited HMAC signature for authentication.
 // This prevents exposing the raw API key in DNS queries.
+// Tokens are reused for the configured auth token TTL.
 // Format: auth-<signature>-t-<timestamp>
-func (c *Client) generateAuthToken(operation, resource, key string) string {
-	timestamp := time.Now().Unix()
+func (c *Client) generateAuthToken(operation, resource, key, namespace string) string {
+	if c.authTokens != nil {
+		return c.authTokens.get(operation, resource, key, namespace, func() string {
+			return c.signAuthToken(operation, resource, key, namespace)
+		})
+	}
+	return c.signAuthToken(operation, resource, key, namespace)
+}
+
+// signAuthToken computes a fresh auth token for the current time.
+func (c *Client) signAuthToken(operation, resource, key, namespace string) string {
+	return c.signAuthTokenAt(operation, resource, key, namespace, c.config.clock.Now().Unix())
+}
 
+// signAuthTokenAt computes the auth token for a timestamp.
+func (c *Client) signAuthTokenAt(operation, resource, key, namespace string, timestamp int64) string {
 	// Build message: operation|resource|key|namespace|timestamp
 	message := fmt.Sprintf("%s|%s|%s|%s|%d",
-		operation, resource, key, c.config.namespace, timestamp)
+		operation, resource, key, namespace, timestamp)
 
 	// HMAC-SHA256 with API key
 	mac := hmac.New(sha256.New, []byte(c.config.apiKey))
@@ -476,12 +1332,3 @@ func (c *Client) generateAuthToken(operation, resource, key string) string {
 
 	return fmt.Sprintf("%s%s-t-%d", PrefixAuth, sig, timestamp)
 }
-
-// insertAfter inserts a value after the given index.
-func insertAfter(slice []string, index int, value string) []string {
-	result := make([]string, len(slice)+1)
-	copy(result, slice[:index+1])
-	result[index+1] = value
-	copy(result[index+2:], slice[index+1:])
-	return result
-}
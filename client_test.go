@@ -0,0 +1,208 @@
+package resolvedb
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+)
+
+// newTestClient returns a client answering from mem, with caching and
+// retries off.
+func newTestClient(tb testing.TB, mem *transport.Memory, opts ...Option) *Client {
+	tb.Helper()
+	opts = append([]Option{
+		WithTransports(mem),
+		WithCache(CacheConfig{}),
+		WithRetry(RetryConfig{}),
+	}, opts...)
+	client, err := New(opts...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { client.Close() })
+	return client
+}
+
+func BenchmarkBuildQueryName(b *testing.B) {
+	benchmarks := []struct {
+		name string
+		opts []Option
+		req  []RequestOption
+	}{
+		{"Public", nil, nil},
+		{"Namespace", []Option{WithNamespace("myapp")}, nil},
+		{"Auth", []Option{WithNamespace("myapp"), WithAPIKey("test-key")}, nil},
+		{"Tokens", []Option{WithNamespace("myapp")}, []RequestOption{WithCTP("ctp-abc123"), WithReadToken("rt-def456")}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			client := newTestClient(b, transport.NewMemory(), bm.opts...)
+			reqConfig := newRequestConfig(context.Background(), bm.req)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = client.buildQueryName("get", "config", "app-settings", reqConfig)
+			}
+		})
+	}
+}
+
+func TestCloseStopsBackgroundGoroutines(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	mem := transport.NewMemory(transport.WithMemoryMissing([]byte("v=rdb1;s=ok;t=json;d=[]")))
+	client, err := New(
+		WithTransports(mem),
+		WithCache(CacheConfig{}),
+		WithRetry(RetryConfig{}),
+		WithAPIKey("test-key"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	events := client.Watch(ctx, "config", "app", WithPollInterval(time.Millisecond))
+	if _, err := client.Heartbeat(ctx, "presence", "device-1", time.Millisecond, "online"); err != nil {
+		t.Fatal(err)
+	}
+	informerDone := make(chan error, 1)
+	go func() {
+		informerDone <- NewInformer(client, "devices", time.Millisecond).Run(ctx)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for range events {
+	}
+	if err := <-informerDone; !errors.Is(err, ErrClosed) {
+		t.Errorf("Informer.Run returned %v, want ErrClosed", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines after Close, want %d:\n%s",
+				runtime.NumGoroutine(), baseline, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// stepClock is a Clock whose time only moves when advanced.
+type stepClock struct{ now time.Time }
+
+func (c *stepClock) Now() time.Time                         { return c.now }
+func (c *stepClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func TestNearestRegionRecovers(t *testing.T) {
+	clock := &stepClock{now: time.Unix(1700000000, 0)}
+	r := newRegionRouter([]Region{{Name: "us-east"}, {Name: "eu-west"}}, NearestRegion, 0, clock)
+	names := func() []string {
+		var names []string
+		for _, rs := range r.route("get", "public", "weather", "tokyo") {
+			names = append(names, rs.Name)
+		}
+		return names
+	}
+	us, eu := r.regions[0], r.regions[1]
+
+	r.observe(us, 10*time.Millisecond, nil)
+	r.observe(eu, 40*time.Millisecond, nil)
+	if got := names(); !slices.Equal(got, []string{"us-east", "eu-west"}) {
+		t.Fatalf("route = %v, want nearest first", got)
+	}
+
+	r.observe(us, time.Second, errors.New("timeout"))
+	if got := names(); !slices.Equal(got, []string{"eu-west", "us-east"}) {
+		t.Fatalf("route after failure = %v, want the failed region last", got)
+	}
+
+	clock.now = clock.now.Add(regionReprobeInterval)
+	if got := names(); got[0] != "us-east" {
+		t.Fatalf("route after %v = %v, want the failed region measured again", regionReprobeInterval, got)
+	}
+	r.observe(us, 10*time.Millisecond, nil)
+	if got := names(); got[0] != "us-east" {
+		t.Fatalf("route after recovery = %v, want the recovered region first", got)
+	}
+}
+
+func TestReplayable(t *testing.T) {
+	client := newTestClient(t, transport.NewMemory(), WithAPIKey("test-key"))
+
+	tests := []struct {
+		name      string
+		operation string
+		resource  string
+		key       string
+		opts      []RequestOption
+		want      bool
+	}{
+		{"read", "get", "readings", "idem-foo", nil, true},
+		{"write", "put", "readings", "sensor-7", nil, false},
+		{"write with idempotency key", "put", "readings", "sensor-7", []RequestOption{WithIdempotencyKey("abc")}, true},
+		{"key with idempotency prefix", "put", "readings", "idem-foo", nil, false},
+		{"resource with idempotency prefix", "put", "idem-readings", "sensor-7", nil, false},
+		{"namespace with idempotency prefix", "put", "readings", "sensor-7", []RequestOption{WithRequestNamespace("idem-ns")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqConfig := newRequestConfig(context.Background(), tt.opts)
+			var data []byte
+			if tt.operation == "put" {
+				data = []byte(`"21.4"`)
+			}
+			name := client.buildQueryNameWithData(tt.operation, tt.resource, tt.key, data, reqConfig)
+			if got := client.replayable(name); got != tt.want {
+				t.Errorf("replayable(%q) = %v, want %v", name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClockAgesResponsesAndExpiry(t *testing.T) {
+	clock := &stepClock{now: time.Unix(1700000000, 0)}
+	mem := transport.NewMemory(transport.WithMemoryMissing([]byte("v=rdb1;s=ok;t=json;ts=1700000000;exp=1700000600;d=1")))
+	client := newTestClient(t, mem, WithClock(clock))
+
+	clock.now = clock.now.Add(time.Minute)
+	resp, err := client.GetRaw(context.Background(), "readings", "sensor-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if age := resp.Age(); age != time.Minute {
+		t.Fatalf("Age = %s, want 1m0s on the client clock", age)
+	}
+	if life := resp.Lifetime(); life != 9*time.Minute {
+		t.Fatalf("Lifetime = %s, want 9m0s on the client clock", life)
+	}
+	if _, err := client.GetRaw(context.Background(), "readings", "sensor-7", WithMaxAge(30*time.Second)); !errors.Is(err, ErrStale) {
+		t.Fatalf("GetRaw with WithMaxAge returned %v, want ErrStale", err)
+	}
+
+	reqConfig := newRequestConfig(context.Background(), []RequestOption{WithExpiry(time.Hour)})
+	want := PrefixExp + strconv.FormatInt(clock.now.Add(time.Hour).Unix(), 10)
+	if params := client.params(reqConfig); !slices.Contains(params, want) {
+		t.Fatalf("params = %v, want %s", params, want)
+	}
+}
+
+func TestStrictKeysCoverTransactions(t *testing.T) {
+	client := newTestClient(t, transport.NewMemory(), WithAPIKey("test-key"), WithStrictKeys())
+
+	_, err := client.Txn(context.Background()).Set("config", "Settings_v2", 1).Commit()
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Commit returned %v, want ErrInvalidKey", err)
+	}
+}
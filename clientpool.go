@@ -0,0 +1,271 @@
+package resolvedb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go/uqrp"
+)
+
+const (
+	// defaultPoolMaxClients bounds the clients a ClientPool keeps.
+	defaultPoolMaxClients = 10000
+
+	// defaultPoolIdleTimeout is how long a ClientPool keeps an unused client.
+	defaultPoolIdleTimeout = 10 * time.Minute
+)
+
+// ClientPool hands out a client per tenant, for SaaS backends serving
+// many tenants from one process. Clients are created on first use from a
+// base client, with the tenant as their namespace, and share its
+// transport, cache, concurrency limit and statistics, so a tenant costs
+// little more than its configuration. Responses stay separate in the
+// shared cache, as each tenant's cache keys carry a fingerprint of the
+// tenant and its credentials: a tenant reading another's namespace with
+// WithRequestNamespace never sees values cached for that tenant.
+//
+// Clients unused for the idle timeout, and the least recently used ones
+// beyond the maximum, are dropped from the pool; the next Get for their
+// tenant creates a new one. Dropped clients keep working, so callers may
+// hold on to a client for the work at hand, but should call Get again for
+// later work. A ClientPool is safe for concurrent use.
+//
+// Example:
+//
+//	pool := resolvedb.NewClientPool(base,
+//	    resolvedb.WithTenantOptions(func(tenant string) []resolvedb.Option {
+//	        return []resolvedb.Option{resolvedb.WithTenantQueryKey(tenantKeys[tenant])}
+//	    }),
+//	)
+//	defer pool.Close()
+//
+//	client, err := pool.Get(tenantID)
+//	if err != nil {
+//	    return err
+//	}
+//	err = client.Get(ctx, "config", "settings", &settings)
+type ClientPool struct {
+	base        *Client
+	tenantOpts  func(tenant string) []Option
+	maxClients  int
+	idleTimeout time.Duration
+
+	mu        sync.Mutex
+	clients   map[string]*pooledClient
+	lastSweep time.Time
+	closed    bool
+}
+
+// pooledClient is a client kept by a ClientPool.
+type pooledClient struct {
+	client   *Client
+	lastUsed time.Time
+}
+
+// ClientPoolOption configures a ClientPool.
+type ClientPoolOption func(*ClientPool)
+
+// WithTenantOptions sets a function returning the options of a tenant's
+// client, such as its API key or tenant query key. They apply on top of
+// the base client's configuration, with the namespace already set to the
+// tenant. Options for the transport, regions and cache are ignored, as
+// those are shared.
+func WithTenantOptions(fn func(tenant string) []Option) ClientPoolOption {
+	return func(p *ClientPool) {
+		p.tenantOpts = fn
+	}
+}
+
+// WithMaxClients sets how many clients the pool keeps (default: 10000).
+// Beyond it, the least recently used client is dropped. Zero or less
+// means no limit.
+func WithMaxClients(n int) ClientPoolOption {
+	return func(p *ClientPool) {
+		p.maxClients = n
+	}
+}
+
+// WithIdleTimeout sets how long the pool keeps a client no Get has
+// returned (default: 10m). Zero or less keeps clients until the maximum
+// is reached.
+func WithIdleTimeout(d time.Duration) ClientPoolOption {
+	return func(p *ClientPool) {
+		p.idleTimeout = d
+	}
+}
+
+// NewClientPool creates a pool of tenant clients derived from base. The
+// pool does not own base: close it after closing the pool.
+func NewClientPool(base *Client, opts ...ClientPoolOption) *ClientPool {
+	p := &ClientPool{
+		base:        base,
+		maxClients:  defaultPoolMaxClients,
+		idleTimeout: defaultPoolIdleTimeout,
+		clients:     make(map[string]*pooledClient),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Get returns the client of tenant, creating it if the pool has none. It
+// fails with ErrClosed once the pool or its base client is closed.
+func (p *ClientPool) Get(tenant string) (*Client, error) {
+	now := p.base.config.clock.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || p.base.isClosed() {
+		return nil, ErrClosed
+	}
+	p.sweep(now)
+	if pc, ok := p.clients[tenant]; ok {
+		pc.lastUsed = now
+		return pc.client, nil
+	}
+
+	var opts []Option
+	if p.tenantOpts != nil {
+		opts = p.tenantOpts(tenant)
+	}
+	client, err := p.base.derive(tenant, opts)
+	if err != nil {
+		return nil, err
+	}
+	if p.maxClients > 0 && len(p.clients) >= p.maxClients {
+		p.evictLeastRecentlyUsed()
+	}
+	p.clients[tenant] = &pooledClient{client: client, lastUsed: now}
+	return client, nil
+}
+
+// Len returns the number of clients in the pool.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.clients)
+}
+
+// Close closes the clients in the pool, stopping their background work,
+// and makes later calls to Get fail. The shared transport is left to the
+// base client.
+func (p *ClientPool) Close() error {
+	p.mu.Lock()
+	clients := p.clients
+	p.clients = make(map[string]*pooledClient)
+	p.closed = true
+	p.mu.Unlock()
+
+	var errs []error
+	for _, pc := range clients {
+		errs = append(errs, pc.client.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// sweep drops clients idle for longer than the idle timeout, at most
+// once per half timeout. Must be called with p.mu held.
+func (p *ClientPool) sweep(now time.Time) {
+	if p.idleTimeout <= 0 || now.Sub(p.lastSweep) < p.idleTimeout/2 {
+		return
+	}
+	p.lastSweep = now
+	for tenant, pc := range p.clients {
+		if now.Sub(pc.lastUsed) > p.idleTimeout {
+			delete(p.clients, tenant)
+		}
+	}
+}
+
+// evictLeastRecentlyUsed drops the least recently used client. Must be
+// called with p.mu held.
+func (p *ClientPool) evictLeastRecentlyUsed() {
+	var oldest string
+	var oldestUsed time.Time
+	found := false
+	for tenant, pc := range p.clients {
+		if !found || pc.lastUsed.Before(oldestUsed) {
+			oldest, oldestUsed, found = tenant, pc.lastUsed, true
+		}
+	}
+	if found {
+		delete(p.clients, oldest)
+	}
+}
+
+// derive creates a client for namespace that shares the transport, cache,
+// concurrency limit, statistics and region routing of c, configured by
+// opts on top of c's configuration. Closing it leaves the transport open.
+func (c *Client) derive(namespace string, opts []Option) (*Client, error) {
+	live := c.live.Load()
+	config := *c.config
+	config.namespace = namespace
+	config.retryConfig = live.retry
+	config.logger = live.logger
+	config.schemas = maps.Clone(config.schemas)
+	config.migrators = maps.Clone(config.migrators)
+	config.compactResources = maps.Clone(config.compactResources)
+	config.dictionaries = maps.Clone(config.dictionaries)
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	// Shared with c
+	config.transports = c.config.transports
+	config.regions = c.config.regions
+	config.cacheConfig = live.cache
+	config.cacheKeyLimit = c.config.cacheKeyLimit
+	config.clock = c.config.clock
+	if err := validateConfig(&config); err != nil {
+		return nil, err
+	}
+
+	var session *sessionTokens
+	if config.session {
+		session = newSessionTokens(config.clock)
+	}
+
+	// The lifetime is not derived from c's, which would keep every client
+	// the pool drops registered with c until c is closed; isClosed and
+	// withLifetime check c instead
+	lifetime, shutdown := context.WithCancelCause(context.Background())
+	d := &Client{
+		lifetime:   lifetime,
+		shutdown:   shutdown,
+		base:       c,
+		config:     &config,
+		transport:  c.transport,
+		shared:     true,
+		cache:      c.cache,
+		cacheScope: tenantCacheScope(namespace, &config),
+		authTokens: newAuthTokenCache(config.authTokenTTL, config.clock),
+		inflight:   c.inflight,
+		stats:      c.stats,
+		regions:    c.regions,
+		session:    session,
+		names:      uqrp.NewBuilder(config.layout()),
+		parser:     uqrp.NewParser(config.layout()),
+
+		prefetching: c.prefetching,
+		cardinality: c.cardinality,
+	}
+	d.live.Store(&liveConfig{retry: config.retryConfig, logger: config.logger, cache: live.cache})
+	return d, nil
+}
+
+// tenantCacheScope returns the fingerprint separating the cache entries
+// of tenant, configured by config, from those of other clients.
+func tenantCacheScope(tenant string, config *clientConfig) string {
+	h := sha256.New()
+	for _, s := range [...]string{"tenant", tenant, config.apiKey, string(config.tenantQueryKey)} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
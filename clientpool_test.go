@@ -0,0 +1,51 @@
+package resolvedb
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+)
+
+func TestClientPoolReleasesDroppedClients(t *testing.T) {
+	base := newTestClient(t, transport.NewMemory())
+	pool := NewClientPool(base, WithMaxClients(2))
+	defer pool.Close()
+
+	// Lifetimes of clients the pool drops must not stay reachable from
+	// the base client
+	const tenants = 200
+	var released atomic.Int64
+	var last *Client
+	for i := 0; i < tenants; i++ {
+		client, err := pool.Get("tenant-" + strconv.Itoa(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		runtime.SetFinalizer(client.lifetime, func(any) { released.Add(1) })
+		last = client
+	}
+	if n := pool.Len(); n != 2 {
+		t.Fatalf("Len = %d, want 2", n)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for released.Load() < tenants-2 && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := released.Load(); n < tenants-2 {
+		t.Fatalf("%d of %d dropped clients were released", n, tenants-2)
+	}
+
+	// Pooled clients still stop with the base client
+	base.Close()
+	if _, err := last.GetRaw(context.Background(), "config", "settings"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("GetRaw after closing the base client returned %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,55 @@
+package resolvedb
+
+import (
+	cryptorand "crypto/rand"
+	"io"
+	"time"
+)
+
+// Clock is the client's source of time: auth token timestamps, cache and
+// token expiry, retry backoff and stickiness windows. Simulations and tests
+// can supply a fake clock with WithClock to make time-dependent behavior
+// reproducible.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After returns a channel that receives the time once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the wall clock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// ClockOf returns the clock set with WithClock when owner is a *Client or
+// *ReadOnlyClient, and the system clock otherwise. Service packages pass
+// the client they were created with, so fake clocks reach them too.
+func ClockOf(owner any) Clock {
+	if c := clientOf(owner); c != nil {
+		return c.config.clock
+	}
+	return systemClock{}
+}
+
+// RandOf returns the source of randomness set with WithRandSource when
+// owner is a *Client or *ReadOnlyClient, and crypto/rand otherwise.
+func RandOf(owner any) io.Reader {
+	if c := clientOf(owner); c != nil {
+		return c.config.rand
+	}
+	return cryptorand.Reader
+}
+
+// clientOf returns the client behind owner, or nil.
+func clientOf(owner any) *Client {
+	switch o := owner.(type) {
+	case *Client:
+		return o
+	case *ReadOnlyClient:
+		return o.c
+	}
+	return nil
+}
@@ -0,0 +1,427 @@
+package resolvedb
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/resolvedb/resolvedb-go/uqrp"
+)
+
+// Codec identifies how a value was encoded for storage.
+type Codec int
+
+const (
+	// CodecStandard is Set's usual encoding, used without WithAutoCodec.
+	CodecStandard Codec = iota
+
+	// CodecJSON stores the value as plain JSON.
+	CodecJSON
+
+	// CodecCompact stores the value as JSON with compact rdb field names.
+	CodecCompact
+
+	// CodecCompressed stores the compact JSON deflate-compressed.
+	CodecCompressed
+
+	// CodecChunked splits the encoded value across several keys of a
+	// reserved resource, with a manifest at the value's own key.
+	CodecChunked
+
+	// CodecDictionary stores the compact JSON deflate-compressed against
+	// a shared dictionary. See WithDictionary.
+	CodecDictionary
+)
+
+// String returns the codec name.
+func (c Codec) String() string {
+	switch c {
+	case CodecStandard:
+		return "standard"
+	case CodecJSON:
+		return "json"
+	case CodecCompact:
+		return "compact"
+	case CodecCompressed:
+		return "compressed"
+	case CodecChunked:
+		return "chunked"
+	case CodecDictionary:
+		return "dictionary"
+	default:
+		return "unknown"
+	}
+}
+
+// Stored values of the compressed, chunked and dictionary codecs start
+// with a header of codecMarker followed by the codec. They are stored in
+// the default JSON format, and JSON never starts with a NUL byte, so the
+// header cannot be mistaken for the start of a plain value. Values read
+// in the text or binary format were written raw and are never decoded,
+// whatever bytes they start with.
+const codecMarker byte = 0x00
+
+// codecHeaderSize is the length of an encoded value's header.
+const codecHeaderSize = 2
+
+// codecHeader returns the header of a value stored with codec.
+func codecHeader(codec Codec) []byte {
+	return []byte{codecMarker, byte(codec)}
+}
+
+// splitCodec returns the codec of an encoded payload and the data after
+// its header. ok is false for payloads without a codec header.
+func splitCodec(payload []byte) (codec Codec, data []byte, ok bool) {
+	if len(payload) < codecHeaderSize || payload[0] != codecMarker {
+		return CodecStandard, payload, false
+	}
+	switch codec := Codec(payload[1]); codec {
+	case CodecCompressed, CodecChunked, CodecDictionary:
+		return codec, payload[codecHeaderSize:], true
+	}
+	return CodecStandard, payload, false
+}
+
+// encodedFormat reports whether a value read in format may carry a codec
+// header: only values in the default JSON format do.
+func encodedFormat(format string) bool {
+	return format == "" || format == string(FormatJSON)
+}
+
+// maxCodecChunks bounds the chunks written for a single value. Reads are
+// bounded by the client's ResponseLimits instead.
+const maxCodecChunks = 1024
+
+// chunkManifest is stored at a chunked value's key.
+type chunkManifest struct {
+	Chunks int    `json:"n"` // Number of chunks
+	Hash   string `json:"h"` // Truncated SHA-256 of the reassembled payload
+}
+
+// chunksResource is the reserved resource holding the chunks of chunked
+// values. Chunks never share a resource with user data, so writing a value
+// cannot overwrite a user key and listings of the value's resource do not
+// show its chunks.
+const chunksResource = "rdb-chunks"
+
+// chunkKey returns the key, in chunksResource, of chunk i of the value at
+// resource/key. It is derived from a hash of the names as sent, so every
+// spelling of a key that reaches the same stored key shares its chunks.
+func chunkKey(resource, key string, i int) string {
+	sum := sha256.Sum256([]byte(uqrp.Sanitize(resource) + "." + uqrp.Sanitize(key)))
+	return hex.EncodeToString(sum[:12]) + "-c" + strconv.Itoa(i)
+}
+
+// chunkIdempotencyKey returns the idempotency key of chunk i of a write
+// sent with idempotency key key.
+func chunkIdempotencyKey(key string, i int) string {
+	return key + "-c" + strconv.Itoa(i)
+}
+
+// payloadHash returns the hash recorded in a chunk manifest.
+func payloadHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:8])
+}
+
+// setAuto writes data with the first codec whose encoding fits in a query.
+func (c *Client) setAuto(ctx context.Context, resource, key string, data any, reqConfig *requestConfig) (*WriteResult, error) {
+	compact, err := c.marshalValue(resource, key, data)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := jsonAPI().Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("encode data: json marshal: %w", err)
+	}
+
+	limit := c.maxPayloadBytes(resource, key, reqConfig)
+	if len(plain) <= limit {
+		return c.putCodec(ctx, resource, key, plain, CodecJSON, reqConfig)
+	}
+	return c.putEncoded(ctx, resource, key, compact, limit, reqConfig)
+}
+
+// putEncoded writes compact, a value's compact JSON, with the first codec
+// whose encoding fits in limit, chunking it if none does.
+func (c *Client) putEncoded(ctx context.Context, resource, key string, compact []byte, limit int, reqConfig *requestConfig) (*WriteResult, error) {
+	if len(compact) <= limit {
+		return c.putCodec(ctx, resource, key, compact, CodecCompact, reqConfig)
+	}
+
+	payload, codec := compact, CodecCompact
+	compressed, err := compress(compact)
+	if err != nil {
+		return nil, err
+	}
+	if len(compressed) < len(compact) {
+		payload, codec = compressed, CodecCompressed
+	}
+	if id := c.config.dictionaries[resource]; id != "" {
+		dict, err := c.dictionary(ctx, id, reqConfig)
+		if err != nil {
+			return nil, err
+		}
+		packed, err := compressDict(compact, dict, id)
+		if err != nil {
+			return nil, err
+		}
+		if len(packed) < len(payload) {
+			payload, codec = packed, CodecDictionary
+		}
+	}
+	if len(payload) <= limit {
+		return c.putCodec(ctx, resource, key, payload, codec, reqConfig)
+	}
+
+	return c.putChunked(ctx, resource, key, payload, limit, reqConfig)
+}
+
+// putChunked writes payload as chunks followed by the manifest. The
+// manifest is written last, so readers never see a partial value.
+func (c *Client) putChunked(ctx context.Context, resource, key string, payload []byte, limit int, reqConfig *requestConfig) (*WriteResult, error) {
+	size := c.chunkSize(resource, key, reqConfig)
+	if size <= 0 {
+		return nil, fmt.Errorf("%w: key %q leaves no room for chunks", ErrPayloadTooLarge, key)
+	}
+	n := (len(payload) + size - 1) / size
+	if n > maxCodecChunks {
+		return nil, fmt.Errorf("%w: %d bytes needs %d chunks, limit is %d", ErrPayloadTooLarge, len(payload), n, maxCodecChunks)
+	}
+
+	manifest, err := json.Marshal(chunkManifest{Chunks: n, Hash: payloadHash(payload)})
+	if err != nil {
+		return nil, fmt.Errorf("encode chunk manifest: %w", err)
+	}
+	manifest = append(codecHeader(CodecChunked), manifest...)
+	if len(manifest) > limit {
+		return nil, fmt.Errorf("%w: key %q leaves no room for a chunk manifest", ErrPayloadTooLarge, key)
+	}
+
+	for i := 0; i < n; i++ {
+		chunk := payload[i*size : min(len(payload), (i+1)*size)]
+
+		chunkConfig := *reqConfig
+		if chunkConfig.idempotencyKey != "" {
+			chunkConfig.idempotencyKey = chunkIdempotencyKey(reqConfig.idempotencyKey, i)
+		}
+		if _, err := c.put(ctx, chunksResource, chunkKey(resource, key, i), chunk, &chunkConfig); err != nil {
+			return nil, fmt.Errorf("write chunk %d of %d: %w", i+1, n, err)
+		}
+	}
+
+	result, err := c.put(ctx, resource, key, manifest, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+	result.Codec = CodecChunked
+	result.Chunks = n
+	return result, nil
+}
+
+// chunkSize returns the size of the chunks putChunked splits a value at
+// resource/key into. Chunks are sized for the longest chunk key.
+func (c *Client) chunkSize(resource, key string, reqConfig *requestConfig) int {
+	return c.maxPayloadBytes(chunksResource, chunkKey(resource, key, maxCodecChunks-1), reqConfig)
+}
+
+// maxChunkedBytes returns the largest payload putChunked can write to
+// resource/key, or the single write limit if the name leaves no room for
+// a manifest.
+func (c *Client) maxChunkedBytes(resource, key string, reqConfig *requestConfig) int {
+	limit := c.maxPayloadBytes(resource, key, reqConfig)
+	manifest, err := json.Marshal(chunkManifest{Chunks: maxCodecChunks, Hash: payloadHash(nil)})
+	if err != nil || codecHeaderSize+len(manifest) > limit {
+		return limit
+	}
+	return max(limit, c.chunkSize(resource, key, reqConfig)*maxCodecChunks)
+}
+
+// storedChunks returns the number of chunks of the value at key, or 0 if
+// it is missing or not chunked.
+func (c *Client) storedChunks(ctx context.Context, resource, key string, reqConfig *requestConfig) (int, error) {
+	getConfig := *reqConfig
+	getConfig.skipCache = true
+	resp, err := c.get(ctx, resource, key, &getConfig)
+	if IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	codec, data, _ := splitCodec(resp.Data)
+	if !encodedFormat(resp.Format) || codec != CodecChunked {
+		return 0, nil
+	}
+	var m chunkManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		if reqConfig.encrypt {
+			// A ciphertext that happens to start like a header
+			return 0, nil
+		}
+		return 0, &DecodeError{Format: "json", Type: "chunk manifest", Err: err}
+	}
+	return min(m.Chunks, maxCodecChunks), nil
+}
+
+// deleteChunks deletes the n chunks of a chunked value whose manifest was
+// deleted.
+func (c *Client) deleteChunks(ctx context.Context, resource, key string, n int, reqConfig *requestConfig) error {
+	for i := 0; i < n; i++ {
+		chunkConfig := *reqConfig
+		chunkConfig.ignoreNotFound = true
+		if chunkConfig.idempotencyKey != "" {
+			chunkConfig.idempotencyKey = chunkIdempotencyKey(reqConfig.idempotencyKey, i)
+		}
+		if _, err := c.deleteKey(ctx, chunksResource, chunkKey(resource, key, i), &chunkConfig); err != nil {
+			return fmt.Errorf("delete chunk %d of %d: %w", i+1, n, err)
+		}
+	}
+	return nil
+}
+
+// putCodec writes a single-key encoded value and records its codec.
+func (c *Client) putCodec(ctx context.Context, resource, key string, value []byte, codec Codec, reqConfig *requestConfig) (*WriteResult, error) {
+	result, err := c.put(ctx, resource, key, value, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+	result.Codec = codec
+	return result, nil
+}
+
+// decodeAuto reverses the codec of a value written with WithAutoCodec,
+// fetching chunks as needed. Plain JSON values are returned unchanged.
+func (c *Client) decodeAuto(ctx context.Context, resource, key string, resp *Response, reqConfig *requestConfig) (*Response, error) {
+	if !encodedFormat(resp.Format) {
+		return resp, nil
+	}
+	codec, data, ok := splitCodec(resp.Data)
+	if !ok {
+		return resp, nil
+	}
+
+	payload := data
+	var err error
+	if codec == CodecChunked {
+		if payload, err = c.readChunks(ctx, resource, key, data, reqConfig); err != nil {
+			return nil, err
+		}
+		codec, data, _ = splitCodec(payload)
+	}
+	max := c.config.responseLimits.MaxBlobBytes
+	switch codec {
+	case CodecCompressed:
+		if payload, err = decompress(data, max); err != nil {
+			return nil, &DecodeError{Format: "deflate", Type: "[]byte", Err: err}
+		}
+	case CodecDictionary:
+		// Dictionaries are stored compressed without one, which also
+		// stops a dictionary from referring to itself
+		if resource == dictionariesResource {
+			return nil, &ProtocolError{Err: fmt.Errorf("dictionary %s is itself dictionary-compressed", key)}
+		}
+		id, data, err := splitDictPayload(data)
+		if err != nil {
+			return nil, &DecodeError{Format: "deflate", Type: "[]byte", Err: err}
+		}
+		dict, err := c.dictionary(ctx, id, reqConfig)
+		if err != nil {
+			return nil, err
+		}
+		if payload, err = decompressDict(data, dict, max); err != nil {
+			return nil, &DecodeError{Format: "deflate", Type: "[]byte", Err: err}
+		}
+	}
+	if len(payload) > max {
+		return nil, &LimitError{Limit: LimitBlobBytes, Resource: resource, Key: key, Size: int64(len(payload)), Max: int64(max)}
+	}
+
+	decoded := *resp
+	decoded.Data = payload
+	decoded.Records = nil
+	decoded.RecordTTLs = nil
+	decoded.Manifest = nil
+	return &decoded, nil
+}
+
+// readChunk fetches one chunk, retrying it on its own up to the
+// WithChunkRetries limit, so a failed chunk never forces the others to be
+// fetched again.
+func (c *Client) readChunk(ctx context.Context, resource, key string, reqConfig *requestConfig) ([]byte, error) {
+	retryConfig := c.retryConfig()
+	retryConfig.MaxRetries = c.config.chunkRetries
+	r := newRetryer(retryConfig, c.config.clock, c.config.rand)
+	return doWithRetry(ctx, r, func() ([]byte, error) {
+		chunkConfig := *reqConfig
+		resp, err := c.get(ctx, resource, key, &chunkConfig)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Data, nil
+	})
+}
+
+// ChunkProgress reports the progress of a chunked read.
+type ChunkProgress struct {
+	Done  int // Chunks fetched so far
+	Total int // Chunks in the value
+	Bytes int // Bytes fetched so far
+}
+
+// chunkProgress serializes progress reports from concurrent chunk fetches.
+type chunkProgress struct {
+	client *Client
+	mu     sync.Mutex
+	state  ChunkProgress
+	fn     func(ChunkProgress)
+}
+
+func newChunkProgress(c *Client, total int, fn func(ChunkProgress)) *chunkProgress {
+	return &chunkProgress{client: c, state: ChunkProgress{Total: total}, fn: fn}
+}
+
+// add records a fetched chunk of n bytes and reports the new progress.
+func (p *chunkProgress) add(n int) {
+	if p.fn == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.Done++
+	p.state.Bytes += n
+	state := p.state
+	_ = SafeCall(p.client, "chunk progress", func() { p.fn(state) })
+}
+
+// compress deflates data and prefixes the compressed codec header.
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(codecHeader(CodecCompressed))
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, fmt.Errorf("compress: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("compress: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("compress: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress inflates deflate data. It stops after max+1 bytes, so callers
+// see an oversized value without it being inflated in full.
+func decompress(data []byte, max int) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, int64(max)+1))
+}
@@ -0,0 +1,231 @@
+package resolvedb
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Compact field names
+//
+// Struct fields tagged with `rdb:"<name>"` are stored under the short name
+// instead of their JSON name, which keeps payloads small enough for DNS:
+//
+//	type Reading struct {
+//	    TempC    float64 `json:"temp_c" rdb:"tc"`
+//	    Humidity int     `json:"humidity" rdb:"hum"`
+//	}
+//
+// Writes of tagged types are encoded with the short names, and reads into
+// tagged types map them back, so tagged structs round-trip transparently.
+// Untagged types are encoded as plain JSON.
+
+// compactType describes the compact field names of a struct type.
+type compactType struct {
+	toCompact map[string]string       // JSON name -> compact name
+	toJSON    map[string]string       // compact name -> JSON name
+	fields    map[string]reflect.Type // JSON name -> field type
+}
+
+// compactTypes caches compact descriptions by type. A nil entry marks a
+// type without rdb tags anywhere in its structure.
+var compactTypes sync.Map // reflect.Type -> *compactType
+
+// marshalJSON encodes v with the JSON implementation set by SetJSON, using
+// compact field names for types with rdb tags.
+func marshalJSON(v any) ([]byte, error) {
+	data, err := jsonAPI().Marshal(v)
+	if err != nil || v == nil {
+		return data, err
+	}
+	t := reflect.TypeOf(v)
+	if !hasCompactTags(t) {
+		return data, nil
+	}
+	return renameJSON(data, t, true)
+}
+
+// unmarshalJSON decodes JSON into v with the JSON implementation set by
+// SetJSON, mapping compact field names back for types with rdb tags.
+func unmarshalJSON(data []byte, v any) error {
+	if v != nil {
+		if t := reflect.TypeOf(v); hasCompactTags(t) {
+			expanded, err := renameJSON(data, t, false)
+			if err != nil {
+				return err
+			}
+			data = expanded
+		}
+	}
+	return jsonAPI().Unmarshal(data, v)
+}
+
+// renameJSON rewrites object keys in data between JSON and compact names
+// following the structure of t.
+func renameJSON(data []byte, t reflect.Type, toCompact bool) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return json.Marshal(renameValue(tree, t, toCompact))
+}
+
+// renameValue renames the keys of a decoded JSON value of type t.
+func renameValue(v any, t reflect.Type, toCompact bool) any {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || !hasCompactTags(t) {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		ct := compactTypeOf(t)
+		out := make(map[string]any, len(obj))
+		for k, val := range obj {
+			jsonName, name := k, k
+			if toCompact {
+				if c, ok := ct.toCompact[k]; ok {
+					name = c
+				}
+			} else if j, ok := ct.toJSON[k]; ok {
+				jsonName, name = j, j
+			}
+			out[name] = renameValue(val, ct.fields[jsonName], toCompact)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]any)
+		if !ok {
+			return v
+		}
+		for i := range arr {
+			arr[i] = renameValue(arr[i], t.Elem(), toCompact)
+		}
+		return arr
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		for k := range obj {
+			obj[k] = renameValue(obj[k], t.Elem(), toCompact)
+		}
+		return obj
+	}
+	return v
+}
+
+// hasCompactTags reports whether t, or any type reachable from it, has
+// fields with rdb tags.
+func hasCompactTags(t reflect.Type) bool {
+	return compactTypeOf(t) != nil
+}
+
+// compactTypeOf returns the compact description of t, or nil if no rdb
+// tags are reachable from t.
+func compactTypeOf(t reflect.Type) *compactType {
+	if cached, ok := compactTypes.Load(t); ok {
+		return cached.(*compactType)
+	}
+	ct := buildCompactType(t, make(map[reflect.Type]bool))
+	compactTypes.Store(t, ct)
+	return ct
+}
+
+// buildCompactType builds the compact description of t. visiting guards
+// against recursive types.
+func buildCompactType(t reflect.Type, visiting map[reflect.Type]bool) *compactType {
+	if visiting[t] {
+		return nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		if buildCompactType(t.Elem(), visiting) != nil {
+			return &compactType{}
+		}
+		return nil
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	ct := &compactType{
+		toCompact: make(map[string]string),
+		toJSON:    make(map[string]string),
+		fields:    make(map[string]reflect.Type),
+	}
+	tagged := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, skip := jsonFieldName(f)
+		if skip {
+			continue
+		}
+
+		// Promote fields of untagged embedded structs, as encoding/json does
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if sub := buildCompactType(ft, visiting); sub != nil {
+					tagged = true
+					for k, v := range sub.toCompact {
+						ct.toCompact[k] = v
+						ct.toJSON[v] = k
+					}
+					for k, v := range sub.fields {
+						ct.fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		ct.fields[name] = f.Type
+		if short := f.Tag.Get("rdb"); short != "" && short != "-" {
+			ct.toCompact[name] = short
+			ct.toJSON[short] = name
+			tagged = true
+		}
+		if buildCompactType(f.Type, visiting) != nil {
+			tagged = true
+		}
+	}
+	if !tagged {
+		return nil
+	}
+	return ct
+}
+
+// jsonFieldName returns the JSON name from a field's json tag, or "" if
+// the tag does not set one. skip is true for fields excluded with "-".
+func jsonFieldName(f reflect.StructField) (name string, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	name, _, _ = strings.Cut(tag, ",")
+	return name, false
+}
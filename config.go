@@ -0,0 +1,248 @@
+package resolvedb
+
+import (
+	"maps"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+)
+
+// redacted replaces secrets in a ConfigSnapshot.
+const redacted = "[redacted]"
+
+// ConfigSnapshot is a read-only view of a client's effective
+// configuration. Secrets are redacted, so it is safe to log or attach to
+// a support bundle.
+type ConfigSnapshot struct {
+	APIKey         string   `json:"api_key,omitempty"` // "[redacted]" when set
+	Namespace      string   `json:"namespace"`
+	Version        string   `json:"version"`
+	Apex           string   `json:"apex"` // Labels after the location labels, e.g. "resolvedb.net"
+	LabelOrder     []string `json:"label_order"`
+	LabelEncoding  string   `json:"label_encoding"`
+	TokenPlacement string   `json:"token_placement"`
+	BaseURL        string   `json:"base_url"`
+
+	Transports       []TransportConfig      `json:"transports"`
+	Regions          []RegionConfig         `json:"regions,omitempty"`
+	ReadPreference   string                 `json:"read_preference,omitempty"`
+	Timeout          time.Duration          `json:"timeout"`
+	AttemptTimeout   time.Duration          `json:"attempt_timeout,omitempty"`
+	Retry            RetryConfig            `json:"retry"`
+	AdaptiveTimeout  *AdaptiveTimeoutConfig `json:"adaptive_timeout,omitempty"`
+	Cache            CacheConfig            `json:"cache"`
+	MaxConcurrency   int                    `json:"max_concurrency"` // 0 = unlimited
+	AnswerValidation string                 `json:"answer_validation"`
+	MaxCNAMEs        int                    `json:"max_cnames,omitempty"` // 0 = transport default
+	ResponseLimits   ResponseLimits         `json:"response_limits"`
+	Security         SecurityConfig         `json:"security"`
+	LocalACL         []ACLRule              `json:"local_acl,omitempty"`
+
+	SessionConsistency bool              `json:"session_consistency"`
+	AutoCodec          bool              `json:"auto_codec"`
+	Dictionaries       map[string]string `json:"dictionaries,omitempty"` // Resource -> dictionary ID
+	LenientDecoding    bool              `json:"lenient_decoding"`
+	StrictKeys         bool              `json:"strict_keys"`
+	Prefetch           int               `json:"prefetch,omitempty"` // Hinted keys prefetched per response
+	CompactResources   []string          `json:"compact_resources,omitempty"`
+	Schemas            []string          `json:"schemas,omitempty"` // Resources with a registered schema
+	SDKVersion         string            `json:"sdk_version"`
+	VersionCheck       bool              `json:"version_check"`
+}
+
+// TransportConfig describes a configured transport.
+type TransportConfig struct {
+	Name      string   `json:"name"`
+	Encrypted bool     `json:"encrypted"`
+	Endpoints []string `json:"endpoints,omitempty"` // Servers or URLs, if the transport reports them
+}
+
+// RegionConfig describes a configured region.
+type RegionConfig struct {
+	Name      string          `json:"name"`
+	Transport TransportConfig `json:"transport"`
+}
+
+// SecurityConfig describes a client's security policy.
+type SecurityConfig struct {
+	EnforceEncryptedTransport bool          `json:"enforce_encrypted_transport"`
+	EncryptionKey             string        `json:"encryption_key,omitempty"`   // "[redacted]" when set
+	TenantQueryKey            string        `json:"tenant_query_key,omitempty"` // "[redacted]" when set
+	CacheDecrypted            bool          `json:"cache_decrypted"`
+	AuthTokenTTL              time.Duration `json:"auth_token_ttl"`
+	PayloadSigning            string        `json:"payload_signing,omitempty"` // Signature algorithm, e.g. "ed"
+}
+
+// endpointer is implemented by transports that can report their servers
+// or URLs, such as the transport package's DNS, DoT and DoH transports.
+type endpointer interface {
+	Endpoints() []string
+}
+
+// Config returns a redacted snapshot of the client's effective
+// configuration, for startup logging and support bundles.
+//
+// Example:
+//
+//	cfg := client.Config()
+//	logger.Info("resolvedb configured", "config", cfg)
+func (c *Client) Config() ConfigSnapshot {
+	cfg := c.config
+	live := c.live.Load()
+	s := ConfigSnapshot{
+		Namespace:        cfg.namespace,
+		Version:          cfg.version,
+		Apex:             c.apex(),
+		LabelEncoding:    labelEncodingName(cfg.labelEncoding),
+		TokenPlacement:   cfg.tokenPlacement.String(),
+		BaseURL:          redactURL(cfg.baseURL),
+		Timeout:          cfg.timeout,
+		AttemptTimeout:   cfg.attemptTimeout,
+		Retry:            live.retry,
+		Cache:            live.cache,
+		MaxConcurrency:   cfg.maxConcurrency,
+		AnswerValidation: cfg.answerValidation.String(),
+		MaxCNAMEs:        cfg.maxCNAMEs,
+		ResponseLimits:   cfg.responseLimits,
+		LocalACL:         append([]ACLRule(nil), cfg.acl...),
+		Security: SecurityConfig{
+			EnforceEncryptedTransport: cfg.enforceSecurity,
+			CacheDecrypted:            cfg.cacheDecrypted,
+			AuthTokenTTL:              cfg.authTokenTTL,
+		},
+		SessionConsistency: cfg.session,
+		AutoCodec:          cfg.autoCodec,
+		Dictionaries:       maps.Clone(cfg.dictionaries),
+		LenientDecoding:    cfg.lenientDecoding,
+		StrictKeys:         cfg.strictKeys,
+		Prefetch:           cfg.prefetch,
+		SDKVersion:         SDKVersion,
+		VersionCheck:       cfg.versionCheck,
+	}
+	if s.Namespace == "" {
+		s.Namespace = cfg.defaultNamespace
+	}
+	if cfg.apiKey != "" {
+		s.APIKey = redacted
+	}
+	if cfg.encryptionKey != nil || cfg.keyProvider != nil {
+		s.Security.EncryptionKey = redacted
+	}
+	if len(cfg.tenantQueryKey) > 0 {
+		s.Security.TenantQueryKey = redacted
+	}
+	if cfg.payloadSigner != nil {
+		s.Security.PayloadSigning = cfg.payloadSigner.Algorithm()
+	}
+	if cfg.adaptiveTimeout != nil {
+		at := *cfg.adaptiveTimeout
+		s.AdaptiveTimeout = &at
+	}
+	for _, l := range cfg.labelOrder {
+		s.LabelOrder = append(s.LabelOrder, labelName(l))
+	}
+
+	if len(cfg.regions) > 0 {
+		for _, r := range cfg.regions {
+			s.Regions = append(s.Regions, RegionConfig{Name: r.Name, Transport: describeTransport(r.Transport)})
+		}
+		s.ReadPreference = cfg.readPreference.String()
+	}
+	if m, ok := c.transport.(*transport.Multi); ok && len(cfg.regions) == 0 {
+		for _, t := range m.Transports() {
+			s.Transports = append(s.Transports, describeTransport(t))
+		}
+	} else {
+		s.Transports = []TransportConfig{describeTransport(c.transport)}
+	}
+
+	for resource, compact := range cfg.compactResources {
+		if compact {
+			s.CompactResources = append(s.CompactResources, resource)
+		}
+	}
+	sort.Strings(s.CompactResources)
+	for resource := range cfg.schemas {
+		s.Schemas = append(s.Schemas, resource)
+	}
+	sort.Strings(s.Schemas)
+
+	return s
+}
+
+// Config returns a redacted snapshot of the client's effective
+// configuration.
+func (r *ReadOnlyClient) Config() ConfigSnapshot {
+	return r.c.Config()
+}
+
+// apex returns the labels written after the location labels.
+func (c *Client) apex() string {
+	switch {
+	case c.config.zone != "":
+		return c.config.zone
+	case len(c.config.apexLabels) > 0:
+		return strings.Join(c.config.apexLabels, ".")
+	default:
+		return "resolvedb." + c.config.tld
+	}
+}
+
+// describeTransport returns the snapshot of a transport.
+func describeTransport(t transport.Transport) TransportConfig {
+	tc := TransportConfig{Name: t.Name(), Encrypted: t.IsEncrypted()}
+	if e, ok := t.(endpointer); ok {
+		for _, ep := range e.Endpoints() {
+			tc.Endpoints = append(tc.Endpoints, redactURL(ep))
+		}
+	}
+	return tc
+}
+
+// redactURL strips credentials and the query string, which may carry
+// tokens, from a URL. Values that are not URLs, such as host:port server
+// addresses, are returned unchanged.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return raw
+	}
+	if u.User != nil {
+		u.User = url.User("redacted")
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = redacted
+	}
+	return u.String()
+}
+
+// labelName returns the snapshot name of a location label.
+func labelName(l Label) string {
+	switch l {
+	case LabelKey:
+		return "key"
+	case LabelResource:
+		return "resource"
+	case LabelNamespace:
+		return "namespace"
+	case LabelVersion:
+		return "version"
+	default:
+		return "unknown"
+	}
+}
+
+// labelEncodingName returns the snapshot name of a label encoding.
+func labelEncodingName(enc LabelEncoding) string {
+	switch enc {
+	case LabelEncodingBase64:
+		return "base64"
+	case LabelEncodingBase32:
+		return "base32"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,66 @@
+package resolvedb
+
+import (
+	"sync"
+	"time"
+)
+
+// sessionTokenTTL bounds how long a session remembers a write's consistency
+// token. Replicas converge well within this window.
+const sessionTokenTTL = 5 * time.Minute
+
+// sessionTokens remembers the consistency tokens of a client's own writes,
+// per key, so later reads of those keys see the writes.
+type sessionTokens struct {
+	clock  Clock
+	mu     sync.Mutex
+	tokens map[string]sessionToken
+}
+
+type sessionToken struct {
+	token   string
+	expires time.Time
+}
+
+func newSessionTokens(clock Clock) *sessionTokens {
+	return &sessionTokens{tokens: make(map[string]sessionToken), clock: clock}
+}
+
+// record stores the token of a write to a key.
+func (s *sessionTokens) record(namespace, resource, key, token string) {
+	now := s.clock.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[stickyKey(namespace, resource, key)] = sessionToken{token: token, expires: now.Add(sessionTokenTTL)}
+
+	// Drop expired entries so the map stays bounded by the write rate
+	for k, t := range s.tokens {
+		if now.After(t.expires) {
+			delete(s.tokens, k)
+		}
+	}
+}
+
+// lookup returns the token of the session's last write to a key.
+func (s *sessionTokens) lookup(namespace, resource, key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[stickyKey(namespace, resource, key)]
+	if !ok || s.clock.Now().After(t.expires) {
+		return ""
+	}
+	return t.token
+}
+
+// consistencyToken returns the token a read must be at least as fresh as:
+// the request's WithConsistencyToken, else the session's last write to the
+// key, else "".
+func (c *Client) consistencyToken(resource, key string, reqConfig *requestConfig) string {
+	if reqConfig.consistencyToken != "" {
+		return reqConfig.consistencyToken
+	}
+	if c.session != nil {
+		return c.session.lookup(c.namespace(reqConfig), resource, key)
+	}
+	return ""
+}
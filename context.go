@@ -0,0 +1,56 @@
+package resolvedb
+
+import "context"
+
+// optionsContextKey is the context key for request options.
+type optionsContextKey struct{}
+
+// ContextWithOptions returns a copy of ctx carrying request options that
+// the client applies to every call made with the returned context. Options
+// attached to a parent context are kept and applied first. Options passed
+// directly to a call are applied last and take precedence.
+//
+// This lets middleware attach per-request settings once, such as the CTP
+// token of the current user or a request namespace, without threading
+// options through every call site.
+//
+// Example:
+//
+//	func withTenant(next http.Handler) http.Handler {
+//	    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//	        ctx := resolvedb.ContextWithOptions(r.Context(),
+//	            resolvedb.WithCTP(ctpFor(r)),
+//	            resolvedb.WithRequestNamespace(tenantOf(r)),
+//	        )
+//	        next.ServeHTTP(w, r.WithContext(ctx))
+//	    })
+//	}
+func ContextWithOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	parent := OptionsFromContext(ctx)
+	merged := make([]RequestOption, 0, len(parent)+len(opts))
+	merged = append(merged, parent...)
+	merged = append(merged, opts...)
+	return context.WithValue(ctx, optionsContextKey{}, merged)
+}
+
+// OptionsFromContext returns the request options attached to ctx by
+// ContextWithOptions, or nil if there are none.
+func OptionsFromContext(ctx context.Context) []RequestOption {
+	opts, _ := ctx.Value(optionsContextKey{}).([]RequestOption)
+	return opts
+}
+
+// newRequestConfig applies the options attached to ctx, then opts.
+func newRequestConfig(ctx context.Context, opts []RequestOption) *requestConfig {
+	reqConfig := &requestConfig{}
+	for _, opt := range OptionsFromContext(ctx) {
+		opt(reqConfig)
+	}
+	for _, opt := range opts {
+		opt(reqConfig)
+	}
+	return reqConfig
+}
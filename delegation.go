@@ -0,0 +1,82 @@
+package resolvedb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go/security"
+)
+
+// MintReadToken creates a delegated read token for resource/key in the
+// client's namespace, valid for ttl and signed with the tenant query key
+// (see WithTenantQueryKey). An empty key covers every key of the resource.
+// Hand the token to untrusted clients, such as mobile apps or browsers
+// using DoH, which attach it with WithReadToken; the API key never leaves
+// the server.
+//
+// Example:
+//
+//	// Server: grant a browser five minutes of access to one document
+//	token, err := client.MintReadToken("profiles", userID, 5*time.Minute)
+//
+//	// Browser-side client, no API key
+//	err = anon.Get(ctx, "profiles", userID, &profile, resolvedb.WithReadToken(token))
+func (c *Client) MintReadToken(resource, key string, ttl time.Duration) (string, error) {
+	if len(c.config.tenantQueryKey) == 0 {
+		return "", fmt.Errorf("tenant query key not configured")
+	}
+	if ttl <= 0 {
+		return "", fmt.Errorf("read token TTL must be positive")
+	}
+	namespace := c.config.namespace
+	if namespace == "" {
+		namespace = c.config.defaultNamespace
+	}
+	return security.NewReadToken(namespace, resource, key, c.config.clock.Now().Add(ttl), c.config.tenantQueryKey)
+}
+
+// PreIssueAuthTokens signs auth tokens for an operation on resource/key
+// covering w, for devices that build queries while offline and transmit
+// them later through a store-and-forward gateway. The device holds the
+// tokens instead of the API key and attaches the one valid when its query
+// will be sent with WithAuthToken (see security.SelectToken). Tokens are
+// issued for the client's namespace, or the one set with
+// WithRequestNamespace. w.Validity must not exceed the server's auth token
+// window, less the gateway's forwarding delay.
+//
+// Example:
+//
+//	tokens, err := client.PreIssueAuthTokens("put", "readings", "sensor-7", security.Window{
+//	    Start:    time.Now(),
+//	    End:      time.Now().Add(24 * time.Hour),
+//	    Validity: time.Minute,
+//	})
+//
+//	// On the device, when building the query
+//	t, ok := security.SelectToken(tokens, sendAt)
+//	err = device.Set(ctx, "readings", "sensor-7", reading, resolvedb.WithAuthToken(t.Token))
+func (c *Client) PreIssueAuthTokens(operation, resource, key string, w security.Window, opts ...RequestOption) ([]security.TimedToken, error) {
+	if c.config.apiKey == "" {
+		return nil, ErrUnauthorized
+	}
+	namespace := c.namespace(newRequestConfig(context.Background(), opts))
+	return security.PreIssue(w, func(timestamp int64) (string, error) {
+		return c.signAuthTokenAt(operation, resource, key, namespace, timestamp), nil
+	})
+}
+
+// PreIssueNBA signs NBA tokens for resource/key in the client's namespace
+// covering w, with the tenant query key (see WithTenantQueryKey). Devices
+// attach the one valid at send time with WithNBA. See
+// security.PreIssueNBA.
+func (c *Client) PreIssueNBA(resource, key string, w security.Window) ([]security.TimedToken, error) {
+	if len(c.config.tenantQueryKey) == 0 {
+		return nil, fmt.Errorf("tenant query key not configured")
+	}
+	namespace := c.config.namespace
+	if namespace == "" {
+		namespace = c.config.defaultNamespace
+	}
+	return security.PreIssueNBA(namespace, resource, key, c.config.tenantQueryKey, w)
+}
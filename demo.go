@@ -0,0 +1,128 @@
+package resolvedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+)
+
+// demoMissing is answered for keys outside the demo dataset.
+const demoMissing = "v=rdb1;s=notfound;err=not in the demo dataset"
+
+// demoValue is a sample value served by a demo client.
+type demoValue struct {
+	resource string
+	key      string
+	value    any
+}
+
+// demoData holds sample responses of the public datasets.
+var demoData = []demoValue{
+	{"weather", "tokyo", demoWeather("Tokyo, JP", 18.2, 64, 11.5, "NE", "partly cloudy")},
+	{"weather", "paris", demoWeather("Paris, FR", 14.6, 71, 15.1, "SW", "light rain")},
+	{"weather", "london", demoWeather("London, GB", 12.3, 82, 19.4, "W", "overcast")},
+	{"weather", "sydney", demoWeather("Sydney, AU", 22.8, 58, 13.0, "SE", "sunny")},
+	{"weather", "quebec", demoWeather("Quebec, CA", 4.1, 69, 21.6, "NW", "snow showers")},
+	{"weather", "new-york", demoWeather("New York, US", 16.7, 55, 17.2, "S", "clear")},
+	{"weather", "ip-8-8-8-8", demoWeather("Mountain View, US", 19.5, 48, 9.4, "NW", "sunny")},
+
+	{"geoip", "8-8-8-8", map[string]any{
+		"ip": "8.8.8.8", "city": "Mountain View", "region": "California",
+		"country": "United States", "country_code": "US",
+		"latitude": 37.4056, "longitude": -122.0775, "timezone": "America/Los_Angeles",
+		"isp": "Google LLC", "asn": 15169, "as_org": "GOOGLE",
+	}},
+	{"geoip", "1-1-1-1", map[string]any{
+		"ip": "1.1.1.1", "city": "Sydney", "region": "New South Wales",
+		"country": "Australia", "country_code": "AU",
+		"latitude": -33.8688, "longitude": 151.2093, "timezone": "Australia/Sydney",
+		"isp": "Cloudflare, Inc.", "asn": 13335, "as_org": "CLOUDFLARENET",
+	}},
+
+	{"flags", "dark-mode", map[string]any{
+		"name": "dark-mode", "enabled": true, "description": "Dark color scheme",
+	}},
+	{"flags", "new-checkout", map[string]any{
+		"name": "new-checkout", "enabled": true, "percentage": 25, "description": "Redesigned checkout flow",
+	}},
+	{"flags", "beta-search", map[string]any{
+		"name": "beta-search", "enabled": false, "description": "Search backed by the new index",
+	}},
+}
+
+// demoWeather returns a sample weather value.
+func demoWeather(location string, tempC float64, humidity int, wind float64, windDir, conditions string) map[string]any {
+	tempF := math.Round((tempC*9/5+32)*10) / 10
+	return map[string]any{
+		"location": location, "temperature": tempC,
+		"temp_c": tempC, "temp_f": tempF,
+		"feels_like": math.Round((tempC-1)*10) / 10, "feels_like_c": math.Round((tempC-1)*10) / 10, "feels_like_f": math.Round((tempF-1.8)*10) / 10,
+		"humidity": humidity, "wind_speed": wind, "wind_dir": windDir,
+		"conditions": conditions,
+	}
+}
+
+// NewDemo creates a client that answers from built-in sample data instead
+// of the network, so documentation examples and playground snippets run
+// offline. It serves a few keys of the public weather, geoip and flags
+// datasets, and lists of their keys; other keys are not found and writes
+// fail. It accepts the same options as New except WithAPIKey, and any
+// transports or regions given are replaced by the in-memory one.
+//
+// Example:
+//
+//	client, _ := resolvedb.NewDemo()
+//	var w map[string]any
+//	err := client.Get(ctx, "weather", "tokyo", &w)
+func NewDemo(opts ...Option) (*Client, error) {
+	mem := transport.NewMemory(transport.WithMemoryMissing([]byte(demoMissing)))
+	opts = append(opts[:len(opts):len(opts)], func(c *clientConfig) {
+		c.transports = []transport.Transport{mem}
+		c.regions = nil
+	})
+	c, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if c.config.apiKey != "" {
+		c.Close()
+		return nil, fmt.Errorf("invalid configuration: demo client does not accept an API key")
+	}
+
+	reqConfig := newRequestConfig(context.Background(), nil)
+	lists := make(map[string][]string)
+	var order []string
+	for _, d := range demoData {
+		answer, err := demoAnswer(d.value)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		mem.Set(c.buildQueryName("get", d.resource, d.key, reqConfig), answer)
+		if _, ok := lists[d.resource]; !ok {
+			order = append(order, d.resource)
+		}
+		lists[d.resource] = append(lists[d.resource], d.key)
+	}
+	for _, resource := range order {
+		answer, err := demoAnswer(lists[resource])
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		mem.Set(c.buildQueryName("list", resource, "", reqConfig), answer)
+	}
+	return c, nil
+}
+
+// demoAnswer encodes v as a UQRP answer.
+func demoAnswer(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("demo data: %w", err)
+	}
+	return []byte("v=rdb1;s=ok;t=json;e=base64;ttl=300;d=" + encodeBase64(data)), nil
+}
@@ -0,0 +1,159 @@
+package resolvedb
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// dictionariesResource is the reserved resource holding compression
+// dictionaries, keyed by dictionary ID.
+const dictionariesResource = "dictionaries"
+
+const (
+	// dictionaryIDSize is the number of SHA-256 bytes identifying a
+	// dictionary, stored in each value compressed with it.
+	dictionaryIDSize = 6
+
+	// maxDictionarySize is the deflate window; earlier dictionary bytes
+	// are never referenced.
+	maxDictionarySize = 32 << 10
+)
+
+// DictionaryID returns the ID of a compression dictionary: the hex of the
+// first 6 bytes of its SHA-256. IDs are derived from the content, so a
+// dictionary can never change under values compressed with it.
+func DictionaryID(dict []byte) string {
+	sum := sha256.Sum256(dict)
+	return hex.EncodeToString(sum[:dictionaryIDSize])
+}
+
+// WithDictionary compresses values written to resource against the shared
+// dictionary id, stored beforehand with PutDictionary. Documents that are
+// largely identical across keys, such as per-service configs, shrink far
+// beyond what compression alone achieves. It applies only with
+// WithAutoCodec, to values that do not fit a query uncompressed; they are
+// stored with CodecDictionary when that is smaller than CodecCompressed.
+// Readers fetch the dictionary on first use and keep it in memory.
+//
+// Example:
+//
+//	client, err := resolvedb.New(
+//	    resolvedb.WithAPIKey(key),
+//	    resolvedb.WithAutoCodec(),
+//	    resolvedb.WithDictionary("config", "3fa2c1e09b7d"),
+//	)
+func WithDictionary(resource, id string) Option {
+	return func(c *clientConfig) {
+		if c.dictionaries == nil {
+			c.dictionaries = make(map[string]string)
+		}
+		c.dictionaries[resource] = id
+	}
+}
+
+// PutDictionary stores a compression dictionary and returns its ID for
+// WithDictionary. A good dictionary is a typical document, or the strings
+// documents share, with the most common content last; only the last 32 KiB
+// are used. Storing the same dictionary again is harmless. Requires an API
+// key.
+//
+// Example:
+//
+//	id, err := client.PutDictionary(ctx, typicalConfig)
+func (c *Client) PutDictionary(ctx context.Context, dict []byte, opts ...RequestOption) (string, error) {
+	if len(dict) == 0 {
+		return "", fmt.Errorf("dictionary is empty")
+	}
+	if len(dict) > maxDictionarySize {
+		dict = dict[len(dict)-maxDictionarySize:]
+	}
+	id := DictionaryID(dict)
+	reqConfig := newRequestConfig(ctx, opts)
+
+	payload, err := compress(dict)
+	if err != nil {
+		return "", err
+	}
+	limit := c.maxPayloadBytes(dictionariesResource, id, reqConfig)
+	if len(payload) <= limit {
+		_, err = c.put(ctx, dictionariesResource, id, payload, reqConfig)
+	} else {
+		_, err = c.putChunked(ctx, dictionariesResource, id, payload, limit, reqConfig)
+	}
+	if err != nil {
+		return "", err
+	}
+	c.dicts.Store(id, bytes.Clone(dict))
+	return id, nil
+}
+
+// dictionary returns the dictionary id, fetching it from the namespace of
+// reqConfig on first use.
+func (c *Client) dictionary(ctx context.Context, id string, reqConfig *requestConfig) ([]byte, error) {
+	if dict, ok := c.dicts.Load(id); ok {
+		return dict.([]byte), nil
+	}
+
+	dictConfig := newRequestConfig(ctx, nil)
+	dictConfig.namespace = reqConfig.namespace
+	resp, err := c.get(ctx, dictionariesResource, id, dictConfig)
+	if err != nil {
+		return nil, fmt.Errorf("load dictionary %s: %w", id, err)
+	}
+	if resp, err = c.decodeAuto(ctx, dictionariesResource, id, resp, dictConfig); err != nil {
+		return nil, fmt.Errorf("load dictionary %s: %w", id, err)
+	}
+	if DictionaryID(resp.Data) != id {
+		return nil, &ProtocolError{Err: fmt.Errorf("dictionary %s does not match its ID", id)}
+	}
+
+	// The response may be cached or pooled, so keep a copy
+	dict := bytes.Clone(resp.Data)
+	c.dicts.Store(id, dict)
+	return dict, nil
+}
+
+// compressDict deflates data against dict and prefixes the dictionary
+// codec header and ID.
+func compressDict(data, dict []byte, id string) ([]byte, error) {
+	rawID, err := hex.DecodeString(id)
+	if err != nil || len(rawID) != dictionaryIDSize {
+		return nil, fmt.Errorf("invalid dictionary ID %q", id)
+	}
+	var buf bytes.Buffer
+	buf.Write(codecHeader(CodecDictionary))
+	buf.Write(rawID)
+	w, err := flate.NewWriterDict(&buf, flate.BestCompression, dict)
+	if err != nil {
+		return nil, fmt.Errorf("compress: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("compress: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("compress: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// splitDictPayload returns the dictionary ID and deflate data of a value
+// following the dictionary codec header.
+func splitDictPayload(payload []byte) (string, []byte, error) {
+	if len(payload) < dictionaryIDSize {
+		return "", nil, fmt.Errorf("dictionary-compressed value too short")
+	}
+	return hex.EncodeToString(payload[:dictionaryIDSize]), payload[dictionaryIDSize:], nil
+}
+
+// decompressDict inflates data compressed against dict. Like decompress,
+// it stops after max+1 bytes.
+func decompressDict(data, dict []byte, max int) ([]byte, error) {
+	r := flate.NewReaderDict(bytes.NewReader(data), dict)
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, int64(max)+1))
+}
@@ -0,0 +1,214 @@
+package resolvedb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+)
+
+// discoveryPrefix is prepended to the apex to form the name ResolveDB
+// advertises its endpoints under (RFC 9461).
+const discoveryPrefix = "_dns."
+
+// maxDiscoveryAliases bounds the AliasMode records followed by discovery.
+const maxDiscoveryAliases = 4
+
+// DiscoverTransports looks up the endpoints ResolveDB advertises in SVCB
+// records at _dns.<apex>, e.g. _dns.resolvedb.net (RFC 9461), and returns
+// a transport for each one this SDK supports, most preferred first. The
+// lookup goes through the client's transports, which must return DNS wire
+// format answers, so DoH JSON cannot bootstrap discovery.
+//
+// Endpoints offering "h2" or "http/1.1" become DoH transports at their
+// dohpath, and those offering "dot" become DoT transports, dialing the
+// advertised address hints when present. Other protocols, such as "doq"
+// and "h3", are skipped. ErrNoEndpoints is returned if nothing usable is
+// advertised.
+//
+// Example:
+//
+//	transports, err := boot.DiscoverTransports(ctx)
+//	if err == nil {
+//	    client, err = resolvedb.New(resolvedb.WithTransports(transports...))
+//	}
+func (c *Client) DiscoverTransports(ctx context.Context) ([]transport.Transport, error) {
+	if c.isClosed() {
+		return nil, ErrClosed
+	}
+	if c.config.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.timeout)
+		defer cancel()
+	}
+
+	name := discoveryPrefix + c.apex()
+	for hops := 0; ; hops++ {
+		bindings, owner, err := c.lookupBindings(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		var alias string
+		var endpoints []transport.ServiceBinding
+		for _, b := range bindings {
+			if b.AliasMode() {
+				alias = b.Target
+			} else {
+				endpoints = append(endpoints, b)
+			}
+		}
+		// ServiceMode records take precedence over an alias (RFC 9460 2.4.2)
+		if len(endpoints) == 0 && alias != "" && hops < maxDiscoveryAliases {
+			name = alias
+			continue
+		}
+
+		sort.SliceStable(endpoints, func(i, j int) bool {
+			return endpoints[i].Priority < endpoints[j].Priority
+		})
+		var transports []transport.Transport
+		for _, b := range endpoints {
+			transports = append(transports, c.bindingTransports(owner, b)...)
+		}
+		if len(transports) == 0 {
+			return nil, fmt.Errorf("%w at %s", ErrNoEndpoints, name)
+		}
+		return transports, nil
+	}
+}
+
+// NewDiscovered creates a client whose transports are discovered with
+// DiscoverTransports. The transports configured by opts, or the default
+// DoH transport, are used for the lookup only. If discovery fails, the
+// failure is logged and the client keeps those transports instead.
+//
+// Example:
+//
+//	client, err := resolvedb.NewDiscovered(ctx, resolvedb.WithLogger(logger))
+func NewDiscovered(ctx context.Context, opts ...Option) (*Client, error) {
+	boot, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	transports, err := boot.DiscoverTransports(ctx)
+	if err != nil {
+		boot.logger().Warn("resolvedb: endpoint discovery failed, using configured transports", "error", err)
+		return boot, nil
+	}
+
+	// The bootstrap transports may be shared by the caller, so they are
+	// left open; the bootstrap client holds nothing else.
+	boot.shutdown(ErrClosed)
+	opts = append(opts[:len(opts):len(opts)], WithTransports(transports...), func(c *clientConfig) {
+		c.regions = nil
+	})
+	return New(opts...)
+}
+
+// lookupBindings queries the SVCB records at name and returns them with
+// the name that owns them.
+func (c *Client) lookupBindings(ctx context.Context, name string) ([]transport.ServiceBinding, string, error) {
+	resp, err := c.transport.Query(ctx, &transport.Request{
+		Name:       name,
+		Type:       transport.TypeSVCB,
+		Rand:       c.config.rand,
+		Validation: transport.ValidateOwnerCNAME,
+		MaxCNAMEs:  c.config.maxCNAMEs,
+		Replayable: true,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("discover %s: %w", name, err)
+	}
+	if resp.Truncated {
+		return nil, "", fmt.Errorf("discover %s: %w", name, ErrTruncated)
+	}
+
+	owner := resp.CanonicalName
+	if owner == "" {
+		owner = strings.ToLower(strings.TrimSuffix(name, "."))
+	}
+	var bindings []transport.ServiceBinding
+	for _, a := range resp.Answers {
+		if a.Type != transport.TypeSVCB || !strings.EqualFold(strings.TrimSuffix(a.Name, "."), owner) {
+			continue
+		}
+		b, err := transport.ParseServiceBinding(a.Data)
+		if err != nil {
+			c.logger().Warn("resolvedb: skipping malformed SVCB record", "name", owner, "error", err)
+			continue
+		}
+		bindings = append(bindings, b)
+	}
+	return bindings, owner, nil
+}
+
+// bindingTransports returns transports for the protocols of b that this
+// SDK supports. owner is the name the record was found at.
+func (c *Client) bindingTransports(owner string, b transport.ServiceBinding) []transport.Transport {
+	host := b.Target
+	if host == "" {
+		// "." targets the owner name, which is the service's own name
+		// once the _dns prefix is removed
+		host = strings.TrimPrefix(owner, discoveryPrefix)
+	}
+
+	var transports []transport.Transport
+	doh := false
+	for _, alpn := range b.ALPN {
+		if !transport.Available(alpnTransports[alpn]) {
+			c.logger().Debug("resolvedb: skipping unsupported endpoint protocol", "target", host, "alpn", alpn)
+			continue
+		}
+		switch alpn {
+		case "h2", "http/1.1":
+			if doh {
+				continue
+			}
+			doh = true
+			transports = append(transports, newDoH(c.config, dohURL(host, b)))
+		case "dot":
+			port := b.Port
+			if port == 0 {
+				port = 853
+			}
+			var servers []string
+			for _, hints := range [][]netip.Addr{b.IPv4Hint, b.IPv6Hint} {
+				for _, ip := range hints {
+					servers = append(servers, net.JoinHostPort(ip.String(), strconv.Itoa(int(port))))
+				}
+			}
+			if len(servers) == 0 {
+				servers = []string{net.JoinHostPort(host, strconv.Itoa(int(port)))}
+			}
+			transports = append(transports, newDoT(c.config, host, servers))
+		}
+	}
+	return transports
+}
+
+// alpnTransports maps the ALPN protocols of service bindings to the
+// transports that speak them.
+var alpnTransports = map[string]string{
+	"h2":       "doh",
+	"http/1.1": "doh",
+	"dot":      "dot",
+}
+
+// dohURL returns the DoH endpoint URL of a service binding, with the
+// template variable of its dohpath removed.
+func dohURL(host string, b transport.ServiceBinding) string {
+	path, _, _ := strings.Cut(b.DoHPath, "{")
+	if path == "" {
+		path = "/dns-query"
+	}
+	if b.Port != 0 && b.Port != 443 {
+		host = net.JoinHostPort(host, strconv.Itoa(int(b.Port)))
+	}
+	return "https://" + host + path
+}
@@ -1,22 +1,38 @@
 package resolvedb
 
 import (
+	"encoding/base32"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
+
+	"github.com/resolvedb/resolvedb-go/uqrp"
 )
 
-// Encoding prefixes used in DNS labels.
-// Per RFC 1035, colons are invalid in DNS labels, so hyphens are used.
+// Encoding prefixes used in DNS labels, as defined by package uqrp.
 const (
-	PrefixBase64 = "b64-"
-	PrefixHex    = "hex-"
-	PrefixAuth   = "auth-"
-	PrefixBDT    = "bdt-"
-	PrefixCTP    = "ctp-"
-	PrefixSig    = "sig-"
+	PrefixBase64   = uqrp.PrefixBase64
+	PrefixBase32   = uqrp.PrefixBase32
+	PrefixHex      = uqrp.PrefixHex
+	PrefixAuth     = uqrp.PrefixAuth
+	PrefixBDT      = uqrp.PrefixBDT
+	PrefixCTP      = uqrp.PrefixCTP
+	PrefixSig      = uqrp.PrefixSig
+	PrefixVer      = uqrp.PrefixVer
+	PrefixAt       = uqrp.PrefixAt
+	PrefixExp      = uqrp.PrefixExp
+	PrefixCST      = uqrp.PrefixCST
+	PrefixIdem     = uqrp.PrefixIdem
+	PrefixFields   = uqrp.PrefixFields
+	PrefixChunk    = uqrp.PrefixChunk
+	PrefixFormat   = uqrp.PrefixFormat
+	PrefixEncoding = uqrp.PrefixEncoding
+	PrefixCursor   = uqrp.PrefixCursor
+	PrefixNonce    = uqrp.PrefixNonce
+	PrefixIfMatch  = uqrp.PrefixIfMatch
 )
 
 // encodeBase64 encodes data as URL-safe base64 without padding.
@@ -35,6 +51,38 @@ func decodeBase64(s string) ([]byte, error) {
 	return base64.URLEncoding.DecodeString(s)
 }
 
+// appendBase64 decodes URL-safe base64 data (with or without padding),
+// appending it to dst.
+func appendBase64(dst []byte, s string) ([]byte, error) {
+	enc := base64.RawURLEncoding
+	if strings.HasSuffix(s, "=") {
+		enc = base64.URLEncoding
+	}
+	n := len(dst)
+	dst = slices.Grow(dst, enc.DecodedLen(len(s)))
+	m, err := enc.Decode(dst[n:n+enc.DecodedLen(len(s))], []byte(s))
+	if err != nil {
+		return nil, err
+	}
+	return dst[:n+m], nil
+}
+
+// base32Label is base32hex without padding. Lowercased, its alphabet
+// (0-9, a-v) survives resolvers and middleboxes that fold or randomize the
+// case of names.
+var base32Label = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// encodeBase32 encodes data as lowercase base32hex without padding.
+func encodeBase32(data []byte) string {
+	return strings.ToLower(base32Label.EncodeToString(data))
+}
+
+// decodeBase32 decodes base32hex data in either case, with or without
+// padding.
+func decodeBase32(s string) ([]byte, error) {
+	return base32Label.DecodeString(strings.TrimRight(strings.ToUpper(s), "="))
+}
+
 // encodeHex encodes data as lowercase hexadecimal.
 func encodeHex(data []byte) string {
 	return hex.EncodeToString(data)
@@ -80,6 +128,8 @@ func decodeParam(s string) ([]byte, error) {
 	switch {
 	case strings.HasPrefix(s, PrefixBase64):
 		return decodeBase64(strings.TrimPrefix(s, PrefixBase64))
+	case strings.HasPrefix(s, PrefixBase32):
+		return decodeBase32(strings.TrimPrefix(s, PrefixBase32))
 	case strings.HasPrefix(s, PrefixHex):
 		return decodeHex(strings.TrimPrefix(s, PrefixHex))
 	default:
@@ -88,24 +138,27 @@ func decodeParam(s string) ([]byte, error) {
 	}
 }
 
-// sanitizeLabel ensures a string is valid for use in a DNS label.
-// Converts to lowercase, replaces invalid characters.
-func sanitizeLabel(s string) string {
-	s = strings.ToLower(s)
-	var result strings.Builder
-	for _, r := range s {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
-			result.WriteRune(r)
-		} else if r == '_' || r == ' ' {
-			result.WriteRune('-')
-		}
-	}
-	// DNS labels must start and end with alphanumeric
-	label := result.String()
-	label = strings.Trim(label, "-")
-	// Max label length is 63 characters
-	if len(label) > 63 {
-		label = label[:63]
-	}
-	return label
+// encodeDataLabel encodes data as a self-describing label: b64-<base64url>,
+// or b32-<base32hex> with LabelEncodingBase32.
+func (c *Client) encodeDataLabel(data []byte) string {
+	return c.config.labelEncoding.DataLabel(data)
+}
+
+// encodeParamValue encodes data for a parameter label whose own prefix
+// implies base64url (fld-, q-, iv-). With LabelEncodingBase32 the value is
+// base32hex, marked by a b32- prefix after the parameter prefix.
+func (c *Client) encodeParamValue(data []byte) string {
+	return c.config.labelEncoding.ParamValue(data)
+}
+
+// encodedLen returns the length of n bytes in the label encoding, without
+// prefix.
+func (c *Client) encodedLen(n int) int {
+	return c.config.labelEncoding.EncodedLen(n)
+}
+
+// decodedLen returns the number of bytes that fit in n characters of the
+// label encoding.
+func (c *Client) decodedLen(n int) int {
+	return c.config.labelEncoding.DecodedLen(n)
 }
@@ -1,66 +1,105 @@
 package resolvedb
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go/transport"
 )
 
 // Standard error codes from ResolveDB protocol.
 const (
-	CodeSuccess        = "E000" // Success
-	CodeBadRequest     = "E001" // Malformed query
-	CodeUnauthorized   = "E002" // Missing or invalid auth
-	CodeForbidden      = "E003" // Insufficient permissions
-	CodeNotFound       = "E004" // Resource not found
-	CodeConflict       = "E005" // Resource already exists
-	CodePayloadTooLarge = "E006" // Data exceeds limits
-	CodeInvalidFormat  = "E007" // Invalid data format
-	CodeVersionMismatch = "E008" // Version conflict
-	CodeNamespaceError = "E009" // Namespace issues
-	CodeServerError    = "E010" // Internal error (retryable)
-	CodeUnavailable    = "E011" // Service unavailable
-	CodeTimeout        = "E012" // Query timeout (retryable)
-	CodeRateLimited    = "E013" // Rate limit exceeded (retryable)
+	CodeSuccess            = "E000" // Success
+	CodeBadRequest         = "E001" // Malformed query
+	CodeUnauthorized       = "E002" // Missing or invalid auth
+	CodeForbidden          = "E003" // Insufficient permissions
+	CodeNotFound           = "E004" // Resource not found
+	CodeConflict           = "E005" // Resource already exists
+	CodePayloadTooLarge    = "E006" // Data exceeds limits
+	CodeInvalidFormat      = "E007" // Invalid data format
+	CodeVersionMismatch    = "E008" // Version conflict
+	CodeNamespaceError     = "E009" // Namespace issues
+	CodeServerError        = "E010" // Internal error (retryable)
+	CodeUnavailable        = "E011" // Service unavailable
+	CodeTimeout            = "E012" // Query timeout (retryable)
+	CodeRateLimited        = "E013" // Rate limit exceeded (retryable)
 	CodeEncryptionRequired = "E014" // Encryption required
 )
 
 // Sentinel errors for use with errors.Is.
 var (
-	ErrBadRequest          = &Error{Code: CodeBadRequest, Message: "malformed query"}
-	ErrUnauthorized        = &Error{Code: CodeUnauthorized, Message: "authentication required"}
-	ErrForbidden           = &Error{Code: CodeForbidden, Message: "insufficient permissions"}
-	ErrNotFound            = &Error{Code: CodeNotFound, Message: "resource not found"}
-	ErrConflict            = &Error{Code: CodeConflict, Message: "resource already exists"}
-	ErrPayloadTooLarge     = &Error{Code: CodePayloadTooLarge, Message: "data exceeds size limit"}
-	ErrInvalidFormat       = &Error{Code: CodeInvalidFormat, Message: "invalid data format"}
-	ErrVersionMismatch     = &Error{Code: CodeVersionMismatch, Message: "version conflict"}
-	ErrNamespaceError      = &Error{Code: CodeNamespaceError, Message: "namespace error"}
-	ErrServerError         = &Error{Code: CodeServerError, Message: "internal server error"}
-	ErrUnavailable         = &Error{Code: CodeUnavailable, Message: "service unavailable"}
-	ErrTimeout             = &Error{Code: CodeTimeout, Message: "query timeout"}
-	ErrRateLimited         = &Error{Code: CodeRateLimited, Message: "rate limit exceeded"}
-	ErrEncryptionRequired  = &Error{Code: CodeEncryptionRequired, Message: "encryption required"}
+	ErrBadRequest         = &Error{Code: CodeBadRequest, Message: "malformed query"}
+	ErrUnauthorized       = &Error{Code: CodeUnauthorized, Message: "authentication required"}
+	ErrForbidden          = &Error{Code: CodeForbidden, Message: "insufficient permissions"}
+	ErrNotFound           = &Error{Code: CodeNotFound, Message: "resource not found"}
+	ErrConflict           = &Error{Code: CodeConflict, Message: "resource already exists"}
+	ErrPayloadTooLarge    = &Error{Code: CodePayloadTooLarge, Message: "data exceeds size limit"}
+	ErrInvalidFormat      = &Error{Code: CodeInvalidFormat, Message: "invalid data format"}
+	ErrVersionMismatch    = &Error{Code: CodeVersionMismatch, Message: "version conflict"}
+	ErrNamespaceError     = &Error{Code: CodeNamespaceError, Message: "namespace error"}
+	ErrServerError        = &Error{Code: CodeServerError, Message: "internal server error"}
+	ErrUnavailable        = &Error{Code: CodeUnavailable, Message: "service unavailable"}
+	ErrTimeout            = &Error{Code: CodeTimeout, Message: "query timeout"}
+	ErrRateLimited        = &Error{Code: CodeRateLimited, Message: "rate limit exceeded"}
+	ErrEncryptionRequired = &Error{Code: CodeEncryptionRequired, Message: "encryption required"}
 
 	// SDK-specific errors.
-	ErrNonceExhausted           = errors.New("resolvedb: nonce counter exhausted, rotate encryption key")
+	ErrNonceExhausted             = errors.New("resolvedb: nonce counter exhausted, rotate encryption key")
 	ErrEncryptedTransportRequired = errors.New("resolvedb: authenticated requests require encrypted transport")
-	ErrInvalidResponse          = errors.New("resolvedb: invalid response format")
-	ErrChunkIntegrity           = errors.New("resolvedb: chunk integrity verification failed")
-	ErrForbiddenAlgorithm       = errors.New("resolvedb: forbidden JWT algorithm")
+	ErrInvalidResponse            = errors.New("resolvedb: invalid response format")
+	ErrChunkIntegrity             = errors.New("resolvedb: chunk integrity verification failed")
+	ErrForbiddenAlgorithm         = errors.New("resolvedb: forbidden JWT algorithm")
+	ErrUnknownTransport           = errors.New("resolvedb: unknown transport")
+	ErrStale                      = errors.New("resolvedb: data older than max age")
+	ErrNotAuthoritative           = errors.New("resolvedb: answer not from the authoritative source")
+	ErrTruncated                  = errors.New("resolvedb: answer truncated")
+	ErrClosed                     = errors.New("resolvedb: client is closed")
+	ErrNoEndpoints                = errors.New("resolvedb: no supported endpoints advertised")
+	ErrResponseTooLarge           = errors.New("resolvedb: response exceeds limit")
+	ErrACLDenied                  = errors.New("resolvedb: denied by local ACL")
+	ErrInvalidKey                 = errors.New("resolvedb: invalid key")
+	ErrNamespaceBoundToken        = errors.New("resolvedb: pre-issued token is bound to one namespace")
+	ErrNotReloadable              = errors.New("resolvedb: setting cannot be changed on a live client")
 )
 
 // Error represents a ResolveDB protocol error.
 type Error struct {
-	Code    string // Error code (E001-E014)
-	Message string // Human-readable message
-	Details string // Additional details from server
+	Code      string     // Error code (E001-E014)
+	Message   string     // Human-readable message
+	Details   string     // Additional details from server
+	RateLimit *RateLimit // Server-reported rate-limit state (E013 only, may be nil)
+
+	// Hint, DocURL and RetryAfter are reported by servers that explain
+	// their errors (the hint=, doc= and retry_after= response fields), and
+	// are empty otherwise. Retries wait at least RetryAfter, bounded by
+	// RetryConfig.MaxBackoff.
+	Hint       string        // Suggested remedy
+	DocURL     string        // Documentation for the error
+	RetryAfter time.Duration // Suggested wait before retrying
 }
 
 func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "resolvedb [%s]: %s", e.Code, e.Message)
 	if e.Details != "" {
-		return fmt.Sprintf("resolvedb [%s]: %s (%s)", e.Code, e.Message, e.Details)
+		fmt.Fprintf(&b, " (%s)", e.Details)
+	}
+	if e.RetryAfter > 0 {
+		fmt.Fprintf(&b, "; retry after %s", e.RetryAfter)
 	}
-	return fmt.Sprintf("resolvedb [%s]: %s", e.Code, e.Message)
+	if e.Hint != "" {
+		fmt.Fprintf(&b, "; hint: %s", e.Hint)
+	}
+	if e.DocURL != "" {
+		fmt.Fprintf(&b, "; see %s", e.DocURL)
+	}
+	return b.String()
 }
 
 // Is implements errors.Is for error comparison.
@@ -82,6 +121,298 @@ func (e *Error) Retryable() bool {
 	}
 }
 
+// QueryError annotates an error with the query that produced it.
+// Use errors.As to inspect it; errors.Is still matches the underlying error.
+//
+// Example:
+//
+//	var qerr *resolvedb.QueryError
+//	if errors.As(err, &qerr) {
+//	    log.Printf("%s %s/%s via %s failed after %d attempts: %v",
+//	        qerr.Op, qerr.Resource, qerr.Key, qerr.Transport, qerr.Attempts, qerr.Err)
+//	}
+type QueryError struct {
+	Op        string // Operation (e.g., "get", "put", "delete")
+	Resource  string // Resource name, empty if not applicable
+	Key       string // Key, empty if not applicable
+	Namespace string // Namespace queried
+	Transport string // Transport name, empty if unknown
+	Attempts  int    // Number of attempts made
+	Err       error  // Underlying error
+
+	// Cause is context.Cause of the query's context if the context ended
+	// before the query finished, nil otherwise. It tells a cancellation by
+	// the caller (context.Canceled or the cause passed to a
+	// context.CancelCauseFunc) from an expired deadline
+	// (context.DeadlineExceeded) and from the client being closed
+	// (ErrClosed). errors.Is matches it as well as Err.
+	Cause error
+}
+
+func (e *QueryError) Error() string {
+	op := e.Op
+	if e.Resource != "" {
+		op += " " + e.Resource
+		if e.Key != "" {
+			op += "/" + e.Key
+		}
+	}
+	msg := fmt.Sprintf("%s (namespace=%s transport=%s attempts=%d): %v",
+		op, e.Namespace, e.Transport, e.Attempts, e.Err)
+	if e.Cause != nil && !errors.Is(e.Err, e.Cause) {
+		msg += " (cause: " + e.Cause.Error() + ")"
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the query's context ended with cause target, so
+// errors.Is(err, ErrClosed) holds for queries aborted by Close.
+func (e *QueryError) Is(target error) bool {
+	return e.Cause != nil && errors.Is(e.Cause, target)
+}
+
+// TransportError reports a failure to exchange a query with the server,
+// such as a network error, an HTTP error status or a malformed DNS message.
+//
+// Failures map to protocol errors where possible: timeouts to ErrTimeout,
+// HTTP 401, 403, 404 and 429 to ErrUnauthorized, ErrForbidden, ErrNotFound
+// and ErrRateLimited, and server failures, refused connections and 5xx
+// statuses to ErrServerError. A JSON error body from a DoH endpoint
+// supplies the code and details when present. errors.Is, errors.As and
+// IsRetryable match the mapped error, so the retry layer retries only
+// transient failures.
+type TransportError struct {
+	Transport string // Transport name
+	Err       error  // Underlying error
+	Protocol  *Error // Protocol error the failure maps to, nil if none
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("resolvedb: transport %s: %v", e.Transport, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the failure maps to the protocol error target.
+func (e *TransportError) Is(target error) bool {
+	return e.Protocol != nil && e.Protocol.Is(target)
+}
+
+// As sets target to the mapped protocol error, if any, when target is a
+// **Error.
+func (e *TransportError) As(target any) bool {
+	t, ok := target.(**Error)
+	if !ok || e.Protocol == nil {
+		return false
+	}
+	*t = e.Protocol
+	return true
+}
+
+// newTransportError wraps a transport failure, mapping transient failures
+// to protocol errors.
+func newTransportError(name string, err error, now time.Time) *TransportError {
+	return &TransportError{Transport: name, Err: err, Protocol: transportProtocolError(err, now)}
+}
+
+// transportProtocolError returns the protocol error a transport failure
+// maps to, or nil if it has none. Cancellation by the caller's context is
+// never mapped.
+func transportProtocolError(err error, now time.Time) *Error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+
+	var herr *transport.HTTPError
+	if errors.As(err, &herr) {
+		return httpProtocolError(herr, now)
+	}
+
+	var rerr *transport.RcodeError
+	if errors.As(err, &rerr) {
+		return ErrServerError
+	}
+
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		return ErrTimeout
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return ErrServerError
+	}
+	return nil
+}
+
+// httpProtocolError maps a DoH HTTP error status to a protocol error,
+// taking the code and details from a JSON error body when the endpoint
+// returns one.
+func httpProtocolError(herr *transport.HTTPError, now time.Time) *Error {
+	var code string
+	switch {
+	case herr.StatusCode == 401: // Unauthorized
+		code = CodeUnauthorized
+	case herr.StatusCode == 403: // Forbidden
+		code = CodeForbidden
+	case herr.StatusCode == 404: // Not Found
+		code = CodeNotFound
+	case herr.StatusCode == 429: // Too Many Requests
+		code = CodeRateLimited
+	case herr.StatusCode == 504: // Gateway Timeout
+		code = CodeTimeout
+	case herr.StatusCode >= 500:
+		code = CodeServerError
+	}
+
+	body := parseHTTPErrorBody(herr.Body)
+	if body.Code != "" {
+		code = body.Code
+	}
+	if code == "" {
+		return nil
+	}
+
+	e := errorFromCode(code, body.Error).(*Error)
+	e.Hint, e.DocURL = body.Hint, body.Doc
+	e.RetryAfter = herr.RetryAfter
+	if body.RetryAfter > 0 {
+		e.RetryAfter = body.RetryAfter
+	}
+	if code == CodeRateLimited && e.RetryAfter > 0 {
+		e.RateLimit = &RateLimit{Reset: now.Add(e.RetryAfter)}
+	}
+	return e
+}
+
+// httpErrorBody is the JSON error document a DoH endpoint may return with
+// an error status, e.g. {"code":"E004","error":"key not found"}, with an
+// optional hint, documentation URL and retry_after in seconds.
+type httpErrorBody struct {
+	Code       string          `json:"code"`
+	Error      string          `json:"error"`
+	Message    string          `json:"message"`
+	Hint       string          `json:"hint"`
+	Doc        string          `json:"doc"`
+	RetryAfter json.RawMessage `json:"retry_after"`
+}
+
+// httpError is the error reported by a JSON error body.
+type httpError struct {
+	Code       string // Protocol error code, empty if the body names none
+	Error      string // Details, empty if the body is not JSON
+	Hint       string
+	Doc        string
+	RetryAfter time.Duration
+}
+
+// parseHTTPErrorBody extracts the protocol error code, details and
+// remedies from a JSON error body.
+func parseHTTPErrorBody(body []byte) httpError {
+	var doc httpErrorBody
+	if len(body) == 0 || json.Unmarshal(body, &doc) != nil {
+		return httpError{}
+	}
+
+	code, details := doc.Code, doc.Message
+	// Servers may report "E004:details" in the error field, as in UQRP
+	if code == "" && strings.HasPrefix(doc.Error, "E0") && len(doc.Error) >= 4 {
+		code, doc.Error = doc.Error[:4], strings.TrimPrefix(doc.Error[4:], ":")
+	}
+	if details == "" {
+		details = doc.Error
+	}
+	if len(code) != 4 || !strings.HasPrefix(code, "E0") || code == CodeSuccess {
+		code = ""
+	}
+	return httpError{
+		Code:       code,
+		Error:      details,
+		Hint:       doc.Hint,
+		Doc:        doc.Doc,
+		RetryAfter: parseRetryAfter(strings.Trim(string(doc.RetryAfter), `"`)),
+	}
+}
+
+// PartialResultError reports that a result the server split into chunks
+// could only be read in part. The entries read before the failure are
+// returned alongside it.
+//
+// Example:
+//
+//	keys, err := client.List(ctx, "devices")
+//	var perr *resolvedb.PartialResultError
+//	if errors.As(err, &perr) {
+//	    log.Printf("listed %d keys from %d of %d chunks: %v", len(keys), perr.Fetched, perr.Total, perr.Err)
+//	}
+type PartialResultError struct {
+	Op      string // Operation (e.g., "list")
+	Fetched int    // Chunks read
+	Total   int    // Chunks in the result
+	Err     error  // Error reading the next chunk
+}
+
+func (e *PartialResultError) Error() string {
+	return fmt.Sprintf("resolvedb: %s: partial result, read %d of %d chunks: %v", e.Op, e.Fetched, e.Total, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *PartialResultError) Unwrap() error {
+	return e.Err
+}
+
+// ProtocolError reports a response that is not valid UQRP.
+type ProtocolError struct {
+	Err error // Underlying error
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("resolvedb: protocol: %v", e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ProtocolError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError reports a failure to decode response data into a Go value.
+type DecodeError struct {
+	Format string // Response data format (e.g., "json", "text")
+	Type   string // Destination Go type
+	Err    error  // Underlying error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("resolvedb: decode %s into %s: %v", e.Format, e.Type, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError reports a value that does not satisfy the schema
+// registered for its resource. No write is sent.
+type ValidationError struct {
+	Resource string // Resource being written
+	Key      string // Key being written
+	Path     string // Location of the violation (e.g., "$.interval")
+	Reason   string // Description of the violation
+}
+
+func (e *ValidationError) Error() string {
+	if e.Resource != "" {
+		return fmt.Sprintf("resolvedb: invalid %s/%s at %s: %s", e.Resource, e.Key, e.Path, e.Reason)
+	}
+	return fmt.Sprintf("resolvedb: invalid value at %s: %s", e.Path, e.Reason)
+}
+
 // IsRetryable checks if an error is retryable.
 func IsRetryable(err error) bool {
 	var e *Error
@@ -91,6 +422,26 @@ func IsRetryable(err error) bool {
 	return false
 }
 
+// RateLimitFromError returns the server-reported rate-limit state carried by
+// a rate-limit error, or nil if none is available.
+func RateLimitFromError(err error) *RateLimit {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.RateLimit
+	}
+	return nil
+}
+
+// RetryAfterFromError returns the server-suggested wait before retrying
+// carried by an error, or 0 if none is available.
+func RetryAfterFromError(err error) time.Duration {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.RetryAfter
+	}
+	return 0
+}
+
 // IsNotFound checks if an error indicates a resource was not found.
 func IsNotFound(err error) bool {
 	return errors.Is(err, ErrNotFound)
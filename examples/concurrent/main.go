@@ -1,11 +1,11 @@
-// Concurrent queries example - parallel requests with errgroup.
+// Concurrent queries example - parallel requests with GetGroup.
 package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"sync"
 
 	"github.com/resolvedb/resolvedb-go"
 )
@@ -24,44 +24,48 @@ func main() {
 	defer client.Close()
 
 	cities := []string{"tokyo", "paris", "london", "sydney", "quebec"}
-
-	// Use WaitGroup for parallel queries
-	var wg sync.WaitGroup
-	results := make(map[string]*Weather)
-	errors := make(map[string]error)
-	var mu sync.Mutex
-
 	ctx := context.Background()
 
-	for _, city := range cities {
-		wg.Add(1)
-		go func(city string) {
-			defer wg.Done()
-
-			var w Weather
-			err := client.Get(ctx, "weather", city, &w)
+	// Queue one read per city; Wait runs them concurrently
+	results := make([]Weather, len(cities))
+	group := resolvedb.NewGetGroup(client)
+	for i, city := range cities {
+		group.Add("weather", city, &results[i])
+	}
+	err = group.Wait(ctx)
 
-			mu.Lock()
-			if err != nil {
-				errors[city] = err
-			} else {
-				results[city] = &w
+	// By default every read runs to completion and Wait returns all errors
+	failed := make(map[string]error)
+	var getErr *resolvedb.GetError
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			if errors.As(e, &getErr) {
+				failed[getErr.Key] = getErr.Err
 			}
-			mu.Unlock()
-		}(city)
+		}
 	}
 
-	wg.Wait()
-
 	// Print results
 	fmt.Println("=== Weather Results ===")
-	for _, city := range cities {
-		if w, ok := results[city]; ok {
-			fmt.Printf("%-10s: %.1f°C\n", w.Location, w.TempC)
-		} else if err, ok := errors[city]; ok {
+	for i, city := range cities {
+		if err, ok := failed[city]; ok {
 			log.Printf("%-10s: error - %v", city, err)
+			continue
 		}
+		fmt.Printf("%-10s: %.1f°C\n", results[i].Location, results[i].TempC)
 	}
 
-	fmt.Printf("\nSuccessful: %d/%d\n", len(results), len(cities))
+	fmt.Printf("\nSuccessful: %d/%d\n", len(cities)-len(failed), len(cities))
+
+	// Fail fast: stop at the first error, e.g. when all values are needed
+	var paris, tokyo Weather
+	err = resolvedb.NewGetGroup(client, resolvedb.WithFailFast()).
+		Add("weather", "paris", &paris).
+		Add("weather", "tokyo", &tokyo).
+		Wait(ctx)
+	if err != nil {
+		log.Printf("comparison unavailable: %v", err)
+		return
+	}
+	fmt.Printf("\nParis is %.1f°C warmer than Tokyo\n", paris.TempC-tokyo.TempC)
 }
@@ -0,0 +1,51 @@
+// Demo example - run offline against built-in sample data.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/services/flags"
+	"github.com/resolvedb/resolvedb-go/services/geoip"
+	"github.com/resolvedb/resolvedb-go/services/weather"
+)
+
+func main() {
+	// Demo client - answers from sample data, no network needed
+	client, err := resolvedb.NewDemo()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+
+	// Service clients work unchanged
+	w, err := weather.NewClient(client).ByCity(ctx, "tokyo")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s: %.1f°C, %s\n", w.Location, w.TempC, w.Conditions)
+
+	loc, err := geoip.NewClient(client).Lookup(ctx, net.ParseIP("8.8.8.8"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("8.8.8.8 is in %s, %s\n", loc.City, loc.Country)
+
+	enabled, err := flags.NewClient(client).Get(ctx, "dark-mode")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("dark-mode enabled: %v\n", enabled)
+
+	// The sample keys of each dataset can be listed
+	cities, err := client.List(ctx, "weather")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Sample weather keys: %v\n", cities)
+}
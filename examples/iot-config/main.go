@@ -37,20 +37,23 @@ func main() {
 	}
 	fmt.Printf("Device BDT: %s\n\n", bdt.String())
 
-	// Query config using BDT (anonymous device identity)
+	// Query config using BDT (anonymous device identity), using defaults
+	// on error
+	defaults := DeviceConfig{
+		FirmwareVersion: "1.0.0",
+		ReportInterval:  60,
+		SensorThreshold: 25.0,
+		Enabled:         true,
+	}
 	var config DeviceConfig
-	err = client.Get(ctx, "device-config", "sensor-v1", &config,
+	err = client.GetOrDefault(ctx, "device-config", "sensor-v1", defaults, &config,
 		resolvedb.WithBDT(bdt.String()),
 	)
 	if err != nil {
-		log.Printf("Config fetch error: %v", err)
-		// Use defaults on error
-		config = DeviceConfig{
-			FirmwareVersion: "1.0.0",
-			ReportInterval:  60,
-			SensorThreshold: 25.0,
-			Enabled:         true,
-		}
+		log.Fatal(err)
+	}
+	if client.Stats().Fallbacks > 0 {
+		fmt.Println("Config unavailable, using defaults")
 	}
 
 	fmt.Println("Device Configuration:")
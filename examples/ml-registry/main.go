@@ -5,8 +5,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/registry"
 )
 
 // ModelConfig represents ML model deployment configuration.
@@ -33,20 +35,23 @@ func main() {
 
 	ctx := context.Background()
 
-	// Query model registry for deployment config
-	models := []string{"gpt-4-turbo", "embeddings-v3", "whisper-large"}
+	// Load the whole model registry once; lookups are then served from
+	// memory and the snapshot is refreshed in the background
+	models, err := registry.New[ModelConfig](ctx, client, "models",
+		registry.WithRefresh(time.Minute),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	names := []string{"gpt-4-turbo", "embeddings-v3", "whisper-large"}
 
 	fmt.Println("=== ML Model Registry ===")
 
-	for _, modelName := range models {
-		var config ModelConfig
-		err := client.Get(ctx, "models", modelName, &config)
-		if err != nil {
-			if resolvedb.IsNotFound(err) {
-				fmt.Printf("%s: not registered\n\n", modelName)
-			} else {
-				log.Printf("%s: error - %v\n\n", modelName, err)
-			}
+	for _, modelName := range names {
+		config, ok := models.Get(modelName)
+		if !ok {
+			fmt.Printf("%s: not registered\n\n", modelName)
 			continue
 		}
 
@@ -63,13 +68,7 @@ func main() {
 
 	// List all available models
 	fmt.Println("=== Available Models ===")
-	modelList, err := client.List(ctx, "models")
-	if err != nil {
-		log.Printf("List error: %v", err)
-		return
-	}
-
-	for _, m := range modelList {
+	for _, m := range models.Keys() {
 		fmt.Printf("  - %s\n", m)
 	}
 }
@@ -41,19 +41,21 @@ func main() {
 	}
 	fmt.Printf("NBA Signature: %s\n\n", nba.String())
 
-	// Query with NBA - cryptographically proves namespace binding
+	// Query with NBA - cryptographically proves namespace binding,
+	// falling back to defaults if the query fails
 	var config TenantConfig
 	err = client.Get(ctx, "config", "settings", &config,
 		resolvedb.WithNBA(nba.String()),
+		resolvedb.WithFallback(func() (any, error) {
+			return TenantConfig{
+				MaxUsers:       100,
+				Features:       []string{"basic"},
+				AllowedRegions: []string{"us-east-1"},
+			}, nil
+		}),
 	)
 	if err != nil {
-		log.Printf("Config fetch error: %v", err)
-		// Use defaults
-		config = TenantConfig{
-			MaxUsers:       100,
-			Features:       []string{"basic"},
-			AllowedRegions: []string{"us-east-1"},
-		}
+		log.Fatal(err)
 	}
 
 	fmt.Println("Tenant Configuration (acme-corp):")
@@ -1,11 +1,13 @@
-// Testing example - interface-based mocking for unit tests.
+// Testing example - interface-based mocking with resolvedbtest.MockClient.
 package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/resolvedbtest"
 )
 
 // WeatherService uses the Querier interface for testability.
@@ -31,52 +33,6 @@ func (s *WeatherService) GetTemperature(ctx context.Context, city string) (float
 	return w.TempC, nil
 }
 
-// MockClient implements Querier for testing.
-type MockClient struct {
-	responses map[string]any
-	errors    map[string]error
-}
-
-func NewMockClient() *MockClient {
-	return &MockClient{
-		responses: make(map[string]any),
-		errors:    make(map[string]error),
-	}
-}
-
-func (m *MockClient) SetResponse(resource, key string, response any) {
-	m.responses[resource+"/"+key] = response
-}
-
-func (m *MockClient) SetError(resource, key string, err error) {
-	m.errors[resource+"/"+key] = err
-}
-
-func (m *MockClient) Get(ctx context.Context, resource, key string, dst any, opts ...resolvedb.RequestOption) error {
-	k := resource + "/" + key
-	if err, ok := m.errors[k]; ok {
-		return err
-	}
-	if resp, ok := m.responses[k]; ok {
-		// Simple copy for demo (real impl would use reflection)
-		switch d := dst.(type) {
-		case *map[string]any:
-			if r, ok := resp.(map[string]any); ok {
-				*d = r
-			}
-		}
-	}
-	return nil
-}
-
-func (m *MockClient) GetRaw(ctx context.Context, resource, key string, opts ...resolvedb.RequestOption) (*resolvedb.Response, error) {
-	return nil, nil
-}
-
-func (m *MockClient) List(ctx context.Context, resource string, opts ...resolvedb.RequestOption) ([]string, error) {
-	return nil, nil
-}
-
 func main() {
 	// Production usage with real client
 	fmt.Println("=== Production Usage ===")
@@ -97,11 +53,17 @@ func main() {
 
 	// Test usage with mock
 	fmt.Println("\n=== Test Usage (Mock) ===")
-	mockClient := NewMockClient()
-	mockClient.SetResponse("weather", "test-city", map[string]any{"temp_c": 25.5})
+	mockClient := &resolvedbtest.MockClient{
+		GetFunc: func(ctx context.Context, resource, key string, dst any, opts ...resolvedb.RequestOption) error {
+			if key != "test-city" {
+				return resolvedb.ErrNotFound
+			}
+			return json.Unmarshal([]byte(`{"temp_c": 25.5}`), dst)
+		},
+	}
 
 	testService := NewWeatherService(mockClient)
-	_ = testService // In a real test, you would call testService.GetTemperature()
-	fmt.Println("Mock client configured for testing")
-	fmt.Println("Use interface-based design for easy mocking")
+	temp, err = testService.GetTemperature(context.Background(), "test-city")
+	fmt.Printf("Mock temperature: %.1f°C (err=%v)\n", temp, err)
+	fmt.Printf("Get called %d time(s)\n", mockClient.CallCount("Get"))
 }
@@ -0,0 +1,68 @@
+//go:build !resolvedb_nohttp
+
+package resolvedb
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// expvarClient is the client whose stats are published under "resolvedb".
+var (
+	expvarClient atomic.Pointer[Client]
+	expvarOnce   sync.Once
+)
+
+// PublishExpvars publishes the client's Stats under the "resolvedb" expvar,
+// served at /debug/vars when expvar's handler is mounted. Calling it again
+// publishes the new client in place of the previous one.
+func PublishExpvars(client *Client) {
+	expvarClient.Store(client)
+	expvarOnce.Do(func() {
+		expvar.Publish("resolvedb", expvar.Func(func() any {
+			if c := expvarClient.Load(); c != nil {
+				return c.Stats()
+			}
+			return nil
+		}))
+	})
+}
+
+// debugInfo is the document rendered by DebugHandler.
+type debugInfo struct {
+	Namespace string `json:"namespace"`
+	Version   string `json:"version"`
+	Transport string `json:"transport"`
+	Encrypted bool   `json:"encrypted"`
+	Stats     Stats  `json:"stats"`
+}
+
+// DebugHandler returns an http.Handler rendering the client's stats, cache
+// size and transport health as JSON. It exposes no keys or cached values
+// but should still be mounted on an internal listener only.
+//
+// Example:
+//
+//	http.Handle("/debug/resolvedb", resolvedb.DebugHandler(client))
+func DebugHandler(client *Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := debugInfo{
+			Namespace: client.config.defaultNamespace,
+			Version:   client.config.version,
+			Transport: client.transport.Name(),
+			Encrypted: client.transport.IsEncrypted(),
+			Stats:     client.Stats(),
+		}
+		if client.config.namespace != "" {
+			info.Namespace = client.config.namespace
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(info)
+	})
+}
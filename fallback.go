@@ -0,0 +1,91 @@
+package resolvedb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// WithFallback makes a failed Get degrade gracefully: instead of
+// returning the error, the read stores the value fn returns in dst and
+// succeeds. The value is assigned to dst if its type allows, directly or
+// through a pointer, and otherwise converted through JSON. Assigned maps
+// and slices are shared with dst, so fn should return a fresh value. If fn
+// fails, Get returns the read's error along with fn's.
+//
+// Each fallback used is counted in Stats().Fallbacks and logged at debug
+// level with the error it replaced.
+//
+// Example:
+//
+//	err := client.Get(ctx, "config", "limits", &limits,
+//	    resolvedb.WithFallback(func() (any, error) {
+//	        return Limits{MaxUsers: 100}, nil
+//	    }),
+//	)
+func WithFallback(fn func() (any, error)) RequestOption {
+	return func(c *requestConfig) {
+		c.fallback = fn
+	}
+}
+
+// GetOrDefault retrieves data for a resource and key into dst, storing
+// defaultVal in dst instead if the read fails, as with WithFallback. It
+// returns an error only if defaultVal cannot be stored in dst.
+//
+// Example:
+//
+//	var cfg TenantConfig
+//	err := client.GetOrDefault(ctx, "config", "settings", TenantConfig{MaxUsers: 100}, &cfg)
+func (c *Client) GetOrDefault(ctx context.Context, resource, key string, defaultVal, dst any, opts ...RequestOption) error {
+	opts = append(opts[:len(opts):len(opts)], WithFallback(func() (any, error) {
+		return defaultVal, nil
+	}))
+	return c.Get(ctx, resource, key, dst, opts...)
+}
+
+// useFallback stores the value of fallback in dst in place of a read that
+// failed with err.
+func (c *Client) useFallback(resource, key string, dst any, err error, fallback func() (any, error)) error {
+	v, ferr := fallback()
+	if ferr == nil {
+		ferr = assignFallback(v, dst)
+	}
+	if ferr != nil {
+		return fmt.Errorf("%w (fallback failed: %w)", err, ferr)
+	}
+	c.stats.fallbacks.Add(1)
+	c.logger().Debug("resolvedb: using fallback value", "resource", resource, "key", key, "error", err)
+	return nil
+}
+
+// assignFallback stores v in dst, a non-nil pointer: directly if v's type
+// is assignable to dst's element, also through a pointer, and otherwise
+// converted through JSON. A nil v leaves dst unchanged.
+func assignFallback(v, dst any) error {
+	d := reflect.ValueOf(dst)
+	if d.Kind() != reflect.Pointer || d.IsNil() {
+		return fmt.Errorf("destination must be a non-nil pointer, got %T", dst)
+	}
+	if v == nil {
+		return nil
+	}
+	elem := d.Elem()
+	src := reflect.ValueOf(v)
+	if src.Type().AssignableTo(elem.Type()) {
+		elem.Set(src)
+		return nil
+	}
+	if src.Kind() == reflect.Pointer && !src.IsNil() && src.Elem().Type().AssignableTo(elem.Type()) {
+		elem.Set(src.Elem())
+		return nil
+	}
+	data, err := marshalJSON(v)
+	if err != nil {
+		return fmt.Errorf("convert %T to %s: %w", v, elem.Type(), err)
+	}
+	if err := unmarshalJSON(data, dst); err != nil {
+		return fmt.Errorf("convert %T to %s: %w", v, elem.Type(), err)
+	}
+	return nil
+}
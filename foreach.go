@@ -0,0 +1,122 @@
+package resolvedb
+
+import (
+	"context"
+	"time"
+)
+
+// ForEachKey lists the keys of resource and calls fn with each key's value,
+// in list order, for batch jobs such as migrations and audits. Lookups are
+// paced to the budget set with WithQPS; when the server reports that the
+// rate-limit window is exhausted, ForEachKey waits for the window to reset.
+// Rate-limited lookups, and calls to fn that return a rate-limit error, are
+// retried per the client's retry configuration.
+//
+// Keys deleted while the job runs are skipped. ForEachKey stops at the
+// first other error and returns it.
+//
+// Example:
+//
+//	err := client.ForEachKey(ctx, "devices", func(ctx context.Context, key string, v *resolvedb.Response) error {
+//	    var d DeviceV1
+//	    if err := v.Unmarshal(&d); err != nil {
+//	        return err
+//	    }
+//	    return client.Set(ctx, "devices-v2", key, migrate(d))
+//	}, resolvedb.WithQPS(50))
+func (c *Client) ForEachKey(ctx context.Context, resource string, fn func(ctx context.Context, key string, value *Response) error, opts ...RequestOption) error {
+	keys, err := c.List(ctx, resource, opts...)
+	if err != nil {
+		return err
+	}
+
+	reqConfig := newRequestConfig(ctx, opts)
+	pace := newPacer(reqConfig.qps, c.config.clock)
+
+	for _, key := range keys {
+		r := c.newRetryer()
+		for {
+			if err := pace.wait(ctx); err != nil {
+				return err
+			}
+
+			err := c.forEachKey(ctx, resource, key, fn, opts)
+			if err == nil || IsNotFound(err) {
+				break
+			}
+			if !IsRateLimited(err) || r.attempt >= r.config.MaxRetries {
+				return err
+			}
+			if err := r.Wait(ctx, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// forEachKey fetches one key and passes it to fn, waiting out an exhausted
+// rate-limit window reported with the response.
+func (c *Client) forEachKey(ctx context.Context, resource, key string, fn func(context.Context, string, *Response) error, opts []RequestOption) error {
+	resp, err := c.GetRaw(ctx, resource, key, opts...)
+	if err != nil {
+		return err
+	}
+	if err := resp.ToError(); err != nil {
+		return err
+	}
+
+	if rl := resp.Meta.RateLimit; rl != nil && rl.Limit > 0 && rl.Remaining == 0 {
+		if err := sleepUntil(ctx, c.config.clock, rl.Reset); err != nil {
+			return err
+		}
+	}
+
+	return fn(ctx, key, resp)
+}
+
+// pacer spaces calls to a queries-per-second budget.
+type pacer struct {
+	interval time.Duration
+	next     time.Time
+	clock    Clock
+}
+
+// newPacer creates a pacer for qps calls per second on clock; qps <= 0
+// disables pacing.
+func newPacer(qps float64, clock Clock) *pacer {
+	if qps <= 0 {
+		return &pacer{clock: clock}
+	}
+	return &pacer{interval: time.Duration(float64(time.Second) / qps), clock: clock}
+}
+
+// wait blocks until the next call is allowed.
+func (p *pacer) wait(ctx context.Context) error {
+	if p.interval == 0 {
+		return ctx.Err()
+	}
+	now := p.clock.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	if err := sleepUntil(ctx, p.clock, p.next); err != nil {
+		return err
+	}
+	p.next = p.next.Add(p.interval)
+	return nil
+}
+
+// sleepUntil blocks until clock reaches t or until ctx is done.
+func sleepUntil(ctx context.Context, clock Clock, t time.Time) error {
+	d := t.Sub(clock.Now())
+	if d <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,74 @@
+package resolvedb
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Format is the format a value is stored in, reported as Response.Format
+// on reads.
+type Format string
+
+// Value formats.
+const (
+	FormatJSON   Format = "json"   // JSON, the default
+	FormatText   Format = "text"   // UTF-8 text, written from a string
+	FormatBinary Format = "binary" // Opaque bytes, written from a []byte
+)
+
+// Encoding is the encoding the server uses for a stored value in TXT
+// answers, reported as Response.Encoding on reads.
+type Encoding string
+
+// Value encodings.
+const (
+	EncodingBase64 Encoding = "base64" // The default
+	EncodingHex    Encoding = "hex"
+	EncodingPlain  Encoding = "plain" // Unencoded, readable with dig; text and JSON only
+)
+
+// marshalFormatted encodes data for a write with WithFormat or
+// WithEncoding: JSON as with Set, text from a string or []byte, and
+// binary from a []byte.
+func (c *Client) marshalFormatted(resource, key string, data any, reqConfig *requestConfig) ([]byte, error) {
+	format := reqConfig.format
+	if format == "" {
+		format = FormatJSON
+	}
+	switch reqConfig.encoding {
+	case "", EncodingBase64, EncodingHex:
+	case EncodingPlain:
+		if format == FormatBinary {
+			return nil, fmt.Errorf("encode data: binary values cannot use encoding %q", EncodingPlain)
+		}
+	default:
+		return nil, fmt.Errorf("encode data: unknown encoding %q", reqConfig.encoding)
+	}
+
+	switch format {
+	case FormatJSON:
+		return c.marshalValue(resource, key, data)
+	case FormatText:
+		var text []byte
+		switch v := data.(type) {
+		case string:
+			text = []byte(v)
+		case []byte:
+			text = v
+		default:
+			return nil, fmt.Errorf("encode data: format %q requires a string, got %T", format, data)
+		}
+		if !utf8.Valid(text) {
+			return nil, fmt.Errorf("encode data: format %q requires UTF-8", format)
+		}
+		return text, nil
+	case FormatBinary:
+		b, ok := data.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("encode data: format %q requires a []byte, got %T", format, data)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("encode data: unknown format %q", format)
+	}
+}
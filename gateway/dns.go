@@ -0,0 +1,203 @@
+package gateway
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+)
+
+// DNS message constants (RFC 1035, RFC 6891).
+const (
+	headerSize    = 12
+	maxNameLength = 255
+	maxTXTString  = 255
+
+	// minUDPSize is the UDP payload every resolver accepts; larger answers
+	// need EDNS or TCP.
+	minUDPSize = 512
+
+	// ednsUDPSize is the UDP payload size the gateway advertises.
+	ednsUDPSize = 1232
+
+	typeOPT = 41
+	classIN = 1
+
+	opcodeQuery = 0
+
+	rcodeSuccess     = 0
+	rcodeFormatError = 1
+	rcodeServFail    = transport.RcodeServerFailure
+	rcodeNotImp      = 4
+	rcodeRefused     = transport.RcodeRefused
+)
+
+// errFormat is returned for queries that are not well-formed.
+var errFormat = errors.New("gateway: malformed query")
+
+// question is a parsed DNS query.
+type question struct {
+	id       uint16
+	opcode   byte
+	rd       bool
+	name     string // Without trailing dot, as sent
+	wireName []byte // Encoded name, echoed in the answer
+	qtype    uint16
+	qclass   uint16
+	edns     bool
+	udpSize  int // Largest UDP answer the client accepts
+}
+
+// parseQuery parses a DNS query message with a single question.
+func parseQuery(msg []byte) (question, error) {
+	var q question
+	if len(msg) < headerSize {
+		return q, errFormat
+	}
+	q.id = uint16(msg[0])<<8 | uint16(msg[1])
+	if msg[2]&0x80 != 0 {
+		return q, errFormat // A response, not a query
+	}
+	q.opcode = (msg[2] >> 3) & 0x0F
+	q.rd = msg[2]&0x01 != 0
+	qdcount := int(msg[4])<<8 | int(msg[5])
+	arcount := int(msg[10])<<8 | int(msg[11])
+	if qdcount != 1 {
+		return q, errFormat
+	}
+
+	// Question name; queries carry no compression pointers
+	var labels []string
+	offset := headerSize
+	for {
+		if offset >= len(msg) {
+			return q, errFormat
+		}
+		n := int(msg[offset])
+		if n == 0 {
+			offset++
+			break
+		}
+		if n&0xC0 != 0 || offset+1+n > len(msg) {
+			return q, errFormat
+		}
+		label := string(msg[offset+1 : offset+1+n])
+		if strings.IndexByte(label, '.') >= 0 {
+			return q, errFormat
+		}
+		labels = append(labels, label)
+		offset += 1 + n
+		if offset-headerSize > maxNameLength {
+			return q, errFormat
+		}
+	}
+	q.wireName = msg[headerSize:offset]
+	q.name = strings.Join(labels, ".")
+	if offset+4 > len(msg) {
+		return q, errFormat
+	}
+	q.qtype = uint16(msg[offset])<<8 | uint16(msg[offset+1])
+	q.qclass = uint16(msg[offset+2])<<8 | uint16(msg[offset+3])
+	offset += 4
+
+	// EDNS OPT record in the additional section, if any (RFC 6891 6.1)
+	q.udpSize = minUDPSize
+	for i := 0; i < arcount; i++ {
+		if offset+11 > len(msg) || msg[offset] != 0 {
+			break // Only root-owned records (OPT) are of interest
+		}
+		rtype := uint16(msg[offset+1])<<8 | uint16(msg[offset+2])
+		class := int(msg[offset+3])<<8 | int(msg[offset+4])
+		rdlen := int(msg[offset+9])<<8 | int(msg[offset+10])
+		if rtype == typeOPT {
+			q.edns = true
+			q.udpSize = max(class, minUDPSize)
+			break
+		}
+		offset += 11 + rdlen
+	}
+	return q, nil
+}
+
+// appendResponse appends the answer to q to dst: the TXT records, or a
+// bare response with rcode when records is nil. Answers longer than
+// maxSize are replaced by an empty truncated response, so UDP clients
+// retry over TCP.
+func appendResponse(dst []byte, q question, rcode int, records [][]byte, ttl uint32, maxSize int) []byte {
+	start := len(dst)
+	dst = appendHeader(dst, q, rcode, len(records), false)
+	dst = append(dst, q.wireName...)
+	dst = append(dst, byte(q.qtype>>8), byte(q.qtype), byte(q.qclass>>8), byte(q.qclass))
+	for _, r := range records {
+		// Owner is a pointer to the question name
+		dst = append(dst, 0xC0, headerSize)
+		dst = append(dst, byte(transport.TypeTXT>>8), byte(transport.TypeTXT), 0, classIN)
+		dst = append(dst, byte(ttl>>24), byte(ttl>>16), byte(ttl>>8), byte(ttl))
+		rdlen := len(r) + (len(r)+maxTXTString-1)/maxTXTString
+		if len(r) == 0 {
+			rdlen = 1
+		}
+		dst = append(dst, byte(rdlen>>8), byte(rdlen))
+		dst = appendTXT(dst, r)
+	}
+	dst = appendOPT(dst, q)
+
+	if len(dst)-start > maxSize {
+		dst = appendHeader(dst[:start], q, rcode, 0, true)
+		dst = append(dst, q.wireName...)
+		dst = append(dst, byte(q.qtype>>8), byte(q.qtype), byte(q.qclass>>8), byte(q.qclass))
+		dst = appendOPT(dst, q)
+	}
+	return dst
+}
+
+// appendHeader appends a response header for q.
+func appendHeader(dst []byte, q question, rcode, ancount int, truncated bool) []byte {
+	flags := byte(0x80) | q.opcode<<3 // QR
+	if truncated {
+		flags |= 0x02
+	}
+	if q.rd {
+		flags |= 0x01
+	}
+	arcount := 0
+	if q.edns {
+		arcount = 1
+	}
+	return append(dst,
+		byte(q.id>>8), byte(q.id),
+		flags, 0x80|byte(rcode&0x0F), // RA
+		0, 1, // QDCOUNT
+		byte(ancount>>8), byte(ancount),
+		0, 0, // NSCOUNT
+		0, byte(arcount),
+	)
+}
+
+// appendTXT appends data as TXT RDATA, split into character-strings.
+func appendTXT(dst, data []byte) []byte {
+	if len(data) == 0 {
+		return append(dst, 0)
+	}
+	for len(data) > 0 {
+		n := min(len(data), maxTXTString)
+		dst = append(dst, byte(n))
+		dst = append(dst, data[:n]...)
+		data = data[n:]
+	}
+	return dst
+}
+
+// appendOPT appends the gateway's OPT record if the query used EDNS.
+func appendOPT(dst []byte, q question) []byte {
+	if !q.edns {
+		return dst
+	}
+	return append(dst,
+		0, // Root owner
+		0, typeOPT,
+		byte(ednsUDPSize>>8), byte(ednsUDPSize&0xFF),
+		0, 0, 0, 0, // Extended rcode, version and flags
+		0, 0, // RDLENGTH
+	)
+}
@@ -0,0 +1,418 @@
+// Package gateway serves ResolveDB over plain DNS on a local address, for
+// devices that can only send ordinary DNS queries. The gateway translates
+// each query for a ResolveDB name into a call on a client, which adds the
+// API key, encrypted transport, retries and caching, and answers with a
+// TXT record in the UQRP format the device would get from ResolveDB
+// itself.
+//
+// Reads (get, list and count) are served by default; put and delete need
+// WithWrites, as anyone who can reach the listener then writes with the
+// client's API key. Tokens the device sends (sig-, ctp-, bdt-, drt-) are
+// passed through. Other parameters are ignored: values and lists are
+// answered whole.
+//
+// Example:
+//
+//	client, _ := resolvedb.New(
+//	    resolvedb.WithAPIKey(os.Getenv("RESOLVEDB_API_KEY")),
+//	    resolvedb.WithCache(resolvedb.DefaultCacheConfig()),
+//	)
+//	gw := gateway.New(client)
+//	log.Fatal(gw.ListenAndServe(ctx, "127.0.0.1:5353"))
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/transport"
+	"github.com/resolvedb/resolvedb-go/uqrp"
+)
+
+// Gateway defaults.
+const (
+	defaultTimeout = 10 * time.Second
+	tcpIdleTimeout = 30 * time.Second
+	maxTCPMessage  = 65535
+)
+
+// Server is a DNS gateway to ResolveDB. It is safe for concurrent use.
+type Server struct {
+	client   resolvedb.FullClient
+	parser   *uqrp.Parser
+	layout   uqrp.Layout
+	writes   bool
+	timeout  time.Duration
+	reqOpts  []resolvedb.RequestOption
+	logger   *slog.Logger
+	handlers sync.WaitGroup
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithLayout sets the query name layout devices use (default:
+// uqrp.DefaultLayout). It need not match the client's: a gateway can
+// answer names under a local zone, e.g. get.tokyo.weather.public.rdb.lan.
+func WithLayout(layout uqrp.Layout) Option {
+	return func(s *Server) {
+		s.layout = layout
+	}
+}
+
+// WithWrites allows put and delete queries, which are otherwise refused.
+func WithWrites() Option {
+	return func(s *Server) {
+		s.writes = true
+	}
+}
+
+// WithTimeout bounds the upstream call made for each query (default: 10s).
+func WithTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = d
+	}
+}
+
+// WithRequestOptions adds options to every upstream call.
+func WithRequestOptions(opts ...resolvedb.RequestOption) Option {
+	return func(s *Server) {
+		s.reqOpts = append(s.reqOpts, opts...)
+	}
+}
+
+// WithLogger sets the logger for failed queries and connections (default:
+// discard).
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// New creates a gateway answering with client.
+func New(client resolvedb.FullClient, opts ...Option) *Server {
+	s := &Server{
+		client:  client,
+		layout:  uqrp.DefaultLayout(),
+		timeout: defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.logger == nil {
+		s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	s.parser = uqrp.NewParser(s.layout)
+	return s
+}
+
+// ListenAndServe serves DNS over UDP and TCP on addr until ctx is
+// cancelled, then waits for queries in progress and returns ctx's error.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	var lc net.ListenConfig
+	conn, err := lc.ListenPacket(ctx, "udp", addr)
+	if err != nil {
+		return err
+	}
+	ln, err := lc.Listen(ctx, "tcp", conn.LocalAddr().String())
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- s.ServeUDP(ctx, conn) }()
+	go func() { errs <- s.ServeTCP(ctx, ln) }()
+	err = <-errs
+	if ctx.Err() == nil {
+		// One listener failed; stop the other
+		conn.Close()
+		ln.Close()
+	}
+	<-errs
+	return err
+}
+
+// ServeUDP answers queries read from conn until ctx is cancelled or conn
+// fails. conn is closed when ServeUDP returns.
+func (s *Server) ServeUDP(ctx context.Context, conn net.PacketConn) error {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer s.handlers.Wait()
+	defer conn.Close()
+
+	buf := make([]byte, maxTCPMessage)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			var nerr net.Error
+			if errors.As(err, &nerr) && nerr.Timeout() {
+				continue
+			}
+			return err
+		}
+		msg := bytes.Clone(buf[:n])
+		s.handlers.Add(1)
+		go func() {
+			defer s.handlers.Done()
+			if resp := s.handle(ctx, msg, false); resp != nil {
+				if _, err := conn.WriteTo(resp, addr); err != nil {
+					s.logger.Debug("gateway: write failed", "addr", addr, "error", err)
+				}
+			}
+		}()
+	}
+}
+
+// ServeTCP answers queries on connections accepted from ln until ctx is
+// cancelled or ln fails. ln is closed when ServeTCP returns.
+func (s *Server) ServeTCP(ctx context.Context, ln net.Listener) error {
+	stop := context.AfterFunc(ctx, func() { ln.Close() })
+	defer stop()
+	defer s.handlers.Wait()
+	defer ln.Close()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			var nerr net.Error
+			if errors.As(err, &nerr) && nerr.Timeout() {
+				continue
+			}
+			return err
+		}
+		s.handlers.Add(1)
+		go func() {
+			defer s.handlers.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// serveConn answers the length-prefixed queries on a TCP connection in
+// order (RFC 7766), closing it when idle or when ctx is cancelled.
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+
+	var prefix [2]byte
+	for {
+		conn.SetReadDeadline(time.Now().Add(tcpIdleTimeout))
+		if _, err := io.ReadFull(conn, prefix[:]); err != nil {
+			return
+		}
+		msg := make([]byte, binary.BigEndian.Uint16(prefix[:]))
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+		resp := s.handle(ctx, msg, true)
+		if resp == nil {
+			return
+		}
+		out := binary.BigEndian.AppendUint16(make([]byte, 0, 2+len(resp)), uint16(len(resp)))
+		if _, err := conn.Write(append(out, resp...)); err != nil {
+			s.logger.Debug("gateway: write failed", "addr", conn.RemoteAddr(), "error", err)
+			return
+		}
+	}
+}
+
+// handle answers a DNS query message, or returns nil if it cannot be
+// answered at all.
+func (s *Server) handle(ctx context.Context, msg []byte, tcp bool) []byte {
+	q, err := parseQuery(msg)
+	if err != nil {
+		if len(msg) < headerSize || msg[2]&0x80 != 0 {
+			return nil
+		}
+		// Echo the ID so the client can match the error
+		resp := appendHeader(nil, question{id: q.id, opcode: q.opcode, rd: q.rd}, rcodeFormatError, 0, false)
+		resp[5] = 0 // No question
+		return resp
+	}
+
+	maxSize := q.udpSize
+	if tcp {
+		maxSize = maxTCPMessage
+	}
+	if q.opcode != opcodeQuery {
+		return appendResponse(nil, q, rcodeNotImp, nil, 0, maxSize)
+	}
+
+	rcode, record, ttl := s.answer(ctx, q)
+	var records [][]byte
+	if record != nil {
+		records = [][]byte{record}
+	}
+	resp := appendResponse(make([]byte, 0, 64+len(record)), q, rcode, records, ttl, maxSize)
+	if tcp && resp[2]&0x02 != 0 {
+		s.logger.Warn("gateway: answer exceeds DNS message size", "name", q.name, "size", len(record))
+		return appendResponse(nil, q, rcodeServFail, nil, 0, maxSize)
+	}
+	return resp
+}
+
+// answer translates a question into a client call and returns the DNS
+// response code, the UQRP TXT record (nil for none) and its TTL.
+func (s *Server) answer(ctx context.Context, q question) (rcode int, record []byte, ttl uint32) {
+	uq, err := s.parser.Parse(q.name)
+	if err != nil {
+		return rcodeRefused, nil, 0
+	}
+	if q.qtype != transport.TypeTXT || q.qclass != classIN {
+		// The name exists, but only as TXT
+		return rcodeSuccess, nil, 0
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	opts := s.requestOptions(uq)
+
+	switch uq.Operation {
+	case uqrp.OpGet:
+		resp, err := s.client.GetRaw(ctx, uq.Resource, uq.Key, opts...)
+		if err != nil {
+			return s.errorAnswer(uq, err)
+		}
+		defer resp.Release()
+		if err := resp.ToError(); err != nil {
+			return s.errorAnswer(uq, err)
+		}
+		return rcodeSuccess, valueRecord(resp), uint32(resp.TTL / time.Second)
+	case uqrp.OpList:
+		keys, err := s.client.List(ctx, uq.Resource, opts...)
+		if err != nil {
+			return s.errorAnswer(uq, err)
+		}
+		if keys == nil {
+			keys = []string{}
+		}
+		data, err := json.Marshal(keys)
+		if err != nil {
+			return s.errorAnswer(uq, err)
+		}
+		return rcodeSuccess, []byte("v=rdb1;s=ok;t=json;e=base64;d=" + base64.RawURLEncoding.EncodeToString(data)), 0
+	case uqrp.OpCount:
+		n, err := s.client.Count(ctx, uq.Resource, opts...)
+		if err != nil {
+			return s.errorAnswer(uq, err)
+		}
+		return rcodeSuccess, []byte("v=rdb1;s=ok;t=json;d=" + strconv.Itoa(n)), 0
+	case uqrp.OpPut, uqrp.OpDelete:
+		if !s.writes {
+			return rcodeSuccess, errorRecord(resolvedb.CodeForbidden, "writes are disabled on this gateway"), 0
+		}
+		if uq.Operation == uqrp.OpPut {
+			if uq.Data == nil || !json.Valid(uq.Data) {
+				return rcodeSuccess, errorRecord(resolvedb.CodeInvalidFormat, "put data must be JSON"), 0
+			}
+			err = s.client.Set(ctx, uq.Resource, uq.Key, json.RawMessage(uq.Data), opts...)
+		} else {
+			err = s.client.Delete(ctx, uq.Resource, uq.Key, opts...)
+		}
+		if err != nil {
+			return s.errorAnswer(uq, err)
+		}
+		return rcodeSuccess, []byte("v=rdb1;s=ok"), 0
+	default:
+		return rcodeNotImp, nil, 0
+	}
+}
+
+// requestOptions returns the options for the upstream call of uq: the
+// device's tokens and namespace, followed by the gateway's options.
+func (s *Server) requestOptions(uq uqrp.Query) []resolvedb.RequestOption {
+	var opts []resolvedb.RequestOption
+	if uq.Namespace != s.layout.DefaultNamespace {
+		opts = append(opts, resolvedb.WithRequestNamespace(uq.Namespace))
+	}
+	for _, t := range uq.Tokens {
+		switch lower := strings.ToLower(t); {
+		case strings.HasPrefix(lower, uqrp.PrefixSig):
+			opts = append(opts, resolvedb.WithNBA(t))
+		case strings.HasPrefix(lower, uqrp.PrefixCTP):
+			opts = append(opts, resolvedb.WithCTP(t))
+		case strings.HasPrefix(lower, uqrp.PrefixBDT):
+			opts = append(opts, resolvedb.WithBDT(t))
+		case strings.HasPrefix(lower, uqrp.PrefixReadToken):
+			opts = append(opts, resolvedb.WithReadToken(t))
+		}
+	}
+	return append(opts, s.reqOpts...)
+}
+
+// errorAnswer returns the answer for a failed upstream call: a UQRP error
+// record for protocol errors, which the device would have received from
+// ResolveDB too, and SERVFAIL otherwise, so the device retries.
+func (s *Server) errorAnswer(uq uqrp.Query, err error) (int, []byte, uint32) {
+	var perr *resolvedb.Error
+	switch {
+	case errors.As(err, &perr):
+		msg := perr.Details
+		if msg == "" {
+			msg = perr.Message
+		}
+		return rcodeSuccess, errorRecord(perr.Code, msg), 0
+	case errors.Is(err, resolvedb.ErrACLDenied):
+		return rcodeSuccess, errorRecord(resolvedb.CodeForbidden, "denied by gateway policy"), 0
+	default:
+		s.logger.Warn("gateway: upstream query failed",
+			"op", string(uq.Operation), "resource", uq.Resource, "key", uq.Key, "error", err)
+		return rcodeServFail, nil, 0
+	}
+}
+
+// valueRecord encodes a response as a UQRP record carrying its data in
+// base64.
+func valueRecord(resp *resolvedb.Response) []byte {
+	var b strings.Builder
+	b.WriteString("v=rdb1;s=ok")
+	writeField(&b, "t", resp.Type)
+	writeField(&b, "f", resp.Format)
+	if resp.TTL > 0 {
+		writeField(&b, "ttl", strconv.FormatInt(int64(resp.TTL/time.Second), 10))
+	}
+	writeField(&b, "hash", resp.Hash)
+	if !resp.Expires.IsZero() {
+		writeField(&b, "exp", strconv.FormatInt(resp.Expires.Unix(), 10))
+	}
+	writeField(&b, "cst", resp.Meta.ConsistencyToken)
+	b.WriteString(";e=base64;d=")
+	b.WriteString(base64.RawURLEncoding.EncodeToString(resp.Data))
+	return []byte(b.String())
+}
+
+// errorRecord encodes a UQRP error record.
+func errorRecord(code, msg string) []byte {
+	return []byte("v=rdb1;s=" + code + ";err=" + strings.ReplaceAll(msg, ";", ","))
+}
+
+// writeField writes a UQRP field, skipping empty values.
+func writeField(b *strings.Builder, key, value string) {
+	if value == "" {
+		return
+	}
+	b.WriteByte(';')
+	b.WriteString(key)
+	b.WriteByte('=')
+	b.WriteString(strings.ReplaceAll(value, ";", ","))
+}
@@ -0,0 +1,185 @@
+// Package geokey quantizes coordinates into keys for location-keyed
+// datasets such as weather and reverse geocoding. Coordinates in the same
+// cell share a key, so nearby lookups share cache entries and the number
+// of distinct keys a fleet of clients produces stays bounded.
+//
+// Two schemes are supported. Grid rounds latitude and longitude to a
+// number of decimal places and keys the cell as "<lat>,<lon>":
+//
+//	Decimals  Cell (at the equator)
+//	0         1°       ~111 km
+//	1         0.1°     ~11 km
+//	2         0.01°    ~1.1 km
+//	3         0.001°   ~111 m
+//	4         0.0001°  ~11 m    (the default, and the historical key format)
+//	5         0.00001° ~1.1 m
+//
+// Geohash keys the geohash cell containing the point as "gh-<hash>":
+//
+//	Length  Cell
+//	3       ~156 km x 156 km
+//	4       ~39 km x 20 km
+//	5       ~4.9 km x 4.9 km
+//	6       ~1.2 km x 0.6 km
+//	7       ~153 m x 153 m
+//	8       ~38 m x 19 m
+//
+// Both are deterministic: latitudes are clamped to [-90, 90], longitudes
+// wrapped into [-180, 180), and grid values rounded half away from zero,
+// so every client and SDK maps a point to the same key. Longitude cells
+// narrow away from the equator by the cosine of the latitude.
+//
+// Example:
+//
+//	q := geokey.Geohash(5)
+//	key := q.Key(46.8139, -71.2080) // "gh-f2m67"
+package geokey
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Limits of the precision levels; constructors clamp to them.
+const (
+	MaxGridDecimals  = 5
+	MaxGeohashLength = 12
+)
+
+// DefaultGridDecimals is the precision of the zero Quantizer.
+const DefaultGridDecimals = 4
+
+// geohashAlphabet is the geohash base32 alphabet.
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// geohashPrefix marks geohash keys, so they cannot be mistaken for names.
+const geohashPrefix = "gh-"
+
+type scheme int
+
+const (
+	schemeGrid scheme = iota
+	schemeGeohash
+)
+
+// Quantizer maps coordinates to cell keys. The zero value is
+// Grid(DefaultGridDecimals).
+type Quantizer struct {
+	scheme    scheme
+	precision int // Decimals or geohash length; 0 for the default grid
+	set       bool
+}
+
+// Grid returns a quantizer that rounds to decimals decimal places, clamped
+// to [0, MaxGridDecimals].
+func Grid(decimals int) Quantizer {
+	return Quantizer{scheme: schemeGrid, precision: min(max(decimals, 0), MaxGridDecimals), set: true}
+}
+
+// Geohash returns a quantizer keyed by geohash cells of length characters,
+// clamped to [1, MaxGeohashLength].
+func Geohash(length int) Quantizer {
+	return Quantizer{scheme: schemeGeohash, precision: min(max(length, 1), MaxGeohashLength), set: true}
+}
+
+// Key returns the key of the cell containing lat, lon.
+func (q Quantizer) Key(lat, lon float64) string {
+	lat = math.Max(-90, math.Min(90, lat))
+	lon = wrapLongitude(lon)
+	if q.scheme == schemeGeohash {
+		return geohashPrefix + encodeGeohash(lat, lon, q.precision)
+	}
+	d := q.decimals()
+	return formatDegrees(lat, d) + "," + formatDegrees(wrapLongitude(roundTo(lon, d)), d)
+}
+
+// CellSize returns the size of the quantizer's cells in degrees of
+// latitude and longitude.
+func (q Quantizer) CellSize() (lat, lon float64) {
+	if q.scheme == schemeGeohash {
+		bits := 5 * q.precision
+		lonBits := (bits + 1) / 2
+		latBits := bits / 2
+		return 180 / math.Exp2(float64(latBits)), 360 / math.Exp2(float64(lonBits))
+	}
+	size := math.Pow(10, -float64(q.decimals()))
+	return size, size
+}
+
+// String returns the scheme and precision, e.g. "grid-4" or "geohash-6".
+func (q Quantizer) String() string {
+	if q.scheme == schemeGeohash {
+		return fmt.Sprintf("geohash-%d", q.precision)
+	}
+	return fmt.Sprintf("grid-%d", q.decimals())
+}
+
+func (q Quantizer) decimals() int {
+	if !q.set {
+		return DefaultGridDecimals
+	}
+	return q.precision
+}
+
+// wrapLongitude wraps lon into [-180, 180).
+func wrapLongitude(lon float64) float64 {
+	if lon >= -180 && lon < 180 {
+		return lon
+	}
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// roundTo rounds v to d decimal places, half away from zero.
+func roundTo(v float64, d int) float64 {
+	scale := math.Pow(10, float64(d))
+	return math.Round(v*scale) / scale
+}
+
+// formatDegrees rounds v to d decimal places and formats it without a
+// negative zero.
+func formatDegrees(v float64, d int) string {
+	v = roundTo(v, d)
+	if v == 0 {
+		v = 0 // Drop the sign of -0
+	}
+	return strconv.FormatFloat(v, 'f', d, 64)
+}
+
+// encodeGeohash returns the geohash of lat, lon with length characters.
+func encodeGeohash(lat, lon float64, length int) string {
+	latLo, latHi := -90.0, 90.0
+	lonLo, lonHi := -180.0, 180.0
+	hash := make([]byte, length)
+	even := true // Bits alternate, starting with longitude
+	for i := range hash {
+		var ch int
+		for bit := 0; bit < 5; bit++ {
+			ch <<= 1
+			if even {
+				mid := (lonLo + lonHi) / 2
+				if lon >= mid {
+					ch |= 1
+					lonLo = mid
+				} else {
+					lonHi = mid
+				}
+			} else {
+				mid := (latLo + latHi) / 2
+				if lat >= mid {
+					ch |= 1
+					latLo = mid
+				} else {
+					latHi = mid
+				}
+			}
+			even = !even
+		}
+		hash[i] = geohashAlphabet[ch]
+	}
+	return string(hash)
+}
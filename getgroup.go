@@ -0,0 +1,145 @@
+package resolvedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// GetGroup runs a set of reads of different resources concurrently and
+// waits for them with a shared error policy. Unlike Batch, it works with
+// any Querier and can stop at the first failure.
+//
+// A GetGroup is built and waited on by one goroutine; it must not be
+// reused after Wait.
+type GetGroup struct {
+	q        Querier
+	gets     []groupGet
+	failFast bool
+	limit    int
+}
+
+type groupGet struct {
+	resource string
+	key      string
+	dst      any
+	opts     []RequestOption
+}
+
+// GetGroupOption configures a GetGroup.
+type GetGroupOption func(*GetGroup)
+
+// WithFailFast makes Wait return the first error and cancel the reads
+// still running. By default every read runs to completion and Wait
+// returns all errors.
+func WithFailFast() GetGroupOption {
+	return func(g *GetGroup) {
+		g.failFast = true
+	}
+}
+
+// WithGroupConcurrency limits how many reads run at once (default
+// unlimited).
+func WithGroupConcurrency(n int) GetGroupOption {
+	return func(g *GetGroup) {
+		g.limit = n
+	}
+}
+
+// GetError is the error of one read in a GetGroup.
+type GetError struct {
+	Resource string
+	Key      string
+	Err      error
+}
+
+func (e *GetError) Error() string {
+	return fmt.Sprintf("get %s/%s: %v", e.Resource, e.Key, e.Err)
+}
+
+func (e *GetError) Unwrap() error {
+	return e.Err
+}
+
+// NewGetGroup returns an empty group reading through q.
+//
+// Example:
+//
+//	var w Weather
+//	var loc geoip.Location
+//	err := resolvedb.NewGetGroup(client).
+//	    Add("weather", "paris", &w).
+//	    Add("geoip", "1-1-1-1", &loc).
+//	    Wait(ctx)
+func NewGetGroup(q Querier, opts ...GetGroupOption) *GetGroup {
+	g := &GetGroup{q: q}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Add queues a read of resource/key into dst.
+func (g *GetGroup) Add(resource, key string, dst any, opts ...RequestOption) *GetGroup {
+	g.gets = append(g.gets, groupGet{resource: resource, key: key, dst: dst, opts: opts})
+	return g
+}
+
+// Len returns the number of queued reads.
+func (g *GetGroup) Len() int {
+	return len(g.gets)
+}
+
+// Wait runs the queued reads and waits for them to finish. Errors are
+// *GetError values naming the failed read. By default Wait returns all of
+// them joined, in the order the reads were added, so errors.Is and
+// errors.As see through to each; with WithFailFast it returns the first to
+// occur.
+func (g *GetGroup) Wait(ctx context.Context) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	errs := make([]error, len(g.gets))
+	var first error
+	var once sync.Once
+
+	var sem chan struct{}
+	if g.limit > 0 {
+		sem = make(chan struct{}, g.limit)
+	}
+
+	var wg sync.WaitGroup
+	for i, get := range g.gets {
+		if sem != nil {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = &GetError{Resource: get.resource, Key: get.key, Err: context.Cause(ctx)}
+				continue
+			}
+		}
+		wg.Add(1)
+		go func(i int, get groupGet) {
+			defer wg.Done()
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			if err := g.q.Get(ctx, get.resource, get.key, get.dst, get.opts...); err != nil {
+				errs[i] = &GetError{Resource: get.resource, Key: get.key, Err: err}
+				if g.failFast {
+					once.Do(func() {
+						first = errs[i]
+						cancel(first)
+					})
+				}
+			}
+		}(i, get)
+	}
+	wg.Wait()
+
+	if first != nil {
+		return first
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,139 @@
+package resolvedb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// heartbeatExpiryFactor is the number of intervals a heartbeat record
+// outlives its last renewal, so one missed renewal does not drop it.
+const heartbeatExpiryFactor = 3
+
+// Heartbeat maintains an ephemeral presence record, renewing it in the
+// background until closed.
+type Heartbeat struct {
+	client   *Client
+	resource string
+	key      string
+	interval time.Duration
+	opts     []RequestOption
+
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+
+	mu      sync.Mutex
+	payload any
+	lastErr error
+}
+
+// Heartbeat writes payload to resource/key with an expiry of three
+// intervals and renews it every interval until ctx is done or the returned
+// Heartbeat is closed. If the process dies, the record expires on its own;
+// Close deletes it immediately. The first write happens before Heartbeat
+// returns, and its error is returned. The interval must be positive.
+//
+// Example:
+//
+//	hb, err := client.Heartbeat(ctx, "presence", deviceID, 30*time.Second, Status{State: "online"})
+//	if err != nil {
+//	    return err
+//	}
+//	defer hb.Close()
+func (c *Client) Heartbeat(ctx context.Context, resource, key string, interval time.Duration, payload any, opts ...RequestOption) (*Heartbeat, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("resolvedb: heartbeat interval %v is not positive", interval)
+	}
+	ctx, release, ok := c.background(ctx)
+	if !ok {
+		return nil, ErrClosed
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	hb := &Heartbeat{
+		client:   c,
+		resource: resource,
+		key:      key,
+		interval: interval,
+		opts:     opts,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		payload:  payload,
+	}
+
+	if err := hb.beat(ctx); err != nil {
+		cancel()
+		release()
+		return nil, err
+	}
+
+	go func() {
+		defer release()
+		hb.run(ctx)
+	}()
+	return hb, nil
+}
+
+// Update replaces the payload written on the next renewal.
+func (h *Heartbeat) Update(payload any) {
+	h.mu.Lock()
+	h.payload = payload
+	h.mu.Unlock()
+}
+
+// Err returns the error of the most recent renewal, or nil if it succeeded.
+func (h *Heartbeat) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastErr
+}
+
+// Close stops renewal and deletes the record. Closing the client stops
+// renewal too, leaving the record to expire; Close then returns ErrClosed.
+func (h *Heartbeat) Close() error {
+	h.closeOnce.Do(func() {
+		h.cancel()
+		<-h.done
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.interval)
+		defer cancel()
+		h.closeErr = h.client.Delete(ctx, h.resource, h.key, append(h.opts, WithIgnoreNotFound())...)
+	})
+	return h.closeErr
+}
+
+// run renews the record every interval until ctx is done.
+func (h *Heartbeat) run(ctx context.Context) {
+	defer close(h.done)
+
+	clock := h.client.config.clock
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(h.interval):
+		}
+
+		if err := h.beat(ctx); err != nil && ctx.Err() == nil {
+			h.client.logger().Warn("resolvedb: heartbeat renewal failed",
+				"resource", h.resource, "key", h.key, "error", err)
+		}
+	}
+}
+
+// beat writes the record once.
+func (h *Heartbeat) beat(ctx context.Context) error {
+	h.mu.Lock()
+	payload := h.payload
+	h.mu.Unlock()
+
+	opts := append(append([]RequestOption(nil), h.opts...), WithExpiry(heartbeatExpiryFactor*h.interval))
+	err := h.client.Set(ctx, h.resource, h.key, payload, opts...)
+
+	h.mu.Lock()
+	h.lastErr = err
+	h.mu.Unlock()
+	return err
+}
@@ -0,0 +1,381 @@
+// Package httpapi exposes a ResolveDB client as a small REST API, so
+// services without an SDK can use ResolveDB through a localhost sidecar.
+//
+// Routes, relative to where the handler is mounted:
+//
+//	GET    /{resource}        List keys, as a JSON array
+//	GET    /{resource}/{key}  Read a value; the body is the stored data
+//	PUT    /{resource}/{key}  Write a JSON value (needs WithWrites)
+//	DELETE /{resource}/{key}  Delete a key (needs WithWrites)
+//
+// Reads go through the client's cache, except reads carrying a read token,
+// BDT, CTP or NBA, which bypass it and are marked private to HTTP caches,
+// so values fetched with one caller's credentials are never served to
+// another. Callers pass their own credentials with the headers below,
+// which become request options; the client's API key signs writes.
+//
+//	X-ResolveDB-Namespace    WithRequestNamespace
+//	X-ResolveDB-Read-Token   WithReadToken
+//	X-ResolveDB-BDT          WithBDT
+//	X-ResolveDB-CTP          WithCTP
+//	X-ResolveDB-NBA          WithNBA
+//	Idempotency-Key          WithIdempotencyKey (writes)
+//	Cache-Control: no-cache  WithRefreshCache (reads)
+//
+// Errors are JSON documents with the protocol error code, e.g.
+// {"code":"E004","error":"resource not found"}, and a matching status.
+//
+// Example:
+//
+//	http.Handle("/v1/", http.StripPrefix("/v1", httpapi.NewHandler(client)))
+//	log.Fatal(http.ListenAndServe("127.0.0.1:8080", nil))
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// Request headers mapped to request options.
+const (
+	HeaderNamespace = "X-ResolveDB-Namespace"
+	HeaderReadToken = "X-ResolveDB-Read-Token"
+	HeaderBDT       = "X-ResolveDB-BDT"
+	HeaderCTP       = "X-ResolveDB-CTP"
+	HeaderNBA       = "X-ResolveDB-NBA"
+)
+
+// credentialHeaders are the request headers passed through as request
+// options. Those marked token carry a caller's credentials.
+var credentialHeaders = []struct {
+	name  string
+	opt   func(string) resolvedb.RequestOption
+	token bool
+}{
+	{HeaderNamespace, resolvedb.WithRequestNamespace, false},
+	{HeaderReadToken, resolvedb.WithReadToken, true},
+	{HeaderBDT, resolvedb.WithBDT, true},
+	{HeaderCTP, resolvedb.WithCTP, true},
+	{HeaderNBA, resolvedb.WithNBA, true},
+}
+
+// Handler defaults.
+const (
+	defaultMaxBodyBytes = 1 << 20
+	defaultTimeout      = 30 * time.Second
+)
+
+// Handler serves the REST API. It is safe for concurrent use.
+type Handler struct {
+	client       resolvedb.FullClient
+	writes       bool
+	maxBodyBytes int64
+	timeout      time.Duration
+	reqOpts      []resolvedb.RequestOption
+	logger       *slog.Logger
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithWrites allows PUT and DELETE, which otherwise fail with 405. Anyone
+// who can reach the handler then writes with the client's API key.
+func WithWrites() Option {
+	return func(h *Handler) {
+		h.writes = true
+	}
+}
+
+// WithMaxBodyBytes bounds the size of PUT bodies (default: 1 MiB).
+func WithMaxBodyBytes(n int64) Option {
+	return func(h *Handler) {
+		h.maxBodyBytes = n
+	}
+}
+
+// WithTimeout bounds the client call made for each request (default: 30s).
+func WithTimeout(d time.Duration) Option {
+	return func(h *Handler) {
+		h.timeout = d
+	}
+}
+
+// WithRequestOptions adds options to every client call.
+func WithRequestOptions(opts ...resolvedb.RequestOption) Option {
+	return func(h *Handler) {
+		h.reqOpts = append(h.reqOpts, opts...)
+	}
+}
+
+// WithLogger sets the logger for failed client calls (default: discard).
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *Handler) {
+		h.logger = logger
+	}
+}
+
+// NewHandler creates a handler serving client.
+func NewHandler(client resolvedb.FullClient, opts ...Option) *Handler {
+	h := &Handler{
+		client:       client,
+		maxBodyBytes: defaultMaxBodyBytes,
+		timeout:      defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.logger == nil {
+		h.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return h
+}
+
+// ServeHTTP routes a request to the client.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resource, key, ok := splitPath(r.URL.Path)
+	if !ok {
+		writeError(w, http.StatusNotFound, resolvedb.CodeBadRequest, "expected /{resource} or /{resource}/{key}")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		h.list(ctx, w, r, resource)
+	case r.Method == http.MethodGet:
+		h.get(ctx, w, r, resource, key)
+	case r.Method == http.MethodPut && key != "" && h.writes:
+		h.put(ctx, w, r, resource, key)
+	case r.Method == http.MethodDelete && key != "" && h.writes:
+		h.delete(ctx, w, r, resource, key)
+	default:
+		allow := "GET"
+		if key != "" && h.writes {
+			allow = "GET, PUT, DELETE"
+		}
+		w.Header().Set("Allow", allow)
+		writeError(w, http.StatusMethodNotAllowed, resolvedb.CodeForbidden, r.Method+" is not allowed here")
+	}
+}
+
+// get writes the stored data of a key.
+func (h *Handler) get(ctx context.Context, w http.ResponseWriter, r *http.Request, resource, key string) {
+	resp, err := h.client.GetRaw(ctx, resource, key, h.readOptions(r)...)
+	if err == nil {
+		defer resp.Release()
+		err = resp.ToError()
+	}
+	if err != nil {
+		h.writeClientError(w, "get", resource, key, err)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", contentType(resp.Type))
+	if resp.TTL > 0 {
+		cacheControl := "max-age=" + strconv.FormatInt(int64(resp.TTL/time.Second), 10)
+		if hasToken(r) {
+			cacheControl = "private, " + cacheControl
+		}
+		header.Set("Cache-Control", cacheControl)
+	}
+	if resp.Hash != "" {
+		header.Set("ETag", strconv.Quote(resp.Hash))
+	}
+	if !resp.Expires.IsZero() {
+		header.Set("Expires", resp.Expires.UTC().Format(http.TimeFormat))
+	}
+	w.Write(resp.Data)
+}
+
+// list writes the keys of a resource as a JSON array.
+func (h *Handler) list(ctx context.Context, w http.ResponseWriter, r *http.Request, resource string) {
+	keys, err := h.client.List(ctx, resource, h.readOptions(r)...)
+	if err != nil {
+		h.writeClientError(w, "list", resource, "", err)
+		return
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// put stores the JSON request body.
+func (h *Handler) put(ctx context.Context, w http.ResponseWriter, r *http.Request, resource, key string) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodyBytes))
+	if err != nil {
+		var mberr *http.MaxBytesError
+		if errors.As(err, &mberr) {
+			writeError(w, http.StatusRequestEntityTooLarge, resolvedb.CodePayloadTooLarge, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, resolvedb.CodeBadRequest, err.Error())
+		return
+	}
+	if !json.Valid(body) {
+		writeError(w, http.StatusBadRequest, resolvedb.CodeInvalidFormat, "body must be JSON")
+		return
+	}
+	if err := h.client.Set(ctx, resource, key, json.RawMessage(body), h.writeOptions(r)...); err != nil {
+		h.writeClientError(w, "put", resource, key, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// delete removes a key.
+func (h *Handler) delete(ctx context.Context, w http.ResponseWriter, r *http.Request, resource, key string) {
+	if err := h.client.Delete(ctx, resource, key, h.writeOptions(r)...); err != nil {
+		h.writeClientError(w, "delete", resource, key, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requestOptions maps the caller's credential headers to request options,
+// followed by the handler's options.
+func (h *Handler) requestOptions(r *http.Request, opts ...resolvedb.RequestOption) []resolvedb.RequestOption {
+	for _, hd := range credentialHeaders {
+		if v := r.Header.Get(hd.name); v != "" {
+			opts = append(opts, hd.opt(v))
+		}
+	}
+	return append(opts, h.reqOpts...)
+}
+
+// readOptions returns the request options of a read. Reads carrying
+// tokens bypass the client's cache.
+func (h *Handler) readOptions(r *http.Request) []resolvedb.RequestOption {
+	var opts []resolvedb.RequestOption
+	if hasToken(r) {
+		opts = append(opts, resolvedb.WithSkipCache())
+	} else if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+		opts = append(opts, resolvedb.WithRefreshCache())
+	}
+	return h.requestOptions(r, opts...)
+}
+
+// hasToken reports whether r carries any of the caller's credentials.
+func hasToken(r *http.Request) bool {
+	for _, hd := range credentialHeaders {
+		if hd.token && r.Header.Get(hd.name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeOptions returns the request options of a write.
+func (h *Handler) writeOptions(r *http.Request) []resolvedb.RequestOption {
+	var opts []resolvedb.RequestOption
+	if v := r.Header.Get("Idempotency-Key"); v != "" {
+		opts = append(opts, resolvedb.WithIdempotencyKey(v))
+	}
+	return h.requestOptions(r, opts...)
+}
+
+// writeClientError writes the error of a client call, with the status
+// matching its protocol error code.
+func (h *Handler) writeClientError(w http.ResponseWriter, op, resource, key string, err error) {
+	var perr *resolvedb.Error
+	switch {
+	case errors.As(err, &perr):
+		msg := perr.Message
+		if perr.Details != "" {
+			msg = perr.Details
+		}
+		if perr.RateLimit != nil && !perr.RateLimit.Reset.IsZero() {
+			if secs := int(time.Until(perr.RateLimit.Reset).Seconds()) + 1; secs > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(secs))
+			}
+		}
+		writeError(w, statusOf(perr.Code), perr.Code, msg)
+	case errors.Is(err, resolvedb.ErrACLDenied):
+		writeError(w, http.StatusForbidden, resolvedb.CodeForbidden, err.Error())
+	case errors.Is(err, resolvedb.ErrPayloadTooLarge), errors.Is(err, resolvedb.ErrResponseTooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, resolvedb.CodePayloadTooLarge, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusGatewayTimeout, resolvedb.CodeTimeout, err.Error())
+	default:
+		h.logger.Warn("httpapi: client call failed", "op", op, "resource", resource, "key", key, "error", err)
+		writeError(w, http.StatusBadGateway, resolvedb.CodeServerError, err.Error())
+	}
+}
+
+// statusOf returns the HTTP status of a protocol error code.
+func statusOf(code string) int {
+	switch code {
+	case resolvedb.CodeBadRequest, resolvedb.CodeInvalidFormat, resolvedb.CodeNamespaceError:
+		return http.StatusBadRequest
+	case resolvedb.CodeUnauthorized:
+		return http.StatusUnauthorized
+	case resolvedb.CodeForbidden, resolvedb.CodeEncryptionRequired:
+		return http.StatusForbidden
+	case resolvedb.CodeNotFound:
+		return http.StatusNotFound
+	case resolvedb.CodeConflict, resolvedb.CodeVersionMismatch:
+		return http.StatusConflict
+	case resolvedb.CodePayloadTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case resolvedb.CodeRateLimited:
+		return http.StatusTooManyRequests
+	case resolvedb.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case resolvedb.CodeTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// errorBody is the JSON document of an error response.
+type errorBody struct {
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// writeError writes a JSON error document.
+func writeError(w http.ResponseWriter, status int, code, msg string) {
+	writeJSON(w, status, errorBody{Code: code, Error: msg})
+}
+
+// writeJSON writes v as a JSON document.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// contentType returns the media type of a UQRP response type.
+func contentType(typ string) string {
+	switch typ {
+	case "json":
+		return "application/json"
+	case "text":
+		return "text/plain; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// splitPath splits "/{resource}" or "/{resource}/{key}".
+func splitPath(p string) (resource, key string, ok bool) {
+	p = strings.Trim(p, "/")
+	resource, key, _ = strings.Cut(p, "/")
+	if resource == "" || strings.Contains(key, "/") {
+		return "", "", false
+	}
+	return resource, key, true
+}
@@ -0,0 +1,122 @@
+package resolvedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/resolvedb/resolvedb-go/uqrp"
+)
+
+// prefixIndexValue marks the labels carrying a base64-encoded JSON index
+// value.
+const prefixIndexValue = uqrp.PrefixIndexValue
+
+// IndexInfo describes a secondary index on a resource.
+type IndexInfo struct {
+	Field string `json:"field"` // Indexed document field
+	State string `json:"state"` // "building" or "ready"
+	Keys  int    `json:"keys"`  // Number of indexed keys
+}
+
+// Ready reports whether the index has finished building.
+func (i IndexInfo) Ready() bool {
+	return i.State == "ready"
+}
+
+// CreateIndex declares a secondary index on a top-level or dotted document
+// field of resource. The server builds the index in the background; use
+// ListIndexes to check when it is ready. Requires an API key.
+//
+// Example:
+//
+//	err := client.CreateIndex(ctx, "customers", "country_code")
+func (c *Client) CreateIndex(ctx context.Context, resource, field string, opts ...RequestOption) error {
+	if err := c.validateFields([]string{field}); err != nil {
+		return err
+	}
+	_, err := c.authQuery(ctx, "createindex", resource, "", append(opts, c.withFieldParam(field)))
+	return err
+}
+
+// DropIndex removes a secondary index. Requires an API key.
+func (c *Client) DropIndex(ctx context.Context, resource, field string, opts ...RequestOption) error {
+	if err := c.validateFields([]string{field}); err != nil {
+		return err
+	}
+	_, err := c.authQuery(ctx, "dropindex", resource, "", append(opts, c.withFieldParam(field)))
+	return err
+}
+
+// ListIndexes returns the secondary indexes declared on resource.
+func (c *Client) ListIndexes(ctx context.Context, resource string, opts ...RequestOption) ([]IndexInfo, error) {
+	reqConfig := newRequestConfig(ctx, opts)
+
+	queryName := c.buildQueryName("indexes", resource, "", reqConfig)
+
+	resp, err := c.query(ctx, "indexes", resource, "", queryName, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resp.ToError(); err != nil {
+		return nil, c.queryError(err, "indexes", resource, "", reqConfig, resp)
+	}
+
+	var indexes []IndexInfo
+	if err := resp.Unmarshal(&indexes); err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+// GetByIndex returns the documents of resource whose indexed field equals
+// value. value is compared as JSON, so "CA", 42 and true match strings,
+// numbers and booleans respectively. With WithKeysOnly, only keys are
+// returned. Fields without an index fail with ErrBadRequest.
+//
+// Example:
+//
+//	matches, err := client.GetByIndex(ctx, "customers", "country_code", "CA")
+func (c *Client) GetByIndex(ctx context.Context, resource, field string, value any, opts ...RequestOption) ([]QueryMatch, error) {
+	if err := c.validateFields([]string{field}); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("encode index value: %w", err)
+	}
+
+	reqConfig := newRequestConfig(ctx, append(opts, c.withFieldParam(field)))
+	reqConfig.params = append(reqConfig.params, chunkLabels(prefixIndexValue, c.encodeParamValue(encoded))...)
+	if reqConfig.keysOnly {
+		reqConfig.params = append(reqConfig.params, queryKeysParam)
+	}
+
+	queryName := c.buildQueryName("index", resource, "", reqConfig)
+
+	resp, err := c.query(ctx, "index", resource, "", queryName, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resp.ToError(); err != nil {
+		return nil, c.queryError(err, "index", resource, "", reqConfig, resp)
+	}
+
+	var matches []QueryMatch
+	if err := resp.Unmarshal(&matches); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// withFieldParam adds an encoded field name to a request's parameters.
+func (c *Client) withFieldParam(field string) RequestOption {
+	value := PrefixFields + c.encodeParamValue([]byte(field))
+	return func(rc *requestConfig) {
+		rc.params = append(rc.params, value)
+	}
+}
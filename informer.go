@@ -0,0 +1,298 @@
+package resolvedb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// InformerHandler receives change notifications from an Informer.
+// Nil callbacks are skipped. Callbacks run sequentially on the informer's
+// goroutine and must not block for long.
+type InformerHandler struct {
+	OnAdd    func(key string, value *Response)
+	OnUpdate func(key string, old, value *Response)
+	OnDelete func(key string, last *Response)
+}
+
+// IndexFunc computes the index values of a stored key.
+type IndexFunc func(key string, value *Response) []string
+
+// Informer keeps a local copy of every key of a resource in sync with the
+// server and notifies handlers of additions, updates and deletions, in the
+// style of client-go informers. Each resync period it lists the resource,
+// compares content hashes, and fetches keys that are new or changed; keys
+// without a reported hash are re-fetched and compared by data.
+//
+// Example:
+//
+//	inf := resolvedb.NewInformer(client, "devices", 30*time.Second)
+//	inf.AddIndexer("site", func(key string, v *resolvedb.Response) []string {
+//	    var d Device
+//	    _ = v.Unmarshal(&d)
+//	    return []string{d.Site}
+//	})
+//	inf.AddEventHandler(resolvedb.InformerHandler{
+//	    OnUpdate: func(key string, old, cur *resolvedb.Response) { reconcile(key, cur) },
+//	})
+//	go inf.Run(ctx)
+type Informer struct {
+	client   *Client
+	resource string
+	resync   time.Duration
+	opts     []RequestOption
+
+	mu       sync.RWMutex
+	handlers []InformerHandler
+	items    map[string]*Response
+	hashes   map[string]string
+	indexers map[string]IndexFunc
+	indices  map[string]map[string]map[string]bool // index -> value -> keys
+	synced   bool
+}
+
+// NewInformer creates an informer for resource that resyncs every resync
+// period. A resync of 0 or less syncs once and disables periodic resyncs.
+// Request options are applied to every lookup.
+func NewInformer(client *Client, resource string, resync time.Duration, opts ...RequestOption) *Informer {
+	return &Informer{
+		client:   client,
+		resource: resource,
+		resync:   resync,
+		opts:     opts,
+		items:    make(map[string]*Response),
+		hashes:   make(map[string]string),
+		indexers: make(map[string]IndexFunc),
+		indices:  make(map[string]map[string]map[string]bool),
+	}
+}
+
+// AddEventHandler registers a handler. Handlers added after the initial
+// sync are not replayed existing keys.
+func (i *Informer) AddEventHandler(h InformerHandler) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.handlers = append(i.handlers, h)
+}
+
+// AddIndexer registers a named index over stored keys. Indexers must be
+// added before Run.
+func (i *Informer) AddIndexer(name string, fn IndexFunc) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := i.indexers[name]; ok {
+		return fmt.Errorf("resolvedb: indexer %q already exists", name)
+	}
+	i.indexers[name] = fn
+	i.indices[name] = make(map[string]map[string]bool)
+	return nil
+}
+
+// Run syncs the informer until ctx is done or the client is closed. Sync
+// errors are retried at the next periodic resync, if any. Run returns
+// ctx.Err(), or ErrClosed once the client is closed.
+func (i *Informer) Run(ctx context.Context) error {
+	if i.client.isClosed() {
+		return ErrClosed
+	}
+	ctx, cancel := i.client.withLifetime(ctx)
+	defer cancel()
+	for {
+		_ = i.sync(ctx)
+
+		var resync <-chan time.Time // Nil without periodic resyncs, never ready
+		if i.resync > 0 {
+			resync = i.client.config.clock.After(i.resync)
+		}
+		select {
+		case <-ctx.Done():
+			if i.client.isClosed() {
+				return ErrClosed
+			}
+			return ctx.Err()
+		case <-resync:
+		}
+	}
+}
+
+// HasSynced reports whether the initial sync has completed.
+func (i *Informer) HasSynced() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.synced
+}
+
+// Get returns the stored value of key.
+func (i *Informer) Get(key string) (*Response, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	v, ok := i.items[key]
+	return v, ok
+}
+
+// Keys returns the stored keys in sorted order.
+func (i *Informer) Keys() []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	keys := make([]string, 0, len(i.items))
+	for k := range i.items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ByIndex returns the keys whose index values include value, sorted.
+func (i *Informer) ByIndex(index, value string) ([]string, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	idx, ok := i.indices[index]
+	if !ok {
+		return nil, fmt.Errorf("resolvedb: indexer %q does not exist", index)
+	}
+	keys := make([]string, 0, len(idx[value]))
+	for k := range idx[value] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// sync performs one list-and-diff pass.
+func (i *Informer) sync(ctx context.Context) error {
+	listed, err := i.client.ListDetailed(ctx, i.resource, i.opts...)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(listed))
+	var errs []error
+	for _, info := range listed {
+		seen[info.Key] = true
+
+		i.mu.RLock()
+		old, exists := i.items[info.Key]
+		unchanged := exists && info.Hash != "" && i.hashes[info.Key] == info.Hash
+		i.mu.RUnlock()
+		if unchanged {
+			continue
+		}
+
+		opts := append(append([]RequestOption(nil), i.opts...), WithRefreshCache())
+		resp, err := i.client.GetRaw(ctx, i.resource, info.Key, opts...)
+		if err == nil {
+			err = resp.ToError()
+		}
+		if err != nil {
+			if IsNotFound(err) {
+				continue
+			}
+			errs = append(errs, err)
+			continue
+		}
+		if exists && !changed(old, resp) {
+			i.mu.Lock()
+			i.hashes[info.Key] = info.Hash
+			i.mu.Unlock()
+			continue
+		}
+
+		i.store(info.Key, info.Hash, resp)
+		for _, h := range i.snapshotHandlers() {
+			switch {
+			case !exists && h.OnAdd != nil:
+				_ = SafeCall(i.client, "informer OnAdd", func() { h.OnAdd(info.Key, resp) })
+			case exists && h.OnUpdate != nil:
+				_ = SafeCall(i.client, "informer OnUpdate", func() { h.OnUpdate(info.Key, old, resp) })
+			}
+		}
+	}
+
+	// Remove keys no longer listed
+	i.mu.RLock()
+	var removed []string
+	for k := range i.items {
+		if !seen[k] {
+			removed = append(removed, k)
+		}
+	}
+	i.mu.RUnlock()
+	sort.Strings(removed)
+	for _, k := range removed {
+		last := i.remove(k)
+		for _, h := range i.snapshotHandlers() {
+			if h.OnDelete != nil {
+				_ = SafeCall(i.client, "informer OnDelete", func() { h.OnDelete(k, last) })
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		i.mu.Lock()
+		i.synced = true
+		i.mu.Unlock()
+		return nil
+	}
+	return fmt.Errorf("resolvedb: informer sync %s: %d keys failed, first: %w", i.resource, len(errs), errs[0])
+}
+
+// store saves a value and updates indices.
+func (i *Informer) store(key, hash string, value *Response) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.unindex(key)
+	i.items[key] = value
+	i.hashes[key] = hash
+	for name, fn := range i.indexers {
+		for _, v := range i.indexValues(name, fn, key, value) {
+			if i.indices[name][v] == nil {
+				i.indices[name][v] = make(map[string]bool)
+			}
+			i.indices[name][v][key] = true
+		}
+	}
+}
+
+// remove deletes a key and returns its last value.
+func (i *Informer) remove(key string) *Response {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	last := i.items[key]
+	i.unindex(key)
+	delete(i.items, key)
+	delete(i.hashes, key)
+	return last
+}
+
+// unindex removes key from all indices. The caller must hold i.mu.
+func (i *Informer) unindex(key string) {
+	old, ok := i.items[key]
+	if !ok {
+		return
+	}
+	for name, fn := range i.indexers {
+		for _, v := range i.indexValues(name, fn, key, old) {
+			delete(i.indices[name][v], key)
+			if len(i.indices[name][v]) == 0 {
+				delete(i.indices[name], v)
+			}
+		}
+	}
+}
+
+// indexValues returns the values fn indexes key under. A panicking
+// indexer leaves the key out of its index.
+func (i *Informer) indexValues(name string, fn IndexFunc, key string, value *Response) []string {
+	var values []string
+	_ = SafeCall(i.client, "informer indexer "+name, func() { values = fn(key, value) })
+	return values
+}
+
+// snapshotHandlers returns the registered handlers.
+func (i *Informer) snapshotHandlers() []InformerHandler {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return append([]InformerHandler(nil), i.handlers...)
+}
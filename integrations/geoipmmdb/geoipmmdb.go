@@ -0,0 +1,105 @@
+// Package geoipmmdb reads MaxMind-format (MMDB) GeoIP databases, such as
+// GeoLite2-City, as an offline database for the geoip service client.
+//
+//	db, err := geoipmmdb.Open("GeoLite2-City.mmdb")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer db.Close()
+//
+//	geo := geoip.NewClient(client, geoip.WithOfflineDB(db, geoip.Fallback))
+package geoipmmdb
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/resolvedb/resolvedb-go/services/geoip"
+)
+
+// DB is an open MMDB database. It is safe for concurrent use.
+type DB struct {
+	reader *maxminddb.Reader
+}
+
+// Ensure DB implements geoip.OfflineDB.
+var _ geoip.OfflineDB = (*DB)(nil)
+
+// Open memory-maps the MMDB file at path.
+func Open(path string) (*DB, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("geoipmmdb: open %s: %w", path, err)
+	}
+	return &DB{reader: reader}, nil
+}
+
+// FromBytes reads an MMDB database held in memory, e.g. one embedded in
+// the binary.
+func FromBytes(data []byte) (*DB, error) {
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("geoipmmdb: %w", err)
+	}
+	return &DB{reader: reader}, nil
+}
+
+// Close releases the database.
+func (db *DB) Close() error {
+	return db.reader.Close()
+}
+
+// record holds the GeoIP2/GeoLite2 City, ASN and ISP fields mapped to a
+// geoip.Location. Fields absent from a database are left zero.
+type record struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+		TimeZone  string  `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+	ISP   string `maxminddb:"isp"`
+	ASN   int    `maxminddb:"autonomous_system_number"`
+	ASOrg string `maxminddb:"autonomous_system_organization"`
+}
+
+// Lookup returns the location of ip, or nil if the database has no entry
+// for it. Names are taken in English.
+func (db *DB) Lookup(ip net.IP) (*geoip.Location, error) {
+	var r record
+	_, ok, err := db.reader.LookupNetwork(ip, &r)
+	if err != nil {
+		return nil, fmt.Errorf("geoipmmdb: lookup %s: %w", ip, err)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	loc := &geoip.Location{
+		IP:          ip.String(),
+		City:        r.City.Names["en"],
+		Country:     r.Country.Names["en"],
+		CountryCode: r.Country.ISOCode,
+		Latitude:    r.Location.Latitude,
+		Longitude:   r.Location.Longitude,
+		Timezone:    r.Location.TimeZone,
+		ISP:         r.ISP,
+		ASN:         r.ASN,
+		ASOrg:       r.ASOrg,
+	}
+	if len(r.Subdivisions) > 0 {
+		loc.Region = r.Subdivisions[0].Names["en"]
+	}
+	return loc, nil
+}
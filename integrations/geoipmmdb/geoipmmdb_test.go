@@ -0,0 +1,15 @@
+package geoipmmdb
+
+import "testing"
+
+func TestFromBytesRejectsInvalidDatabase(t *testing.T) {
+	if _, err := FromBytes([]byte("not an mmdb database")); err == nil {
+		t.Fatal("FromBytes succeeded on invalid data")
+	}
+}
+
+func TestOpenMissingFile(t *testing.T) {
+	if _, err := Open(t.TempDir() + "/missing.mmdb"); err == nil {
+		t.Fatal("Open succeeded on a missing file")
+	}
+}
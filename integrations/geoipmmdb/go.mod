@@ -0,0 +1,13 @@
+module github.com/resolvedb/resolvedb-go/integrations/geoipmmdb
+
+go 1.21
+
+require (
+	github.com/oschwald/maxminddb-golang v1.12.0
+	github.com/resolvedb/resolvedb-go v0.9.0
+)
+
+require (
+	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+)
@@ -0,0 +1,14 @@
+module github.com/resolvedb/resolvedb-go/integrations/grpcresolver
+
+go 1.21
+
+require (
+	github.com/resolvedb/resolvedb-go v0.9.0
+	google.golang.org/grpc v1.64.1
+)
+
+require (
+	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+)
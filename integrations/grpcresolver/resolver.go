@@ -0,0 +1,261 @@
+// Package grpcresolver provides a gRPC name resolver backed by service
+// endpoint records stored in ResolveDB.
+//
+// Register the builder, then dial targets of the form
+// "resolvedb:///<service>":
+//
+//	client, _ := resolvedb.New()
+//	grpcresolver.Register(client)
+//
+//	conn, err := grpc.NewClient("resolvedb:///payments",
+//	    grpc.WithTransportCredentials(creds),
+//	)
+//
+// Endpoints are read from the "services" resource, keyed by service name.
+// A record is either a list of addresses or an object with endpoint
+// details:
+//
+//	["10.0.0.1:443", "10.0.0.2:443"]
+//	{"endpoints": [{"addr": "10.0.0.1:443", "attrs": {"zone": "a"}}]}
+//
+// Records are re-resolved when their TTL expires, bounded by the minimum
+// and maximum refresh intervals, and immediately when gRPC requests it.
+package grpcresolver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// Scheme is the gRPC target scheme handled by the resolver.
+const Scheme = "resolvedb"
+
+// Endpoint is a service endpoint record.
+type Endpoint struct {
+	Addr       string            `json:"addr"`
+	Attributes map[string]string `json:"attrs,omitempty"`
+}
+
+// AttributeKey is the key of endpoint attributes attached to resolved
+// addresses. Use it from custom balancers:
+//
+//	zone, _ := addr.Attributes.Value(grpcresolver.AttributeKey("zone")).(string)
+type AttributeKey string
+
+// config holds resolver settings.
+type config struct {
+	resource    string
+	minRefresh  time.Duration
+	maxRefresh  time.Duration
+	balancer    string
+	requestOpts []resolvedb.RequestOption
+}
+
+// Option configures the resolver builder.
+type Option func(*config)
+
+// WithResource sets the resource holding service records (default: "services").
+func WithResource(resource string) Option {
+	return func(c *config) {
+		c.resource = resource
+	}
+}
+
+// WithRefreshBounds bounds the TTL-driven refresh interval
+// (default: 5s to 5m).
+func WithRefreshBounds(min, max time.Duration) Option {
+	return func(c *config) {
+		c.minRefresh = min
+		c.maxRefresh = max
+	}
+}
+
+// WithBalancer sets the load-balancing policy advertised in the service
+// config, such as "round_robin" (default: gRPC's pick_first).
+func WithBalancer(policy string) Option {
+	return func(c *config) {
+		c.balancer = policy
+	}
+}
+
+// WithRequestOptions sets request options applied to every lookup.
+func WithRequestOptions(opts ...resolvedb.RequestOption) Option {
+	return func(c *config) {
+		c.requestOpts = opts
+	}
+}
+
+// builder builds resolvers for the resolvedb scheme.
+type builder struct {
+	client resolvedb.Querier
+	config config
+}
+
+// NewBuilder creates a resolver builder that looks up endpoints with client.
+func NewBuilder(client resolvedb.Querier, opts ...Option) resolver.Builder {
+	cfg := config{
+		resource:   "services",
+		minRefresh: 5 * time.Second,
+		maxRefresh: 5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &builder{client: client, config: cfg}
+}
+
+// Register registers a resolver builder for the resolvedb scheme globally.
+// It must be called during initialization, before dialing.
+func Register(client resolvedb.Querier, opts ...Option) {
+	resolver.Register(NewBuilder(client, opts...))
+}
+
+// Scheme returns the scheme handled by the builder.
+func (b *builder) Scheme() string {
+	return Scheme
+}
+
+// Build starts a resolver for the service named by the target.
+func (b *builder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	service := strings.TrimPrefix(target.Endpoint(), "/")
+	if service == "" {
+		return nil, fmt.Errorf("grpcresolver: missing service name in target %q", target.URL.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &serviceResolver{
+		client:  b.client,
+		config:  b.config,
+		service: service,
+		cc:      cc,
+		cancel:  cancel,
+		resolve: make(chan struct{}, 1),
+	}
+
+	r.wg.Add(1)
+	go r.run(ctx)
+	return r, nil
+}
+
+// serviceResolver watches the endpoint record of one service.
+type serviceResolver struct {
+	client  resolvedb.Querier
+	config  config
+	service string
+	cc      resolver.ClientConn
+	cancel  context.CancelFunc
+	resolve chan struct{}
+	wg      sync.WaitGroup
+}
+
+// ResolveNow triggers an immediate lookup.
+func (r *serviceResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolve <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the resolver.
+func (r *serviceResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// run resolves the service until the resolver is closed.
+func (r *serviceResolver) run(ctx context.Context) {
+	defer r.wg.Done()
+
+	for {
+		next := r.update(ctx)
+
+		timer := time.NewTimer(next)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-r.resolve:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// update looks up the service, pushes the result to gRPC, and returns the
+// delay until the next lookup.
+func (r *serviceResolver) update(ctx context.Context) time.Duration {
+	opts := append([]resolvedb.RequestOption{resolvedb.WithRefreshCache()}, r.config.requestOpts...)
+	resp, err := r.client.GetRaw(ctx, r.config.resource, r.service, opts...)
+	if err == nil {
+		err = resp.ToError()
+	}
+	var endpoints []Endpoint
+	if err == nil {
+		endpoints, err = parseEndpoints(resp)
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			r.cc.ReportError(fmt.Errorf("grpcresolver: resolve %q: %w", r.service, err))
+		}
+		return r.config.minRefresh
+	}
+
+	state := resolver.State{Addresses: make([]resolver.Address, 0, len(endpoints))}
+	for _, ep := range endpoints {
+		addr := resolver.Address{Addr: ep.Addr}
+		for k, v := range ep.Attributes {
+			addr.Attributes = addr.Attributes.WithValue(AttributeKey(k), v)
+		}
+		state.Addresses = append(state.Addresses, addr)
+	}
+	if r.config.balancer != "" {
+		state.ServiceConfig = r.cc.ParseServiceConfig(
+			fmt.Sprintf(`{"loadBalancingConfig":[{%q:{}}]}`, r.config.balancer))
+	}
+	if err := r.cc.UpdateState(state); err != nil {
+		return r.config.minRefresh
+	}
+
+	return clamp(resp.TTL, r.config.minRefresh, r.config.maxRefresh)
+}
+
+// parseEndpoints decodes a service record.
+func parseEndpoints(resp *resolvedb.Response) ([]Endpoint, error) {
+	var addrs []string
+	if err := json.Unmarshal(resp.Data, &addrs); err == nil {
+		endpoints := make([]Endpoint, len(addrs))
+		for i, a := range addrs {
+			endpoints[i] = Endpoint{Addr: a}
+		}
+		return endpoints, nil
+	}
+
+	var record struct {
+		Endpoints []Endpoint `json:"endpoints"`
+	}
+	if err := resp.Unmarshal(&record); err != nil {
+		return nil, err
+	}
+	return record.Endpoints, nil
+}
+
+// clamp bounds d to [min, max], using max when d is unset.
+func clamp(d, min, max time.Duration) time.Duration {
+	switch {
+	case d <= 0:
+		return max
+	case d < min:
+		return min
+	case d > max:
+		return max
+	}
+	return d
+}
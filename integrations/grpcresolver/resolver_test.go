@@ -0,0 +1,43 @@
+package grpcresolver
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+func TestParseEndpoints(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data string
+		want []Endpoint
+	}{
+		{"Addresses", `["10.0.0.1:443","10.0.0.2:443"]`, []Endpoint{{Addr: "10.0.0.1:443"}, {Addr: "10.0.0.2:443"}}},
+		{"Object", `{"endpoints":[{"addr":"10.0.0.1:443","attrs":{"zone":"a"}}]}`, []Endpoint{{Addr: "10.0.0.1:443", Attributes: map[string]string{"zone": "a"}}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEndpoints(&resolvedb.Response{Data: []byte(tt.data), Format: "json"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseEndpoints = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClamp(t *testing.T) {
+	for _, tt := range []struct{ d, want time.Duration }{
+		{0, time.Minute},
+		{time.Millisecond, time.Second},
+		{30 * time.Second, 30 * time.Second},
+		{time.Hour, time.Minute},
+	} {
+		if got := clamp(tt.d, time.Second, time.Minute); got != tt.want {
+			t.Errorf("clamp(%v) = %v, want %v", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package koanfprovider implements a koanf provider backed by a ResolveDB
+// key, with change notifications through Watch.
+//
+// The provider satisfies koanf's Provider interface structurally, so this
+// package does not depend on koanf:
+//
+//	provider := koanfprovider.New(client, "config", "app")
+//	k := koanf.New(".")
+//	if err := k.Load(provider, json.Parser()); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	provider.Watch(func(_ any, err error) {
+//	    if err == nil {
+//	        k.Load(provider, json.Parser())
+//	    }
+//	})
+package koanfprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// Provider reads configuration from one ResolveDB key.
+type Provider struct {
+	client   *resolvedb.Client
+	resource string
+	key      string
+	opts     []resolvedb.RequestOption
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// New creates a provider for resource and key. Request options are applied
+// to every lookup.
+func New(client *resolvedb.Client, resource, key string, opts ...resolvedb.RequestOption) *Provider {
+	return &Provider{client: client, resource: resource, key: key, opts: opts}
+}
+
+// ReadBytes returns the raw config value for parsing by a koanf parser.
+// Each call fetches the current value, bypassing the cache.
+func (p *Provider) ReadBytes() ([]byte, error) {
+	opts := append(append([]resolvedb.RequestOption(nil), p.opts...), resolvedb.WithRefreshCache())
+	resp, err := p.client.GetRaw(context.Background(), p.resource, p.key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.ToError(); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// Read returns the config value decoded as a JSON object.
+func (p *Provider) Read() (map[string]any, error) {
+	data, err := p.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Watch calls cb each time the config value changes, or with an error if
+// a lookup fails. The value current when Watch is called is not reported.
+// Only one watch may be active per provider; stop it with Unwatch.
+func (p *Provider) Watch(cb func(event any, err error)) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		return errors.New("koanfprovider: already watching")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	events := p.client.Watch(ctx, p.resource, p.key, p.opts...)
+
+	go func() {
+		first := true
+		for ev := range events {
+			if ev.Err != nil {
+				cb(nil, ev.Err)
+				continue
+			}
+			if first {
+				first = false
+				continue
+			}
+			cb(ev.Response.Data, nil)
+		}
+	}()
+	return nil
+}
+
+// Unwatch stops an active watch.
+func (p *Provider) Unwatch() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Package viperremote registers ResolveDB as a viper remote config
+// provider, including live updates through viper's watch channel.
+//
+// The provider endpoint is the ResolveDB namespace and the path is
+// "<resource>/<key>":
+//
+//	client, _ := resolvedb.New(resolvedb.WithAPIKey(key))
+//	viperremote.Register(client)
+//
+//	v := viper.New()
+//	v.AddRemoteProvider("resolvedb", "public", "config/app")
+//	v.SetConfigType("json")
+//	err := v.ReadRemoteConfig()
+//
+// Viper supports a single remote config backend per process, so Register
+// replaces viper/remote (etcd, consul and firestore) if it was imported.
+package viperremote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// Provider is the viper remote provider name.
+const Provider = "resolvedb"
+
+// remoteConfig implements viper's remote config factory.
+type remoteConfig struct {
+	client *resolvedb.Client
+	opts   []resolvedb.RequestOption
+}
+
+// Register installs client as viper's remote config backend under the
+// "resolvedb" provider name. Request options are applied to every lookup.
+func Register(client *resolvedb.Client, opts ...resolvedb.RequestOption) {
+	viper.RemoteConfig = &remoteConfig{client: client, opts: opts}
+	for _, p := range viper.SupportedRemoteProviders {
+		if p == Provider {
+			return
+		}
+	}
+	viper.SupportedRemoteProviders = append(viper.SupportedRemoteProviders, Provider)
+}
+
+// Get reads the config value for rp.
+func (r *remoteConfig) Get(rp viper.RemoteProvider) (io.Reader, error) {
+	return r.read(rp)
+}
+
+// Watch re-reads the config value for rp, bypassing the cache.
+func (r *remoteConfig) Watch(rp viper.RemoteProvider) (io.Reader, error) {
+	return r.read(rp, resolvedb.WithRefreshCache())
+}
+
+// WatchChannel streams config changes for rp until quit is closed or
+// receives a value.
+func (r *remoteConfig) WatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	updates := make(chan *viper.RemoteResponse)
+	quit := make(chan bool)
+
+	resource, key, err := splitPath(rp.Path())
+	if err != nil {
+		go func() {
+			select {
+			case updates <- &viper.RemoteResponse{Error: err}:
+			case <-quit:
+			}
+		}()
+		return updates, quit
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := r.client.Watch(ctx, resource, key, r.options(rp)...)
+	go func() {
+		<-quit
+		cancel()
+	}()
+	go func() {
+		for ev := range events {
+			resp := &viper.RemoteResponse{Error: ev.Err}
+			if ev.Response != nil {
+				resp.Value = ev.Response.Data
+			}
+			select {
+			case updates <- resp:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return updates, quit
+}
+
+// read fetches the raw config value for rp.
+func (r *remoteConfig) read(rp viper.RemoteProvider, extra ...resolvedb.RequestOption) (io.Reader, error) {
+	resource, key, err := splitPath(rp.Path())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.GetRaw(context.Background(), resource, key, append(r.options(rp), extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.ToError(); err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(resp.Data), nil
+}
+
+// options returns the request options for rp, scoping lookups to the
+// namespace given as the provider endpoint.
+func (r *remoteConfig) options(rp viper.RemoteProvider) []resolvedb.RequestOption {
+	opts := append([]resolvedb.RequestOption(nil), r.opts...)
+	if ns := rp.Endpoint(); ns != "" {
+		opts = append(opts, resolvedb.WithRequestNamespace(ns))
+	}
+	return opts
+}
+
+// splitPath splits a "<resource>/<key>" provider path.
+func splitPath(path string) (resource, key string, err error) {
+	resource, key, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok || resource == "" || key == "" {
+		return "", "", fmt.Errorf("viperremote: path %q must be <resource>/<key>", path)
+	}
+	return resource, key, nil
+}
@@ -0,0 +1,41 @@
+package viperremote_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/integrations/viperremote"
+	"github.com/resolvedb/resolvedb-go/resolvedbtest"
+)
+
+func TestReadRemoteConfig(t *testing.T) {
+	client, err := resolvedb.New(
+		resolvedb.WithTransports(resolvedbtest.NewServer()),
+		resolvedb.WithCache(resolvedb.CacheConfig{}),
+		resolvedb.WithRetry(resolvedb.RetryConfig{}),
+		resolvedb.WithAPIKey("test-key"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.Set(context.Background(), "config", "app", map[string]any{"port": 8080}); err != nil {
+		t.Fatal(err)
+	}
+	viperremote.Register(client)
+
+	v := viper.New()
+	if err := v.AddRemoteProvider(viperremote.Provider, "public", "config/app"); err != nil {
+		t.Fatal(err)
+	}
+	v.SetConfigType("json")
+	if err := v.ReadRemoteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.GetInt("port"); got != 8080 {
+		t.Fatalf("port = %d, want 8080", got)
+	}
+}
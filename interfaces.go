@@ -1,6 +1,9 @@
 package resolvedb
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+)
 
 // Querier provides read operations on ResolveDB.
 type Querier interface {
@@ -48,7 +51,31 @@ type SecureClient interface {
 	EncryptedWriter
 }
 
-// Ensure Client implements all interfaces.
+// FullClient is the data API of a *Client in one interface: plain and
+// encrypted reads and writes, streaming reads and key listing. Depend on
+// it when a component needs more than one of the narrower interfaces,
+// and use resolvedbtest.MockClient to stand in for it in tests.
+//
+// Methods may be added to FullClient in minor releases. Implementations
+// outside this module should embed resolvedbtest.MockClient (or another
+// FullClient) so they keep compiling as it grows.
+type FullClient interface {
+	SecureClient
+
+	// GetStream retrieves data for a resource and key as a JSON decoder.
+	GetStream(ctx context.Context, resource, key string, opts ...RequestOption) (*json.Decoder, error)
+
+	// ListDetailed retrieves the keys of a resource with per-key metadata.
+	ListDetailed(ctx context.Context, resource string, opts ...RequestOption) ([]KeyInfo, error)
+
+	// Count returns the number of keys stored for a resource.
+	Count(ctx context.Context, resource string, opts ...RequestOption) (int, error)
+
+	// Close releases resources held by the client.
+	Close() error
+}
+
+// Ensure Client, ReadOnlyClient and Mirror implement their interfaces.
 var (
 	_ Querier          = (*Client)(nil)
 	_ Writer           = (*Client)(nil)
@@ -56,4 +83,10 @@ var (
 	_ EncryptedQuerier = (*Client)(nil)
 	_ EncryptedWriter  = (*Client)(nil)
 	_ SecureClient     = (*Client)(nil)
+	_ FullClient       = (*Client)(nil)
+
+	_ Querier          = (*ReadOnlyClient)(nil)
+	_ EncryptedQuerier = (*ReadOnlyClient)(nil)
+
+	_ ReadWriter = (*Mirror)(nil)
 )
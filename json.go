@@ -0,0 +1,57 @@
+package resolvedb
+
+import (
+	"encoding/json"
+	"sync/atomic"
+)
+
+// JSONAPI is a JSON implementation. The standard-compatible
+// configurations of jsoniter, go-json and sonic satisfy it, such as
+// jsoniter.ConfigCompatibleWithStandardLibrary and sonic.ConfigStd.
+type JSONAPI interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// stdJSON is the encoding/json implementation.
+type stdJSON struct{}
+
+func (stdJSON) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (stdJSON) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// jsonImpl holds the JSONAPI in use. It is boxed so atomic.Pointer can
+// hold any implementation.
+type jsonImpl struct {
+	api JSONAPI
+}
+
+var currentJSON atomic.Pointer[jsonImpl]
+
+// SetJSON replaces the JSON implementation used for stored values: values
+// written by Set, SetWithResult, MergePatch and Append, and values decoded
+// by Get, Response.Unmarshal, QueryMatch.Decode and StreamEvent.Decode.
+// Protocol metadata, compact field renaming and lenient decoding keep
+// using encoding/json. A nil api restores encoding/json.
+//
+// The implementation is shared by all clients. Set it during program
+// initialization, before clients are in use.
+//
+// Example:
+//
+//	func init() {
+//	    resolvedb.SetJSON(jsoniter.ConfigCompatibleWithStandardLibrary)
+//	}
+func SetJSON(api JSONAPI) {
+	if api == nil {
+		api = stdJSON{}
+	}
+	currentJSON.Store(&jsonImpl{api: api})
+}
+
+// jsonAPI returns the JSON implementation in use.
+func jsonAPI() JSONAPI {
+	if impl := currentJSON.Load(); impl != nil {
+		return impl.api
+	}
+	return stdJSON{}
+}
@@ -0,0 +1,48 @@
+package resolvedb
+
+import (
+	"context"
+	"fmt"
+)
+
+// KeyProvider supplies the AES-256-GCM key for GetEncrypted and
+// SetEncrypted, e.g. a data key unwrapped by a cloud KMS. It is the stable
+// interface KMS integrations build on, so they can live in their own
+// modules and keep their dependencies out of the core SDK.
+//
+// EncryptionKey is called for every encrypted operation and must return
+// 32 bytes. Implementations should cache the key rather than reach the
+// KMS each time, and must be safe for concurrent use.
+type KeyProvider interface {
+	EncryptionKey(ctx context.Context) ([]byte, error)
+}
+
+// WithKeyProvider gets the encryption key from p instead of a fixed key,
+// and takes precedence over WithEncryptionKey.
+func WithKeyProvider(p KeyProvider) Option {
+	return func(c *clientConfig) {
+		c.keyProvider = p
+	}
+}
+
+// encryptionKey returns the key for an encrypted operation.
+func (c *Client) encryptionKey(ctx context.Context) (*[32]byte, error) {
+	p := c.config.keyProvider
+	if p == nil {
+		if c.config.encryptionKey == nil {
+			return nil, fmt.Errorf("encryption key not configured")
+		}
+		return c.config.encryptionKey, nil
+	}
+
+	key, err := p.EncryptionKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	var k [32]byte
+	copy(k[:], key)
+	return &k, nil
+}
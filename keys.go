@@ -0,0 +1,114 @@
+package resolvedb
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// keysResource is the reserved resource for API key management.
+const keysResource = "keys"
+
+// APIKey describes an API key issued by the server.
+type APIKey struct {
+	ID        string    // Key identifier, safe to log
+	Secret    string    // Key material; only returned on creation and rotation
+	Scope     string    // Permission scope (empty for full access)
+	CreatedAt time.Time // Issue time
+	ExpiresAt time.Time // Expiry time (zero if the key does not expire)
+}
+
+// UnmarshalJSON decodes an API key from the server's key format.
+func (k *APIKey) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ID      string `json:"id"`
+		Key     string `json:"key"`
+		Scope   string `json:"scope"`
+		Created int64  `json:"created"`
+		Expires int64  `json:"expires"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	k.ID = raw.ID
+	k.Secret = raw.Key
+	k.Scope = raw.Scope
+	if raw.Created > 0 {
+		k.CreatedAt = time.Unix(raw.Created, 0)
+	}
+	if raw.Expires > 0 {
+		k.ExpiresAt = time.Unix(raw.Expires, 0)
+	}
+	return nil
+}
+
+// RotateAPIKey issues a replacement for the client's API key and schedules
+// the current key for revocation. The client keeps using the old key;
+// create a new client with the returned secret. The query carries an
+// idempotency key, generated unless set with WithIdempotencyKey, so a
+// retry after a lost response returns the same replacement instead of
+// rotating again.
+//
+// Example:
+//
+//	newKey, err := client.RotateAPIKey(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	storeSecret(newKey.Secret)
+func (c *Client) RotateAPIKey(ctx context.Context, opts ...RequestOption) (*APIKey, error) {
+	resp, err := c.authQuery(ctx, "rotate", keysResource, "", c.withIdempotencyKey(ctx, opts))
+	if err != nil {
+		return nil, err
+	}
+
+	var key APIKey
+	if err := resp.Unmarshal(&key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// CreateScopedKey issues a new API key restricted to scope.
+// A zero expiry creates a key that does not expire. As with RotateAPIKey,
+// an idempotency key keeps retries from creating duplicate keys.
+//
+// Example:
+//
+//	key, err := client.CreateScopedKey(ctx, "read:config", 90*24*time.Hour)
+func (c *Client) CreateScopedKey(ctx context.Context, scope string, expiry time.Duration, opts ...RequestOption) (*APIKey, error) {
+	params := []string{c.encodeDataLabel([]byte(scope))}
+	if expiry > 0 {
+		params = append(params, PrefixExp+strconv.FormatInt(c.config.clock.Now().Add(expiry).Unix(), 10))
+	}
+
+	opts = append(c.withIdempotencyKey(ctx, opts), withParams(params...))
+	resp, err := c.authQuery(ctx, "createkey", keysResource, "", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var key APIKey
+	if err := resp.Unmarshal(&key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RevokeKey revokes the API key with the given ID.
+func (c *Client) RevokeKey(ctx context.Context, keyID string, opts ...RequestOption) error {
+	_, err := c.authQuery(ctx, "revoke", keysResource, keyID, opts)
+	return err
+}
+
+// withIdempotencyKey returns opts with a generated idempotency key
+// appended, unless they set one, for operations that must not be repeated
+// by retries.
+func (c *Client) withIdempotencyKey(ctx context.Context, opts []RequestOption) []RequestOption {
+	if newRequestConfig(ctx, opts).idempotencyKey != "" {
+		return opts
+	}
+	key := newIdempotencyKey(c.config.rand, c.config.clock)
+	return append(opts[:len(opts):len(opts)], WithIdempotencyKey(key))
+}
@@ -0,0 +1,88 @@
+// Package kv adapts a ResolveDB resource to a minimal byte-oriented
+// key-value store, for frameworks with pluggable KV backends such as
+// session stores and cache layers.
+//
+// Example:
+//
+//	store := kv.New(client, "sessions")
+//	err := store.Set(ctx, sessionID, payload, 30*time.Minute)
+//	payload, err = store.Get(ctx, sessionID)
+//	if errors.Is(err, kv.ErrNotFound) {
+//	    // start a new session
+//	}
+package kv
+
+import (
+	"context"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// ErrNotFound is returned by Get for missing keys.
+// It matches resolvedb.ErrNotFound with errors.Is.
+var ErrNotFound = resolvedb.ErrNotFound
+
+// Store is a byte-oriented key-value store.
+type Store interface {
+	// Get returns the value of key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores value under key. The key expires ttl after the write, after
+	// which Get returns ErrNotFound; a ttl of 0 keeps it until deleted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// Keys lists the stored keys.
+	Keys(ctx context.Context) ([]string, error)
+}
+
+// Client is the subset of *resolvedb.Client used by the adapter.
+type Client interface {
+	resolvedb.ReadWriter
+}
+
+// resourceStore stores values as keys of one resource.
+type resourceStore struct {
+	client   Client
+	resource string
+	opts     []resolvedb.RequestOption
+}
+
+// New returns a Store backed by resource. Request options are applied to
+// every operation.
+func New(client Client, resource string, opts ...resolvedb.RequestOption) Store {
+	return &resourceStore{client: client, resource: resource, opts: opts}
+}
+
+// Ensure resourceStore implements Store.
+var _ Store = (*resourceStore)(nil)
+
+func (s *resourceStore) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	if err := s.client.Get(ctx, s.resource, key, &value, s.opts...); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (s *resourceStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	opts := s.opts
+	if ttl > 0 {
+		opts = append(opts[:len(opts):len(opts)], resolvedb.WithExpiry(ttl))
+	}
+	// []byte values are stored base64-encoded in a JSON string and decoded
+	// symmetrically by Get.
+	return s.client.Set(ctx, s.resource, key, value, opts...)
+}
+
+func (s *resourceStore) Delete(ctx context.Context, key string) error {
+	opts := append(s.opts[:len(s.opts):len(s.opts)], resolvedb.WithIgnoreNotFound())
+	return s.client.Delete(ctx, s.resource, key, opts...)
+}
+
+func (s *resourceStore) Keys(ctx context.Context) ([]string, error) {
+	return s.client.List(ctx, s.resource, s.opts...)
+}
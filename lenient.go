@@ -0,0 +1,172 @@
+package resolvedb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// lenientType maps normalized key names of a struct type to its fields.
+type lenientType struct {
+	names map[string]string       // normalized key -> JSON field name
+	types map[string]reflect.Type // JSON field name -> field type
+}
+
+// lenientTypes caches lenient descriptions by struct type.
+var lenientTypes sync.Map // reflect.Type -> *lenientType
+
+// UnmarshalLenient is like Unmarshal, but matches JSON object keys to
+// struct fields ignoring case, underscores and hyphens, so "temp_c",
+// "tempC" and "TempC" all populate a field named TempC. It returns the
+// paths of keys that matched no field (e.g., "$.wind.gust").
+//
+// Non-JSON formats and ResponseUnmarshaler implementations are decoded as
+// by Unmarshal.
+func (r *Response) UnmarshalLenient(v any) ([]string, error) {
+	if r.Data == nil {
+		return nil, ErrNotFound
+	}
+	if _, ok := v.(ResponseUnmarshaler); ok || (r.Format != "json" && r.Format != "") {
+		return nil, r.Unmarshal(v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(r.Data))
+	dec.UseNumber()
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return nil, &DecodeError{Format: "json", Type: fmt.Sprintf("%T", v), Err: err}
+	}
+
+	var unmatched []string
+	tree = matchLenient(tree, reflect.TypeOf(v), "$", &unmatched)
+
+	data, err := json.Marshal(tree)
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		return unmatched, &DecodeError{Format: "json", Type: fmt.Sprintf("%T", v), Err: err}
+	}
+	return unmatched, nil
+}
+
+// matchLenient renames the keys of a decoded JSON value to the JSON field
+// names of t, recording keys without a matching field.
+func matchLenient(v any, t reflect.Type, path string, unmatched *[]string) any {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		lt := lenientTypeOf(t)
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		out := make(map[string]any, len(obj))
+		for _, k := range keys {
+			name, ok := lt.names[normalizeFieldName(k)]
+			if !ok {
+				*unmatched = append(*unmatched, path+"."+k)
+				continue
+			}
+			out[name] = matchLenient(obj[k], lt.types[name], path+"."+k, unmatched)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]any)
+		if !ok {
+			return v
+		}
+		for i := range arr {
+			arr[i] = matchLenient(arr[i], t.Elem(), fmt.Sprintf("%s[%d]", path, i), unmatched)
+		}
+		return arr
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		for k := range obj {
+			obj[k] = matchLenient(obj[k], t.Elem(), path+"."+k, unmatched)
+		}
+		return obj
+	}
+	return v
+}
+
+// lenientTypeOf returns the lenient description of struct type t.
+func lenientTypeOf(t reflect.Type) *lenientType {
+	if cached, ok := lenientTypes.Load(t); ok {
+		return cached.(*lenientType)
+	}
+	lt := &lenientType{
+		names: make(map[string]string),
+		types: make(map[string]reflect.Type),
+	}
+	addLenientFields(lt, t)
+	lenientTypes.Store(t, lt)
+	return lt
+}
+
+// addLenientFields adds the fields of struct type t, promoting fields of
+// untagged embedded structs as encoding/json does.
+func addLenientFields(lt *lenientType, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, skip := jsonFieldName(f)
+		if skip {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addLenientFields(lt, ft)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		lt.types[name] = f.Type
+		lt.names[normalizeFieldName(name)] = name
+		lt.names[normalizeFieldName(f.Name)] = name
+		if short := f.Tag.Get("rdb"); short != "" && short != "-" {
+			lt.names[normalizeFieldName(short)] = name
+		}
+	}
+}
+
+// normalizeFieldName lowercases name and drops underscores and hyphens.
+func normalizeFieldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '_' || r == '-':
+			return -1
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return r
+	}, name)
+}
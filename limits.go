@@ -0,0 +1,93 @@
+package resolvedb
+
+import "fmt"
+
+// ResponseLimits bounds the memory the client commits to a response, so a
+// malicious or faulty resolver cannot make it buffer without end. Answers
+// past a limit fail with a *LimitError.
+type ResponseLimits struct {
+	// MaxResponseBytes bounds the TXT data of a single answer, across all
+	// of its records (default: MaxResponseBytes).
+	MaxResponseBytes int `json:"max_response_bytes"`
+
+	// MaxChunks bounds the chunks of a chunked value or list response
+	// (default: 4096).
+	MaxChunks int `json:"max_chunks"`
+
+	// MaxBlobBytes bounds a value reassembled from chunks or decompressed,
+	// and the data of a list gathered from chunks (default: 16 MiB).
+	MaxBlobBytes int `json:"max_blob_bytes"`
+}
+
+// DefaultResponseLimits returns the default response limits.
+func DefaultResponseLimits() ResponseLimits {
+	return ResponseLimits{
+		MaxResponseBytes: MaxResponseBytes,
+		MaxChunks:        4096,
+		MaxBlobBytes:     16 << 20,
+	}
+}
+
+// WithResponseLimits sets the response limits. Fields left at zero keep
+// their defaults.
+//
+// Example:
+//
+//	client, err := resolvedb.New(
+//	    resolvedb.WithResponseLimits(resolvedb.ResponseLimits{
+//	        MaxChunks:    64,
+//	        MaxBlobBytes: 1 << 20,
+//	    }),
+//	)
+func WithResponseLimits(limits ResponseLimits) Option {
+	return func(c *clientConfig) {
+		def := DefaultResponseLimits()
+		if limits.MaxResponseBytes <= 0 {
+			limits.MaxResponseBytes = def.MaxResponseBytes
+		}
+		if limits.MaxChunks <= 0 {
+			limits.MaxChunks = def.MaxChunks
+		}
+		if limits.MaxBlobBytes <= 0 {
+			limits.MaxBlobBytes = def.MaxBlobBytes
+		}
+		c.responseLimits = limits
+	}
+}
+
+// Response limit names reported in LimitError.Limit.
+const (
+	LimitResponseBytes = "response_bytes"
+	LimitChunks        = "chunks"
+	LimitBlobBytes     = "blob_bytes"
+)
+
+// LimitError reports a response that exceeds one of the client's
+// ResponseLimits. It matches ErrResponseTooLarge.
+//
+// Example:
+//
+//	var lerr *resolvedb.LimitError
+//	if errors.As(err, &lerr) {
+//	    log.Printf("%s/%s: %s %d exceeds %d", lerr.Resource, lerr.Key, lerr.Limit, lerr.Size, lerr.Max)
+//	}
+type LimitError struct {
+	Limit    string // LimitResponseBytes, LimitChunks or LimitBlobBytes
+	Resource string
+	Key      string // Empty for lists
+	Size     int64  // Size reported or reached when reading stopped
+	Max      int64  // Configured limit
+}
+
+func (e *LimitError) Error() string {
+	target := e.Resource
+	if e.Key != "" {
+		target += "/" + e.Key
+	}
+	return fmt.Sprintf("resolvedb: %s: %s %d exceeds limit of %d", target, e.Limit, e.Size, e.Max)
+}
+
+// Unwrap returns ErrResponseTooLarge.
+func (e *LimitError) Unwrap() error {
+	return ErrResponseTooLarge
+}
@@ -0,0 +1,146 @@
+package resolvedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// listDetailParam requests per-key metadata from the list operation.
+const listDetailParam = "detail"
+
+// KeyInfo describes a key returned by ListDetailed. Fields other than Key
+// are zero when the server does not report them.
+type KeyInfo struct {
+	Key     string        // Key name
+	Size    int           // Stored size in bytes
+	TTL     time.Duration // Remaining time to live, 0 if none
+	Updated time.Time     // Time of the last write
+	Hash    string        // Content hash of the current value
+}
+
+// UnmarshalJSON decodes a key entry. Entries may be plain key strings or
+// objects carrying metadata.
+func (k *KeyInfo) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		*k = KeyInfo{Key: key}
+		return nil
+	}
+
+	var raw struct {
+		Key  string `json:"key"`
+		Size int    `json:"size"`
+		TTL  int64  `json:"ttl"`
+		TS   int64  `json:"ts"`
+		Hash string `json:"hash"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*k = KeyInfo{
+		Key:  raw.Key,
+		Size: raw.Size,
+		TTL:  time.Duration(raw.TTL) * time.Second,
+		Hash: raw.Hash,
+	}
+	if raw.TS > 0 {
+		k.Updated = time.Unix(raw.TS, 0)
+	}
+	return nil
+}
+
+// ListDetailed retrieves the keys of a resource with per-key metadata,
+// so callers can compare hashes or timestamps without fetching values.
+//
+// Example:
+//
+//	keys, err := client.ListDetailed(ctx, "config")
+//	for _, k := range keys {
+//	    if k.Hash != localHashes[k.Key] {
+//	        // fetch and update
+//	    }
+//	}
+func (c *Client) ListDetailed(ctx context.Context, resource string, opts ...RequestOption) ([]KeyInfo, error) {
+	_, keys, err := c.listDetailed(ctx, resource, newRequestConfig(ctx, opts))
+	return keys, err
+}
+
+// listDetailed executes a detailed list query, returning the response
+// alongside the decoded keys.
+func (c *Client) listDetailed(ctx context.Context, resource string, reqConfig *requestConfig) (*Response, []KeyInfo, error) {
+	reqConfig.params = []string{listDetailParam}
+
+	queryName := c.buildQueryName("list", resource, "", reqConfig)
+
+	resp, err := c.query(ctx, "list", resource, "", queryName, reqConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := resp.ToError(); err != nil {
+		return nil, nil, c.queryError(err, "list", resource, "", reqConfig, resp)
+	}
+
+	keys, err := listEntries[KeyInfo](ctx, c, resource, reqConfig, resp)
+	return resp, keys, err
+}
+
+// listEntries decodes the entries of a list response. When the server
+// splits a large list across chunks, the remaining chunks are fetched in
+// order. If one fails, the entries read so far are returned with a
+// *PartialResultError.
+func listEntries[T any](ctx context.Context, c *Client, resource string, reqConfig *requestConfig, resp *Response) ([]T, error) {
+	var entries []T
+	if err := resp.Unmarshal(&entries); err != nil {
+		return nil, err
+	}
+	if resp.Chunks <= 1 {
+		return entries, nil
+	}
+	limits := c.config.responseLimits
+	if resp.Chunks > limits.MaxChunks {
+		return nil, &LimitError{Limit: LimitChunks, Resource: resource, Size: int64(resp.Chunks), Max: int64(limits.MaxChunks)}
+	}
+
+	params := reqConfig.params
+	size := int64(len(resp.Data))
+	for i := 1; i < resp.Chunks; i++ {
+		page, err := c.listChunk(ctx, resource, params, i, reqConfig)
+		if err == nil {
+			if size += int64(len(page.Data)); size > int64(limits.MaxBlobBytes) {
+				err = &LimitError{Limit: LimitBlobBytes, Resource: resource, Size: size, Max: int64(limits.MaxBlobBytes)}
+			}
+		}
+		if err == nil {
+			var more []T
+			if err = page.Unmarshal(&more); err == nil {
+				entries = append(entries, more...)
+				continue
+			}
+		}
+		return entries, &PartialResultError{Op: "list", Fetched: i, Total: resp.Chunks, Err: err}
+	}
+	return entries, nil
+}
+
+// listChunk fetches chunk i of a chunked list response.
+func (c *Client) listChunk(ctx context.Context, resource string, params []string, i int, reqConfig *requestConfig) (*Response, error) {
+	chunkConfig := *reqConfig
+	chunkConfig.params = append(params[:len(params):len(params)], PrefixChunk+strconv.Itoa(i))
+
+	queryName := c.buildQueryName("list", resource, "", &chunkConfig)
+	resp, err := c.query(ctx, "list", resource, "", queryName, &chunkConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.ToError(); err != nil {
+		return nil, c.queryError(err, "list", resource, "", &chunkConfig, resp)
+	}
+	if resp.Chunks > 1 && resp.ChunkID != i {
+		return nil, &ProtocolError{Err: fmt.Errorf("asked for list chunk %d of %s, got chunk %d", i, resource, resp.ChunkID)}
+	}
+	return resp, nil
+}
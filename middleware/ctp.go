@@ -0,0 +1,73 @@
+// Package middleware provides net/http middleware that attaches ResolveDB
+// request options to incoming requests.
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/security"
+)
+
+// UserFunc returns the authenticated user and cohort for a request.
+// It returns ok=false for anonymous requests.
+type UserFunc func(r *http.Request) (userID, cohort string, ok bool)
+
+// ctpContextKey is the context key for the minted CTP token.
+type ctpContextKey struct{}
+
+// CTP returns middleware that mints a Cohort Token Pattern token for the
+// authenticated user of each request and attaches it to the request
+// context with resolvedb.ContextWithOptions, so downstream lookups made
+// with that context are targeted to the user's cohort.
+//
+// Anonymous requests, and requests for which minting fails, are passed
+// through without a token so lookups fall back to untargeted values.
+// The key must be exactly 32 bytes. Panics if the key length is invalid.
+//
+// Example:
+//
+//	mw := middleware.CTP(ctpKey, func(r *http.Request) (string, string, bool) {
+//	    u, ok := auth.UserFrom(r.Context())
+//	    return u.ID, u.Plan, ok
+//	})
+//	http.ListenAndServe(":8080", mw(mux))
+//
+//	// In a handler:
+//	enabled, _ := flagClient.Get(r.Context(), "new-checkout")
+func CTP(key []byte, user UserFunc) func(http.Handler) http.Handler {
+	if len(key) != 32 {
+		panic(fmt.Sprintf("middleware: CTP key must be 32 bytes, got %d", len(key)))
+	}
+	var k [32]byte
+	copy(k[:], key)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, cohort, ok := user(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, err := security.NewCTP(userID, cohort, &k)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ctpContextKey{}, token.String())
+			ctx = resolvedb.ContextWithOptions(ctx, resolvedb.WithCTP(token.String()))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// CTPFromContext returns the CTP token minted for the request, or "" if
+// none was attached.
+func CTPFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(ctpContextKey{}).(string)
+	return token
+}
@@ -0,0 +1,247 @@
+package resolvedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// DefaultVersionField is the document field a Migrator reads the stored
+// version from, unless set with WithVersionField.
+const DefaultVersionField = "schema_version"
+
+// Migration upgrades a document by one version, modifying it in place.
+// Numbers in doc are json.Number, so integers too large for a float64
+// survive a migration unchanged.
+type Migration func(doc map[string]any) error
+
+// Migrator upgrades the stored documents of a resource as they are read,
+// for long-lived datasets whose structs evolve. Register a migration per
+// version step; reads of older documents run every step from the stored
+// version to the latest and decode the result. Documents without the
+// version field are at version 1; documents at or past the latest
+// version, and values that are not JSON objects, are left alone.
+//
+// Example:
+//
+//	m := resolvedb.NewMigrator("devices", resolvedb.WithWriteBack()).
+//	    Register(1, func(doc map[string]any) error {
+//	        secs, err := doc["interval"].(json.Number).Int64()
+//	        if err != nil {
+//	            return err
+//	        }
+//	        doc["interval_ms"] = secs * 1000
+//	        delete(doc, "interval")
+//	        return nil
+//	    })
+//	client, err := resolvedb.New(resolvedb.WithAPIKey(key), resolvedb.WithMigrator(m))
+type Migrator struct {
+	resource  string
+	field     string
+	steps     map[int]Migration
+	latest    int
+	writeBack bool
+}
+
+// MigratorOption configures a Migrator.
+type MigratorOption func(*Migrator)
+
+// WithVersionField reads and writes the document version in field instead
+// of DefaultVersionField.
+func WithVersionField(field string) MigratorOption {
+	return func(m *Migrator) {
+		m.field = field
+	}
+}
+
+// WithWriteBack stores upgraded documents after Get reads them, so each is
+// migrated once. Writes happen in the background with the client's API
+// key; a failed write is logged and retried on the next read, and writes
+// still pending when the client is closed are dropped. The write is
+// unconditional, so it can overwrite a concurrent update; enable
+// it only for resources whose writers have all moved to the latest
+// version.
+func WithWriteBack() MigratorOption {
+	return func(m *Migrator) {
+		m.writeBack = true
+	}
+}
+
+// NewMigrator creates a Migrator for resource.
+func NewMigrator(resource string, opts ...MigratorOption) *Migrator {
+	m := &Migrator{
+		resource: resource,
+		field:    DefaultVersionField,
+		steps:    make(map[int]Migration),
+		latest:   1,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Register adds the migration from version from to from+1 and returns m.
+// It panics if from is below 1 or already registered. Register every
+// migration before passing m to WithMigrator.
+func (m *Migrator) Register(from int, fn Migration) *Migrator {
+	if from < 1 {
+		panic(fmt.Sprintf("resolvedb: migration from version %d; versions start at 1", from))
+	}
+	if _, ok := m.steps[from]; ok {
+		panic(fmt.Sprintf("resolvedb: migration from version %d registered twice", from))
+	}
+	m.steps[from] = fn
+	m.latest = max(m.latest, from+1)
+	return m
+}
+
+// Resource returns the resource m migrates.
+func (m *Migrator) Resource() string {
+	return m.resource
+}
+
+// Version returns the latest version, one past the highest migration.
+func (m *Migrator) Version() int {
+	return m.latest
+}
+
+// Migrate upgrades a JSON document to the latest version and reports
+// whether it changed. It fails if a step between the stored version and
+// the latest has no migration.
+func (m *Migrator) Migrate(data []byte) ([]byte, bool, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil || doc == nil {
+		return data, false, nil
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		// Trailing data: not a single JSON object
+		return data, false, nil
+	}
+	version, err := m.version(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	if version >= m.latest {
+		return data, false, nil
+	}
+
+	for v := version; v < m.latest; v++ {
+		fn := m.steps[v]
+		if fn == nil {
+			return nil, false, fmt.Errorf("no migration from version %d of %s", v, m.resource)
+		}
+		if err := fn(doc); err != nil {
+			return nil, false, fmt.Errorf("migrate %s from version %d: %w", m.resource, v, err)
+		}
+	}
+	doc[m.field] = m.latest
+
+	upgraded, err := jsonAPI().Marshal(doc)
+	if err != nil {
+		return nil, false, fmt.Errorf("encode migrated %s: %w", m.resource, err)
+	}
+	return upgraded, true, nil
+}
+
+// version returns the version recorded in doc.
+func (m *Migrator) version(doc map[string]any) (int, error) {
+	raw, ok := doc[m.field]
+	if !ok {
+		return 1, nil
+	}
+	var v float64
+	switch n := raw.(type) {
+	case float64:
+		v = n
+	case int:
+		v = float64(n)
+	case int64:
+		v = float64(n)
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("%s field %s: %w", m.resource, m.field, err)
+		}
+		v = f
+	default:
+		return 0, fmt.Errorf("%s field %s is %T, not a number", m.resource, m.field, raw)
+	}
+	if v != math.Trunc(v) || v < 1 {
+		return 0, fmt.Errorf("%s field %s is %v, not a version", m.resource, m.field, v)
+	}
+	return int(v), nil
+}
+
+// WithMigrator upgrades documents of the migrator's resource as they are
+// read by Get, GetEncrypted, GetVersion and GetAt. One migrator applies
+// per resource; a later one replaces an earlier one.
+func WithMigrator(m *Migrator) Option {
+	return func(c *clientConfig) {
+		if c.migrators == nil {
+			c.migrators = make(map[string]*Migrator)
+		}
+		c.migrators[m.resource] = m
+	}
+}
+
+// migrate upgrades resp's data with the resource's migrator, if any,
+// returning a copy of resp with the upgraded data if it changed.
+func (c *Client) migrate(resp *Response, resource, key string) (*Response, bool, error) {
+	m := c.config.migrators[resource]
+	if m == nil {
+		return resp, false, nil
+	}
+	data, changed, err := m.Migrate(resp.Data)
+	if err != nil {
+		return nil, false, fmt.Errorf("resolvedb: %s/%s: %w", resource, key, err)
+	}
+	if !changed {
+		return resp, false, nil
+	}
+	migrated := *resp
+	migrated.Data = data
+	migrated.Records = nil
+	migrated.RecordTTLs = nil
+	migrated.Manifest = nil
+	return &migrated, true, nil
+}
+
+// writeBack stores a migrated document in the background, if the client
+// can write. Reads of the document while it is being written, which may
+// be served the old version from the cache, do not write it again.
+func (c *Client) writeBack(resource, key string, data []byte, reqConfig *requestConfig) {
+	if c.config.apiKey == "" {
+		return
+	}
+	ns := c.namespace(reqConfig)
+	id := buildCacheKey("migrate", resource, key, ns, c.config.version)
+	if _, busy := c.writeBacks.LoadOrStore(id, struct{}{}); busy {
+		return
+	}
+	ctx, done, ok := c.background(context.Background())
+	if !ok {
+		c.writeBacks.Delete(id)
+		return
+	}
+	writeConfig := newRequestConfig(ctx, []RequestOption{WithRequestNamespace(ns)})
+	go func() {
+		defer done()
+		defer c.writeBacks.Delete(id)
+		var err error
+		if c.config.autoCodec {
+			_, err = c.setAuto(ctx, resource, key, json.RawMessage(data), writeConfig)
+		} else {
+			_, err = c.put(ctx, resource, key, data, writeConfig)
+		}
+		if err != nil {
+			c.logger().Warn("resolvedb: write back migrated document failed",
+				"resource", resource, "key", key, "error", err)
+		}
+	}()
+}
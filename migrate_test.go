@@ -0,0 +1,33 @@
+package resolvedb
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMigrateKeepsLargeIntegers(t *testing.T) {
+	m := NewMigrator("devices").Register(1, func(doc map[string]any) error {
+		doc["renamed"] = doc["id"]
+		delete(doc, "id")
+		return nil
+	})
+
+	out, changed, err := m.Migrate([]byte(`{"id":9007199254740993,"count":9007199254740993}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("Migrate reported no change")
+	}
+	var got struct {
+		Renamed int64 `json:"renamed"`
+		Count   int64 `json:"count"`
+		Version int   `json:"schema_version"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Renamed != 9007199254740993 || got.Count != 9007199254740993 || got.Version != 2 {
+		t.Fatalf("Migrate returned %s, want the integers unchanged at version 2", out)
+	}
+}
@@ -0,0 +1,315 @@
+package resolvedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go/rollout"
+)
+
+// Mirror defaults.
+const (
+	defaultMirrorSeed          = "mirror"
+	defaultMirrorShadowTimeout = 5 * time.Second
+	defaultMirrorMaxShadows    = 16
+)
+
+// MirrorConfig configures a Mirror. Percentages select keys by their
+// rollout bucket, so the same keys stay mirrored as a percentage grows.
+type MirrorConfig struct {
+	WritePercent int    // Keys whose writes are copied to the secondary, 0-100
+	ReadPercent  int    // Keys whose reads are shadowed on the secondary, 0-100
+	Seed         string // Rollout seed (default: "mirror")
+
+	// SecondaryOptions are added to every secondary request, e.g.
+	// WithRequestNamespace to mirror into another namespace of the same
+	// client.
+	SecondaryOptions []RequestOption
+
+	ShadowTimeout time.Duration // Bound on a shadow read (default: 5s)
+	MaxShadows    int           // Shadow reads in flight; more are skipped (default: 16)
+
+	// OnMismatch is called, from a background goroutine for reads, when
+	// the secondary disagrees with the primary or a copied write fails.
+	// With a nil OnMismatch, mismatches are logged.
+	OnMismatch func(Mismatch)
+}
+
+// Mismatch reports a secondary result that differs from the primary's.
+// Get compares decoded values re-encoded as JSON, so encoding differences
+// between deployments are not reported; GetRaw compares response data and
+// List compares sorted keys.
+type Mismatch struct {
+	Op           string // "get", "getraw", "list", "set" or "delete"
+	Resource     string
+	Key          string // Empty for lists
+	Primary      []byte // Primary result as compared, nil for writes and errors
+	Secondary    []byte // Secondary result as compared, nil for writes and errors
+	PrimaryErr   error  // Primary error, for reads where only one side failed
+	SecondaryErr error
+}
+
+// MirrorStats reports a Mirror's activity.
+type MirrorStats struct {
+	MirroredWrites int64 // Writes copied to the secondary
+	ShadowReads    int64 // Reads compared against the secondary
+	ShadowsSkipped int64 // Shadow reads skipped because MaxShadows were in flight
+	Mismatches     int64 // Reads and copied writes reported to OnMismatch
+}
+
+// Mirror validates a new ResolveDB deployment before cutover. It serves
+// every call from the primary client, copies a percentage of writes to
+// the secondary, and shadow-reads a percentage of keys from the secondary,
+// reporting differences. The secondary never affects results: its errors
+// are reported, not returned. It is safe for concurrent use.
+//
+// Copied writes run after the primary write succeeds, on the caller's
+// goroutine, so they reach the secondary in order. Shadow reads run in
+// the background; Wait blocks until they finish.
+//
+// Example:
+//
+//	mirror := resolvedb.NewMirror(oldClient, newClient, resolvedb.MirrorConfig{
+//	    WritePercent: 100,
+//	    ReadPercent:  10,
+//	    OnMismatch: func(m resolvedb.Mismatch) {
+//	        log.Printf("mirror: %s %s/%s differs: %v", m.Op, m.Resource, m.Key, m.SecondaryErr)
+//	    },
+//	})
+//	var rw resolvedb.ReadWriter = mirror
+type Mirror struct {
+	primary   *Client
+	secondary *Client
+	config    MirrorConfig
+	shadows   chan struct{}
+	wg        sync.WaitGroup
+
+	writes     atomic.Int64
+	reads      atomic.Int64
+	skipped    atomic.Int64
+	mismatches atomic.Int64
+}
+
+// NewMirror creates a mirror serving from primary and validating
+// secondary. Both may be the same client when config.SecondaryOptions
+// selects another namespace.
+func NewMirror(primary, secondary *Client, config MirrorConfig) *Mirror {
+	if config.Seed == "" {
+		config.Seed = defaultMirrorSeed
+	}
+	if config.ShadowTimeout <= 0 {
+		config.ShadowTimeout = defaultMirrorShadowTimeout
+	}
+	if config.MaxShadows <= 0 {
+		config.MaxShadows = defaultMirrorMaxShadows
+	}
+	return &Mirror{
+		primary:   primary,
+		secondary: secondary,
+		config:    config,
+		shadows:   make(chan struct{}, config.MaxShadows),
+	}
+}
+
+// Get retrieves data from the primary, unmarshaling into dst, and may
+// shadow the read on the secondary.
+func (m *Mirror) Get(ctx context.Context, resource, key string, dst any, opts ...RequestOption) error {
+	err := m.primary.Get(ctx, resource, key, dst, opts...)
+	if !m.sampled(m.config.ReadPercent, resource, key) {
+		return err
+	}
+	dstType := reflect.TypeOf(dst)
+	if dstType == nil || dstType.Kind() != reflect.Pointer {
+		return err
+	}
+
+	// Encode now, as the caller owns dst once Get returns
+	primary := mirrorJSON(dst, err)
+	m.shadow(ctx, func(ctx context.Context) {
+		v := reflect.New(dstType.Elem()).Interface()
+		serr := m.secondary.Get(ctx, resource, key, v, m.secondaryOpts(opts)...)
+		m.compare("get", resource, key, primary, err, mirrorJSON(v, serr), serr)
+	})
+	return err
+}
+
+// GetRaw retrieves raw data from the primary and may shadow the read on
+// the secondary, comparing response data.
+func (m *Mirror) GetRaw(ctx context.Context, resource, key string, opts ...RequestOption) (*Response, error) {
+	resp, err := m.primary.GetRaw(ctx, resource, key, opts...)
+	if !m.sampled(m.config.ReadPercent, resource, key) {
+		return resp, err
+	}
+	var primary []byte
+	if err == nil {
+		primary = bytes.Clone(resp.Data)
+	}
+	m.shadow(ctx, func(ctx context.Context) {
+		sresp, serr := m.secondary.GetRaw(ctx, resource, key, m.secondaryOpts(opts)...)
+		var secondary []byte
+		if serr == nil {
+			secondary = sresp.Data
+		}
+		m.compare("getraw", resource, key, primary, err, secondary, serr)
+	})
+	return resp, err
+}
+
+// List retrieves the keys of a resource from the primary and may shadow
+// the read on the secondary. Key order is ignored.
+func (m *Mirror) List(ctx context.Context, resource string, opts ...RequestOption) ([]string, error) {
+	keys, err := m.primary.List(ctx, resource, opts...)
+	if !m.sampled(m.config.ReadPercent, resource, "") {
+		return keys, err
+	}
+	primary := sortedKeysJSON(keys, err)
+	m.shadow(ctx, func(ctx context.Context) {
+		skeys, serr := m.secondary.List(ctx, resource, m.secondaryOpts(opts)...)
+		m.compare("list", resource, "", primary, err, sortedKeysJSON(skeys, serr), serr)
+	})
+	return keys, err
+}
+
+// Set stores data on the primary and, once that succeeds, may copy the
+// write to the secondary.
+func (m *Mirror) Set(ctx context.Context, resource, key string, data any, opts ...RequestOption) error {
+	if err := m.primary.Set(ctx, resource, key, data, opts...); err != nil {
+		return err
+	}
+	if m.sampled(m.config.WritePercent, resource, key) {
+		m.writes.Add(1)
+		if err := m.secondary.Set(ctx, resource, key, data, m.secondaryOpts(opts)...); err != nil {
+			m.report(Mismatch{Op: "set", Resource: resource, Key: key, SecondaryErr: err})
+		}
+	}
+	return nil
+}
+
+// Delete removes data from the primary and, once that succeeds, may copy
+// the deletion to the secondary.
+func (m *Mirror) Delete(ctx context.Context, resource, key string, opts ...RequestOption) error {
+	if err := m.primary.Delete(ctx, resource, key, opts...); err != nil {
+		return err
+	}
+	if m.sampled(m.config.WritePercent, resource, key) {
+		m.writes.Add(1)
+		if err := m.secondary.Delete(ctx, resource, key, m.secondaryOpts(opts)...); err != nil && !IsNotFound(err) {
+			m.report(Mismatch{Op: "delete", Resource: resource, Key: key, SecondaryErr: err})
+		}
+	}
+	return nil
+}
+
+// Wait blocks until all shadow reads in flight have finished.
+func (m *Mirror) Wait() {
+	m.wg.Wait()
+}
+
+// Stats returns a snapshot of the mirror's activity counters.
+func (m *Mirror) Stats() MirrorStats {
+	return MirrorStats{
+		MirroredWrites: m.writes.Load(),
+		ShadowReads:    m.reads.Load(),
+		ShadowsSkipped: m.skipped.Load(),
+		Mismatches:     m.mismatches.Load(),
+	}
+}
+
+// sampled reports whether resource and key fall within percent.
+func (m *Mirror) sampled(percent int, resource, key string) bool {
+	return rollout.InPercentage(m.config.Seed, resource+"/"+key, percent)
+}
+
+// secondaryOpts returns opts followed by the configured secondary options.
+func (m *Mirror) secondaryOpts(opts []RequestOption) []RequestOption {
+	if len(m.config.SecondaryOptions) == 0 {
+		return opts
+	}
+	return append(opts[:len(opts):len(opts)], m.config.SecondaryOptions...)
+}
+
+// shadow runs fn in the background, detached from the caller's
+// cancellation and bounded by ShadowTimeout. It is skipped when
+// MaxShadows are already in flight.
+func (m *Mirror) shadow(ctx context.Context, fn func(ctx context.Context)) {
+	select {
+	case m.shadows <- struct{}{}:
+	default:
+		m.skipped.Add(1)
+		return
+	}
+	m.reads.Add(1)
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() { <-m.shadows }()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.config.ShadowTimeout)
+		defer cancel()
+		fn(ctx)
+	}()
+}
+
+// compare reports a mismatch when the primary and secondary results of a
+// read differ. Not-found on both sides is a match.
+func (m *Mirror) compare(op, resource, key string, primary []byte, perr error, secondary []byte, serr error) {
+	switch {
+	case perr == nil && serr == nil:
+		if bytes.Equal(primary, secondary) {
+			return
+		}
+	case perr != nil && serr != nil:
+		if IsNotFound(perr) == IsNotFound(serr) {
+			return
+		}
+	}
+	m.report(Mismatch{
+		Op:           op,
+		Resource:     resource,
+		Key:          key,
+		Primary:      primary,
+		Secondary:    secondary,
+		PrimaryErr:   perr,
+		SecondaryErr: serr,
+	})
+}
+
+// report counts a mismatch and hands it to OnMismatch, or logs it.
+func (m *Mirror) report(mm Mismatch) {
+	m.mismatches.Add(1)
+	if fn := m.config.OnMismatch; fn != nil {
+		_ = SafeCall(m.primary, "mirror mismatch", func() { fn(mm) })
+		return
+	}
+	m.primary.logger().Warn("resolvedb: mirror mismatch",
+		"op", mm.Op, "resource", mm.Resource, "key", mm.Key,
+		"primary_error", mm.PrimaryErr, "secondary_error", mm.SecondaryErr)
+}
+
+// mirrorJSON encodes a read result for comparison, or returns nil if the
+// read failed.
+func mirrorJSON(v any, err error) []byte {
+	if err != nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// sortedKeysJSON encodes listed keys in sorted order for comparison.
+func sortedKeysJSON(keys []string, err error) []byte {
+	if err != nil {
+		return nil
+	}
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	return mirrorJSON(sorted, nil)
+}
@@ -0,0 +1,89 @@
+package resolvedb
+
+import "strings"
+
+// NameMapper rewrites query names between the client and the transport,
+// for deployments that route through vanity zones, split-horizon names or
+// per-tenant subdomains without changing how names are built.
+//
+// MapName receives a fully built query name and returns the name sent on
+// the wire. UnmapName reverses it, and is applied to names reported back
+// by the transport, such as ResponseMeta.CanonicalName, so logs, errors
+// and observers only ever see the logical name. Mapped names must remain
+// valid DNS names. Implementations must be safe for concurrent use.
+type NameMapper interface {
+	MapName(name string) string
+	UnmapName(name string) string
+}
+
+// WithNameMapper rewrites every query name with m before it is sent.
+//
+// Example:
+//
+//	// Send queries for the public zone through a tenant's vanity zone
+//	client, err := resolvedb.New(resolvedb.WithNameMapper(
+//	    resolvedb.SuffixMapper("resolvedb.net", "acme.dns.example.com")))
+func WithNameMapper(m NameMapper) Option {
+	return func(c *clientConfig) {
+		c.nameMapper = m
+	}
+}
+
+// SuffixMapper returns a NameMapper that replaces the domain suffix from
+// with to, and back. Names outside from are left alone.
+func SuffixMapper(from, to string) NameMapper {
+	return suffixMapper{from: normalizeDomain(from), to: normalizeDomain(to)}
+}
+
+type suffixMapper struct {
+	from, to string
+}
+
+func (m suffixMapper) MapName(name string) string {
+	return replaceSuffix(name, m.from, m.to)
+}
+
+func (m suffixMapper) UnmapName(name string) string {
+	return replaceSuffix(name, m.to, m.from)
+}
+
+// replaceSuffix replaces the domain suffix from of name with to, matching
+// whole labels in any case. A trailing dot on name is kept.
+func replaceSuffix(name, from, to string) string {
+	base, dot := strings.CutSuffix(name, ".")
+	lower := strings.ToLower(base)
+	var prefix string
+	switch {
+	case lower == from:
+	case strings.HasSuffix(lower, "."+from):
+		prefix = base[:len(base)-len(from)]
+	default:
+		return name
+	}
+	if dot {
+		return prefix + to + "."
+	}
+	return prefix + to
+}
+
+// normalizeDomain lowercases a domain and strips its leading and trailing
+// dots.
+func normalizeDomain(domain string) string {
+	return strings.Trim(strings.ToLower(domain), ".")
+}
+
+// mapName returns the wire name of a query name.
+func (c *Client) mapName(name string) string {
+	if c.config.nameMapper == nil {
+		return name
+	}
+	return c.config.nameMapper.MapName(name)
+}
+
+// unmapName returns the logical name of a wire name.
+func (c *Client) unmapName(name string) string {
+	if c.config.nameMapper == nil || name == "" {
+		return name
+	}
+	return c.config.nameMapper.UnmapName(name)
+}
@@ -0,0 +1,142 @@
+package resolvedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// acrossConcurrency bounds the namespaces queried at once by ListAcross
+// and GetAcross.
+const acrossConcurrency = 8
+
+// NamespaceError is the error of one namespace in a ListAcross or
+// GetAcross call.
+type NamespaceError struct {
+	Namespace string
+	Err       error
+}
+
+func (e *NamespaceError) Error() string {
+	return fmt.Sprintf("resolvedb: namespace %s: %v", e.Namespace, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *NamespaceError) Unwrap() error {
+	return e.Err
+}
+
+// ListAcross lists the keys of a resource in each of the given namespaces,
+// for platform teams operating on many tenant namespaces at once. Each
+// namespace is queried as if with WithRequestNamespace, so auth tokens are
+// signed for that namespace.
+//
+// Keys are returned by namespace. Namespaces that fail are reported as
+// *NamespaceError values joined in the order given, and the others are
+// still returned; a namespace whose list could only be read in part keeps
+// the keys read. Pre-issued auth and read tokens are bound to a single
+// namespace, so requests carrying them fail with ErrNamespaceBoundToken.
+//
+// Example:
+//
+//	keys, err := client.ListAcross(ctx, []string{"tenant-a", "tenant-b"}, "config")
+//	for ns, k := range keys {
+//	    fmt.Printf("%s: %d keys\n", ns, len(k))
+//	}
+func (c *Client) ListAcross(ctx context.Context, namespaces []string, resource string, opts ...RequestOption) (map[string][]string, error) {
+	if err := checkAcrossOptions(ctx, opts); err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	keys := make(map[string][]string, len(namespaces))
+	err := acrossNamespaces(ctx, namespaces, func(ctx context.Context, ns string) error {
+		k, err := c.List(ctx, resource, withNamespace(opts, ns)...)
+		var perr *PartialResultError
+		if err == nil || errors.As(err, &perr) {
+			mu.Lock()
+			keys[ns] = k
+			mu.Unlock()
+		}
+		return err
+	})
+	return keys, err
+}
+
+// GetAcross reads resource/key in each of the given namespaces, returning
+// the values by namespace. Auth tokens and errors are handled as in
+// Client.ListAcross; a namespace without the key is reported with an error
+// matching ErrNotFound.
+//
+// Example:
+//
+//	limits, err := resolvedb.GetAcross[Limits](ctx, client, tenants, "config", "limits")
+//	if err != nil {
+//	    log.Printf("some tenants failed: %v", err)
+//	}
+func GetAcross[T any](ctx context.Context, c *Client, namespaces []string, resource, key string, opts ...RequestOption) (map[string]T, error) {
+	if err := checkAcrossOptions(ctx, opts); err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	values := make(map[string]T, len(namespaces))
+	err := acrossNamespaces(ctx, namespaces, func(ctx context.Context, ns string) error {
+		var v T
+		if err := c.Get(ctx, resource, key, &v, withNamespace(opts, ns)...); err != nil {
+			return err
+		}
+		mu.Lock()
+		values[ns] = v
+		mu.Unlock()
+		return nil
+	})
+	return values, err
+}
+
+// checkAcrossOptions rejects request options carrying tokens bound to a
+// single namespace.
+func checkAcrossOptions(ctx context.Context, opts []RequestOption) error {
+	reqConfig := newRequestConfig(ctx, opts)
+	if reqConfig.authToken != "" || reqConfig.readToken != "" {
+		return ErrNamespaceBoundToken
+	}
+	return nil
+}
+
+// withNamespace returns opts followed by a namespace override for ns.
+func withNamespace(opts []RequestOption, ns string) []RequestOption {
+	return append(opts[:len(opts):len(opts)], WithRequestNamespace(ns))
+}
+
+// acrossNamespaces calls fn for each distinct namespace, at most
+// acrossConcurrency at a time, and joins the errors as *NamespaceError
+// values in the order given.
+func acrossNamespaces(ctx context.Context, namespaces []string, fn func(ctx context.Context, ns string) error) error {
+	errs := make([]error, len(namespaces))
+	seen := make(map[string]bool, len(namespaces))
+	sem := make(chan struct{}, acrossConcurrency)
+
+	var wg sync.WaitGroup
+	for i, ns := range namespaces {
+		if seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = &NamespaceError{Namespace: ns, Err: context.Cause(ctx)}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, ns string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, ns); err != nil {
+				errs[i] = &NamespaceError{Namespace: ns, Err: err}
+			}
+		}(i, ns)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,91 @@
+package resolvedb
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// QueryObserver observes the queries a client sends, for tracing and
+// metrics integrations such as OpenTelemetry. It is the stable interface
+// those integrations build on, so they can live in their own modules and
+// keep their dependencies out of the core SDK.
+//
+// StartQuery is called before a query's first attempt. The context it
+// returns is used for the query, so spans started there parent transport
+// calls made with it, and the function it returns is called once when the
+// query finishes. Cache hits are not queries and are not observed.
+// Implementations must be safe for concurrent use.
+type QueryObserver interface {
+	StartQuery(ctx context.Context, q QueryInfo) (context.Context, func(QueryResult))
+}
+
+// QueryInfo describes a query passed to a QueryObserver.
+type QueryInfo struct {
+	Op        string // Operation, e.g. "get"
+	Resource  string
+	Key       string
+	Namespace string
+	Name      string // Query name, including any auth label
+}
+
+// QueryResult describes the outcome of an observed query.
+type QueryResult struct {
+	Err       error         // Transport or protocol failure, nil otherwise
+	Cause     error         // Why the query's context ended, nil if it did not; see QueryError.Cause
+	Status    string        // Response status, empty on failure
+	Attempts  int           // Attempts made
+	Transport string        // Transport that answered or failed last
+	Region    string        // Region that answered, empty without WithRegions
+	Duration  time.Duration // Time from the first attempt to the outcome
+}
+
+// WithQueryObserver reports every query to o.
+//
+// Example:
+//
+//	client, err := resolvedb.New(resolvedb.WithQueryObserver(tracing.New(tracer)))
+func WithQueryObserver(o QueryObserver) Option {
+	return func(c *clientConfig) {
+		c.observer = o
+	}
+}
+
+// observeQuery starts observing a query, returning the context to run it
+// with and the function to call with its outcome. Without an observer the
+// context is returned as is.
+func (c *Client) observeQuery(ctx context.Context, operation, resource, key, queryName string, reqConfig *requestConfig) (context.Context, func(*Response, error)) {
+	o := c.config.observer
+	if o == nil {
+		return ctx, func(*Response, error) {}
+	}
+	info := QueryInfo{
+		Op:        operation,
+		Resource:  resource,
+		Key:       key,
+		Namespace: c.namespace(reqConfig),
+		Name:      queryName,
+	}
+	if info.Namespace == "" {
+		info.Namespace = c.config.defaultNamespace
+	}
+	start := c.config.clock.Now()
+	var end func(QueryResult)
+	ctx, end = o.StartQuery(ctx, info)
+	return ctx, func(resp *Response, err error) {
+		r := QueryResult{Err: err, Duration: c.config.clock.Now().Sub(start)}
+		if resp != nil {
+			r.Status = resp.Status
+			r.Attempts = resp.Meta.Attempts
+			r.Transport = resp.Meta.Transport
+			r.Region = resp.Meta.Region
+		}
+		var qerr *QueryError
+		if errors.As(err, &qerr) {
+			r.Attempts = qerr.Attempts
+			r.Transport = qerr.Transport
+			r.Cause = qerr.Cause
+		}
+		end(r)
+	}
+}
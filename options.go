@@ -1,11 +1,16 @@
 package resolvedb
 
 import (
+	cryptorand "crypto/rand"
 	"fmt"
-	"net/http"
+	"io"
+	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/resolvedb/resolvedb-go/security"
 	"github.com/resolvedb/resolvedb-go/transport"
+	"github.com/resolvedb/resolvedb-go/uqrp"
 )
 
 // Option configures a Client.
@@ -24,8 +29,54 @@ type clientConfig struct {
 	cacheConfig     CacheConfig
 	encryptionKey   *[32]byte
 	tenantQueryKey  []byte
-	httpClient      *http.Client
+	httpClient      any // *http.Client; untyped so builds without net/http compile
 	enforceSecurity bool
+
+	defaultNamespace   string
+	apexLabels         []string
+	labelOrder         []Label
+	labelEncoding      LabelEncoding
+	tokenPlacement     TokenPlacement
+	zone               string
+	authTokenTTL       time.Duration
+	maxConcurrency     int
+	cacheDecrypted     bool
+	schemas            map[string]*Schema
+	migrators          map[string]*Migrator
+	lenientDecoding    bool
+	compactResources   map[string]bool
+	logger             *slog.Logger
+	regions            []Region
+	readPreference     ReadPreference
+	regionStickiness   time.Duration
+	failoverStickiness time.Duration
+	chunkConcurrency   int
+	chunkRetries       int
+	session            bool
+	autoCodec          bool
+	clock              Clock
+	rand               io.Reader
+	profilerLabels     bool
+	adaptiveTimeout    *AdaptiveTimeoutConfig
+	payloadWarning     *payloadWarningConfig
+	cacheKeyLimit      int
+	cardinalityAction  CardinalityAction
+	answerValidation   transport.AnswerValidation
+	maxCNAMEs          int
+	responseLimits     ResponseLimits
+	acl                []ACLRule
+	responsePooling    bool
+	dictionaries       map[string]string
+	prefetch           int
+	strictKeys         bool
+	attemptTimeout     time.Duration
+	warningHandler     func(Warning)
+	observer           QueryObserver
+	nameMapper         NameMapper
+	keyProvider        KeyProvider
+	payloadSigner      security.PayloadSigner
+	versionCheck       bool
+	reloaded           reloadable // Settings set by options Update accepts
 }
 
 // defaultConfig returns the default client configuration.
@@ -38,6 +89,31 @@ func defaultConfig() *clientConfig {
 		retryConfig:     DefaultRetryConfig(),
 		cacheConfig:     DefaultCacheConfig(),
 		enforceSecurity: true,
+
+		defaultNamespace: "public",
+		labelOrder:       DefaultLabelOrder(),
+		authTokenTTL:     15 * time.Second,
+		compactResources: map[string]bool{"weather": true, "geoip": true},
+		regionStickiness: defaultRegionStickiness,
+		chunkConcurrency: defaultChunkConcurrency,
+		chunkRetries:     defaultChunkRetries,
+		responseLimits:   DefaultResponseLimits(),
+		clock:            systemClock{},
+		rand:             cryptorand.Reader,
+	}
+}
+
+// layout returns the query name layout of the configuration.
+func (c *clientConfig) layout() uqrp.Layout {
+	return uqrp.Layout{
+		Order:            c.labelOrder,
+		Version:          c.version,
+		TLD:              c.tld,
+		ApexLabels:       c.apexLabels,
+		Zone:             c.zone,
+		DefaultNamespace: c.defaultNamespace,
+		Encoding:         c.labelEncoding,
+		Tokens:           c.tokenPlacement,
 	}
 }
 
@@ -69,6 +145,119 @@ func WithTLD(tld string) Option {
 	}
 }
 
+// WithDefaultNamespace sets the namespace label used when no namespace is
+// configured (default: "public").
+func WithDefaultNamespace(ns string) Option {
+	return func(c *clientConfig) {
+		c.defaultNamespace = ns
+	}
+}
+
+// WithApexLabels sets the labels appended after the version label
+// (default: "resolvedb", <tld>). Overrides WithTLD.
+//
+// Example:
+//
+//	// Queries end in ".v1.rdb.example.com"
+//	resolvedb.WithApexLabels("rdb", "example", "com")
+func WithApexLabels(labels ...string) Option {
+	return func(c *clientConfig) {
+		c.apexLabels = labels
+	}
+}
+
+// WithZone sets the DNS zone of a self-hosted ResolveDB-compatible server.
+// The zone replaces the <version>.resolvedb.<tld> suffix, so queries take the
+// form <operation>.<key>.<resource>.<namespace>.<zone>. The DoH endpoint is
+// not derived from the zone; set it with WithBaseURL or WithTransports.
+//
+// Example:
+//
+//	client, err := resolvedb.New(
+//	    resolvedb.WithZone("data.internal.corp"),
+//	    resolvedb.WithBaseURL("https://data.internal.corp"),
+//	)
+func WithZone(zone string) Option {
+	return func(c *clientConfig) {
+		c.zone = strings.TrimSuffix(strings.ToLower(zone), ".")
+	}
+}
+
+// Label identifies a location component of a query name.
+type Label = uqrp.Label
+
+// Query name location components.
+const (
+	LabelKey       = uqrp.LabelKey
+	LabelResource  = uqrp.LabelResource
+	LabelNamespace = uqrp.LabelNamespace
+	LabelVersion   = uqrp.LabelVersion
+)
+
+// DefaultLabelOrder returns the standard label layout:
+// <key>.<resource>.<namespace>.<version>.
+func DefaultLabelOrder() []Label {
+	return uqrp.DefaultLabelOrder()
+}
+
+// WithLabelOrder sets the order of the location labels between the operation
+// (and any tokens) and the apex labels. Key and resource labels are required;
+// namespace and version labels may be omitted for custom zone layouts.
+func WithLabelOrder(order ...Label) Option {
+	return func(c *clientConfig) {
+		c.labelOrder = order
+	}
+}
+
+// LabelEncoding selects how binary data is encoded in query labels.
+type LabelEncoding = uqrp.Encoding
+
+// Label encodings.
+const (
+	// LabelEncodingBase64 encodes data as base64url, prefixed "b64-" in data
+	// labels. It is the most compact encoding.
+	LabelEncodingBase64 = uqrp.EncodingBase64
+
+	// LabelEncodingBase32 encodes data as lowercase base32hex, prefixed
+	// "b32-". Its alphabet is case-insensitive and free of "_", so it
+	// survives resolvers and middleboxes that fold or randomize case or
+	// mangle unusual hostname characters, at the cost of longer labels.
+	LabelEncodingBase32 = uqrp.EncodingBase32
+)
+
+// WithLabelEncoding sets the encoding of data, field, query and index
+// labels (default: LabelEncodingBase64).
+func WithLabelEncoding(enc LabelEncoding) Option {
+	return func(c *clientConfig) {
+		c.labelEncoding = enc
+	}
+}
+
+// TokenPlacement selects where security token labels (BDT, CTP, NBA and
+// read tokens) go in query names.
+type TokenPlacement = uqrp.TokenPlacement
+
+// Token placements.
+const (
+	// TokensAfterOperation writes tokens right after the operation, before
+	// the auth label. It is the default.
+	TokensAfterOperation = uqrp.TokensAfterOperation
+
+	// TokensAfterAuth writes tokens after the auth label, as some other
+	// SDKs do. Use it when a deployment shared with them rejects tokens
+	// placed after the operation.
+	TokensAfterAuth = uqrp.TokensAfterAuth
+)
+
+// WithTokenPlacement pins where security tokens go in query names
+// (default: TokensAfterOperation), for interoperating with servers that
+// expect the placement of another SDK.
+func WithTokenPlacement(p TokenPlacement) Option {
+	return func(c *clientConfig) {
+		c.tokenPlacement = p
+	}
+}
+
 // WithBaseURL sets the DoH endpoint URL (default: "https://api.resolvedb.io").
 func WithBaseURL(url string) Option {
 	return func(c *clientConfig) {
@@ -81,6 +270,91 @@ func WithBaseURL(url string) Option {
 func WithTransports(transports ...transport.Transport) Option {
 	return func(c *clientConfig) {
 		c.transports = transports
+		c.reloaded |= reloadTransports
+	}
+}
+
+// WithAnswerValidation sets which records of a DNS answer make up a
+// response (default: transport.ValidateOwner, records owned by the query
+// name). Use transport.ValidateOwnerCNAME for data published behind
+// CNAME aliases, or transport.ValidateNone to use every record as
+// resolvers return them.
+func WithAnswerValidation(v transport.AnswerValidation) Option {
+	return func(c *clientConfig) {
+		c.answerValidation = v
+	}
+}
+
+// WithCNAMEFollowing reads resources published behind CNAME aliases, such
+// as a vanity zone pointing at resolvedb.net, following up to maxDepth
+// aliases from the query name (default: transport.DefaultMaxCNAMEs).
+// Longer chains fail as malformed. Response.Meta.CanonicalName reports the
+// name that answered. It implies
+// WithAnswerValidation(transport.ValidateOwnerCNAME).
+//
+// Example:
+//
+//	client, _ := resolvedb.New(
+//	    resolvedb.WithZone("data.example.com"), // CNAME to resolvedb.net
+//	    resolvedb.WithCNAMEFollowing(4),
+//	)
+func WithCNAMEFollowing(maxDepth int) Option {
+	return func(c *clientConfig) {
+		c.answerValidation = transport.ValidateOwnerCNAME
+		c.maxCNAMEs = maxDepth
+	}
+}
+
+// WithFailoverStickiness makes a client with several transports remember
+// for d that a transport failed, sending later queries straight to the
+// next transport while the failed one is probed in the background.
+// Zero disables stickiness (the default). See transport.WithFailoverStickiness.
+func WithFailoverStickiness(d time.Duration) Option {
+	return func(c *clientConfig) {
+		c.failoverStickiness = d
+	}
+}
+
+// WithRegions configures regional endpoints. Writes go to the primary
+// region; reads follow the read preference. WithRegions replaces
+// WithTransports and WithBaseURL.
+//
+// Example:
+//
+//	client, err := resolvedb.New(
+//	    resolvedb.WithRegions(
+//	        resolvedb.Region{Name: "us-east", Transport: transport.NewDoH(transport.WithDoHURL("https://us-east.api.resolvedb.io/dns-query"))},
+//	        resolvedb.Region{Name: "eu-west", Transport: transport.NewDoH(transport.WithDoHURL("https://eu-west.api.resolvedb.io/dns-query"))},
+//	    ),
+//	    resolvedb.WithReadPreference(resolvedb.NearestRegion),
+//	)
+func WithRegions(primary Region, others ...Region) Option {
+	return func(c *clientConfig) {
+		c.regions = append([]Region{primary}, others...)
+	}
+}
+
+// WithReadPreference sets which region serves reads (default: PrimaryOnly).
+func WithReadPreference(p ReadPreference) Option {
+	return func(c *clientConfig) {
+		c.readPreference = p
+	}
+}
+
+// WithRegionStickiness sets how long reads of a written key stay on the
+// primary region (default: 30s). Zero disables stickiness.
+func WithRegionStickiness(d time.Duration) Option {
+	return func(c *clientConfig) {
+		c.regionStickiness = d
+	}
+}
+
+// WithSessionConsistency makes reads see the client's own writes: the
+// consistency token of each write is remembered per key and sent with
+// later reads of that key, as with WithConsistencyToken.
+func WithSessionConsistency() Option {
+	return func(c *clientConfig) {
+		c.session = true
 	}
 }
 
@@ -91,10 +365,58 @@ func WithTimeout(d time.Duration) Option {
 	}
 }
 
+// WithAttemptTimeout bounds each attempt of a query, so a stalled attempt
+// is abandoned and retried while the caller's deadline, or WithTimeout,
+// still bounds the query as a whole. With RetryConfig.SplitDeadline the
+// sooner of the two deadlines applies.
+//
+// Example:
+//
+//	client, err := resolvedb.New(
+//	    resolvedb.WithTimeout(10*time.Second),
+//	    resolvedb.WithAttemptTimeout(2*time.Second),
+//	)
+func WithAttemptTimeout(d time.Duration) Option {
+	return func(c *clientConfig) {
+		c.attemptTimeout = d
+	}
+}
+
 // WithRetry configures retry behavior.
 func WithRetry(config RetryConfig) Option {
 	return func(c *clientConfig) {
 		c.retryConfig = config
+		c.reloaded |= reloadRetry
+	}
+}
+
+// WithAdaptiveTimeout bounds each query by a multiple of the recent p99
+// latency of the transport it is sent on, so a stalled query fails fast
+// and can be retried instead of waiting out the static timeout. Until a
+// transport has MinSamples samples, only the static timeout applies. Zero
+// fields take their defaults from DefaultAdaptiveTimeoutConfig.
+//
+// Example:
+//
+//	client, err := resolvedb.New(
+//	    resolvedb.WithAdaptiveTimeout(resolvedb.AdaptiveTimeoutConfig{Multiplier: 4}),
+//	)
+func WithAdaptiveTimeout(config AdaptiveTimeoutConfig) Option {
+	return func(c *clientConfig) {
+		def := DefaultAdaptiveTimeoutConfig()
+		if config.Multiplier <= 0 {
+			config.Multiplier = def.Multiplier
+		}
+		if config.Min <= 0 {
+			config.Min = def.Min
+		}
+		if config.Window <= 0 {
+			config.Window = def.Window
+		}
+		if config.MinSamples <= 0 {
+			config.MinSamples = def.MinSamples
+		}
+		c.adaptiveTimeout = &config
 	}
 }
 
@@ -102,6 +424,7 @@ func WithRetry(config RetryConfig) Option {
 func WithCache(config CacheConfig) Option {
 	return func(c *clientConfig) {
 		c.cacheConfig = config
+		c.reloaded |= reloadCache
 	}
 }
 
@@ -118,6 +441,27 @@ func WithEncryptionKey(key []byte) Option {
 	}
 }
 
+// WithDecryptedCache caches the plaintext of values read with GetEncrypted,
+// so hot secrets are not decrypted with AES-GCM on every call.
+//
+// Security: decrypted values are held in process memory, in the configured
+// Cache, for up to the response TTL. Only enable this when the cache is not
+// shared with less trusted code and is not persisted. Use
+// WithoutDecryptedCache to opt individual reads out.
+func WithDecryptedCache() Option {
+	return func(c *clientConfig) {
+		c.cacheDecrypted = true
+	}
+}
+
+// WithRefreshCache bypasses the cache lookup for this request but stores
+// the fresh response, replacing any cached entry.
+func WithRefreshCache() RequestOption {
+	return func(c *requestConfig) {
+		c.refresh = true
+	}
+}
+
 // WithTenantQueryKey sets the key for NBA (Namespace-Bound Authentication) signatures.
 func WithTenantQueryKey(key []byte) Option {
 	return func(c *clientConfig) {
@@ -126,10 +470,172 @@ func WithTenantQueryKey(key []byte) Option {
 	}
 }
 
-// WithHTTPClient sets a custom HTTP client for DoH transport.
-func WithHTTPClient(client *http.Client) Option {
+// WithPayloadSigner adds a detached signature over the payload of every
+// write to its query, alongside the auth label, so servers can verify the
+// payload end to end even when intermediate proxies re-encode the query
+// name. The signature covers the operation, resource, key, namespace and
+// payload bytes (see security.PayloadMessage) and takes room from the
+// payload: about 60 bytes of the name for HMAC-SHA256 and 120 for Ed25519.
+//
+// Example:
+//
+//	client, err := resolvedb.New(
+//	    resolvedb.WithAPIKey(apiKey),
+//	    resolvedb.WithPayloadSigner(security.NewEd25519PayloadSigner(deviceKey)),
+//	)
+func WithPayloadSigner(s security.PayloadSigner) Option {
+	return func(c *clientConfig) {
+		c.payloadSigner = s
+	}
+}
+
+// WithAuthTokenTTL sets how long a signed auth token is reused for the same
+// operation, resource and key before a new one is signed (default: 15s).
+// Keep it well below the server's token validity window. Zero disables reuse.
+func WithAuthTokenTTL(d time.Duration) Option {
+	return func(c *clientConfig) {
+		c.authTokenTTL = d
+	}
+}
+
+// WithMaxConcurrency caps the number of queries the client has in flight at
+// once across all goroutines (default: unlimited). Callers beyond the cap
+// wait for a free slot or for their context to be done, which protects
+// small resolvers from bursts.
+func WithMaxConcurrency(n int) Option {
+	return func(c *clientConfig) {
+		c.maxConcurrency = n
+	}
+}
+
+// Defaults for chunked reads.
+const (
+	defaultChunkConcurrency = 8
+	defaultChunkRetries     = 2
+)
+
+// WithChunkConcurrency caps the chunks of a chunked value fetched in
+// parallel (default: 8). Zero fetches all chunks at once.
+func WithChunkConcurrency(n int) Option {
+	return func(c *clientConfig) {
+		c.chunkConcurrency = n
+	}
+}
+
+// WithChunkRetries sets how many times a failed chunk of a chunked value is
+// retried (default: 2). Each chunk is retried on its own with the client's
+// backoff, so chunks already fetched are kept. Zero disables chunk retries.
+func WithChunkRetries(n int) Option {
+	return func(c *clientConfig) {
+		c.chunkRetries = n
+	}
+}
+
+// WithSchema registers a schema that values written to resource must
+// satisfy. Set, SetEncrypted and transactions validate payloads before
+// sending them and return a *ValidationError on violation.
+//
+// Example:
+//
+//	client, err := resolvedb.New(
+//	    resolvedb.WithAPIKey(key),
+//	    resolvedb.WithSchema("config", resolvedb.MustCompileSchema(configSchema)),
+//	)
+func WithSchema(resource string, schema *Schema) Option {
+	return func(c *clientConfig) {
+		if c.schemas == nil {
+			c.schemas = make(map[string]*Schema)
+		}
+		c.schemas[resource] = schema
+	}
+}
+
+// WithCompactExpansion sets the resources whose compact response fields
+// are expanded to full names (e.g., "tc" to "temp_c"). The default is
+// "weather" and "geoip". Calling it with no resources disables expansion,
+// leaving short keys of unrelated datasets untouched.
+func WithCompactExpansion(resources ...string) Option {
+	return func(c *clientConfig) {
+		c.compactResources = make(map[string]bool, len(resources))
+		for _, r := range resources {
+			c.compactResources[r] = true
+		}
+	}
+}
+
+// WithStrictKeys rejects resources, keys and namespaces that are not
+// valid DNS labels as given, with an error wrapping ErrInvalidKey, instead
+// of sanitizing them. Sanitizing lowercases, drops invalid characters and
+// truncates to 63 bytes, so distinct keys can map to the same label, e.g.
+// "User_1" and "user-1", or two long keys sharing a 63-byte prefix.
+func WithStrictKeys() Option {
+	return func(c *clientConfig) {
+		c.strictKeys = true
+	}
+}
+
+// WithLenientDecoding matches response fields to struct fields ignoring
+// case, underscores and hyphens, so untagged Go structs decode snake_case
+// and camelCase data. Unmatched fields are logged as warnings.
+// See Response.UnmarshalLenient.
+func WithLenientDecoding() Option {
+	return func(c *clientConfig) {
+		c.lenientDecoding = true
+	}
+}
+
+// WithAutoCodec makes Set pick the smallest encoding that fits a value
+// in a query: plain JSON, then compact field names, then compression, then
+// a chunked blob whose chunks are kept in the reserved "rdb-chunks"
+// resource. Get and GetStream reverse the encoding transparently, and
+// Delete removes the chunks of a chunked value. Values written this way
+// are only readable by clients with WithAutoCodec. Values written with
+// WithFormat are stored raw and never decoded. See Codec.
+func WithAutoCodec() Option {
+	return func(c *clientConfig) {
+		c.autoCodec = true
+	}
+}
+
+// WithClock sets the client's source of time (default: the system clock).
+// See Clock.
+func WithClock(clock Clock) Option {
+	return func(c *clientConfig) {
+		c.clock = clock
+	}
+}
+
+// WithRandSource sets the client's source of randomness for retry jitter,
+// generated idempotency keys and DNS transaction IDs (default:
+// crypto/rand). A seeded source makes these reproducible in simulations
+// and tests.
+//
+// Security: transaction IDs guard plaintext DNS against spoofed responses.
+// Use a predictable source only in tests.
+func WithRandSource(r io.Reader) Option {
+	return func(c *clientConfig) {
+		c.rand = r
+	}
+}
+
+// WithProfilerLabels runs queries under pprof labels naming the operation,
+// resource and transport (see ProfileLabelOperation), so CPU profiles of
+// busy services attribute time to individual resources:
+//
+//	go tool pprof -tagfocus=resolvedb.resource=flags cpu.pprof
+//
+// Labels cost an allocation per query, so they are off by default.
+func WithProfilerLabels() Option {
 	return func(c *clientConfig) {
-		c.httpClient = client
+		c.profilerLabels = true
+	}
+}
+
+// WithLogger sets the logger for client diagnostics (default: discard).
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *clientConfig) {
+		c.logger = logger
+		c.reloaded |= reloadLogger
 	}
 }
 
@@ -147,13 +653,43 @@ type RequestOption func(*requestConfig)
 
 // requestConfig holds per-request configuration.
 type requestConfig struct {
-	ttl        time.Duration
-	forceBlob  bool
-	skipCache  bool
-	encrypt    bool
-	bdtToken   string
-	ctpToken   string
-	nbaToken   string
+	ttl       time.Duration
+	forceBlob bool
+	skipCache bool
+	refresh   bool
+	cacheTTL  time.Duration
+	pin       bool
+	encrypt   bool
+	bdtToken  string
+	ctpToken  string
+	nbaToken  string
+	authToken string
+	readToken string
+
+	noResolverCache      bool
+	requireAuthoritative bool
+
+	ignoreNotFound   bool
+	noDecryptedCache bool
+	namespace        string
+	transportName    string
+	maxAge           time.Duration
+	pollInterval     time.Duration
+	watchBuffer      int
+	qps              float64
+	consistencyToken string
+	idempotencyKey   string
+	ifMatch          string // Content hash digest prefix, or uqrp.IfAbsent
+	fields           []string
+	keysOnly         bool
+	expiry           time.Duration // WithExpiry, counted from when the write is sent
+	expiresAt        time.Time
+	chunkProgress    func(ChunkProgress)
+	fallback         func() (any, error)
+	format           Format
+	encoding         Encoding
+	params           []string // Operation parameter labels, set internally
+	prefetched       bool     // Background prefetch, whose hints are not followed
 }
 
 // WithTTL sets the TTL for a write operation.
@@ -163,6 +699,49 @@ func WithTTL(d time.Duration) RequestOption {
 	}
 }
 
+// WithExpiry makes the server delete the key d after the write. Unlike
+// WithTTL, which bounds how long resolvers and caches may serve the value,
+// the key itself is removed once it expires; reads then return ErrNotFound.
+// Use Response.Expires or Client.Expiry to read the remaining lifetime.
+//
+// Example:
+//
+//	// Presence record that disappears unless refreshed
+//	err := client.Set(ctx, "presence", deviceID, status, resolvedb.WithExpiry(90*time.Second))
+func WithExpiry(d time.Duration) RequestOption {
+	return func(c *requestConfig) {
+		c.expiry = d
+		c.expiresAt = time.Time{}
+	}
+}
+
+// WithFormat stores a written value in format, which the server records
+// and reports in the format field of answers. FormatText writes a string
+// as is, rather than as a JSON string, and FormatBinary writes a []byte
+// as is. WithAutoCodec does not apply to such writes.
+//
+// Example:
+//
+//	// Readable with: dig TXT get.motd.site.public.v1.resolvedb.net
+//	err := client.Set(ctx, "site", "motd", "Back at 5pm",
+//	    resolvedb.WithFormat(resolvedb.FormatText),
+//	    resolvedb.WithEncoding(resolvedb.EncodingPlain))
+func WithFormat(format Format) RequestOption {
+	return func(c *requestConfig) {
+		c.format = format
+	}
+}
+
+// WithEncoding makes the server store a written value with encoding and
+// serve it that way in TXT answers, so tools other than this client, such
+// as dig, can read EncodingPlain values unmodified. The value still
+// travels in the query name with the configured label encoding.
+func WithEncoding(encoding Encoding) RequestOption {
+	return func(c *requestConfig) {
+		c.encoding = encoding
+	}
+}
+
 // WithForceBlob forces data to be stored as a blob, bypassing TXT record limits.
 func WithForceBlob(force bool) RequestOption {
 	return func(c *requestConfig) {
@@ -177,7 +756,45 @@ func WithSkipCache() RequestOption {
 	}
 }
 
-// WithEncrypt enables encryption for this request.
+// WithCacheTTL caches the response of this read for d, regardless of the
+// TTL the server sent. It does not change how long resolvers cache it.
+//
+// Example:
+//
+//	// The catalog changes daily; don't re-query it every 5 minutes
+//	err := client.Get(ctx, "catalog", "datasets", &list, resolvedb.WithCacheTTL(time.Hour))
+func WithCacheTTL(d time.Duration) RequestOption {
+	return func(c *requestConfig) {
+		c.cacheTTL = d
+	}
+}
+
+// WithPin keeps the cached response of this read from being evicted to make
+// room when the cache is full, for entries that must stay available, such
+// as kill-switch flags. Pinned entries still expire with their TTL and are
+// invalidated by writes; they count towards CacheConfig.MaxEntries, so pin
+// sparingly.
+//
+// Example:
+//
+//	err := client.Get(ctx, "flags", "payments-kill-switch", &flag, resolvedb.WithPin())
+func WithPin() RequestOption {
+	return func(c *requestConfig) {
+		c.pin = true
+	}
+}
+
+// WithoutDecryptedCache prevents GetEncrypted from caching the decrypted
+// value of this read when WithDecryptedCache is enabled.
+func WithoutDecryptedCache() RequestOption {
+	return func(c *requestConfig) {
+		c.noDecryptedCache = true
+	}
+}
+
+// WithEncrypt marks a request as being for an encrypted value.
+// GetEncrypted and SetEncrypted add it themselves; pass it to Delete so
+// that deleting a value SetEncrypted stored in chunks deletes the chunks.
 func WithEncrypt() RequestOption {
 	return func(c *requestConfig) {
 		c.encrypt = true
@@ -204,3 +821,203 @@ func WithNBA(signature string) RequestOption {
 		c.nbaToken = signature
 	}
 }
+
+// WithAuthToken sends a pre-issued auth token (see
+// Client.PreIssueAuthTokens) instead of signing one, so a device without
+// the API key can build writes for a store-and-forward gateway to relay.
+// The token must have been issued for the same operation, resource, key
+// and namespace, and be valid when the query reaches the server.
+func WithAuthToken(token string) RequestOption {
+	return func(c *requestConfig) {
+		c.authToken = token
+	}
+}
+
+// WithReadToken attaches a delegated read token minted with
+// Client.MintReadToken, granting this read without an API key.
+// Values read with it are cached apart from those read without it or with
+// another token.
+func WithReadToken(token string) RequestOption {
+	return func(c *requestConfig) {
+		c.readToken = token
+	}
+}
+
+// WithRequestNamespace sets the namespace for this request, overriding the
+// client's namespace.
+func WithRequestNamespace(ns string) RequestOption {
+	return func(c *requestConfig) {
+		c.namespace = ns
+	}
+}
+
+// WithTransportName pins this request to the configured transport with the
+// given name (e.g., "doh", "dot", "dns"), bypassing fallback. Requests fail
+// with ErrUnknownTransport if no configured transport has that name.
+func WithTransportName(name string) RequestOption {
+	return func(c *requestConfig) {
+		c.transportName = name
+	}
+}
+
+// WithMaxAge sets the oldest server data this read accepts, based on the
+// response timestamp. Cached responses older than d are refetched; if the
+// fresh response is still older, the read fails with ErrStale. Responses
+// without a timestamp are always accepted.
+func WithMaxAge(d time.Duration) RequestOption {
+	return func(c *requestConfig) {
+		c.maxAge = d
+	}
+}
+
+// WithNoResolverCache adds a random single-use nonce label to the query
+// name, so no DNS resolver between the client and the server can answer
+// from its cache. WithSkipCache only bypasses the client's own cache;
+// this reaches past the resolver chain too, for reads right after a
+// write. The client cache lookup is skipped as with WithRefreshCache. Each
+// such query reaches the server, so use it sparingly.
+func WithNoResolverCache() RequestOption {
+	return func(c *requestConfig) {
+		c.noResolverCache = true
+		c.refresh = true
+	}
+}
+
+// WithRequireAuthoritative makes this read bypass caches, for reads that
+// must reflect the latest write. It implies WithNoResolverCache, and if
+// the server still reports the answer as coming from a replica or cache
+// (ResponseMeta.Source), the read fails with ErrNotAuthoritative.
+func WithRequireAuthoritative() RequestOption {
+	return func(c *requestConfig) {
+		c.requireAuthoritative = true
+		c.noResolverCache = true
+		c.refresh = true
+	}
+}
+
+// WithPollInterval sets a fixed polling interval for Watch, instead of
+// following the value's TTL.
+func WithPollInterval(d time.Duration) RequestOption {
+	return func(c *requestConfig) {
+		c.pollInterval = d
+	}
+}
+
+// WithWatchBuffer sets how many events Watch and WatchResource buffer for
+// a slow consumer before coalescing them (default: 1 for Watch, 16 for
+// WatchResource). Larger buffers deliver more intermediate changes.
+func WithWatchBuffer(n int) RequestOption {
+	return func(c *requestConfig) {
+		c.watchBuffer = n
+	}
+}
+
+// WithQPS limits ForEachKey to qps lookups per second (default: unlimited).
+func WithQPS(qps float64) RequestOption {
+	return func(c *requestConfig) {
+		c.qps = qps
+	}
+}
+
+// WithConsistencyToken requests data at least as fresh as the write that
+// returned token (see WriteResult.ConsistencyToken). The read bypasses the
+// local cache, and the server answers from a replica that has applied the
+// write.
+func WithConsistencyToken(token string) RequestOption {
+	return func(c *requestConfig) {
+		c.consistencyToken = token
+	}
+}
+
+// WithIdempotencyKey tags a write with a key the server uses to apply it at
+// most once, so a write retried after a lost response is not repeated.
+// The key must be a valid DNS label.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(c *requestConfig) {
+		c.idempotencyKey = key
+	}
+}
+
+// WithIfMatch makes a write apply only if the stored value's content hash
+// (Response.Hash or WriteResult.Hash) is hash, for optimistic concurrency:
+// read a value, change it, and write it back conditioned on what was
+// read. If the value changed meanwhile, the write fails with
+// ErrVersionMismatch. The first 128 bits of the digest are sent.
+func WithIfMatch(hash string) RequestOption {
+	return func(c *requestConfig) {
+		_, digest := security.SplitHash(hash)
+		digest = strings.ToLower(digest)
+		if len(digest) > ifMatchDigestLen {
+			digest = digest[:ifMatchDigestLen]
+		}
+		c.ifMatch = digest
+	}
+}
+
+// WithIfAbsent makes a write apply only if the key does not exist, so
+// concurrent writers cannot overwrite each other's first value. If the key
+// exists, the write fails with ErrConflict.
+func WithIfAbsent() RequestOption {
+	return func(c *requestConfig) {
+		c.ifMatch = uqrp.IfAbsent
+	}
+}
+
+// ifMatchDigestLen is the number of hex digits of a content hash sent by
+// WithIfMatch.
+const ifMatchDigestLen = 32
+
+// WithChunkProgress calls fn as each chunk of a chunked value is fetched,
+// for progress reporting on large downloads. Calls are serialized.
+//
+// Example:
+//
+//	err := client.Get(ctx, "docs", "handbook", &doc,
+//	    resolvedb.WithChunkProgress(func(p resolvedb.ChunkProgress) {
+//	        log.Printf("fetched %d/%d chunks (%d bytes)", p.Done, p.Total, p.Bytes)
+//	    }))
+func WithChunkProgress(fn func(ChunkProgress)) RequestOption {
+	return func(c *requestConfig) {
+		c.chunkProgress = fn
+	}
+}
+
+// WithFields requests only the named top-level fields of a JSON document,
+// so large documents come back small enough to skip chunking. Fields are
+// named as stored, i.e. by their short names for types with rdb tags.
+// Projected responses are cached separately from full ones.
+//
+// Example:
+//
+//	var w struct {
+//	    TempC      float64 `json:"temp_c"`
+//	    Conditions string  `json:"conditions"`
+//	}
+//	err := client.Get(ctx, "weather", "london", &w, resolvedb.WithFields("temp_c", "conditions"))
+func WithFields(fields ...string) RequestOption {
+	return func(c *requestConfig) {
+		c.fields = append(c.fields, fields...)
+	}
+}
+
+// WithKeysOnly makes Query return only the keys of matching documents.
+func WithKeysOnly() RequestOption {
+	return func(c *requestConfig) {
+		c.keysOnly = true
+	}
+}
+
+// WithIgnoreNotFound makes Delete succeed when the key does not exist.
+// Use DeleteWithResult to learn whether anything was actually deleted.
+func WithIgnoreNotFound() RequestOption {
+	return func(c *requestConfig) {
+		c.ignoreNotFound = true
+	}
+}
+
+// withParams appends operation parameter labels to a request.
+func withParams(params ...string) RequestOption {
+	return func(c *requestConfig) {
+		c.params = append(c.params, params...)
+	}
+}
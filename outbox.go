@@ -0,0 +1,432 @@
+package resolvedb
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go/uqrp"
+)
+
+// OutboxEntry is a queued write.
+type OutboxEntry struct {
+	ID        string          `json:"id"`            // Idempotency key sent with the write
+	Op        string          `json:"op"`            // "put" or "delete"
+	Resource  string          `json:"r"`             // Resource name
+	Key       string          `json:"k"`             // Key
+	Namespace string          `json:"ns,omitempty"`  // Namespace override, empty for the client's
+	Data      json.RawMessage `json:"d,omitempty"`   // Encoded value for puts
+	TTL       time.Duration   `json:"ttl,omitempty"` // Write TTL
+	Expires   time.Time       `json:"exp,omitempty"` // Expiry set with WithExpiry, zero if none
+	Created   time.Time       `json:"created"`       // Time the write was queued
+}
+
+// OutboxStore persists queued writes in order. Implementations must be
+// safe for concurrent use.
+type OutboxStore interface {
+	// Append adds an entry to the end of the queue.
+	Append(ctx context.Context, e OutboxEntry) error
+
+	// Peek returns the oldest entry, or false if the queue is empty.
+	Peek(ctx context.Context) (OutboxEntry, bool, error)
+
+	// Remove deletes the entry with the given ID.
+	Remove(ctx context.Context, id string) error
+
+	// Len returns the number of queued entries.
+	Len(ctx context.Context) (int, error)
+}
+
+// Outbox sends writes through a client, queueing them in a store when the
+// transport fails and replaying them in order once it recovers. Each write
+// carries an idempotency key, so a replay of a write whose response was
+// lost is not applied twice.
+//
+// While entries are queued, new writes are queued behind them to preserve
+// order. Writes rejected by the server for reasons other than transport
+// failure are returned to the caller and never queued. Flushing stops,
+// keeping the write queued, when the transport fails, the context is done
+// or the client is closed.
+//
+// Example:
+//
+//	store, err := resolvedb.NewFileOutboxStore("/var/lib/sensor/outbox.jsonl")
+//	outbox := resolvedb.NewOutbox(client, store)
+//	go outbox.Run(ctx, 30*time.Second)
+//
+//	// Succeeds while offline; delivered when connectivity returns
+//	err = outbox.Set(ctx, "readings", "sensor-7", reading)
+type Outbox struct {
+	client *Client
+	store  OutboxStore
+	mu     sync.Mutex // serializes sends so replay order is preserved
+}
+
+// NewOutbox creates an outbox that queues writes in store.
+func NewOutbox(client *Client, store OutboxStore) *Outbox {
+	return &Outbox{client: client, store: store}
+}
+
+// Set stores data for a resource and key, queueing the write if the
+// transport fails. The first attempt uses opts as given. Queued writes
+// keep WithTTL, WithExpiry, WithRequestNamespace and WithIdempotencyKey;
+// options that cannot be replayed later, such as WithIfMatch, WithIfAbsent,
+// WithFormat or WithAuthToken, are rejected with an error.
+func (o *Outbox) Set(ctx context.Context, resource, key string, data any, opts ...RequestOption) error {
+	e, err := o.newEntry(ctx, "put", resource, key, opts)
+	if err != nil {
+		return err
+	}
+	if e.Data, err = o.client.marshalValue(resource, key, data); err != nil {
+		return err
+	}
+	return o.send(ctx, e, opts)
+}
+
+// Delete removes data for a resource and key, queueing the write if the
+// transport fails. Options are handled as by Set.
+func (o *Outbox) Delete(ctx context.Context, resource, key string, opts ...RequestOption) error {
+	e, err := o.newEntry(ctx, "delete", resource, key, opts)
+	if err != nil {
+		return err
+	}
+	return o.send(ctx, e, opts)
+}
+
+// MaxPayloadBytes returns the largest encoded value a single write through
+// the outbox can carry, as Client.MaxPayloadBytes.
+func (o *Outbox) MaxPayloadBytes(resource, key string, opts ...RequestOption) int {
+	return o.client.MaxPayloadBytes(resource, key, opts...)
+}
+
+// MaxValueBytes returns the largest encoded value Set can write through
+// the outbox, as Client.MaxValueBytes. Queued writes are chunked as Set
+// chunks them when the client uses WithAutoCodec.
+func (o *Outbox) MaxValueBytes(resource, key string, opts ...RequestOption) int {
+	return o.client.MaxValueBytes(resource, key, opts...)
+}
+
+// Len returns the number of queued writes.
+func (o *Outbox) Len(ctx context.Context) (int, error) {
+	return o.store.Len(ctx)
+}
+
+// Flush replays queued writes in order. It stops at the first transport
+// failure, leaving that write queued, and returns nil. Writes the server
+// rejects are dropped and reported in the returned error.
+func (o *Outbox) Flush(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, err := o.flush(ctx)
+	return err
+}
+
+// Run flushes the outbox every interval until ctx is done.
+func (o *Outbox) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		if err := o.Flush(ctx); err != nil {
+			o.client.logger().Warn("resolvedb: outbox dropped writes", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-o.client.config.clock.After(interval):
+		}
+	}
+}
+
+// newEntry builds a queued write from request options. It fails if an
+// option cannot be kept in the entry.
+func (o *Outbox) newEntry(ctx context.Context, op, resource, key string, opts []RequestOption) (OutboxEntry, error) {
+	reqConfig := newRequestConfig(ctx, opts)
+	if name := unqueueableOption(reqConfig); name != "" {
+		return OutboxEntry{}, fmt.Errorf("resolvedb: outbox cannot queue %s %s/%s with %s", op, resource, key, name)
+	}
+	id := reqConfig.idempotencyKey
+	if id == "" {
+		id = newIdempotencyKey(o.client.config.rand, o.client.config.clock)
+	}
+	return OutboxEntry{
+		ID:        id,
+		Op:        op,
+		Resource:  resource,
+		Key:       key,
+		Namespace: reqConfig.namespace,
+		TTL:       reqConfig.ttl,
+		Expires:   o.client.expiresAt(reqConfig),
+		Created:   o.client.config.clock.Now(),
+	}, nil
+}
+
+// unqueueableOption returns the name of an option in reqConfig that a
+// queued write cannot keep, or "" if there is none.
+func unqueueableOption(reqConfig *requestConfig) string {
+	switch {
+	case reqConfig.ifMatch == uqrp.IfAbsent:
+		return "WithIfAbsent"
+	case reqConfig.ifMatch != "":
+		return "WithIfMatch"
+	case reqConfig.format != "":
+		return "WithFormat"
+	case reqConfig.encoding != "":
+		return "WithEncoding"
+	case reqConfig.encrypt:
+		return "WithEncrypt"
+	case reqConfig.authToken != "":
+		return "WithAuthToken"
+	case reqConfig.nbaToken != "":
+		return "WithNBA"
+	case reqConfig.ctpToken != "":
+		return "WithCTP"
+	case reqConfig.bdtToken != "":
+		return "WithBDT"
+	}
+	return ""
+}
+
+// send delivers e after any queued writes, queueing it if that fails. The
+// first attempt applies opts before the entry's own options.
+func (o *Outbox) send(ctx context.Context, e OutboxEntry, opts []RequestOption) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	drained, err := o.flush(ctx)
+	if err != nil {
+		o.client.logger().Warn("resolvedb: outbox dropped writes", "error", err)
+	}
+	if !drained {
+		return o.store.Append(ctx, e)
+	}
+
+	err = o.deliver(ctx, e, opts)
+	if isUndelivered(err) {
+		return o.store.Append(context.WithoutCancel(ctx), e)
+	}
+	return err
+}
+
+// flush replays queued writes and reports whether the queue was drained.
+// The caller must hold o.mu.
+func (o *Outbox) flush(ctx context.Context) (bool, error) {
+	var dropped []error
+	for {
+		e, ok, err := o.store.Peek(ctx)
+		if err != nil {
+			return false, errors.Join(append(dropped, err)...)
+		}
+		if !ok {
+			return true, errors.Join(dropped...)
+		}
+
+		err = o.deliver(ctx, e, nil)
+		if isUndelivered(err) {
+			return false, errors.Join(dropped...)
+		}
+		if err != nil {
+			dropped = append(dropped, fmt.Errorf("outbox %s %s/%s: %w", e.Op, e.Resource, e.Key, err))
+		}
+		if err := o.store.Remove(ctx, e.ID); err != nil {
+			return false, errors.Join(append(dropped, err)...)
+		}
+	}
+}
+
+// deliver sends a single write, applying opts before the entry's options.
+func (o *Outbox) deliver(ctx context.Context, e OutboxEntry, opts []RequestOption) error {
+	opts = append(opts[:len(opts):len(opts)], WithIdempotencyKey(e.ID), WithTTL(e.TTL))
+	if e.Namespace != "" {
+		opts = append(opts, WithRequestNamespace(e.Namespace))
+	}
+	if !e.Expires.IsZero() {
+		opts = append(opts, func(c *requestConfig) { c.expiresAt, c.expiry = e.Expires, 0 })
+	}
+
+	switch e.Op {
+	case "put":
+		reqConfig := newRequestConfig(ctx, opts)
+		if o.client.config.autoCodec {
+			limit := o.client.maxPayloadBytes(e.Resource, e.Key, reqConfig)
+			_, err := o.client.putEncoded(ctx, e.Resource, e.Key, e.Data, limit, reqConfig)
+			return err
+		}
+		_, err := o.client.put(ctx, e.Resource, e.Key, e.Data, reqConfig)
+		return err
+	case "delete":
+		_, err := o.client.DeleteWithResult(ctx, e.Resource, e.Key, append(opts, WithIgnoreNotFound())...)
+		return err
+	default:
+		return fmt.Errorf("unknown outbox operation %q", e.Op)
+	}
+}
+
+// isUndelivered reports whether err means the write may not have reached
+// the server and must stay queued: the transport failed, the context was
+// done before an answer, or the client was closed.
+func isUndelivered(err error) bool {
+	var terr *TransportError
+	return errors.As(err, &terr) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, ErrClosed)
+}
+
+// newIdempotencyKey returns a random 32-character hex key read from rnd.
+func newIdempotencyKey(rnd io.Reader, clock Clock) string {
+	var b [16]byte
+	if _, err := io.ReadFull(rnd, b[:]); err != nil {
+		// Fall back to a time-based key if the source fails (should never happen)
+		return fmt.Sprintf("%032x", clock.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// memoryOutboxStore is an in-memory OutboxStore.
+type memoryOutboxStore struct {
+	mu      sync.Mutex
+	entries []OutboxEntry
+}
+
+// NewMemoryOutboxStore returns an OutboxStore that keeps entries in memory.
+// Queued writes are lost when the process exits.
+func NewMemoryOutboxStore() OutboxStore {
+	return &memoryOutboxStore{}
+}
+
+func (s *memoryOutboxStore) Append(_ context.Context, e OutboxEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+	return nil
+}
+
+func (s *memoryOutboxStore) Peek(context.Context) (OutboxEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) == 0 {
+		return OutboxEntry{}, false, nil
+	}
+	return s.entries[0], true, nil
+}
+
+func (s *memoryOutboxStore) Remove(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = removeOutboxEntry(s.entries, id)
+	return nil
+}
+
+func (s *memoryOutboxStore) Len(context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries), nil
+}
+
+// fileOutboxStore is an OutboxStore backed by a JSON-lines file.
+type fileOutboxStore struct {
+	memoryOutboxStore
+	path string
+}
+
+// NewFileOutboxStore returns an OutboxStore persisted to a JSON-lines file
+// at path, loading any entries already queued there. Each change rewrites
+// the file atomically, so it suits outboxes of modest size.
+func NewFileOutboxStore(path string) (OutboxStore, error) {
+	s := &fileOutboxStore{path: path}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open outbox: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e OutboxEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("read outbox: %w", err)
+		}
+		s.entries = append(s.entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read outbox: %w", err)
+	}
+	return s, nil
+}
+
+func (s *fileOutboxStore) Append(_ context.Context, e OutboxEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := append(s.entries[:len(s.entries):len(s.entries)], e)
+	if err := s.write(entries); err != nil {
+		return err
+	}
+	s.entries = entries
+	return nil
+}
+
+func (s *fileOutboxStore) Remove(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := removeOutboxEntry(append([]OutboxEntry(nil), s.entries...), id)
+	if err := s.write(entries); err != nil {
+		return err
+	}
+	s.entries = entries
+	return nil
+}
+
+// write replaces the file with entries. The caller must hold s.mu.
+func (s *fileOutboxStore) write(entries []OutboxEntry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("write outbox: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			return fmt.Errorf("write outbox: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write outbox: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write outbox: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write outbox: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write outbox: %w", err)
+	}
+	return nil
+}
+
+// removeOutboxEntry removes the entry with the given ID from entries.
+func removeOutboxEntry(entries []OutboxEntry, id string) []OutboxEntry {
+	for i, e := range entries {
+		if e.ID == id {
+			return append(entries[:i], entries[i+1:]...)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,121 @@
+package resolvedb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+)
+
+// failingTransport fails every query with a retryable SERVFAIL, calling
+// onQuery first.
+type failingTransport struct {
+	onQuery func()
+}
+
+func (t *failingTransport) Name() string      { return "failing" }
+func (t *failingTransport) IsEncrypted() bool { return true }
+func (t *failingTransport) Close() error      { return nil }
+
+func (t *failingTransport) Query(context.Context, *transport.Request) (*transport.Response, error) {
+	if t.onQuery != nil {
+		t.onQuery()
+	}
+	return nil, &transport.RcodeError{Rcode: transport.RcodeServerFailure}
+}
+
+func TestOutboxKeepsEntryWhenCanceledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := New(
+		WithTransports(&failingTransport{onQuery: cancel}),
+		WithCache(CacheConfig{}),
+		WithRetry(RetryConfig{MaxRetries: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour, Multiplier: 1}),
+		WithAPIKey("test-key"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	store := NewMemoryOutboxStore()
+	if err := store.Append(ctx, OutboxEntry{ID: "1", Op: "put", Resource: "readings", Key: "sensor-7", Data: []byte("1")}); err != nil {
+		t.Fatal(err)
+	}
+	outbox := NewOutbox(client, store)
+
+	if err := outbox.Flush(ctx); err != nil {
+		t.Fatalf("Flush returned %v, want nil", err)
+	}
+	if n, _ := outbox.Len(context.Background()); n != 1 {
+		t.Fatalf("Len = %d after canceled flush, want 1", n)
+	}
+}
+
+func TestOutboxKeepsEntryWhenClientClosed(t *testing.T) {
+	client := newTestClient(t, transport.NewMemory(), WithAPIKey("test-key"))
+	client.Close()
+
+	outbox := NewOutbox(client, NewMemoryOutboxStore())
+	if err := outbox.Set(context.Background(), "readings", "sensor-7", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := outbox.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush returned %v, want nil", err)
+	}
+	if n, _ := outbox.Len(context.Background()); n != 1 {
+		t.Fatalf("Len = %d after flushing through a closed client, want 1", n)
+	}
+}
+
+func TestOutboxRejectsUnqueueableOptions(t *testing.T) {
+	client := newTestClient(t, transport.NewMemory(), WithAPIKey("test-key"))
+	outbox := NewOutbox(client, NewMemoryOutboxStore())
+
+	for name, opt := range map[string]RequestOption{
+		"WithIfMatch":   WithIfMatch("sha256:0123456789abcdef0123456789abcdef"),
+		"WithIfAbsent":  WithIfAbsent(),
+		"WithFormat":    WithFormat(FormatText),
+		"WithAuthToken": WithAuthToken("auth-abc-t-1700000000"),
+	} {
+		if err := outbox.Set(context.Background(), "readings", "sensor-7", "1", opt); err == nil {
+			t.Errorf("Set with %s succeeded, want an error", name)
+		}
+	}
+	if n, _ := outbox.Len(context.Background()); n != 0 {
+		t.Fatalf("Len = %d, want 0", n)
+	}
+}
+
+func TestOutboxChunksLargeWrites(t *testing.T) {
+	mem := transport.NewMemory(transport.WithMemoryMissing([]byte("v=rdb1;s=ok")))
+	client := newTestClient(t, mem, WithAPIKey("test-key"), WithAutoCodec())
+	outbox := NewOutbox(client, NewMemoryOutboxStore())
+
+	limit := outbox.MaxValueBytes("readings", "sensor-7")
+	if want := client.MaxValueBytes("readings", "sensor-7"); limit != want {
+		t.Fatalf("MaxValueBytes = %d, want the client's %d", limit, want)
+	}
+	if single := outbox.MaxPayloadBytes("readings", "sensor-7"); limit <= single {
+		t.Fatalf("MaxValueBytes = %d, want more than a single write's %d", limit, single)
+	}
+
+	// A queued value too large for a single write is chunked on replay
+	data, err := marshalJSON(strings.Repeat("0123456789", 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := OutboxEntry{ID: "1", Op: "put", Resource: "readings", Key: "sensor-7", Data: data}
+	if err := outbox.store.Append(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := outbox.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush returned %v, want nil", err)
+	}
+	if n, _ := outbox.Len(context.Background()); n != 0 {
+		t.Fatalf("Len = %d, want 0", n)
+	}
+}
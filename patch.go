@@ -0,0 +1,85 @@
+package resolvedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// PatchOperation is a single RFC 6902 JSON Patch operation.
+type PatchOperation struct {
+	Op    string // "add", "remove", "replace", "move", "copy" or "test"
+	Path  string // JSON Pointer to the target location
+	From  string // Source location for "move" and "copy"
+	Value any    // Value for "add", "replace" and "test"
+}
+
+// MarshalJSON encodes the operation, omitting members its op does not use.
+func (p PatchOperation) MarshalJSON() ([]byte, error) {
+	op := struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		From  string `json:"from,omitempty"`
+		Value *any   `json:"value,omitempty"`
+	}{Op: p.Op, Path: p.Path, From: p.From}
+	switch p.Op {
+	case "add", "replace", "test":
+		op.Value = &p.Value
+	}
+	return json.Marshal(op)
+}
+
+// JSONPatch is an RFC 6902 JSON Patch document.
+type JSONPatch []PatchOperation
+
+// Patch applies an RFC 6902 JSON Patch to the stored value of a key, so
+// only the change travels over DNS instead of the whole document. The patch
+// is applied atomically by the server; if any operation fails (including a
+// "test"), the value is left unchanged and ErrBadRequest is returned.
+//
+// The encoded patch travels in a single label of the query name, like a
+// value written with Set, so it must fit in MaxPayloadBytes (about 44
+// bytes with an API key): larger patches fail with ErrPayloadTooLarge
+// without being sent. Write the whole value with Set or WithAutoCodec
+// instead.
+//
+// Schemas registered with WithSchema are not checked against patches.
+//
+// Example:
+//
+//	_, err := client.Patch(ctx, "config", "fleet", resolvedb.JSONPatch{
+//	    {Op: "replace", Path: "/v", Value: 42},
+//	})
+func (c *Client) Patch(ctx context.Context, resource, key string, patch JSONPatch, opts ...RequestOption) (*WriteResult, error) {
+	if len(patch) == 0 {
+		return nil, fmt.Errorf("patch: no operations")
+	}
+	raw, err := json.Marshal(patch)
+	if err != nil {
+		return nil, fmt.Errorf("encode patch: %w", err)
+	}
+	return c.write(ctx, "patch", resource, key, raw, newRequestConfig(ctx, opts))
+}
+
+// MergePatch applies an RFC 7396 JSON Merge Patch to the stored value of a
+// key. Fields present in patch replace stored fields, null removes them,
+// and nested objects are merged recursively. patch may be any value that
+// encodes to a JSON object, or a json.RawMessage. Like Patch, patches that
+// do not fit in a query fail with ErrPayloadTooLarge.
+//
+// Example:
+//
+//	_, err := client.MergePatch(ctx, "config", "fleet", map[string]any{
+//	    "v":      42,
+//	    "legacy": nil,
+//	})
+func (c *Client) MergePatch(ctx context.Context, resource, key string, patch any, opts ...RequestOption) (*WriteResult, error) {
+	raw, err := marshalJSON(patch)
+	if err != nil {
+		return nil, fmt.Errorf("encode patch: %w", err)
+	}
+	if len(raw) == 0 || raw[0] != '{' {
+		return nil, fmt.Errorf("merge patch must be a JSON object")
+	}
+	return c.write(ctx, "merge", resource, key, raw, newRequestConfig(ctx, opts))
+}
@@ -0,0 +1,85 @@
+package resolvedb
+
+import (
+	"context"
+	"sync"
+)
+
+// semaphore bounds concurrent work. A nil semaphore is unlimited.
+type semaphore chan struct{}
+
+// newSemaphore creates a semaphore with n slots, or nil if n <= 0.
+func newSemaphore(n int) semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return make(semaphore, n)
+}
+
+// acquire takes a slot, waiting until one is free or ctx is done.
+func (s semaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release returns a slot taken by acquire.
+func (s semaphore) release() {
+	if s != nil {
+		<-s
+	}
+}
+
+// Pool runs functions concurrently with a bounded number in flight.
+// It is safe for concurrent use.
+//
+// Example:
+//
+//	pool := resolvedb.NewPool(8)
+//	for _, city := range cities {
+//	    city := city
+//	    if err := pool.Go(ctx, func() {
+//	        var w Weather
+//	        _ = client.Get(ctx, "weather", city, &w)
+//	    }); err != nil {
+//	        break // ctx cancelled while waiting for a slot
+//	    }
+//	}
+//	pool.Wait()
+type Pool struct {
+	sem semaphore
+	wg  sync.WaitGroup
+}
+
+// NewPool creates a pool running at most n functions at once.
+// A pool with n <= 0 is unbounded.
+func NewPool(n int) *Pool {
+	return &Pool{sem: newSemaphore(n)}
+}
+
+// Go runs fn in a new goroutine once a slot is free. It blocks while the
+// pool is at capacity and returns ctx.Err() without running fn if ctx is
+// done first.
+func (p *Pool) Go(ctx context.Context, fn func()) error {
+	if err := p.sem.acquire(ctx); err != nil {
+		return err
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer p.sem.release()
+		fn()
+	}()
+	return nil
+}
+
+// Wait blocks until all functions started by Go have returned.
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
@@ -0,0 +1,96 @@
+package resolvedb
+
+import (
+	"context"
+	"strings"
+)
+
+// maxPrefetchInFlight bounds the background prefetches running at once;
+// hints arriving while it is reached are dropped.
+const maxPrefetchInFlight = 8
+
+// PrefetchHint is a key the server suggests reading next, sent in the
+// "pf" field of a response, e.g. pf=forecast/tokyo,alerts/tokyo. An empty
+// Resource means the resource of the response.
+type PrefetchHint struct {
+	Resource string
+	Key      string
+}
+
+// parsePrefetchHints parses a comma-separated list of "resource/key" or
+// "key" hints. Empty entries are skipped.
+func parsePrefetchHints(value string) []PrefetchHint {
+	var hints []PrefetchHint
+	for _, entry := range strings.Split(value, ",") {
+		resource, key, ok := strings.Cut(entry, "/")
+		if !ok {
+			resource, key = "", entry
+		}
+		if key == "" {
+			continue
+		}
+		hints = append(hints, PrefetchHint{Resource: resource, Key: key})
+	}
+	return hints
+}
+
+// WithPrefetch makes the client read up to n of the keys hinted by each
+// response (see PrefetchHint) in the background, so the cache is warm by
+// the time a multi-call flow asks for them. It needs the cache enabled.
+// Hints are followed from responses read from the network only, and
+// prefetched responses' own hints are ignored.
+//
+// Example:
+//
+//	client, err := resolvedb.New(resolvedb.WithPrefetch(4))
+//
+//	// Current weather hints at the forecast, which is then served from cache
+//	err = client.Get(ctx, "weather", "tokyo", &current)
+//	err = client.Get(ctx, "forecast", "tokyo", &forecast)
+func WithPrefetch(n int) Option {
+	return func(c *clientConfig) {
+		c.prefetch = n
+	}
+}
+
+// prefetch reads the keys hinted by resp, a response for resource, in
+// the background. Hints already cached, or beyond the configured number,
+// are skipped, as are all hints while maxPrefetchInFlight reads run.
+func (c *Client) prefetch(resource string, resp *Response, reqConfig *requestConfig) {
+	if c.config.prefetch <= 0 || !c.config.cacheConfig.Enabled || reqConfig.prefetched || len(resp.Prefetch) == 0 {
+		return
+	}
+	ns := c.namespace(reqConfig)
+	for i, hint := range resp.Prefetch {
+		if i == c.config.prefetch {
+			break
+		}
+		if hint.Resource == "" {
+			hint.Resource = resource
+		}
+		if _, ok := c.cache.Get(buildCacheKey("get", hint.Resource, hint.Key, ns, c.config.version)); ok {
+			continue
+		}
+
+		select {
+		case c.prefetching <- struct{}{}:
+		default:
+			return
+		}
+		ctx, done, ok := c.background(context.Background())
+		if !ok {
+			<-c.prefetching
+			return
+		}
+		c.stats.prefetches.Add(1)
+		hint := hint
+		go func() {
+			defer done()
+			defer func() { <-c.prefetching }()
+			_, err := c.getRaw(ctx, hint.Resource, hint.Key, &requestConfig{namespace: reqConfig.namespace, prefetched: true})
+			if err != nil {
+				c.logger().Debug("resolvedb: prefetch failed", "resource", hint.Resource, "key", hint.Key, "error", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,24 @@
+package resolvedb
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// Profiler label keys set with WithProfilerLabels.
+const (
+	ProfileLabelOperation = "resolvedb.operation"
+	ProfileLabelResource  = "resolvedb.resource"
+	ProfileLabelTransport = "resolvedb.transport"
+)
+
+// withProfilerLabels calls fn with the label key-value pairs added to ctx
+// and the calling goroutine's profiler labels, if enabled with
+// WithProfilerLabels, and with ctx unchanged otherwise.
+func (c *Client) withProfilerLabels(ctx context.Context, fn func(context.Context), labels ...string) {
+	if !c.config.profilerLabels {
+		fn(ctx)
+		return
+	}
+	pprof.Do(ctx, pprof.Labels(labels...), fn)
+}
@@ -0,0 +1,126 @@
+// Package protocoltest publishes canonical ResolveDB wire-format test
+// vectors: the query names an SDK must build for given inputs, UQRP
+// answers with the fields they must parse to, how multi-record answers
+// reassemble, and the rollout buckets keys hash into.
+//
+// The vectors live in vectors.json, which SDKs in other languages vendor
+// unchanged, so protocol drift between SDKs shows up as a failing vector.
+// Verify checks this SDK against them:
+//
+//	func TestProtocolVectors(t *testing.T) {
+//	    if err := protocoltest.Verify(context.Background()); err != nil {
+//	        t.Fatal(err)
+//	    }
+//	}
+package protocoltest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
+//go:embed vectors.json
+var vectorsJSON []byte
+
+// Vectors is the set of test vectors.
+type Vectors struct {
+	// Version is incremented whenever vectors change meaning. Adding
+	// vectors does not change it.
+	Version    int               `json:"version"`
+	QueryNames []QueryNameVector `json:"query_names"`
+	Responses  []ResponseVector  `json:"responses"`
+	Buckets    []BucketVector    `json:"buckets"`
+	Records    []RecordVector    `json:"records"`
+}
+
+// QueryNameVector is the query name expected for an operation. Empty
+// fields take the client defaults: namespace "public", version "v1", TLD
+// "net" and base64 labels.
+type QueryNameVector struct {
+	Name          string          `json:"name"`
+	Operation     string          `json:"operation"` // "get", "list", "put" or "delete"
+	Resource      string          `json:"resource"`
+	Key           string          `json:"key,omitempty"`
+	Value         json.RawMessage `json:"value,omitempty"` // JSON value written by put
+	Namespace     string          `json:"namespace,omitempty"`
+	Version       string          `json:"version,omitempty"`
+	TLD           string          `json:"tld,omitempty"`
+	Zone          string          `json:"zone,omitempty"`
+	LabelEncoding string          `json:"label_encoding,omitempty"` // "base64" or "base32"
+	APIKey        string          `json:"api_key,omitempty"`
+	Time          int64           `json:"time,omitempty"` // Unix time auth tokens are signed at
+	Expected      string          `json:"expected"`
+}
+
+// ResponseVector is a UQRP answer and the fields it parses to.
+type ResponseVector struct {
+	Name     string         `json:"name"`
+	Input    string         `json:"input"`             // TXT data of the answer
+	Invalid  bool           `json:"invalid,omitempty"` // The answer must be rejected
+	Expected ResponseFields `json:"expected"`
+}
+
+// ResponseFields are the parsed fields of an answer. Data is the decoded
+// payload as text; vectors only carry textual payloads.
+type ResponseFields struct {
+	Version          string     `json:"version,omitempty"`
+	Status           string     `json:"status,omitempty"`
+	Type             string     `json:"type,omitempty"`
+	Encoding         string     `json:"encoding,omitempty"`
+	Format           string     `json:"format,omitempty"`
+	TTL              int64      `json:"ttl,omitempty"` // Seconds
+	Data             string     `json:"data,omitempty"`
+	Error            string     `json:"error,omitempty"`
+	ErrorCode        string     `json:"error_code,omitempty"` // Protocol error code the status maps to, e.g. "E004"
+	ErrorHint        string     `json:"error_hint,omitempty"`
+	ErrorDoc         string     `json:"error_doc,omitempty"`
+	RetryAfter       float64    `json:"retry_after,omitempty"` // Seconds
+	Chunks           int        `json:"chunks,omitempty"`
+	ChunkID          int        `json:"chunk,omitempty"`
+	Hash             string     `json:"hash,omitempty"`
+	Timestamp        int64      `json:"timestamp,omitempty"` // Unix time
+	Expires          int64      `json:"expires,omitempty"`   // Unix time
+	ConsistencyToken string     `json:"consistency_token,omitempty"`
+	Source           string     `json:"source,omitempty"` // Normalized src field, e.g. "authoritative"
+	RateLimit        *RateLimit `json:"rate_limit,omitempty"`
+}
+
+// RateLimit is the expected rate-limit metadata of an answer.
+type RateLimit struct {
+	Limit     int   `json:"limit"`
+	Remaining int   `json:"remaining"`
+	Reset     int64 `json:"reset"` // Unix time
+}
+
+// BucketVector is the rollout bucket a key hashes into for a seed.
+type BucketVector struct {
+	Seed   string `json:"seed"`
+	Key    string `json:"key"`
+	Bucket int    `json:"bucket"`
+}
+
+// RecordVector is the records of a multi-record answer, in the order a
+// resolver returned them, and the payload they reassemble to (see
+// uqrp.Sequence).
+type RecordVector struct {
+	Name     string   `json:"name"`
+	Records  []string `json:"records"`
+	Invalid  bool     `json:"invalid,omitempty"` // The records must be rejected
+	Expected string   `json:"expected,omitempty"`
+}
+
+// JSON returns the vectors file as published, for SDKs and tools that
+// consume it directly.
+func JSON() []byte {
+	return append([]byte(nil), vectorsJSON...)
+}
+
+// Load decodes the vectors.
+func Load() (*Vectors, error) {
+	var v Vectors
+	if err := json.Unmarshal(vectorsJSON, &v); err != nil {
+		return nil, fmt.Errorf("protocoltest: decode vectors: %w", err)
+	}
+	return &v, nil
+}
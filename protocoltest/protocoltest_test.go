@@ -0,0 +1,12 @@
+package protocoltest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	if err := Verify(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
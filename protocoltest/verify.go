@@ -0,0 +1,239 @@
+package protocoltest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/rollout"
+	"github.com/resolvedb/resolvedb-go/transport"
+	"github.com/resolvedb/resolvedb-go/uqrp"
+)
+
+// okAnswer is answered to every query sent while checking query names.
+const okAnswer = "v=rdb1;s=ok;t=json;d=[]"
+
+// Verify checks this SDK against every vector and returns the mismatches
+// joined, or nil if all vectors pass.
+func Verify(ctx context.Context) error {
+	v, err := Load()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, qv := range v.QueryNames {
+		if err := CheckQueryName(ctx, qv); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, rv := range v.Responses {
+		if err := CheckResponse(rv); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, bv := range v.Buckets {
+		if err := CheckBucket(bv); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, rv := range v.Records {
+		if err := CheckRecords(rv); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckQueryName sends the vector's operation through a client configured
+// from it and compares the query name sent with the expected one.
+func CheckQueryName(ctx context.Context, v QueryNameVector) error {
+	capture := &captureTransport{}
+	opts := []resolvedb.Option{
+		resolvedb.WithTransports(capture),
+		resolvedb.WithCache(resolvedb.CacheConfig{}),
+		resolvedb.WithRetry(resolvedb.RetryConfig{}),
+		resolvedb.WithClock(fixedClock(time.Unix(v.Time, 0))),
+	}
+	if v.Namespace != "" {
+		opts = append(opts, resolvedb.WithNamespace(v.Namespace))
+	}
+	if v.Version != "" {
+		opts = append(opts, resolvedb.WithVersion(v.Version))
+	}
+	if v.TLD != "" {
+		opts = append(opts, resolvedb.WithTLD(v.TLD))
+	}
+	if v.Zone != "" {
+		opts = append(opts, resolvedb.WithZone(v.Zone))
+	}
+	switch v.LabelEncoding {
+	case "", "base64":
+	case "base32":
+		opts = append(opts, resolvedb.WithLabelEncoding(resolvedb.LabelEncodingBase32))
+	default:
+		return fmt.Errorf("query name %q: unknown label encoding %q", v.Name, v.LabelEncoding)
+	}
+	if v.APIKey != "" {
+		opts = append(opts, resolvedb.WithAPIKey(v.APIKey))
+	}
+
+	client, err := resolvedb.New(opts...)
+	if err != nil {
+		return fmt.Errorf("query name %q: %w", v.Name, err)
+	}
+	defer client.Close()
+
+	switch v.Operation {
+	case "get":
+		_, err = client.GetRaw(ctx, v.Resource, v.Key)
+	case "list":
+		_, err = client.List(ctx, v.Resource)
+	case "put":
+		err = client.Set(ctx, v.Resource, v.Key, v.Value)
+	case "delete":
+		err = client.Delete(ctx, v.Resource, v.Key)
+	default:
+		return fmt.Errorf("query name %q: unknown operation %q", v.Name, v.Operation)
+	}
+	if err != nil {
+		return fmt.Errorf("query name %q: %w", v.Name, err)
+	}
+
+	if got := capture.last(); got != v.Expected {
+		return fmt.Errorf("query name %q:\n\tgot  %s\n\twant %s", v.Name, got, v.Expected)
+	}
+	return nil
+}
+
+// CheckResponse parses the vector's answer and compares its fields with
+// the expected ones.
+func CheckResponse(v ResponseVector) error {
+	resp, err := resolvedb.ParseResponse(v.Input)
+	if v.Invalid {
+		if err == nil {
+			return fmt.Errorf("response %q: parsed, want an error", v.Name)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("response %q: %w", v.Name, err)
+	}
+
+	got := fields(resp)
+	if !reflect.DeepEqual(got, v.Expected) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(v.Expected)
+		return fmt.Errorf("response %q:\n\tgot  %s\n\twant %s", v.Name, gotJSON, wantJSON)
+	}
+	return nil
+}
+
+// CheckBucket compares the rollout bucket of the vector's key with the
+// expected one.
+func CheckBucket(v BucketVector) error {
+	if got := rollout.Bucket(v.Seed, v.Key); got != v.Bucket {
+		return fmt.Errorf("bucket %q/%q: got %d, want %d", v.Seed, v.Key, got, v.Bucket)
+	}
+	return nil
+}
+
+// CheckRecords reassembles the vector's records and compares the payload
+// with the expected one.
+func CheckRecords(v RecordVector) error {
+	records := make([][]byte, len(v.Records))
+	for i, r := range v.Records {
+		records[i] = []byte(r)
+	}
+	got, err := uqrp.JoinSequence(records)
+	if v.Invalid {
+		if err == nil {
+			return fmt.Errorf("records %q: reassembled, want an error", v.Name)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("records %q: %w", v.Name, err)
+	}
+	if string(got) != v.Expected {
+		return fmt.Errorf("records %q:\n\tgot  %s\n\twant %s", v.Name, got, v.Expected)
+	}
+	return nil
+}
+
+// fields returns the comparable fields of resp.
+func fields(resp *resolvedb.Response) ResponseFields {
+	f := ResponseFields{
+		Version:          resp.Version,
+		Status:           resp.Status,
+		Type:             resp.Type,
+		Encoding:         resp.Encoding,
+		Format:           resp.Format,
+		TTL:              int64(resp.TTL / time.Second),
+		Data:             string(resp.Data),
+		Error:            resp.Error,
+		ErrorHint:        resp.ErrorHint,
+		ErrorDoc:         resp.ErrorDoc,
+		RetryAfter:       resp.RetryAfter.Seconds(),
+		Chunks:           resp.Chunks,
+		ChunkID:          resp.ChunkID,
+		Hash:             resp.Hash,
+		ConsistencyToken: resp.Meta.ConsistencyToken,
+		Source:           string(resp.Meta.Source),
+	}
+	if !resp.Timestamp.IsZero() {
+		f.Timestamp = resp.Timestamp.Unix()
+	}
+	if !resp.Expires.IsZero() {
+		f.Expires = resp.Expires.Unix()
+	}
+	var perr *resolvedb.Error
+	if errors.As(resp.ToError(), &perr) {
+		f.ErrorCode = perr.Code
+	}
+	if rl := resp.Meta.RateLimit; rl != nil {
+		f.RateLimit = &RateLimit{Limit: rl.Limit, Remaining: rl.Remaining}
+		if !rl.Reset.IsZero() {
+			f.RateLimit.Reset = rl.Reset.Unix()
+		}
+	}
+	return f
+}
+
+// captureTransport records query names and answers every query with
+// okAnswer.
+type captureTransport struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (t *captureTransport) Name() string      { return "capture" }
+func (t *captureTransport) IsEncrypted() bool { return true }
+func (t *captureTransport) Close() error      { return nil }
+
+func (t *captureTransport) Query(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	t.mu.Lock()
+	t.names = append(t.names, req.Name)
+	t.mu.Unlock()
+	return &transport.Response{Data: []byte(okAnswer)}, nil
+}
+
+// last returns the last query name sent.
+func (t *captureTransport) last() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.names) == 0 {
+		return ""
+	}
+	return t.names[len(t.names)-1]
+}
+
+// fixedClock is a clock stopped at a point in time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time                         { return time.Time(c) }
+func (c fixedClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
@@ -0,0 +1,304 @@
+package resolvedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/resolvedb/resolvedb-go/uqrp"
+)
+
+const (
+	// prefixQuery marks the labels carrying a base64-encoded query
+	// expression. Expressions longer than one label span several
+	// consecutive labels, which the server concatenates.
+	prefixQuery = uqrp.PrefixQuery
+
+	// queryKeysParam requests only the keys of matching documents.
+	queryKeysParam = "keys"
+)
+
+// QueryMatch is a document matched by Query.
+type QueryMatch struct {
+	Key  string          // Key of the matching document
+	Data json.RawMessage // Document, nil with WithKeysOnly
+}
+
+// Decode unmarshals the matched document into v.
+func (m QueryMatch) Decode(v any) error {
+	if m.Data == nil {
+		return fmt.Errorf("query match %q has no data", m.Key)
+	}
+	if err := unmarshalJSON(m.Data, v); err != nil {
+		return &DecodeError{Format: "json", Type: fmt.Sprintf("%T", v), Err: err}
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a match. Matches may be plain key strings or
+// objects carrying the document.
+func (m *QueryMatch) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		*m = QueryMatch{Key: key}
+		return nil
+	}
+
+	var raw struct {
+		Key  string          `json:"key"`
+		Data json.RawMessage `json:"d"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.Key = raw.Key
+	m.Data = raw.Data
+	return nil
+}
+
+// Query returns the documents of resource matching expr, evaluated by the
+// server so only matches are transferred. Expressions compare document
+// fields to literals and combine comparisons with &&, || and !:
+//
+//	enabled == true && region == "eu"
+//	(tier == "gold" || spend >= 1000) && !suspended == true
+//
+// Fields may be nested with dots (limits.daily). Literals are strings,
+// numbers, true, false and null. Malformed expressions are rejected before
+// any query is sent.
+//
+// Example:
+//
+//	matches, err := client.Query(ctx, "devices", `enabled == true && region == "eu"`)
+//	for _, m := range matches {
+//	    var d Device
+//	    if err := m.Decode(&d); err != nil {
+//	        return err
+//	    }
+//	}
+func (c *Client) Query(ctx context.Context, resource, expr string, opts ...RequestOption) ([]QueryMatch, error) {
+	expr = strings.TrimSpace(expr)
+	if err := validateQueryExpr(expr); err != nil {
+		return nil, err
+	}
+
+	reqConfig := newRequestConfig(ctx, opts)
+	reqConfig.params = append(reqConfig.params, chunkLabels(prefixQuery, c.encodeParamValue([]byte(expr)))...)
+	if reqConfig.keysOnly {
+		reqConfig.params = append(reqConfig.params, queryKeysParam)
+	}
+
+	queryName := c.buildQueryName("query", resource, "", reqConfig)
+
+	resp, err := c.query(ctx, "query", resource, "", queryName, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resp.ToError(); err != nil {
+		return nil, c.queryError(err, "query", resource, "", reqConfig, resp)
+	}
+
+	var matches []QueryMatch
+	if err := resp.Unmarshal(&matches); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// chunkLabels splits data into labels of at most 63 characters, each
+// starting with prefix.
+func chunkLabels(prefix, data string) []string {
+	size := 63 - len(prefix)
+	labels := make([]string, 0, (len(data)+size-1)/size)
+	for len(data) > size {
+		labels = append(labels, prefix+data[:size])
+		data = data[size:]
+	}
+	return append(labels, prefix+data)
+}
+
+// validateQueryExpr checks that expr is a well-formed query expression.
+func validateQueryExpr(expr string) error {
+	p := &exprParser{src: expr}
+	p.next()
+	if err := p.parseOr(); err != nil {
+		return fmt.Errorf("%w: expression: %v", ErrBadRequest, err)
+	}
+	if p.tok != "" {
+		return fmt.Errorf("%w: expression: unexpected %q at offset %d", ErrBadRequest, p.tok, p.start)
+	}
+	return nil
+}
+
+// exprParser is a recursive-descent validator for query expressions:
+//
+//	or         = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" or ")" | comparison
+//	comparison = field op literal
+type exprParser struct {
+	src   string
+	pos   int
+	tok   string // current token, "" at end of input
+	kind  tokenKind
+	start int // offset of the current token
+}
+
+type tokenKind int
+
+const (
+	tokEnd tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokInvalid
+)
+
+func (p *exprParser) parseOr() error {
+	if err := p.parseAnd(); err != nil {
+		return err
+	}
+	for p.tok == "||" {
+		p.next()
+		if err := p.parseAnd(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *exprParser) parseAnd() error {
+	if err := p.parseUnary(); err != nil {
+		return err
+	}
+	for p.tok == "&&" {
+		p.next()
+		if err := p.parseUnary(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *exprParser) parseUnary() error {
+	switch p.tok {
+	case "!":
+		p.next()
+		return p.parseUnary()
+	case "(":
+		p.next()
+		if err := p.parseOr(); err != nil {
+			return err
+		}
+		if p.tok != ")" {
+			return p.expected(`")"`)
+		}
+		p.next()
+		return nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() error {
+	if p.kind != tokIdent || isLiteralIdent(p.tok) {
+		return p.expected("field name")
+	}
+	p.next()
+
+	switch p.tok {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+	default:
+		return p.expected("comparison operator")
+	}
+
+	switch {
+	case p.kind == tokString, p.kind == tokNumber, p.kind == tokIdent && isLiteralIdent(p.tok):
+		p.next()
+		return nil
+	}
+	return p.expected("literal")
+}
+
+func (p *exprParser) expected(what string) error {
+	if p.tok == "" {
+		return fmt.Errorf("expected %s at end of expression", what)
+	}
+	return fmt.Errorf("expected %s at offset %d, found %q", what, p.start, p.tok)
+}
+
+// next advances to the next token.
+func (p *exprParser) next() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	p.start = p.pos
+	if p.pos >= len(p.src) {
+		p.tok, p.kind = "", tokEnd
+		return
+	}
+
+	ch := p.src[p.pos]
+	switch {
+	case ch == '"':
+		end := p.pos + 1
+		for end < len(p.src) && p.src[end] != '"' {
+			if p.src[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.src) {
+			p.tok, p.kind = p.src[p.pos:], tokInvalid
+			p.pos = len(p.src)
+			return
+		}
+		p.pos = end + 1
+		p.tok, p.kind = p.src[p.start:p.pos], tokString
+
+	case ch == '-' || (ch >= '0' && ch <= '9'):
+		end := p.pos + 1
+		for end < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[end]) >= 0 {
+			end++
+		}
+		p.pos = end
+		p.tok = p.src[p.start:p.pos]
+		p.kind = tokNumber
+		if !json.Valid([]byte(p.tok)) {
+			p.kind = tokInvalid
+		}
+
+	case ch == '_' || unicode.IsLetter(rune(ch)):
+		end := p.pos + 1
+		for end < len(p.src) {
+			c := rune(p.src[end])
+			if c != '_' && c != '.' && c != '-' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+				break
+			}
+			end++
+		}
+		p.pos = end
+		p.tok, p.kind = p.src[p.start:p.pos], tokIdent
+
+	default:
+		for _, op := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"} {
+			if strings.HasPrefix(p.src[p.pos:], op) {
+				p.pos += len(op)
+				p.tok, p.kind = op, tokOp
+				return
+			}
+		}
+		p.pos++
+		p.tok, p.kind = p.src[p.start:p.pos], tokInvalid
+	}
+}
+
+// isLiteralIdent reports whether an identifier is a keyword literal.
+func isLiteralIdent(s string) bool {
+	return s == "true" || s == "false" || s == "null"
+}
@@ -0,0 +1,237 @@
+// Package rdbtypes provides JSON helper types with compact, stable wire
+// formats for values stored in ResolveDB, where every byte counts against
+// the DNS payload budget.
+//
+// The formats match those the ResolveDB API uses for its own metadata:
+// durations are seconds and times are Unix seconds, both as JSON numbers
+// with a fraction only when needed; addresses are strings. Decoding also
+// accepts the encodings encoding/json produces for the standard types
+// (RFC 3339 times and Go duration strings), so fields can switch to these
+// types without rewriting stored values.
+//
+// Example:
+//
+//	type Job struct {
+//	    Timeout rdbtypes.Duration `json:"timeout"` // 90 rather than 90000000000
+//	    Started rdbtypes.Time     `json:"started"` // 1700000000 rather than "2023-11-14T22:13:20Z"
+//	    Client  rdbtypes.Addr     `json:"client"`
+//	}
+package rdbtypes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSeconds bounds the seconds representable in nanoseconds as an int64.
+const maxSeconds = math.MaxInt64/1_000_000_000 - 1
+
+// Duration is a time.Duration encoded as a JSON number of seconds, e.g.
+// 90 or 0.25. Nanosecond precision round-trips exactly.
+type Duration time.Duration
+
+// Std returns d as a time.Duration.
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// String returns the duration in time.Duration's format.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalJSON encodes d as seconds.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return formatNanos(int64(d)), nil
+}
+
+// UnmarshalJSON decodes seconds, a Go duration string such as "1m30s", or
+// null, which leaves d unchanged.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+	if data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("rdbtypes: invalid duration %q", s)
+		}
+		*d = Duration(v)
+		return nil
+	}
+	n, err := parseNanos(data)
+	if err != nil {
+		return fmt.Errorf("rdbtypes: invalid duration %s", data)
+	}
+	*d = Duration(n)
+	return nil
+}
+
+// Time is a time.Time encoded as a JSON number of Unix seconds, e.g.
+// 1700000000 or 1700000000.5. The zero Time encodes as 0, and 0 decodes
+// to the zero Time. Times decode in UTC.
+type Time struct {
+	time.Time
+}
+
+// NewTime returns t as a Time.
+func NewTime(t time.Time) Time {
+	return Time{t}
+}
+
+// MarshalJSON encodes t as Unix seconds.
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("0"), nil
+	}
+	sec, nsec := t.Unix(), int64(t.Nanosecond())
+	if sec > maxSeconds || sec < -maxSeconds {
+		return nil, fmt.Errorf("rdbtypes: time %v out of range", t.Time)
+	}
+	return formatNanos(sec*1e9 + nsec), nil
+}
+
+// UnmarshalJSON decodes Unix seconds, an RFC 3339 string, or null, which
+// leaves t unchanged.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+	if data[0] == '"' {
+		var v time.Time
+		if err := v.UnmarshalJSON(data); err != nil {
+			return fmt.Errorf("rdbtypes: invalid time %s", data)
+		}
+		t.Time = v
+		return nil
+	}
+	n, err := parseNanos(data)
+	if err != nil {
+		return fmt.Errorf("rdbtypes: invalid time %s", data)
+	}
+	if n == 0 {
+		t.Time = time.Time{}
+		return nil
+	}
+	t.Time = time.Unix(0, n).UTC()
+	return nil
+}
+
+// Addr is a netip.Addr encoded as a JSON string in its canonical form,
+// e.g. "192.0.2.1" or "2001:db8::1". The zero Addr encodes as "".
+type Addr struct {
+	netip.Addr
+}
+
+// NewAddr returns a as an Addr.
+func NewAddr(a netip.Addr) Addr {
+	return Addr{a}
+}
+
+// MarshalJSON encodes a as a string.
+func (a Addr) MarshalJSON() ([]byte, error) {
+	if !a.IsValid() {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(a.Addr.String())
+}
+
+// UnmarshalJSON decodes an address string, "" or null. IPv4 addresses in
+// the dashed form used by ResolveDB keys, e.g. "192-0-2-1", are accepted
+// too.
+func (a *Addr) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		a.Addr = netip.Addr{}
+		return nil
+	}
+	v, err := netip.ParseAddr(s)
+	if err != nil && strings.Count(s, "-") == 3 && !strings.ContainsAny(s, ".:") {
+		v, err = netip.ParseAddr(strings.ReplaceAll(s, "-", "."))
+	}
+	if err != nil {
+		return fmt.Errorf("rdbtypes: invalid address %q", s)
+	}
+	a.Addr = v
+	return nil
+}
+
+// formatNanos formats n nanoseconds as a decimal number of seconds,
+// without trailing zeros.
+func formatNanos(n int64) []byte {
+	neg := n < 0
+	u := uint64(n)
+	if neg {
+		u = -u
+	}
+	b := strconv.AppendUint(nil, u/1e9, 10)
+	if frac := u % 1e9; frac != 0 {
+		digits := strconv.AppendUint(nil, frac+1e9, 10)[1:] // Zero-padded to 9 digits
+		b = append(append(b, '.'), bytes.TrimRight(digits, "0")...)
+	}
+	if neg {
+		b = append([]byte{'-'}, b...)
+	}
+	return b
+}
+
+// parseNanos parses a JSON number of seconds into nanoseconds exactly,
+// rounding toward zero past nanosecond precision.
+func parseNanos(data []byte) (int64, error) {
+	s := string(data)
+	if strings.ContainsAny(s, "eE") {
+		// Exponent notation, as some encoders write large or small numbers
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.Abs(f) > maxSeconds {
+			return 0, fmt.Errorf("invalid number")
+		}
+		return int64(f * 1e9), nil
+	}
+
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" {
+		return 0, fmt.Errorf("invalid number")
+	}
+	sec, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || sec > maxSeconds {
+		return 0, fmt.Errorf("invalid number")
+	}
+	var nsec int64
+	if frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		frac += strings.Repeat("0", 9-len(frac))
+		if nsec, err = strconv.ParseInt(frac, 10, 64); err != nil || nsec < 0 {
+			return 0, fmt.Errorf("invalid number")
+		}
+	}
+	n := sec*1e9 + nsec
+	if neg {
+		n = -n
+	}
+	return n, nil
+}
+
+// isNull reports whether data is the JSON null literal.
+func isNull(data []byte) bool {
+	return len(data) == 0 || string(data) == "null"
+}
@@ -0,0 +1,84 @@
+package resolvedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ReadOnlyClient is a client restricted to read operations. It has no write
+// methods and holds no API key, so code handling untrusted input cannot be
+// steered into mutations. It is safe for concurrent use.
+type ReadOnlyClient struct {
+	c *Client
+}
+
+// NewReadOnly creates a read-only client. It accepts the same options as New
+// but rejects WithAPIKey.
+//
+// Example:
+//
+//	client, err := resolvedb.NewReadOnly(resolvedb.WithNamespace("public"))
+//	var q resolvedb.Querier = client
+func NewReadOnly(opts ...Option) (*ReadOnlyClient, error) {
+	c, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if c.config.apiKey != "" {
+		c.Close()
+		return nil, fmt.Errorf("invalid configuration: read-only client does not accept an API key")
+	}
+	return &ReadOnlyClient{c: c}, nil
+}
+
+// Get retrieves data for a resource and key, unmarshaling into dst.
+func (r *ReadOnlyClient) Get(ctx context.Context, resource, key string, dst any, opts ...RequestOption) error {
+	return r.c.Get(ctx, resource, key, dst, opts...)
+}
+
+// GetRaw retrieves raw data for a resource and key.
+func (r *ReadOnlyClient) GetRaw(ctx context.Context, resource, key string, opts ...RequestOption) (*Response, error) {
+	return r.c.GetRaw(ctx, resource, key, opts...)
+}
+
+// GetStream retrieves data for a resource and key as a JSON decoder.
+func (r *ReadOnlyClient) GetStream(ctx context.Context, resource, key string, opts ...RequestOption) (*json.Decoder, error) {
+	return r.c.GetStream(ctx, resource, key, opts...)
+}
+
+// OpenReader retrieves a value as a reader, streaming chunked values.
+func (r *ReadOnlyClient) OpenReader(ctx context.Context, resource, key string, opts ...RequestOption) (io.ReadCloser, error) {
+	return r.c.OpenReader(ctx, resource, key, opts...)
+}
+
+// GetEncrypted retrieves and decrypts data.
+func (r *ReadOnlyClient) GetEncrypted(ctx context.Context, resource, key string, dst any, opts ...RequestOption) error {
+	return r.c.GetEncrypted(ctx, resource, key, dst, opts...)
+}
+
+// List retrieves a list of keys for a resource.
+func (r *ReadOnlyClient) List(ctx context.Context, resource string, opts ...RequestOption) ([]string, error) {
+	return r.c.List(ctx, resource, opts...)
+}
+
+// ListDetailed retrieves the keys of a resource with per-key metadata.
+func (r *ReadOnlyClient) ListDetailed(ctx context.Context, resource string, opts ...RequestOption) ([]KeyInfo, error) {
+	return r.c.ListDetailed(ctx, resource, opts...)
+}
+
+// Count returns the number of keys stored for a resource.
+func (r *ReadOnlyClient) Count(ctx context.Context, resource string, opts ...RequestOption) (int, error) {
+	return r.c.Count(ctx, resource, opts...)
+}
+
+// Stats returns a snapshot of the client's activity counters.
+func (r *ReadOnlyClient) Stats() Stats {
+	return r.c.Stats()
+}
+
+// Close releases resources held by the client.
+func (r *ReadOnlyClient) Close() error {
+	return r.c.Close()
+}
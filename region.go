@@ -0,0 +1,197 @@
+package resolvedb
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+	"github.com/resolvedb/resolvedb-go/uqrp"
+)
+
+// ReadPreference controls which regional endpoint serves reads.
+// Writes always go to the primary region.
+type ReadPreference int
+
+const (
+	// PrimaryOnly sends reads to the primary region.
+	PrimaryOnly ReadPreference = iota
+
+	// NearestRegion sends reads to the region with the lowest observed
+	// latency and, if it cannot be reached, to the next nearest. Regions
+	// that fail are penalized and tried again after a while to see
+	// whether they have recovered.
+	NearestRegion
+
+	// Fallback sends reads to the primary region and, if it cannot be
+	// reached, to the other regions in configured order.
+	Fallback
+)
+
+// String returns the preference name.
+func (p ReadPreference) String() string {
+	switch p {
+	case PrimaryOnly:
+		return "primary-only"
+	case NearestRegion:
+		return "nearest"
+	case Fallback:
+		return "fallback"
+	default:
+		return "unknown"
+	}
+}
+
+// Region is a regional ResolveDB endpoint.
+type Region struct {
+	Name      string              // Region name (e.g., "eu-west")
+	Transport transport.Transport // Transport for the region's endpoint
+}
+
+const (
+	// defaultRegionStickiness is how long reads of a written key stay on
+	// the primary region.
+	defaultRegionStickiness = 30 * time.Second
+
+	// regionFailurePenalty is the latency sample recorded for a failed query.
+	regionFailurePenalty = 5 * time.Second
+
+	// regionReprobeInterval is how long a failed region is penalized
+	// before it is measured again.
+	regionReprobeInterval = 30 * time.Second
+
+	// regionLatencyWeight is the weight of a new sample in the latency average.
+	regionLatencyWeight = 0.3
+)
+
+// regionRouter picks regional transports for queries. After a write, reads
+// of the written key and its resource stay on the primary region for the
+// stickiness window, so a session reads its own writes.
+type regionRouter struct {
+	regions    []*regionState // primary first
+	preference ReadPreference
+	stickiness time.Duration
+	clock      Clock
+
+	mu      sync.Mutex
+	written map[string]time.Time // sticky key -> expiry
+}
+
+// regionState tracks a region's observed latency.
+type regionState struct {
+	Region
+	latency  time.Duration // moving average, 0 until measured
+	failedAt time.Time     // time of the last failure, zero once recovered
+}
+
+// score returns the latency nearest ranks rs by at now. A region that
+// failed longer than regionReprobeInterval ago scores as unmeasured, so
+// the next read measures it again.
+func (rs *regionState) score(now time.Time) time.Duration {
+	if !rs.failedAt.IsZero() && now.Sub(rs.failedAt) >= regionReprobeInterval {
+		return 0
+	}
+	return rs.latency
+}
+
+func newRegionRouter(regions []Region, preference ReadPreference, stickiness time.Duration, clock Clock) *regionRouter {
+	r := &regionRouter{
+		preference: preference,
+		stickiness: stickiness,
+		clock:      clock,
+		written:    make(map[string]time.Time),
+	}
+	for _, region := range regions {
+		r.regions = append(r.regions, &regionState{Region: region})
+	}
+	return r
+}
+
+// route returns the regions to try for a query, in order.
+func (r *regionRouter) route(operation, namespace, resource, key string) []*regionState {
+	primary := r.regions[:1]
+	if !uqrp.Operation(operation).IsRead() {
+		return primary
+	}
+
+	switch r.preference {
+	case NearestRegion:
+		if r.isSticky(namespace, resource, key) {
+			return primary
+		}
+		return r.nearest()
+	case Fallback:
+		return r.regions
+	default:
+		return primary
+	}
+}
+
+// nearest returns the regions ordered by latency, lowest first, with ties
+// in configured order. Unmeasured regions are preferred so that every
+// region gets measured.
+func (r *regionRouter) nearest() []*regionState {
+	now := r.clock.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	regions := slices.Clone(r.regions)
+	slices.SortStableFunc(regions, func(a, b *regionState) int {
+		return cmp.Compare(a.score(now), b.score(now))
+	})
+	return regions
+}
+
+// observe records the outcome of a query to a region. The first success
+// after a failure starts the latency average over, so a recovered region
+// is not held back by its penalty.
+func (r *regionRouter) observe(rs *regionState, d time.Duration, err error) {
+	now := r.clock.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		rs.failedAt = now
+		d = max(d, regionFailurePenalty)
+	} else if !rs.failedAt.IsZero() {
+		rs.failedAt = time.Time{}
+		rs.latency = 0
+	}
+	if rs.latency == 0 {
+		rs.latency = d
+		return
+	}
+	rs.latency += time.Duration(regionLatencyWeight * float64(d-rs.latency))
+}
+
+// pin keeps reads of a written key, and lists of its resource, on the
+// primary region for the stickiness window.
+func (r *regionRouter) pin(namespace, resource, key string) {
+	if r.stickiness <= 0 {
+		return
+	}
+	now := r.clock.Now()
+	expiry := now.Add(r.stickiness)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.written[stickyKey(namespace, resource, key)] = expiry
+	r.written[stickyKey(namespace, resource, "")] = expiry
+
+	// Drop expired entries so the map stays bounded by the write rate
+	for k, exp := range r.written {
+		if now.After(exp) {
+			delete(r.written, k)
+		}
+	}
+}
+
+// isSticky reports whether a read must go to the primary region.
+func (r *regionRouter) isSticky(namespace, resource, key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	exp, ok := r.written[stickyKey(namespace, resource, key)]
+	return ok && r.clock.Now().Before(exp)
+}
+
+func stickyKey(namespace, resource, key string) string {
+	return normalizeKey(namespace + "/" + resource + "/" + key)
+}
@@ -0,0 +1,292 @@
+// Package registry keeps an in-memory snapshot of every value of a
+// resource, for catalogs that are read far more often than they change
+// and are small enough to hold whole: model registries, feature flags,
+// configuration documents. Lookups never touch the network; a background
+// refresh rebuilds the snapshot and swaps it in atomically.
+//
+// Example:
+//
+//	models, err := registry.New[ModelConfig](ctx, client, "models",
+//	    registry.WithRefresh(time.Minute))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if m, ok := models.Get("embeddings-v3"); ok {
+//	    route(m.Endpoint)
+//	}
+package registry
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+const (
+	// DefaultJitter is the refresh jitter used without WithJitter.
+	DefaultJitter = 0.1
+
+	// defaultBackoffFactor bounds the backoff after failed refreshes, as a
+	// multiple of the refresh interval, unless WithMaxBackoff is used.
+	defaultBackoffFactor = 8
+)
+
+// Option configures a Registry.
+type Option func(*config)
+
+type config struct {
+	refresh    time.Duration
+	jitter     float64
+	maxBackoff time.Duration
+	onRefresh  []func(error)
+	reqOpts    []resolvedb.RequestOption
+	load       func(ctx context.Context, key string, dst any, opts ...resolvedb.RequestOption) error
+}
+
+// WithRefresh reloads the registry every d until New's ctx is done.
+// Without it the registry holds the values loaded by New until Refresh is
+// called.
+func WithRefresh(d time.Duration) Option {
+	return func(c *config) {
+		c.refresh = d
+	}
+}
+
+// WithJitter spreads refreshes by up to fraction of the interval either
+// way (DefaultJitter by default), so a fleet of processes started
+// together does not refresh in lockstep.
+func WithJitter(fraction float64) Option {
+	return func(c *config) {
+		c.jitter = fraction
+	}
+}
+
+// WithMaxBackoff bounds the wait after failed refreshes. Each consecutive
+// failure doubles the wait, starting from the refresh interval, up to d
+// (8 intervals by default); a successful refresh returns to the interval.
+// The last good snapshot is served meanwhile.
+func WithMaxBackoff(d time.Duration) Option {
+	return func(c *config) {
+		c.maxBackoff = d
+	}
+}
+
+// OnRefresh calls fn after each background refresh, with the error if it
+// failed.
+func OnRefresh(fn func(error)) Option {
+	return func(c *config) {
+		c.onRefresh = append(c.onRefresh, fn)
+	}
+}
+
+// WithRequestOptions applies opts to every query made by the registry.
+func WithRequestOptions(opts ...resolvedb.RequestOption) Option {
+	return func(c *config) {
+		c.reqOpts = append(c.reqOpts, opts...)
+	}
+}
+
+// WithLoader loads each value with fn instead of the client's Get, for
+// services that post-process values, such as resolving references. fn
+// has the signature of Get without the resource.
+func WithLoader(fn func(ctx context.Context, key string, dst any, opts ...resolvedb.RequestOption) error) Option {
+	return func(c *config) {
+		c.load = fn
+	}
+}
+
+// Stats reports the activity of a registry.
+type Stats struct {
+	Entries       int       // Values in the current snapshot
+	LoadedAt      time.Time // When the current snapshot was loaded
+	Refreshes     uint64    // Background refreshes completed
+	RefreshErrors uint64    // Background refreshes that failed
+	LastError     error     // Error of the last background refresh; nil if it succeeded
+}
+
+// Registry holds a snapshot of every value of a resource, indexed by key.
+// It is safe for concurrent use.
+type Registry[T any] struct {
+	client   resolvedb.Querier
+	resource string
+	config   config
+	snap     atomic.Pointer[snapshot[T]]
+	clock    resolvedb.Clock
+	rand     io.Reader // Jitter; used by the refresh loop only
+
+	refreshes     atomic.Uint64
+	refreshErrors atomic.Uint64
+	mu            sync.Mutex
+	lastErr       error
+}
+
+// snapshot is one load of the registry.
+type snapshot[T any] struct {
+	values map[string]T
+	keys   []string // Sorted
+	loaded time.Time
+}
+
+// New loads every value of resource into a Registry. Keys deleted while
+// loading are skipped; any other error fails New. With WithRefresh, the
+// registry is reloaded in the background until ctx is done. Refreshes
+// follow the clock and randomness of client (see resolvedb.WithClock and
+// resolvedb.WithRandSource).
+func New[T any](ctx context.Context, client resolvedb.Querier, resource string, opts ...Option) (*Registry[T], error) {
+	r := &Registry[T]{
+		client:   client,
+		resource: resource,
+		config:   config{jitter: DefaultJitter},
+		clock:    resolvedb.ClockOf(client),
+		rand:     resolvedb.RandOf(client),
+	}
+	for _, opt := range opts {
+		opt(&r.config)
+	}
+	if r.config.load == nil {
+		r.config.load = func(ctx context.Context, key string, dst any, opts ...resolvedb.RequestOption) error {
+			return client.Get(ctx, resource, key, dst, opts...)
+		}
+	}
+	if r.config.maxBackoff <= 0 {
+		r.config.maxBackoff = defaultBackoffFactor * r.config.refresh
+	}
+
+	if err := r.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	if r.config.refresh > 0 {
+		go r.refreshLoop(ctx)
+	}
+	return r, nil
+}
+
+// Get returns the value of key. Values are shared by all readers and must
+// not be modified.
+func (r *Registry[T]) Get(key string) (T, bool) {
+	v, ok := r.snap.Load().values[key]
+	return v, ok
+}
+
+// Keys returns the keys of the registry in sorted order.
+func (r *Registry[T]) Keys() []string {
+	keys := r.snap.Load().keys
+	return append([]string(nil), keys...)
+}
+
+// Len returns the number of values in the registry.
+func (r *Registry[T]) Len() int {
+	return len(r.snap.Load().keys)
+}
+
+// Range calls fn with each key and value in key order, stopping if fn
+// returns false. It sees a single snapshot, even if a refresh swaps in
+// another meanwhile.
+func (r *Registry[T]) Range(fn func(key string, value T) bool) {
+	s := r.snap.Load()
+	for _, key := range s.keys {
+		if !fn(key, s.values[key]) {
+			return
+		}
+	}
+}
+
+// Stats returns the activity of the registry.
+func (r *Registry[T]) Stats() Stats {
+	s := r.snap.Load()
+	r.mu.Lock()
+	lastErr := r.lastErr
+	r.mu.Unlock()
+	return Stats{
+		Entries:       len(s.keys),
+		LoadedAt:      s.loaded,
+		Refreshes:     r.refreshes.Load(),
+		RefreshErrors: r.refreshErrors.Load(),
+		LastError:     lastErr,
+	}
+}
+
+// Refresh loads every value again and swaps in the new snapshot. On error
+// the previous snapshot is kept.
+func (r *Registry[T]) Refresh(ctx context.Context) error {
+	opts := append([]resolvedb.RequestOption{resolvedb.WithRefreshCache()}, r.config.reqOpts...)
+	keys, err := r.client.List(ctx, r.resource, opts...)
+	if err != nil {
+		return err
+	}
+
+	s := &snapshot[T]{values: make(map[string]T, len(keys)), loaded: r.clock.Now()}
+	for _, key := range keys {
+		var v T
+		if err := r.config.load(ctx, key, &v, opts...); err != nil {
+			if resolvedb.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		s.values[key] = v
+		s.keys = append(s.keys, key)
+	}
+	sort.Strings(s.keys)
+	r.snap.Store(s)
+	return nil
+}
+
+func (r *Registry[T]) refreshLoop(ctx context.Context) {
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.clock.After(r.wait(failures)):
+		}
+
+		err := r.Refresh(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			failures++
+			r.refreshErrors.Add(1)
+		} else {
+			failures = 0
+			r.refreshes.Add(1)
+		}
+		r.mu.Lock()
+		r.lastErr = err
+		r.mu.Unlock()
+		for _, fn := range r.config.onRefresh {
+			_ = resolvedb.SafeCall(r.client, "registry refresh callback", func() { fn(err) })
+		}
+	}
+}
+
+// wait returns the jittered delay before the next refresh after the given
+// number of consecutive failures.
+func (r *Registry[T]) wait(failures int) time.Duration {
+	d := r.config.refresh
+	for i := 0; i < failures && d < r.config.maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, max(r.config.maxBackoff, r.config.refresh))
+	if j := r.config.jitter; j > 0 {
+		d += time.Duration((r.randFloat()*2 - 1) * j * float64(d))
+	}
+	return d
+}
+
+// randFloat returns a number in [0, 1) read from the random source, or
+// 0.5, meaning no jitter, if the source fails.
+func (r *Registry[T]) randFloat() float64 {
+	var b [8]byte
+	if _, err := io.ReadFull(r.rand, b[:]); err != nil {
+		return 0.5
+	}
+	return float64(binary.BigEndian.Uint64(b[:])>>11) / (1 << 53)
+}
@@ -0,0 +1,187 @@
+// Package resolvedbtest provides a mock resolvedb.FullClient and an
+// in-process server for tests.
+//
+// MockClient is kept in step with resolvedb.FullClient, so tests that use
+// it keep compiling as the interface grows.
+//
+// Server is an in-process ResolveDB server for tests that need a real
+// client talking to storage.
+package resolvedbtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// ErrNotMocked is returned by MockClient methods whose function is not set.
+var ErrNotMocked = errors.New("resolvedbtest: method not mocked")
+
+// Call records a call made to a MockClient.
+type Call struct {
+	Method string // Method name (e.g., "Get")
+	Args   []any  // Arguments after ctx, excluding request options
+	Opts   int    // Number of request options passed
+}
+
+// MockClient implements resolvedb.FullClient with a function field per
+// method. Methods whose function is nil return ErrNotMocked, except Close,
+// which returns nil. All calls are recorded and can be inspected with
+// Calls.
+//
+// Example:
+//
+//	mock := &resolvedbtest.MockClient{
+//	    GetFunc: func(ctx context.Context, resource, key string, dst any, opts ...resolvedb.RequestOption) error {
+//	        *dst.(*Weather) = Weather{TempC: 21}
+//	        return nil
+//	    },
+//	}
+//	svc := NewService(mock)
+type MockClient struct {
+	GetFunc          func(ctx context.Context, resource, key string, dst any, opts ...resolvedb.RequestOption) error
+	GetRawFunc       func(ctx context.Context, resource, key string, opts ...resolvedb.RequestOption) (*resolvedb.Response, error)
+	GetStreamFunc    func(ctx context.Context, resource, key string, opts ...resolvedb.RequestOption) (*json.Decoder, error)
+	GetEncryptedFunc func(ctx context.Context, resource, key string, dst any, opts ...resolvedb.RequestOption) error
+	ListFunc         func(ctx context.Context, resource string, opts ...resolvedb.RequestOption) ([]string, error)
+	ListDetailedFunc func(ctx context.Context, resource string, opts ...resolvedb.RequestOption) ([]resolvedb.KeyInfo, error)
+	CountFunc        func(ctx context.Context, resource string, opts ...resolvedb.RequestOption) (int, error)
+	SetFunc          func(ctx context.Context, resource, key string, data any, opts ...resolvedb.RequestOption) error
+	SetEncryptedFunc func(ctx context.Context, resource, key string, data any, opts ...resolvedb.RequestOption) error
+	DeleteFunc       func(ctx context.Context, resource, key string, opts ...resolvedb.RequestOption) error
+	CloseFunc        func() error
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Ensure MockClient implements resolvedb.FullClient.
+var _ resolvedb.FullClient = (*MockClient)(nil)
+
+// Calls returns the calls made so far, in order.
+func (m *MockClient) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallCount returns the number of calls made to method.
+func (m *MockClient) CallCount(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, c := range m.calls {
+		if c.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *MockClient) record(c Call) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, c)
+}
+
+// Get calls GetFunc.
+func (m *MockClient) Get(ctx context.Context, resource, key string, dst any, opts ...resolvedb.RequestOption) error {
+	m.record(Call{Method: "Get", Args: []any{resource, key}, Opts: len(opts)})
+	if m.GetFunc == nil {
+		return ErrNotMocked
+	}
+	return m.GetFunc(ctx, resource, key, dst, opts...)
+}
+
+// GetRaw calls GetRawFunc.
+func (m *MockClient) GetRaw(ctx context.Context, resource, key string, opts ...resolvedb.RequestOption) (*resolvedb.Response, error) {
+	m.record(Call{Method: "GetRaw", Args: []any{resource, key}, Opts: len(opts)})
+	if m.GetRawFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.GetRawFunc(ctx, resource, key, opts...)
+}
+
+// GetStream calls GetStreamFunc.
+func (m *MockClient) GetStream(ctx context.Context, resource, key string, opts ...resolvedb.RequestOption) (*json.Decoder, error) {
+	m.record(Call{Method: "GetStream", Args: []any{resource, key}, Opts: len(opts)})
+	if m.GetStreamFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.GetStreamFunc(ctx, resource, key, opts...)
+}
+
+// GetEncrypted calls GetEncryptedFunc.
+func (m *MockClient) GetEncrypted(ctx context.Context, resource, key string, dst any, opts ...resolvedb.RequestOption) error {
+	m.record(Call{Method: "GetEncrypted", Args: []any{resource, key}, Opts: len(opts)})
+	if m.GetEncryptedFunc == nil {
+		return ErrNotMocked
+	}
+	return m.GetEncryptedFunc(ctx, resource, key, dst, opts...)
+}
+
+// List calls ListFunc.
+func (m *MockClient) List(ctx context.Context, resource string, opts ...resolvedb.RequestOption) ([]string, error) {
+	m.record(Call{Method: "List", Args: []any{resource}, Opts: len(opts)})
+	if m.ListFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.ListFunc(ctx, resource, opts...)
+}
+
+// ListDetailed calls ListDetailedFunc.
+func (m *MockClient) ListDetailed(ctx context.Context, resource string, opts ...resolvedb.RequestOption) ([]resolvedb.KeyInfo, error) {
+	m.record(Call{Method: "ListDetailed", Args: []any{resource}, Opts: len(opts)})
+	if m.ListDetailedFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.ListDetailedFunc(ctx, resource, opts...)
+}
+
+// Count calls CountFunc.
+func (m *MockClient) Count(ctx context.Context, resource string, opts ...resolvedb.RequestOption) (int, error) {
+	m.record(Call{Method: "Count", Args: []any{resource}, Opts: len(opts)})
+	if m.CountFunc == nil {
+		return 0, ErrNotMocked
+	}
+	return m.CountFunc(ctx, resource, opts...)
+}
+
+// Set calls SetFunc.
+func (m *MockClient) Set(ctx context.Context, resource, key string, data any, opts ...resolvedb.RequestOption) error {
+	m.record(Call{Method: "Set", Args: []any{resource, key, data}, Opts: len(opts)})
+	if m.SetFunc == nil {
+		return ErrNotMocked
+	}
+	return m.SetFunc(ctx, resource, key, data, opts...)
+}
+
+// SetEncrypted calls SetEncryptedFunc.
+func (m *MockClient) SetEncrypted(ctx context.Context, resource, key string, data any, opts ...resolvedb.RequestOption) error {
+	m.record(Call{Method: "SetEncrypted", Args: []any{resource, key, data}, Opts: len(opts)})
+	if m.SetEncryptedFunc == nil {
+		return ErrNotMocked
+	}
+	return m.SetEncryptedFunc(ctx, resource, key, data, opts...)
+}
+
+// Delete calls DeleteFunc.
+func (m *MockClient) Delete(ctx context.Context, resource, key string, opts ...resolvedb.RequestOption) error {
+	m.record(Call{Method: "Delete", Args: []any{resource, key}, Opts: len(opts)})
+	if m.DeleteFunc == nil {
+		return ErrNotMocked
+	}
+	return m.DeleteFunc(ctx, resource, key, opts...)
+}
+
+// Close calls CloseFunc, returning nil if it is not set.
+func (m *MockClient) Close() error {
+	m.record(Call{Method: "Close"})
+	if m.CloseFunc == nil {
+		return nil
+	}
+	return m.CloseFunc()
+}
@@ -0,0 +1,186 @@
+package resolvedbtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+	"github.com/resolvedb/resolvedb-go/uqrp"
+)
+
+// Server is an in-process ResolveDB server for tests. It implements
+// transport.Transport, so a real client can be pointed at it with
+// resolvedb.WithTransports, and it stores what the client writes: get,
+// put, delete, list and count are served, with expiry (WithExpiry) and
+// conditional writes (WithIfMatch, WithIfAbsent). Auth labels and tokens
+// are accepted without being verified.
+//
+// Example:
+//
+//	srv := resolvedbtest.NewServer()
+//	client, _ := resolvedb.New(resolvedb.WithTransports(srv), resolvedb.WithAPIKey("test"))
+//	err := client.Set(ctx, "config", "app", cfg)
+//	keys := srv.Keys("public", "config") // ["app"]
+type Server struct {
+	parser *uqrp.Parser
+
+	mu     sync.Mutex
+	values map[string]serverValue // namespace/resource/key -> value
+}
+
+// serverValue is a stored value.
+type serverValue struct {
+	data    []byte
+	expires time.Time
+}
+
+// hash returns the content hash of the value.
+func (v serverValue) hash() string {
+	sum := sha256.Sum256(v.data)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewServer creates an empty server for names in the default layout.
+func NewServer() *Server {
+	return &Server{
+		parser: uqrp.NewParser(uqrp.DefaultLayout()),
+		values: make(map[string]serverValue),
+	}
+}
+
+// Ensure Server implements transport.Transport.
+var _ transport.Transport = (*Server)(nil)
+
+func (s *Server) Name() string { return "resolvedbtest" }
+
+// IsEncrypted returns true: queries never leave the process.
+func (s *Server) IsEncrypted() bool { return true }
+
+func (s *Server) Close() error { return nil }
+
+// Keys returns the unexpired keys stored in a resource, in sorted order.
+func (s *Server) Keys(namespace, resource string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys(namespace, resource, time.Now())
+}
+
+// keys lists a resource. The caller must hold s.mu.
+func (s *Server) keys(namespace, resource string, now time.Time) []string {
+	prefix := namespace + "/" + resource + "/"
+	keys := []string{}
+	for k, v := range s.values {
+		if strings.HasPrefix(k, prefix) && !v.expired(now) {
+			keys = append(keys, strings.TrimPrefix(k, prefix))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// expired reports whether the value has expired at now.
+func (v serverValue) expired(now time.Time) bool {
+	return !v.expires.IsZero() && !now.Before(v.expires)
+}
+
+// Query answers req from the stored values.
+func (s *Server) Query(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	q, err := s.parser.Parse(req.Name)
+	if err != nil {
+		return answer(errorRecord("E001", err.Error())), nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return answer(s.serve(q, time.Now())), nil
+}
+
+// serve executes a query. The caller must hold s.mu.
+func (s *Server) serve(q uqrp.Query, now time.Time) string {
+	id := q.Namespace + "/" + q.Resource + "/" + q.Key
+	cur, exists := s.values[id]
+	if exists && cur.expired(now) {
+		delete(s.values, id)
+		exists = false
+	}
+
+	switch q.Operation {
+	case uqrp.OpGet:
+		if !exists {
+			return notFound(q)
+		}
+		record := "v=rdb1;s=ok;e=base64;hash=" + cur.hash()
+		if !cur.expires.IsZero() {
+			record += ";exp=" + strconv.FormatInt(cur.expires.Unix(), 10)
+		}
+		return record + ";d=" + base64.RawURLEncoding.EncodeToString(cur.data)
+
+	case uqrp.OpPut:
+		next := serverValue{data: q.Data}
+		for _, p := range q.Params {
+			switch {
+			case strings.HasPrefix(p, uqrp.PrefixExp):
+				unix, err := strconv.ParseInt(strings.TrimPrefix(p, uqrp.PrefixExp), 10, 64)
+				if err != nil {
+					return errorRecord("E001", "malformed expiry "+p)
+				}
+				next.expires = time.Unix(unix, 0)
+			case p == uqrp.PrefixIfMatch+uqrp.IfAbsent:
+				if exists {
+					return errorRecord("E005", "key exists")
+				}
+			case strings.HasPrefix(p, uqrp.PrefixIfMatch):
+				if !exists || !strings.HasPrefix(cur.hash(), strings.TrimPrefix(p, uqrp.PrefixIfMatch)) {
+					return errorRecord("E008", "hash mismatch")
+				}
+			}
+		}
+		s.values[id] = next
+		return "v=rdb1;s=ok;hash=" + next.hash()
+
+	case uqrp.OpDelete:
+		if !exists {
+			return notFound(q)
+		}
+		delete(s.values, id)
+		return "v=rdb1;s=ok"
+
+	case uqrp.OpList:
+		data, _ := json.Marshal(s.keys(q.Namespace, q.Resource, now))
+		return "v=rdb1;s=ok;t=json;e=base64;d=" + base64.RawURLEncoding.EncodeToString(data)
+
+	case uqrp.OpCount:
+		return "v=rdb1;s=ok;t=json;d=" + strconv.Itoa(len(s.keys(q.Namespace, q.Resource, now)))
+
+	default:
+		return errorRecord("E001", fmt.Sprintf("operation %q is not supported by resolvedbtest", q.Operation))
+	}
+}
+
+// answer wraps a UQRP record in a transport response.
+func answer(record string) *transport.Response {
+	data := []byte(record)
+	return &transport.Response{Data: data, Records: [][]byte{data}}
+}
+
+// notFound returns the record answered for a missing key.
+func notFound(q uqrp.Query) string {
+	return errorRecord("E004", "no such key "+q.Resource+"/"+q.Key)
+}
+
+// errorRecord returns an error record with status code.
+func errorRecord(code, msg string) string {
+	return "v=rdb1;s=" + code + ";err=" + strings.ReplaceAll(msg, ";", ",")
+}
@@ -0,0 +1,108 @@
+package resolvedbtest_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/resolvedbtest"
+)
+
+// newClient returns a client backed by srv, without caching or retries.
+func newClient(t *testing.T, srv *resolvedbtest.Server, opts ...resolvedb.Option) *resolvedb.Client {
+	t.Helper()
+	opts = append([]resolvedb.Option{
+		resolvedb.WithTransports(srv),
+		resolvedb.WithCache(resolvedb.CacheConfig{}),
+		resolvedb.WithRetry(resolvedb.RetryConfig{}),
+		resolvedb.WithAPIKey("test-key"),
+	}, opts...)
+	client, err := resolvedb.New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestServerRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	srv := resolvedbtest.NewServer()
+	client := newClient(t, srv)
+
+	type config struct {
+		Name string `json:"name"`
+	}
+	if err := client.Set(ctx, "config", "app", config{Name: "demo"}); err != nil {
+		t.Fatal(err)
+	}
+	var got config
+	if err := client.Get(ctx, "config", "app", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "demo" {
+		t.Fatalf("Get = %+v, want demo", got)
+	}
+	if err := client.Set(ctx, "config", "app", config{}, resolvedb.WithIfAbsent()); !errors.Is(err, resolvedb.ErrConflict) {
+		t.Fatalf("Set WithIfAbsent on an existing key = %v, want ErrConflict", err)
+	}
+
+	if err := client.Delete(ctx, "config", "app"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Get(ctx, "config", "app", &got); !resolvedb.IsNotFound(err) {
+		t.Fatalf("Get after Delete = %v, want not found", err)
+	}
+}
+
+func TestServerKeepsChunksOutOfUserResources(t *testing.T) {
+	ctx := context.Background()
+	srv := resolvedbtest.NewServer()
+	client := newClient(t, srv, resolvedb.WithAutoCodec())
+
+	// A user key named like a chunk must survive a chunked write.
+	if err := client.Set(ctx, "docs", "doc-c0", "mine"); err != nil {
+		t.Fatal(err)
+	}
+	// Random-looking text that compression cannot fit in one label
+	var b strings.Builder
+	for i := 0; b.Len() < 4000; i++ {
+		b.WriteString(strings.Repeat(string(rune('a'+i*7%26)), i%5+1))
+		b.WriteString(string(rune('A' + i*11%26)))
+	}
+	doc := b.String()
+	if err := client.Set(ctx, "docs", "doc", doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.Keys("public", "rdb-chunks")) < 2 {
+		t.Fatalf("chunks = %v, want the value split across several", srv.Keys("public", "rdb-chunks"))
+	}
+
+	keys, err := client.List(ctx, "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"doc", "doc-c0"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("List = %v, want %v", keys, want)
+	}
+	var got string
+	if err := client.Get(ctx, "docs", "doc", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != doc {
+		t.Fatalf("Get returned %d bytes, want the %d written", len(got), len(doc))
+	}
+	if err := client.Get(ctx, "docs", "doc-c0", &got); err != nil || got != "mine" {
+		t.Fatalf("Get doc-c0 = %q, %v; want the user's value", got, err)
+	}
+
+	if err := client.Delete(ctx, "docs", "doc"); err != nil {
+		t.Fatal(err)
+	}
+	if chunks := srv.Keys("public", "rdb-chunks"); len(chunks) != 0 {
+		t.Fatalf("chunks left after Delete: %v", chunks)
+	}
+}
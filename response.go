@@ -1,52 +1,167 @@
 package resolvedb
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/resolvedb/resolvedb-go/security"
+	"github.com/resolvedb/resolvedb-go/transport"
 )
 
 // Response represents a parsed ResolveDB response.
 type Response struct {
-	Version  string        // Protocol version (e.g., "rdb1")
-	Status   string        // Status code (e.g., "ok", "notfound", "error")
-	Type     string        // Response type (e.g., "json", "text", "binary")
-	Encoding string        // Data encoding (e.g., "base64", "hex", "plain")
-	Format   string        // Data format (e.g., "json", "text")
-	TTL      time.Duration // Cache TTL
-	Data     []byte        // Raw response data
-	Error    string        // Error details if status != "ok"
-	Chunks   int           // Number of chunks for large data
-	ChunkID  int           // Current chunk ID
-	Hash     string        // Content hash for verification
+	Version    string          // Protocol version (e.g., "rdb1")
+	Status     string          // Status code (e.g., "ok", "notfound", "error")
+	Type       string          // Response type (e.g., "json", "text", "binary")
+	Encoding   string          // Data encoding (e.g., "base64", "hex", "plain")
+	Format     string          // Data format (e.g., "json", "text", "binary")
+	TTL        time.Duration   // Cache TTL
+	Data       []byte          // Raw response data
+	Error      string          // Error details if status != "ok"
+	ErrorHint  string          // Suggested remedy for the error, if reported
+	ErrorDoc   string          // Documentation URL for the error, if reported
+	RetryAfter time.Duration   // Server-suggested wait before retrying, 0 if not reported
+	Chunks     int             // Number of chunks for large data
+	ChunkID    int             // Current chunk ID
+	Hash       string          // Content hash for verification
+	Timestamp  time.Time       // Server timestamp, zero if not provided
+	Expires    time.Time       // Time the server deletes the key, zero if it does not expire
+	Records    [][]byte        // Individual TXT records in sequence order
+	RecordTTLs []time.Duration // DNS TTL of each record, parallel to Records
+	Manifest   *ChunkManifest  // Manifest of a chunked answer, nil if none
+	Prefetch   []PrefetchHint  // Keys the server suggests reading next, see WithPrefetch
+	Meta       ResponseMeta    // Protocol metadata
+
+	buf     []byte    // Data buffer, reused when the response is pooled
+	poolRef *Response // The response itself while Release may pool it
+	clock   Clock     // Clock of the client that received it, nil for the system clock
+}
+
+// ResponseMeta carries protocol and query metadata reported alongside a
+// response.
+type ResponseMeta struct {
+	RateLimit *RateLimit // Rate-limit state, nil if not reported
+	Transport string     // Name of the transport that was queried
+	Attempts  int        // Number of attempts made for the query
+	Region    string     // Region that answered, empty without WithRegions
+
+	// ConsistencyToken identifies the write a response reflects, empty if
+	// not reported. See WithConsistencyToken.
+	ConsistencyToken string
+
+	// CanonicalName is the DNS name that answered: the query name, or the
+	// name its CNAME aliases lead to (see WithCNAMEFollowing). Empty if
+	// the transport does not report it.
+	CanonicalName string
+
+	// ChangeCursor marks the point in a resource's change log that a list
+	// or changes response reflects, empty if the server does not keep one.
+	// See WatchResource.
+	ChangeCursor string
+
+	// Warnings lists soft protocol problems with the response, nil if
+	// none. See WithWarningHandler.
+	Warnings []Warning
+
+	// Source reports where the answer came from: the server's src field
+	// if it sent one, otherwise SourceAuthoritative if the DNS answer had
+	// the AA flag set. Empty if unknown, as through most recursive
+	// resolvers. See WithRequireAuthoritative.
+	Source ResponseSource
+}
+
+// ResponseSource identifies what produced an answer.
+type ResponseSource string
+
+// Response sources.
+const (
+	SourceAuthoritative ResponseSource = "authoritative" // The primary store
+	SourceReplica       ResponseSource = "replica"       // A read replica, which may lag the primary
+	SourceCache         ResponseSource = "cache"         // A server or resolver cache
+)
+
+// IsAuthoritative reports whether the answer is known to come from the
+// primary store.
+func (m ResponseMeta) IsAuthoritative() bool {
+	return m.Source == SourceAuthoritative
+}
+
+// parseSource parses the src field of a response.
+func parseSource(value string) ResponseSource {
+	switch value {
+	case "auth", "authoritative", "primary":
+		return SourceAuthoritative
+	case "replica":
+		return SourceReplica
+	case "cache", "cached":
+		return SourceCache
+	}
+	return ResponseSource(value)
+}
+
+// RateLimit describes the server's rate-limit state for the caller.
+type RateLimit struct {
+	Limit     int       // Queries allowed per window
+	Remaining int       // Queries remaining in the current window
+	Reset     time.Time // Start of the next window
 }
 
 // ParseResponse parses a UQRP response string.
+// Malformed responses are reported as a *ProtocolError.
 // Supports two formats:
 // 1. JSON format: v=rdb1;s=<status>;t=<type>;d=<json_data>
 // 2. Compact format: v=rdb1;s=ok;loc=Quebec;tc=-7.2;tf=19.0;...
+//
+// The parser makes a single pass over s without splitting it, and compact
+// data fields are written straight to JSON in a pooled buffer.
+//
+// Compact field names of the built-in datasets (e.g., "tc" for temp_c) are
+// expanded. Clients only expand them for resources configured with
+// WithCompactExpansion.
 func ParseResponse(s string) (*Response, error) {
 	resp := &Response{}
-
-	// Reserved keys that are not part of the data payload
-	reservedKeys := map[string]bool{
-		"v": true, "s": true, "t": true, "e": true, "f": true,
-		"ttl": true, "d": true, "err": true, "chunks": true,
-		"chunk": true, "hash": true, "ts": true,
+	if err := parseResponse(resp, s, true); err != nil {
+		return nil, err
 	}
+	return resp, nil
+}
 
-	// Collect non-reserved keys as data fields
-	dataFields := make(map[string]any)
+// parseResponse parses a UQRP response string into the empty resp,
+// expanding compact field names if expand is set. Data is decoded into
+// resp's buffer.
+func parseResponse(resp *Response, s string, expand bool) error {
+	// Non-reserved keys are collected as JSON data fields
+	var fields *bytes.Buffer
+	// Fields before d= are only known to be unknown once d= is seen
+	var pendingField string
+	var pendingFields int
+	defer func() {
+		if fields != nil {
+			putFieldBuffer(fields)
+		}
+	}()
 
-	parts := strings.Split(s, ";")
-	for _, part := range parts {
-		kv := strings.SplitN(part, "=", 2)
-		if len(kv) != 2 {
+	for len(s) > 0 {
+		// Next "key=value" part
+		part := s
+		if i := strings.IndexByte(s, ';'); i >= 0 {
+			part, s = s[:i], s[i+1:]
+		} else {
+			s = ""
+		}
+
+		eq := strings.IndexByte(part, '=')
+		if eq < 0 {
 			continue
 		}
-		key, value := kv[0], kv[1]
+		key, value := part[:eq], part[eq+1:]
 
 		switch key {
 		case "v":
@@ -64,13 +179,19 @@ func ParseResponse(s string) (*Response, error) {
 				resp.TTL = time.Duration(ttl) * time.Second
 			}
 		case "d":
-			data, err := decodeResponseData(value, resp.Encoding)
+			data, err := appendResponseData(resp.buf[:0], value, resp.Encoding)
 			if err != nil {
-				return nil, fmt.Errorf("decode data: %w", err)
+				return &ProtocolError{Err: fmt.Errorf("decode data: %w", err)}
 			}
 			resp.Data = data
 		case "err":
 			resp.Error = value
+		case "hint":
+			resp.ErrorHint = value
+		case "doc":
+			resp.ErrorDoc = value
+		case "retry_after":
+			resp.RetryAfter = parseRetryAfter(value)
 		case "chunks":
 			if n, err := strconv.Atoi(value); err == nil {
 				resp.Chunks = n
@@ -82,94 +203,229 @@ func ParseResponse(s string) (*Response, error) {
 		case "hash":
 			resp.Hash = value
 		case "ts":
-			// Timestamp - reserved but not stored in Response
+			if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+				resp.Timestamp = time.Unix(ts, 0)
+			}
+		case "rl":
+			if n, err := strconv.Atoi(value); err == nil {
+				resp.rateLimit().Limit = n
+			}
+		case "rr":
+			if n, err := strconv.Atoi(value); err == nil {
+				resp.rateLimit().Remaining = n
+			}
+		case "rs":
+			if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+				resp.rateLimit().Reset = time.Unix(ts, 0)
+			}
+		case "exp":
+			if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+				resp.Expires = time.Unix(ts, 0)
+			}
+		case "cst":
+			resp.Meta.ConsistencyToken = value
+		case "cur":
+			resp.Meta.ChangeCursor = value
+		case "src":
+			resp.Meta.Source = parseSource(value)
+		case "pf":
+			resp.Prefetch = parsePrefetchHints(value)
+		case "dep":
+			resp.Meta.Warnings = append(resp.Meta.Warnings, Warning{Code: WarningDeprecated, Message: value})
 		default:
-			// Non-reserved key - part of data payload
-			if !reservedKeys[key] {
-				dataFields[key] = parseValue(value)
+			// Non-reserved key - part of data payload, unless there is a d= field
+			if resp.Data != nil {
+				resp.Meta.Warnings = append(resp.Meta.Warnings, unknownFieldWarning(key, 0))
+			} else if pendingFields++; pendingField == "" {
+				pendingField = key
+			}
+			if fields == nil {
+				fields = getFieldBuffer()
+				fields.WriteByte('{')
+			} else {
+				fields.WriteByte(',')
 			}
+			// Expand compact field names to full names for weather/geoip data
+			if expand {
+				key = expandCompactField(key)
+			}
+			writeJSONString(fields, key)
+			fields.WriteByte(':')
+			writeJSONValue(fields, value)
 		}
 	}
 
 	// Validate required fields
 	if resp.Version == "" {
-		return nil, ErrInvalidResponse
+		return &ProtocolError{Err: ErrInvalidResponse}
 	}
 
-	// If no explicit d= field but we have data fields, convert to JSON
-	if resp.Data == nil && len(dataFields) > 0 {
-		// Expand compact field names to full names for weather data
-		expanded := expandCompactFields(dataFields)
-		jsonData, err := json.Marshal(expanded)
-		if err != nil {
-			return nil, fmt.Errorf("marshal data fields: %w", err)
-		}
-		resp.Data = jsonData
+	// If no explicit d= field but we have data fields, use them as JSON
+	if resp.Data == nil && fields != nil {
+		fields.WriteByte('}')
+		resp.Data = append(resp.buf[:0], fields.Bytes()...)
+	} else if pendingFields > 0 {
+		resp.Meta.Warnings = append(resp.Meta.Warnings, unknownFieldWarning(pendingField, pendingFields-1))
+	}
+	if resp.Data != nil {
+		resp.buf = resp.Data
 	}
 
-	return resp, nil
+	return nil
+}
+
+// rateLimit returns the response's rate-limit metadata, allocating it if needed.
+func (r *Response) rateLimit() *RateLimit {
+	if r.Meta.RateLimit == nil {
+		r.Meta.RateLimit = &RateLimit{}
+	}
+	return r.Meta.RateLimit
+}
+
+// fieldBufferPool holds buffers for assembling compact data fields.
+var fieldBufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// maxPooledFieldBuffer caps the size of buffers returned to the pool.
+const maxPooledFieldBuffer = 64 << 10
+
+func getFieldBuffer() *bytes.Buffer {
+	return fieldBufferPool.Get().(*bytes.Buffer)
 }
 
-// parseValue attempts to parse a string value as a number if possible.
-func parseValue(s string) any {
-	// Try integer
-	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
-		return i
+func putFieldBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledFieldBuffer {
+		return
 	}
-	// Try float
-	if f, err := strconv.ParseFloat(s, 64); err == nil {
-		return f
+	buf.Reset()
+	fieldBufferPool.Put(buf)
+}
+
+// writeJSONValue writes a compact field value as JSON, typed as an integer,
+// float or boolean if it parses as one, and as a string otherwise.
+func writeJSONValue(buf *bytes.Buffer, s string) {
+	var scratch [32]byte
+
+	switch numberKind(s) {
+	case kindInt:
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			buf.Write(strconv.AppendInt(scratch[:0], i, 10))
+			return
+		}
+		fallthrough
+	case kindFloat:
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) {
+			buf.Write(strconv.AppendFloat(scratch[:0], f, 'g', -1, 64))
+			return
+		}
 	}
 	// Try boolean
-	if s == "true" {
-		return true
-	}
-	if s == "false" {
-		return false
-	}
-	// Return as string
-	return s
-}
-
-// expandCompactFields expands compact UQRP field names to full JSON field names.
-func expandCompactFields(fields map[string]any) map[string]any {
-	// Mapping of compact names to full names
-	fieldMap := map[string]string{
-		"loc": "location",
-		"tc":  "temp_c",
-		"tf":  "temp_f",
-		"cnd": "conditions",
-		"hum": "humidity",
-		"wnd": "wind_kph",
-		"vis": "visibility_km",
-		"uv":  "uv_index",
-		"tz":  "timezone",
-		"lt":  "local_time",
-		// GeoIP fields
-		"ip":      "ip",
-		"cc":      "country_code",
-		"cn":      "country",
-		"rg":      "region",
-		"ct":      "city",
-		"lat":     "latitude",
-		"lon":     "longitude",
-		"isp":     "isp",
-		"org":     "organization",
-		"as":      "asn",
-		"mobile":  "mobile",
-		"proxy":   "proxy",
-		"hosting": "hosting",
-	}
-
-	expanded := make(map[string]any)
-	for k, v := range fields {
-		if fullName, ok := fieldMap[k]; ok {
-			expanded[fullName] = v
-		} else {
-			expanded[k] = v
+	if s == "true" || s == "false" {
+		buf.WriteString(s)
+		return
+	}
+	// Write as string
+	writeJSONString(buf, s)
+}
+
+// Numeric classes reported by numberKind.
+const (
+	kindNone = iota
+	kindInt
+	kindFloat
+)
+
+// numberKind reports whether s could be a decimal integer or float, so that
+// non-numeric values skip strconv (whose errors allocate).
+func numberKind(s string) int {
+	if s == "" {
+		return kindNone
+	}
+	kind := kindInt
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= '0' && c <= '9':
+		case c == '-' || c == '+':
+			if i != 0 && s[i-1] != 'e' && s[i-1] != 'E' {
+				return kindNone
+			}
+		case c == '.' || c == 'e' || c == 'E':
+			kind = kindFloat
+		default:
+			return kindNone
 		}
 	}
-	return expanded
+	return kind
+}
+
+// writeJSONString writes s as a quoted JSON string.
+func writeJSONString(buf *bytes.Buffer, s string) {
+	const hexDigits = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 0x20 && c != '"' && c != '\\' {
+			continue
+		}
+		buf.WriteString(s[start:i])
+		switch c {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hexDigits[c>>4])
+			buf.WriteByte(hexDigits[c&0xF])
+		}
+		start = i + 1
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
+
+// compactFieldNames maps compact UQRP field names to full JSON field names.
+var compactFieldNames = map[string]string{
+	"loc": "location",
+	"tc":  "temp_c",
+	"tf":  "temp_f",
+	"cnd": "conditions",
+	"hum": "humidity",
+	"wnd": "wind_kph",
+	"vis": "visibility_km",
+	"uv":  "uv_index",
+	"tz":  "timezone",
+	"lt":  "local_time",
+	// GeoIP fields
+	"ip":      "ip",
+	"cc":      "country_code",
+	"cn":      "country",
+	"rg":      "region",
+	"ct":      "city",
+	"lat":     "latitude",
+	"lon":     "longitude",
+	"isp":     "isp",
+	"org":     "organization",
+	"as":      "asn",
+	"mobile":  "mobile",
+	"proxy":   "proxy",
+	"hosting": "hosting",
+}
+
+// expandCompactField expands a compact UQRP field name to its full JSON field name.
+func expandCompactField(name string) string {
+	if fullName, ok := compactFieldNames[name]; ok {
+		return fullName
+	}
+	return name
 }
 
 // decodeResponseData decodes the data field based on encoding.
@@ -177,6 +433,8 @@ func decodeResponseData(data, encoding string) ([]byte, error) {
 	switch encoding {
 	case "base64", "b64":
 		return decodeBase64(data)
+	case "base32", "b32":
+		return decodeBase32(data)
 	case "hex":
 		return decodeHex(data)
 	case "plain", "text", "":
@@ -190,6 +448,32 @@ func decodeResponseData(data, encoding string) ([]byte, error) {
 	}
 }
 
+// appendResponseData decodes data in the given encoding, appending it to
+// dst. Plain and base64 data are decoded without intermediate copies.
+// The result is never nil.
+func appendResponseData(dst []byte, data, encoding string) ([]byte, error) {
+	var out []byte
+	switch encoding {
+	case "plain", "text", "":
+		out = append(dst, data...)
+	case "base64", "b64":
+		var err error
+		if out, err = appendBase64(dst, data); err != nil {
+			return nil, err
+		}
+	default:
+		decoded, err := decodeResponseData(data, encoding)
+		if err != nil {
+			return nil, err
+		}
+		out = append(dst, decoded...)
+	}
+	if out == nil {
+		out = []byte{}
+	}
+	return out, nil
+}
+
 // IsSuccess returns true if the response indicates success.
 func (r *Response) IsSuccess() bool {
 	return r.Status == "ok" || r.Status == "success"
@@ -200,16 +484,28 @@ func (r *Response) IsError() bool {
 	return r.Status == "error" || strings.HasPrefix(r.Status, "E0")
 }
 
+// ResponseUnmarshaler is implemented by types that decode themselves from a
+// Response, such as compact field formats, binary blobs, or versioned schemas.
+type ResponseUnmarshaler interface {
+	UnmarshalRDB(resp *Response) error
+}
+
 // Unmarshal decodes the response data into v.
+// If v implements ResponseUnmarshaler, its UnmarshalRDB method is used
+// instead of the format-based decoding.
 func (r *Response) Unmarshal(v any) error {
 	if r.Data == nil {
 		return ErrNotFound
 	}
 
+	if u, ok := v.(ResponseUnmarshaler); ok {
+		return u.UnmarshalRDB(r)
+	}
+
 	switch r.Format {
 	case "json", "":
-		if err := json.Unmarshal(r.Data, v); err != nil {
-			return fmt.Errorf("json unmarshal: %w", err)
+		if err := unmarshalJSON(r.Data, v); err != nil {
+			return &DecodeError{Format: "json", Type: fmt.Sprintf("%T", v), Err: err}
 		}
 		return nil
 	case "text":
@@ -217,23 +513,107 @@ func (r *Response) Unmarshal(v any) error {
 			*s = string(r.Data)
 			return nil
 		}
-		return fmt.Errorf("cannot unmarshal text into %T", v)
+		return &DecodeError{Format: "text", Type: fmt.Sprintf("%T", v), Err: errors.New("text requires *string")}
+	case "binary":
+		if b, ok := v.(*[]byte); ok {
+			*b = append([]byte(nil), r.Data...)
+			return nil
+		}
+		return &DecodeError{Format: "binary", Type: fmt.Sprintf("%T", v), Err: errors.New("binary requires *[]byte")}
 	default:
 		// Try JSON first
-		if err := json.Unmarshal(r.Data, v); err == nil {
+		if err := unmarshalJSON(r.Data, v); err == nil {
 			return nil
 		}
-		return fmt.Errorf("unsupported format: %s", r.Format)
+		return &DecodeError{Format: r.Format, Type: fmt.Sprintf("%T", v), Err: errors.New("unsupported format")}
 	}
 }
 
+// Age returns how long ago the server produced the data, based on the
+// response timestamp and the clock of the client that received it (see
+// WithClock). Returns 0 if the server did not report one.
+func (r *Response) Age() time.Duration {
+	return r.ageAt(r.now())
+}
+
+// ageAt returns the response's age at now.
+func (r *Response) ageAt(now time.Time) time.Duration {
+	if r.Timestamp.IsZero() {
+		return 0
+	}
+	if age := now.Sub(r.Timestamp); age > 0 {
+		return age
+	}
+	return 0
+}
+
+// Lifetime returns how long until the server deletes the key, on the clock
+// of the client that received the response. Returns 0 if the key has
+// expired or does not expire; check Expires to tell them apart.
+func (r *Response) Lifetime() time.Duration {
+	if r.Expires.IsZero() {
+		return 0
+	}
+	if d := r.Expires.Sub(r.now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// now returns the current time on the response's clock.
+func (r *Response) now() time.Time {
+	if r.clock == nil {
+		return time.Now()
+	}
+	return r.clock.Now()
+}
+
+// Decoder returns a JSON decoder reading the response data in place.
+// Use it to stream-decode large payloads, such as arrays of records,
+// one element at a time instead of unmarshaling them in one step.
+func (r *Response) Decoder() *json.Decoder {
+	return json.NewDecoder(bytes.NewReader(r.Data))
+}
+
 // String returns the raw data as a string.
 func (r *Response) String() string {
 	return string(r.Data)
 }
 
 // ToError converts the response to an error if it indicates failure.
+// Rate-limit errors carry the server-reported RateLimit when available.
 func (r *Response) ToError() error {
+	err := r.toError()
+	if e, ok := err.(*Error); ok {
+		if e.Code == CodeRateLimited {
+			e.RateLimit = r.Meta.RateLimit
+		}
+		e.Hint, e.DocURL, e.RetryAfter = r.ErrorHint, r.ErrorDoc, r.RetryAfter
+	}
+	return err
+}
+
+// parseRetryAfter parses a retry_after value: seconds, possibly
+// fractional, or a Go duration such as "1m30s". Invalid and negative
+// values yield 0.
+func parseRetryAfter(value string) time.Duration {
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		if secs <= 0 || secs > maxRetryAfter.Seconds() {
+			return 0
+		}
+		return time.Duration(secs * float64(time.Second))
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 && d <= maxRetryAfter {
+		return d
+	}
+	return 0
+}
+
+// maxRetryAfter bounds the retry_after values accepted from servers.
+const maxRetryAfter = 24 * time.Hour
+
+// toError maps the response status to a protocol error.
+func (r *Response) toError() error {
 	if r.IsSuccess() {
 		return nil
 	}
@@ -270,6 +650,104 @@ func (r *Response) ToError() error {
 	}
 }
 
+// WriteResult describes what the server stored for a write operation.
+type WriteResult struct {
+	Hash      string        // Content hash of the stored data
+	TTL       time.Duration // Effective TTL applied by the server
+	Chunks    int           // Number of chunks the data was stored as (0 if not reported)
+	Timestamp time.Time     // Server timestamp of the write (zero if not reported)
+	Deleted   bool          // Whether a delete removed an existing key
+	Expires   time.Time     // Time the server deletes the key, zero if it does not expire
+	Codec     Codec         // Encoding chosen by WithAutoCodec, CodecStandard otherwise
+
+	// ConsistencyToken identifies this write. Pass it to WithConsistencyToken
+	// to read data at least this fresh. Empty if not reported.
+	ConsistencyToken string
+}
+
+// newWriteResult builds a WriteResult from a successful write response.
+func newWriteResult(resp *Response) *WriteResult {
+	return &WriteResult{
+		Hash:      resp.Hash,
+		TTL:       resp.TTL,
+		Chunks:    resp.Chunks,
+		Timestamp: resp.Timestamp,
+		Expires:   resp.Expires,
+
+		ConsistencyToken: resp.Meta.ConsistencyToken,
+	}
+}
+
+// ChunkManifest describes the records of a chunked answer: their count,
+// the SHA-256 of each record and the Merkle root over those hashes. The
+// client verifies answers against their manifest before parsing them, and
+// a response keeps its manifest so cached copies can be verified again.
+//
+// Example:
+//
+//	if resp.Manifest != nil {
+//	    if err := resp.Manifest.Verify(resp.Records); err != nil {
+//	        // errors.Is(err, resolvedb.ErrChunkIntegrity)
+//	    }
+//	}
+type ChunkManifest struct {
+	Chunks int      // Number of records
+	Hashes []string // Content hash of each record, in sequence order
+	Root   string   // Hex Merkle root over Hashes (RFC 6962)
+}
+
+// newChunkManifest converts a transport manifest, returning nil for nil.
+func newChunkManifest(m *transport.Manifest) *ChunkManifest {
+	if m == nil {
+		return nil
+	}
+	return &ChunkManifest{Chunks: m.Chunks, Hashes: m.Hashes, Root: m.Root}
+}
+
+// Verify checks records against the manifest: each record against its
+// hash, then all records together against the root. It returns an error
+// wrapping ErrChunkIntegrity on mismatch.
+func (m *ChunkManifest) Verify(records [][]byte) error {
+	if len(records) != m.Chunks || len(m.Hashes) != m.Chunks {
+		return fmt.Errorf("%w: manifest lists %d records, got %d", ErrChunkIntegrity, m.Chunks, len(records))
+	}
+	for i, record := range records {
+		if !security.VerifyHash(record, m.Hashes[i]) {
+			return fmt.Errorf("%w: record %d of %d does not match its hash", ErrChunkIntegrity, i+1, m.Chunks)
+		}
+	}
+	if security.VerifyMerkleRoot(records, m.Root) != nil {
+		return fmt.Errorf("%w: records do not match the manifest root", ErrChunkIntegrity)
+	}
+	return nil
+}
+
+// VerifyHash checks Data against the content hash the server reported,
+// using the algorithm named by its prefix (see security.RegisterHash). It
+// returns nil if no hash was reported, and an error wrapping
+// ErrChunkIntegrity on mismatch or if the algorithm is not registered.
+//
+// Example:
+//
+//	resp, err := client.GetRaw(ctx, "archives", "2024")
+//	...
+//	if err := resp.VerifyHash(); err != nil {
+//	    return err
+//	}
+func (r *Response) VerifyHash() error {
+	if r.Hash == "" {
+		return nil
+	}
+	id, _ := security.SplitHash(r.Hash)
+	if _, err := security.NewHash(id); err != nil {
+		return fmt.Errorf("%w: %v", ErrChunkIntegrity, err)
+	}
+	if !security.VerifyHash(r.Data, r.Hash) {
+		return fmt.Errorf("%w: data does not match its %s hash", ErrChunkIntegrity, id)
+	}
+	return nil
+}
+
 // IsChunked returns true if the response is part of a chunked data set.
 func (r *Response) IsChunked() bool {
 	return r.Chunks > 1
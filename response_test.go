@@ -0,0 +1,26 @@
+package resolvedb
+
+import "testing"
+
+func BenchmarkParseResponse(b *testing.B) {
+	benchmarks := []struct {
+		name  string
+		input string
+	}{
+		{"Plain", "v=rdb1;s=ok;t=text;d=hello"},
+		{"Base64", "v=rdb1;s=ok;t=json;e=base64;ttl=300;d=eyJ0ZW1wX2MiOjIxLjV9"},
+		{"Fields", "v=rdb1;s=ok;t=json;city=Paris;temp_c=14.6;rain=true"},
+		{"Metadata", "v=rdb1;s=ok;t=json;f=json;ttl=60;hash=ab12cd34;ts=1700000000;exp=1700003600;cst=w-42;rl=100;rr=7;rs=1700000060;d=1"},
+		{"Error", "v=rdb1;s=E011;err=maintenance;hint=fail over to another region;retry_after=1.5"},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ParseResponse(bm.input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
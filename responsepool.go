@@ -0,0 +1,68 @@
+package resolvedb
+
+import "sync"
+
+// maxPooledResponseData bounds the data buffer a pooled response keeps, so
+// one large answer does not pin its buffer in the pool.
+const maxPooledResponseData = 64 << 10
+
+var responsePool = sync.Pool{
+	New: func() any { return new(Response) },
+}
+
+// WithResponsePooling reuses Response structs and their data buffers from
+// a pool, which cuts allocations at high query rates. Get returns its
+// response to the pool itself once dst is decoded. Responses from GetRaw
+// may be handed back with Release when the caller is done with them.
+//
+// Responses that are cached, or decoded by a ResponseUnmarshaler, are
+// never pooled, so Release cannot corrupt a cache entry.
+//
+// Example:
+//
+//	client, err := resolvedb.New(resolvedb.WithResponsePooling())
+//
+//	resp, err := client.GetRaw(ctx, "events", key, resolvedb.WithSkipCache())
+//	if err == nil {
+//	    process(resp.Data)
+//	    resp.Release()
+//	}
+func WithResponsePooling() Option {
+	return func(c *clientConfig) {
+		c.responsePooling = true
+	}
+}
+
+// newResponse returns an empty response, from the pool if pooling is on.
+func (c *Client) newResponse() *Response {
+	if !c.config.responsePooling {
+		return &Response{clock: c.config.clock}
+	}
+	r := responsePool.Get().(*Response)
+	r.poolRef = r
+	r.clock = c.config.clock
+	return r
+}
+
+// Release returns a pooled response to the pool. Neither the response nor
+// slices taken from it, such as Data, may be used afterwards. Release is a
+// no-op for responses that are not pooled: those from clients without
+// WithResponsePooling, cached responses, and copies of a Response value,
+// so it is always safe to call once.
+func (r *Response) Release() {
+	if r == nil || r.poolRef != r {
+		return
+	}
+	buf := r.buf[:0]
+	if cap(buf) > maxPooledResponseData {
+		buf = nil
+	}
+	*r = Response{buf: buf}
+	responsePool.Put(r)
+}
+
+// retain takes r out of pooling, for responses that outlive the caller
+// that fetched them, such as cached responses.
+func (r *Response) retain() {
+	r.poolRef = nil
+}
@@ -0,0 +1,59 @@
+package resolvedb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+)
+
+// benchmarkAnswer is the TXT data answered to pooled-path benchmarks.
+const benchmarkAnswer = "v=rdb1;s=ok;t=json;e=base64;ttl=300;d=eyJ0ZW1wX2MiOjIxLjV9"
+
+// poolingBenchmarks compares the query path with and without pooling.
+var poolingBenchmarks = []struct {
+	name string
+	opts []Option
+}{
+	{"Unpooled", nil},
+	{"Pooled", []Option{WithResponsePooling()}},
+}
+
+func BenchmarkGet(b *testing.B) {
+	for _, bm := range poolingBenchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			mem := transport.NewMemory(transport.WithMemoryMissing([]byte(benchmarkAnswer)))
+			client := newTestClient(b, mem, bm.opts...)
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var v struct {
+					TempC float64 `json:"temp_c"`
+				}
+				if err := client.Get(ctx, "weather", "tokyo", &v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetRaw(b *testing.B) {
+	for _, bm := range poolingBenchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			mem := transport.NewMemory(transport.WithMemoryMissing([]byte(benchmarkAnswer)))
+			client := newTestClient(b, mem, bm.opts...)
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				resp, err := client.GetRaw(ctx, "weather", "tokyo")
+				if err != nil {
+					b.Fatal(err)
+				}
+				resp.Release()
+			}
+		})
+	}
+}
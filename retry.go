@@ -2,19 +2,28 @@ package resolvedb
 
 import (
 	"context"
-	cryptorand "crypto/rand"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
 	"math/rand"
 	"time"
 )
 
 // RetryConfig configures retry behavior with exponential backoff.
 type RetryConfig struct {
-	MaxRetries     int           // Maximum number of retries (0 = no retries)
-	InitialBackoff time.Duration // Initial backoff duration
-	MaxBackoff     time.Duration // Maximum backoff duration
-	Multiplier     float64       // Backoff multiplier (e.g., 2.0 for doubling)
-	JitterFactor   float64       // Jitter factor (0.0-1.0)
+	MaxRetries     int           `json:"max_retries"`     // Maximum number of retries (0 = no retries)
+	InitialBackoff time.Duration `json:"initial_backoff"` // Initial backoff duration
+	MaxBackoff     time.Duration `json:"max_backoff"`     // Maximum backoff duration
+	Multiplier     float64       `json:"multiplier"`      // Backoff multiplier (e.g., 2.0 for doubling)
+	JitterFactor   float64       `json:"jitter_factor"`   // Jitter factor (0.0-1.0)
+
+	// SplitDeadline divides the time left before the context's deadline
+	// evenly among the attempts left, so one slow attempt cannot use up
+	// the whole budget and leave no time to retry. An attempt that runs
+	// out of its share fails with a retryable ErrTimeout. It has no effect
+	// on contexts without a deadline.
+	SplitDeadline bool `json:"split_deadline"`
 }
 
 // DefaultRetryConfig returns the default retry configuration.
@@ -38,22 +47,33 @@ type retryer struct {
 	config  RetryConfig
 	attempt int
 	rng     *rand.Rand
+	clock   Clock
+	timeout time.Duration // Per-attempt timeout, 0 = none
 }
 
-// newRetryer creates a new retryer.
-func newRetryer(config RetryConfig) *retryer {
-	// Use crypto/rand for secure seeding to prevent predictable backoff timing
+// newRetryer creates a new retryer. Jitter is seeded from rnd, which is
+// crypto/rand unless overridden, to prevent predictable backoff timing.
+func newRetryer(config RetryConfig, clock Clock, rnd io.Reader) *retryer {
 	var seed int64
-	if err := binary.Read(cryptorand.Reader, binary.BigEndian, &seed); err != nil {
-		// Fallback to time-based seed if crypto/rand fails (should never happen)
-		seed = time.Now().UnixNano()
+	if err := binary.Read(rnd, binary.BigEndian, &seed); err != nil {
+		// Fallback to time-based seed if the source fails (should never happen)
+		seed = clock.Now().UnixNano()
 	}
 	return &retryer{
 		config: config,
 		rng:    rand.New(rand.NewSource(seed)),
+		clock:  clock,
 	}
 }
 
+// newRetryer creates a retryer with the client's retry configuration,
+// clock and randomness source.
+func (c *Client) newRetryer() *retryer {
+	r := newRetryer(c.retryConfig(), c.config.clock, c.config.rand)
+	r.timeout = c.config.attemptTimeout
+	return r
+}
+
 // ShouldRetry returns true if the operation should be retried.
 func (r *retryer) ShouldRetry(err error) bool {
 	if r.attempt >= r.config.MaxRetries {
@@ -86,17 +106,61 @@ func (r *retryer) NextBackoff() time.Duration {
 }
 
 // Wait waits for the next backoff duration or until context is cancelled.
-func (r *retryer) Wait(ctx context.Context) error {
+// When err carries a server-reported rate-limit reset or retry hint, the
+// wait extends to it, bounded by MaxBackoff.
+func (r *retryer) Wait(ctx context.Context, err error) error {
 	backoff := r.NextBackoff()
+	wait := RetryAfterFromError(err)
+	if rl := RateLimitFromError(err); rl != nil && !rl.Reset.IsZero() {
+		wait = max(wait, rl.Reset.Sub(r.clock.Now()))
+	}
+	if wait > backoff {
+		backoff = wait
+		if r.config.MaxBackoff > 0 && backoff > r.config.MaxBackoff {
+			backoff = r.config.MaxBackoff
+		}
+	}
 
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case <-time.After(backoff):
+	case <-r.clock.After(backoff):
 		return nil
 	}
 }
 
+// attemptContext returns the context for the next attempt. Its deadline
+// is the per-attempt timeout, or with SplitDeadline the attempt's share of
+// the time left if that is sooner.
+func (r *retryer) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := r.timeout
+	deadline, ok := ctx.Deadline()
+	if left := r.config.MaxRetries - r.attempt + 1; r.config.SplitDeadline && ok && left > 1 {
+		share := deadline.Sub(r.clock.Now()) / time.Duration(left)
+		if timeout <= 0 || share < timeout {
+			timeout = share
+		}
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// attemptError makes err retryable if the attempt ran out of its share of
+// the deadline while the caller's context is still live.
+func attemptError(ctx, attemptCtx context.Context, err error) error {
+	if err == nil || ctx.Err() != nil || !errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	var terr *TransportError
+	if errors.As(err, &terr) && terr.Protocol == nil {
+		terr.Protocol = ErrTimeout
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrTimeout, err)
+}
+
 // Attempt returns the current attempt number (0-indexed).
 func (r *retryer) Attempt() int {
 	return r.attempt
@@ -108,8 +172,7 @@ func (r *retryer) Reset() {
 }
 
 // doWithRetry executes a function with retry logic.
-func doWithRetry[T any](ctx context.Context, config RetryConfig, fn func() (T, error)) (T, error) {
-	r := newRetryer(config)
+func doWithRetry[T any](ctx context.Context, r *retryer, fn func() (T, error)) (T, error) {
 	var zero T
 
 	for {
@@ -122,7 +185,7 @@ func doWithRetry[T any](ctx context.Context, config RetryConfig, fn func() (T, e
 			return zero, err
 		}
 
-		if waitErr := r.Wait(ctx); waitErr != nil {
+		if waitErr := r.Wait(ctx, err); waitErr != nil {
 			return zero, waitErr
 		}
 	}
@@ -0,0 +1,50 @@
+// Package rollout provides the stable bucketing used for percentage
+// rollouts and experiment assignment, so that services, SDKs in other
+// languages and the ResolveDB server agree on which users are in a
+// rollout.
+//
+// A key is hashed into one of Buckets buckets for a seed, usually the
+// flag or experiment name:
+//
+//	bucket = uint64_be(SHA-256(seed + "." + key)[0:8]) mod 10000
+//
+// A percentage p covers buckets [0, p*100), so raising a rollout from 10%
+// to 20% keeps everyone who was already in it. The same key lands in
+// independent buckets for different seeds. The algorithm is part of the
+// protocol and never changes; protocoltest carries vectors for it.
+//
+// Example:
+//
+//	if rollout.InPercentage("new-checkout", user.ID, 10) {
+//	    renderNewCheckout()
+//	}
+package rollout
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Buckets is the number of buckets keys are hashed into. Each bucket is
+// 0.01% of keys.
+const Buckets = 10000
+
+// Bucket deterministically assigns key a bucket in [0, Buckets) for seed.
+func Bucket(seed, key string) int {
+	sum := sha256.Sum256([]byte(seed + "." + key))
+	return int(binary.BigEndian.Uint64(sum[:8]) % Buckets)
+}
+
+// InPercentage reports whether key falls within the first percentage
+// percent of buckets for seed. It is false for percentages of 0 or less
+// and true for 100 or more.
+func InPercentage(seed, key string, percentage int) bool {
+	return InRange(seed, key, 0, percentage*Buckets/100)
+}
+
+// InRange reports whether key's bucket for seed is in [lo, hi), for
+// splitting traffic into adjacent, non-overlapping slices.
+func InRange(seed, key string, lo, hi int) bool {
+	b := Bucket(seed, key)
+	return b >= lo && b < hi
+}
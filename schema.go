@@ -0,0 +1,313 @@
+package resolvedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema validates values before they are written.
+// It implements a subset of JSON Schema covering the keywords config
+// payloads typically need: type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// pattern, minimum, maximum, exclusiveMinimum and exclusiveMaximum.
+// Unsupported keywords are ignored.
+type Schema struct {
+	types                []string
+	enum                 []any
+	constValue           any
+	hasConst             bool
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *bool
+	items                *Schema
+	minItems, maxItems   *int
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	minimum, maximum     *float64
+	exclusiveMin         *float64
+	exclusiveMax         *float64
+}
+
+// schemaJSON is the wire form of a Schema.
+type schemaJSON struct {
+	Type                 json.RawMessage            `json:"type"`
+	Enum                 []any                      `json:"enum"`
+	Const                json.RawMessage            `json:"const"`
+	Properties           map[string]json.RawMessage `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties *bool                      `json:"additionalProperties"`
+	Items                json.RawMessage            `json:"items"`
+	MinItems             *int                       `json:"minItems"`
+	MaxItems             *int                       `json:"maxItems"`
+	MinLength            *int                       `json:"minLength"`
+	MaxLength            *int                       `json:"maxLength"`
+	Pattern              string                     `json:"pattern"`
+	Minimum              *float64                   `json:"minimum"`
+	Maximum              *float64                   `json:"maximum"`
+	ExclusiveMinimum     *float64                   `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64                   `json:"exclusiveMaximum"`
+}
+
+// CompileSchema parses a JSON Schema document.
+//
+// Example:
+//
+//	schema, err := resolvedb.CompileSchema([]byte(`{
+//	    "type": "object",
+//	    "required": ["interval"],
+//	    "properties": {
+//	        "interval": {"type": "integer", "minimum": 10},
+//	        "mode": {"enum": ["eco", "normal"]}
+//	    }
+//	}`))
+func CompileSchema(schema []byte) (*Schema, error) {
+	var raw schemaJSON
+	if err := json.Unmarshal(schema, &raw); err != nil {
+		return nil, fmt.Errorf("resolvedb: parse schema: %w", err)
+	}
+
+	s := &Schema{
+		enum:                 raw.Enum,
+		required:             raw.Required,
+		additionalProperties: raw.AdditionalProperties,
+		minItems:             raw.MinItems,
+		maxItems:             raw.MaxItems,
+		minLength:            raw.MinLength,
+		maxLength:            raw.MaxLength,
+		minimum:              raw.Minimum,
+		maximum:              raw.Maximum,
+		exclusiveMin:         raw.ExclusiveMinimum,
+		exclusiveMax:         raw.ExclusiveMaximum,
+	}
+
+	if len(raw.Type) > 0 {
+		var one string
+		if err := json.Unmarshal(raw.Type, &one); err == nil {
+			s.types = []string{one}
+		} else if err := json.Unmarshal(raw.Type, &s.types); err != nil {
+			return nil, fmt.Errorf("resolvedb: schema type must be a string or array of strings")
+		}
+	}
+
+	if len(raw.Const) > 0 {
+		if err := json.Unmarshal(raw.Const, &s.constValue); err != nil {
+			return nil, fmt.Errorf("resolvedb: schema const: %w", err)
+		}
+		s.hasConst = true
+	}
+
+	if raw.Pattern != "" {
+		re, err := regexp.Compile(raw.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("resolvedb: schema pattern: %w", err)
+		}
+		s.pattern = re
+	}
+
+	if len(raw.Properties) > 0 {
+		s.properties = make(map[string]*Schema, len(raw.Properties))
+		for name, prop := range raw.Properties {
+			sub, err := CompileSchema(prop)
+			if err != nil {
+				return nil, err
+			}
+			s.properties[name] = sub
+		}
+	}
+
+	if len(raw.Items) > 0 {
+		sub, err := CompileSchema(raw.Items)
+		if err != nil {
+			return nil, err
+		}
+		s.items = sub
+	}
+
+	return s, nil
+}
+
+// MustCompileSchema is like CompileSchema but panics on error.
+func MustCompileSchema(schema []byte) *Schema {
+	s, err := CompileSchema(schema)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Validate checks a Go value against the schema using its JSON encoding.
+// Returns a *ValidationError describing the first violation found.
+func (s *Schema) Validate(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+	return s.ValidateJSON(data)
+}
+
+// ValidateJSON checks a JSON document against the schema.
+func (s *Schema) ValidateJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return &ValidationError{Path: "$", Reason: "invalid JSON: " + err.Error()}
+	}
+	return s.validate("$", v)
+}
+
+// validate checks v at path against the schema.
+func (s *Schema) validate(path string, v any) error {
+	if len(s.types) > 0 && !s.matchesType(v) {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("expected %s, got %s", strings.Join(s.types, " or "), jsonType(v))}
+	}
+
+	if s.hasConst && !jsonEqual(v, s.constValue) {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must equal %v", s.constValue)}
+	}
+
+	if len(s.enum) > 0 {
+		found := false
+		for _, e := range s.enum {
+			if jsonEqual(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &ValidationError{Path: path, Reason: fmt.Sprintf("must be one of %v", s.enum)}
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		return s.validateObject(path, val)
+	case []any:
+		return s.validateArray(path, val)
+	case string:
+		return s.validateString(path, val)
+	case float64:
+		return s.validateNumber(path, val)
+	}
+	return nil
+}
+
+func (s *Schema) validateObject(path string, obj map[string]any) error {
+	for _, name := range s.required {
+		if _, ok := obj[name]; !ok {
+			return &ValidationError{Path: path, Reason: fmt.Sprintf("missing required property %q", name)}
+		}
+	}
+
+	// Check properties in a stable order so errors are deterministic
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sub, ok := s.properties[name]
+		if !ok {
+			if s.additionalProperties != nil && !*s.additionalProperties {
+				return &ValidationError{Path: path, Reason: fmt.Sprintf("unexpected property %q", name)}
+			}
+			continue
+		}
+		if err := sub.validate(path+"."+name, obj[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateArray(path string, arr []any) error {
+	if s.minItems != nil && len(arr) < *s.minItems {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must have at least %d items", *s.minItems)}
+	}
+	if s.maxItems != nil && len(arr) > *s.maxItems {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must have at most %d items", *s.maxItems)}
+	}
+	if s.items != nil {
+		for i, item := range arr {
+			if err := s.items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateString(path, str string) error {
+	n := utf8.RuneCountInString(str)
+	if s.minLength != nil && n < *s.minLength {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be at least %d characters", *s.minLength)}
+	}
+	if s.maxLength != nil && n > *s.maxLength {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be at most %d characters", *s.maxLength)}
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must match pattern %q", s.pattern.String())}
+	}
+	return nil
+}
+
+func (s *Schema) validateNumber(path string, n float64) error {
+	if s.minimum != nil && n < *s.minimum {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be >= %v", *s.minimum)}
+	}
+	if s.maximum != nil && n > *s.maximum {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be <= %v", *s.maximum)}
+	}
+	if s.exclusiveMin != nil && n <= *s.exclusiveMin {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be > %v", *s.exclusiveMin)}
+	}
+	if s.exclusiveMax != nil && n >= *s.exclusiveMax {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be < %v", *s.exclusiveMax)}
+	}
+	return nil
+}
+
+// matchesType reports whether v matches one of the schema types.
+func (s *Schema) matchesType(v any) bool {
+	actual := jsonType(v)
+	for _, t := range s.types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON Schema type name of a decoded JSON value.
+func jsonType(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == math.Trunc(val) && !math.IsInf(val, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// jsonEqual compares two decoded JSON values.
+func jsonEqual(a, b any) bool {
+	ab, err1 := json.Marshal(a)
+	bb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(ab) == string(bb)
+}
@@ -0,0 +1,152 @@
+package resolvedb
+
+import (
+	"context"
+	"time"
+)
+
+// SDKVersion is the version of this SDK. The default DoH transport
+// advertises it in the User-Agent header, and WithVersionCheck compares it
+// with the published SDK advisory.
+const SDKVersion = "0.9.0"
+
+// userAgent identifies this SDK to HTTP servers.
+const userAgent = "resolvedb-go/" + SDKVersion
+
+// The SDK advisory is published as public data at public/sdk/go.
+const (
+	sdkAdvisoryNamespace = "public"
+	sdkAdvisoryResource  = "sdk"
+	sdkAdvisoryKey       = "go"
+)
+
+const (
+	// versionCheckInterval is how often WithVersionCheck repeats the check
+	// in long-running processes.
+	versionCheckInterval = 24 * time.Hour
+
+	// versionCheckTimeout bounds each check.
+	versionCheckTimeout = 30 * time.Second
+)
+
+// SeverityCritical marks an SDKDefect that warrants upgrading at once.
+const SeverityCritical = "critical"
+
+// SDKAdvisory is the record ResolveDB publishes about SDK releases: the
+// latest version and the known defects of earlier ones.
+type SDKAdvisory struct {
+	Latest  string      `json:"latest"`
+	Defects []SDKDefect `json:"defects,omitempty"`
+}
+
+// SDKDefect is a known defect of a range of SDK versions.
+type SDKDefect struct {
+	Versions string `json:"versions"` // Affected versions, e.g. ">=0.8.0 <0.8.3"
+	Severity string `json:"severity"` // SeverityCritical, or informational
+	Summary  string `json:"summary"`
+	FixedIn  string `json:"fixed_in,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// Affects reports whether the defect affects version v. Versions takes
+// the constraints ResolveVersion does; a defect whose Versions cannot be
+// parsed affects no version.
+func (d SDKDefect) Affects(v Version) bool {
+	r, err := parseVersionRange(d.Versions)
+	return err == nil && r.matches(v)
+}
+
+// WithVersionCheck makes the client check the published SDK advisory
+// when created and daily afterwards, for fleets that cannot be redeployed
+// quickly. Critical defects affecting SDKVersion are reported as
+// WarningSDKDefect warnings, and a newer release is logged at info level.
+// The check runs in the background and never fails the client; its
+// errors are logged at debug level. It is off by default.
+func WithVersionCheck() Option {
+	return func(c *clientConfig) {
+		c.versionCheck = true
+	}
+}
+
+// CheckSDKVersion reads the published SDK advisory and returns it with the
+// defects affecting SDKVersion.
+//
+// Example:
+//
+//	advisory, defects, err := client.CheckSDKVersion(ctx)
+//	if err == nil && len(defects) > 0 {
+//	    log.Printf("resolvedb-go %s is affected by %d defects, latest is %s",
+//	        resolvedb.SDKVersion, len(defects), advisory.Latest)
+//	}
+func (c *Client) CheckSDKVersion(ctx context.Context) (*SDKAdvisory, []SDKDefect, error) {
+	var advisory SDKAdvisory
+	err := c.Get(ctx, sdkAdvisoryResource, sdkAdvisoryKey, &advisory,
+		WithRequestNamespace(sdkAdvisoryNamespace))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, _ := ParseVersion(SDKVersion)
+	var affecting []SDKDefect
+	for _, d := range advisory.Defects {
+		if d.Affects(current) {
+			affecting = append(affecting, d)
+		}
+	}
+	return &advisory, affecting, nil
+}
+
+// startVersionCheck runs the version check in the background until the
+// client is closed.
+func (c *Client) startVersionCheck() {
+	ctx, release, ok := c.background(context.Background())
+	if !ok {
+		return
+	}
+	go func() {
+		defer release()
+
+		for {
+			c.checkVersion(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.config.clock.After(versionCheckInterval):
+			}
+		}
+	}()
+}
+
+// checkVersion checks the SDK advisory once and reports the outcome.
+func (c *Client) checkVersion(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, versionCheckTimeout)
+	defer cancel()
+
+	advisory, defects, err := c.CheckSDKVersion(ctx)
+	if err != nil {
+		if context.Cause(ctx) != ErrClosed {
+			c.logger().Debug("resolvedb: SDK version check failed", "error", err)
+		}
+		return
+	}
+
+	for _, d := range defects {
+		if d.Severity != SeverityCritical {
+			continue
+		}
+		msg := "resolvedb-go " + SDKVersion + " has a critical defect: " + d.Summary
+		if d.FixedIn != "" {
+			msg += "; fixed in " + d.FixedIn
+		}
+		if d.URL != "" {
+			msg += "; see " + d.URL
+		}
+		c.warn(Warning{Code: WarningSDKDefect, Resource: sdkAdvisoryResource, Message: msg})
+	}
+
+	current, _ := ParseVersion(SDKVersion)
+	if latest, err := ParseVersion(advisory.Latest); err == nil && latest.Compare(current) > 0 {
+		c.logger().Info("resolvedb: a newer SDK version is available",
+			"current", SDKVersion, "latest", latest.String())
+	}
+}
@@ -0,0 +1,142 @@
+package security
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Default resources used by EnrollDevice.
+const (
+	DefaultEnrollmentResource   = "enrollments"
+	DefaultDeviceConfigResource = "device-config"
+)
+
+// minEnrollmentKeyLen is the minimum enrollment key length in bytes.
+const minEnrollmentKeyLen = 16
+
+// DeviceStore is the write side of a ResolveDB client, as used by
+// EnrollDevice. *resolvedb.Client satisfies it.
+type DeviceStore[O any] interface {
+	Set(ctx context.Context, resource, key string, data any, opts ...O) error
+}
+
+// Enrollment is the outcome of enrolling a device.
+type Enrollment struct {
+	BDT            *BDT      // Device identity token, to be stored on the device
+	DeviceID       string    // BDT without its prefix, used as the device's key
+	ConfigResource string    // Resource holding the device's configuration
+	ConfigKey      string    // Key of the device's configuration
+	EnrolledAt     time.Time // Time of enrollment
+}
+
+// EnrollmentRecord is the binding stored for an enrolled device. Its proof
+// shows the enrolling device held the fleet enrollment key.
+type EnrollmentRecord struct {
+	DeviceID       string `json:"device_id"`
+	ConfigResource string `json:"config_resource"`
+	ConfigKey      string `json:"config_key"`
+	EnrolledAt     int64  `json:"enrolled_at"`
+	Proof          string `json:"proof"`
+}
+
+// EnrollOption configures EnrollDevice.
+type EnrollOption func(*enrollConfig)
+
+type enrollConfig struct {
+	enrollmentResource string
+	configResource     string
+}
+
+// WithEnrollmentResource sets the resource enrollment records are stored
+// in (default: "enrollments").
+func WithEnrollmentResource(resource string) EnrollOption {
+	return func(c *enrollConfig) {
+		c.enrollmentResource = resource
+	}
+}
+
+// WithDeviceConfigResource sets the resource holding per-device
+// configuration (default: "device-config").
+func WithDeviceConfigResource(resource string) EnrollOption {
+	return func(c *enrollConfig) {
+		c.configResource = resource
+	}
+}
+
+// EnrollDevice onboards a device: it mints a BDT, binds it to a
+// device-scoped configuration key, and stores the binding, with a proof of
+// possession of the fleet enrollment key, through client. The device keeps
+// the returned BDT and reads its configuration from ConfigResource/ConfigKey.
+// The enrollment key is never sent.
+//
+// Example:
+//
+//	enrollment, err := security.EnrollDevice(ctx, client, fleetEnrollmentKey)
+//	if err != nil {
+//	    return err
+//	}
+//	saveToFlash(enrollment.BDT.String())
+func EnrollDevice[O any](ctx context.Context, client DeviceStore[O], enrollmentKey []byte, opts ...EnrollOption) (*Enrollment, error) {
+	if len(enrollmentKey) < minEnrollmentKeyLen {
+		return nil, fmt.Errorf("enrollment key must be at least %d bytes", minEnrollmentKeyLen)
+	}
+
+	config := enrollConfig{
+		enrollmentResource: DefaultEnrollmentResource,
+		configResource:     DefaultDeviceConfigResource,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	bdt, err := NewBDT()
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment := &Enrollment{
+		BDT:            bdt,
+		DeviceID:       bdt.String()[len(PrefixBDT):],
+		ConfigResource: config.configResource,
+		EnrolledAt:     time.Now(),
+	}
+	enrollment.ConfigKey = enrollment.DeviceID
+
+	record := EnrollmentRecord{
+		DeviceID:       enrollment.DeviceID,
+		ConfigResource: enrollment.ConfigResource,
+		ConfigKey:      enrollment.ConfigKey,
+		EnrolledAt:     enrollment.EnrolledAt.Unix(),
+	}
+	record.Proof = enrollmentProof(&record, enrollmentKey)
+
+	if err := client.Set(ctx, config.enrollmentResource, enrollment.DeviceID, record); err != nil {
+		return nil, fmt.Errorf("store enrollment: %w", err)
+	}
+	return enrollment, nil
+}
+
+// VerifyEnrollment checks that an enrollment record was produced by a
+// holder of the enrollment key.
+// Per security review: constant-time comparison.
+func VerifyEnrollment(record *EnrollmentRecord, enrollmentKey []byte) error {
+	if !ConstantTimeCompareString(record.Proof, enrollmentProof(record, enrollmentKey)) {
+		return fmt.Errorf("enrollment proof mismatch")
+	}
+	return nil
+}
+
+// enrollmentProof computes the 128-bit proof of an enrollment record.
+func enrollmentProof(record *EnrollmentRecord, enrollmentKey []byte) string {
+	// Build message: enroll|device|config_resource|config_key|enrolled_at
+	message := "enroll|" + record.DeviceID + "|" + record.ConfigResource + "|" +
+		record.ConfigKey + "|" + strconv.FormatInt(record.EnrolledAt, 10)
+	mac := hmac.New(sha256.New, enrollmentKey)
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
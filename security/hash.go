@@ -2,10 +2,88 @@ package security
 
 import (
 	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/subtle"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"sync"
 )
 
+// Content hash algorithms
+//
+// Content hashes are hex digests, optionally prefixed with the identifier
+// of the algorithm that produced them and a colon, as in "b3:<hex>".
+// Digests without a prefix are SHA-256. SHA-256 ("sha256") and SHA-512
+// ("sha512") are built in; register others, such as BLAKE3 for speed on
+// large blobs, with RegisterHash:
+//
+//	security.RegisterHash("b3", func() hash.Hash { return blake3.New(32, nil) })
+
+// DefaultHash identifies the algorithm of digests without a prefix.
+const DefaultHash = "sha256"
+
+// ErrUnknownHash is returned for content hashes of an unregistered
+// algorithm.
+var ErrUnknownHash = errors.New("unknown hash algorithm")
+
+var (
+	hashesMu sync.RWMutex
+	hashes   = map[string]func() hash.Hash{
+		"sha256": sha256.New,
+		"sha512": sha512.New,
+	}
+)
+
+// RegisterHash makes the algorithm identified by id available for content
+// hashes. It replaces any algorithm registered under the same id, and
+// panics if id is empty or contains a colon, or newHash is nil.
+func RegisterHash(id string, newHash func() hash.Hash) {
+	if id == "" || strings.Contains(id, ":") || newHash == nil {
+		panic("security: invalid hash registration " + id)
+	}
+	hashesMu.Lock()
+	defer hashesMu.Unlock()
+	hashes[id] = newHash
+}
+
+// NewHash returns a new hash.Hash for the algorithm identified by id.
+func NewHash(id string) (hash.Hash, error) {
+	hashesMu.RLock()
+	newHash, ok := hashes[id]
+	hashesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownHash, id)
+	}
+	return newHash(), nil
+}
+
+// SplitHash splits a content hash into its algorithm identifier and hex
+// digest. Digests without a prefix are DefaultHash.
+func SplitHash(contentHash string) (id, digest string) {
+	if id, digest, ok := strings.Cut(contentHash, ":"); ok {
+		return id, digest
+	}
+	return DefaultHash, contentHash
+}
+
+// ContentHash computes the content hash of data with the algorithm
+// identified by id, prefixed with id unless it is DefaultHash.
+func ContentHash(id string, data []byte) (string, error) {
+	h, err := NewHash(id)
+	if err != nil {
+		return "", err
+	}
+	h.Write(data)
+	digest := hex.EncodeToString(h.Sum(nil))
+	if id == DefaultHash {
+		return digest, nil
+	}
+	return id + ":" + digest, nil
+}
+
 // SHA256 computes the SHA-256 hash of data.
 func SHA256(data []byte) []byte {
 	h := sha256.Sum256(data)
@@ -28,10 +106,17 @@ func ConstantTimeCompareString(a, b string) bool {
 	return ConstantTimeCompare([]byte(a), []byte(b))
 }
 
-// VerifyHash verifies that data matches the expected hash.
-func VerifyHash(data []byte, expectedHex string) bool {
-	actual := SHA256Hex(data)
-	return ConstantTimeCompareString(actual, expectedHex)
+// VerifyHash verifies that data matches the expected content hash, using
+// the algorithm named by its prefix. Hashes of unregistered algorithms
+// never match.
+func VerifyHash(data []byte, expected string) bool {
+	id, digest := SplitHash(expected)
+	h, err := NewHash(id)
+	if err != nil {
+		return false
+	}
+	h.Write(data)
+	return ConstantTimeCompareString(hex.EncodeToString(h.Sum(nil)), strings.ToLower(digest))
 }
 
 // VerifyChunkIntegrity verifies the integrity of a data chunk.
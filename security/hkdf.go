@@ -2,6 +2,7 @@ package security
 
 import (
 	"crypto/sha256"
+	"fmt"
 	"io"
 
 	"golang.org/x/crypto/hkdf"
@@ -61,3 +62,61 @@ func BuildHKDFInfo(fqdn string, clientPubKey, serverPubKey []byte, timestamp int
 
 	return info
 }
+
+// Key purposes for DeriveResourceKey. Keys derived for different purposes
+// are independent, so an encryption key never doubles as a signing key.
+const (
+	KeyPurposeEncryption = "enc"
+	KeyPurposeSigning    = "sig"
+)
+
+// minMasterKeyLen is the minimum master key length in bytes.
+const minMasterKeyLen = 16
+
+// DeriveNamespaceKey derives the 256-bit key of a namespace from a master
+// key, so one root secret fans out into independent per-tenant keys.
+// Knowing one namespace key reveals nothing about the master key or other
+// namespaces.
+//
+// Example:
+//
+//	nsKey, err := security.DeriveNamespaceKey(rootSecret, "acme")
+//	encKey, err := security.DeriveResourceKey(nsKey, "secrets", security.KeyPurposeEncryption)
+//	client, err := resolvedb.New(resolvedb.WithEncryptionKey(encKey[:]))
+func DeriveNamespaceKey(masterKey []byte, namespace string) (*[32]byte, error) {
+	if len(masterKey) < minMasterKeyLen {
+		return nil, fmt.Errorf("master key must be at least %d bytes", minMasterKeyLen)
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	return DeriveKey32(masterKey, nil, buildDerivationInfo("resolvedb-ns", namespace))
+}
+
+// DeriveResourceKey derives the 256-bit key of a resource for purpose
+// (KeyPurposeEncryption, KeyPurposeSigning, or an application-defined
+// label) from a namespace key returned by DeriveNamespaceKey.
+func DeriveResourceKey(namespaceKey *[32]byte, resource, purpose string) (*[32]byte, error) {
+	if namespaceKey == nil {
+		return nil, fmt.Errorf("namespace key is required")
+	}
+	if resource == "" {
+		return nil, fmt.Errorf("resource is required")
+	}
+	if purpose == "" {
+		return nil, fmt.Errorf("key purpose is required")
+	}
+	return DeriveKey32(namespaceKey[:], nil, buildDerivationInfo("resolvedb-res", resource, purpose))
+}
+
+// buildDerivationInfo builds the HKDF info for a key derivation step:
+// a label followed by length-prefixed components, so distinct inputs
+// never produce the same info.
+func buildDerivationInfo(label string, parts ...string) []byte {
+	info := []byte(label)
+	for _, part := range parts {
+		info = append(info, byte(len(part)>>8), byte(len(part)))
+		info = append(info, part...)
+	}
+	return info
+}
@@ -0,0 +1,63 @@
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Merkle tree node prefixes (RFC 6962 2.1). Distinct prefixes for leaves
+// and interior nodes prevent a second-preimage attack that passes an
+// interior node off as a leaf.
+const (
+	merkleLeafPrefix byte = 0x00
+	merkleNodePrefix byte = 0x01
+)
+
+// MerkleRoot computes the Merkle tree hash (RFC 6962 2.1) over chunk
+// hashes, in order. The root of no hashes is the SHA-256 of the empty
+// string.
+func MerkleRoot(hashes [][]byte) []byte {
+	if len(hashes) == 0 {
+		return SHA256(nil)
+	}
+	if len(hashes) == 1 {
+		return merkleHash(merkleLeafPrefix, hashes[0])
+	}
+
+	// Split at the largest power of two smaller than the number of leaves
+	k := 1
+	for k*2 < len(hashes) {
+		k *= 2
+	}
+	return merkleHash(merkleNodePrefix, MerkleRoot(hashes[:k]), MerkleRoot(hashes[k:]))
+}
+
+// MerkleRootHex computes the hex Merkle root over the SHA-256 hashes of
+// chunks.
+func MerkleRootHex(chunks [][]byte) string {
+	hashes := make([][]byte, len(chunks))
+	for i, chunk := range chunks {
+		hashes[i] = SHA256(chunk)
+	}
+	return hex.EncodeToString(MerkleRoot(hashes))
+}
+
+// VerifyMerkleRoot verifies that chunks, in order, hash to the expected
+// hex Merkle root.
+// Per security review: verify the assembled payload, not only its chunks.
+func VerifyMerkleRoot(chunks [][]byte, expectedRootHex string) error {
+	if !ConstantTimeCompareString(MerkleRootHex(chunks), expectedRootHex) {
+		return ErrChunkIntegrity
+	}
+	return nil
+}
+
+// merkleHash hashes a node prefix followed by parts.
+func merkleHash(prefix byte, parts ...[]byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{prefix})
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
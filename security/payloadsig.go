@@ -0,0 +1,143 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PrefixPayloadSig marks the labels of a detached payload signature.
+const PrefixPayloadSig = "psig-"
+
+// Payload signature algorithm identifiers, carried in the label.
+const (
+	PayloadSigHMAC    = "hs" // HMAC-SHA256
+	PayloadSigEd25519 = "ed" // Ed25519
+)
+
+// maxPayloadSigLabel is the DNS label length limit.
+const maxPayloadSigLabel = 63
+
+// PayloadSigner signs write payloads, so servers can verify a payload
+// end to end even when intermediate proxies re-encode the query name.
+// Signatures must have a fixed length per signer, as the room left for
+// the payload is computed from an empty one.
+type PayloadSigner interface {
+	// Algorithm returns the identifier carried in the label, such as
+	// PayloadSigHMAC.
+	Algorithm() string
+
+	// Sign returns the signature of message.
+	Sign(message []byte) []byte
+}
+
+// PayloadMessage returns the message a payload signature covers: the
+// operation, resource, key and namespace as in auth tokens, separated by
+// "|", followed by "|" and the payload bytes before label encoding.
+func PayloadMessage(operation, resource, key, namespace string, payload []byte) []byte {
+	msg := make([]byte, 0, len(operation)+len(resource)+len(key)+len(namespace)+4+len(payload))
+	for _, s := range [...]string{operation, resource, key, namespace} {
+		msg = append(msg, s...)
+		msg = append(msg, '|')
+	}
+	return append(msg, payload...)
+}
+
+// PayloadSignatureLabels encodes a signature as query labels. The
+// signature is written lowercase base32hex, which survives case changes
+// by resolvers, and split into labels of at most 63 bytes, each
+// "psig-<algorithm>-<part>"; an HMAC-SHA256 signature takes one label and
+// an Ed25519 signature two.
+func PayloadSignatureLabels(algorithm string, sig []byte) []string {
+	prefix := PrefixPayloadSig + algorithm + "-"
+	encoded := strings.ToLower(base32Label.EncodeToString(sig))
+	room := maxPayloadSigLabel - len(prefix)
+	var labels []string
+	for len(encoded) > room {
+		labels = append(labels, prefix+encoded[:room])
+		encoded = encoded[room:]
+	}
+	return append(labels, prefix+encoded)
+}
+
+// ParsePayloadSignature decodes the payload signature labels of a query,
+// given in order. Labels without PrefixPayloadSig are skipped.
+func ParsePayloadSignature(labels []string) (algorithm string, sig []byte, err error) {
+	var encoded strings.Builder
+	for _, l := range labels {
+		rest, ok := strings.CutPrefix(strings.ToLower(l), PrefixPayloadSig)
+		if !ok {
+			continue
+		}
+		alg, part, ok := strings.Cut(rest, "-")
+		if !ok || (algorithm != "" && alg != algorithm) {
+			return "", nil, fmt.Errorf("invalid payload signature label %q", l)
+		}
+		algorithm = alg
+		encoded.WriteString(part)
+	}
+	if algorithm == "" {
+		return "", nil, errors.New("no payload signature")
+	}
+	sig, err = base32Label.DecodeString(strings.ToUpper(encoded.String()))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid payload signature: %w", err)
+	}
+	return algorithm, sig, nil
+}
+
+// hmacPayloadSigner signs payloads with HMAC-SHA256.
+type hmacPayloadSigner struct {
+	key []byte
+}
+
+// NewHMACPayloadSigner returns a signer computing HMAC-SHA256 with key.
+func NewHMACPayloadSigner(key []byte) PayloadSigner {
+	return &hmacPayloadSigner{key: append([]byte(nil), key...)}
+}
+
+func (s *hmacPayloadSigner) Algorithm() string {
+	return PayloadSigHMAC
+}
+
+func (s *hmacPayloadSigner) Sign(message []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// ed25519PayloadSigner signs payloads with Ed25519.
+type ed25519PayloadSigner struct {
+	key ed25519.PrivateKey
+}
+
+// NewEd25519PayloadSigner returns a signer using the Ed25519 private key,
+// letting servers verify payloads with the public key alone.
+func NewEd25519PayloadSigner(key ed25519.PrivateKey) PayloadSigner {
+	return &ed25519PayloadSigner{key: key}
+}
+
+func (s *ed25519PayloadSigner) Algorithm() string {
+	return PayloadSigEd25519
+}
+
+func (s *ed25519PayloadSigner) Sign(message []byte) []byte {
+	return ed25519.Sign(s.key, message)
+}
+
+// VerifyPayloadHMAC reports whether sig is the HMAC-SHA256 of message
+// with key.
+func VerifyPayloadHMAC(key, message, sig []byte) bool {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return hmac.Equal(mac.Sum(nil), sig)
+}
+
+// VerifyPayloadEd25519 reports whether sig is an Ed25519 signature of
+// message by the holder of the private key of pub.
+func VerifyPayloadEd25519(pub ed25519.PublicKey, message, sig []byte) bool {
+	return len(pub) == ed25519.PublicKeySize && ed25519.Verify(pub, message, sig)
+}
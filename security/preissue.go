@@ -0,0 +1,95 @@
+package security
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxPreIssued bounds the number of tokens a single pre-issuance returns.
+const MaxPreIssued = 10000
+
+// Window describes the period over which pre-issued tokens are needed, for
+// devices that build queries while offline and transmit them later
+// through a store-and-forward gateway.
+type Window struct {
+	Start    time.Time     // Earliest time a query may be transmitted
+	End      time.Time     // Latest time a query may be transmitted
+	Validity time.Duration // How long the verifier accepts a token after its timestamp; at least a second
+}
+
+// TimedToken is a token signed for a future timestamp, with the period
+// during which it is accepted.
+type TimedToken struct {
+	Token     string
+	NotBefore time.Time // The token's timestamp
+	NotAfter  time.Time // NotBefore plus the window's validity
+}
+
+// ValidAt reports whether t falls within the token's validity window.
+func (t TimedToken) ValidAt(at time.Time) bool {
+	return !at.Before(t.NotBefore) && at.Before(t.NotAfter)
+}
+
+// SelectToken returns the token of tokens valid at t, preferring the one
+// with the latest timestamp, so the query stays valid longest.
+func SelectToken(tokens []TimedToken, at time.Time) (TimedToken, bool) {
+	var best TimedToken
+	found := false
+	for _, t := range tokens {
+		if t.ValidAt(at) && (!found || t.NotBefore.After(best.NotBefore)) {
+			best, found = t, true
+		}
+	}
+	return best, found
+}
+
+// PreIssue calls sign with a timestamp every w.Validity from w.Start
+// until w.End, so the returned tokens' windows tile the whole period
+// without gaps. Timestamps are whole seconds.
+func PreIssue(w Window, sign func(timestamp int64) (string, error)) ([]TimedToken, error) {
+	if w.Validity < time.Second {
+		return nil, fmt.Errorf("token validity must be at least a second")
+	}
+	if w.End.Before(w.Start) {
+		return nil, fmt.Errorf("window ends before it starts")
+	}
+	start := w.Start.Truncate(time.Second)
+	if n := w.End.Sub(start)/w.Validity + 1; n > MaxPreIssued {
+		return nil, fmt.Errorf("window needs %d tokens, more than %d", n, MaxPreIssued)
+	}
+
+	var tokens []TimedToken
+	for at := start; !at.After(w.End); at = at.Add(w.Validity) {
+		token, err := sign(at.Unix())
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, TimedToken{Token: token, NotBefore: at, NotAfter: at.Add(w.Validity)})
+	}
+	return tokens, nil
+}
+
+// PreIssueNBA signs NBA tokens for namespace/resource/key covering w, for
+// devices that cannot sign at the time they transmit. Validity should be
+// the maxAge the verifier passes to ValidateNBA, less the longest delay
+// the gateway may add before forwarding a query.
+//
+// Example:
+//
+//	tokens, err := security.PreIssueNBA("acme", "readings", "sensor-7", key, security.Window{
+//	    Start:    time.Now(),
+//	    End:      time.Now().Add(24 * time.Hour),
+//	    Validity: 5 * time.Minute,
+//	})
+//	// On the device, when building a query:
+//	if t, ok := security.SelectToken(tokens, sendTime); ok {
+//	    opts = append(opts, resolvedb.WithNBA(t.Token))
+//	}
+func PreIssueNBA(namespace, resource, key string, signingKey []byte, w Window) ([]TimedToken, error) {
+	if len(signingKey) == 0 {
+		return nil, fmt.Errorf("signing key cannot be empty")
+	}
+	return PreIssue(w, func(timestamp int64) (string, error) {
+		return signNBA(namespace, resource, key, timestamp, signingKey), nil
+	})
+}
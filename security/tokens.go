@@ -4,11 +4,13 @@ import (
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base32"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,6 +21,13 @@ const (
 	PrefixNBA = "sig-"
 )
 
+// prefixBase32 marks a token payload encoded as base32hex rather than
+// base64url.
+const prefixBase32 = "b32-"
+
+// base32Label is base32hex without padding, used lowercased in labels.
+var base32Label = base32.HexEncoding.WithPadding(base32.NoPadding)
+
 // BDT (Blind Device Token) provides anonymous device identity.
 // Format: bdt-<32-hex-chars>
 // Use case: IoT devices querying config without revealing identity.
@@ -117,6 +126,17 @@ func (c *CTP) String() string {
 	return c.token
 }
 
+// Base32 returns the token with its payload in lowercase base32hex, for
+// clients using resolvedb.LabelEncodingBase32.
+// Format: ctp-b32-<encrypted-base32hex>
+func (c *CTP) Base32() string {
+	encrypted, err := base64.RawURLEncoding.DecodeString(c.token[len(PrefixCTP):])
+	if err != nil {
+		return c.token
+	}
+	return PrefixCTP + prefixBase32 + strings.ToLower(base32Label.EncodeToString(encrypted))
+}
+
 // ValidateCTP validates and decrypts a CTP token.
 // Returns the payload if valid, error otherwise.
 // Per security review: 30-second replay window.
@@ -125,8 +145,13 @@ func ValidateCTP(token string, key *[32]byte) (*CTPPayload, error) {
 		return nil, fmt.Errorf("invalid CTP format")
 	}
 
-	encoded := token[len(PrefixCTP):]
-	encrypted, err := base64.RawURLEncoding.DecodeString(encoded)
+	var encrypted []byte
+	var err error
+	if encoded := token[len(PrefixCTP):]; strings.HasPrefix(encoded, prefixBase32) {
+		encrypted, err = base32Label.DecodeString(strings.ToUpper(encoded[len(prefixBase32):]))
+	} else {
+		encrypted, err = base64.RawURLEncoding.DecodeString(encoded)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("decode: %w", err)
 	}
@@ -161,7 +186,14 @@ type NBA struct {
 // NewNBA creates a new Namespace-Bound Authentication signature.
 func NewNBA(namespace, resource, key string, signingKey []byte) (*NBA, error) {
 	timestamp := time.Now().Unix()
+	return &NBA{
+		signature: signNBA(namespace, resource, key, timestamp, signingKey),
+		timestamp: timestamp,
+	}, nil
+}
 
+// signNBA returns the NBA token for namespace/resource/key at timestamp.
+func signNBA(namespace, resource, key string, timestamp int64, signingKey []byte) string {
 	// Build message: namespace|resource|key|timestamp
 	message := fmt.Sprintf("%s|%s|%s|%d", namespace, resource, key, timestamp)
 
@@ -173,10 +205,7 @@ func NewNBA(namespace, resource, key string, signingKey []byte) (*NBA, error) {
 	// Use first 16 bytes (128 bits) per security review
 	sig := hex.EncodeToString(signature[:16])
 
-	return &NBA{
-		signature: fmt.Sprintf("%s%s-t-%d", PrefixNBA, sig, timestamp),
-		timestamp: timestamp,
-	}, nil
+	return fmt.Sprintf("%s%s-t-%d", PrefixNBA, sig, timestamp)
 }
 
 // String returns the signature string.
@@ -230,3 +259,101 @@ func ValidateNBA(token, namespace, resource, key string, signingKey []byte, maxA
 
 	return nil
 }
+
+// PrefixDRT is the prefix of delegated read tokens.
+const PrefixDRT = "drt-"
+
+// Delegated read token scopes.
+const (
+	scopeKey      = "k" // a single key
+	scopeResource = "r" // every key of a resource
+)
+
+// ReadTokenInfo describes a parsed delegated read token.
+type ReadTokenInfo struct {
+	Expires     time.Time // Time the token stops being valid
+	ResourceAll bool      // Whether the token covers every key of its resource
+}
+
+// NewReadToken mints a delegated read token granting read access to
+// namespace/resource/key until expires, signed with the tenant signing key.
+// An empty key grants access to every key of the resource. Tokens carry no
+// secret and can be handed to untrusted clients, which attach them to
+// queries for the scope they were minted for.
+// Format: drt-<32-hex-chars>-<k|r>-<unix-expiry>
+func NewReadToken(namespace, resource, key string, expires time.Time, signingKey []byte) (string, error) {
+	if len(signingKey) == 0 {
+		return "", fmt.Errorf("signing key cannot be empty")
+	}
+	scope := scopeKey
+	if key == "" {
+		scope = scopeResource
+	}
+	exp := expires.Unix()
+	sig := readTokenSignature(namespace, resource, key, scope, exp, signingKey)
+	return fmt.Sprintf("%s%s-%s-%d", PrefixDRT, sig, scope, exp), nil
+}
+
+// ParseReadToken parses a delegated read token without verifying its
+// signature, so holders can check its expiry.
+func ParseReadToken(token string) (*ReadTokenInfo, error) {
+	_, scope, exp, err := splitReadToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &ReadTokenInfo{Expires: time.Unix(exp, 0), ResourceAll: scope == scopeResource}, nil
+}
+
+// ValidateReadToken verifies that a delegated read token is unexpired and
+// grants read access to namespace/resource/key.
+// Per security review: constant-time comparison.
+func ValidateReadToken(token, namespace, resource, key string, signingKey []byte) error {
+	sig, scope, exp, err := splitReadToken(token)
+	if err != nil {
+		return err
+	}
+	if time.Now().Unix() > exp {
+		return fmt.Errorf("read token expired")
+	}
+
+	scopedKey := key
+	if scope == scopeResource {
+		scopedKey = ""
+	}
+	expected := readTokenSignature(namespace, resource, scopedKey, scope, exp, signingKey)
+	if !ConstantTimeCompareString(sig, expected) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// splitReadToken splits a delegated read token into its signature, scope
+// and expiry.
+func splitReadToken(token string) (sig, scope string, exp int64, err error) {
+	if len(token) < len(PrefixDRT)+32+5 || token[:len(PrefixDRT)] != PrefixDRT {
+		return "", "", 0, fmt.Errorf("invalid read token format")
+	}
+	rest := token[len(PrefixDRT):]
+	sig, rest = rest[:32], rest[32:]
+	if len(rest) < 4 || rest[0] != '-' || rest[2] != '-' {
+		return "", "", 0, fmt.Errorf("invalid read token format")
+	}
+	scope = rest[1:2]
+	if scope != scopeKey && scope != scopeResource {
+		return "", "", 0, fmt.Errorf("invalid read token scope")
+	}
+	exp, err = strconv.ParseInt(rest[3:], 10, 64)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid read token expiry")
+	}
+	return sig, scope, exp, nil
+}
+
+// readTokenSignature computes the 128-bit signature of a read token.
+func readTokenSignature(namespace, resource, key, scope string, exp int64, signingKey []byte) string {
+	// Build message: drt|namespace|resource|key|scope|expiry
+	message := fmt.Sprintf("drt|%s|%s|%s|%s|%d", namespace, resource, key, scope, exp)
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,383 @@
+package resolvedb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/resolvedb/resolvedb-go/uqrp"
+)
+
+// Version is a semantic version (https://semver.org).
+type Version struct {
+	Major, Minor, Patch int
+	Prerelease          string // Pre-release identifiers (e.g., "rc.1"), empty for releases
+}
+
+// ParseVersion parses a semantic version such as "2.3.1", "v2.3.1-rc.1"
+// or the key form "v2-3-1-rc-1". Missing minor and patch numbers are zero.
+func ParseVersion(s string) (Version, error) {
+	v, _, err := parsePartialVersion(s)
+	return v, err
+}
+
+// String returns the version in dotted form, e.g. "2.3.1-rc.1".
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Key returns the version as a DNS-safe key suffix, e.g. "v2-3-1-rc-1".
+func (v Version) Key() string {
+	s := fmt.Sprintf("v%d-%d-%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + uqrp.Sanitize(strings.ReplaceAll(v.Prerelease, ".", "-"))
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 as v is lower than, equal to or higher than
+// w, ordering pre-releases before their release.
+func (v Version) Compare(w Version) int {
+	switch {
+	case v.Major != w.Major:
+		return cmpInt(v.Major, w.Major)
+	case v.Minor != w.Minor:
+		return cmpInt(v.Minor, w.Minor)
+	case v.Patch != w.Patch:
+		return cmpInt(v.Patch, w.Patch)
+	}
+	return comparePrerelease(v.Prerelease, w.Prerelease)
+}
+
+// VersionKey returns the key for version v of name, e.g. "resnet-v2-3-1".
+func VersionKey(name string, v Version) string {
+	if name == "" {
+		return v.Key()
+	}
+	return name + "-" + v.Key()
+}
+
+// ResolveVersion lists the keys of resource, reads each as a version
+// ("v2-3-1", or "<name>-v2-3-1"), and returns the key and version of the
+// highest one matching constraint. Use it for resources that hold the
+// versions of a single artifact; ResolveKeyVersion picks among the
+// versions of one name.
+//
+// Constraints follow npm and Cargo conventions: "^2.3" (>=2.3.0 <3.0.0),
+// "~2.3" (>=2.3.0 <2.4.0), "2.x", "2.3.1", comparisons such as
+// ">=2.0 <2.5", alternatives joined by "||", and "*" for any version.
+// Pre-releases only match constraints that mention a pre-release.
+// If no version matches, the error wraps ErrNotFound.
+//
+// Example:
+//
+//	key, v, err := resolvedb.ResolveVersion(ctx, client, "firmware-sensor", "^2.3")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	log.Printf("installing %s from %s", v, key)
+func ResolveVersion(ctx context.Context, q Querier, resource, constraint string, opts ...RequestOption) (string, Version, error) {
+	return resolveVersion(ctx, q, resource, "", constraint, opts)
+}
+
+// ResolveKeyVersion is like ResolveVersion, but only considers keys of the
+// form "<name>-v<version>".
+//
+// Example:
+//
+//	key, _, err := resolvedb.ResolveKeyVersion(ctx, client, "models", "resnet", "~1.4")
+//	err = client.Get(ctx, "models", key, &model)
+func ResolveKeyVersion(ctx context.Context, q Querier, resource, name, constraint string, opts ...RequestOption) (string, Version, error) {
+	return resolveVersion(ctx, q, resource, name, constraint, opts)
+}
+
+func resolveVersion(ctx context.Context, q Querier, resource, name, constraint string, opts []RequestOption) (string, Version, error) {
+	vr, err := parseVersionRange(constraint)
+	if err != nil {
+		return "", Version{}, err
+	}
+
+	keys, err := q.List(ctx, resource, opts...)
+	if err != nil {
+		return "", Version{}, err
+	}
+
+	var bestKey string
+	var best Version
+	for _, key := range keys {
+		v, ok := versionFromKey(key, name)
+		if !ok || !vr.matches(v) {
+			continue
+		}
+		if bestKey == "" || v.Compare(best) > 0 {
+			bestKey, best = key, v
+		}
+	}
+	if bestKey == "" {
+		return "", Version{}, fmt.Errorf("resolvedb: no version of %s matches %q: %w", resource, constraint, ErrNotFound)
+	}
+	return bestKey, best, nil
+}
+
+// versionFromKey parses the version suffix of key. With a name, the key
+// must be "<name>-v<version>"; without, any "v<version>" suffix is read.
+func versionFromKey(key, name string) (Version, bool) {
+	var suffix string
+	if name != "" {
+		rest, ok := strings.CutPrefix(key, name+"-v")
+		if !ok {
+			return Version{}, false
+		}
+		suffix = rest
+	} else {
+		i := strings.LastIndex(key, "v")
+		for i >= 0 {
+			if i == 0 || key[i-1] == '-' {
+				if v, err := ParseVersion(key[i:]); err == nil {
+					return v, true
+				}
+			}
+			i = strings.LastIndex(key[:i], "v")
+		}
+		return Version{}, false
+	}
+	v, err := ParseVersion(suffix)
+	return v, err == nil
+}
+
+// parsePartialVersion parses a version that may omit its minor and patch
+// numbers or use x, X or * wildcards for them. It returns the number of
+// numeric parts given before any wildcard.
+func parsePartialVersion(s string) (Version, int, error) {
+	invalid := fmt.Errorf("resolvedb: invalid version %q", s)
+	rest := strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+
+	// Dotted form: 2.3.1-rc.1; key form: 2-3-1-rc-1
+	var nums []string
+	var pre string
+	if strings.Contains(rest, ".") {
+		var core string
+		core, pre, _ = strings.Cut(rest, "-")
+		nums = strings.Split(core, ".")
+	} else {
+		nums = strings.SplitN(rest, "-", 4)
+		if len(nums) == 4 {
+			nums, pre = nums[:3], nums[3]
+		}
+	}
+	if len(nums) > 3 || (pre != "" && len(nums) < 3) {
+		return Version{}, 0, invalid
+	}
+
+	var v Version
+	fields := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, num := range nums {
+		if num == "x" || num == "X" || num == "*" {
+			if i != len(nums)-1 || pre != "" {
+				return Version{}, 0, invalid
+			}
+			return v, i, nil
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil || n < 0 {
+			return Version{}, 0, invalid
+		}
+		*fields[i] = n
+	}
+	v.Prerelease = pre
+	return v, len(nums), nil
+}
+
+// comparePrerelease orders pre-release strings per semver: a release is
+// higher than any pre-release, numeric identifiers compare numerically
+// and below alphanumeric ones. Dots and hyphens both separate identifiers.
+func comparePrerelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool { return r == '.' || r == '-' })
+	}
+	as, bs := split(a), split(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return cmpInt(an, bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmpInt(len(as), len(bs))
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// versionRange is a parsed constraint: alternatives of comparator sets.
+type versionRange struct {
+	alternatives [][]versionComparator
+	prerelease   bool // Whether the constraint mentions a pre-release
+}
+
+// versionComparator compares a version against a bound.
+type versionComparator struct {
+	op string // "=", ">", ">=", "<" or "<="
+	v  Version
+}
+
+func (c versionComparator) matches(v Version) bool {
+	cmp := v.Compare(c.v)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+func (r *versionRange) matches(v Version) bool {
+	if v.Prerelease != "" && !r.prerelease {
+		return false
+	}
+	for _, set := range r.alternatives {
+		ok := true
+		for _, c := range set {
+			if !c.matches(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVersionRange parses a version constraint.
+func parseVersionRange(constraint string) (*versionRange, error) {
+	r := &versionRange{}
+	for _, alt := range strings.Split(constraint, "||") {
+		var set []versionComparator
+		for _, term := range strings.Fields(alt) {
+			cs, err := parseVersionTerm(term)
+			if err != nil {
+				return nil, fmt.Errorf("resolvedb: invalid version constraint %q: %w", constraint, err)
+			}
+			for _, c := range cs {
+				if c.v.Prerelease != "" {
+					r.prerelease = true
+				}
+			}
+			set = append(set, cs...)
+		}
+		r.alternatives = append(r.alternatives, set)
+	}
+	return r, nil
+}
+
+// parseVersionTerm expands one constraint term into comparators.
+func parseVersionTerm(term string) ([]versionComparator, error) {
+	if term == "*" || term == "x" || term == "X" {
+		return nil, nil
+	}
+
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, prefix) {
+			op, term = prefix, term[len(prefix):]
+			break
+		}
+	}
+	v, parts, err := parsePartialVersion(term)
+	if err != nil {
+		return nil, err
+	}
+
+	// next returns the lowest version above every version sharing the
+	// first n numeric parts of v
+	next := func(n int) Version {
+		switch n {
+		case 1:
+			return Version{Major: v.Major + 1}
+		case 2:
+			return Version{Major: v.Major, Minor: v.Minor + 1}
+		}
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+	lower := versionComparator{op: ">=", v: v}
+	if parts == 0 {
+		// A wildcard major version matches anything
+		return nil, nil
+	}
+
+	switch op {
+	case "^":
+		// Allow changes that do not modify the leftmost non-zero part
+		n := 1
+		switch {
+		case v.Major > 0 || parts == 1:
+		case v.Minor > 0 || parts == 2:
+			n = 2
+		default:
+			n = 3
+		}
+		return []versionComparator{lower, {op: "<", v: next(n)}}, nil
+	case "~":
+		// Allow patch changes, or minor ones if only the major is given
+		return []versionComparator{lower, {op: "<", v: next(min(parts, 2))}}, nil
+	case ">":
+		if parts < 3 {
+			return []versionComparator{{op: ">=", v: next(parts)}}, nil
+		}
+		return []versionComparator{{op: ">", v: v}}, nil
+	case "<=":
+		if parts < 3 {
+			return []versionComparator{{op: "<", v: next(parts)}}, nil
+		}
+		return []versionComparator{{op: "<=", v: v}}, nil
+	case ">=", "<":
+		return []versionComparator{{op: op, v: v}}, nil
+	}
+
+	// Bare or "=": exact for full versions, a range for partial ones
+	if parts < 3 {
+		return []versionComparator{lower, {op: "<", v: next(parts)}}, nil
+	}
+	return []versionComparator{{op: "=", v: v}}, nil
+}
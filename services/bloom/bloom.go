@@ -0,0 +1,469 @@
+// Package bloom stores Bloom filters in ResolveDB for set-membership tests
+// against lists too large to query item by item, such as revoked tokens or
+// blocked addresses. A filter is published as a binary blob split into
+// segments and tested locally; a List keeps a local copy refreshed in the
+// background.
+//
+// Segments are written with Set, so both publishers and readers must use a
+// client created with resolvedb.WithAutoCodec, which compresses and chunks
+// them to fit in queries.
+package bloom
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/security"
+)
+
+// resource is the resource holding filters.
+const resource = "bloom"
+
+const (
+	// magic and formatVersion identify the binary filter format.
+	magic         = "RDBF"
+	formatVersion = 1
+	headerSize    = len(magic) + 1 + 4 + 1 + 8
+
+	// maxBits bounds the size of a filter (64 MiB).
+	maxBits = 1 << 29
+	maxHash = 32
+
+	// segmentSize is the size of each stored segment of a filter, which
+	// the client's codec compresses and chunks further.
+	segmentSize = 16 << 10
+	maxSegments = 4096
+
+	segmentConcurrency     = 4
+	defaultRefreshInterval = 5 * time.Minute
+)
+
+// ErrInvalidFilter is returned when a stored filter cannot be decoded.
+var ErrInvalidFilter = errors.New("bloom: invalid filter")
+
+// Filter is a Bloom filter over strings. A membership test never misses
+// an added item, and reports an item that was not added with a
+// probability close to the rate the filter was sized for. It is not safe
+// for concurrent writes.
+type Filter struct {
+	bits  []byte
+	m     uint32 // Number of bits
+	k     uint8  // Number of hash functions
+	count uint64 // Items added
+}
+
+// New returns an empty filter sized for n items at a false-positive rate
+// of fpRate, e.g. 0.001.
+func New(n int, fpRate float64) *Filter {
+	n = max(n, 1)
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	m := math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	m = min(max(m, 8), maxBits)
+	k := math.Round(m / float64(n) * math.Ln2)
+	k = min(max(k, 1), maxHash)
+	return &Filter{
+		bits: make([]byte, (int(m)+7)/8),
+		m:    uint32(m),
+		k:    uint8(k),
+	}
+}
+
+// Add adds item to the filter.
+func (f *Filter) Add(item string) {
+	h1, h2 := hashes(item)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % uint64(f.m)
+		f.bits[bit/8] |= 1 << (bit % 8)
+	}
+	f.count++
+}
+
+// MayContain reports whether item may have been added. False means it was
+// certainly not added.
+func (f *Filter) MayContain(item string) bool {
+	h1, h2 := hashes(item)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % uint64(f.m)
+		if f.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of items added.
+func (f *Filter) Count() uint64 {
+	return f.count
+}
+
+// FalsePositiveRate estimates the filter's current false-positive rate
+// from the items added.
+func (f *Filter) FalsePositiveRate() float64 {
+	k, m := float64(f.k), float64(f.m)
+	return math.Pow(1-math.Exp(-k*float64(f.count)/m), k)
+}
+
+// hashes derives the two hashes used for double hashing from SHA-256, so
+// filters built by other SDKs test the same bits.
+func hashes(item string) (uint64, uint64) {
+	sum := security.SHA256([]byte(item))
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1 // Odd, so probes do not repeat early
+	return h1, h2
+}
+
+// MarshalBinary encodes the filter: "RDBF", a format version byte, the bit
+// count (uint32), the hash count (uint8), the item count (uint64), all big
+// endian, then the bits.
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, headerSize, headerSize+len(f.bits))
+	copy(buf, magic)
+	buf[4] = formatVersion
+	binary.BigEndian.PutUint32(buf[5:9], f.m)
+	buf[9] = f.k
+	binary.BigEndian.PutUint64(buf[10:18], f.count)
+	return append(buf, f.bits...), nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	if len(data) < headerSize || string(data[:4]) != magic {
+		return ErrInvalidFilter
+	}
+	if data[4] != formatVersion {
+		return fmt.Errorf("%w: unsupported format version %d", ErrInvalidFilter, data[4])
+	}
+	m := binary.BigEndian.Uint32(data[5:9])
+	k := data[9]
+	if m == 0 || m > maxBits || k == 0 || k > maxHash {
+		return fmt.Errorf("%w: %d bits, %d hashes", ErrInvalidFilter, m, k)
+	}
+	bits := data[headerSize:]
+	if len(bits) != (int(m)+7)/8 {
+		return fmt.Errorf("%w: %d bytes of bits, want %d", ErrInvalidFilter, len(bits), (m+7)/8)
+	}
+	*f = Filter{
+		bits:  append([]byte(nil), bits...),
+		m:     m,
+		k:     k,
+		count: binary.BigEndian.Uint64(data[10:18]),
+	}
+	return nil
+}
+
+// BloomClient defines the interface for Bloom filter operations.
+// Implement this interface for testing with mocks.
+type BloomClient interface {
+	Get(ctx context.Context, name string, opts ...resolvedb.RequestOption) (*Filter, error)
+	Publish(ctx context.Context, w resolvedb.Writer, name string, f *Filter, opts ...resolvedb.RequestOption) error
+	Open(ctx context.Context, name string, opts ...ListOption) (*List, error)
+}
+
+// Client is a Bloom filter service client.
+type Client struct {
+	client resolvedb.Querier
+}
+
+// NewClient creates a new Bloom filter client.
+func NewClient(c resolvedb.Querier) *Client {
+	return &Client{client: c}
+}
+
+// Ensure Client implements BloomClient.
+var _ BloomClient = (*Client)(nil)
+
+// manifest describes a published filter. The encoded filter is stored in
+// segments keyed by its hash, so publishing a new filter never changes
+// the segments a reader of the previous manifest is fetching.
+type manifest struct {
+	Segments int    `json:"segments"`
+	Size     int    `json:"size"`
+	Hash     string `json:"sha256"`
+}
+
+// segmentKey returns the key of segment i of the filter with the given hash.
+func segmentKey(name, hash string, i int) string {
+	return name + "-" + hash[:16] + "-" + strconv.Itoa(i)
+}
+
+// Get retrieves and decodes the filter published under name.
+func (c *Client) Get(ctx context.Context, name string, opts ...resolvedb.RequestOption) (*Filter, error) {
+	m, err := c.manifest(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.fetch(ctx, name, m, opts)
+}
+
+func (c *Client) manifest(ctx context.Context, name string, opts []resolvedb.RequestOption) (*manifest, error) {
+	var m manifest
+	if err := c.client.Get(ctx, resource, name, &m, opts...); err != nil {
+		return nil, err
+	}
+	if m.Segments < 1 || m.Segments > maxSegments || m.Size < headerSize || len(m.Hash) != 64 {
+		return nil, fmt.Errorf("bloom: %s: %w: bad manifest", name, ErrInvalidFilter)
+	}
+	return &m, nil
+}
+
+// fetch downloads, verifies and decodes the segments of a filter.
+func (c *Client) fetch(ctx context.Context, name string, m *manifest, opts []resolvedb.RequestOption) (*Filter, error) {
+	segments := make([][]byte, m.Segments)
+	errs := make([]error, m.Segments)
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, segmentConcurrency)
+	for i := range segments {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			errs[i] = c.client.Get(ctx, resource, segmentKey(name, m.Hash, i), &segments[i], opts...)
+		}(i)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("bloom: %s: %w", name, err)
+	}
+
+	data := bytes.Join(segments, nil)
+	if len(data) != m.Size || security.SHA256Hex(data) != m.Hash {
+		return nil, fmt.Errorf("bloom: %s: %w", name, resolvedb.ErrChunkIntegrity)
+	}
+	f := &Filter{}
+	if err := f.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("bloom: %s: %w", name, err)
+	}
+	return f, nil
+}
+
+// Publish stores f under name, replacing the previous filter, whose
+// segments are then deleted. Lists opened on name pick it up on their next
+// refresh.
+//
+// Example:
+//
+//	f := bloom.New(len(revoked), 0.0001)
+//	for _, id := range revoked {
+//	    f.Add(id)
+//	}
+//	err := bloomClient.Publish(ctx, client, "revoked-tokens", f)
+func (c *Client) Publish(ctx context.Context, w resolvedb.Writer, name string, f *Filter, opts ...resolvedb.RequestOption) error {
+	data, err := f.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	next := manifest{
+		Segments: (len(data) + segmentSize - 1) / segmentSize,
+		Size:     len(data),
+		Hash:     security.SHA256Hex(data),
+	}
+	if next.Segments > maxSegments {
+		return fmt.Errorf("bloom: %s: %d bytes exceeds the maximum of %d", name, len(data), maxSegments*segmentSize)
+	}
+
+	fresh := append(append([]resolvedb.RequestOption(nil), opts...), resolvedb.WithRefreshCache())
+	prev, err := c.manifest(ctx, name, fresh)
+	if err != nil && !resolvedb.IsNotFound(err) && !errors.Is(err, ErrInvalidFilter) {
+		return err
+	}
+	if prev != nil && prev.Hash == next.Hash {
+		return nil
+	}
+
+	// Segments first, so the manifest never names missing segments
+	for i := 0; i < next.Segments; i++ {
+		seg := data[i*segmentSize : min((i+1)*segmentSize, len(data))]
+		if err := w.Set(ctx, resource, segmentKey(name, next.Hash, i), seg, opts...); err != nil {
+			return fmt.Errorf("bloom: publish %s: %w", name, err)
+		}
+	}
+	if err := w.Set(ctx, resource, name, next, opts...); err != nil {
+		return fmt.Errorf("bloom: publish %s: %w", name, err)
+	}
+
+	if prev != nil {
+		for i := 0; i < prev.Segments; i++ {
+			if err := w.Delete(ctx, resource, segmentKey(name, prev.Hash, i), opts...); err != nil && !resolvedb.IsNotFound(err) {
+				return fmt.Errorf("bloom: delete previous segments of %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ListOption configures a List.
+type ListOption func(*listConfig)
+
+type listConfig struct {
+	refreshInterval time.Duration
+	onError         func(error)
+	reqOpts         []resolvedb.RequestOption
+}
+
+// WithRefreshInterval sets how often a List reloads its filter (default
+// 5m).
+func WithRefreshInterval(d time.Duration) ListOption {
+	return func(c *listConfig) {
+		if d > 0 {
+			c.refreshInterval = d
+		}
+	}
+}
+
+// OnError calls fn with background refresh errors. The List keeps its
+// previous filter when a refresh fails.
+func OnError(fn func(error)) ListOption {
+	return func(c *listConfig) {
+		c.onError = fn
+	}
+}
+
+// WithRequestOptions applies opts to every filter lookup.
+func WithRequestOptions(opts ...resolvedb.RequestOption) ListOption {
+	return func(c *listConfig) {
+		c.reqOpts = append(c.reqOpts, opts...)
+	}
+}
+
+// List is a local copy of a published filter, refreshed in the
+// background. It is safe for concurrent use. Close it when done.
+type List struct {
+	client *Client
+	name   string
+	cfg    listConfig
+
+	mu        sync.RWMutex
+	filter    *Filter
+	hash      string // Hash of the loaded filter
+	refreshed time.Time
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// Open loads the filter stored under name and keeps it refreshed. The
+// first load must succeed.
+//
+// Example:
+//
+//	revoked, err := bloomClient.Open(ctx, "revoked-tokens", bloom.WithRefreshInterval(time.Minute))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer revoked.Close()
+//
+//	if revoked.MayContain(tokenID) {
+//	    // Confirm against the authoritative store before rejecting
+//	}
+func (c *Client) Open(ctx context.Context, name string, opts ...ListOption) (*List, error) {
+	cfg := listConfig{refreshInterval: defaultRefreshInterval}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	l := &List{
+		client: c,
+		name:   name,
+		cfg:    cfg,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if err := l.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	go l.run()
+	return l, nil
+}
+
+// MayContain reports whether item may be in the list. False means it is
+// certainly not.
+func (l *List) MayContain(item string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.filter.MayContain(item)
+}
+
+// Filter returns the current filter. It must not be modified.
+func (l *List) Filter() *Filter {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.filter
+}
+
+// Refreshed returns when the filter was last loaded.
+func (l *List) Refreshed() time.Time {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.refreshed
+}
+
+// Refresh reloads the filter now, bypassing the cache. Segments are only
+// downloaded when a different filter has been published.
+func (l *List) Refresh(ctx context.Context) error {
+	opts := append(append([]resolvedb.RequestOption(nil), l.cfg.reqOpts...), resolvedb.WithRefreshCache())
+	m, err := l.client.manifest(ctx, l.name, opts)
+	if err != nil {
+		return err
+	}
+
+	l.mu.RLock()
+	unchanged := m.Hash == l.hash
+	l.mu.RUnlock()
+	if !unchanged {
+		// Segments are immutable, so cached copies are fine
+		f, err := l.client.fetch(ctx, l.name, m, l.cfg.reqOpts)
+		if err != nil {
+			return err
+		}
+		l.mu.Lock()
+		l.filter, l.hash = f, m.Hash
+		l.mu.Unlock()
+	}
+
+	l.mu.Lock()
+	l.refreshed = time.Now()
+	l.mu.Unlock()
+	return nil
+}
+
+// Close stops background refreshes.
+func (l *List) Close() {
+	l.once.Do(func() { close(l.stop) })
+	<-l.done
+}
+
+func (l *List) run() {
+	defer close(l.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-l.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(l.cfg.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+		if err := l.Refresh(ctx); err != nil && ctx.Err() == nil && l.cfg.onError != nil {
+			_ = resolvedb.SafeCall(l.client.client, "bloom error callback", func() { l.cfg.onError(err) })
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package catalog provides a client for ResolveDB's public dataset
+// catalog, which describes the public data ResolveDB hosts: where each
+// dataset lives, how its keys are formed, the schema of its values and how
+// fresh they are.
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/rdbtypes"
+)
+
+// resource is the resource holding the catalog.
+const resource = "catalog"
+
+// indexKey is the key of the catalog's dataset list. Full descriptors are
+// stored under "ds-<name>".
+const indexKey = "datasets"
+
+// CatalogClient defines the interface for Catalog operations.
+// Implement this interface for testing with mocks.
+type CatalogClient interface {
+	ListDatasets(ctx context.Context, opts ...resolvedb.RequestOption) ([]Dataset, error)
+	Dataset(ctx context.Context, name string, opts ...resolvedb.RequestOption) (*Dataset, error)
+}
+
+// Client is a Catalog service client.
+type Client struct {
+	client resolvedb.Querier
+}
+
+// NewClient creates a new Catalog client.
+func NewClient(c resolvedb.Querier) *Client {
+	return &Client{client: c}
+}
+
+// Ensure Client implements CatalogClient.
+var _ CatalogClient = (*Client)(nil)
+
+// Dataset describes a public dataset.
+type Dataset struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Resource    string          `json:"resource"`               // Resource to query
+	KeyFormat   string          `json:"key_format,omitempty"`   // How keys are formed, e.g. "<city>" or "ip-<addr>"
+	ExampleKeys []string        `json:"example_keys,omitempty"` // Keys that exist, for trying the dataset out
+	Schema      json.RawMessage `json:"schema,omitempty"`       // JSON Schema of values; only returned by Dataset
+	Freshness   Freshness       `json:"freshness"`              // How often values are updated
+	Source      string          `json:"source,omitempty"`       // Upstream data provider
+	License     string          `json:"license,omitempty"`      // SPDX identifier or license name
+	Tags        []string        `json:"tags,omitempty"`         // Topics, e.g. "weather" or "network"
+	Deprecated  string          `json:"deprecated,omitempty"`   // Replacement or reason, if deprecated
+}
+
+// Freshness describes how current a dataset's values are.
+type Freshness struct {
+	UpdateInterval time.Duration // How often values are refreshed upstream
+	TTL            time.Duration // How long values may be cached
+	UpdatedAt      time.Time     // When the dataset was last refreshed
+}
+
+// freshnessJSON is the wire form of Freshness: seconds and a Unix
+// timestamp.
+type freshnessJSON struct {
+	Interval rdbtypes.Duration `json:"update_interval,omitempty"`
+	TTL      rdbtypes.Duration `json:"ttl,omitempty"`
+	Updated  *rdbtypes.Time    `json:"updated,omitempty"`
+}
+
+// UnmarshalJSON decodes freshness from seconds and a Unix timestamp.
+func (f *Freshness) UnmarshalJSON(data []byte) error {
+	var raw freshnessJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*f = Freshness{
+		UpdateInterval: raw.Interval.Std(),
+		TTL:            raw.TTL.Std(),
+	}
+	if raw.Updated != nil {
+		f.UpdatedAt = raw.Updated.Time
+	}
+	return nil
+}
+
+// MarshalJSON encodes freshness in the form UnmarshalJSON reads.
+func (f Freshness) MarshalJSON() ([]byte, error) {
+	raw := freshnessJSON{
+		Interval: rdbtypes.Duration(f.UpdateInterval),
+		TTL:      rdbtypes.Duration(f.TTL),
+	}
+	if !f.UpdatedAt.IsZero() {
+		raw.Updated = &rdbtypes.Time{Time: f.UpdatedAt}
+	}
+	return json.Marshal(raw)
+}
+
+// Stale reports whether the dataset has missed its expected refresh, i.e.
+// it was last updated more than twice its update interval ago.
+func (f Freshness) Stale() bool {
+	if f.UpdateInterval <= 0 || f.UpdatedAt.IsZero() {
+		return false
+	}
+	return time.Since(f.UpdatedAt) > 2*f.UpdateInterval
+}
+
+// CompileSchema compiles the dataset's schema, for use with
+// resolvedb.WithSchema. It returns nil if the dataset has no schema.
+//
+// Example:
+//
+//	ds, _ := catalogClient.Dataset(ctx, "weather")
+//	schema, err := ds.CompileSchema()
+//	client, err := resolvedb.New(resolvedb.WithSchema(ds.Resource, schema))
+func (d *Dataset) CompileSchema() (*resolvedb.Schema, error) {
+	if len(d.Schema) == 0 {
+		return nil, nil
+	}
+	s, err := resolvedb.CompileSchema(d.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("catalog: schema of %s: %w", d.Name, err)
+	}
+	return s, nil
+}
+
+// ListDatasets retrieves the public datasets. Schemas are omitted; use
+// Dataset for a dataset's full descriptor.
+//
+// Example:
+//
+//	datasets, err := catalogClient.ListDatasets(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, ds := range datasets {
+//	    fmt.Printf("%-12s %s (resource %q, updated every %s)\n",
+//	        ds.Name, ds.Title, ds.Resource, ds.Freshness.UpdateInterval)
+//	}
+func (c *Client) ListDatasets(ctx context.Context, opts ...resolvedb.RequestOption) ([]Dataset, error) {
+	var datasets []Dataset
+	err := c.client.Get(ctx, resource, indexKey, &datasets, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return datasets, nil
+}
+
+// Dataset retrieves the full descriptor of a dataset, including its
+// schema.
+func (c *Client) Dataset(ctx context.Context, name string, opts ...resolvedb.RequestOption) (*Dataset, error) {
+	var d Dataset
+	err := c.client.Get(ctx, resource, "ds-"+name, &d, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,255 @@
+// Package cohorts provides a client for managing ResolveDB cohorts: the
+// named user groups that feature flags and other services target through
+// Cohort Token Pattern (CTP) tokens.
+package cohorts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/security"
+)
+
+// Resources holding cohort definitions and memberships.
+const (
+	resourceCohorts = "cohorts"
+	resourceMembers = "cohort-members"
+)
+
+// maxNameLength is the longest cohort name, the length of a DNS label.
+const maxNameLength = 63
+
+var (
+	// ErrInvalidName is returned for cohort names that are not valid DNS
+	// labels: 1-63 lowercase letters, digits and hyphens, starting and
+	// ending with a letter or digit.
+	ErrInvalidName = errors.New("cohorts: invalid cohort name")
+
+	// ErrNoCTPKey is returned by Token and TokenOption when the client was
+	// created without WithCTPKey.
+	ErrNoCTPKey = errors.New("cohorts: no CTP key configured")
+)
+
+// CohortsClient defines the interface for Cohort operations.
+// Implement this interface for testing with mocks.
+type CohortsClient interface {
+	Get(ctx context.Context, name string, opts ...resolvedb.RequestOption) (*Cohort, error)
+	List(ctx context.Context, opts ...resolvedb.RequestOption) ([]string, error)
+	Create(ctx context.Context, cohort *Cohort, opts ...resolvedb.RequestOption) error
+	Update(ctx context.Context, cohort *Cohort, opts ...resolvedb.RequestOption) error
+	Delete(ctx context.Context, name string, opts ...resolvedb.RequestOption) error
+	AddMember(ctx context.Context, name, userID string, opts ...resolvedb.RequestOption) error
+	RemoveMember(ctx context.Context, name, userID string, opts ...resolvedb.RequestOption) error
+	IsMember(ctx context.Context, name, userID string, opts ...resolvedb.RequestOption) (bool, error)
+	Token(userID, name string) (string, error)
+	TokenOption(userID, name string) (resolvedb.RequestOption, error)
+}
+
+// Client is a Cohorts service client.
+type Client struct {
+	client resolvedb.ReadWriter
+	ctpKey *[32]byte // nil unless WithCTPKey is used
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithCTPKey sets the 32-byte key used to mint CTP tokens with Token and
+// TokenOption. Panics if the key length is invalid.
+func WithCTPKey(key []byte) Option {
+	if len(key) != 32 {
+		panic(fmt.Sprintf("cohorts: CTP key must be 32 bytes, got %d", len(key)))
+	}
+	var k [32]byte
+	copy(k[:], key)
+	return func(c *Client) {
+		c.ctpKey = &k
+	}
+}
+
+// NewClient creates a new Cohorts client.
+func NewClient(c resolvedb.ReadWriter, opts ...Option) *Client {
+	client := &Client{client: c}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// Ensure Client implements CohortsClient.
+var _ CohortsClient = (*Client)(nil)
+
+// Cohort represents a cohort definition.
+type Cohort struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// membership is the record stored for a cohort member. The user ID itself
+// is not stored; the record's key is derived from it.
+type membership struct {
+	Cohort  string    `json:"cohort"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// Get retrieves a cohort definition by name.
+func (c *Client) Get(ctx context.Context, name string, opts ...resolvedb.RequestOption) (*Cohort, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	var cohort Cohort
+	if err := c.client.Get(ctx, resourceCohorts, name, &cohort, opts...); err != nil {
+		return nil, err
+	}
+	return &cohort, nil
+}
+
+// List returns the names of all cohorts.
+func (c *Client) List(ctx context.Context, opts ...resolvedb.RequestOption) ([]string, error) {
+	return c.client.List(ctx, resourceCohorts, opts...)
+}
+
+// Create stores a new cohort definition. It returns an error wrapping
+// resolvedb.ErrConflict if the cohort already exists. The check is not
+// atomic with the write, so concurrent creates of the same cohort may
+// both succeed.
+//
+// Example:
+//
+//	err := cohortClient.Create(ctx, &cohorts.Cohort{
+//	    Name:        "beta",
+//	    Description: "Early access program",
+//	})
+func (c *Client) Create(ctx context.Context, cohort *Cohort, opts ...resolvedb.RequestOption) error {
+	if err := validateName(cohort.Name); err != nil {
+		return err
+	}
+	if _, err := c.Get(ctx, cohort.Name, opts...); err == nil {
+		return fmt.Errorf("cohorts: create %q: %w", cohort.Name, resolvedb.ErrConflict)
+	} else if !resolvedb.IsNotFound(err) {
+		return err
+	}
+
+	now := time.Now().UTC()
+	stored := *cohort
+	stored.CreatedAt = now
+	stored.UpdatedAt = now
+	return c.client.Set(ctx, resourceCohorts, cohort.Name, &stored, opts...)
+}
+
+// Update replaces an existing cohort definition, keeping its creation
+// time. It returns an error wrapping resolvedb.ErrNotFound if the cohort
+// does not exist.
+func (c *Client) Update(ctx context.Context, cohort *Cohort, opts ...resolvedb.RequestOption) error {
+	existing, err := c.Get(ctx, cohort.Name, opts...)
+	if err != nil {
+		return err
+	}
+
+	stored := *cohort
+	stored.CreatedAt = existing.CreatedAt
+	stored.UpdatedAt = time.Now().UTC()
+	return c.client.Set(ctx, resourceCohorts, cohort.Name, &stored, opts...)
+}
+
+// Delete removes a cohort definition. Memberships are not removed, but
+// stop mattering once nothing targets the cohort.
+func (c *Client) Delete(ctx context.Context, name string, opts ...resolvedb.RequestOption) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	return c.client.Delete(ctx, resourceCohorts, name, opts...)
+}
+
+// AddMember adds a user to a cohort. Memberships are keyed by a hash of
+// the cohort and user ID, so user IDs never appear in DNS queries.
+func (c *Client) AddMember(ctx context.Context, name, userID string, opts ...resolvedb.RequestOption) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	m := membership{Cohort: name, AddedAt: time.Now().UTC()}
+	return c.client.Set(ctx, resourceMembers, MemberKey(name, userID), &m, opts...)
+}
+
+// RemoveMember removes a user from a cohort.
+func (c *Client) RemoveMember(ctx context.Context, name, userID string, opts ...resolvedb.RequestOption) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	return c.client.Delete(ctx, resourceMembers, MemberKey(name, userID), opts...)
+}
+
+// IsMember reports whether a user belongs to a cohort.
+func (c *Client) IsMember(ctx context.Context, name, userID string, opts ...resolvedb.RequestOption) (bool, error) {
+	if err := validateName(name); err != nil {
+		return false, err
+	}
+	var m membership
+	err := c.client.Get(ctx, resourceMembers, MemberKey(name, userID), &m, opts...)
+	if err != nil {
+		if resolvedb.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Token mints a CTP token targeting userID as a member of the named
+// cohort. Tokens are short-lived, so mint one per request.
+func (c *Client) Token(userID, name string) (string, error) {
+	if c.ctpKey == nil {
+		return "", ErrNoCTPKey
+	}
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+	token, err := security.NewCTP(userID, name, c.ctpKey)
+	if err != nil {
+		return "", fmt.Errorf("cohorts: mint token: %w", err)
+	}
+	return token.String(), nil
+}
+
+// TokenOption mints a CTP token like Token and returns it as a request
+// option, for targeting lookups in other services.
+//
+// Example:
+//
+//	opt, err := cohortClient.TokenOption(user.ID, "beta")
+//	if err != nil {
+//	    return err
+//	}
+//	enabled, err := flagClient.Get(ctx, "new-checkout", opt)
+func (c *Client) TokenOption(userID, name string) (resolvedb.RequestOption, error) {
+	token, err := c.Token(userID, name)
+	if err != nil {
+		return nil, err
+	}
+	return resolvedb.WithCTP(token), nil
+}
+
+// MemberKey returns the key under which a user's membership of a cohort
+// is stored: a truncated SHA-256 of the cohort and user ID, in hex.
+func MemberKey(name, userID string) string {
+	return security.SHA256Hex([]byte(name + "\x00" + userID))[:32]
+}
+
+// validateName checks that name can be used as a cohort key.
+func validateName(name string) error {
+	if name == "" || len(name) > maxNameLength || name[0] == '-' || name[len(name)-1] == '-' {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return fmt.Errorf("%w: %q", ErrInvalidName, name)
+		}
+	}
+	return nil
+}
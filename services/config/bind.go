@@ -0,0 +1,330 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// BindOption configures Bind.
+type BindOption func(*bindConfig)
+
+type bindConfig struct {
+	defaults    any
+	envPrefix   string
+	env         bool
+	reloadEvery time.Duration
+	onReload    []func(error)
+	reqOpts     []resolvedb.RequestOption
+}
+
+// WithDefaults sets the values used for fields the remote document does
+// not set. def must be the bound type or a pointer to it.
+func WithDefaults(def any) BindOption {
+	return func(c *bindConfig) {
+		c.defaults = def
+	}
+}
+
+// WithEnvOverride lets environment variables override the remote and
+// default values. Each field is read from prefix followed by its name in
+// upper snake case, with nested struct names joined by underscores: with
+// prefix "APP_", field DB.MaxConns is read from APP_DB_MAX_CONNS. An env
+// struct tag replaces the field's name.
+//
+// Strings, booleans, integers, floats, time.Duration and comma-separated
+// string slices are supported.
+func WithEnvOverride(prefix string) BindOption {
+	return func(c *bindConfig) {
+		c.envPrefix = prefix
+		c.env = true
+	}
+}
+
+// WithReloadEvery reloads the binding every d until Bind's ctx is done.
+func WithReloadEvery(d time.Duration) BindOption {
+	return func(c *bindConfig) {
+		c.reloadEvery = d
+	}
+}
+
+// OnReload calls fn after each background reload, with the error if it
+// failed. A failed reload keeps the previous value.
+func OnReload(fn func(error)) BindOption {
+	return func(c *bindConfig) {
+		c.onReload = append(c.onReload, fn)
+	}
+}
+
+// WithRequestOptions applies opts to every lookup made by the binding.
+func WithRequestOptions(opts ...resolvedb.RequestOption) BindOption {
+	return func(c *bindConfig) {
+		c.reqOpts = append(c.reqOpts, opts...)
+	}
+}
+
+// Binding holds the current value of a bound configuration document.
+type Binding[T any] struct {
+	client *Client
+	key    string
+	config bindConfig
+	value  atomic.Pointer[T]
+}
+
+// Bind loads the configuration document key into a T, fills dst with it
+// and returns a Binding holding it. Values are resolved with the
+// precedence env > remote > default: the defaults are decoded first, the
+// remote document over them, and then environment overrides. A missing
+// document leaves the defaults in place.
+//
+// Reloads build a new T and swap it in atomically, so readers call Load
+// for the current value rather than reading dst, which only holds the
+// initial value.
+//
+// Example:
+//
+//	var cfg AppConfig
+//	b, err := config.Bind(ctx, configClient, "app", &cfg,
+//	    config.WithDefaults(AppConfig{Port: 8080}),
+//	    config.WithEnvOverride("APP_"),
+//	    config.WithReloadEvery(time.Minute),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	port := b.Load().Port
+func Bind[T any](ctx context.Context, c *Client, key string, dst *T, opts ...BindOption) (*Binding[T], error) {
+	b := &Binding[T]{client: c, key: key}
+	for _, opt := range opts {
+		opt(&b.config)
+	}
+	if b.config.defaults != nil {
+		switch b.config.defaults.(type) {
+		case T, *T:
+		default:
+			return nil, fmt.Errorf("config: defaults have type %T, want %T", b.config.defaults, *dst)
+		}
+	}
+
+	if err := b.Reload(ctx); err != nil {
+		return nil, err
+	}
+	*dst = *b.Load()
+
+	if b.config.reloadEvery > 0 {
+		go b.reloadLoop(ctx)
+	}
+	return b, nil
+}
+
+// Load returns the current value. It must not be modified.
+func (b *Binding[T]) Load() *T {
+	return b.value.Load()
+}
+
+// Reload fetches the document again and swaps in the new value. On error
+// the previous value is kept.
+func (b *Binding[T]) Reload(ctx context.Context) error {
+	v, err := b.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	b.value.Store(v)
+	return nil
+}
+
+func (b *Binding[T]) reloadLoop(ctx context.Context) {
+	ticker := time.NewTicker(b.config.reloadEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := b.Reload(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		for _, fn := range b.config.onReload {
+			_ = resolvedb.SafeCall(b.client.client, "config reload callback", func() { fn(err) })
+		}
+	}
+}
+
+// resolve builds a new value from the defaults, the remote document and
+// the environment.
+func (b *Binding[T]) resolve(ctx context.Context) (*T, error) {
+	v := new(T)
+	if b.config.defaults != nil {
+		// Round-trip through JSON so the value shares no maps or slices
+		// with the defaults
+		data, err := json.Marshal(b.config.defaults)
+		if err != nil {
+			return nil, fmt.Errorf("config: encode defaults: %w", err)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			return nil, fmt.Errorf("config: decode defaults: %w", err)
+		}
+	}
+
+	opts := append([]resolvedb.RequestOption{resolvedb.WithRefreshCache()}, b.config.reqOpts...)
+	if err := b.client.Get(ctx, b.key, v, opts...); err != nil && !resolvedb.IsNotFound(err) {
+		return nil, err
+	}
+
+	if b.config.env {
+		if err := applyEnv(reflect.ValueOf(v).Elem(), b.config.envPrefix); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// applyEnv sets the fields of struct v from environment variables named
+// prefix plus the field's env name.
+func applyEnv(v reflect.Value, prefix string) error {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+
+		if field.Anonymous && fv.Kind() == reflect.Struct {
+			if err := applyEnv(fv, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name, ok := envName(field)
+		if !ok {
+			continue
+		}
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			if err := applyEnv(fv, prefix+name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := os.LookupEnv(prefix + name)
+		if !ok {
+			continue
+		}
+		if err := setFromString(fv, raw); err != nil {
+			return fmt.Errorf("config: env %s: %w", prefix+name, err)
+		}
+	}
+	return nil
+}
+
+// envName returns the environment name of a field: its env tag, or its
+// JSON or Go name in upper snake case. ok is false for skipped fields.
+func envName(field reflect.StructField) (string, bool) {
+	if tag := field.Tag.Get("env"); tag != "" {
+		return tag, tag != "-"
+	}
+	name := field.Name
+	if tag := field.Tag.Get("json"); tag != "" {
+		jsonName, _, _ := strings.Cut(tag, ",")
+		if jsonName == "-" {
+			return "", false
+		}
+		if jsonName != "" {
+			name = jsonName
+		}
+	}
+	return upperSnake(name), true
+}
+
+// upperSnake converts a Go or JSON name to upper snake case, e.g.
+// "MaxConns" and "max_conns" to "MAX_CONNS".
+func upperSnake(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if r == '-' || r == '.' {
+			r = '_'
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// setFromString parses raw into v according to its kind.
+func setFromString(v reflect.Value, raw string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var parts []string
+		if raw != "" {
+			parts = strings.Split(raw, ",")
+			for i := range parts {
+				parts[i] = strings.TrimSpace(parts[i])
+			}
+		}
+		v.Set(reflect.ValueOf(parts).Convert(v.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Package config provides a client for configuration documents stored in
+// ResolveDB, including typed binding with defaults, environment overrides
+// and atomic reloads.
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/registry"
+)
+
+// resource is the resource holding configuration documents.
+const resource = "config"
+
+// ConfigClient defines the interface for Config operations.
+// Implement this interface for testing with mocks.
+type ConfigClient interface {
+	Get(ctx context.Context, key string, dst any, opts ...resolvedb.RequestOption) error
+	List(ctx context.Context, opts ...resolvedb.RequestOption) ([]string, error)
+}
+
+// Client is a Config service client.
+type Client struct {
+	client resolvedb.Querier
+}
+
+// NewClient creates a new Config client.
+func NewClient(c resolvedb.Querier) *Client {
+	return &Client{client: c}
+}
+
+// Ensure Client implements ConfigClient.
+var _ ConfigClient = (*Client)(nil)
+
+// Get retrieves the configuration document key, unmarshaling into dst.
+//
+// Documents can include shared fragments with ${ref:resource/key}
+// references, which are resolved before unmarshaling. A string that is
+// exactly one reference is replaced by the referenced document, which may
+// itself contain references; references inside longer strings are
+// replaced by the referenced value's text. Each referenced document is
+// fetched once per Get, and reference cycles return an error wrapping
+// ErrRefCycle.
+//
+// Example:
+//
+//	// config/app: {"db": "${ref:config/db-shared}", "name": "app-${ref:config/env}"}
+//	var cfg AppConfig
+//	err := configClient.Get(ctx, "app", &cfg)
+func (c *Client) Get(ctx context.Context, key string, dst any, opts ...resolvedb.RequestOption) error {
+	var raw json.RawMessage
+	if err := c.client.Get(ctx, resource, key, &raw, opts...); err != nil {
+		return err
+	}
+	if !bytes.Contains(raw, refMarker) {
+		return json.Unmarshal(raw, dst)
+	}
+
+	doc, err := newRefResolver(c.client, opts).resolveDoc(ctx, resource+"/"+key, raw)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("config: encode %s: %w", key, err)
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// NewRegistry loads every configuration document into a registry of T,
+// resolving references as Get does.
+//
+// Example:
+//
+//	services, err := config.NewRegistry[ServiceConfig](ctx, configClient,
+//	    registry.WithRefresh(time.Minute))
+//	if svc, ok := services.Get("billing"); ok {
+//	    dial(svc.Addr)
+//	}
+func NewRegistry[T any](ctx context.Context, c *Client, opts ...registry.Option) (*registry.Registry[T], error) {
+	opts = append([]registry.Option{registry.WithLoader(c.Get)}, opts...)
+	return registry.New[T](ctx, c.client, resource, opts...)
+}
+
+// List returns the keys of all configuration documents.
+func (c *Client) List(ctx context.Context, opts ...resolvedb.RequestOption) ([]string, error) {
+	return c.client.List(ctx, resource, opts...)
+}
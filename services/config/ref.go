@@ -0,0 +1,145 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// ErrRefCycle is returned when configuration documents reference each
+// other in a cycle.
+var ErrRefCycle = errors.New("config: reference cycle")
+
+// refMarker starts every reference; documents without it are not walked.
+var refMarker = []byte("${ref:")
+
+// refPattern matches a reference: ${ref:resource/key}.
+var refPattern = regexp.MustCompile(`\$\{ref:([a-z0-9-]+)/([^}]+)\}`)
+
+// refResolver resolves references for a single load, fetching each
+// referenced document once.
+type refResolver struct {
+	client resolvedb.Querier
+	opts   []resolvedb.RequestOption
+
+	cache map[string]any // Resolved documents by "resource/key"
+	path  []string       // References being resolved, outermost first
+}
+
+func newRefResolver(client resolvedb.Querier, opts []resolvedb.RequestOption) *refResolver {
+	return &refResolver{client: client, opts: opts, cache: make(map[string]any)}
+}
+
+// load fetches resource/key and resolves the references in it.
+func (r *refResolver) load(ctx context.Context, resource, key string) (any, error) {
+	ref := resource + "/" + key
+	if doc, ok := r.cache[ref]; ok {
+		return doc, nil
+	}
+	for _, p := range r.path {
+		if p == ref {
+			cycle := append(append([]string(nil), r.path...), ref)
+			return nil, fmt.Errorf("%w: %s", ErrRefCycle, strings.Join(cycle, " -> "))
+		}
+	}
+
+	var raw json.RawMessage
+	if err := r.client.Get(ctx, resource, key, &raw, r.opts...); err != nil {
+		return nil, err
+	}
+	return r.resolveDoc(ctx, ref, raw)
+}
+
+// resolveDoc decodes the document fetched as ref and resolves the
+// references in it.
+func (r *refResolver) resolveDoc(ctx context.Context, ref string, raw []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("config: decode %s: %w", ref, err)
+	}
+
+	if bytes.Contains(raw, refMarker) {
+		r.path = append(r.path, ref)
+		var err error
+		doc, err = r.resolve(ctx, doc)
+		r.path = r.path[:len(r.path)-1]
+		if err != nil {
+			return nil, err
+		}
+	}
+	r.cache[ref] = doc
+	return doc, nil
+}
+
+// resolve replaces the references in v. A string that is exactly one
+// reference is replaced by the referenced document; references inside
+// longer strings are replaced by the referenced scalar's text.
+func (r *refResolver) resolve(ctx context.Context, v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, elem := range v {
+			resolved, err := r.resolve(ctx, elem)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = resolved
+		}
+		return v, nil
+	case []any:
+		for i, elem := range v {
+			resolved, err := r.resolve(ctx, elem)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+		return v, nil
+	case string:
+		return r.resolveString(ctx, v)
+	default:
+		return v, nil
+	}
+}
+
+func (r *refResolver) resolveString(ctx context.Context, s string) (any, error) {
+	matches := refPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s, nil
+	}
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(s) {
+		return r.load(ctx, s[matches[0][2]:matches[0][3]], s[matches[0][4]:matches[0][5]])
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		doc, err := r.load(ctx, s[m[2]:m[3]], s[m[4]:m[5]])
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(s[last:m[0]])
+		switch doc := doc.(type) {
+		case string:
+			b.WriteString(doc)
+		case map[string]any, []any:
+			return nil, fmt.Errorf("config: %s: cannot interpolate an object or array into a string", s[m[0]:m[1]])
+		default:
+			text, err := json.Marshal(doc)
+			if err != nil {
+				return nil, err
+			}
+			b.Write(text)
+		}
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
@@ -0,0 +1,289 @@
+// Package counter provides distributed counters on ResolveDB. A counter is
+// sharded across several keys so concurrent writers rarely touch the same
+// key; its value is the sum of the shards.
+package counter
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// resource is the resource holding counters.
+const resource = "counters"
+
+// shardResource is the resource holding counter shards. Shards never share
+// a resource with counters, so no counter name can address a shard.
+const shardResource = "counter-shards"
+
+const (
+	defaultShards      = 16
+	defaultMaxAttempts = 8
+
+	// maxShards bounds the shard count, and so the reads per Get.
+	maxShards = 256
+)
+
+// ErrContention is returned when an Add loses the race for a shard on
+// every attempt. Retrying later, or creating the counter with more shards,
+// resolves it.
+var ErrContention = errors.New("counter: too many concurrent writers")
+
+// Store is the subset of resolvedb.Client used by the counter client.
+type Store interface {
+	resolvedb.ReadWriter
+}
+
+// CounterClient defines the interface for Counter operations.
+// Implement this interface for testing with mocks.
+type CounterClient interface {
+	Create(ctx context.Context, name string, shards int, opts ...resolvedb.RequestOption) error
+	Add(ctx context.Context, name string, delta int64, opts ...resolvedb.RequestOption) error
+	Get(ctx context.Context, name string, opts ...resolvedb.RequestOption) (int64, error)
+	Delete(ctx context.Context, name string, opts ...resolvedb.RequestOption) error
+}
+
+// Client is a Counter service client.
+type Client struct {
+	client      Store
+	maxAttempts int
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithMaxAttempts sets how many shards Add tries before returning
+// ErrContention (default 8).
+func WithMaxAttempts(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.maxAttempts = n
+		}
+	}
+}
+
+// NewClient creates a new Counter client.
+func NewClient(c Store, opts ...Option) *Client {
+	client := &Client{client: c, maxAttempts: defaultMaxAttempts}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// Ensure Client implements CounterClient.
+var _ CounterClient = (*Client)(nil)
+
+// meta describes a counter.
+type meta struct {
+	Shards int `json:"shards"`
+}
+
+// shard is the stored value of one shard.
+type shard struct {
+	N int64 `json:"n"`
+}
+
+// Create creates a counter with the given number of shards, all zero. Use
+// more shards for counters with more concurrent writers; zero or less
+// uses 16. It returns an error wrapping resolvedb.ErrConflict if the
+// counter already exists. The check is not atomic, so create each counter
+// from a single place.
+//
+// Example:
+//
+//	err := counterClient.Create(ctx, "page-views", 32)
+func (c *Client) Create(ctx context.Context, name string, shards int, opts ...resolvedb.RequestOption) error {
+	if shards <= 0 {
+		shards = defaultShards
+	}
+	if shards > maxShards {
+		return fmt.Errorf("counter: %d shards exceeds the maximum of %d", shards, maxShards)
+	}
+
+	var existing meta
+	err := c.client.Get(ctx, resource, name, &existing, withRefresh(opts)...)
+	if err == nil {
+		return fmt.Errorf("counter: create %q: %w", name, resolvedb.ErrConflict)
+	}
+	if !resolvedb.IsNotFound(err) {
+		return err
+	}
+
+	// Shards first, so a counter is never visible with missing shards
+	for i := 0; i < shards; i++ {
+		if err := c.client.Set(ctx, shardResource, shardKey(name, i), shard{}, opts...); err != nil {
+			return fmt.Errorf("counter: create %q: %w", name, err)
+		}
+	}
+	return c.client.Set(ctx, resource, name, meta{Shards: shards}, opts...)
+}
+
+// Add adds delta to a counter. Each attempt picks a random shard and
+// updates it with a compare-and-swap (resolvedb.WithIfMatch), so writers
+// only conflict when they pick the same shard at the same time; a
+// conflicting attempt moves on to another shard. Each update carries an
+// idempotency key, so an update retried after a lost response is applied
+// once. If Add fails with a transport error, the update may still have
+// been applied.
+//
+// Example:
+//
+//	err := counterClient.Add(ctx, "page-views", 1)
+func (c *Client) Add(ctx context.Context, name string, delta int64, opts ...resolvedb.RequestOption) error {
+	m, err := c.meta(ctx, name, opts)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < c.maxAttempts; attempt++ {
+		i, err := c.randShard(m.Shards)
+		if err != nil {
+			return err
+		}
+		key := shardKey(name, i)
+		cur, hash, err := c.shard(ctx, key, opts)
+		if err != nil {
+			return err
+		}
+		idem, err := c.idempotencyKey()
+		if err != nil {
+			return err
+		}
+		update := append(opts[:len(opts):len(opts)], resolvedb.WithIfMatch(hash), resolvedb.WithIdempotencyKey(idem))
+		err = c.client.Set(ctx, shardResource, key, shard{N: cur.N + delta}, update...)
+		if err == nil {
+			return nil
+		}
+		// A mismatch means another writer updated the shard first
+		if !errors.Is(err, resolvedb.ErrVersionMismatch) {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: %q after %d attempts", ErrContention, name, c.maxAttempts)
+}
+
+// Get returns the value of a counter: the sum of its shards, read
+// concurrently. Shards are read through the client's cache; pass
+// resolvedb.WithRefreshCache for an up-to-date value.
+func (c *Client) Get(ctx context.Context, name string, opts ...resolvedb.RequestOption) (int64, error) {
+	m, err := c.meta(ctx, name, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([]int64, m.Shards)
+	errs := make([]error, m.Shards)
+	var wg sync.WaitGroup
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var s shard
+			errs[i] = c.client.Get(ctx, shardResource, shardKey(name, i), &s, opts...)
+			values[i] = s.N
+		}(i)
+	}
+	wg.Wait()
+
+	var sum int64
+	for i, err := range errs {
+		if err != nil {
+			return 0, fmt.Errorf("counter: read shard %d of %q: %w", i, name, err)
+		}
+		sum += values[i]
+	}
+	return sum, nil
+}
+
+// Delete deletes a counter and its shards.
+func (c *Client) Delete(ctx context.Context, name string, opts ...resolvedb.RequestOption) error {
+	m, err := c.meta(ctx, name, opts)
+	if err != nil {
+		return err
+	}
+	// Counter first, so it is never visible with missing shards
+	if err := c.client.Delete(ctx, resource, name, opts...); err != nil {
+		return err
+	}
+	for i := 0; i < m.Shards; i++ {
+		if err := c.client.Delete(ctx, shardResource, shardKey(name, i), opts...); err != nil && !resolvedb.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// shard reads a shard, bypassing the cache, with the content hash to
+// condition its update on.
+func (c *Client) shard(ctx context.Context, key string, opts []resolvedb.RequestOption) (shard, string, error) {
+	var s shard
+	resp, err := c.client.GetRaw(ctx, shardResource, key, withRefresh(opts)...)
+	if err != nil {
+		return s, "", err
+	}
+	defer resp.Release()
+	if err := resp.ToError(); err != nil {
+		return s, "", err
+	}
+	if resp.Hash == "" {
+		return s, "", fmt.Errorf("counter: shard %q has no content hash to update against", key)
+	}
+	if err := resp.Unmarshal(&s); err != nil {
+		return s, "", err
+	}
+	return s, resp.Hash, nil
+}
+
+// randShard returns a random shard index below n, read from the client's
+// random source.
+func (c *Client) randShard(n int) (int, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(resolvedb.RandOf(c.client), b[:]); err != nil {
+		return 0, fmt.Errorf("counter: pick shard: %w", err)
+	}
+	return int(binary.BigEndian.Uint64(b[:]) % uint64(n)), nil
+}
+
+// idempotencyKey returns a random key for one shard update.
+func (c *Client) idempotencyKey() (string, error) {
+	var b [16]byte
+	if _, err := io.ReadFull(resolvedb.RandOf(c.client), b[:]); err != nil {
+		return "", fmt.Errorf("counter: idempotency key: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// meta reads a counter's description. The shard count never changes, so
+// cached reads are fine.
+func (c *Client) meta(ctx context.Context, name string, opts []resolvedb.RequestOption) (*meta, error) {
+	var m meta
+	if err := c.client.Get(ctx, resource, name, &m, opts...); err != nil {
+		return nil, err
+	}
+	if m.Shards <= 0 || m.Shards > maxShards {
+		return nil, fmt.Errorf("counter: %q has invalid shard count %d", name, m.Shards)
+	}
+	return &m, nil
+}
+
+// shardKey returns the key of shard i of a counter.
+func shardKey(name string, i int) string {
+	return name + "-s" + strconv.Itoa(i)
+}
+
+// withRefresh returns opts with resolvedb.WithRefreshCache appended,
+// without modifying opts.
+func withRefresh(opts []resolvedb.RequestOption) []resolvedb.RequestOption {
+	return append(append([]resolvedb.RequestOption(nil), opts...), resolvedb.WithRefreshCache())
+}
@@ -0,0 +1,192 @@
+// Package experiments provides a client for ResolveDB's Experiments
+// service: A/B tests whose variants are assigned to users locally and
+// deterministically, with the same hash bucketing as flag rollouts.
+package experiments
+
+import (
+	"context"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/services/flags"
+)
+
+// ExperimentsClient defines the interface for Experiments operations.
+// Implement this interface for testing with mocks.
+type ExperimentsClient interface {
+	Get(ctx context.Context, name string, opts ...resolvedb.RequestOption) (*Experiment, error)
+	List(ctx context.Context, opts ...resolvedb.RequestOption) ([]string, error)
+	Assign(ctx context.Context, name, userID string, opts ...resolvedb.RequestOption) (*Assignment, error)
+}
+
+// Client is an Experiments service client.
+type Client struct {
+	client resolvedb.Querier
+	hooks  []func(Exposure)
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithExposureHook calls fn after every Assign, so experimentation
+// pipelines can join exposure with outcomes. fn is called synchronously
+// and must not block.
+//
+// Example:
+//
+//	ec := experiments.NewClient(client, experiments.WithExposureHook(func(e experiments.Exposure) {
+//	    analytics.Track(e.UserID, "exposure", e.Experiment, e.Variant)
+//	}))
+func WithExposureHook(fn func(Exposure)) Option {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, fn)
+	}
+}
+
+// NewClient creates a new Experiments client.
+func NewClient(c resolvedb.Querier, opts ...Option) *Client {
+	client := &Client{client: c}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// Ensure Client implements ExperimentsClient.
+var _ ExperimentsClient = (*Client)(nil)
+
+// Experiment represents an experiment configuration.
+type Experiment struct {
+	Name        string    `json:"name"`
+	Active      bool      `json:"active"`
+	Traffic     int       `json:"traffic,omitempty"` // Percentage of users enrolled (0 enrolls everyone)
+	Variants    []Variant `json:"variants"`
+	Seed        string    `json:"seed,omitempty"` // Bucketing seed (defaults to Name)
+	Description string    `json:"description,omitempty"`
+}
+
+// Variant is one arm of an experiment.
+type Variant struct {
+	Name   string      `json:"name"`
+	Weight int         `json:"weight"` // Relative share of enrolled users
+	Value  interface{} `json:"value,omitempty"`
+}
+
+// Assignment is the variant a user was assigned to.
+type Assignment struct {
+	Experiment string      // Experiment name
+	Variant    string      // Variant name, empty if not enrolled
+	Value      interface{} // Variant value
+	Enrolled   bool        // Whether the user is in the experiment
+	Bucket     int         // User's bucket, in [0, flags.Buckets)
+}
+
+// Exposure describes a user being assigned to an experiment variant.
+type Exposure struct {
+	Experiment string
+	Variant    string // Empty if not enrolled
+	Enrolled   bool
+	UserID     string
+	Bucket     int
+	Time       time.Time
+}
+
+// Get retrieves an experiment configuration by name.
+func (c *Client) Get(ctx context.Context, name string, opts ...resolvedb.RequestOption) (*Experiment, error) {
+	var exp Experiment
+	if err := c.client.Get(ctx, "experiments", name, &exp, opts...); err != nil {
+		return nil, err
+	}
+	if exp.Name == "" {
+		exp.Name = name
+	}
+	return &exp, nil
+}
+
+// List returns the names of all experiments.
+func (c *Client) List(ctx context.Context, opts ...resolvedb.RequestOption) ([]string, error) {
+	return c.client.List(ctx, "experiments", opts...)
+}
+
+// Assign fetches an experiment and assigns userID to one of its variants.
+// Users outside the experiment's traffic, or of an inactive experiment,
+// are returned as not enrolled.
+//
+// Example:
+//
+//	a, err := expClient.Assign(ctx, "checkout-button", user.ID)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if a.Variant == "green" {
+//	    renderGreenButton()
+//	}
+func (c *Client) Assign(ctx context.Context, name, userID string, opts ...resolvedb.RequestOption) (*Assignment, error) {
+	exp, err := c.Get(ctx, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	a := exp.Assign(userID)
+	if len(c.hooks) > 0 {
+		e := Exposure{
+			Experiment: a.Experiment,
+			Variant:    a.Variant,
+			Enrolled:   a.Enrolled,
+			UserID:     userID,
+			Bucket:     a.Bucket,
+			Time:       time.Now(),
+		}
+		for _, hook := range c.hooks {
+			_ = resolvedb.SafeCall(c.client, "experiments exposure hook", func() { hook(e) })
+		}
+	}
+	return &a, nil
+}
+
+// Assign deterministically assigns userID to a variant, without any
+// network access. Users are hashed with flags.Bucket, so a user's
+// assignment is stable for as long as the experiment's seed, traffic and
+// weights are unchanged.
+func (e *Experiment) Assign(userID string) Assignment {
+	seed := e.Seed
+	if seed == "" {
+		seed = e.Name
+	}
+	a := Assignment{Experiment: e.Name, Bucket: flags.Bucket(seed, userID)}
+	if !e.Active {
+		return a
+	}
+
+	traffic := flags.Buckets
+	if e.Traffic > 0 && e.Traffic < 100 {
+		traffic = e.Traffic * flags.Buckets / 100
+	}
+	if a.Bucket >= traffic {
+		return a
+	}
+
+	total := 0
+	for _, v := range e.Variants {
+		if v.Weight > 0 {
+			total += v.Weight
+		}
+	}
+	if total == 0 {
+		return a
+	}
+
+	// Spread the enrolled buckets across variants in proportion to weight
+	point := a.Bucket * total / traffic
+	for _, v := range e.Variants {
+		if v.Weight <= 0 {
+			continue
+		}
+		if point < v.Weight {
+			a.Variant, a.Value, a.Enrolled = v.Name, v.Value, true
+			return a
+		}
+		point -= v.Weight
+	}
+	return a
+}
@@ -0,0 +1,138 @@
+package flags
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// CacheStats reports the activity of a client's flag evaluation cache.
+type CacheStats struct {
+	Hits          uint64 // Lookups answered from a fresh entry
+	StaleHits     uint64 // Lookups answered from a stale entry while it refreshed
+	Misses        uint64 // Lookups that queried ResolveDB
+	Refreshes     uint64 // Background refreshes completed
+	RefreshErrors uint64 // Background refreshes that failed
+	Entries       int    // Flags currently cached
+}
+
+// flagCache is a short-TTL cache of flag lookups, separate from the main
+// client cache. Entries older than ttl are served for up to maxStale more
+// while a background refresh runs; older entries are fetched again.
+type flagCache struct {
+	ttl      time.Duration
+	maxStale time.Duration
+
+	mu         sync.Mutex
+	entries    map[string]*flagEntry
+	refreshing map[string]bool
+
+	hits          atomic.Uint64
+	staleHits     atomic.Uint64
+	misses        atomic.Uint64
+	refreshes     atomic.Uint64
+	refreshErrors atomic.Uint64
+}
+
+// flagEntry is a cached lookup: a flag, or nil if it does not exist.
+type flagEntry struct {
+	flag    *Flag
+	fetched time.Time
+}
+
+func newFlagCache(ttl, maxStale time.Duration) *flagCache {
+	return &flagCache{
+		ttl:        ttl,
+		maxStale:   maxStale,
+		entries:    make(map[string]*flagEntry),
+		refreshing: make(map[string]bool),
+	}
+}
+
+// get returns the flag called name, from the cache when possible. A nil
+// flag with a nil error means the flag does not exist.
+func (fc *flagCache) get(ctx context.Context, name string, fetch func(context.Context) (*Flag, error)) (*Flag, error) {
+	now := time.Now()
+	fc.mu.Lock()
+	entry, ok := fc.entries[name]
+	fc.mu.Unlock()
+
+	if ok {
+		age := now.Sub(entry.fetched)
+		switch {
+		case age <= fc.ttl:
+			fc.hits.Add(1)
+			return entry.flag, nil
+		case age <= fc.ttl+fc.maxStale:
+			fc.staleHits.Add(1)
+			fc.refresh(ctx, name, fetch)
+			return entry.flag, nil
+		}
+	}
+
+	fc.misses.Add(1)
+	flag, err := fetchFlag(ctx, fetch)
+	if err != nil {
+		return nil, err
+	}
+	fc.store(name, flag)
+	return flag, nil
+}
+
+// refresh fetches name in the background, at most once at a time per
+// flag, detached from the caller's cancellation.
+func (fc *flagCache) refresh(ctx context.Context, name string, fetch func(context.Context) (*Flag, error)) {
+	fc.mu.Lock()
+	if fc.refreshing[name] {
+		fc.mu.Unlock()
+		return
+	}
+	fc.refreshing[name] = true
+	fc.mu.Unlock()
+
+	go func() {
+		flag, err := fetchFlag(context.WithoutCancel(ctx), fetch)
+		if err != nil {
+			fc.refreshErrors.Add(1)
+		} else {
+			fc.refreshes.Add(1)
+			fc.store(name, flag)
+		}
+		fc.mu.Lock()
+		delete(fc.refreshing, name)
+		fc.mu.Unlock()
+	}()
+}
+
+func (fc *flagCache) store(name string, flag *Flag) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.entries[name] = &flagEntry{flag: flag, fetched: time.Now()}
+}
+
+func (fc *flagCache) stats() CacheStats {
+	fc.mu.Lock()
+	entries := len(fc.entries)
+	fc.mu.Unlock()
+	return CacheStats{
+		Hits:          fc.hits.Load(),
+		StaleHits:     fc.staleHits.Load(),
+		Misses:        fc.misses.Load(),
+		Refreshes:     fc.refreshes.Load(),
+		RefreshErrors: fc.refreshErrors.Load(),
+		Entries:       entries,
+	}
+}
+
+// fetchFlag calls fetch, mapping a missing flag to a nil flag so that it
+// can be cached.
+func fetchFlag(ctx context.Context, fetch func(context.Context) (*Flag, error)) (*Flag, error) {
+	flag, err := fetch(ctx)
+	if resolvedb.IsNotFound(err) {
+		return nil, nil
+	}
+	return flag, err
+}
@@ -0,0 +1,177 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/rollout"
+)
+
+// ErrPrerequisiteCycle is returned when flags list each other as
+// prerequisites, directly or through other flags.
+var ErrPrerequisiteCycle = errors.New("flags: prerequisite cycle")
+
+// Reason explains the result of a flag evaluation.
+type Reason string
+
+// Evaluation reasons.
+const (
+	ReasonOn              Reason = "on"               // Enabled, with no cohort targeting applied
+	ReasonCohortMatch     Reason = "cohort_match"     // Enabled and targets the cohort
+	ReasonOff             Reason = "off"              // Disabled
+	ReasonCohortMiss      Reason = "cohort_miss"      // Enabled, but does not target the cohort
+	ReasonNotFound        Reason = "not_found"        // The flag does not exist
+	ReasonPrerequisiteOff Reason = "prerequisite_off" // A prerequisite is off
+	ReasonError           Reason = "error"            // The flag could not be evaluated
+)
+
+// Evaluation describes a single flag evaluation, for joining flag exposure
+// with outcomes in experimentation pipelines.
+type Evaluation struct {
+	Flag   string    // Flag evaluated
+	Cohort string    // Cohort evaluated for, empty for Get
+	Result bool      // Whether the flag was on
+	Reason Reason    // Why the flag was on or off
+	Rule   string    // Cohort entry matched, or the prerequisite that was off
+	Err    error     // Evaluation error, for ReasonError
+	Time   time.Time // When the evaluation finished
+}
+
+// WithEvaluationHook calls fn after every Get and IsEnabledForCohort
+// evaluation, including those answered from the cache. Prerequisites are
+// reported as part of the evaluation that needed them, not separately. fn
+// is called synchronously and must not block.
+//
+// Example:
+//
+//	fc := flags.NewClient(client, flags.WithEvaluationHook(func(ev flags.Evaluation) {
+//	    exposures.Record(ev.Flag, ev.Cohort, ev.Result, ev.Reason)
+//	}))
+func WithEvaluationHook(fn func(Evaluation)) Option {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, fn)
+	}
+}
+
+// evaluation evaluates a flag and its prerequisites, either globally or
+// for a single cohort.
+type evaluation struct {
+	client    *Client
+	opts      []resolvedb.RequestOption
+	cohort    string
+	forCohort bool
+
+	path []string // Flags being evaluated, outermost first
+}
+
+// outcome is the result of evaluating a flag.
+type outcome struct {
+	on     bool
+	reason Reason
+	rule   string
+}
+
+// eval evaluates the flag called name. A flag is on when it is enabled,
+// targets the cohort (for cohort evaluations), and all of its
+// prerequisites are on as well. Missing flags are off.
+func (e *evaluation) eval(ctx context.Context, name string) (outcome, error) {
+	for _, n := range e.path {
+		if n == name {
+			cycle := append(append([]string(nil), e.path...), name)
+			return outcome{reason: ReasonError}, fmt.Errorf("%w: %s", ErrPrerequisiteCycle, strings.Join(cycle, " -> "))
+		}
+	}
+
+	flag, err := e.client.getFlag(ctx, name, e.opts)
+	if err != nil {
+		if resolvedb.IsNotFound(err) {
+			return outcome{reason: ReasonNotFound}, nil
+		}
+		return outcome{reason: ReasonError}, err
+	}
+	return e.evalFlag(ctx, name, flag)
+}
+
+// evalFlag evaluates flag, fetched as name.
+func (e *evaluation) evalFlag(ctx context.Context, name string, flag *Flag) (outcome, error) {
+	if !flag.Enabled {
+		return outcome{reason: ReasonOff}, nil
+	}
+	result := outcome{on: true, reason: ReasonOn}
+	if e.forCohort {
+		rule, ok := flag.targets(e.cohort)
+		if !ok {
+			return outcome{reason: ReasonCohortMiss}, nil
+		}
+		result = outcome{on: true, reason: ReasonCohortMatch, rule: rule}
+	}
+
+	e.path = append(e.path, name)
+	defer func() { e.path = e.path[:len(e.path)-1] }()
+	for _, prereq := range flag.Prerequisites {
+		pre, err := e.eval(ctx, prereq)
+		if err != nil {
+			return outcome{reason: ReasonError}, err
+		}
+		if !pre.on {
+			return outcome{reason: ReasonPrerequisiteOff, rule: prereq}, nil
+		}
+	}
+	return result, nil
+}
+
+// report passes the outcome of evaluating name to the client's hooks and
+// returns it.
+func (e *evaluation) report(name string, res outcome, err error) (bool, error) {
+	if len(e.client.hooks) > 0 {
+		ev := Evaluation{
+			Flag:   name,
+			Cohort: e.cohort,
+			Result: res.on,
+			Reason: res.reason,
+			Rule:   res.rule,
+			Err:    err,
+			Time:   time.Now(),
+		}
+		for _, hook := range e.client.hooks {
+			_ = resolvedb.SafeCall(e.client.client, "flags evaluation hook", func() { hook(ev) })
+		}
+	}
+	return res.on, err
+}
+
+// targets reports whether the flag's cohorts include cohort, and the
+// entry that matched.
+func (f *Flag) targets(cohort string) (string, bool) {
+	for _, co := range f.Cohorts {
+		if co == cohort || co == "*" {
+			return co, true
+		}
+	}
+	return "", false
+}
+
+// Buckets is the number of buckets users are hashed into for percentage
+// rollouts. A percentage p covers buckets [0, p*Buckets/100).
+const Buckets = rollout.Buckets
+
+// Bucket deterministically assigns userID a bucket in [0, Buckets) for
+// the flag or experiment called seed. The same user lands in the same
+// bucket for a given seed, and in independent buckets across seeds. It is
+// rollout.Bucket, which SDKs in other languages implement identically.
+func Bucket(seed, userID string) int {
+	return rollout.Bucket(seed, userID)
+}
+
+// InRollout reports whether userID falls within the flag's Percentage
+// rollout. A zero Percentage rolls out to everyone.
+func (f *Flag) InRollout(userID string) bool {
+	if f.Percentage <= 0 || f.Percentage >= 100 {
+		return true
+	}
+	return rollout.InPercentage(f.Name, userID, f.Percentage)
+}
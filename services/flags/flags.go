@@ -3,8 +3,10 @@ package flags
 
 import (
 	"context"
+	"time"
 
 	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/registry"
 )
 
 // FlagsClient defines the interface for Feature Flags operations.
@@ -20,11 +22,62 @@ type FlagsClient interface {
 // Client is a Feature Flags service client.
 type Client struct {
 	client resolvedb.Querier
+	cache  *flagCache // nil unless WithCache is used
+	hooks  []func(Evaluation)
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithCache caches flag lookups for ttl in the flags client itself,
+// separately from the main client cache, since flags are read far more
+// often than they change. Missing flags are cached too. After ttl, an
+// entry is served for up to maxStale longer while it is refreshed in the
+// background; past that, lookups wait for ResolveDB again.
+//
+// Lookups made with request options bypass the cache, since options such
+// as a namespace or token can change the answer.
+//
+// Example:
+//
+//	fc := flags.NewClient(client, flags.WithCache(5*time.Second, time.Minute))
+func WithCache(ttl, maxStale time.Duration) Option {
+	return func(c *Client) {
+		c.cache = newFlagCache(ttl, maxStale)
+	}
 }
 
 // NewClient creates a new Feature Flags client.
-func NewClient(c resolvedb.Querier) *Client {
-	return &Client{client: c}
+func NewClient(c resolvedb.Querier, opts ...Option) *Client {
+	client := &Client{client: c}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// CacheStats returns the activity of the evaluation cache enabled with
+// WithCache, or zero stats without one.
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.stats()
+}
+
+// NewRegistry loads every flag into a registry, for processes that read
+// flags on hot paths and would rather not query per lookup. Registry
+// lookups return the stored flag as is; evaluate it with Get, or check
+// Enabled and prerequisites directly.
+//
+// Example:
+//
+//	all, err := flags.NewRegistry(ctx, flagClient, registry.WithRefresh(30*time.Second))
+//	if f, ok := all.Get("dark-mode"); ok && f.Enabled {
+//	    enableDarkMode()
+//	}
+func NewRegistry(ctx context.Context, c *Client, opts ...registry.Option) (*registry.Registry[Flag], error) {
+	return registry.New[Flag](ctx, c.client, "flags", opts...)
 }
 
 // Ensure Client implements FlagsClient.
@@ -38,9 +91,16 @@ type Flag struct {
 	Percentage  int         `json:"percentage,omitempty"`
 	Cohorts     []string    `json:"cohorts,omitempty"`
 	Description string      `json:"description,omitempty"`
+
+	// Prerequisites names flags that must also be on for this flag to be
+	// on. For cohort checks, each prerequisite must be on for the same
+	// cohort. A missing prerequisite counts as off.
+	Prerequisites []string `json:"prerequisites,omitempty"`
 }
 
-// Get retrieves a feature flag by name.
+// Get retrieves a feature flag by name. The flag is on only if its
+// prerequisites are on as well; a prerequisite cycle returns an error
+// wrapping ErrPrerequisiteCycle.
 //
 // Example:
 //
@@ -52,16 +112,10 @@ type Flag struct {
 //	    enableDarkMode()
 //	}
 func (c *Client) Get(ctx context.Context, name string, opts ...resolvedb.RequestOption) (bool, error) {
-	var flag Flag
-	err := c.client.Get(ctx, "flags", name, &flag, opts...)
-	if err != nil {
-		// Treat not found as disabled
-		if resolvedb.IsNotFound(err) {
-			return false, nil
-		}
-		return false, err
-	}
-	return flag.Enabled, nil
+	// Not found is treated as disabled
+	e := &evaluation{client: c, opts: opts}
+	res, err := e.eval(ctx, name)
+	return e.report(name, res, err)
 }
 
 // GetWithDefault retrieves a flag with a default value.
@@ -75,12 +129,32 @@ func (c *Client) GetWithDefault(ctx context.Context, name string, defaultValue b
 
 // GetFull retrieves the complete flag configuration.
 func (c *Client) GetFull(ctx context.Context, name string, opts ...resolvedb.RequestOption) (*Flag, error) {
-	var flag Flag
-	err := c.client.Get(ctx, "flags", name, &flag, opts...)
+	return c.getFlag(ctx, name, opts)
+}
+
+// getFlag retrieves a flag, from the evaluation cache when enabled. The
+// returned flag is the caller's to modify.
+func (c *Client) getFlag(ctx context.Context, name string, opts []resolvedb.RequestOption) (*Flag, error) {
+	fetch := func(ctx context.Context) (*Flag, error) {
+		var flag Flag
+		if err := c.client.Get(ctx, "flags", name, &flag, opts...); err != nil {
+			return nil, err
+		}
+		return &flag, nil
+	}
+	if c.cache == nil || len(opts) > 0 {
+		return fetch(ctx)
+	}
+
+	flag, err := c.cache.get(ctx, name, fetch)
 	if err != nil {
 		return nil, err
 	}
-	return &flag, nil
+	if flag == nil {
+		return nil, resolvedb.ErrNotFound
+	}
+	flagCopy := *flag
+	return &flagCopy, nil
 }
 
 // GetValue retrieves a flag's value (for non-boolean flags).
@@ -92,24 +166,20 @@ func (c *Client) GetValue(ctx context.Context, name string, opts ...resolvedb.Re
 	return flag.Value, nil
 }
 
-// IsEnabledForCohort checks if a flag is enabled for a specific cohort.
+// IsEnabledForCohort checks if a flag is enabled for a specific cohort,
+// along with its prerequisites.
 func (c *Client) IsEnabledForCohort(ctx context.Context, name, cohort string, opts ...resolvedb.RequestOption) (bool, error) {
 	// Use CTP token if provided via options
+	e := &evaluation{client: c, opts: opts, cohort: cohort, forCohort: true}
 	flag, err := c.GetFull(ctx, name, opts...)
 	if err != nil {
-		return false, err
-	}
-
-	if !flag.Enabled {
-		return false, nil
-	}
-
-	// Check cohort membership
-	for _, co := range flag.Cohorts {
-		if co == cohort || co == "*" {
-			return true, nil
+		res := outcome{reason: ReasonError}
+		if resolvedb.IsNotFound(err) {
+			res.reason = ReasonNotFound
 		}
+		return e.report(name, res, err)
 	}
 
-	return false, nil
+	res, err := e.evalFlag(ctx, name, flag)
+	return e.report(name, res, err)
 }
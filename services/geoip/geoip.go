@@ -3,7 +3,14 @@ package geoip
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/resolvedb/resolvedb-go"
 )
@@ -12,18 +19,103 @@ import (
 // Implement this interface for testing with mocks.
 type GeoIPClient interface {
 	Lookup(ctx context.Context, ip net.IP, opts ...resolvedb.RequestOption) (*Location, error)
+	LookupAddr(ctx context.Context, addr netip.Addr, opts ...resolvedb.RequestOption) (*Location, error)
 	LookupString(ctx context.Context, ip string, opts ...resolvedb.RequestOption) (*Location, error)
 	LookupSelf(ctx context.Context, opts ...resolvedb.RequestOption) (*Location, error)
+	LookupNetwork(ctx context.Context, prefix netip.Prefix, opts ...resolvedb.RequestOption) (*Location, error)
 }
 
 // Client is a GeoIP service client.
 type Client struct {
-	client resolvedb.Querier
+	client  resolvedb.Querier
+	offline OfflineDB
+	mode    Mode
+
+	// Hybrid mode: ResolveDB answers refreshed in the background
+	mu         sync.Mutex
+	refreshed  map[string]refreshedLocation
+	refreshing map[string]bool
+	refreshTTL time.Duration
+}
+
+// OfflineDB is a local GeoIP database, such as a MaxMind MMDB file opened
+// with the integrations/geoipmmdb package.
+type OfflineDB interface {
+	// Lookup returns the location of ip, or nil if the database has no
+	// entry for it.
+	Lookup(ip net.IP) (*Location, error)
+}
+
+// Mode selects how a client with an offline database uses it.
+type Mode int
+
+const (
+	// Fallback queries ResolveDB and answers from the offline database
+	// only when ResolveDB is unreachable.
+	Fallback Mode = iota
+
+	// Hybrid answers from the offline database for low latency and
+	// refreshes each address from ResolveDB in the background. Refreshed
+	// ResolveDB answers are authoritative and take precedence over the
+	// offline database until they expire. Addresses missing from the
+	// offline database are looked up in ResolveDB directly.
+	Hybrid
+)
+
+const (
+	// defaultRefreshTTL is how long a hybrid-mode refresh is used.
+	defaultRefreshTTL = time.Hour
+
+	// maxRefreshed bounds the refreshed answers kept in hybrid mode.
+	maxRefreshed = 10000
+)
+
+// refreshedLocation is a ResolveDB answer kept in hybrid mode.
+type refreshedLocation struct {
+	loc     *Location
+	expires time.Time
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithOfflineDB sets a local database used in the given mode.
+//
+// Example:
+//
+//	db, err := geoipmmdb.Open("GeoLite2-City.mmdb")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer db.Close()
+//	geo := geoip.NewClient(client, geoip.WithOfflineDB(db, geoip.Hybrid))
+func WithOfflineDB(db OfflineDB, mode Mode) Option {
+	return func(c *Client) {
+		c.offline = db
+		c.mode = mode
+	}
+}
+
+// WithRefreshTTL sets how long a ResolveDB answer refreshed in hybrid mode
+// takes precedence over the offline database (default: 1h).
+func WithRefreshTTL(d time.Duration) Option {
+	return func(c *Client) {
+		c.refreshTTL = d
+	}
 }
 
 // NewClient creates a new GeoIP client.
-func NewClient(c resolvedb.Querier) *Client {
-	return &Client{client: c}
+func NewClient(c resolvedb.Querier, opts ...Option) *Client {
+	client := &Client{
+		client:     c,
+		refreshed:  make(map[string]refreshedLocation),
+		refreshing: make(map[string]bool),
+		refreshTTL: defaultRefreshTTL,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
 }
 
 // Ensure Client implements GeoIPClient.
@@ -39,11 +131,76 @@ type Location struct {
 	Latitude    float64 `json:"latitude"`
 	Longitude   float64 `json:"longitude"`
 	Timezone    string  `json:"timezone"`
+	Local       string  `json:"local_time,omitempty"`
 	ISP         string  `json:"isp,omitempty"`
 	ASN         int     `json:"asn,omitempty"`
 	ASOrg       string  `json:"as_org,omitempty"`
 }
 
+// Zone returns the location's IANA time zone, such as "America/Toronto".
+func (l *Location) Zone() (*time.Location, error) {
+	return LoadZone(l.Timezone)
+}
+
+// LocalTime returns the local time reported with the location, in its
+// time zone (see ParseLocalTime).
+//
+// Example:
+//
+//	loc, err := geoClient.LookupSelf(ctx)
+//	...
+//	now, err := loc.LocalTime()
+//	fmt.Println(now.Format("15:04 MST"))
+func (l *Location) LocalTime() (time.Time, error) {
+	return ParseLocalTime(l.Local, l.Timezone)
+}
+
+// localTimeLayouts are the layouts accepted for local times without an
+// offset, tried in order.
+var localTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+}
+
+// LoadZone loads the IANA time zone tz. It differs from time.LoadLocation
+// in rejecting the empty name, which would otherwise mean UTC.
+func LoadZone(tz string) (*time.Location, error) {
+	if tz == "" {
+		return nil, errors.New("geoip: no time zone")
+	}
+	zone, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: time zone %q: %w", tz, err)
+	}
+	return zone, nil
+}
+
+// ParseLocalTime parses a local time reported by the GeoIP and Weather
+// services in the IANA time zone tz. Times with an offset, in RFC 3339,
+// are converted to the zone; times without one, such as
+// "2024-06-01T14:30:00" or "2024-06-01 14:30", are read as wall clock
+// times in the zone.
+func ParseLocalTime(local, tz string) (time.Time, error) {
+	if local == "" {
+		return time.Time{}, errors.New("geoip: no local time")
+	}
+	zone, err := LoadZone(tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if t, err := time.Parse(time.RFC3339Nano, local); err == nil {
+		return t.In(zone), nil
+	}
+	for _, layout := range localTimeLayouts {
+		if t, err := time.ParseInLocation(layout, local, zone); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("geoip: invalid local time %q", local)
+}
+
 // Lookup retrieves geolocation data for an IP address.
 //
 // Example:
@@ -54,24 +211,190 @@ type Location struct {
 //	}
 //	fmt.Printf("City: %s, Country: %s\n", loc.City, loc.Country)
 func (c *Client) Lookup(ctx context.Context, ip net.IP, opts ...resolvedb.RequestOption) (*Location, error) {
-	var loc Location
-	err := c.client.Get(ctx, "geoip", ip.String(), &loc, opts...)
-	if err != nil {
-		return nil, err
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return nil, fmt.Errorf("geoip: invalid IP address %v", ip)
 	}
-	return &loc, nil
+	return c.lookupAddr(ctx, addr, opts)
+}
+
+// LookupAddr retrieves geolocation data for an IP address. It avoids the
+// allocations of net.IP and treats IPv4-mapped IPv6 addresses as IPv4.
+//
+// Example:
+//
+//	loc, err := geoClient.LookupAddr(ctx, netip.MustParseAddr("2001:4860:4860::8888"))
+func (c *Client) LookupAddr(ctx context.Context, addr netip.Addr, opts ...resolvedb.RequestOption) (*Location, error) {
+	if !addr.IsValid() {
+		return nil, fmt.Errorf("geoip: invalid IP address %v", addr)
+	}
+	return c.lookupAddr(ctx, addr, opts)
 }
 
 // LookupString retrieves geolocation data for an IP address string.
+// Strings that are not IP addresses, such as "self", are passed to the
+// service as keys.
 func (c *Client) LookupString(ctx context.Context, ip string, opts ...resolvedb.RequestOption) (*Location, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return c.lookupOnline(ctx, ip, opts)
+	}
+	return c.lookupAddr(ctx, addr, opts)
+}
+
+// LookupNetwork retrieves geolocation data for a network. Host bits of
+// prefix are ignored.
+//
+// Example:
+//
+//	loc, err := geoClient.LookupNetwork(ctx, netip.MustParsePrefix("8.8.8.0/24"))
+func (c *Client) LookupNetwork(ctx context.Context, prefix netip.Prefix, opts ...resolvedb.RequestOption) (*Location, error) {
+	key := NetworkKey(prefix)
+	if key == "" {
+		return nil, fmt.Errorf("geoip: invalid network %v", prefix)
+	}
+	return c.lookupOnline(ctx, key, opts)
+}
+
+// lookupAddr looks up addr, using the offline database if configured.
+func (c *Client) lookupAddr(ctx context.Context, addr netip.Addr, opts []resolvedb.RequestOption) (*Location, error) {
+	if c.offline == nil {
+		return c.lookupOnline(ctx, AddrKey(addr), opts)
+	}
+	return c.lookupWithOffline(ctx, addr.Unmap().WithZone(""), opts)
+}
+
+// AddrKey returns the service key for addr, or "" if addr is invalid.
+// DNS labels cannot hold dots or colons, so the separators of an address
+// become dashes: IPv4 addresses, including IPv4-mapped IPv6 addresses, as
+// "8-8-8-8", and IPv6 addresses fully expanded, as
+// "2001-0db8-0000-0000-0000-0000-0000-0001". Zones are dropped.
+func AddrKey(addr netip.Addr) string {
+	if !addr.IsValid() {
+		return ""
+	}
+	addr = addr.Unmap().WithZone("")
+	if addr.Is4() {
+		return strings.ReplaceAll(addr.String(), ".", "-")
+	}
+	return strings.ReplaceAll(addr.StringExpanded(), ":", "-")
+}
+
+// NetworkKey returns the service key for a network, "net-" followed by
+// the key of its masked address and its prefix length, e.g.
+// "net-8-8-8-0-24". It returns "" if prefix is invalid.
+func NetworkKey(prefix netip.Prefix) string {
+	if !prefix.IsValid() {
+		return ""
+	}
+	bits := prefix.Bits()
+	addr := prefix.Addr()
+	if addr.Is4In6() {
+		// ::ffff:0:0/96 holds the IPv4 space
+		if bits < 96 {
+			return "net-" + AddrKey(prefix.Masked().Addr()) + "-" + strconv.Itoa(bits)
+		}
+		bits -= 96
+	}
+	addr = addr.Unmap().WithZone("")
+	masked, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return "net-" + AddrKey(masked.Addr()) + "-" + strconv.Itoa(bits)
+}
+
+// lookupOnline queries ResolveDB for key.
+func (c *Client) lookupOnline(ctx context.Context, key string, opts []resolvedb.RequestOption) (*Location, error) {
 	var loc Location
-	err := c.client.Get(ctx, "geoip", ip, &loc, opts...)
+	err := c.client.Get(ctx, "geoip", key, &loc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return &loc, nil
 }
 
+// lookupWithOffline looks up addr using the offline database in the
+// client's mode.
+func (c *Client) lookupWithOffline(ctx context.Context, addr netip.Addr, opts []resolvedb.RequestOption) (*Location, error) {
+	key := AddrKey(addr)
+	ip := net.IP(addr.AsSlice())
+	if c.mode == Hybrid {
+		if loc, ok := c.refreshedLocation(key); ok {
+			return loc, nil
+		}
+		if loc, err := c.offline.Lookup(ip); err == nil && loc != nil {
+			c.refresh(ctx, key, opts)
+			return loc, nil
+		}
+		loc, err := c.lookupOnline(ctx, key, opts)
+		if err == nil {
+			c.storeRefreshed(key, loc)
+		}
+		return loc, err
+	}
+
+	loc, err := c.lookupOnline(ctx, key, opts)
+	if err == nil || !unreachable(err) {
+		return loc, err
+	}
+	if offline, offErr := c.offline.Lookup(ip); offErr == nil && offline != nil {
+		return offline, nil
+	}
+	return nil, err
+}
+
+// unreachable reports whether err means ResolveDB could not be reached,
+// as opposed to an answer such as not found.
+func unreachable(err error) bool {
+	var terr *resolvedb.TransportError
+	return errors.As(err, &terr) || resolvedb.IsRetryable(err)
+}
+
+// refreshedLocation returns an unexpired hybrid-mode refresh for key.
+func (c *Client) refreshedLocation(key string) (*Location, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.refreshed[key]
+	if !ok || time.Now().After(r.expires) {
+		return nil, false
+	}
+	return r.loc, true
+}
+
+// storeRefreshed keeps a ResolveDB answer for key in hybrid mode.
+func (c *Client) storeRefreshed(key string, loc *Location) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.refreshed) >= maxRefreshed {
+		// Start over rather than track recency; refreshes are cheap
+		c.refreshed = make(map[string]refreshedLocation)
+	}
+	c.refreshed[key] = refreshedLocation{loc: loc, expires: time.Now().Add(c.refreshTTL)}
+}
+
+// refresh looks key up in ResolveDB in the background, at most once at a
+// time per key, detached from the caller's cancellation.
+func (c *Client) refresh(ctx context.Context, key string, opts []resolvedb.RequestOption) {
+	c.mu.Lock()
+	if c.refreshing[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	go func() {
+		loc, err := c.lookupOnline(context.WithoutCancel(ctx), key, opts)
+		if err == nil {
+			c.storeRefreshed(key, loc)
+		}
+		c.mu.Lock()
+		delete(c.refreshing, key)
+		c.mu.Unlock()
+	}()
+}
+
 // LookupSelf retrieves geolocation data for the client's IP address.
 func (c *Client) LookupSelf(ctx context.Context, opts ...resolvedb.RequestOption) (*Location, error) {
 	return c.LookupString(ctx, "self", opts...)
@@ -0,0 +1,399 @@
+// Package ota provides firmware distribution over ResolveDB: signed
+// release metadata, firmware images stored as blocks, update checks by
+// semantic version, and verified, resumable downloads.
+//
+// Images are stored as blocks written with Set, so both publishers and
+// devices must use a client created with resolvedb.WithAutoCodec, which
+// compresses and chunks each block to fit in queries.
+package ota
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/security"
+)
+
+// Resources holding release metadata and image blocks. Blocks live apart
+// from releases so that listing releases never sees block keys.
+const (
+	resourceReleases = "firmware"
+	resourceBlocks   = "firmware-blocks"
+)
+
+const (
+	defaultBlockSize   = 16 << 10
+	defaultConcurrency = 4
+)
+
+var (
+	// ErrUnsigned is returned when a release has no signature but the
+	// client was created with WithVerifyKey.
+	ErrUnsigned = errors.New("ota: release is not signed")
+
+	// ErrBadSignature is returned when a release signature does not verify.
+	ErrBadSignature = errors.New("ota: release signature is invalid")
+
+	// ErrReadOnly is returned by Publish when the client cannot write.
+	ErrReadOnly = errors.New("ota: client cannot write")
+)
+
+// OTAClient defines the interface for OTA operations.
+// Implement this interface for testing with mocks.
+type OTAClient interface {
+	CheckUpdate(ctx context.Context, device, currentVersion string, opts ...resolvedb.RequestOption) (*Release, error)
+	GetRelease(ctx context.Context, device, version string, opts ...resolvedb.RequestOption) (*Release, error)
+	Download(ctx context.Context, release *Release, dst io.WriterAt, opts ...DownloadOption) error
+	Publish(ctx context.Context, device, version string, image []byte, opts ...resolvedb.RequestOption) (*Release, error)
+}
+
+// Client is an OTA service client.
+type Client struct {
+	client     resolvedb.Querier
+	signingKey ed25519.PrivateKey
+	verifyKey  ed25519.PublicKey
+	blockSize  int
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithSigningKey signs releases written by Publish.
+func WithSigningKey(key ed25519.PrivateKey) Option {
+	return func(c *Client) {
+		c.signingKey = key
+	}
+}
+
+// WithVerifyKey requires releases to be signed by the matching private key
+// before they are downloaded. Without it, downloads are checked against
+// the release's SHA-256 only, which detects corruption but not tampering.
+func WithVerifyKey(key ed25519.PublicKey) Option {
+	return func(c *Client) {
+		c.verifyKey = key
+	}
+}
+
+// WithBlockSize sets the size of the blocks Publish splits images into
+// (default 16 KiB). Downloads resume at block boundaries.
+func WithBlockSize(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.blockSize = n
+		}
+	}
+}
+
+// NewClient creates a new OTA client. Publishing requires c to also
+// implement resolvedb.Writer.
+func NewClient(c resolvedb.Querier, opts ...Option) *Client {
+	client := &Client{client: c, blockSize: defaultBlockSize}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// Ensure Client implements OTAClient.
+var _ OTAClient = (*Client)(nil)
+
+// Release describes a published firmware image.
+type Release struct {
+	Device    string    `json:"device"`
+	Version   string    `json:"version"`
+	Size      int64     `json:"size"`
+	BlockSize int       `json:"block_size"`
+	Blocks    int       `json:"blocks"`
+	SHA256    string    `json:"sha256"`              // Hex SHA-256 of the image
+	Signature []byte    `json:"signature,omitempty"` // Ed25519 signature of the release digest
+	Published time.Time `json:"published"`
+}
+
+// Key returns the key of the release's metadata, e.g. "sensor-v2-3-1".
+func (r *Release) Key() (string, error) {
+	v, err := resolvedb.ParseVersion(r.Version)
+	if err != nil {
+		return "", err
+	}
+	return resolvedb.VersionKey(r.Device, v), nil
+}
+
+// digest returns the bytes a release signature covers.
+func (r *Release) digest() []byte {
+	var b bytes.Buffer
+	b.WriteString("resolvedb-ota-v1\n")
+	b.WriteString(r.Device + "\n" + r.Version + "\n" + r.SHA256 + "\n")
+	binary.Write(&b, binary.BigEndian, r.Size)
+	return security.SHA256(b.Bytes())
+}
+
+// Verify checks the release signature against key.
+func (r *Release) Verify(key ed25519.PublicKey) error {
+	if len(r.Signature) == 0 {
+		return ErrUnsigned
+	}
+	if !ed25519.Verify(key, r.digest(), r.Signature) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// CheckUpdate returns the newest release for device above currentVersion,
+// or nil if the device is up to date. Pre-releases are only offered to
+// devices already running one.
+//
+// Example:
+//
+//	rel, err := otaClient.CheckUpdate(ctx, "sensor", "2.3.1")
+//	if err != nil || rel == nil {
+//	    return err
+//	}
+//	err = otaClient.Download(ctx, rel, file)
+func (c *Client) CheckUpdate(ctx context.Context, device, currentVersion string, opts ...resolvedb.RequestOption) (*Release, error) {
+	current, err := resolvedb.ParseVersion(currentVersion)
+	if err != nil {
+		return nil, err
+	}
+	key, _, err := resolvedb.ResolveKeyVersion(ctx, c.client, resourceReleases, device, ">"+current.String(), opts...)
+	if err != nil {
+		if resolvedb.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return c.getRelease(ctx, key, opts)
+}
+
+// GetRelease retrieves the release of version for device.
+func (c *Client) GetRelease(ctx context.Context, device, version string, opts ...resolvedb.RequestOption) (*Release, error) {
+	key, err := (&Release{Device: device, Version: version}).Key()
+	if err != nil {
+		return nil, err
+	}
+	return c.getRelease(ctx, key, opts)
+}
+
+func (c *Client) getRelease(ctx context.Context, key string, opts []resolvedb.RequestOption) (*Release, error) {
+	var rel Release
+	if err := c.client.Get(ctx, resourceReleases, key, &rel, opts...); err != nil {
+		return nil, err
+	}
+	if rel.Blocks < 0 || rel.BlockSize <= 0 || rel.Size < 0 || int64(rel.Blocks) != (rel.Size+int64(rel.BlockSize)-1)/int64(rel.BlockSize) {
+		return nil, fmt.Errorf("ota: release %s has an invalid block layout", key)
+	}
+	return &rel, nil
+}
+
+// blockKey returns the key of block i of the release stored at key.
+func blockKey(key string, i int) string {
+	return key + "-b" + strconv.Itoa(i)
+}
+
+// Publish splits image into blocks, writes them, and then writes the
+// release metadata, signed if the client has a signing key. Metadata is
+// written last, so devices never see a release whose blocks are missing.
+func (c *Client) Publish(ctx context.Context, device, version string, image []byte, opts ...resolvedb.RequestOption) (*Release, error) {
+	w, ok := c.client.(resolvedb.Writer)
+	if !ok {
+		return nil, ErrReadOnly
+	}
+
+	sum := sha256.Sum256(image)
+	rel := &Release{
+		Device:    device,
+		Version:   version,
+		Size:      int64(len(image)),
+		BlockSize: c.blockSize,
+		Blocks:    (len(image) + c.blockSize - 1) / c.blockSize,
+		SHA256:    hex.EncodeToString(sum[:]),
+		Published: time.Now().UTC(),
+	}
+	key, err := rel.Key()
+	if err != nil {
+		return nil, err
+	}
+	if c.signingKey != nil {
+		rel.Signature = ed25519.Sign(c.signingKey, rel.digest())
+	}
+
+	for i := 0; i < rel.Blocks; i++ {
+		block := image[i*c.blockSize : min(len(image), (i+1)*c.blockSize)]
+		if err := w.Set(ctx, resourceBlocks, blockKey(key, i), block, opts...); err != nil {
+			return nil, fmt.Errorf("ota: write block %d of %d: %w", i+1, rel.Blocks, err)
+		}
+	}
+	if err := w.Set(ctx, resourceReleases, key, rel, opts...); err != nil {
+		return nil, err
+	}
+	return rel, nil
+}
+
+// DownloadOption configures Download.
+type DownloadOption func(*downloadConfig)
+
+type downloadConfig struct {
+	resumeFrom  int64
+	concurrency int
+	progress    func(done, total int64)
+	reqOpts     []resolvedb.RequestOption
+}
+
+// ResumeFrom resumes a download whose first n bytes were already written
+// to dst. Blocks that are only partly written are fetched again; the
+// existing bytes are still covered by the final SHA-256 check, so dst must
+// also implement io.ReaderAt.
+func ResumeFrom(n int64) DownloadOption {
+	return func(c *downloadConfig) {
+		c.resumeFrom = n
+	}
+}
+
+// WithDownloadConcurrency sets how many blocks are fetched at once
+// (default 4).
+func WithDownloadConcurrency(n int) DownloadOption {
+	return func(c *downloadConfig) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithDownloadProgress calls fn with the bytes written so far, including
+// resumed ones, after each block. Calls are serialized.
+func WithDownloadProgress(fn func(done, total int64)) DownloadOption {
+	return func(c *downloadConfig) {
+		c.progress = fn
+	}
+}
+
+// WithDownloadRequestOptions applies opts to every block lookup.
+func WithDownloadRequestOptions(opts ...resolvedb.RequestOption) DownloadOption {
+	return func(c *downloadConfig) {
+		c.reqOpts = append(c.reqOpts, opts...)
+	}
+}
+
+// Download writes the release image to dst. The release signature is
+// checked before any block is fetched (when the client has a verify key),
+// and the image's SHA-256 after the last one; on a mismatch the error
+// wraps resolvedb.ErrChunkIntegrity and dst must not be installed.
+//
+// Blocks are written at their offsets as they arrive, so an interrupted
+// download can continue with ResumeFrom. dst must implement io.ReaderAt
+// for the final check; *os.File does.
+//
+// Example:
+//
+//	f, _ := os.OpenFile("update.bin", os.O_RDWR|os.O_CREATE, 0o600)
+//	info, _ := f.Stat()
+//	err := otaClient.Download(ctx, rel, f, ota.ResumeFrom(info.Size()))
+func (c *Client) Download(ctx context.Context, release *Release, dst io.WriterAt, opts ...DownloadOption) error {
+	cfg := downloadConfig{concurrency: defaultConcurrency}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if c.verifyKey != nil {
+		if err := release.Verify(c.verifyKey); err != nil {
+			return err
+		}
+	}
+	src, ok := dst.(io.ReaderAt)
+	if !ok {
+		return errors.New("ota: download destination must implement io.ReaderAt")
+	}
+	key, err := release.Key()
+	if err != nil {
+		return err
+	}
+
+	first := int(max(cfg.resumeFrom, 0) / int64(release.BlockSize))
+	if err := c.fetchBlocks(ctx, release, key, first, dst, &cfg); err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(src, 0, release.Size)); err != nil {
+		return fmt.Errorf("ota: hash image: %w", err)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != release.SHA256 {
+		return fmt.Errorf("ota: image %s does not match its release hash: %w", key, resolvedb.ErrChunkIntegrity)
+	}
+	return nil
+}
+
+// fetchBlocks fetches blocks first onwards into dst.
+func (c *Client) fetchBlocks(ctx context.Context, release *Release, key string, first int, dst io.WriterAt, cfg *downloadConfig) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		done     = int64(first) * int64(release.BlockSize)
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	slots := make(chan struct{}, cfg.concurrency)
+	for i := first; i < release.Blocks; i++ {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			var block []byte
+			if err := c.client.Get(ctx, resourceBlocks, blockKey(key, i), &block, cfg.reqOpts...); err != nil {
+				fail(fmt.Errorf("ota: read block %d of %d: %w", i+1, release.Blocks, err))
+				return
+			}
+			want := min(int64(release.BlockSize), release.Size-int64(i)*int64(release.BlockSize))
+			if int64(len(block)) != want {
+				fail(fmt.Errorf("ota: block %d of %d has %d bytes, want %d: %w", i+1, release.Blocks, len(block), want, resolvedb.ErrChunkIntegrity))
+				return
+			}
+			if _, err := dst.WriteAt(block, int64(i)*int64(release.BlockSize)); err != nil {
+				fail(fmt.Errorf("ota: write block %d of %d: %w", i+1, release.Blocks, err))
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			done += int64(len(block))
+			if cfg.progress != nil {
+				cfg.progress(done, release.Size)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
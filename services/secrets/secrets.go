@@ -0,0 +1,249 @@
+// Package secrets provides a client for secrets stored encrypted in
+// ResolveDB, with versioning and scheduled rotation.
+package secrets
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// resource is the resource holding secrets.
+const resource = "secrets"
+
+// defaultRetain is the default number of previous versions kept.
+const defaultRetain = 1
+
+// Store is the subset of resolvedb.Client used by the secrets client.
+type Store interface {
+	resolvedb.EncryptedQuerier
+	resolvedb.EncryptedWriter
+}
+
+// SecretsClient defines the interface for Secrets operations.
+// Implement this interface for testing with mocks.
+type SecretsClient interface {
+	Get(ctx context.Context, name string, opts ...resolvedb.RequestOption) (*Secret, error)
+	Set(ctx context.Context, name, value string, opts ...resolvedb.RequestOption) (*Secret, error)
+	RotateEvery(ctx context.Context, name string, d time.Duration, gen Generator, opts ...RotateOption) *Rotator
+}
+
+// Client is a Secrets service client.
+type Client struct {
+	client Store
+	retain int
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithRetainVersions sets how many previous versions of a secret are kept
+// alongside the current one (default 1), so values issued before a
+// rotation can still be verified. Negative values are treated as zero.
+func WithRetainVersions(n int) Option {
+	return func(c *Client) {
+		c.retain = max(n, 0)
+	}
+}
+
+// NewClient creates a new Secrets client. The client must be configured
+// with an encryption key.
+func NewClient(c Store, opts ...Option) *Client {
+	client := &Client{client: c, retain: defaultRetain}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// Ensure Client implements SecretsClient.
+var _ SecretsClient = (*Client)(nil)
+
+// Secret represents a secret and its retained previous versions.
+type Secret struct {
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Previous  []Version `json:"previous,omitempty"` // Newest first
+}
+
+// Version is a previous value of a secret.
+type Version struct {
+	Value     string    `json:"value"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Values returns the current value followed by the retained previous
+// values, newest first.
+func (s *Secret) Values() []string {
+	values := make([]string, 0, 1+len(s.Previous))
+	values = append(values, s.Value)
+	for _, v := range s.Previous {
+		values = append(values, v.Value)
+	}
+	return values
+}
+
+// Get retrieves and decrypts a secret.
+func (c *Client) Get(ctx context.Context, name string, opts ...resolvedb.RequestOption) (*Secret, error) {
+	var s Secret
+	if err := c.client.GetEncrypted(ctx, resource, name, &s, opts...); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Set writes value as the next version of a secret, keeping the retained
+// number of previous versions, and returns the stored secret.
+func (c *Client) Set(ctx context.Context, name, value string, opts ...resolvedb.RequestOption) (*Secret, error) {
+	next := &Secret{Name: name, Value: value, Version: 1, CreatedAt: time.Now().UTC()}
+
+	cur, err := c.Get(ctx, name, opts...)
+	switch {
+	case err == nil:
+		next.Version = cur.Version + 1
+		if c.retain > 0 {
+			prev := Version{Value: cur.Value, Version: cur.Version, CreatedAt: cur.CreatedAt}
+			next.Previous = append([]Version{prev}, cur.Previous...)
+			next.Previous = next.Previous[:min(len(next.Previous), c.retain)]
+		}
+	case !resolvedb.IsNotFound(err):
+		return nil, err
+	}
+
+	if err := c.client.SetEncrypted(ctx, resource, name, next, opts...); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// Generator produces a new secret value for rotation.
+type Generator func(ctx context.Context) (string, error)
+
+// RandomHex returns a Generator of n random bytes, hex encoded.
+func RandomHex(n int) Generator {
+	return func(ctx context.Context) (string, error) {
+		b := make([]byte, n)
+		if _, err := rand.Read(b); err != nil {
+			return "", fmt.Errorf("secrets: generate: %w", err)
+		}
+		return hex.EncodeToString(b), nil
+	}
+}
+
+// maxRetryDelay bounds the delay before retrying a failed rotation.
+const maxRetryDelay = time.Minute
+
+// RotateOption configures a Rotator.
+type RotateOption func(*Rotator)
+
+// OnRotate calls fn with the new secret after each rotation. fn is called
+// from the rotation goroutine and should not block.
+func OnRotate(fn func(*Secret)) RotateOption {
+	return func(r *Rotator) {
+		r.onRotate = append(r.onRotate, fn)
+	}
+}
+
+// OnRotateError calls fn when a rotation fails. The rotation is retried
+// after d, bounded to a minute.
+func OnRotateError(fn func(error)) RotateOption {
+	return func(r *Rotator) {
+		r.onError = append(r.onError, fn)
+	}
+}
+
+// Rotator rotates a secret on a schedule. Create one with RotateEvery.
+type Rotator struct {
+	onRotate []func(*Secret)
+	onError  []func(error)
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// RotateEvery rotates the named secret every d with values from gen,
+// until ctx is done or Stop is called. Each rotation writes a new version
+// with Set, keeping the retained previous versions, and then notifies the
+// OnRotate watchers; other processes see the change through
+// resolvedb.Client.Watch on the "secrets" resource.
+//
+// The schedule follows the current version's creation time, so a
+// restarted process does not rotate early. A missing secret, or one older
+// than d, is rotated immediately. Run a single rotator per secret.
+//
+// Example:
+//
+//	r := secretClient.RotateEvery(ctx, "webhook-signing", 24*time.Hour, secrets.RandomHex(32),
+//	    secrets.OnRotate(func(s *secrets.Secret) { signer.SetKeys(s.Values()) }),
+//	    secrets.OnRotateError(func(err error) { log.Printf("rotate: %v", err) }),
+//	)
+//	defer r.Stop()
+func (c *Client) RotateEvery(ctx context.Context, name string, d time.Duration, gen Generator, opts ...RotateOption) *Rotator {
+	ctx, cancel := context.WithCancel(ctx)
+	r := &Rotator{cancel: cancel, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(r)
+	}
+	go r.run(ctx, c, name, d, gen)
+	return r
+}
+
+// Stop stops the rotator and waits for an in-flight rotation to finish.
+func (r *Rotator) Stop() {
+	r.cancel()
+	<-r.done
+}
+
+func (r *Rotator) run(ctx context.Context, c *Client, name string, d time.Duration, gen Generator) {
+	defer close(r.done)
+
+	wait := time.Duration(0)
+	if cur, err := c.Get(ctx, name); err == nil {
+		wait = max(time.Until(cur.CreatedAt.Add(d)), 0)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		s, err := r.rotate(ctx, c, name, gen)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			for _, fn := range r.onError {
+				_ = resolvedb.SafeCall(c.client, "secrets rotation error callback", func() { fn(err) })
+			}
+			timer.Reset(min(d, maxRetryDelay))
+			continue
+		}
+		for _, fn := range r.onRotate {
+			_ = resolvedb.SafeCall(c.client, "secrets rotation callback", func() { fn(s) })
+		}
+		timer.Reset(d)
+	}
+}
+
+func (r *Rotator) rotate(ctx context.Context, c *Client, name string, gen Generator) (*Secret, error) {
+	value, err := gen(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s, err := c.Set(ctx, name, value)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: rotate %q: %w", name, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,45 @@
+package secrets_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/resolvedbtest"
+	"github.com/resolvedb/resolvedb-go/services/secrets"
+)
+
+func TestSetAndGet(t *testing.T) {
+	ctx := context.Background()
+	client, err := resolvedb.New(
+		resolvedb.WithTransports(resolvedbtest.NewServer()),
+		resolvedb.WithCache(resolvedb.CacheConfig{}),
+		resolvedb.WithRetry(resolvedb.RetryConfig{}),
+		resolvedb.WithAPIKey("test-key"),
+		resolvedb.WithEncryptionKey(bytes.Repeat([]byte{7}, 32)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	s := secrets.NewClient(client)
+
+	if _, err := s.Set(ctx, "db-password", "correct horse battery staple"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Set(ctx, "db-password", "tr0ub4dor&3"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Get(ctx, "db-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Value != "tr0ub4dor&3" || got.Version != 2 {
+		t.Fatalf("Get = %q version %d, want tr0ub4dor&3 version 2", got.Value, got.Version)
+	}
+	if vals := got.Values(); len(vals) != 2 || vals[1] != "correct horse battery staple" {
+		t.Fatalf("Values = %q, want the previous version retained", vals)
+	}
+}
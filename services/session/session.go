@@ -0,0 +1,348 @@
+// Package session provides an HTTP session store backed by ResolveDB.
+// Session data is encrypted by default and expires server-side after a
+// period of inactivity. The cookie only carries a random session ID; the
+// stored key is derived from a hash of it, so listing the resource does
+// not reveal live session IDs.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/rdbtypes"
+	"github.com/resolvedb/resolvedb-go/security"
+)
+
+const (
+	defaultResource   = "sessions"
+	defaultCookieName = "session"
+	defaultMaxAge     = 24 * time.Hour
+
+	// idBytes is the entropy of a session ID.
+	idBytes = 20
+)
+
+// idEncoding encodes session IDs as lowercase base32 without padding.
+var idEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// Backend is the subset of resolvedb.Client used by the store.
+type Backend interface {
+	resolvedb.ReadWriter
+	resolvedb.EncryptedQuerier
+	resolvedb.EncryptedWriter
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithResource sets the resource sessions are stored in (default
+// "sessions").
+func WithResource(resource string) Option {
+	return func(s *Store) {
+		s.resource = resource
+	}
+}
+
+// WithMaxAge sets how long a session lives after it was last saved
+// (default 24h). The cookie and the stored key expire together.
+func WithMaxAge(d time.Duration) Option {
+	return func(s *Store) {
+		if d > 0 {
+			s.maxAge = d
+		}
+	}
+}
+
+// WithCookie sets the template for session cookies. Name, Path, Domain,
+// Secure, HttpOnly and SameSite are copied; Value, MaxAge and Expires are
+// set by the store. The default is an HttpOnly, Secure, SameSite=Lax
+// cookie named "session" on path "/".
+func WithCookie(c http.Cookie) Option {
+	return func(s *Store) {
+		s.cookie = c
+	}
+}
+
+// WithoutEncryption stores session data in plain text. Only use it when
+// the client cannot be configured with an encryption key and the data
+// holds nothing sensitive.
+func WithoutEncryption() Option {
+	return func(s *Store) {
+		s.plaintext = true
+	}
+}
+
+// WithRequestOptions applies opts to every lookup and write.
+func WithRequestOptions(opts ...resolvedb.RequestOption) Option {
+	return func(s *Store) {
+		s.reqOpts = append(s.reqOpts, opts...)
+	}
+}
+
+// Store loads and saves sessions for HTTP requests. It is safe for
+// concurrent use.
+type Store struct {
+	client    Backend
+	resource  string
+	maxAge    time.Duration
+	cookie    http.Cookie
+	plaintext bool
+	reqOpts   []resolvedb.RequestOption
+}
+
+// NewStore creates a session store. Unless WithoutEncryption is given,
+// the client must be configured with an encryption key.
+//
+// Example:
+//
+//	client, _ := resolvedb.New(resolvedb.WithEncryptionKey(key))
+//	store := session.NewStore(client, session.WithMaxAge(8*time.Hour))
+//
+//	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+//	    sess, err := store.Get(r)
+//	    if err != nil {
+//	        http.Error(w, "session unavailable", http.StatusInternalServerError)
+//	        return
+//	    }
+//	    sess.Values["visits"] = sess.GetInt("visits") + 1
+//	    if err := store.Save(w, r, sess); err != nil {
+//	        http.Error(w, "session unavailable", http.StatusInternalServerError)
+//	        return
+//	    }
+//	    fmt.Fprintf(w, "visit %d", sess.GetInt("visits"))
+//	})
+func NewStore(c Backend, opts ...Option) *Store {
+	s := &Store{
+		client:   c,
+		resource: defaultResource,
+		maxAge:   defaultMaxAge,
+		cookie: http.Cookie{
+			Name:     defaultCookieName,
+			Path:     "/",
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Session is the data of one browser session. Values round-trip through
+// JSON, so numbers read back as float64; use the typed accessors to read
+// them.
+type Session struct {
+	ID        string
+	Values    map[string]any
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	IsNew     bool // Whether the session was created by this request
+
+	oldID string // ID replaced by Regenerate, deleted on Save
+}
+
+// record is the stored form of a session.
+type record struct {
+	Values    map[string]any `json:"values"`
+	CreatedAt rdbtypes.Time  `json:"created_at"`
+	ExpiresAt rdbtypes.Time  `json:"expires_at"`
+}
+
+// Get returns the session named by the request's cookie, or a new empty
+// session if there is no cookie or the session has expired or been
+// destroyed. Errors are only returned when the lookup itself fails.
+func (s *Store) Get(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(s.cookie.Name)
+	if err != nil || !validID(cookie.Value) {
+		return s.New()
+	}
+
+	var rec record
+	if err := s.get(r.Context(), cookie.Value, &rec); err != nil {
+		if resolvedb.IsNotFound(err) {
+			return s.New()
+		}
+		return nil, fmt.Errorf("session: load: %w", err)
+	}
+	if time.Now().After(rec.ExpiresAt.Time) {
+		return s.New()
+	}
+	if rec.Values == nil {
+		rec.Values = map[string]any{}
+	}
+	return &Session{
+		ID:        cookie.Value,
+		Values:    rec.Values,
+		CreatedAt: rec.CreatedAt.Time,
+		ExpiresAt: rec.ExpiresAt.Time,
+	}, nil
+}
+
+// New returns a new empty session with a fresh ID. It is not stored until
+// it is saved.
+func (s *Store) New() (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	return &Session{
+		ID:        id,
+		Values:    map[string]any{},
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.maxAge),
+		IsNew:     true,
+	}, nil
+}
+
+// Save stores the session, extends its lifetime by the store's max age and
+// sets the session cookie. Call it before writing the response body.
+func (s *Store) Save(w http.ResponseWriter, r *http.Request, sess *Session) error {
+	ctx := r.Context()
+	sess.ExpiresAt = time.Now().UTC().Add(s.maxAge)
+	rec := record{Values: sess.Values, CreatedAt: rdbtypes.NewTime(sess.CreatedAt), ExpiresAt: rdbtypes.NewTime(sess.ExpiresAt)}
+	if err := s.set(ctx, sess.ID, rec); err != nil {
+		return fmt.Errorf("session: save: %w", err)
+	}
+	if sess.oldID != "" {
+		if err := s.delete(ctx, sess.oldID); err != nil {
+			return fmt.Errorf("session: delete previous session: %w", err)
+		}
+		sess.oldID = ""
+	}
+
+	cookie := s.newCookie(sess.ID)
+	cookie.MaxAge = int(s.maxAge / time.Second)
+	cookie.Expires = sess.ExpiresAt
+	http.SetCookie(w, cookie)
+	return nil
+}
+
+// Destroy deletes the session and expires its cookie.
+func (s *Store) Destroy(w http.ResponseWriter, r *http.Request, sess *Session) error {
+	ctx := r.Context()
+	for _, id := range []string{sess.ID, sess.oldID} {
+		if id == "" {
+			continue
+		}
+		if err := s.delete(ctx, id); err != nil {
+			return fmt.Errorf("session: destroy: %w", err)
+		}
+	}
+	sess.oldID = ""
+	sess.Values = map[string]any{}
+
+	cookie := s.newCookie("")
+	cookie.MaxAge = -1
+	http.SetCookie(w, cookie)
+	return nil
+}
+
+// Regenerate gives the session a new ID, keeping its values. The old
+// session is deleted on the next Save. Call it when a user signs in or
+// their privileges change, to prevent session fixation.
+func (sess *Session) Regenerate() error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	if !sess.IsNew && sess.oldID == "" {
+		sess.oldID = sess.ID
+	}
+	sess.ID = id
+	return nil
+}
+
+// GetString returns the string value of key, or "" if it is missing or not a
+// string.
+func (sess *Session) GetString(key string) string {
+	v, _ := sess.Values[key].(string)
+	return v
+}
+
+// GetInt returns the integer value of key, or 0 if it is missing or not a
+// number.
+func (sess *Session) GetInt(key string) int {
+	switch v := sess.Values[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// GetBool returns the boolean value of key, or false if it is missing or not
+// a boolean.
+func (sess *Session) GetBool(key string) bool {
+	v, _ := sess.Values[key].(bool)
+	return v
+}
+
+func (s *Store) newCookie(value string) *http.Cookie {
+	c := s.cookie
+	c.Value = value
+	c.MaxAge = 0
+	c.Expires = time.Time{}
+	return &c
+}
+
+func (s *Store) get(ctx context.Context, id string, dst *record) error {
+	// Sessions may be destroyed by another instance, so skip the cache
+	opts := append(append([]resolvedb.RequestOption(nil), s.reqOpts...), resolvedb.WithSkipCache())
+	if s.plaintext {
+		return s.client.Get(ctx, s.resource, storageKey(id), dst, opts...)
+	}
+	return s.client.GetEncrypted(ctx, s.resource, storageKey(id), dst, opts...)
+}
+
+func (s *Store) set(ctx context.Context, id string, rec record) error {
+	opts := append(append([]resolvedb.RequestOption(nil), s.reqOpts...), resolvedb.WithExpiry(s.maxAge))
+	if s.plaintext {
+		return s.client.Set(ctx, s.resource, storageKey(id), rec, opts...)
+	}
+	return s.client.SetEncrypted(ctx, s.resource, storageKey(id), rec, opts...)
+}
+
+func (s *Store) delete(ctx context.Context, id string) error {
+	opts := s.reqOpts
+	if !s.plaintext {
+		// Large records are stored in chunks, deleted with the record
+		opts = append(opts[:len(opts):len(opts)], resolvedb.WithEncrypt())
+	}
+	err := s.client.Delete(ctx, s.resource, storageKey(id), opts...)
+	if resolvedb.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// newID returns a random session ID.
+func newID() (string, error) {
+	b := make([]byte, idBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("session: generate id: %w", err)
+	}
+	return idEncoding.EncodeToString(b), nil
+}
+
+// validID reports whether id has the form of a session ID.
+func validID(id string) bool {
+	b, err := idEncoding.DecodeString(id)
+	return err == nil && len(b) == idBytes
+}
+
+// storageKey derives the stored key from a session ID.
+func storageKey(id string) string {
+	return hex.EncodeToString(security.SHA256([]byte(id))[:16])
+}
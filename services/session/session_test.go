@@ -0,0 +1,67 @@
+package session_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/resolvedbtest"
+	"github.com/resolvedb/resolvedb-go/services/session"
+)
+
+func TestSaveAndGet(t *testing.T) {
+	srv := resolvedbtest.NewServer()
+	client, err := resolvedb.New(
+		resolvedb.WithTransports(srv),
+		resolvedb.WithCache(resolvedb.CacheConfig{}),
+		resolvedb.WithRetry(resolvedb.RetryConfig{}),
+		resolvedb.WithAPIKey("test-key"),
+		resolvedb.WithEncryptionKey(bytes.Repeat([]byte{7}, 32)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	store := session.NewStore(client)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	sess, err := store.Get(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess.Values["user"] = "ada@example.com"
+	sess.Values["visits"] = 3
+	w := httptest.NewRecorder()
+	if err := store.Save(w, r, sess); err != nil {
+		t.Fatal(err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range w.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	got, err := store.Get(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.IsNew || got.ID != sess.ID {
+		t.Fatalf("Get returned a new session, want %s", sess.ID)
+	}
+	if got.GetString("user") != "ada@example.com" || got.GetInt("visits") != 3 {
+		t.Fatalf("Values = %v, want the saved values", got.Values)
+	}
+
+	if len(srv.Keys("public", "rdb-chunks")) == 0 {
+		t.Fatal("record was not stored in chunks")
+	}
+	if err := store.Destroy(httptest.NewRecorder(), r, got); err != nil {
+		t.Fatal(err)
+	}
+	for _, resource := range []string{"sessions", "rdb-chunks"} {
+		if keys := srv.Keys("public", resource); len(keys) != 0 {
+			t.Fatalf("%s left after Destroy: %v", resource, keys)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+// Package shadow maintains device shadows on ResolveDB: a desired state
+// document written by applications and a reported state document written
+// by the device, with the difference between them driving reconciliation.
+package shadow
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// Resources holding the two state documents, keyed by device.
+const (
+	resourceDesired  = "shadow-desired"
+	resourceReported = "shadow-reported"
+)
+
+// defaultPollInterval is how often Reconcile polls desired state when the
+// client cannot watch keys.
+const defaultPollInterval = 5 * time.Second
+
+// ShadowClient defines the interface for Shadow operations.
+// Implement this interface for testing with mocks.
+type ShadowClient interface {
+	Get(ctx context.Context, device string, opts ...resolvedb.RequestOption) (*Shadow, error)
+	GetDesired(ctx context.Context, device string, opts ...resolvedb.RequestOption) (*State, error)
+	GetReported(ctx context.Context, device string, opts ...resolvedb.RequestOption) (*State, error)
+	UpdateDesired(ctx context.Context, device string, patch map[string]any, opts ...resolvedb.RequestOption) (*State, error)
+	UpdateReported(ctx context.Context, device string, patch map[string]any, opts ...resolvedb.RequestOption) (*State, error)
+	Reconcile(ctx context.Context, device string, handler Handler, opts ...ReconcileOption) error
+}
+
+// Client is a Shadow service client.
+type Client struct {
+	client resolvedb.ReadWriter
+}
+
+// NewClient creates a new Shadow client.
+func NewClient(c resolvedb.ReadWriter) *Client {
+	return &Client{client: c}
+}
+
+// Ensure Client implements ShadowClient.
+var _ ShadowClient = (*Client)(nil)
+
+// State is a versioned state document.
+type State struct {
+	State     map[string]any `json:"state"`
+	Version   int            `json:"version"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// Shadow is a device's desired and reported state, and the delta between
+// them.
+type Shadow struct {
+	Device   string
+	Desired  *State
+	Reported *State
+	Delta    map[string]any // Desired values the device has not reported
+}
+
+// Get retrieves both state documents of a device and their delta. A
+// document that does not exist yet is returned empty.
+func (c *Client) Get(ctx context.Context, device string, opts ...resolvedb.RequestOption) (*Shadow, error) {
+	desired, err := c.GetDesired(ctx, device, opts...)
+	if err != nil {
+		return nil, err
+	}
+	reported, err := c.GetReported(ctx, device, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Shadow{
+		Device:   device,
+		Desired:  desired,
+		Reported: reported,
+		Delta:    Diff(desired.State, reported.State),
+	}, nil
+}
+
+// GetDesired retrieves a device's desired state.
+func (c *Client) GetDesired(ctx context.Context, device string, opts ...resolvedb.RequestOption) (*State, error) {
+	return c.getState(ctx, resourceDesired, device, opts)
+}
+
+// GetReported retrieves a device's reported state.
+func (c *Client) GetReported(ctx context.Context, device string, opts ...resolvedb.RequestOption) (*State, error) {
+	return c.getState(ctx, resourceReported, device, opts)
+}
+
+func (c *Client) getState(ctx context.Context, resource, device string, opts []resolvedb.RequestOption) (*State, error) {
+	var s State
+	if err := c.client.Get(ctx, resource, device, &s, opts...); err != nil {
+		if resolvedb.IsNotFound(err) {
+			return &State{State: map[string]any{}}, nil
+		}
+		return nil, err
+	}
+	if s.State == nil {
+		s.State = map[string]any{}
+	}
+	return &s, nil
+}
+
+// UpdateDesired merges patch into a device's desired state, following
+// RFC 7396: nested objects are merged and nil values delete keys.
+//
+// Example:
+//
+//	_, err := shadowClient.UpdateDesired(ctx, "thermostat-42", map[string]any{
+//	    "target_temp": 21.5,
+//	    "schedule":    map[string]any{"night": 17},
+//	})
+func (c *Client) UpdateDesired(ctx context.Context, device string, patch map[string]any, opts ...resolvedb.RequestOption) (*State, error) {
+	return c.updateState(ctx, resourceDesired, device, patch, opts)
+}
+
+// UpdateReported merges patch into a device's reported state, like
+// UpdateDesired.
+func (c *Client) UpdateReported(ctx context.Context, device string, patch map[string]any, opts ...resolvedb.RequestOption) (*State, error) {
+	return c.updateState(ctx, resourceReported, device, patch, opts)
+}
+
+// updateState reads, merges and writes a state document. Each document
+// should have a single writer, since the update is not atomic.
+func (c *Client) updateState(ctx context.Context, resource, device string, patch map[string]any, opts []resolvedb.RequestOption) (*State, error) {
+	fresh := append(append([]resolvedb.RequestOption(nil), opts...), resolvedb.WithRefreshCache())
+	cur, err := c.getState(ctx, resource, device, fresh)
+	if err != nil {
+		return nil, err
+	}
+	next := &State{
+		State:     mergePatch(cur.State, patch),
+		Version:   cur.Version + 1,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := c.client.Set(ctx, resource, device, next, opts...); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// Diff returns the entries of desired that reported does not match.
+// Nested objects are compared key by key, so the delta only holds the
+// leaves that differ; keys only present in reported are ignored.
+func Diff(desired, reported map[string]any) map[string]any {
+	delta := map[string]any{}
+	for k, want := range desired {
+		have, ok := reported[k]
+		wantMap, wantIsMap := want.(map[string]any)
+		haveMap, haveIsMap := have.(map[string]any)
+		switch {
+		case wantIsMap && haveIsMap:
+			if sub := Diff(wantMap, haveMap); len(sub) > 0 {
+				delta[k] = sub
+			}
+		case !ok || !jsonEqual(want, have):
+			delta[k] = want
+		}
+	}
+	return delta
+}
+
+// jsonEqual reports whether a and b encode to the same JSON, so that
+// values decoded from JSON compare equal to the Go values they came from.
+func jsonEqual(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// mergePatch returns doc with patch applied per RFC 7396. doc is not
+// modified.
+func mergePatch(doc, patch map[string]any) map[string]any {
+	out := make(map[string]any, len(doc)+len(patch))
+	for k, v := range doc {
+		out[k] = v
+	}
+	for k, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			delete(out, k)
+		case map[string]any:
+			cur, _ := out[k].(map[string]any)
+			out[k] = mergePatch(cur, v)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// Handler applies a delta to a device and returns the state it now
+// reports, which is merged into the reported document. Returning an error
+// leaves the reported state unchanged; the delta is offered again on the
+// next change of desired state or poll.
+type Handler func(ctx context.Context, delta map[string]any) (map[string]any, error)
+
+// ReconcileOption configures Reconcile.
+type ReconcileOption func(*reconcileConfig)
+
+type reconcileConfig struct {
+	pollInterval time.Duration
+	onError      func(error)
+	reqOpts      []resolvedb.RequestOption
+}
+
+// WithPollInterval sets how often desired state is checked (default 5s,
+// or the key's TTL when the client supports Watch).
+func WithPollInterval(d time.Duration) ReconcileOption {
+	return func(c *reconcileConfig) {
+		c.pollInterval = d
+	}
+}
+
+// OnError calls fn with lookup, handler and write errors. Reconciliation
+// continues after an error.
+func OnError(fn func(error)) ReconcileOption {
+	return func(c *reconcileConfig) {
+		c.onError = fn
+	}
+}
+
+// WithRequestOptions applies opts to every lookup and write made while
+// reconciling.
+func WithRequestOptions(opts ...resolvedb.RequestOption) ReconcileOption {
+	return func(c *reconcileConfig) {
+		c.reqOpts = append(c.reqOpts, opts...)
+	}
+}
+
+// watcher is implemented by clients that can watch keys, such as
+// *resolvedb.Client.
+type watcher interface {
+	Watch(ctx context.Context, resource, key string, opts ...resolvedb.RequestOption) <-chan resolvedb.WatchEvent
+}
+
+// Reconcile runs on the device side: it watches the device's desired
+// state and, whenever it differs from the reported state, calls handler
+// with the delta and merges the state the handler returns into the
+// reported document. It blocks until ctx is done and returns ctx.Err().
+//
+// Desired state is watched with the client's Watch when available, and
+// polled otherwise.
+//
+// Example:
+//
+//	err := shadowClient.Reconcile(ctx, "thermostat-42", func(ctx context.Context, delta map[string]any) (map[string]any, error) {
+//	    if t, ok := delta["target_temp"].(float64); ok {
+//	        hw.SetTarget(t)
+//	    }
+//	    return delta, nil
+//	})
+func (c *Client) Reconcile(ctx context.Context, device string, handler Handler, opts ...ReconcileOption) error {
+	cfg := reconcileConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	reconcile := func() {
+		if err := c.reconcileOnce(ctx, device, handler, cfg.reqOpts); err != nil && ctx.Err() == nil && cfg.onError != nil {
+			_ = resolvedb.SafeCall(c.client, "shadow error callback", func() { cfg.onError(err) })
+		}
+	}
+
+	if w, ok := c.client.(watcher); ok {
+		watchOpts := cfg.reqOpts
+		if cfg.pollInterval > 0 {
+			watchOpts = append(append([]resolvedb.RequestOption(nil), cfg.reqOpts...), resolvedb.WithPollInterval(cfg.pollInterval))
+		}
+		for ev := range w.Watch(ctx, resourceDesired, device, watchOpts...) {
+			if ev.Err != nil && !resolvedb.IsNotFound(ev.Err) {
+				if cfg.onError != nil {
+					_ = resolvedb.SafeCall(c.client, "shadow error callback", func() { cfg.onError(ev.Err) })
+				}
+				continue
+			}
+			reconcile()
+		}
+		return ctx.Err()
+	}
+
+	interval := cfg.pollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		reconcile()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcileOnce applies the current delta, if any.
+func (c *Client) reconcileOnce(ctx context.Context, device string, handler Handler, opts []resolvedb.RequestOption) error {
+	fresh := append(append([]resolvedb.RequestOption(nil), opts...), resolvedb.WithRefreshCache())
+	s, err := c.Get(ctx, device, fresh...)
+	if err != nil {
+		return err
+	}
+	if len(s.Delta) == 0 {
+		return nil
+	}
+
+	var applied map[string]any
+	if perr := resolvedb.SafeCall(c.client, "shadow handler", func() { applied, err = handler(ctx, s.Delta) }); perr != nil {
+		return perr
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		return nil
+	}
+	_, err = c.UpdateReported(ctx, device, applied, opts...)
+	return err
+}
@@ -0,0 +1,208 @@
+// Package status provides a client for ResolveDB's own status: component
+// health, incidents and maintenance windows, served over the same DNS
+// channel as data so it stays reachable when the status web page is not.
+package status
+
+import (
+	"context"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// resource is the resource holding platform status.
+const resource = "status"
+
+// Component statuses, from best to worst.
+const (
+	Operational         = "operational"
+	UnderMaintenance    = "under_maintenance"
+	DegradedPerformance = "degraded_performance"
+	PartialOutage       = "partial_outage"
+	MajorOutage         = "major_outage"
+)
+
+// Incident statuses.
+const (
+	Investigating = "investigating"
+	Identified    = "identified"
+	Monitoring    = "monitoring"
+	Resolved      = "resolved"
+)
+
+// StatusClient defines the interface for Status operations.
+// Implement this interface for testing with mocks.
+type StatusClient interface {
+	Summary(ctx context.Context, opts ...resolvedb.RequestOption) (*Summary, error)
+	Incidents(ctx context.Context, opts ...resolvedb.RequestOption) ([]Incident, error)
+	Maintenance(ctx context.Context, opts ...resolvedb.RequestOption) ([]Maintenance, error)
+}
+
+// Client is a Status service client.
+type Client struct {
+	client resolvedb.Querier
+}
+
+// NewClient creates a new Status client.
+func NewClient(c resolvedb.Querier) *Client {
+	return &Client{client: c}
+}
+
+// Ensure Client implements StatusClient.
+var _ StatusClient = (*Client)(nil)
+
+// Summary is the current state of the platform.
+type Summary struct {
+	Status      string        `json:"status"` // Worst component status
+	Description string        `json:"description,omitempty"`
+	Components  []Component   `json:"components"`
+	Incidents   []Incident    `json:"incidents,omitempty"`   // Unresolved incidents
+	Maintenance []Maintenance `json:"maintenance,omitempty"` // Active and upcoming windows
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// Component is a part of the platform, such as a region or transport.
+type Component struct {
+	Name        string    `json:"name"` // e.g. "doh", "writes" or "eu-west"
+	Status      string    `json:"status"`
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
+
+// Incident is an unplanned disruption.
+type Incident struct {
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`
+	Status     string           `json:"status"`
+	Impact     string           `json:"impact,omitempty"`     // Worst component status caused
+	Components []string         `json:"components,omitempty"` // Affected component names
+	StartedAt  time.Time        `json:"started_at"`
+	ResolvedAt time.Time        `json:"resolved_at,omitempty"`
+	Updates    []IncidentUpdate `json:"updates,omitempty"` // Newest first
+	URL        string           `json:"url,omitempty"`
+}
+
+// IncidentUpdate is a progress note on an incident.
+type IncidentUpdate struct {
+	Status    string    `json:"status"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Maintenance is a scheduled maintenance window.
+type Maintenance struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Components  []string  `json:"components,omitempty"` // Affected component names
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	URL         string    `json:"url,omitempty"`
+}
+
+// Operational reports whether the component is fully operational.
+func (c Component) Operational() bool {
+	return c.Status == Operational
+}
+
+// Active reports whether the incident is unresolved.
+func (i Incident) Active() bool {
+	return i.Status != Resolved
+}
+
+// ActiveAt reports whether the window covers t.
+func (m Maintenance) ActiveAt(t time.Time) bool {
+	return !t.Before(m.Start) && t.Before(m.End)
+}
+
+// Component returns the named component, or false if the summary has no
+// such component.
+func (s *Summary) Component(name string) (Component, bool) {
+	for _, c := range s.Components {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Component{}, false
+}
+
+// Affecting returns the unresolved incidents and active maintenance
+// windows that involve the named component, so callers can explain a
+// degraded experience instead of guessing from error rates.
+//
+// Example:
+//
+//	summary, err := statusClient.Summary(ctx)
+//	if err == nil {
+//	    if incidents, windows := summary.Affecting("writes"); len(incidents) > 0 || len(windows) > 0 {
+//	        banner.Show("Saving is delayed: " + describe(incidents, windows))
+//	    }
+//	}
+func (s *Summary) Affecting(component string) ([]Incident, []Maintenance) {
+	var incidents []Incident
+	for _, i := range s.Incidents {
+		if i.Active() && contains(i.Components, component) {
+			incidents = append(incidents, i)
+		}
+	}
+	var windows []Maintenance
+	now := time.Now()
+	for _, m := range s.Maintenance {
+		if m.ActiveAt(now) && contains(m.Components, component) {
+			windows = append(windows, m)
+		}
+	}
+	return incidents, windows
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary retrieves the current platform status. Status is served with a
+// short TTL; pass resolvedb.WithRefreshCache to bypass the local cache.
+//
+// Example:
+//
+//	summary, err := statusClient.Summary(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, c := range summary.Components {
+//	    fmt.Printf("%-10s %s\n", c.Name, c.Status)
+//	}
+func (c *Client) Summary(ctx context.Context, opts ...resolvedb.RequestOption) (*Summary, error) {
+	var s Summary
+	err := c.client.Get(ctx, resource, "summary", &s, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Incidents retrieves recent incidents, newest first, including resolved
+// ones.
+func (c *Client) Incidents(ctx context.Context, opts ...resolvedb.RequestOption) ([]Incident, error) {
+	var incidents []Incident
+	err := c.client.Get(ctx, resource, "incidents", &incidents, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return incidents, nil
+}
+
+// Maintenance retrieves active and upcoming maintenance windows, soonest
+// first.
+func (c *Client) Maintenance(ctx context.Context, opts ...resolvedb.RequestOption) ([]Maintenance, error) {
+	var windows []Maintenance
+	err := c.client.Get(ctx, resource, "maintenance", &windows, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
@@ -0,0 +1,387 @@
+// Package telemetry batches small metrics and events locally and writes
+// them to ResolveDB as compressed batches, on a timer or when a batch is
+// full.
+//
+// Batches are larger than a single write can carry, so the client must be
+// created with resolvedb.WithAutoCodec, which chunks them to fit in
+// queries; readers need it too.
+//
+// Pass a *resolvedb.Outbox as the writer to keep recording through
+// offline periods: batches are queued on disk and delivered in order once
+// the transport recovers.
+package telemetry
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/resolvedb/resolvedb-go"
+)
+
+// resource is the resource batches are written to.
+const resource = "telemetry"
+
+const (
+	defaultFlushInterval = 10 * time.Second
+	defaultMaxBatch      = 500
+	defaultMaxBuffered   = 10000
+
+	// maxBatchSize bounds the decompressed size of a batch read back.
+	maxBatchSize = 16 << 20
+)
+
+// ErrPointTooLarge is returned when a single point does not fit in a
+// write on its own. The point is dropped.
+var ErrPointTooLarge = errors.New("telemetry: point too large for a single write")
+
+// Point is a metric sample or event.
+type Point struct {
+	Name  string            `json:"n"`
+	Value float64           `json:"v,omitempty"`
+	Tags  map[string]string `json:"t,omitempty"`
+	Data  any               `json:"d,omitempty"` // Event payload
+	Time  time.Time         `json:"ts"`
+}
+
+// Stats reports a Writer's activity.
+type Stats struct {
+	Buffered int    // Points waiting to be written
+	Written  uint64 // Points written
+	Batches  uint64 // Batches written
+	Dropped  uint64 // Points dropped because the buffer was full or a point was too large
+	Failures uint64 // Failed batch writes
+}
+
+// valueLimiter is implemented by writers that can report their largest
+// value, such as *resolvedb.Client and *resolvedb.Outbox.
+type valueLimiter interface {
+	MaxValueBytes(resource, key string, opts ...resolvedb.RequestOption) int
+}
+
+// Option configures a Writer.
+type Option func(*Writer)
+
+// WithSource sets the name batches are keyed by, e.g. a device or host
+// name (default "app").
+func WithSource(source string) Option {
+	return func(w *Writer) {
+		w.source = source
+	}
+}
+
+// WithFlushInterval sets how often buffered points are written (default
+// 10s).
+func WithFlushInterval(d time.Duration) Option {
+	return func(w *Writer) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+// WithMaxBatch flushes as soon as n points are buffered (default 500).
+func WithMaxBatch(n int) Option {
+	return func(w *Writer) {
+		if n > 0 {
+			w.maxBatch = n
+		}
+	}
+}
+
+// WithMaxBuffered bounds the points held while writes fail (default
+// 10000). The oldest points are dropped first.
+func WithMaxBuffered(n int) Option {
+	return func(w *Writer) {
+		if n > 0 {
+			w.maxBuffered = n
+		}
+	}
+}
+
+// OnError calls fn with errors from background flushes.
+func OnError(fn func(error)) Option {
+	return func(w *Writer) {
+		w.onError = fn
+	}
+}
+
+// WithRequestOptions applies opts to every batch write, e.g. WithTTL to
+// expire old telemetry.
+func WithRequestOptions(opts ...resolvedb.RequestOption) Option {
+	return func(w *Writer) {
+		w.reqOpts = append(w.reqOpts, opts...)
+	}
+}
+
+// Writer buffers points and writes them in compressed batches. It is safe
+// for concurrent use. Create one with NewWriter and Close it when done.
+type Writer struct {
+	w           resolvedb.Writer
+	source      string
+	interval    time.Duration
+	maxBatch    int
+	maxBuffered int
+	onError     func(error)
+	reqOpts     []resolvedb.RequestOption
+
+	mu     sync.Mutex
+	points []Point
+	seq    uint64
+	stats  Stats
+
+	flushMu sync.Mutex // serializes flushes so batches are written in order
+	kick    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewWriter creates a Writer that writes batches through w and starts its
+// background flush loop.
+//
+// Example:
+//
+//	client, _ := resolvedb.New(resolvedb.WithAPIKey(key), resolvedb.WithAutoCodec())
+//	store, _ := resolvedb.NewFileOutboxStore("/var/lib/sensor/outbox.jsonl")
+//	outbox := resolvedb.NewOutbox(client, store)
+//	go outbox.Run(ctx, 30*time.Second)
+//
+//	tw := telemetry.NewWriter(outbox, telemetry.WithSource("sensor-7"))
+//	defer tw.Close(ctx)
+//	tw.Record("temp_c", 21.4, map[string]string{"room": "lab"})
+func NewWriter(w resolvedb.Writer, opts ...Option) *Writer {
+	tw := &Writer{
+		w:           w,
+		source:      "app",
+		interval:    defaultFlushInterval,
+		maxBatch:    defaultMaxBatch,
+		maxBuffered: defaultMaxBuffered,
+		kick:        make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(tw)
+	}
+	go tw.run()
+	return tw
+}
+
+// Record buffers a metric sample.
+func (w *Writer) Record(name string, value float64, tags map[string]string) {
+	w.Add(Point{Name: name, Value: value, Tags: tags})
+}
+
+// Event buffers an event with a payload.
+func (w *Writer) Event(name string, data any, tags map[string]string) {
+	w.Add(Point{Name: name, Data: data, Tags: tags})
+}
+
+// Add buffers a point, stamping it with the current time if it has none.
+// A full batch is flushed in the background.
+func (w *Writer) Add(p Point) {
+	if p.Time.IsZero() {
+		p.Time = time.Now().UTC()
+	}
+
+	w.mu.Lock()
+	w.points = append(w.points, p)
+	if over := len(w.points) - w.maxBuffered; over > 0 {
+		w.points = append(w.points[:0], w.points[over:]...)
+		w.stats.Dropped += uint64(over)
+	}
+	full := len(w.points) >= w.maxBatch
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Stats returns the writer's activity counters.
+func (w *Writer) Stats() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := w.stats
+	s.Buffered = len(w.points)
+	return s
+}
+
+// Flush writes all buffered points. Points whose batch fails to write are
+// kept for the next flush.
+func (w *Writer) Flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	points := w.points
+	w.points = nil
+	w.mu.Unlock()
+
+	var errs []error
+	for len(points) > 0 {
+		n := min(len(points), w.maxBatch)
+		written, err := w.writeBatch(ctx, points[:n])
+		points = points[written:]
+		if err != nil {
+			if errors.Is(err, ErrPointTooLarge) {
+				// Drop the offending point and carry on
+				points = points[1:]
+				w.mu.Lock()
+				w.stats.Dropped++
+				w.mu.Unlock()
+				errs = append(errs, err)
+				continue
+			}
+			w.requeue(points)
+			w.mu.Lock()
+			w.stats.Failures++
+			w.mu.Unlock()
+			return errors.Join(append(errs, err)...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops the background loop and flushes the remaining points.
+func (w *Writer) Close(ctx context.Context) error {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+	return w.Flush(ctx)
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		case <-w.kick:
+		}
+		if err := w.Flush(context.Background()); err != nil && w.onError != nil {
+			_ = resolvedb.SafeCall(w.w, "telemetry error callback", func() { w.onError(err) })
+		}
+	}
+}
+
+// requeue puts unwritten points back in front of those added since.
+func (w *Writer) requeue(points []Point) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.points = append(append([]Point(nil), points...), w.points...)
+	if over := len(w.points) - w.maxBuffered; over > 0 {
+		w.points = w.points[over:]
+		w.stats.Dropped += uint64(over)
+	}
+}
+
+// writeBatch writes points as one or more batches and returns how many
+// leading points were written. Batches too large for a single write are
+// split in half until they fit.
+func (w *Writer) writeBatch(ctx context.Context, points []Point) (int, error) {
+	key := w.nextKey()
+	data, err := encodeBatch(points)
+	if err != nil {
+		return 0, err
+	}
+
+	if limiter, ok := w.w.(valueLimiter); ok {
+		// Batches are stored as base64 JSON strings
+		limit := (limiter.MaxValueBytes(resource, key, w.reqOpts...) - 2) / 4 * 3
+		if len(data) > limit {
+			if len(points) == 1 {
+				return 0, fmt.Errorf("%w: %q is %d bytes compressed, limit is %d", ErrPointTooLarge, points[0].Name, len(data), limit)
+			}
+			half := len(points) / 2
+			n, err := w.writeBatch(ctx, points[:half])
+			if err != nil {
+				return n, err
+			}
+			m, err := w.writeBatch(ctx, points[half:])
+			return n + m, err
+		}
+	}
+
+	if err := w.w.Set(ctx, resource, key, data, w.reqOpts...); err != nil {
+		return 0, err
+	}
+	w.mu.Lock()
+	w.stats.Written += uint64(len(points))
+	w.stats.Batches++
+	w.mu.Unlock()
+	return len(points), nil
+}
+
+// nextKey returns a unique, time-ordered batch key.
+func (w *Writer) nextKey() string {
+	w.mu.Lock()
+	w.seq++
+	seq := w.seq
+	w.mu.Unlock()
+	return w.source + "-" + strconv.FormatInt(time.Now().UnixMilli(), 10) + "-" + strconv.FormatUint(seq, 10)
+}
+
+// encodeBatch encodes points as deflated JSON.
+func encodeBatch(points []Point) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, fmt.Errorf("telemetry: compress: %w", err)
+	}
+	if err := json.NewEncoder(zw).Encode(points); err != nil {
+		return nil, fmt.Errorf("telemetry: encode: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("telemetry: compress: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeBatch decodes a batch written by a Writer.
+func DecodeBatch(data []byte) ([]Point, error) {
+	zr := flate.NewReader(bytes.NewReader(data))
+	defer zr.Close()
+	raw, err := io.ReadAll(io.LimitReader(zr, maxBatchSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("telemetry: decompress: %w", err)
+	}
+	if len(raw) > maxBatchSize {
+		return nil, fmt.Errorf("telemetry: batch exceeds %d bytes", maxBatchSize)
+	}
+	var points []Point
+	if err := json.Unmarshal(raw, &points); err != nil {
+		return nil, fmt.Errorf("telemetry: decode: %w", err)
+	}
+	return points, nil
+}
+
+// ReadBatch retrieves and decodes the batch stored at key.
+//
+// Example:
+//
+//	keys, _ := client.List(ctx, "telemetry")
+//	for _, key := range keys {
+//	    points, err := telemetry.ReadBatch(ctx, client, key)
+//	    ...
+//	}
+func ReadBatch(ctx context.Context, q resolvedb.Querier, key string, opts ...resolvedb.RequestOption) ([]Point, error) {
+	var data []byte
+	if err := q.Get(ctx, resource, key, &data, opts...); err != nil {
+		return nil, err
+	}
+	return DecodeBatch(data)
+}
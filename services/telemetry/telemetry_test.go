@@ -0,0 +1,76 @@
+package telemetry_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/resolvedbtest"
+	"github.com/resolvedb/resolvedb-go/services/telemetry"
+)
+
+func newClient(t *testing.T, srv *resolvedbtest.Server) *resolvedb.Client {
+	t.Helper()
+	client, err := resolvedb.New(
+		resolvedb.WithTransports(srv),
+		resolvedb.WithCache(resolvedb.CacheConfig{}),
+		resolvedb.WithRetry(resolvedb.RetryConfig{}),
+		resolvedb.WithAPIKey("test-key"),
+		resolvedb.WithAutoCodec(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// writeAndRead records points through w, closes it and reads back every
+// batch stored on srv.
+func writeAndRead(t *testing.T, srv *resolvedbtest.Server, client *resolvedb.Client, w resolvedb.Writer) []telemetry.Point {
+	t.Helper()
+	ctx := context.Background()
+	tw := telemetry.NewWriter(w, telemetry.WithSource("sensor-7"))
+	for i := 0; i < 50; i++ {
+		tw.Record("temp_c", 21.4+float64(i), map[string]string{"room": "lab"})
+	}
+	if err := tw.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if stats := tw.Stats(); stats.Dropped != 0 || stats.Written != 50 {
+		t.Fatalf("Stats = %+v, want 50 points written and none dropped", stats)
+	}
+
+	var points []telemetry.Point
+	for _, key := range srv.Keys("public", "telemetry") {
+		batch, err := telemetry.ReadBatch(ctx, client, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		points = append(points, batch...)
+	}
+	return points
+}
+
+func TestWriterWithClient(t *testing.T) {
+	srv := resolvedbtest.NewServer()
+	client := newClient(t, srv)
+
+	points := writeAndRead(t, srv, client, client)
+	if len(points) != 50 {
+		t.Fatalf("read %d points, want 50", len(points))
+	}
+	if p := points[0]; p.Name != "temp_c" || p.Tags["room"] != "lab" {
+		t.Fatalf("first point = %+v, want temp_c in the lab", p)
+	}
+}
+
+func TestWriterWithOutbox(t *testing.T) {
+	srv := resolvedbtest.NewServer()
+	client := newClient(t, srv)
+	outbox := resolvedb.NewOutbox(client, resolvedb.NewMemoryOutboxStore())
+
+	if points := writeAndRead(t, srv, client, outbox); len(points) != 50 {
+		t.Fatalf("read %d points, want 50", len(points))
+	}
+}
@@ -4,28 +4,59 @@ package weather
 import (
 	"context"
 	"fmt"
+	"math"
 	"net"
+	"net/netip"
+	"time"
 
 	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/geokey"
+	"github.com/resolvedb/resolvedb-go/services/geoip"
 )
 
 // WeatherClient defines the interface for Weather operations.
-// Implement this interface for testing with mocks.
+// Implement this interface for testing with mocks, or use
+// weathertest.MockClient, which tracks the interface as it grows.
 type WeatherClient interface {
 	ByCity(ctx context.Context, city string, opts ...resolvedb.RequestOption) (*Weather, error)
 	ByCoords(ctx context.Context, lat, lon float64, opts ...resolvedb.RequestOption) (*Weather, error)
 	ByIP(ctx context.Context, ip net.IP, opts ...resolvedb.RequestOption) (*Weather, error)
+	ByAddr(ctx context.Context, addr netip.Addr, opts ...resolvedb.RequestOption) (*Weather, error)
 	BySelf(ctx context.Context, opts ...resolvedb.RequestOption) (*Weather, error)
+	Forecast(ctx context.Context, city string, opts ...resolvedb.RequestOption) ([]Forecast, error)
+	Alerts(ctx context.Context, city string, opts ...resolvedb.RequestOption) ([]Alert, error)
 }
 
 // Client is a Weather service client.
 type Client struct {
 	client resolvedb.Querier
+	coords geokey.Quantizer
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithCoordPrecision sets how ByCoords quantizes coordinates into keys
+// (see package geokey for the schemes and their cell sizes). Coarser
+// cells let nearby lookups share cache entries. The default,
+// geokey.Grid(4), keys cells of about 11 m.
+//
+// Example:
+//
+//	wxClient := weather.NewClient(client, weather.WithCoordPrecision(geokey.Geohash(5)))
+func WithCoordPrecision(q geokey.Quantizer) Option {
+	return func(c *Client) {
+		c.coords = q
+	}
 }
 
 // NewClient creates a new Weather client.
-func NewClient(c resolvedb.Querier) *Client {
-	return &Client{client: c}
+func NewClient(c resolvedb.Querier, opts ...Option) *Client {
+	client := &Client{client: c}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
 }
 
 // Ensure Client implements WeatherClient.
@@ -48,6 +79,26 @@ type Weather struct {
 	Sunrise     string  `json:"sunrise,omitempty"`
 	Sunset      string  `json:"sunset,omitempty"`
 	UpdatedAt   string  `json:"updated_at,omitempty"`
+	Timezone    string  `json:"timezone,omitempty"`
+	Local       string  `json:"local_time,omitempty"`
+}
+
+// Zone returns the IANA time zone of the weather's location.
+func (w *Weather) Zone() (*time.Location, error) {
+	return geoip.LoadZone(w.Timezone)
+}
+
+// LocalTime returns the local time at the weather's location, in its time
+// zone (see geoip.ParseLocalTime).
+//
+// Example:
+//
+//	w, err := wxClient.ByCity(ctx, "quebec")
+//	...
+//	now, err := w.LocalTime()
+//	fmt.Printf("%.1f°C at %s\n", w.TempC, now.Format("15:04 MST"))
+func (w *Weather) LocalTime() (time.Time, error) {
+	return geoip.ParseLocalTime(w.Local, w.Timezone)
 }
 
 // Forecast represents a weather forecast entry.
@@ -61,6 +112,16 @@ type Forecast struct {
 	Icon       string  `json:"icon,omitempty"`
 }
 
+// Alert represents an active weather alert.
+type Alert struct {
+	Event       string `json:"event"`
+	Severity    string `json:"severity"`
+	Headline    string `json:"headline"`
+	Description string `json:"description,omitempty"`
+	Start       string `json:"start,omitempty"`
+	End         string `json:"end,omitempty"`
+}
+
 // ByCity retrieves weather for a city.
 //
 // Example:
@@ -79,13 +140,17 @@ func (c *Client) ByCity(ctx context.Context, city string, opts ...resolvedb.Requ
 	return &w, nil
 }
 
-// ByCoords retrieves weather for coordinates.
+// ByCoords retrieves weather for coordinates, keyed by the cell that
+// contains them (see WithCoordPrecision).
 //
 // Example:
 //
 //	weather, err := wxClient.ByCoords(ctx, 46.81, -71.21)  // Quebec City
 func (c *Client) ByCoords(ctx context.Context, lat, lon float64, opts ...resolvedb.RequestOption) (*Weather, error) {
-	key := fmt.Sprintf("%.4f,%.4f", lat, lon)
+	if math.IsNaN(lat) || math.IsNaN(lon) || math.IsInf(lat, 0) || math.IsInf(lon, 0) {
+		return nil, fmt.Errorf("weather: invalid coordinates %v,%v", lat, lon)
+	}
+	key := c.coords.Key(lat, lon)
 	var w Weather
 	err := c.client.Get(ctx, "weather", key, &w, opts...)
 	if err != nil {
@@ -96,8 +161,25 @@ func (c *Client) ByCoords(ctx context.Context, lat, lon float64, opts ...resolve
 
 // ByIP retrieves weather for an IP address location.
 func (c *Client) ByIP(ctx context.Context, ip net.IP, opts ...resolvedb.RequestOption) (*Weather, error) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return nil, fmt.Errorf("weather: invalid IP address %v", ip)
+	}
+	return c.ByAddr(ctx, addr, opts...)
+}
+
+// ByAddr retrieves weather for an IP address location. Addresses are keyed
+// as in the geoip client, so IPv6 and IPv4-mapped addresses are label-safe.
+//
+// Example:
+//
+//	weather, err := wxClient.ByAddr(ctx, netip.MustParseAddr("8.8.8.8"))
+func (c *Client) ByAddr(ctx context.Context, addr netip.Addr, opts ...resolvedb.RequestOption) (*Weather, error) {
+	if !addr.IsValid() {
+		return nil, fmt.Errorf("weather: invalid IP address %v", addr)
+	}
 	var w Weather
-	err := c.client.Get(ctx, "weather", "ip-"+ip.String(), &w, opts...)
+	err := c.client.Get(ctx, "weather", "ip-"+geoip.AddrKey(addr), &w, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -108,3 +190,30 @@ func (c *Client) ByIP(ctx context.Context, ip net.IP, opts ...resolvedb.RequestO
 func (c *Client) BySelf(ctx context.Context, opts ...resolvedb.RequestOption) (*Weather, error) {
 	return c.ByCity(ctx, "self", opts...)
 }
+
+// Forecast retrieves the daily forecast for a city, earliest day first.
+//
+// Example:
+//
+//	days, err := wxClient.Forecast(ctx, "quebec")
+//	for _, d := range days {
+//	    fmt.Printf("%s: %.0f/%.0f°C %s\n", d.Date, d.TempHighC, d.TempLowC, d.Conditions)
+//	}
+func (c *Client) Forecast(ctx context.Context, city string, opts ...resolvedb.RequestOption) ([]Forecast, error) {
+	var f []Forecast
+	err := c.client.Get(ctx, "forecast", city, &f, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Alerts retrieves the active weather alerts for a city.
+func (c *Client) Alerts(ctx context.Context, city string, opts ...resolvedb.RequestOption) ([]Alert, error) {
+	var a []Alert
+	err := c.client.Get(ctx, "alerts", city, &a, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
@@ -0,0 +1,144 @@
+// Package weathertest provides a mock weather.WeatherClient for tests.
+//
+// MockClient is kept in step with weather.WeatherClient: a compile-time
+// assertion fails the build if it misses a method, and the package tests
+// fail if a method has no function field to mock it with.
+package weathertest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"sync"
+
+	"github.com/resolvedb/resolvedb-go"
+	"github.com/resolvedb/resolvedb-go/services/weather"
+)
+
+// ErrNotMocked is returned by MockClient methods whose function is not set.
+var ErrNotMocked = errors.New("weathertest: method not mocked")
+
+// Call records a call made to a MockClient.
+type Call struct {
+	Method string // Method name (e.g., "ByCity")
+	Args   []any  // Arguments after ctx, excluding request options
+	Opts   int    // Number of request options passed
+}
+
+// MockClient implements weather.WeatherClient with a function field per
+// method. Methods whose function is nil return ErrNotMocked. All calls are
+// recorded and can be inspected with Calls.
+//
+// Example:
+//
+//	mock := &weathertest.MockClient{
+//	    ByCityFunc: func(ctx context.Context, city string, opts ...resolvedb.RequestOption) (*weather.Weather, error) {
+//	        return &weather.Weather{Location: city, TempC: 21}, nil
+//	    },
+//	}
+//	svc := NewService(mock)
+type MockClient struct {
+	ByCityFunc   func(ctx context.Context, city string, opts ...resolvedb.RequestOption) (*weather.Weather, error)
+	ByCoordsFunc func(ctx context.Context, lat, lon float64, opts ...resolvedb.RequestOption) (*weather.Weather, error)
+	ByIPFunc     func(ctx context.Context, ip net.IP, opts ...resolvedb.RequestOption) (*weather.Weather, error)
+	ByAddrFunc   func(ctx context.Context, addr netip.Addr, opts ...resolvedb.RequestOption) (*weather.Weather, error)
+	BySelfFunc   func(ctx context.Context, opts ...resolvedb.RequestOption) (*weather.Weather, error)
+	ForecastFunc func(ctx context.Context, city string, opts ...resolvedb.RequestOption) ([]weather.Forecast, error)
+	AlertsFunc   func(ctx context.Context, city string, opts ...resolvedb.RequestOption) ([]weather.Alert, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Ensure MockClient implements weather.WeatherClient.
+var _ weather.WeatherClient = (*MockClient)(nil)
+
+// Calls returns the calls made so far, in order.
+func (m *MockClient) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallCount returns the number of calls made to method.
+func (m *MockClient) CallCount(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, c := range m.calls {
+		if c.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *MockClient) record(c Call) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, c)
+}
+
+// ByCity calls ByCityFunc.
+func (m *MockClient) ByCity(ctx context.Context, city string, opts ...resolvedb.RequestOption) (*weather.Weather, error) {
+	m.record(Call{Method: "ByCity", Args: []any{city}, Opts: len(opts)})
+	if m.ByCityFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.ByCityFunc(ctx, city, opts...)
+}
+
+// ByCoords calls ByCoordsFunc.
+func (m *MockClient) ByCoords(ctx context.Context, lat, lon float64, opts ...resolvedb.RequestOption) (*weather.Weather, error) {
+	m.record(Call{Method: "ByCoords", Args: []any{lat, lon}, Opts: len(opts)})
+	if m.ByCoordsFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.ByCoordsFunc(ctx, lat, lon, opts...)
+}
+
+// ByIP calls ByIPFunc.
+func (m *MockClient) ByIP(ctx context.Context, ip net.IP, opts ...resolvedb.RequestOption) (*weather.Weather, error) {
+	m.record(Call{Method: "ByIP", Args: []any{ip}, Opts: len(opts)})
+	if m.ByIPFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.ByIPFunc(ctx, ip, opts...)
+}
+
+// ByAddr calls ByAddrFunc.
+func (m *MockClient) ByAddr(ctx context.Context, addr netip.Addr, opts ...resolvedb.RequestOption) (*weather.Weather, error) {
+	m.record(Call{Method: "ByAddr", Args: []any{addr}, Opts: len(opts)})
+	if m.ByAddrFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.ByAddrFunc(ctx, addr, opts...)
+}
+
+// BySelf calls BySelfFunc.
+func (m *MockClient) BySelf(ctx context.Context, opts ...resolvedb.RequestOption) (*weather.Weather, error) {
+	m.record(Call{Method: "BySelf", Opts: len(opts)})
+	if m.BySelfFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.BySelfFunc(ctx, opts...)
+}
+
+// Forecast calls ForecastFunc.
+func (m *MockClient) Forecast(ctx context.Context, city string, opts ...resolvedb.RequestOption) ([]weather.Forecast, error) {
+	m.record(Call{Method: "Forecast", Args: []any{city}, Opts: len(opts)})
+	if m.ForecastFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.ForecastFunc(ctx, city, opts...)
+}
+
+// Alerts calls AlertsFunc.
+func (m *MockClient) Alerts(ctx context.Context, city string, opts ...resolvedb.RequestOption) ([]weather.Alert, error) {
+	m.record(Call{Method: "Alerts", Args: []any{city}, Opts: len(opts)})
+	if m.AlertsFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.AlertsFunc(ctx, city, opts...)
+}
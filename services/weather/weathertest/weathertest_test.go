@@ -0,0 +1,26 @@
+package weathertest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/resolvedb/resolvedb-go/services/weather"
+)
+
+// TestMockCoversInterface fails when weather.WeatherClient gains a method
+// MockClient has no function field for, or the two signatures differ.
+func TestMockCoversInterface(t *testing.T) {
+	iface := reflect.TypeOf((*weather.WeatherClient)(nil)).Elem()
+	mock := reflect.TypeOf(MockClient{})
+	for i := 0; i < iface.NumMethod(); i++ {
+		m := iface.Method(i)
+		field, ok := mock.FieldByName(m.Name + "Func")
+		if !ok {
+			t.Errorf("MockClient has no %sFunc field", m.Name)
+			continue
+		}
+		if field.Type != m.Type {
+			t.Errorf("MockClient.%sFunc is %s, want %s", m.Name, field.Type, m.Type)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package resolvedb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxQueryNameLength is the longest query name, in characters, that
+	// fits in a DNS question (RFC 1035 2.3.4: 255 octets on the wire).
+	MaxQueryNameLength = 253
+
+	// maxLabelLength is the longest single DNS label.
+	maxLabelLength = 63
+)
+
+// EstimateQuerySize returns the length of the query name the client would
+// send for operation on resource and key with opts, including security
+// tokens, the auth label, parameters and the zone. Names longer than
+// MaxQueryNameLength cannot be sent.
+//
+// Example:
+//
+//	if client.EstimateQuerySize("get", "config", key, opts...) > resolvedb.MaxQueryNameLength {
+//	    // shorten the key or drop fields
+//	}
+func (c *Client) EstimateQuerySize(operation, resource, key string, opts ...RequestOption) int {
+	reqConfig := newRequestConfig(context.Background(), opts)
+	return len(c.buildQueryName(operation, resource, key, reqConfig))
+}
+
+// MaxPayloadBytes returns the largest encoded value, in bytes, that Set can
+// write to resource and key with opts. Values are sent encoded in a
+// single label of the query name, so the budget is bounded both by the
+// label length and by what the rest of the name leaves over. Larger values
+// must be compressed, stored with a more compact codec, or split, as
+// WithAutoCodec does; see MaxValueBytes. Returns 0 if the name leaves no
+// room for data.
+//
+// Example:
+//
+//	raw, _ := json.Marshal(doc)
+//	if len(raw) > client.MaxPayloadBytes("docs", key) {
+//	    return fmt.Errorf("document %s too large: %d bytes", key, len(raw))
+//	}
+func (c *Client) MaxPayloadBytes(resource, key string, opts ...RequestOption) int {
+	return c.maxPayloadBytes(resource, key, newRequestConfig(context.Background(), opts))
+}
+
+// MaxValueBytes returns the largest encoded value, in bytes, that Set can
+// write to resource and key with opts. Without WithAutoCodec it is
+// MaxPayloadBytes. With it, values too large for a single write are split
+// into chunks, so the budget is what the most chunks a value may be split
+// into can hold; values within it are written whether or not they
+// compress.
+func (c *Client) MaxValueBytes(resource, key string, opts ...RequestOption) int {
+	reqConfig := newRequestConfig(context.Background(), opts)
+	if !c.config.autoCodec {
+		return c.maxPayloadBytes(resource, key, reqConfig)
+	}
+	return c.maxChunkedBytes(resource, key, reqConfig)
+}
+
+// maxPayloadBytes returns the largest value that fits in a put query.
+func (c *Client) maxPayloadBytes(resource, key string, reqConfig *requestConfig) int {
+	// The name with an empty data label already counts the data prefix
+	base := len(c.buildQueryNameWithData("put", resource, key, nil, reqConfig))
+	room := MaxQueryNameLength - base
+	if label := maxLabelLength - len(PrefixBase64); room > label {
+		room = label
+	}
+	if room <= 0 {
+		return 0
+	}
+	return c.decodedLen(room)
+}
+
+// checkWriteName returns an error wrapping ErrPayloadTooLarge if name, the
+// query name of a write, does not fit in a DNS question: the data label
+// is longer than a label may be, or the name longer than
+// MaxQueryNameLength.
+func checkWriteName(name string) error {
+	if len(name) > MaxQueryNameLength {
+		return fmt.Errorf("%w: query name of %d characters exceeds %d", ErrPayloadTooLarge, len(name), MaxQueryNameLength)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if len(label) > maxLabelLength {
+			return fmt.Errorf("%w: label of %d characters exceeds %d", ErrPayloadTooLarge, len(label), maxLabelLength)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package resolvedb
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MaxResponseBytes is the largest DNS message, and so the hard limit on the
+// size of an answer.
+const MaxResponseBytes = 65535
+
+// defaultPayloadWarningThreshold is the fraction of a limit above which a
+// size is reported when WithPayloadWarning is given no threshold.
+const defaultPayloadWarningThreshold = 0.8
+
+// Histogram bucket upper bounds. The last bucket of each is unbounded.
+var (
+	queryNameBuckets = []int{64, 128, 160, 192, 224, MaxQueryNameLength, math.MaxInt}
+	responseBuckets  = []int{512, 1232, 4096, 16384, 32768, MaxResponseBytes, math.MaxInt}
+)
+
+// SizeKind identifies a measured size.
+type SizeKind string
+
+// Measured sizes.
+const (
+	// SizeQueryName is the length of an encoded query name, in characters,
+	// bounded by MaxQueryNameLength. Writes carry their value in the name,
+	// so this is what fails with ErrPayloadTooLarge as values grow.
+	SizeQueryName SizeKind = "query_name"
+
+	// SizeResponse is the size of an answer's TXT data, in bytes, bounded
+	// by MaxResponseBytes.
+	SizeResponse SizeKind = "response"
+)
+
+// limit returns the hard limit on a size of kind k.
+func (k SizeKind) limit() int {
+	if k == SizeQueryName {
+		return MaxQueryNameLength
+	}
+	return MaxResponseBytes
+}
+
+// ResourceSizes reports the query name and response sizes seen for a
+// resource.
+type ResourceSizes struct {
+	Resource  string        `json:"resource"`
+	QueryName SizeHistogram `json:"query_name"` // Encoded query name lengths, in characters
+	Response  SizeHistogram `json:"response"`   // Answer sizes, in bytes
+}
+
+// SizeHistogram is a distribution of sizes.
+type SizeHistogram struct {
+	Count   int64        `json:"count"`
+	Sum     int64        `json:"sum"`
+	Max     int          `json:"max"`
+	Buckets []SizeBucket `json:"buckets"`
+}
+
+// SizeBucket counts the sizes above the previous bucket's bound, up to and
+// including UpperBound. The last bucket's UpperBound is math.MaxInt.
+type SizeBucket struct {
+	UpperBound int   `json:"le"`
+	Count      int64 `json:"count"`
+}
+
+// PayloadWarning reports a size close to its DNS limit.
+type PayloadWarning struct {
+	Resource string
+	Kind     SizeKind
+	Size     int // Size observed
+	Limit    int // Hard limit for Kind
+}
+
+// WithPayloadWarning calls fn when a query name or answer for a resource
+// exceeds threshold (a fraction of its limit, default 0.8) and is the
+// largest seen for that resource so far, so growth is noticed before
+// writes start failing with ErrPayloadTooLarge. With a nil fn, warnings
+// go to the warning handler (see WithWarningHandler), or are logged. Size
+// distributions are reported in Stats().Sizes either way.
+//
+// Example:
+//
+//	client, err := resolvedb.New(
+//	    resolvedb.WithPayloadWarning(0.9, func(w resolvedb.PayloadWarning) {
+//	        alerts.Notify("%s %s at %d of %d", w.Resource, w.Kind, w.Size, w.Limit)
+//	    }),
+//	)
+func WithPayloadWarning(threshold float64, fn func(PayloadWarning)) Option {
+	return func(c *clientConfig) {
+		if threshold <= 0 || threshold > 1 {
+			threshold = defaultPayloadWarningThreshold
+		}
+		c.payloadWarning = &payloadWarningConfig{threshold: threshold, fn: fn}
+	}
+}
+
+// payloadWarningConfig configures payload warnings.
+type payloadWarningConfig struct {
+	threshold float64
+	fn        func(PayloadWarning)
+}
+
+// sizeHistogram accumulates sizes into fixed buckets.
+type sizeHistogram struct {
+	bounds []int
+	counts []int64
+	count  int64
+	sum    int64
+	max    int
+	warned int // Largest size reported by a payload warning
+}
+
+func newSizeHistogram(bounds []int) *sizeHistogram {
+	return &sizeHistogram{bounds: bounds, counts: make([]int64, len(bounds))}
+}
+
+// add records a size and reports whether it is a new maximum.
+func (h *sizeHistogram) add(size int) bool {
+	i := sort.SearchInts(h.bounds, size)
+	h.counts[i]++
+	h.count++
+	h.sum += int64(size)
+	if size > h.max {
+		h.max = size
+		return true
+	}
+	return false
+}
+
+// snapshot returns the histogram's current state.
+func (h *sizeHistogram) snapshot() SizeHistogram {
+	s := SizeHistogram{Count: h.count, Sum: h.sum, Max: h.max, Buckets: make([]SizeBucket, len(h.bounds))}
+	for i, b := range h.bounds {
+		s.Buckets[i] = SizeBucket{UpperBound: b, Count: h.counts[i]}
+	}
+	return s
+}
+
+// resourceSizes holds a resource's size histograms.
+type resourceSizes struct {
+	queryName *sizeHistogram
+	response  *sizeHistogram
+}
+
+// recordSize records a size for a resource and returns the payload warning
+// to raise, if any.
+func (s *clientStats) recordSize(resource string, kind SizeKind, size int, cfg *payloadWarningConfig) *PayloadWarning {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rs, ok := s.sizes[resource]
+	if !ok {
+		rs = &resourceSizes{
+			queryName: newSizeHistogram(queryNameBuckets),
+			response:  newSizeHistogram(responseBuckets),
+		}
+		s.sizes[resource] = rs
+	}
+	h := rs.response
+	if kind == SizeQueryName {
+		h = rs.queryName
+	}
+
+	if !h.add(size) || cfg == nil || size <= h.warned {
+		return nil
+	}
+	limit := kind.limit()
+	if float64(size) < cfg.threshold*float64(limit) {
+		return nil
+	}
+	h.warned = size
+	return &PayloadWarning{Resource: resource, Kind: kind, Size: size, Limit: limit}
+}
+
+// sizeSnapshot returns the size histograms of every resource, sorted by
+// resource.
+func (s *clientStats) sizeSnapshot() []ResourceSizes {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ResourceSizes, 0, len(s.sizes))
+	for resource, rs := range s.sizes {
+		out = append(out, ResourceSizes{
+			Resource:  resource,
+			QueryName: rs.queryName.snapshot(),
+			Response:  rs.response.snapshot(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Resource < out[j].Resource })
+	return out
+}
+
+// recordSize records a query name or answer size and raises a payload
+// warning if it nears its limit.
+func (c *Client) recordSize(resource string, kind SizeKind, size int) {
+	w := c.stats.recordSize(resource, kind, size, c.config.payloadWarning)
+	if w == nil {
+		return
+	}
+	if fn := c.config.payloadWarning.fn; fn != nil {
+		_ = SafeCall(c, "payload warning", func() { fn(*w) })
+		return
+	}
+	c.warn(Warning{
+		Code:     WarningPayloadNearLimit,
+		Resource: w.Resource,
+		Message:  fmt.Sprintf("%s size %d nearing DNS limit %d", w.Kind, w.Size, w.Limit),
+	})
+}
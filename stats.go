@@ -0,0 +1,154 @@
+package resolvedb
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of client activity counters.
+type Stats struct {
+	Queries        int64            `json:"queries"`         // Queries sent to a transport
+	Errors         int64            `json:"errors"`          // Queries that failed at the transport or parse stage
+	CacheHits      int64            `json:"cache_hits"`      // Reads served from the cache
+	CacheMisses    int64            `json:"cache_misses"`    // Reads that went to a transport
+	CacheEntries   int              `json:"cache_entries"`   // Entries currently cached (-1 if unknown)
+	CacheRefused   int64            `json:"cache_refused"`   // Responses left uncached by WithCacheKeyLimit
+	CallbackPanics int64            `json:"callback_panics"` // Panics recovered from user callbacks
+	WatchDropped   int64            `json:"watch_dropped"`   // Watch events coalesced away before a slow consumer read them
+	Prefetches     int64            `json:"prefetches"`      // Background reads of hinted keys, see WithPrefetch
+	Warnings       int64            `json:"warnings"`        // Soft protocol problems, see WithWarningHandler
+	Fallbacks      int64            `json:"fallbacks"`       // Failed reads answered by WithFallback or GetOrDefault
+	Transports     []TransportStats `json:"transports"`      // Per-transport health, sorted by name
+	Sizes          []ResourceSizes  `json:"sizes"`           // Query name and answer sizes per resource, sorted by resource
+}
+
+// TransportStats reports the health of a single transport.
+type TransportStats struct {
+	Name        string    `json:"name"`
+	Encrypted   bool      `json:"encrypted"`
+	Queries     int64     `json:"queries"`
+	Errors      int64     `json:"errors"`
+	LastSuccess time.Time `json:"last_success"`
+	LastFailure time.Time `json:"last_failure"`
+	LastError   string    `json:"last_error,omitempty"`
+
+	// LatencyP99 is the 99th percentile of recent query latencies, 0
+	// until measured.
+	LatencyP99 time.Duration `json:"latency_p99"`
+}
+
+// clientStats holds the live counters behind Client.Stats.
+type clientStats struct {
+	queries      atomic.Int64
+	errors       atomic.Int64
+	cacheHits    atomic.Int64
+	cacheMisses  atomic.Int64
+	cacheRefused atomic.Int64 // Responses left uncached by the key limit
+	panics       atomic.Int64 // Panics recovered from user callbacks
+	watchDropped atomic.Int64 // Watch events coalesced away
+	prefetches   atomic.Int64 // Background reads of hinted keys
+	warnings     atomic.Int64 // Soft protocol problems
+	fallbacks    atomic.Int64 // Failed reads answered by a fallback
+	clock        Clock
+
+	mu          sync.Mutex
+	transports  map[string]*TransportStats
+	latencies   map[string]*latencyWindow
+	latencySize int
+	sizes       map[string]*resourceSizes
+}
+
+func newClientStats(clock Clock, latencySize int) *clientStats {
+	return &clientStats{
+		transports:  make(map[string]*TransportStats),
+		latencies:   make(map[string]*latencyWindow),
+		latencySize: latencySize,
+		sizes:       make(map[string]*resourceSizes),
+		clock:       clock,
+	}
+}
+
+// recordLatency records how long a query on the named transport took.
+func (s *clientStats) recordLatency(name string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.latencies[name]
+	if !ok {
+		w = newLatencyWindow(s.latencySize)
+		s.latencies[name] = w
+	}
+	w.add(d)
+}
+
+// latencyP99 returns the 99th percentile latency of the named transport,
+// or 0 if it has fewer than minSamples samples.
+func (s *clientStats) latencyP99(name string, minSamples int) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.latencies[name]; ok {
+		return w.percentile99(minSamples)
+	}
+	return 0
+}
+
+// recordQuery records the outcome of a transport query.
+func (s *clientStats) recordQuery(name string, encrypted bool, err error) {
+	s.queries.Add(1)
+	if err != nil {
+		s.errors.Add(1)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ts, ok := s.transports[name]
+	if !ok {
+		ts = &TransportStats{Name: name, Encrypted: encrypted}
+		s.transports[name] = ts
+	}
+	ts.Queries++
+	if err != nil {
+		ts.Errors++
+		ts.LastFailure = s.clock.Now()
+		ts.LastError = err.Error()
+	} else {
+		ts.LastSuccess = s.clock.Now()
+	}
+}
+
+// Stats returns a snapshot of the client's activity counters.
+func (c *Client) Stats() Stats {
+	s := Stats{
+		Queries:        c.stats.queries.Load(),
+		Errors:         c.stats.errors.Load(),
+		CacheHits:      c.stats.cacheHits.Load(),
+		CacheMisses:    c.stats.cacheMisses.Load(),
+		CacheRefused:   c.stats.cacheRefused.Load(),
+		CallbackPanics: c.stats.panics.Load(),
+		WatchDropped:   c.stats.watchDropped.Load(),
+		Prefetches:     c.stats.prefetches.Load(),
+		Warnings:       c.stats.warnings.Load(),
+		Fallbacks:      c.stats.fallbacks.Load(),
+		CacheEntries:   -1,
+	}
+	if l, ok := c.cache.(interface{ Len() int }); ok {
+		s.CacheEntries = l.Len()
+	}
+
+	c.stats.mu.Lock()
+	for name, ts := range c.stats.transports {
+		t := *ts
+		if w, ok := c.stats.latencies[name]; ok {
+			t.LatencyP99 = w.percentile99(1)
+		}
+		s.Transports = append(s.Transports, t)
+	}
+	c.stats.mu.Unlock()
+	sort.Slice(s.Transports, func(i, j int) bool {
+		return s.Transports[i].Name < s.Transports[j].Name
+	})
+	s.Sizes = c.stats.sizeSnapshot()
+
+	return s
+}
@@ -0,0 +1,199 @@
+package resolvedb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// streamHeadKey holds the sequence number of a stream's latest event.
+	streamHeadKey = "head"
+
+	// streamReadLimit bounds the events returned by one ReadStream call.
+	streamReadLimit = 100
+
+	// streamAppendAttempts bounds retries when concurrent appends race for
+	// the same sequence number.
+	streamAppendAttempts = 10
+
+	// streamGapTimeout is how long after a later event a missing event is
+	// presumed abandoned by a failed appender and skipped.
+	streamGapTimeout = time.Minute
+)
+
+// StreamEvent is an event read from a stream.
+type StreamEvent struct {
+	Seq  uint64          `json:"seq"`  // Sequence number, starting at 1
+	Time time.Time       `json:"time"` // Time the event was appended
+	Data json.RawMessage `json:"data"` // Event payload
+}
+
+// Decode unmarshals the event payload into v.
+func (e StreamEvent) Decode(v any) error {
+	if err := unmarshalJSON(e.Data, v); err != nil {
+		return &DecodeError{Format: "json", Type: fmt.Sprintf("%T", v), Err: err}
+	}
+	return nil
+}
+
+// streamHead is the stored head pointer of a stream.
+type streamHead struct {
+	Seq uint64 `json:"seq"`
+}
+
+// streamEventKey returns the key of the event with sequence number seq.
+// Keys are zero-padded so they list in order.
+func streamEventKey(seq uint64) string {
+	return fmt.Sprintf("%016d", seq)
+}
+
+// Append adds event to the end of an append-only stream and returns its
+// sequence number. A stream is a resource holding one key per event plus
+// a head pointer; appends claim the next sequence number by writing the
+// head conditioned on the value they read (WithIfMatch), so concurrent
+// appenders never share a number. The first append creates the head only
+// if no other appender has (WithIfAbsent). Requires an API key.
+//
+// Example:
+//
+//	seq, err := client.Append(ctx, "audit-log", AuditEvent{Actor: "alice", Action: "rotate-key"})
+func (c *Client) Append(ctx context.Context, stream string, event any, opts ...RequestOption) (uint64, error) {
+	data, err := marshalJSON(event)
+	if err != nil {
+		return 0, fmt.Errorf("encode data: json marshal: %w", err)
+	}
+
+	for attempt := 0; attempt < streamAppendAttempts; attempt++ {
+		head, hash, err := c.streamHead(ctx, stream, opts)
+		if IsNotFound(err) {
+			err := c.Set(ctx, stream, streamHeadKey, streamHead{}, append(opts[:len(opts):len(opts)], WithIfAbsent())...)
+			if err != nil && !errors.Is(err, ErrConflict) {
+				return 0, err
+			}
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if hash == "" {
+			return 0, fmt.Errorf("append to stream %s: head has no content hash to write against", stream)
+		}
+
+		// Claim the next sequence number; the write fails if another
+		// appender claimed it first. The idempotency key keeps a claim
+		// retried after a lost response from failing against itself.
+		seq := head.Seq + 1
+		claim := append(opts[:len(opts):len(opts)], WithIfMatch(hash),
+			WithIdempotencyKey(newIdempotencyKey(c.config.rand, c.config.clock)))
+		err = c.Set(ctx, stream, streamHeadKey, streamHead{Seq: seq}, claim...)
+		if errors.Is(err, ErrVersionMismatch) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		record := StreamEvent{Seq: seq, Time: c.config.clock.Now(), Data: data}
+		if err := c.Set(ctx, stream, streamEventKey(seq), record, opts...); err != nil {
+			return 0, err
+		}
+		return seq, nil
+	}
+	return 0, fmt.Errorf("append to stream %s: too much contention after %d attempts", stream, streamAppendAttempts)
+}
+
+// streamHead reads the head of a stream, bypassing the cache, with the
+// content hash to condition the next claim on.
+func (c *Client) streamHead(ctx context.Context, stream string, opts []RequestOption) (streamHead, string, error) {
+	var head streamHead
+	reqConfig := newRequestConfig(ctx, append(opts[:len(opts):len(opts)], WithRefreshCache()))
+	resp, err := c.get(ctx, stream, streamHeadKey, reqConfig)
+	if err != nil {
+		return head, "", err
+	}
+	defer resp.Release()
+	if err := c.unmarshal(resp, stream, streamHeadKey, &head); err != nil {
+		return head, "", err
+	}
+	return head, resp.Hash, nil
+}
+
+// ReadStream returns up to 100 events of a stream starting at sequence
+// number fromSeq, in order. Read the next page from the last event's Seq+1;
+// an empty result means the reader has caught up. Reading stops before an
+// event that has been claimed but not yet written, unless a later event is
+// more than a minute old, in which case the missing event is presumed lost
+// and skipped.
+//
+// Example:
+//
+//	next := uint64(1)
+//	for {
+//	    events, err := client.ReadStream(ctx, "audit-log", next)
+//	    if err != nil || len(events) == 0 {
+//	        break
+//	    }
+//	    for _, e := range events {
+//	        process(e)
+//	    }
+//	    next = events[len(events)-1].Seq + 1
+//	}
+func (c *Client) ReadStream(ctx context.Context, stream string, fromSeq uint64, opts ...RequestOption) ([]StreamEvent, error) {
+	if fromSeq == 0 {
+		fromSeq = 1
+	}
+
+	var head streamHead
+	err := c.Get(ctx, stream, streamHeadKey, &head, append(opts[:len(opts):len(opts)], WithRefreshCache())...)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if head.Seq < fromSeq {
+		return nil, nil
+	}
+
+	last := head.Seq
+	if last-fromSeq >= streamReadLimit {
+		last = fromSeq + streamReadLimit - 1
+	}
+
+	// Events are immutable once written, so cached copies are safe
+	records := make([]StreamEvent, last-fromSeq+1)
+	batch := c.Batch()
+	for i := range records {
+		batch.Get(stream, streamEventKey(fromSeq+uint64(i)), &records[i], opts...)
+	}
+	results, _ := batch.Do(ctx)
+
+	events := make([]StreamEvent, 0, len(records))
+	for i, r := range results {
+		if r.Err == nil {
+			events = append(events, records[i])
+			continue
+		}
+		if !IsNotFound(r.Err) {
+			return events, r.Err
+		}
+		if !gapAbandoned(records[i+1:], results[i+1:], c.config.clock.Now()) {
+			break
+		}
+	}
+	return events, nil
+}
+
+// gapAbandoned reports whether a missing event is followed by an event
+// written more than streamGapTimeout before now.
+func gapAbandoned(records []StreamEvent, results []BatchResult, now time.Time) bool {
+	for i, r := range results {
+		if r.Err == nil {
+			return now.Sub(records[i].Time) > streamGapTimeout
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package resolvedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// SyncAction is the action Sync takes for a key.
+type SyncAction string
+
+// Sync actions.
+const (
+	SyncCopy      SyncAction = "copy"      // Key is new or changed in the source
+	SyncDelete    SyncAction = "delete"    // Key exists only in the destination
+	SyncUnchanged SyncAction = "unchanged" // Key has the same hash in both
+)
+
+// SyncOptions configures Client.Sync.
+type SyncOptions struct {
+	// DryRun computes the diff without writing to the destination.
+	DryRun bool
+
+	// Delete removes destination keys that do not exist in the source.
+	Delete bool
+
+	// Concurrency bounds the number of keys copied at once (default: 4).
+	Concurrency int
+
+	// Progress, if set, is called after each key is processed.
+	// Calls are serialized.
+	Progress func(SyncProgress)
+}
+
+// SyncProgress reports the outcome for one key during Sync.
+type SyncProgress struct {
+	Key    string     // Key processed
+	Action SyncAction // Action taken, or planned in a dry run
+	Done   int        // Keys processed so far
+	Total  int        // Keys to process
+	Err    error      // Error for this key, nil on success
+}
+
+// SyncResult summarizes a Sync run.
+type SyncResult struct {
+	Copied    []string // Keys copied to the destination
+	Deleted   []string // Keys removed from the destination
+	Unchanged []string // Keys already identical in both namespaces
+	Failed    []string // Keys that could not be synced
+}
+
+// Sync mirrors a resource from one namespace to another. It lists both
+// sides with ListDetailed, compares content hashes, and copies keys that
+// are new or changed. Keys without a reported hash are always copied.
+// Values are copied as stored, so encrypted values stay encrypted, and
+// expiring keys expire in the destination when they do in the source.
+//
+// Sync continues past per-key failures and returns them joined in the
+// error alongside a result describing what was done.
+//
+// Example:
+//
+//	result, err := client.Sync(ctx, "staging", "prod", "config", resolvedb.SyncOptions{
+//	    Delete: true,
+//	    Progress: func(p resolvedb.SyncProgress) {
+//	        log.Printf("[%d/%d] %s %s", p.Done, p.Total, p.Action, p.Key)
+//	    },
+//	})
+func (c *Client) Sync(ctx context.Context, srcNamespace, dstNamespace, resource string, opts SyncOptions) (*SyncResult, error) {
+	src, err := c.ListDetailed(ctx, resource, WithRequestNamespace(srcNamespace), WithSkipCache())
+	if err != nil {
+		return nil, fmt.Errorf("list source: %w", err)
+	}
+	dst, err := c.ListDetailed(ctx, resource, WithRequestNamespace(dstNamespace), WithSkipCache())
+	if err != nil {
+		return nil, fmt.Errorf("list destination: %w", err)
+	}
+
+	// Diff by content hash
+	dstHashes := make(map[string]string, len(dst))
+	for _, k := range dst {
+		dstHashes[k.Key] = k.Hash
+	}
+	type syncItem struct {
+		info   KeyInfo
+		action SyncAction
+	}
+	items := make([]syncItem, 0, len(src))
+	srcKeys := make(map[string]bool, len(src))
+	for _, k := range src {
+		srcKeys[k.Key] = true
+		hash, ok := dstHashes[k.Key]
+		if ok && k.Hash != "" && hash == k.Hash {
+			items = append(items, syncItem{info: k, action: SyncUnchanged})
+		} else {
+			items = append(items, syncItem{info: k, action: SyncCopy})
+		}
+	}
+	if opts.Delete {
+		for _, k := range dst {
+			if !srcKeys[k.Key] {
+				items = append(items, syncItem{info: k, action: SyncDelete})
+			}
+		}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	var (
+		mu     sync.Mutex
+		result = &SyncResult{}
+		errs   []error
+		done   int
+	)
+	record := func(item syncItem, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		key := item.info.Key
+		switch {
+		case err != nil:
+			result.Failed = append(result.Failed, key)
+			errs = append(errs, fmt.Errorf("%s %s: %w", item.action, key, err))
+		case item.action == SyncCopy:
+			result.Copied = append(result.Copied, key)
+		case item.action == SyncDelete:
+			result.Deleted = append(result.Deleted, key)
+		default:
+			result.Unchanged = append(result.Unchanged, key)
+		}
+		if opts.Progress != nil {
+			opts.Progress(SyncProgress{Key: key, Action: item.action, Done: done, Total: len(items), Err: err})
+		}
+	}
+
+	pool := NewPool(concurrency)
+	for _, item := range items {
+		item := item
+		if item.action == SyncUnchanged || opts.DryRun {
+			record(item, nil)
+			continue
+		}
+		if err := pool.Go(ctx, func() {
+			record(item, c.syncKey(ctx, srcNamespace, dstNamespace, resource, item.info, item.action))
+		}); err != nil {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+			break
+		}
+	}
+	pool.Wait()
+
+	return result, errors.Join(errs...)
+}
+
+// syncKey applies one sync action to the destination namespace.
+func (c *Client) syncKey(ctx context.Context, srcNamespace, dstNamespace, resource string, info KeyInfo, action SyncAction) error {
+	if action == SyncDelete {
+		_, err := c.DeleteWithResult(ctx, resource, info.Key, WithRequestNamespace(dstNamespace), WithIgnoreNotFound())
+		return err
+	}
+
+	src := newRequestConfig(ctx, []RequestOption{WithRequestNamespace(srcNamespace), WithSkipCache()})
+	resp, err := c.get(ctx, resource, info.Key, src)
+	if err != nil {
+		return err
+	}
+	dst := newRequestConfig(ctx, []RequestOption{WithRequestNamespace(dstNamespace)})
+	// Keep the source's expiry, so copies of expiring keys expire with them
+	switch {
+	case !resp.Expires.IsZero():
+		dst.expiresAt = resp.Expires
+	case info.TTL > 0:
+		dst.expiresAt = c.config.clock.Now().Add(info.TTL)
+	}
+	_, err = c.put(ctx, resource, info.Key, resp.Data, dst)
+	return err
+}
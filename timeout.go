@@ -0,0 +1,95 @@
+package resolvedb
+
+import (
+	"sort"
+	"time"
+)
+
+// AdaptiveTimeoutConfig configures adaptive per-attempt timeouts.
+type AdaptiveTimeoutConfig struct {
+	Multiplier float64       `json:"multiplier"`  // Timeout as a multiple of the transport's p99 latency
+	Min        time.Duration `json:"min"`         // Lower bound of the timeout
+	Max        time.Duration `json:"max"`         // Upper bound of the timeout (0 = the client timeout)
+	Window     int           `json:"window"`      // Latency samples kept per transport
+	MinSamples int           `json:"min_samples"` // Samples needed before timeouts adapt
+}
+
+// DefaultAdaptiveTimeoutConfig returns the default adaptive timeout
+// configuration.
+func DefaultAdaptiveTimeoutConfig() AdaptiveTimeoutConfig {
+	return AdaptiveTimeoutConfig{
+		Multiplier: 3,
+		Min:        100 * time.Millisecond,
+		Window:     256,
+		MinSamples: 20,
+	}
+}
+
+// latencyWindowSize returns the number of latency samples kept per
+// transport.
+func latencyWindowSize(config *clientConfig) int {
+	if config.adaptiveTimeout != nil {
+		return config.adaptiveTimeout.Window
+	}
+	return DefaultAdaptiveTimeoutConfig().Window
+}
+
+// latencyWindow holds a transport's most recent query latencies.
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+	p99     time.Duration // Cached, 0 when stale
+}
+
+func newLatencyWindow(size int) *latencyWindow {
+	return &latencyWindow{samples: make([]time.Duration, 0, size)}
+}
+
+// add records a latency sample, replacing the oldest once full.
+func (w *latencyWindow) add(d time.Duration) {
+	if len(w.samples) < cap(w.samples) {
+		w.samples = append(w.samples, d)
+	} else {
+		w.samples[w.next] = d
+		w.next = (w.next + 1) % len(w.samples)
+	}
+	w.p99 = 0
+}
+
+// percentile99 returns the 99th percentile of the samples, or 0 if there
+// are fewer than minSamples.
+func (w *latencyWindow) percentile99(minSamples int) time.Duration {
+	if len(w.samples) == 0 || len(w.samples) < minSamples {
+		return 0
+	}
+	if w.p99 == 0 {
+		sorted := append([]time.Duration(nil), w.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		w.p99 = sorted[(len(sorted)*99+99)/100-1]
+	}
+	return w.p99
+}
+
+// attemptTimeout returns the adaptive timeout for a query on the named
+// transport, or 0 if timeouts are not adaptive or the transport has too
+// few samples.
+func (c *Client) attemptTimeout(name string) time.Duration {
+	cfg := c.config.adaptiveTimeout
+	if cfg == nil {
+		return 0
+	}
+	p99 := c.stats.latencyP99(name, cfg.MinSamples)
+	if p99 == 0 {
+		return 0
+	}
+	timeout := time.Duration(cfg.Multiplier * float64(p99))
+	timeout = max(timeout, cfg.Min)
+	limit := cfg.Max
+	if limit <= 0 {
+		limit = c.config.timeout
+	}
+	if limit > 0 {
+		timeout = min(timeout, limit)
+	}
+	return timeout
+}
@@ -0,0 +1,130 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnexpectedCNAME is returned when the query name is an alias (CNAME)
+// and the request's AnswerValidation does not follow aliases.
+var ErrUnexpectedCNAME = errors.New("transport: answer is a CNAME alias")
+
+// DefaultMaxCNAMEs is the number of CNAMEs followed from the query name
+// when a request does not set MaxCNAMEs.
+const DefaultMaxCNAMEs = 8
+
+// AnswerValidation selects which answer records make up a response.
+type AnswerValidation int
+
+const (
+	// ValidateOwner uses only records owned by the query name. Unrelated
+	// records some resolvers add are ignored, and an alias fails with
+	// ErrUnexpectedCNAME. This is the default.
+	ValidateOwner AnswerValidation = iota
+
+	// ValidateOwnerCNAME also follows CNAME records from the query name
+	// and uses the records owned by the name the chain ends at.
+	ValidateOwnerCNAME
+
+	// ValidateNone uses every record in the answer section, regardless
+	// of owner.
+	ValidateNone
+)
+
+// String returns the validation name.
+func (v AnswerValidation) String() string {
+	switch v {
+	case ValidateOwner:
+		return "owner"
+	case ValidateOwnerCNAME:
+		return "owner_cname"
+	case ValidateNone:
+		return "none"
+	default:
+		return fmt.Sprintf("AnswerValidation(%d)", int(v))
+	}
+}
+
+// finishResponse selects the records of resp.Answers that answer req, and
+// assembles them into the response payload.
+func finishResponse(resp *Response, req *Request) (*Response, error) {
+	answers, owner, err := selectAnswers(resp.Answers, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.CanonicalName = owner
+	resp.Records = make([][]byte, 0, len(answers))
+	resp.RecordTTLs = make([]uint32, 0, len(answers))
+	for _, a := range answers {
+		resp.Records = append(resp.Records, a.Data)
+		resp.RecordTTLs = append(resp.RecordTTLs, a.TTL)
+	}
+
+	// Restore record order and combine all records
+	records, ttls, manifest, err := extractManifest(resp.Records, resp.RecordTTLs)
+	if err != nil {
+		return nil, err
+	}
+	records, ttls, err = assembleRecords(records, ttls)
+	if err != nil {
+		return nil, err
+	}
+	resp.Manifest = manifest
+	resp.Records = records
+	resp.RecordTTLs = ttls
+	resp.TTL = minTTL(ttls)
+	resp.Data = joinRecords(records)
+	return resp, nil
+}
+
+// selectAnswers returns the answers owned by the query name, or by the
+// end of its CNAME chain when req allows aliases, and that owner name.
+func selectAnswers(answers []Answer, req *Request) ([]Answer, string, error) {
+	if req.Validation == ValidateNone {
+		return answers, "", nil
+	}
+
+	maxCNAMEs := req.MaxCNAMEs
+	if maxCNAMEs <= 0 {
+		maxCNAMEs = DefaultMaxCNAMEs
+	}
+	owner := canonicalName(req.Name)
+	for hops := 0; ; hops++ {
+		target, ok := cnameTarget(answers, owner)
+		if !ok {
+			break
+		}
+		if req.Validation != ValidateOwnerCNAME {
+			return nil, "", fmt.Errorf("%w: %s is an alias for %s", ErrUnexpectedCNAME, owner, target)
+		}
+		if hops == maxCNAMEs {
+			return nil, "", fmt.Errorf("%w: CNAME chain from %s exceeds %d aliases", ErrMalformed, req.Name, maxCNAMEs)
+		}
+		owner = target
+	}
+
+	var selected []Answer
+	for _, a := range answers {
+		if a.Type != TypeCNAME && canonicalName(a.Name) == owner {
+			selected = append(selected, a)
+		}
+	}
+	return selected, owner, nil
+}
+
+// cnameTarget returns the target of the CNAME record owned by name.
+func cnameTarget(answers []Answer, name string) (string, bool) {
+	for _, a := range answers {
+		if a.Type == TypeCNAME && canonicalName(a.Name) == name {
+			return canonicalName(string(a.Data)), true
+		}
+	}
+	return "", false
+}
+
+// canonicalName lowercases name and strips its trailing dot, so names
+// compare as DNS compares them.
+func canonicalName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
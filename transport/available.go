@@ -0,0 +1,12 @@
+package transport
+
+// compiled holds the names of the transports built in. Files excluded by
+// build tags register their transport in init, so this lists the rest.
+var compiled = map[string]bool{"dns": true, "memory": true}
+
+// Available reports whether the transport called name (as returned by its
+// Name method) is included in this build. Transports can be left out with
+// build tags; see the package documentation.
+func Available(name string) bool {
+	return compiled[name]
+}
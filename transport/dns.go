@@ -12,6 +12,7 @@ import (
 type DNS struct {
 	servers []string
 	timeout time.Duration
+	pool    *udpPool // nil unless WithDNSSocketPool is set
 }
 
 // DNSOption configures a DNS transport.
@@ -31,6 +32,28 @@ func WithDNSTimeout(timeout time.Duration) DNSOption {
 	}
 }
 
+// WithDNSSocketPool shares size UDP sockets between queries instead of
+// opening a socket per query, which under high query rates can exhaust
+// ephemeral ports. Answers are matched to queries by server address and
+// transaction ID, and must repeat the question asked.
+//
+// Pooled sockets keep their source ports, so answers are only as hard to
+// spoof as the 16-bit transaction ID; prefer an encrypted transport where
+// that matters. Call Close to release the sockets.
+//
+// Example:
+//
+//	dns := transport.NewDNS(transport.WithDNSSocketPool(8))
+//	defer dns.Close()
+func WithDNSSocketPool(size int) DNSOption {
+	return func(d *DNS) {
+		d.pool = nil
+		if size > 0 {
+			d.pool = newUDPPool(size)
+		}
+	}
+}
+
 // NewDNS creates a new traditional DNS transport.
 func NewDNS(opts ...DNSOption) *DNS {
 	d := &DNS{
@@ -45,15 +68,44 @@ func NewDNS(opts ...DNSOption) *DNS {
 
 func (d *DNS) Name() string { return "dns" }
 
+// Endpoints returns the DNS servers queried, in order.
+func (d *DNS) Endpoints() []string { return append([]string(nil), d.servers...) }
+
 // IsEncrypted returns false - traditional DNS is not encrypted.
 // SECURITY WARNING: Do not use this transport for authenticated requests.
 func (d *DNS) IsEncrypted() bool { return false }
 
-func (d *DNS) Close() error { return nil }
+// Close releases pooled sockets. Queries on a closed transport with a
+// socket pool fail with ErrClosed.
+func (d *DNS) Close() error {
+	if d.pool != nil {
+		return d.pool.close()
+	}
+	return nil
+}
 
-// Query sends a DNS query over UDP.
+// Query sends a DNS query over UDP, retrying over TCP if the answer is
+// truncated (RFC 1035 4.2.1).
 func (d *DNS) Query(ctx context.Context, req *Request) (*Response, error) {
-	wireMsg := buildDNSQuery(req.Name, req.Type)
+	resp, err := d.queryUDP(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Truncated {
+		return d.QueryTCP(ctx, req)
+	}
+	return finishResponse(resp, req)
+}
+
+// queryUDP sends a DNS query over UDP to each server until one answers.
+func (d *DNS) queryUDP(ctx context.Context, req *Request) (*Response, error) {
+	query := getQueryBuffer()
+	defer putQueryBuffer(query)
+	var err error
+	if *query, err = appendDNSQuery(*query, req.Name, req.Type, req.Rand); err != nil {
+		return nil, err
+	}
+	wireMsg := *query
 
 	var lastErr error
 	for _, server := range d.servers {
@@ -67,6 +119,18 @@ func (d *DNS) Query(ctx context.Context, req *Request) (*Response, error) {
 }
 
 func (d *DNS) queryServer(ctx context.Context, server string, query []byte) (*Response, error) {
+	if d.pool != nil {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			deadline = time.Now().Add(d.timeout)
+		}
+		msg, err := d.pool.query(ctx, server, query, deadline)
+		if err != nil {
+			return nil, err
+		}
+		return parseDNSResponse(msg)
+	}
+
 	// Create UDP connection
 	dialer := net.Dialer{Timeout: d.timeout}
 	conn, err := dialer.DialContext(ctx, "udp", server)
@@ -87,31 +151,32 @@ func (d *DNS) queryServer(ctx context.Context, server string, query []byte) (*Re
 		return nil, fmt.Errorf("write: %w", err)
 	}
 
-	// Read response
-	buf := make([]byte, 65535)
-	n, err := conn.Read(buf)
+	// Read response into a pooled buffer
+	buf := getMessageBuffer()
+	defer putMessageBuffer(buf)
+	n, err := conn.Read(*buf)
 	if err != nil {
 		return nil, fmt.Errorf("read: %w", err)
 	}
 
-	return parseDNSResponse(buf[:n])
+	return parseDNSResponse((*buf)[:n])
 }
 
 // QueryTCP sends a DNS query over TCP (for large responses).
 func (d *DNS) QueryTCP(ctx context.Context, req *Request) (*Response, error) {
-	wireMsg := buildDNSQuery(req.Name, req.Type)
-
-	// Prepend 2-byte length for TCP
-	tcpMsg := make([]byte, len(wireMsg)+2)
-	tcpMsg[0] = byte(len(wireMsg) >> 8)
-	tcpMsg[1] = byte(len(wireMsg) & 0xFF)
-	copy(tcpMsg[2:], wireMsg)
+	query := getQueryBuffer()
+	defer putQueryBuffer(query)
+	var err error
+	if *query, err = appendTCPQuery(*query, req.Name, req.Type, req.Rand); err != nil {
+		return nil, err
+	}
+	tcpMsg := *query
 
 	var lastErr error
 	for _, server := range d.servers {
 		resp, err := d.queryServerTCP(ctx, server, tcpMsg)
 		if err == nil {
-			return resp, nil
+			return finishResponse(resp, req)
 		}
 		lastErr = err
 	}
@@ -136,23 +201,39 @@ func (d *DNS) queryServerTCP(ctx context.Context, server string, query []byte) (
 		return nil, fmt.Errorf("write: %w", err)
 	}
 
+	return readTCPResponse(conn)
+}
+
+// appendTCPQuery appends a DNS query prefixed with its 2-byte length (RFC 1035 4.2.2).
+func appendTCPQuery(dst []byte, name string, qtype uint16, rnd io.Reader) ([]byte, error) {
+	start := len(dst)
+	dst, err := appendDNSQuery(append(dst, 0, 0), name, qtype, rnd)
+	if err != nil {
+		return dst[:start], err
+	}
+	length := len(dst) - start - 2
+	dst[start] = byte(length >> 8)
+	dst[start+1] = byte(length & 0xFF)
+	return dst, nil
+}
+
+// readTCPResponse reads a length-prefixed DNS message into a pooled buffer and parses it.
+func readTCPResponse(r io.Reader) (*Response, error) {
 	// Read length - use io.ReadFull to ensure complete read
-	lenBuf := make([]byte, 2)
-	if _, err := io.ReadFull(conn, lenBuf); err != nil {
+	var lenBuf [2]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
 		return nil, fmt.Errorf("read length: %w", err)
 	}
+	// A 2-byte length bounds the response at 64KB (per security review)
 	length := int(lenBuf[0])<<8 | int(lenBuf[1])
 
-	// Limit response size (64KB max per security review)
-	if length > 65535 {
-		return nil, fmt.Errorf("response too large: %d bytes", length)
-	}
-
 	// Read response - use io.ReadFull to ensure complete read
-	buf := make([]byte, length)
-	if _, err := io.ReadFull(conn, buf); err != nil {
+	buf := getMessageBuffer()
+	defer putMessageBuffer(buf)
+	msg := (*buf)[:length]
+	if _, err := io.ReadFull(r, msg); err != nil {
 		return nil, fmt.Errorf("read: %w", err)
 	}
 
-	return parseDNSResponse(buf)
+	return parseDNSResponse(msg)
 }
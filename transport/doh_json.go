@@ -1,21 +1,26 @@
+//go:build !resolvedb_nohttp && !resolvedb_nodohjson
+
 package transport
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
+func init() { compiled["doh-json"] = true }
+
 // DoHJSON implements DNS-over-HTTPS using JSON API format.
 // This follows the Google/Cloudflare JSON API style.
 type DoHJSON struct {
 	baseURL    string
 	httpClient *http.Client
+	ownClient  bool // httpClient was created by NewDoHJSON
 }
 
 // DoHJSONOption configures a DoHJSON transport.
@@ -32,6 +37,7 @@ func WithDoHJSONURL(url string) DoHJSONOption {
 func WithDoHJSONClient(client *http.Client) DoHJSONOption {
 	return func(d *DoHJSON) {
 		d.httpClient = client
+		d.ownClient = false
 	}
 }
 
@@ -42,6 +48,7 @@ func NewDoHJSON(opts ...DoHJSONOption) *DoHJSON {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		ownClient: true,
 	}
 	for _, opt := range opts {
 		opt(d)
@@ -51,6 +58,9 @@ func NewDoHJSON(opts ...DoHJSONOption) *DoHJSON {
 
 func (d *DoHJSON) Name() string { return "doh-json" }
 
+// Endpoints returns the JSON API endpoint URL.
+func (d *DoHJSON) Endpoints() []string { return []string{d.baseURL} }
+
 func (d *DoHJSON) IsEncrypted() bool { return true }
 
 func (d *DoHJSON) Close() error { return nil }
@@ -68,28 +78,28 @@ func (d *DoHJSON) Query(ctx context.Context, req *Request) (*Response, error) {
 	q.Set("type", strconv.Itoa(int(req.Type)))
 	u.RawQuery = q.Encode()
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-	httpReq.Header.Set("Accept", "application/dns-json")
-
-	resp, err := d.httpClient.Do(httpReq)
+	resp, err := doHTTP(d.httpClient, d.ownClient, req.Replayable, func() (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Accept", "application/dns-json")
+		return httpReq, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("http request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("http status %d", resp.StatusCode)
+		return nil, newHTTPError(resp)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	r, err := readBody(resp.Body, parseJSONResponse)
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil, err
 	}
-
-	return parseJSONResponse(body)
+	return finishResponse(r, req)
 }
 
 // jsonDNSResponse represents the JSON API response format.
@@ -118,14 +128,23 @@ type jsonDNSResponse struct {
 	} `json:"Authority"`
 }
 
-// parseJSONResponse parses a JSON API DNS response.
+// parseJSONResponse parses the answers of a JSON API DNS response;
+// finishResponse then assembles the payload.
 func parseJSONResponse(data []byte) (*Response, error) {
 	var jsonResp jsonDNSResponse
 	if err := json.Unmarshal(data, &jsonResp); err != nil {
 		return nil, fmt.Errorf("json unmarshal: %w", err)
 	}
 
-	resp := &Response{}
+	if err := checkRcode(jsonResp.Status); err != nil {
+		return nil, err
+	}
+
+	if len(jsonResp.Answer) > maxAnswerRecords {
+		return nil, fmt.Errorf("%w: %d answers exceeds limit of %d", ErrMalformed, len(jsonResp.Answer), maxAnswerRecords)
+	}
+
+	resp := &Response{Truncated: jsonResp.TC}
 
 	for _, answer := range jsonResp.Answer {
 		// Remove surrounding quotes from TXT records
@@ -133,16 +152,16 @@ func parseJSONResponse(data []byte) (*Response, error) {
 		if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
 			data = data[1 : len(data)-1]
 		}
-
-		resp.Records = append(resp.Records, []byte(data))
-		if resp.TTL == 0 {
-			resp.TTL = uint32(answer.TTL)
+		if answer.Type == int(TypeCNAME) {
+			data = strings.TrimSuffix(data, ".")
 		}
-	}
 
-	// Combine all records
-	for _, r := range resp.Records {
-		resp.Data = append(resp.Data, r...)
+		resp.Answers = append(resp.Answers, Answer{
+			Name: strings.TrimSuffix(answer.Name, "."),
+			Type: uint16(answer.Type),
+			TTL:  uint32(answer.TTL),
+			Data: []byte(data),
+		})
 	}
 
 	return resp, nil
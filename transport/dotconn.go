@@ -0,0 +1,193 @@
+//go:build !resolvedb_nodot
+
+package transport
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// dotConn is a persistent DoT connection carrying pipelined queries
+// (RFC 7766 6.2.1.1). Queries are written as they arrive and answers,
+// which may come back in any order, are matched by message ID.
+type dotConn struct {
+	conn     net.Conn
+	inFlight chan struct{} // Bounds outstanding queries
+
+	wmu sync.Mutex // Serializes writes
+
+	mu      sync.Mutex
+	pending map[uint16]chan dotAnswer
+	err     error // Set once the connection has failed
+}
+
+// dotAnswer is a message read for a pipelined query, or the error that
+// ended the connection.
+type dotAnswer struct {
+	buf *[]byte // Pooled buffer holding the message
+	n   int
+	err error
+}
+
+func newDoTConn(conn net.Conn, maxInFlight int) *dotConn {
+	c := &dotConn{
+		conn:     conn,
+		inFlight: make(chan struct{}, maxInFlight),
+		pending:  make(map[uint16]chan dotAnswer),
+	}
+	go c.read()
+	return c
+}
+
+// broken reports whether the connection has failed.
+func (c *dotConn) broken() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err != nil
+}
+
+// close closes the connection; outstanding queries fail with err.
+func (c *dotConn) close(err error) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	c.mu.Unlock()
+	c.conn.Close()
+}
+
+// read dispatches answers until the connection fails, then fails the
+// queries still waiting.
+func (c *dotConn) read() {
+	for {
+		buf, n, err := readTCPMessage(c.conn)
+		if err != nil {
+			c.mu.Lock()
+			if c.err == nil {
+				c.err = err
+			}
+			err = c.err
+			for id, ch := range c.pending {
+				ch <- dotAnswer{err: err}
+				delete(c.pending, id)
+			}
+			c.mu.Unlock()
+			c.conn.Close()
+			return
+		}
+		if n < dnsHeaderSize {
+			putMessageBuffer(buf)
+			continue
+		}
+
+		id := uint16((*buf)[0])<<8 | uint16((*buf)[1])
+		c.mu.Lock()
+		ch, ok := c.pending[id]
+		delete(c.pending, id)
+		c.mu.Unlock()
+		// Answers for abandoned queries are dropped
+		if !ok {
+			putMessageBuffer(buf)
+			continue
+		}
+		ch <- dotAnswer{buf: buf, n: n}
+	}
+}
+
+// register reserves the message ID of a length-prefixed query, choosing a
+// new random ID if another outstanding query already uses it.
+func (c *dotConn) register(msg []byte) (uint16, chan dotAnswer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, nil, c.err
+	}
+	id := uint16(msg[2])<<8 | uint16(msg[3])
+	for {
+		if _, taken := c.pending[id]; !taken {
+			break
+		}
+		if _, err := io.ReadFull(rand.Reader, msg[2:4]); err != nil {
+			return 0, nil, fmt.Errorf("message id: %w", err)
+		}
+		id = uint16(msg[2])<<8 | uint16(msg[3])
+	}
+	ch := make(chan dotAnswer, 1)
+	c.pending[id] = ch
+	return id, ch, nil
+}
+
+// unregister abandons an outstanding query.
+func (c *dotConn) unregister(id uint16) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+// query sends a length-prefixed query and waits for its answer until
+// deadline.
+func (c *dotConn) query(ctx context.Context, msg []byte, deadline time.Time) (*Response, error) {
+	select {
+	case c.inFlight <- struct{}{}:
+		defer func() { <-c.inFlight }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	id, ch, err := c.register(msg)
+	if err != nil {
+		return nil, err
+	}
+	defer c.unregister(id)
+
+	c.wmu.Lock()
+	c.conn.SetWriteDeadline(deadline)
+	_, err = c.conn.Write(msg)
+	c.wmu.Unlock()
+	if err != nil {
+		// A partial write leaves the stream unusable
+		c.close(err)
+		return nil, fmt.Errorf("write: %w", err)
+	}
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case ans := <-ch:
+		if ans.err != nil {
+			return nil, fmt.Errorf("read: %w", ans.err)
+		}
+		defer putMessageBuffer(ans.buf)
+		answer := (*ans.buf)[:ans.n]
+		if !sameQuestion(msg[2:], answer) {
+			return nil, fmt.Errorf("%w: answer does not match question", ErrMalformed)
+		}
+		return parseDNSResponse(answer)
+	case <-timer.C:
+		return nil, fmt.Errorf("read: %w", context.DeadlineExceeded)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// readTCPMessage reads a length-prefixed DNS message into a pooled buffer
+// and returns the buffer and the message length.
+func readTCPMessage(r io.Reader) (*[]byte, int, error) {
+	var lenBuf [2]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, 0, err
+	}
+	length := int(lenBuf[0])<<8 | int(lenBuf[1])
+
+	buf := getMessageBuffer()
+	if _, err := io.ReadFull(r, (*buf)[:length]); err != nil {
+		putMessageBuffer(buf)
+		return nil, 0, err
+	}
+	return buf, length, nil
+}
@@ -0,0 +1,73 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrClosed is returned by queries on a transport that has been closed.
+var ErrClosed = errors.New("transport: closed")
+
+// maxErrorBodySize bounds the response body kept by HTTPError.
+const maxErrorBodySize = 4096
+
+// HTTPError reports a non-200 HTTP status from a DoH endpoint.
+type HTTPError struct {
+	StatusCode  int           // HTTP status code
+	RetryAfter  time.Duration // Delay from the Retry-After header, 0 if absent
+	ContentType string        // Content-Type of the response body
+	Body        []byte        // Start of the response body, at most 4KB
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http status %d", e.StatusCode)
+}
+
+// isConnectivityError reports whether err means a transport could not get
+// an answer from its server: the connection failed or timed out, or the
+// server failed or refused the query (an HTTP 5xx status, SERVFAIL or
+// REFUSED). An HTTP 4xx status, such as 401, 403, 404 or 429, comes from
+// a reachable server that rejected the query itself.
+func isConnectivityError(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+	return err != nil
+}
+
+// DNS response codes reported by RcodeError (RFC 1035 4.1.1).
+const (
+	RcodeServerFailure = 2
+	RcodeNameError     = 3
+	RcodeRefused       = 5
+)
+
+// RcodeError reports a DNS response whose response code signals that the
+// server failed or refused to answer.
+type RcodeError struct {
+	Rcode int // DNS response code
+}
+
+func (e *RcodeError) Error() string {
+	switch e.Rcode {
+	case RcodeServerFailure:
+		return "dns server failure"
+	case RcodeNameError:
+		return "dns name does not exist"
+	case RcodeRefused:
+		return "dns query refused"
+	default:
+		return fmt.Sprintf("dns rcode %d", e.Rcode)
+	}
+}
+
+// checkRcode returns an RcodeError for server failure and refused
+// response codes, and nil otherwise.
+func checkRcode(rcode int) error {
+	if rcode == RcodeServerFailure || rcode == RcodeRefused {
+		return &RcodeError{Rcode: rcode}
+	}
+	return nil
+}
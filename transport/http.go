@@ -0,0 +1,103 @@
+//go:build !resolvedb_nohttp
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// doHTTP sends the request built by newReq. If the request fails because
+// the pooled connection it was sent on had been closed by the peer (an
+// HTTP/2 GOAWAY, a broken pipe or a reset), and replayable is true, it is
+// resent once, separately from the client's retry policy. The server may
+// have processed the first attempt, so only queries marked
+// Request.Replayable are resent. Idle connections are dropped before the
+// retry only when client is owned by the transport, never a caller's.
+func doHTTP(client *http.Client, owned, replayable bool, newReq func() (*http.Request, error)) (*http.Response, error) {
+	resp, reused, err := doTraced(client, newReq)
+	if err != nil && reused && replayable && isConnReuseError(err) {
+		if owned {
+			// Drop pooled connections so the retry dials a new one
+			client.CloseIdleConnections()
+		}
+		resp, _, err = doTraced(client, newReq)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// doTraced sends the request built by newReq, reporting whether it went
+// out on a reused connection.
+func doTraced(client *http.Client, newReq func() (*http.Request, error)) (resp *http.Response, reused bool, err error) {
+	httpReq, err := newReq()
+	if err != nil {
+		return nil, false, fmt.Errorf("create request: %w", err)
+	}
+	ctx := httptrace.WithClientTrace(httpReq.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) { reused = info.Reused },
+	})
+	resp, err = client.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			// The query was canceled or timed out, not the connection lost
+			reused = false
+		}
+		return nil, reused, fmt.Errorf("http request: %w", err)
+	}
+	return resp, reused, nil
+}
+
+// isConnReuseError reports whether err shows that the connection carrying
+// a request was closed by the peer, rather than that the request failed.
+// HTTP/2 transports do not export a common GOAWAY error type, so GOAWAY
+// is recognized by its message; net/http's bundled HTTP/2 transport also
+// resends requests a GOAWAY left unprocessed itself.
+func isConnReuseError(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		strings.Contains(err.Error(), goAwayMessage)
+}
+
+// goAwayMessage is part of the error HTTP/2 transports return for
+// requests on a connection the server closed with GOAWAY.
+const goAwayMessage = "server sent GOAWAY"
+
+// newHTTPError builds an HTTPError from a failed HTTP response, keeping
+// the start of the body so callers can parse an error document.
+func newHTTPError(resp *http.Response) *HTTPError {
+	// A failed read leaves a partial body, which is still useful context
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	return &HTTPError{
+		StatusCode:  resp.StatusCode,
+		RetryAfter:  parseRetryAfter(resp.Header.Get("Retry-After")),
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an
+// HTTP date. Returns 0 if the header is absent or invalid.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package transport
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Memory is an in-process transport that answers queries from a table of
+// TXT data keyed by query name. It never touches the network, which makes
+// it suitable for demos, documentation examples and tests.
+type Memory struct {
+	mu      sync.RWMutex
+	answers map[string][]byte
+	missing []byte
+}
+
+// MemoryOption configures a Memory transport.
+type MemoryOption func(*Memory)
+
+// WithMemoryMissing sets the TXT data answered for names without an entry.
+// Without it, such queries fail with an RcodeError for NXDOMAIN.
+func WithMemoryMissing(data []byte) MemoryOption {
+	return func(m *Memory) {
+		m.missing = data
+	}
+}
+
+// NewMemory creates an empty in-memory transport.
+//
+// Example:
+//
+//	mem := transport.NewMemory()
+//	mem.Set("get.tokyo.weather.public.v1.resolvedb.net", []byte("v=rdb1;s=ok;t=json;tc=21"))
+//	client, _ := resolvedb.New(resolvedb.WithTransports(mem))
+func NewMemory(opts ...MemoryOption) *Memory {
+	m := &Memory{answers: make(map[string][]byte)}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *Memory) Name() string { return "memory" }
+
+// IsEncrypted returns true: queries never leave the process.
+func (m *Memory) IsEncrypted() bool { return true }
+
+func (m *Memory) Close() error { return nil }
+
+// Set stores the TXT data answered for name. Names are matched without
+// regard to case or a trailing dot.
+func (m *Memory) Set(name string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.answers[memoryKey(name)] = data
+}
+
+// Delete removes the answer for name.
+func (m *Memory) Delete(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.answers, memoryKey(name))
+}
+
+// Query answers req from the table.
+func (m *Memory) Query(ctx context.Context, req *Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	data, ok := m.answers[memoryKey(req.Name)]
+	if !ok {
+		data = m.missing
+	}
+	m.mu.RUnlock()
+	if data == nil {
+		return nil, &RcodeError{Rcode: RcodeNameError}
+	}
+
+	data = append([]byte(nil), data...)
+	return &Response{Data: data, Records: [][]byte{data}}, nil
+}
+
+// memoryKey normalizes a query name for lookup.
+func memoryKey(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,76 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxMessageSize is the largest DNS message representable on the wire.
+const maxMessageSize = 65535
+
+// messagePool holds maximum-size buffers for reading DNS messages.
+var messagePool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, maxMessageSize)
+		return &buf
+	},
+}
+
+// getMessageBuffer returns a pooled buffer of maxMessageSize bytes.
+func getMessageBuffer() *[]byte {
+	return messagePool.Get().(*[]byte)
+}
+
+// putMessageBuffer returns a buffer to the pool.
+// Parsed responses never alias pooled buffers, so this is safe after parsing.
+func putMessageBuffer(buf *[]byte) {
+	messagePool.Put(buf)
+}
+
+// queryPool holds buffers for encoding DNS queries.
+var queryPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, 512)
+		return &buf
+	},
+}
+
+// getQueryBuffer returns an empty pooled query buffer.
+func getQueryBuffer() *[]byte {
+	buf := queryPool.Get().(*[]byte)
+	*buf = (*buf)[:0]
+	return buf
+}
+
+// putQueryBuffer returns a query buffer to the pool.
+func putQueryBuffer(buf *[]byte) {
+	if cap(*buf) > maxMessageSize {
+		return
+	}
+	queryPool.Put(buf)
+}
+
+// bodyPool holds buffers for reading HTTP response bodies.
+var bodyPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// readBody reads a DNS message body of at most maxMessageSize bytes into a
+// pooled buffer and passes it to parse. The buffer is recycled afterwards.
+func readBody(r io.Reader, parse func([]byte) (*Response, error)) (*Response, error) {
+	buf := bodyPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bodyPool.Put(buf)
+
+	n, err := buf.ReadFrom(io.LimitReader(r, maxMessageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if n > maxMessageSize {
+		return nil, fmt.Errorf("response too large: more than %d bytes", maxMessageSize)
+	}
+
+	return parse(buf.Bytes())
+}
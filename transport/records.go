@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/resolvedb/resolvedb-go/uqrp"
+)
+
+// manifestPrefix marks the manifest record of a chunked answer.
+var manifestPrefix = []byte("m:")
+
+// Manifest describes the records of a chunked answer. Servers send it as
+// an extra record, "m:" followed by the manifest JSON, alongside the
+// sequenced records it describes.
+type Manifest struct {
+	Chunks int      `json:"n"` // Number of records
+	Hashes []string `json:"h"` // Hex SHA-256 of each record, prefix stripped, in sequence order
+	Root   string   `json:"r"` // Hex Merkle root over Hashes (RFC 6962)
+}
+
+// extractManifest removes the manifest record, if any, from records and
+// their parallel TTLs. The manifest is checked for consistency with the
+// records but not verified against them.
+func extractManifest(records [][]byte, ttls []uint32) ([][]byte, []uint32, *Manifest, error) {
+	for i, r := range records {
+		if !bytes.HasPrefix(r, manifestPrefix) {
+			continue
+		}
+		var m Manifest
+		if err := json.Unmarshal(r[len(manifestPrefix):], &m); err != nil {
+			return nil, nil, nil, fmt.Errorf("%w: manifest record: %v", ErrMalformed, err)
+		}
+		rest := append(records[:i:i], records[i+1:]...)
+		restTTLs := append(ttls[:i:i], ttls[i+1:]...)
+		if m.Chunks != len(rest) || len(m.Hashes) != m.Chunks {
+			return nil, nil, nil, fmt.Errorf("%w: manifest lists %d records with %d hashes, answer has %d",
+				ErrMalformed, m.Chunks, len(m.Hashes), len(rest))
+		}
+		return rest, restTTLs, &m, nil
+	}
+	return records, ttls, nil, nil
+}
+
+// assembleRecords restores the server-side order of multi-record answers.
+//
+// DNS does not guarantee the order of records in an answer, so servers that
+// split a payload across several TXT records prefix each one with its
+// sequence index ("<idx>:<data>", see uqrp.Sequence). When every record
+// carries a prefix, the records are sorted by index and the prefixes are
+// stripped. Otherwise the records are returned in answer order. The record
+// TTLs in ttls, parallel to records, are reordered with them.
+func assembleRecords(records [][]byte, ttls []uint32) ([][]byte, []uint32, error) {
+	if len(records) == 0 {
+		return records, ttls, nil
+	}
+
+	type sequenced struct {
+		index int
+		data  []byte
+		ttl   uint32
+	}
+
+	seq := make([]sequenced, 0, len(records))
+	for i, r := range records {
+		idx, data, ok := uqrp.SplitSequence(r)
+		if !ok {
+			// Unprefixed record - keep answer order
+			return records, ttls, nil
+		}
+		seq = append(seq, sequenced{index: idx, data: data, ttl: ttls[i]})
+	}
+
+	sort.Slice(seq, func(i, j int) bool { return seq[i].index < seq[j].index })
+
+	ordered := make([][]byte, len(seq))
+	orderedTTLs := make([]uint32, len(seq))
+	for i, s := range seq {
+		if s.index != i {
+			return nil, nil, fmt.Errorf("%w: expected index %d, got %d", uqrp.ErrSequence, i, s.index)
+		}
+		ordered[i] = s.data
+		orderedTTLs[i] = s.ttl
+	}
+	return ordered, orderedTTLs, nil
+}
+
+// minTTL returns the smallest TTL in ttls, or 0 if ttls is empty. A
+// payload split across records is only as fresh as its shortest-lived part.
+func minTTL(ttls []uint32) uint32 {
+	if len(ttls) == 0 {
+		return 0
+	}
+	m := ttls[0]
+	for _, ttl := range ttls[1:] {
+		m = min(m, ttl)
+	}
+	return m
+}
+
+// joinRecords concatenates record payloads in order.
+func joinRecords(records [][]byte) []byte {
+	n := 0
+	for _, r := range records {
+		n += len(r)
+	}
+	if n == 0 {
+		return nil
+	}
+	data := make([]byte, 0, n)
+	for _, r := range records {
+		data = append(data, r...)
+	}
+	return data
+}
@@ -0,0 +1,103 @@
+package transport
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// SvcParamKeys read by ParseServiceBinding (RFC 9460 14.3.2, RFC 9461 5).
+const (
+	svcParamALPN          = 1
+	svcParamNoDefaultALPN = 2
+	svcParamPort          = 3
+	svcParamIPv4Hint      = 4
+	svcParamIPv6Hint      = 6
+	svcParamDoHPath       = 7
+)
+
+// ServiceBinding is the data of an SVCB or HTTPS record (RFC 9460).
+// Parameters this package does not use are skipped.
+type ServiceBinding struct {
+	Priority      uint16       // 0 for AliasMode, otherwise lower is preferred
+	Target        string       // Target name without trailing dot, "" for the owner name
+	ALPN          []string     // Protocols offered, e.g. "h2" or "dot"
+	NoDefaultALPN bool         // The scheme's default protocol is not offered
+	Port          uint16       // Port, 0 for the protocol default
+	IPv4Hint      []netip.Addr // Addresses of the target, if given
+	IPv6Hint      []netip.Addr
+	DoHPath       string // DoH URI template path, e.g. "/dns-query{?dns}" (RFC 9461)
+}
+
+// AliasMode reports whether the record delegates to Target's records
+// instead of describing an endpoint.
+func (b ServiceBinding) AliasMode() bool {
+	return b.Priority == 0
+}
+
+// ParseServiceBinding parses the RDATA of an SVCB or HTTPS record, as
+// found in Answer.Data. Malformed data returns an error wrapping
+// ErrMalformed.
+func ParseServiceBinding(rdata []byte) (ServiceBinding, error) {
+	var b ServiceBinding
+	if len(rdata) < 3 {
+		return b, fmt.Errorf("%w: service binding too short", ErrMalformed)
+	}
+	b.Priority = uint16(rdata[0])<<8 | uint16(rdata[1])
+
+	// The target name is never compressed (RFC 9460 2.2)
+	target, next, err := readName(rdata, 2)
+	if err != nil {
+		return b, err
+	}
+	b.Target = target
+
+	for pos := next; pos < len(rdata); {
+		if pos+4 > len(rdata) {
+			return b, fmt.Errorf("%w: truncated service parameter", ErrMalformed)
+		}
+		key := int(rdata[pos])<<8 | int(rdata[pos+1])
+		length := int(rdata[pos+2])<<8 | int(rdata[pos+3])
+		pos += 4
+		if pos+length > len(rdata) {
+			return b, fmt.Errorf("%w: truncated service parameter %d", ErrMalformed, key)
+		}
+		value := rdata[pos : pos+length]
+		pos += length
+
+		switch key {
+		case svcParamALPN:
+			for i := 0; i < len(value); {
+				n := int(value[i])
+				if n == 0 || i+1+n > len(value) {
+					return b, fmt.Errorf("%w: invalid alpn parameter", ErrMalformed)
+				}
+				b.ALPN = append(b.ALPN, string(value[i+1:i+1+n]))
+				i += 1 + n
+			}
+		case svcParamNoDefaultALPN:
+			b.NoDefaultALPN = true
+		case svcParamPort:
+			if length != 2 {
+				return b, fmt.Errorf("%w: invalid port parameter", ErrMalformed)
+			}
+			b.Port = uint16(value[0])<<8 | uint16(value[1])
+		case svcParamIPv4Hint:
+			if length == 0 || length%4 != 0 {
+				return b, fmt.Errorf("%w: invalid ipv4hint parameter", ErrMalformed)
+			}
+			for i := 0; i < length; i += 4 {
+				b.IPv4Hint = append(b.IPv4Hint, netip.AddrFrom4([4]byte(value[i:i+4])))
+			}
+		case svcParamIPv6Hint:
+			if length == 0 || length%16 != 0 {
+				return b, fmt.Errorf("%w: invalid ipv6hint parameter", ErrMalformed)
+			}
+			for i := 0; i < length; i += 16 {
+				b.IPv6Hint = append(b.IPv6Hint, netip.AddrFrom16([16]byte(value[i:i+16])))
+			}
+		case svcParamDoHPath:
+			b.DoHPath = string(value)
+		}
+	}
+	return b, nil
+}
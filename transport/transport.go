@@ -1,9 +1,23 @@
 // Package transport provides DNS transport implementations for ResolveDB.
+//
+// Builds for constrained devices can leave transports out with build tags:
+//
+//	resolvedb_nohttp     DoH and DoH JSON, and with them net/http
+//	resolvedb_nodohjson  DoH JSON only
+//	resolvedb_nodot      DoT
+//
+// Available reports which transports a build includes.
 package transport
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Transport defines the interface for DNS query transports.
@@ -23,16 +37,48 @@ type Transport interface {
 
 // Request represents a DNS query request.
 type Request struct {
-	Name   string   // Query name (FQDN)
-	Type   uint16   // Query type (TXT, NULL, etc.)
-	Labels []string // Parsed labels for convenience
+	Name   string    // Query name (FQDN)
+	Type   uint16    // Query type (TXT, NULL, etc.)
+	Labels []string  // Parsed labels for convenience
+	Rand   io.Reader // Source of the transaction ID, crypto/rand if nil
+
+	// Validation selects the answer records the response is built from
+	// (default: ValidateOwner).
+	Validation AnswerValidation
+
+	// MaxCNAMEs bounds the CNAME chain followed with ValidateOwnerCNAME
+	// (default: DefaultMaxCNAMEs).
+	MaxCNAMEs int
+
+	// Replayable marks a query that is safe to send twice: it only reads,
+	// or the server deduplicates it by its idempotency key. HTTP
+	// transports resend such queries once if the connection they were
+	// sent on was closed under them.
+	Replayable bool
 }
 
 // Response represents a DNS query response.
 type Response struct {
-	Data    []byte // Raw TXT record data
-	TTL     uint32 // TTL from DNS response
-	Records [][]byte // Multiple TXT records if present
+	Data          []byte    // Raw TXT record data
+	TTL           uint32    // Smallest TTL across the records
+	Records       [][]byte  // Individual TXT records in sequence order, prefixes stripped
+	RecordTTLs    []uint32  // TTL of each record, parallel to Records
+	Manifest      *Manifest // Manifest of a chunked answer, nil if none
+	Truncated     bool      // Server set the TC flag: the answer is incomplete
+	Authoritative bool      // Server set the AA flag: the answer did not come from a resolver cache
+	Answers       []Answer  // All answer records in wire order, before validation
+
+	// CanonicalName owns the records used: the query name, or the end of
+	// its CNAME chain. Empty with ValidateNone.
+	CanonicalName string
+}
+
+// Answer is a single resource record from the answer section.
+type Answer struct {
+	Name string // Owner name, decompressed, without trailing dot
+	Type uint16 // Record type
+	TTL  uint32 // Record TTL
+	Data []byte // Record data: TXT character-strings concatenated, or a CNAME target name
 }
 
 // Common DNS record types.
@@ -47,6 +93,8 @@ const (
 	TypeAAAA  uint16 = 28
 	TypeSRV   uint16 = 33
 	TypeNULL  uint16 = 10
+	TypeSVCB  uint16 = 64
+	TypeHTTPS uint16 = 65
 )
 
 // Closer wraps io.Closer for transports that don't need cleanup.
@@ -58,35 +106,249 @@ func (noopCloser) Close() error { return nil }
 type EmbedCloser struct{ noopCloser }
 
 // Multi wraps multiple transports with automatic fallback.
+//
+// With failover stickiness, a transport that fails is skipped for the
+// stickiness period instead of being waited on again by every query, and
+// is probed in the background until it recovers.
 type Multi struct {
-	transports []Transport
+	transports    []Transport
+	preference    atomic.Pointer[[]int] // Indexes of transports in the order to try, see SetOrder
+	stickiness    time.Duration
+	probeInterval time.Duration
+
+	mu     sync.Mutex
+	health []transportHealth // parallel to transports
+	closed bool              // no probes start once set
+
+	stopProbes context.Context    // cancelled by Close
+	stop       context.CancelFunc // cancels stopProbes
+	probes     sync.WaitGroup     // in-flight recovery probes
+	closeOnce  sync.Once
+	closeErr   error
+}
+
+// transportHealth tracks a transport that failed under failover stickiness.
+type transportHealth struct {
+	downUntil time.Time // skip the transport until then
+	nextProbe time.Time // earliest start of the next recovery probe
+	probing   bool      // a recovery probe is in flight
+}
+
+// defaultProbeInterval is the minimum time between recovery probes of a
+// transport that is down.
+const defaultProbeInterval = 5 * time.Second
+
+// MultiOption configures a Multi transport.
+type MultiOption func(*Multi)
+
+// WithFailoverStickiness makes Multi remember a failed transport for d:
+// later queries go straight to the next transport instead of timing out
+// on it again. Zero disables stickiness (the default).
+func WithFailoverStickiness(d time.Duration) MultiOption {
+	return func(m *Multi) {
+		m.stickiness = d
+	}
+}
+
+// WithProbeInterval sets the minimum time between background recovery
+// probes of a transport skipped under failover stickiness (default: 5s).
+// A probe resends a current query to the transport, with the interval as
+// its timeout, and restores the transport early if it succeeds. At most
+// one probe per transport is in flight at a time.
+func WithProbeInterval(d time.Duration) MultiOption {
+	return func(m *Multi) {
+		m.probeInterval = d
+	}
 }
 
 // NewMulti creates a multi-transport with fallback support.
 func NewMulti(transports ...Transport) *Multi {
-	return &Multi{transports: transports}
+	return NewMultiWithOptions(transports)
+}
+
+// NewMultiWithOptions creates a multi-transport with fallback support and
+// the given options.
+//
+// Example:
+//
+//	t := transport.NewMultiWithOptions(
+//	    []transport.Transport{transport.NewDoH(), transport.NewDoT()},
+//	    transport.WithFailoverStickiness(time.Minute),
+//	)
+func NewMultiWithOptions(transports []Transport, opts ...MultiOption) *Multi {
+	stopProbes, stop := context.WithCancel(context.Background())
+	m := &Multi{
+		transports:    transports,
+		probeInterval: defaultProbeInterval,
+		health:        make([]transportHealth, len(transports)),
+		stopProbes:    stopProbes,
+		stop:          stop,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	preference := make([]int, len(transports))
+	for i := range preference {
+		preference[i] = i
+	}
+	m.preference.Store(&preference)
+	return m
+}
+
+// SetOrder changes the order in which transports are tried, without
+// interrupting queries in flight. transports must hold each of the
+// multi-transport's transports exactly once. Failover health is kept.
+func (m *Multi) SetOrder(transports []Transport) error {
+	if len(transports) != len(m.transports) {
+		return fmt.Errorf("reorder %d transports: got %d", len(m.transports), len(transports))
+	}
+	preference := make([]int, 0, len(transports))
+	used := make([]bool, len(m.transports))
+	for _, t := range transports {
+		i := slices.Index(m.transports, t)
+		if i < 0 || used[i] {
+			return fmt.Errorf("reorder transports: %s is not one of them or is listed twice", t.Name())
+		}
+		used[i] = true
+		preference = append(preference, i)
+	}
+	m.preference.Store(&preference)
+	return nil
 }
 
 func (m *Multi) Name() string {
-	if len(m.transports) > 0 {
-		return "multi(" + m.transports[0].Name() + "+fallback)"
+	if preference := *m.preference.Load(); len(preference) > 0 {
+		return "multi(" + m.transports[preference[0]].Name() + "+fallback)"
 	}
 	return "multi"
 }
 
+// Query tries each transport in order until one succeeds. It stops as
+// soon as ctx is done, returning the cancellation cause wrapped with the
+// last transport error so both match with errors.Is. A transport that
+// answers with an HTTP 4xx status is reachable and has rejected the
+// query, so the error is returned without trying the others.
+//
+// Under failover stickiness, transports that recently failed are tried
+// only after the healthy ones.
 func (m *Multi) Query(ctx context.Context, req *Request) (*Response, error) {
 	var lastErr error
-	for _, t := range m.transports {
-		resp, err := t.Query(ctx, req)
+	for _, i := range m.order(ctx, req) {
+		if ctx.Err() != nil {
+			return nil, canceledError(ctx, lastErr)
+		}
+		resp, err := m.transports[i].Query(ctx, req)
 		if err == nil {
+			m.markUp(i)
 			return resp, nil
 		}
 		lastErr = err
+		if !isConnectivityError(err) {
+			return nil, err
+		}
+		// A failure caused by the caller's context says nothing about the transport
+		if ctx.Err() == nil {
+			m.markDown(i)
+		}
 		// Continue to next transport on error
 	}
+	if lastErr != nil && ctx.Err() != nil {
+		return nil, canceledError(ctx, lastErr)
+	}
 	return nil, lastErr
 }
 
+// order returns the transport indexes to try: healthy transports in
+// configured order, then transports that are down. It starts a recovery
+// probe for each down transport that is due one.
+func (m *Multi) order(ctx context.Context, req *Request) []int {
+	preference := *m.preference.Load()
+	if m.stickiness <= 0 {
+		return preference
+	}
+
+	order := make([]int, 0, len(preference))
+	now := time.Now()
+	var down []int
+	m.mu.Lock()
+	for _, i := range preference {
+		h := &m.health[i]
+		if !now.Before(h.downUntil) {
+			order = append(order, i)
+			continue
+		}
+		down = append(down, i)
+		if !h.probing && !m.closed && !now.Before(h.nextProbe) {
+			h.probing = true
+			h.nextProbe = now.Add(m.probeInterval)
+			m.probes.Add(1)
+			go m.probe(ctx, i, *req)
+		}
+	}
+	m.mu.Unlock()
+	return append(order, down...)
+}
+
+// probe sends req to a down transport, detached from the caller's
+// cancellation, and restores the transport if it answers. Close cancels
+// the probe and waits for it.
+func (m *Multi) probe(ctx context.Context, i int, req Request) {
+	defer m.probes.Done()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.probeInterval)
+	defer cancel()
+	stop := context.AfterFunc(m.stopProbes, cancel)
+	defer stop()
+	_, err := m.transports[i].Query(ctx, &req)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.health[i].probing = false
+	if err == nil || !isConnectivityError(err) {
+		m.health[i].downUntil = time.Time{}
+	}
+}
+
+// markDown records a connectivity failure of transport i under failover
+// stickiness.
+func (m *Multi) markDown(i int) {
+	if m.stickiness <= 0 {
+		return
+	}
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := &m.health[i]
+	if now.Before(h.downUntil) {
+		// Already down: a foreground retry of a down transport failed again
+		return
+	}
+	h.downUntil = now.Add(m.stickiness)
+	h.nextProbe = now.Add(m.probeInterval)
+}
+
+// markUp records a success of transport i under failover stickiness.
+func (m *Multi) markUp(i int) {
+	if m.stickiness <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.health[i].downUntil = time.Time{}
+}
+
+// canceledError reports that ctx ended a fallback chain, wrapping the
+// last transport error if one occurred.
+func canceledError(ctx context.Context, lastErr error) error {
+	cause := context.Cause(ctx)
+	if lastErr == nil {
+		return cause
+	}
+	if errors.Is(lastErr, cause) {
+		return lastErr
+	}
+	return fmt.Errorf("%w (last transport error: %w)", cause, lastErr)
+}
+
 func (m *Multi) IsEncrypted() bool {
 	// Only encrypted if ALL transports are encrypted
 	for _, t := range m.transports {
@@ -97,22 +359,35 @@ func (m *Multi) IsEncrypted() bool {
 	return len(m.transports) > 0
 }
 
+// Close stops recovery probes, waits for those in flight and closes the
+// underlying transports. It is safe to call more than once; later calls
+// return the first result.
 func (m *Multi) Close() error {
-	var errs []error
-	for _, t := range m.transports {
-		if closer, ok := t.(io.Closer); ok {
-			if err := closer.Close(); err != nil {
-				errs = append(errs, err)
+	m.closeOnce.Do(func() {
+		m.mu.Lock()
+		m.closed = true
+		m.mu.Unlock()
+		m.stop()
+		m.probes.Wait()
+
+		for _, t := range m.transports {
+			if closer, ok := t.(io.Closer); ok {
+				if err := closer.Close(); err != nil && m.closeErr == nil {
+					m.closeErr = err
+				}
 			}
 		}
-	}
-	if len(errs) > 0 {
-		return errs[0]
-	}
-	return nil
+	})
+	return m.closeErr
 }
 
-// Transports returns the underlying transports.
+// Transports returns the underlying transports, in the order they are
+// tried.
 func (m *Multi) Transports() []Transport {
-	return m.transports
+	preference := *m.preference.Load()
+	transports := make([]Transport, len(preference))
+	for i, j := range preference {
+		transports[i] = m.transports[j]
+	}
+	return transports
 }
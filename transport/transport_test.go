@@ -0,0 +1,59 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// errTransport fails every query with err, counting queries.
+type errTransport struct {
+	err     error
+	queries int
+}
+
+func (t *errTransport) Name() string      { return "err" }
+func (t *errTransport) IsEncrypted() bool { return true }
+func (t *errTransport) Close() error      { return nil }
+
+func (t *errTransport) Query(context.Context, *Request) (*Response, error) {
+	t.queries++
+	return nil, t.err
+}
+
+func TestMultiFailover(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		err       error
+		failsOver bool
+	}{
+		{"Unauthorized", &HTTPError{StatusCode: 401}, false},
+		{"Forbidden", &HTTPError{StatusCode: 403}, false},
+		{"NotFound", &HTTPError{StatusCode: 404}, false},
+		{"RateLimited", &HTTPError{StatusCode: 429, RetryAfter: time.Second}, false},
+		{"BadGateway", &HTTPError{StatusCode: 502}, true},
+		{"ServerFailure", &RcodeError{Rcode: RcodeServerFailure}, true},
+		{"Timeout", context.DeadlineExceeded, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &errTransport{err: tt.err}
+			m := NewMultiWithOptions([]Transport{primary, NewMemory(WithMemoryMissing([]byte("v=rdb1;s=ok;d=1")))}, WithFailoverStickiness(time.Hour))
+			defer m.Close()
+
+			for i := 0; i < 2; i++ {
+				_, err := m.Query(context.Background(), &Request{Name: "get.tokyo.weather.public.v1.resolvedb.net"})
+				if tt.failsOver != (err == nil) {
+					t.Fatalf("Query = %v, want fail over %t", err, tt.failsOver)
+				}
+				if !tt.failsOver && !errors.Is(err, tt.err) {
+					t.Fatalf("Query = %v, want %v", err, tt.err)
+				}
+			}
+			// A transport marked down is skipped by the second query
+			if want := map[bool]int{true: 1, false: 2}[tt.failsOver]; primary.queries != want {
+				t.Fatalf("primary queried %d times, want %d", primary.queries, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// udpReadBuffer is the receive buffer requested for pooled sockets.
+const udpReadBuffer = 1 << 20
+
+// udpPool shares a fixed number of UDP sockets between concurrent queries.
+// Each socket has a reader that hands answers to the waiting query by
+// server address and transaction ID.
+type udpPool struct {
+	mu     sync.Mutex
+	size   int
+	socks  []*udpSocket
+	next   int
+	closed bool
+}
+
+// udpKey identifies an outstanding query on a socket.
+type udpKey struct {
+	server netip.AddrPort
+	id     uint16
+}
+
+// udpSocket is a pooled UDP socket and its outstanding queries.
+type udpSocket struct {
+	pool *udpPool
+	conn *net.UDPConn
+
+	mu      sync.Mutex
+	pending map[udpKey]chan []byte
+	dead    bool
+}
+
+func newUDPPool(size int) *udpPool {
+	return &udpPool{size: size}
+}
+
+// get returns a socket, opening one while the pool is below its size and
+// otherwise taking the next in turn.
+func (p *udpPool) get() (*udpSocket, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrClosed
+	}
+	if len(p.socks) < p.size {
+		conn, err := net.ListenUDP("udp", nil)
+		if err != nil {
+			return nil, fmt.Errorf("listen: %w", err)
+		}
+		// Answers for many queries queue on one socket; best effort, as
+		// the OS may cap the size
+		_ = conn.SetReadBuffer(udpReadBuffer)
+		s := &udpSocket{pool: p, conn: conn, pending: make(map[udpKey]chan []byte)}
+		p.socks = append(p.socks, s)
+		go s.read()
+		return s, nil
+	}
+	s := p.socks[p.next%len(p.socks)]
+	p.next++
+	return s, nil
+}
+
+// remove drops a failed socket so a fresh one replaces it.
+func (p *udpPool) remove(s *udpSocket) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, cur := range p.socks {
+		if cur == s {
+			p.socks = append(p.socks[:i], p.socks[i+1:]...)
+			return
+		}
+	}
+}
+
+// close closes every socket; outstanding queries fail with ErrClosed.
+func (p *udpPool) close() error {
+	p.mu.Lock()
+	p.closed = true
+	socks := p.socks
+	p.socks = nil
+	p.mu.Unlock()
+
+	var firstErr error
+	for _, s := range socks {
+		if err := s.conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// read dispatches answers until the socket fails or is closed, then fails
+// the queries still waiting.
+func (s *udpSocket) read() {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, from, err := s.conn.ReadFromUDPAddrPort(buf)
+		if err != nil {
+			s.pool.remove(s)
+			s.conn.Close()
+			s.mu.Lock()
+			s.dead = true
+			for key, ch := range s.pending {
+				close(ch)
+				delete(s.pending, key)
+			}
+			s.mu.Unlock()
+			return
+		}
+		if n < dnsHeaderSize {
+			continue
+		}
+
+		key := udpKey{server: unmapAddrPort(from), id: uint16(buf[0])<<8 | uint16(buf[1])}
+		s.mu.Lock()
+		ch, ok := s.pending[key]
+		delete(s.pending, key)
+		s.mu.Unlock()
+		// Answers from unknown servers or for unknown IDs are dropped
+		if ok {
+			ch <- append([]byte(nil), buf[:n]...)
+		}
+	}
+}
+
+// register reserves the query's transaction ID for server, choosing a new
+// random ID if another outstanding query already uses it.
+func (s *udpSocket) register(server netip.AddrPort, query []byte) (udpKey, chan []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dead {
+		return udpKey{}, nil, ErrClosed
+	}
+	key := udpKey{server: server, id: uint16(query[0])<<8 | uint16(query[1])}
+	for {
+		if _, taken := s.pending[key]; !taken {
+			break
+		}
+		if _, err := io.ReadFull(rand.Reader, query[:2]); err != nil {
+			return udpKey{}, nil, fmt.Errorf("transaction id: %w", err)
+		}
+		key.id = uint16(query[0])<<8 | uint16(query[1])
+	}
+	ch := make(chan []byte, 1)
+	s.pending[key] = ch
+	return key, ch, nil
+}
+
+// unregister abandons an outstanding query.
+func (s *udpSocket) unregister(key udpKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, key)
+}
+
+// query sends query to server on a pooled socket and waits for the
+// matching answer until deadline.
+func (p *udpPool) query(ctx context.Context, server string, query []byte, deadline time.Time) ([]byte, error) {
+	addr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", server, err)
+	}
+	to := unmapAddrPort(addr.AddrPort())
+
+	s, err := p.get()
+	if err != nil {
+		return nil, err
+	}
+	key, ch, err := s.register(to, query)
+	if err != nil {
+		return nil, err
+	}
+	defer s.unregister(key)
+
+	if _, err := s.conn.WriteToUDPAddrPort(query, to); err != nil {
+		return nil, fmt.Errorf("write: %w", err)
+	}
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			return nil, ErrClosed
+		}
+		if !sameQuestion(query, msg) {
+			return nil, fmt.Errorf("%w: answer does not match question", ErrMalformed)
+		}
+		return msg, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("read: %w", context.DeadlineExceeded)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// sameQuestion reports whether msg repeats the question of query, which
+// guards pooled sockets, whose source ports are shared, against spoofed
+// answers that guessed a transaction ID.
+func sameQuestion(query, msg []byte) bool {
+	if len(msg) < len(query) {
+		return false
+	}
+	return bytes.EqualFold(msg[dnsHeaderSize:len(query)], query[dnsHeaderSize:])
+}
+
+// unmapAddrPort converts IPv4-mapped IPv6 addresses to IPv4, so answers
+// received on dual-stack sockets match the server they were sent to.
+func unmapAddrPort(ap netip.AddrPort) netip.AddrPort {
+	return netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port())
+}
@@ -0,0 +1,298 @@
+package transport
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrMalformed is returned when a DNS message cannot be parsed safely.
+var ErrMalformed = errors.New("transport: malformed DNS message")
+
+// ErrNameTooLong is returned for query names that cannot be encoded in a
+// DNS question: a label longer than 63 bytes, or a name longer than 255
+// bytes on the wire.
+var ErrNameTooLong = errors.New("transport: query name too long")
+
+// DNS wire format limits.
+const (
+	dnsHeaderSize  = 12
+	maxNameLength  = 255 // RFC 1035 2.3.4
+	maxLabelLength = 63
+
+	// maxAnswerRecords bounds the answers accepted from a single message.
+	maxAnswerRecords = 512
+
+	// maxPointerHops bounds compression pointers followed within one name.
+	maxPointerHops = 64
+)
+
+// buildDNSQuery creates a DNS wire format query message.
+func buildDNSQuery(name string, qtype uint16, rnd io.Reader) ([]byte, error) {
+	return appendDNSQuery(make([]byte, 0, len(name)+18), name, qtype, rnd)
+}
+
+// checkQueryName returns an error wrapping ErrNameTooLong if name cannot
+// be encoded in a DNS question.
+func checkQueryName(name string) error {
+	wire := 1 // Root label
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if len(label) > maxLabelLength {
+			return fmt.Errorf("%w: label of %d bytes exceeds %d", ErrNameTooLong, len(label), maxLabelLength)
+		}
+		if len(label) > 0 {
+			wire += 1 + len(label)
+		}
+	}
+	if wire > maxNameLength {
+		return fmt.Errorf("%w: %d bytes exceeds %d", ErrNameTooLong, wire, maxNameLength)
+	}
+	return nil
+}
+
+// appendDNSQuery appends a DNS wire format query message to dst. The
+// transaction ID is read from rnd, or crypto/rand if rnd is nil. Names
+// that cannot be encoded fail with ErrNameTooLong, leaving dst unchanged.
+func appendDNSQuery(dst []byte, name string, qtype uint16, rnd io.Reader) ([]byte, error) {
+	if err := checkQueryName(name); err != nil {
+		return dst, err
+	}
+	// Transaction ID - cryptographically random to prevent cache poisoning
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	var txid [2]byte
+	if _, err := io.ReadFull(rnd, txid[:]); err != nil {
+		// Fallback to less secure but functional value
+		txid = [2]byte{0x00, 0x01}
+	}
+	dst = append(dst, txid[:]...)
+
+	// Flags: standard query, recursion desired
+	dst = append(dst, 0x01, 0x00)
+
+	// Question count: 1
+	dst = append(dst, 0x00, 0x01)
+
+	// Answer, Authority, Additional counts: 0
+	dst = append(dst, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00)
+
+	// Question section
+	// Encode name as DNS labels
+	for len(name) > 0 {
+		label := name
+		if i := strings.IndexByte(name, '.'); i >= 0 {
+			label, name = name[:i], name[i+1:]
+		} else {
+			name = ""
+		}
+		if len(label) > 0 {
+			dst = append(dst, byte(len(label)))
+			dst = append(dst, label...)
+		}
+	}
+	dst = append(dst, 0x00) // Root label
+
+	// Query type
+	dst = append(dst, byte(qtype>>8), byte(qtype&0xFF))
+
+	// Query class (IN)
+	dst = append(dst, 0x00, 0x01)
+
+	return dst, nil
+}
+
+// parseDNSResponse parses the answer section of a DNS wire format
+// response; finishResponse then assembles the payload. Every offset read
+// from the message is bounds-checked; truncated or malformed messages
+// return an error wrapping ErrMalformed.
+func parseDNSResponse(data []byte) (*Response, error) {
+	if len(data) < dnsHeaderSize {
+		return nil, fmt.Errorf("%w: response too short", ErrMalformed)
+	}
+
+	if err := checkRcode(int(data[3] & 0x0F)); err != nil {
+		return nil, err
+	}
+
+	qdcount := int(data[4])<<8 | int(data[5])
+	ancount := int(data[6])<<8 | int(data[7])
+	if ancount > maxAnswerRecords {
+		return nil, fmt.Errorf("%w: %d answers exceeds limit of %d", ErrMalformed, ancount, maxAnswerRecords)
+	}
+
+	// Skip question section
+	offset := dnsHeaderSize
+	for i := 0; i < qdcount; i++ {
+		next, err := skipName(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		// QTYPE and QCLASS
+		if next+4 > len(data) {
+			return nil, fmt.Errorf("%w: truncated question", ErrMalformed)
+		}
+		offset = next + 4
+	}
+
+	// Parse answer section
+	resp := &Response{Truncated: data[2]&0x02 != 0, Authoritative: data[2]&0x04 != 0}
+	for i := 0; i < ancount; i++ {
+		name, next, err := readName(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next
+
+		// TYPE, CLASS, TTL, RDLENGTH
+		if offset+10 > len(data) {
+			return nil, fmt.Errorf("%w: truncated answer %d", ErrMalformed, i)
+		}
+		rtype := uint16(data[offset])<<8 | uint16(data[offset+1])
+		ttl := uint32(data[offset+4])<<24 | uint32(data[offset+5])<<16 |
+			uint32(data[offset+6])<<8 | uint32(data[offset+7])
+		rdlen := int(data[offset+8])<<8 | int(data[offset+9])
+		offset += 10
+
+		// RDATA
+		if offset+rdlen > len(data) {
+			return nil, fmt.Errorf("%w: truncated rdata in answer %d", ErrMalformed, i)
+		}
+		rdata := data[offset : offset+rdlen]
+		offset += rdlen
+
+		switch rtype {
+		case TypeTXT:
+			// For TXT records, strip length bytes
+			txt, err := parseTXT(rdata)
+			if err != nil {
+				return nil, err
+			}
+			rdata = txt
+		case TypeCNAME:
+			// The target may be compressed against the rest of the message
+			target, _, err := readName(data, offset-rdlen)
+			if err != nil {
+				return nil, err
+			}
+			rdata = []byte(target)
+		default:
+			// Copy so the response never aliases a pooled read buffer
+			rdata = append([]byte(nil), rdata...)
+		}
+
+		resp.Answers = append(resp.Answers, Answer{Name: name, Type: rtype, TTL: ttl, Data: rdata})
+	}
+
+	return resp, nil
+}
+
+// parseTXT concatenates the character-strings of a TXT record's RDATA.
+func parseTXT(rdata []byte) ([]byte, error) {
+	txt := make([]byte, 0, len(rdata))
+	for pos := 0; pos < len(rdata); {
+		length := int(rdata[pos])
+		pos++
+		if pos+length > len(rdata) {
+			return nil, fmt.Errorf("%w: truncated TXT string", ErrMalformed)
+		}
+		txt = append(txt, rdata[pos:pos+length]...)
+		pos += length
+	}
+	return txt, nil
+}
+
+// skipName returns the offset just past the (possibly compressed) name at offset.
+func skipName(data []byte, offset int) (int, error) {
+	nameLen := 0
+	for {
+		if offset >= len(data) {
+			return 0, fmt.Errorf("%w: truncated name", ErrMalformed)
+		}
+		length := int(data[offset])
+		switch {
+		case length == 0:
+			// Root label
+			return offset + 1, nil
+		case length&0xC0 == 0xC0:
+			// Compression pointer terminates the name
+			if offset+2 > len(data) {
+				return 0, fmt.Errorf("%w: truncated compression pointer", ErrMalformed)
+			}
+			return offset + 2, nil
+		case length&0xC0 != 0:
+			return 0, fmt.Errorf("%w: unsupported label type 0x%02x", ErrMalformed, length)
+		}
+
+		nameLen += length + 1
+		if nameLen > maxNameLength {
+			return 0, fmt.Errorf("%w: name exceeds %d bytes", ErrMalformed, maxNameLength)
+		}
+		offset += 1 + length
+	}
+}
+
+// readName decodes the name at offset, following compression pointers
+// (RFC 1035 4.1.4). It returns the name without a trailing dot and the
+// offset just past the name as it appears at offset.
+//
+// Pointers must refer to an earlier offset than the one being decoded and
+// at most maxPointerHops are followed, so malicious pointer loops fail fast.
+func readName(data []byte, offset int) (string, int, error) {
+	var name []byte
+	next := -1 // Offset after the name at its original position
+	hops := 0
+	nameLen := 0
+
+	for {
+		if offset >= len(data) {
+			return "", 0, fmt.Errorf("%w: truncated name", ErrMalformed)
+		}
+		length := int(data[offset])
+		switch {
+		case length == 0:
+			// Root label
+			if next < 0 {
+				next = offset + 1
+			}
+			return string(name), next, nil
+
+		case length&0xC0 == 0xC0:
+			// Compression pointer
+			if offset+2 > len(data) {
+				return "", 0, fmt.Errorf("%w: truncated compression pointer", ErrMalformed)
+			}
+			target := (length&0x3F)<<8 | int(data[offset+1])
+			if target >= offset {
+				return "", 0, fmt.Errorf("%w: forward compression pointer", ErrMalformed)
+			}
+			hops++
+			if hops > maxPointerHops {
+				return "", 0, fmt.Errorf("%w: too many compression pointers", ErrMalformed)
+			}
+			if next < 0 {
+				next = offset + 2
+			}
+			offset = target
+			continue
+
+		case length&0xC0 != 0:
+			return "", 0, fmt.Errorf("%w: unsupported label type 0x%02x", ErrMalformed, length)
+		}
+
+		nameLen += length + 1
+		if nameLen > maxNameLength {
+			return "", 0, fmt.Errorf("%w: name exceeds %d bytes", ErrMalformed, maxNameLength)
+		}
+		if offset+1+length > len(data) {
+			return "", 0, fmt.Errorf("%w: truncated label", ErrMalformed)
+		}
+		if len(name) > 0 {
+			name = append(name, '.')
+		}
+		name = append(name, data[offset+1:offset+1+length]...)
+		offset += 1 + length
+	}
+}
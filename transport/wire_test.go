@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"errors"
+	"testing"
+)
+
+// txtResponse returns a response to a TXT query for name answering data,
+// with the answer name compressed against the question.
+func txtResponse(tb testing.TB, name string, data string) []byte {
+	tb.Helper()
+	msg, err := buildDNSQuery(name, TypeTXT, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	msg[2] |= 0x80 // QR
+	msg[7] = 1     // ANCOUNT
+	msg = append(msg, 0xC0, dnsHeaderSize)
+	msg = append(msg, byte(TypeTXT>>8), byte(TypeTXT), 0x00, 0x01)
+	msg = append(msg, 0x00, 0x00, 0x01, 0x2C) // TTL 300
+	msg = append(msg, 0x00, byte(len(data)+1), byte(len(data)))
+	return append(msg, data...)
+}
+
+func FuzzParseDNSResponse(f *testing.F) {
+	valid := txtResponse(f, "get.tokyo.weather.public.v1.resolvedb.net", "v=rdb1;s=ok;t=text;d=hello")
+	if _, err := parseDNSResponse(valid); err != nil {
+		f.Fatal(err)
+	}
+	f.Add(valid)
+
+	// Truncated at every section boundary
+	f.Add(valid[:dnsHeaderSize-1])
+	f.Add(valid[:dnsHeaderSize+5])
+	f.Add(valid[:len(valid)-5])
+
+	// Header only, claiming more answers than the limit
+	f.Add([]byte{0x12, 0x34, 0x81, 0x80, 0x00, 0x00, 0xFF, 0xFF, 0x00, 0x00, 0x00, 0x00})
+
+	// Answer name pointing at itself
+	f.Add([]byte{
+		0x12, 0x34, 0x81, 0x80, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+		0xC0, 0x0C,
+	})
+
+	// Two pointers referring to each other
+	f.Add([]byte{
+		0x12, 0x34, 0x81, 0x80, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+		0xC0, 0x0E, 0xC0, 0x0C,
+	})
+
+	// Truncated compression pointer
+	f.Add([]byte{
+		0x12, 0x34, 0x81, 0x80, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+		0xC0,
+	})
+
+	// Label running past the end of the message
+	f.Add([]byte{
+		0x12, 0x34, 0x81, 0x80, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x3F, 'a', 'b', 'c',
+	})
+
+	// TXT character-string longer than its RDATA
+	bad := append([]byte(nil), valid...)
+	bad[len(bad)-len("v=rdb1;s=ok;t=text;d=hello")-1] = 0xFF
+	f.Add(bad)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		resp, err := parseDNSResponse(data)
+		if err != nil {
+			var rcodeErr *RcodeError
+			if !errors.Is(err, ErrMalformed) && !errors.As(err, &rcodeErr) {
+				t.Fatalf("unexpected error type: %v", err)
+			}
+			return
+		}
+		if len(resp.Answers) > maxAnswerRecords {
+			t.Fatalf("%d answers exceeds limit of %d", len(resp.Answers), maxAnswerRecords)
+		}
+		for _, a := range resp.Answers {
+			if len(a.Name) > maxNameLength {
+				t.Fatalf("answer name of %d bytes exceeds %d", len(a.Name), maxNameLength)
+			}
+		}
+	})
+}
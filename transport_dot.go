@@ -0,0 +1,22 @@
+//go:build !resolvedb_nodot
+
+package resolvedb
+
+import (
+	"crypto/tls"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+)
+
+// newDoT returns a DoT transport dialing servers, verifying certificates
+// for host.
+func newDoT(config *clientConfig, host string, servers []string) transport.Transport {
+	dotOpts := []transport.DoTOption{
+		transport.WithDoTServers(servers...),
+		transport.WithDoTTLSConfig(&tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}),
+	}
+	if config.timeout > 0 {
+		dotOpts = append(dotOpts, transport.WithDoTTimeout(config.timeout))
+	}
+	return transport.NewDoT(dotOpts...)
+}
@@ -0,0 +1,31 @@
+//go:build !resolvedb_nohttp
+
+package resolvedb
+
+import (
+	"net/http"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+)
+
+// WithHTTPClient sets a custom HTTP client for DoH transport.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *clientConfig) {
+		c.httpClient = client
+	}
+}
+
+// newDoH returns a DoH transport for url using the configured HTTP client
+// and timeout, advertising the SDK version. The timeout bounds each query
+// through its context rather than through http.Client.Timeout, so it
+// composes with the caller's deadline and per-attempt timeouts.
+func newDoH(config *clientConfig, url string) transport.Transport {
+	dohOpts := []transport.DoHOption{transport.WithDoHURL(url), transport.WithDoHUserAgent(userAgent)}
+	if hc, _ := config.httpClient.(*http.Client); hc != nil {
+		dohOpts = append(dohOpts, transport.WithDoHClient(hc))
+	}
+	if config.timeout > 0 {
+		dohOpts = append(dohOpts, transport.WithDoHTimeout(config.timeout))
+	}
+	return transport.NewDoH(dohOpts...)
+}
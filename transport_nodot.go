@@ -0,0 +1,11 @@
+//go:build resolvedb_nodot
+
+package resolvedb
+
+import "github.com/resolvedb/resolvedb-go/transport"
+
+// newDoT returns nil: this build leaves DoT out, and discovery skips DoT
+// endpoints.
+func newDoT(config *clientConfig, host string, servers []string) transport.Transport {
+	return nil
+}
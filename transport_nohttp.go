@@ -0,0 +1,11 @@
+//go:build resolvedb_nohttp
+
+package resolvedb
+
+import "github.com/resolvedb/resolvedb-go/transport"
+
+// newDoH returns nil: this build leaves DoH out, so clients need
+// WithTransports or WithRegions, and discovery skips DoH endpoints.
+func newDoH(config *clientConfig, url string) transport.Transport {
+	return nil
+}
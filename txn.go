@@ -0,0 +1,150 @@
+package resolvedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Txn collects writes that are committed atomically as a single
+// transaction query. Either all operations are applied or none are. The
+// whole transaction must fit in one query; see Commit.
+// Transactions require server support; servers without it reject the
+// query with ErrBadRequest.
+//
+// Example:
+//
+//	result, err := client.Txn(ctx).
+//	    Set("config", "app", appConfig).
+//	    Set("config", "app-version", "2.4.0").
+//	    Delete("config", "app-legacy").
+//	    Commit()
+type Txn struct {
+	client *Client
+	ctx    context.Context
+	opts   []RequestOption
+	ops    []txnOp
+	err    error
+}
+
+// txnOp is a single operation in a transaction envelope.
+type txnOp struct {
+	Op       string          `json:"op"`
+	Resource string          `json:"r"`
+	Key      string          `json:"k"`
+	Data     json.RawMessage `json:"d,omitempty"`
+	TTL      int64           `json:"ttl,omitempty"`
+	Exp      int64           `json:"exp,omitempty"`
+}
+
+// Txn starts a transaction. Request options apply to the commit query.
+func (c *Client) Txn(ctx context.Context, opts ...RequestOption) *Txn {
+	return &Txn{client: c, ctx: ctx, opts: opts}
+}
+
+// Set adds a write of data to the transaction.
+// Only WithTTL and WithExpiry are honored from opts.
+func (t *Txn) Set(resource, key string, data any, opts ...RequestOption) *Txn {
+	if t.err != nil {
+		return t
+	}
+
+	encoded, err := t.client.marshalValue(resource, key, data)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	reqConfig := &requestConfig{}
+	for _, opt := range opts {
+		opt(reqConfig)
+	}
+
+	op := txnOp{
+		Op:       "put",
+		Resource: resource,
+		Key:      key,
+		Data:     encoded,
+		TTL:      int64(reqConfig.ttl.Seconds()),
+	}
+	if exp := t.client.expiresAt(reqConfig); !exp.IsZero() {
+		op.Exp = exp.Unix()
+	}
+	t.ops = append(t.ops, op)
+	return t
+}
+
+// Delete adds a deletion to the transaction.
+func (t *Txn) Delete(resource, key string) *Txn {
+	if t.err != nil {
+		return t
+	}
+	t.ops = append(t.ops, txnOp{Op: "delete", Resource: resource, Key: key})
+	return t
+}
+
+// Len returns the number of operations in the transaction.
+func (t *Txn) Len() int {
+	return len(t.ops)
+}
+
+// Commit sends the transaction as a single query. Committing an empty
+// transaction is a no-op. The encoded envelope travels in a single label
+// of the query name, like a value written with Set, so only small
+// transactions fit: larger ones fail with ErrPayloadTooLarge without
+// being sent.
+func (t *Txn) Commit() (*WriteResult, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	if len(t.ops) == 0 {
+		return &WriteResult{}, nil
+	}
+
+	c := t.client
+	reqConfig := newRequestConfig(t.ctx, t.opts)
+	if !c.authenticated(reqConfig) {
+		return nil, ErrUnauthorized
+	}
+
+	for _, op := range t.ops {
+		if err := c.checkACL(op.Op, op.Resource, op.Key, reqConfig); err != nil {
+			return nil, err
+		}
+		if err := c.checkKeys(op.Resource, op.Key, reqConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.checkTransportSecurity(reqConfig); err != nil {
+		return nil, err
+	}
+
+	// Encode transaction envelope
+	envelope, err := json.Marshal(t.ops)
+	if err != nil {
+		return nil, fmt.Errorf("encode transaction: json marshal: %w", err)
+	}
+
+	queryName := c.buildQueryNameWithData("txn", "", "", envelope, reqConfig)
+	if err := checkWriteName(queryName); err != nil {
+		return nil, c.queryError(fmt.Errorf("%w: transaction of %d operations is %d bytes",
+			err, len(t.ops), len(envelope)), "txn", "", "", reqConfig, nil)
+	}
+
+	resp, err := c.query(t.ctx, "txn", "", "", queryName, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resp.ToError(); err != nil {
+		return nil, c.queryError(err, "txn", "", "", reqConfig, resp)
+	}
+
+	// Invalidate cache for every key touched
+	for _, op := range t.ops {
+		c.invalidate(op.Resource, op.Key, reqConfig, resp)
+	}
+
+	return newWriteResult(resp), nil
+}
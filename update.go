@@ -0,0 +1,139 @@
+package resolvedb
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+)
+
+// liveConfig holds the settings Update can change on a live client. It
+// is replaced as a whole, so a request sees either the old settings or
+// the new ones.
+type liveConfig struct {
+	retry  RetryConfig
+	logger *slog.Logger
+	cache  CacheConfig
+}
+
+// reloadable is a set of settings Update can change. The options that
+// set them record it in clientConfig.reloaded, so Update knows which
+// options were passed even when they set a zero value.
+type reloadable uint8
+
+const (
+	reloadRetry reloadable = 1 << iota
+	reloadLogger
+	reloadCache
+	reloadTransports
+)
+
+// Update changes settings of a live client without recreating it or
+// disturbing requests in flight, for services tuned from configuration
+// while they run. Requests started after Update returns use the new
+// settings. The options it accepts are:
+//
+//   - WithRetry, replacing the retry configuration.
+//   - WithLogger, replacing the logger, for example to change the level.
+//   - WithCache, changing MaxEntries and DefaultTTL of the built-in cache.
+//     Lowering MaxEntries evicts the least recently used entries at once.
+//     Caching cannot be turned on or off.
+//   - WithTransports, reordering the configured transports. It must list
+//     each of them exactly once; failover health is kept.
+//
+// Other options fail with an error matching ErrNotReloadable. Update
+// applies all options or none.
+//
+// Example:
+//
+//	retry := resolvedb.DefaultRetryConfig()
+//	retry.MaxRetries = cfg.MaxRetries
+//	err := client.Update(
+//	    resolvedb.WithRetry(retry),
+//	    resolvedb.WithCache(resolvedb.CacheConfig{Enabled: true, MaxEntries: cfg.CacheSize, DefaultTTL: time.Minute}),
+//	)
+func (c *Client) Update(opts ...Option) error {
+	c.updateMu.Lock()
+	defer c.updateMu.Unlock()
+
+	// Options are told apart by what they record, not by the values they
+	// set, so zero settings such as NoRetry or WithTimeout(0) are seen
+	var probe clientConfig
+	for i, opt := range opts {
+		var one clientConfig
+		opt(&one)
+		if one.reloaded == 0 {
+			return fmt.Errorf("%w: %s", ErrNotReloadable, optionName(opt, i))
+		}
+		opt(&probe)
+	}
+
+	cur := c.live.Load()
+	next := *cur
+	if probe.reloaded&reloadRetry != 0 {
+		next.retry = probe.retryConfig
+	}
+	if probe.reloaded&reloadLogger != 0 {
+		next.logger = probe.logger
+		if next.logger == nil {
+			// As New, a nil logger discards
+			next.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+		}
+	}
+
+	cache, _ := c.cache.(*memoryCache)
+	if probe.reloaded&reloadCache != 0 {
+		if probe.cacheConfig.Enabled != cur.cache.Enabled {
+			return fmt.Errorf("%w: caching cannot be turned on or off", ErrNotReloadable)
+		}
+		if probe.cacheConfig.MaxEntries < 0 || probe.cacheConfig.DefaultTTL < 0 {
+			return fmt.Errorf("cache limits cannot be negative")
+		}
+		if cache == nil && probe.cacheConfig.Enabled {
+			return fmt.Errorf("%w: the cache has no limits to change", ErrNotReloadable)
+		}
+		next.cache = probe.cacheConfig
+	}
+
+	if probe.reloaded&reloadTransports != 0 {
+		m, ok := c.transport.(*transport.Multi)
+		if !ok || len(c.config.regions) > 0 {
+			return fmt.Errorf("%w: transports can only be reordered on a client with several", ErrNotReloadable)
+		}
+		if err := m.SetOrder(probe.transports); err != nil {
+			return err
+		}
+	}
+	if cache != nil && next.cache != cur.cache {
+		cache.setLimits(next.cache)
+	}
+	c.live.Store(&next)
+	return nil
+}
+
+// optionName returns the name of the function that made opt, such as
+// "WithTimeout", or its position in the call if that is unknown.
+func optionName(opt Option, i int) string {
+	if fn := runtime.FuncForPC(reflect.ValueOf(opt).Pointer()); fn != nil {
+		name := fn.Name()
+		name = name[strings.LastIndex(name, "/")+1:]
+		if parts := strings.Split(name, "."); len(parts) >= 2 && parts[1] != "" {
+			return parts[1]
+		}
+	}
+	return fmt.Sprintf("option %d", i+1)
+}
+
+// retryConfig returns the current retry configuration.
+func (c *Client) retryConfig() RetryConfig {
+	return c.live.Load().retry
+}
+
+// logger returns the current logger.
+func (c *Client) logger() *slog.Logger {
+	return c.live.Load().logger
+}
@@ -0,0 +1,53 @@
+package resolvedb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/resolvedb/resolvedb-go/transport"
+)
+
+func TestUpdateRejectsZeroValueOptions(t *testing.T) {
+	client := newTestClient(t, transport.NewMemory(), WithCache(DefaultCacheConfig()))
+
+	for name, opt := range map[string]Option{
+		"WithTimeout":                WithTimeout(0),
+		"WithNamespace":              WithNamespace(""),
+		"WithMaxConcurrency":         WithMaxConcurrency(0),
+		"WithAttemptTimeout":         WithAttemptTimeout(0),
+		"WithoutSecurityEnforcement": WithoutSecurityEnforcement(),
+	} {
+		if err := client.Update(opt); !errors.Is(err, ErrNotReloadable) {
+			t.Errorf("Update(%s) returned %v, want ErrNotReloadable", name, err)
+		}
+	}
+
+	// A rejected option leaves the others in the call unapplied
+	if err := client.Update(WithRetry(DefaultRetryConfig()), WithTimeout(0)); !errors.Is(err, ErrNotReloadable) {
+		t.Fatalf("Update returned %v, want ErrNotReloadable", err)
+	}
+	if got := client.retryConfig(); got == DefaultRetryConfig() {
+		t.Fatal("Update applied WithRetry alongside a rejected option")
+	}
+
+	// Zero settings of reloadable options still apply
+	if err := client.Update(WithRetry(DefaultRetryConfig())); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Update(WithRetry(NoRetry())); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.retryConfig(); got != NoRetry() {
+		t.Fatalf("retry config = %+v, want NoRetry", got)
+	}
+}
+
+func TestUpdateRejectsNegativeCacheLimits(t *testing.T) {
+	client := newTestClient(t, transport.NewMemory(), WithCache(DefaultCacheConfig()))
+
+	cache := DefaultCacheConfig()
+	cache.MaxEntries = -1
+	if err := client.Update(WithCache(cache)); err == nil {
+		t.Fatal("Update with MaxEntries -1 succeeded, want an error")
+	}
+}
@@ -0,0 +1,233 @@
+package uqrp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layout describes how a deployment arranges query names: the order of
+// the location labels, the protocol version, the apex, and how data is
+// encoded.
+type Layout struct {
+	Order            []Label        // Location label order (default: DefaultLabelOrder)
+	Version          string         // Version label, e.g. "v1"
+	TLD              string         // TLD after "resolvedb", e.g. "net"
+	ApexLabels       []string       // Replace "resolvedb.<tld>" when set
+	Zone             string         // Self-hosted zone replacing "<version>.resolvedb.<tld>"
+	DefaultNamespace string         // Namespace label for queries without one, e.g. "public"
+	Encoding         Encoding       // Data label encoding
+	Tokens           TokenPlacement // Where token labels go (default: TokensAfterOperation)
+}
+
+// DefaultLayout returns the layout of the hosted service:
+// <key>.<resource>.<namespace>.v1.resolvedb.net with base64url data.
+func DefaultLayout() Layout {
+	return Layout{
+		Order:            DefaultLabelOrder(),
+		Version:          "v1",
+		TLD:              "net",
+		DefaultNamespace: "public",
+	}
+}
+
+// Validate checks that the layout is complete and that the names it
+// produces can be parsed back.
+func (l Layout) Validate() error {
+	if l.Version == "" {
+		return fmt.Errorf("version cannot be empty")
+	}
+	if l.TLD == "" && len(l.ApexLabels) == 0 {
+		return fmt.Errorf("TLD cannot be empty")
+	}
+	for _, a := range l.ApexLabels {
+		if a == "" {
+			return fmt.Errorf("apex labels cannot be empty")
+		}
+	}
+	if l.DefaultNamespace == "" {
+		return fmt.Errorf("default namespace cannot be empty")
+	}
+	if l.Tokens != TokensAfterOperation && l.Tokens != TokensAfterAuth {
+		return fmt.Errorf("unknown token placement %v", l.Tokens)
+	}
+	if err := validateOrder(l.order()); err != nil {
+		return err
+	}
+	if l.Zone != "" {
+		if len(l.ApexLabels) > 0 {
+			return fmt.Errorf("zone and apex labels are mutually exclusive")
+		}
+		if err := validateZone(l.Zone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// order returns the location label order, defaulting when unset.
+func (l Layout) order() []Label {
+	if l.Order == nil {
+		return DefaultLabelOrder()
+	}
+	return l.Order
+}
+
+// apex returns the labels that end every query name.
+func (l Layout) apex() []string {
+	switch {
+	case l.Zone != "":
+		return strings.Split(l.Zone, ".")
+	case len(l.ApexLabels) > 0:
+		return l.ApexLabels
+	default:
+		return []string{"resolvedb", l.TLD}
+	}
+}
+
+// validateOrder checks that a label layout is complete and unambiguous.
+func validateOrder(order []Label) error {
+	seen := make(map[Label]bool, len(order))
+	for _, l := range order {
+		if l < LabelKey || l > LabelVersion {
+			return fmt.Errorf("unknown label %d in label order", l)
+		}
+		if seen[l] {
+			return fmt.Errorf("duplicate label %d in label order", l)
+		}
+		seen[l] = true
+	}
+	if !seen[LabelKey] || !seen[LabelResource] {
+		return fmt.Errorf("label order must include key and resource")
+	}
+	return nil
+}
+
+// validateZone checks that a zone is a valid DNS name.
+func validateZone(zone string) error {
+	if len(zone) > 253 {
+		return fmt.Errorf("zone %q exceeds 253 characters", zone)
+	}
+	for _, label := range strings.Split(zone, ".") {
+		if label == "" {
+			return fmt.Errorf("zone %q contains an empty label", zone)
+		}
+		if len(label) > 63 {
+			return fmt.Errorf("zone label %q exceeds 63 characters", label)
+		}
+		if Sanitize(label) != label {
+			return fmt.Errorf("zone label %q contains invalid characters", label)
+		}
+	}
+	return nil
+}
+
+// Query is the content of a query name.
+type Query struct {
+	Operation Operation
+	Tokens    []string // Security token labels (sig-, ctp-, bdt-, drt-), in order
+	Auth      string   // Signed auth label (auth-<signature>-t-<timestamp>), if any
+	Params    []string // Parameter labels, e.g. "chk-2" or "idem-abc"
+	Data      []byte   // Written data, nil for no data label
+	Key       string
+	Resource  string
+	Namespace string // Empty for the layout's default namespace
+}
+
+// Builder writes query names for a layout.
+type Builder struct {
+	layout Layout
+	apex   []string
+}
+
+// NewBuilder creates a builder for layout, which should be valid.
+func NewBuilder(layout Layout) *Builder {
+	return &Builder{layout: layout, apex: layout.apex()}
+}
+
+// Layout returns the builder's layout.
+func (b *Builder) Layout() Layout {
+	return b.layout
+}
+
+// Build returns the query name for q. Key, resource and namespace are
+// sanitized with Sanitize; other labels are written as given, and empty
+// ones are skipped.
+func (b *Builder) Build(q Query) string {
+	var sb strings.Builder
+	sb.Grow(b.estimateLen(q))
+
+	sb.WriteString(string(q.Operation))
+	if b.layout.Tokens == TokensAfterAuth {
+		writeLabel(&sb, q.Auth)
+	}
+	for _, t := range q.Tokens {
+		writeLabel(&sb, t)
+	}
+	if b.layout.Tokens != TokensAfterAuth {
+		writeLabel(&sb, q.Auth)
+	}
+	for _, p := range q.Params {
+		writeLabel(&sb, p)
+	}
+	if q.Data != nil {
+		sb.WriteByte('.')
+		sb.WriteString(b.layout.Encoding.DataLabel(q.Data))
+	}
+
+	for _, l := range b.layout.order() {
+		switch l {
+		case LabelKey:
+			if q.Key != "" {
+				writeLabel(&sb, Sanitize(q.Key))
+			}
+		case LabelResource:
+			if q.Resource != "" {
+				writeLabel(&sb, Sanitize(q.Resource))
+			}
+		case LabelNamespace:
+			if q.Namespace != "" {
+				writeLabel(&sb, Sanitize(q.Namespace))
+			} else {
+				writeLabel(&sb, b.layout.DefaultNamespace)
+			}
+		case LabelVersion:
+			// Self-hosted zones replace the version/resolvedb/tld triplet
+			if b.layout.Zone == "" {
+				writeLabel(&sb, b.layout.Version)
+			}
+		}
+	}
+	for _, a := range b.apex {
+		writeLabel(&sb, a)
+	}
+	return sb.String()
+}
+
+// estimateLen returns an upper bound of the length of q's name, used to
+// size the builder in a single allocation.
+func (b *Builder) estimateLen(q Query) int {
+	n := len(q.Operation) + len(q.Auth) + len(q.Key) + len(q.Resource) + len(q.Namespace) +
+		len(b.layout.DefaultNamespace) + len(b.layout.Version) + 8
+	for _, t := range q.Tokens {
+		n += len(t) + 1
+	}
+	for _, p := range q.Params {
+		n += len(p) + 1
+	}
+	if q.Data != nil {
+		n += len(PrefixBase64) + b.layout.Encoding.EncodedLen(len(q.Data)) + 1
+	}
+	for _, a := range b.apex {
+		n += len(a) + 1
+	}
+	return n
+}
+
+// writeLabel writes a dot-separated label, skipping empty labels.
+func writeLabel(b *strings.Builder, label string) {
+	if label == "" {
+		return
+	}
+	b.WriteByte('.')
+	b.WriteString(label)
+}
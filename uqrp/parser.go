@@ -0,0 +1,118 @@
+package uqrp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidName is returned by Parse for names that do not follow the
+// parser's layout.
+var ErrInvalidName = errors.New("uqrp: invalid query name")
+
+// Parser decodes query names written for a layout.
+type Parser struct {
+	layout Layout
+	apex   []string
+}
+
+// NewParser creates a parser for layout, which should be valid.
+func NewParser(layout Layout) *Parser {
+	return &Parser{layout: layout, apex: layout.apex()}
+}
+
+// Parse decodes a query name. The name may be fully qualified and in any
+// case; data is decoded, and the other labels are returned lowercased
+// as they appear, so sanitized keys stay sanitized.
+//
+// Which location labels are present follows from the operation (see
+// HasKey and HasResource). Between the operation and the location labels,
+// labels with a token prefix are tokens, an auth- label is the auth
+// label, a final b64- or b32- label is the data, and the rest are
+// parameters. Tokens are recognized in either TokenPlacement.
+func (p *Parser) Parse(name string) (Query, error) {
+	var q Query
+	name = strings.TrimSuffix(name, ".")
+	labels := strings.Split(name, ".")
+	for _, l := range labels {
+		if l == "" {
+			return Query{}, fmt.Errorf("%w: %q has an empty label", ErrInvalidName, name)
+		}
+	}
+
+	// Apex
+	apex := p.apex
+	if len(labels) < len(apex)+1 {
+		return Query{}, fmt.Errorf("%w: %q is too short", ErrInvalidName, name)
+	}
+	rest := len(labels) - len(apex)
+	for i, a := range apex {
+		if !strings.EqualFold(labels[rest+i], a) {
+			return Query{}, fmt.Errorf("%w: %q is not under %s", ErrInvalidName, name, strings.Join(apex, "."))
+		}
+	}
+	labels = labels[:rest]
+	q.Operation = Operation(strings.ToLower(labels[0]))
+
+	// Location labels, which end the name in layout order
+	var location []Label
+	for _, l := range p.layout.order() {
+		switch {
+		case l == LabelKey && !q.Operation.HasKey(),
+			l == LabelResource && !q.Operation.HasResource(),
+			l == LabelVersion && p.layout.Zone != "":
+			continue
+		}
+		location = append(location, l)
+	}
+	if len(labels) < len(location)+1 {
+		return Query{}, fmt.Errorf("%w: %q is missing location labels", ErrInvalidName, name)
+	}
+	rest = len(labels) - len(location)
+	for i, l := range location {
+		value := strings.ToLower(labels[rest+i])
+		switch l {
+		case LabelKey:
+			q.Key = value
+		case LabelResource:
+			q.Resource = value
+		case LabelNamespace:
+			q.Namespace = value
+		case LabelVersion:
+			if value != strings.ToLower(p.layout.Version) {
+				return Query{}, fmt.Errorf("%w: %q has version %q, not %q", ErrInvalidName, name, value, p.layout.Version)
+			}
+		}
+	}
+
+	// Tokens, auth, parameters and data
+	middle := labels[1:rest]
+	for i, l := range middle {
+		lower := strings.ToLower(l)
+		switch {
+		case hasTokenPrefix(lower):
+			q.Tokens = append(q.Tokens, l)
+		case strings.HasPrefix(lower, PrefixAuth):
+			q.Auth = lower
+		case i == len(middle)-1 && (strings.HasPrefix(lower, PrefixBase64) || strings.HasPrefix(lower, PrefixBase32)):
+			data, err := DecodeValue(lower[:4] + l[4:])
+			if err != nil {
+				return Query{}, fmt.Errorf("%w: %q has a malformed data label: %v", ErrInvalidName, name, err)
+			}
+			q.Data = data
+		default:
+			q.Params = append(q.Params, l)
+		}
+	}
+	return q, nil
+}
+
+// hasTokenPrefix reports whether label is a security token label.
+func hasTokenPrefix(label string) bool {
+	for _, prefix := range tokenPrefixes {
+		if strings.HasPrefix(label, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package uqrp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// MaxSequenceDigits bounds the index of a sequence prefix, so a sequence
+// has at most 100000 records.
+const MaxSequenceDigits = 5
+
+// ErrSequence is returned for records that do not form a complete
+// sequence.
+var ErrSequence = errors.New("uqrp: incomplete record sequence")
+
+// Sequence splits an answer payload into TXT records of at most size
+// bytes for servers. DNS does not preserve the order of the records in an
+// answer, and resolvers shuffle them, so when the payload needs more than
+// one record each is prefixed with its zero-based sequence index in
+// decimal and a colon, "<idx>:<data>", counted in size. A payload that
+// fits in one record is returned as is.
+//
+// Example:
+//
+//	records, err := uqrp.Sequence(answer, 255)
+//	// records[0] == "0:v=rdb1;s=ok;...", records[1] == "1:..."
+func Sequence(data []byte, size int) ([][]byte, error) {
+	if len(data) <= size {
+		return [][]byte{data}, nil
+	}
+
+	var records [][]byte
+	for i := 0; len(data) > 0; i++ {
+		prefix := strconv.Itoa(i) + ":"
+		if len(prefix) > MaxSequenceDigits+1 {
+			return nil, fmt.Errorf("uqrp: payload needs more than %d records of %d bytes", len(records), size)
+		}
+		n := min(len(data), size-len(prefix))
+		if n <= 0 {
+			return nil, fmt.Errorf("uqrp: record size %d leaves no room for data after %q", size, prefix)
+		}
+		record := make([]byte, 0, len(prefix)+n)
+		record = append(append(record, prefix...), data[:n]...)
+		records = append(records, record)
+		data = data[n:]
+	}
+	return records, nil
+}
+
+// SplitSequence splits a "<idx>:<data>" record into its index and data.
+// ok is false if the record has no sequence prefix.
+func SplitSequence(record []byte) (index int, data []byte, ok bool) {
+	for i, b := range record {
+		switch {
+		case b >= '0' && b <= '9':
+			if i >= MaxSequenceDigits {
+				return 0, nil, false
+			}
+			index = index*10 + int(b-'0')
+		case b == ':' && i > 0:
+			return index, record[i+1:], true
+		default:
+			return 0, nil, false
+		}
+	}
+	return 0, nil, false
+}
+
+// JoinSequence reassembles an answer payload from its records, given in
+// the order a resolver returned them. If every record has a sequence
+// prefix, the records are put in index order and the prefixes stripped,
+// and the indexes must run from 0 without gaps or repeats; otherwise the
+// records are joined in the order given.
+func JoinSequence(records [][]byte) ([]byte, error) {
+	type sequenced struct {
+		index int
+		data  []byte
+	}
+	seq := make([]sequenced, 0, len(records))
+	for _, r := range records {
+		idx, data, ok := SplitSequence(r)
+		if !ok {
+			return join(records), nil
+		}
+		seq = append(seq, sequenced{index: idx, data: data})
+	}
+	sort.Slice(seq, func(i, j int) bool { return seq[i].index < seq[j].index })
+
+	ordered := make([][]byte, len(seq))
+	for i, s := range seq {
+		if s.index != i {
+			return nil, fmt.Errorf("%w: expected index %d, got %d", ErrSequence, i, s.index)
+		}
+		ordered[i] = s.data
+	}
+	return join(ordered), nil
+}
+
+// join concatenates records.
+func join(records [][]byte) []byte {
+	n := 0
+	for _, r := range records {
+		n += len(r)
+	}
+	data := make([]byte, 0, n)
+	for _, r := range records {
+		data = append(data, r...)
+	}
+	return data
+}
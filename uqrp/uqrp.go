@@ -0,0 +1,282 @@
+// Package uqrp builds and parses ResolveDB query names, the question half
+// of the Universal Query Response Protocol. The client uses it to name
+// every query it sends, so servers, proxies and debugging tools that use
+// it agree with the SDK label for label. It also defines how answers too
+// large for one TXT record are split into sequenced records (see
+// Sequence).
+//
+// A query name is laid out as
+//
+//	<operation>.<tokens>.<auth>.<params>.<data>.<key>.<resource>.<namespace>.<version>.resolvedb.<tld>
+//
+// where everything between the operation and the key is optional, the
+// order of the location labels (key through version) and the placement of
+// the tokens are set by a Layout, and the apex (resolvedb.<tld>) may be
+// replaced by custom labels or, together with the version, by a
+// self-hosted zone.
+//
+// Example:
+//
+//	name := uqrp.NewBuilder(uqrp.DefaultLayout()).Build(uqrp.Query{
+//	    Operation: uqrp.OpGet,
+//	    Resource:  "weather",
+//	    Key:       "tokyo",
+//	})
+//	// name == "get.tokyo.weather.public.v1.resolvedb.net"
+//
+//	q, err := uqrp.NewParser(uqrp.DefaultLayout()).Parse(name)
+package uqrp
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// Operation is the first label of a query name.
+type Operation string
+
+// Operations understood by ResolveDB.
+const (
+	OpGet         Operation = "get"
+	OpPut         Operation = "put"
+	OpDelete      Operation = "delete"
+	OpPatch       Operation = "patch"
+	OpMerge       Operation = "merge"
+	OpList        Operation = "list"
+	OpResources   Operation = "resources"
+	OpCount       Operation = "count"
+	OpVersions    Operation = "versions"
+	OpQuery       Operation = "query"
+	OpIndex       Operation = "index"
+	OpIndexes     Operation = "indexes"
+	OpCreateIndex Operation = "createindex"
+	OpDropIndex   Operation = "dropindex"
+	OpTxn         Operation = "txn"
+	OpUsage       Operation = "usage"
+	OpCreateKey   Operation = "createkey"
+	OpRotate      Operation = "rotate"
+	OpRevoke      Operation = "revoke"
+	OpChanges     Operation = "changes"
+)
+
+// IsRead reports whether the operation only reads data, so it may be
+// served by any replica.
+func (o Operation) IsRead() bool {
+	switch o {
+	case OpGet, OpList, OpResources, OpCount, OpVersions, OpUsage, OpQuery, OpIndex, OpIndexes, OpChanges:
+		return true
+	}
+	return false
+}
+
+// HasKey reports whether query names of the operation carry a key label.
+// Operations this package does not know are assumed to, like get.
+func (o Operation) HasKey() bool {
+	switch o {
+	case OpList, OpResources, OpCount, OpQuery, OpIndex, OpIndexes, OpCreateIndex, OpDropIndex,
+		OpTxn, OpUsage, OpCreateKey, OpRotate, OpChanges:
+		return false
+	}
+	return true
+}
+
+// HasResource reports whether query names of the operation carry a
+// resource label.
+func (o Operation) HasResource() bool {
+	switch o {
+	case OpResources, OpTxn, OpUsage:
+		return false
+	}
+	return true
+}
+
+// Label prefixes. Per RFC 1035, colons are invalid in DNS labels, so
+// hyphens are used.
+const (
+	PrefixBase64     = "b64-"
+	PrefixBase32     = "b32-"
+	PrefixHex        = "hex-"
+	PrefixAuth       = "auth-"
+	PrefixBDT        = "bdt-"
+	PrefixCTP        = "ctp-"
+	PrefixSig        = "sig-"
+	PrefixReadToken  = "drt-"
+	PrefixVer        = "ver-"
+	PrefixAt         = "at-"
+	PrefixExp        = "exp-"
+	PrefixCST        = "cst-"
+	PrefixIdem       = "idem-"
+	PrefixFields     = "fld-"
+	PrefixChunk      = "chk-"
+	PrefixQuery      = "q-"
+	PrefixIndexValue = "iv-"
+	PrefixFormat     = "fmt-"
+	PrefixEncoding   = "enc-"
+	PrefixCursor     = "cur-"
+	PrefixNonce      = "nc-"
+	PrefixIfMatch    = "ifm-"
+)
+
+// IfAbsent is the PrefixIfMatch value of writes that only create a key.
+const IfAbsent = "none"
+
+// tokenPrefixes mark the security token labels that follow the operation.
+var tokenPrefixes = []string{PrefixSig, PrefixCTP, PrefixBDT, PrefixReadToken}
+
+// Label identifies a location component of a query name.
+type Label int
+
+// Query name location components.
+const (
+	LabelKey Label = iota
+	LabelResource
+	LabelNamespace
+	LabelVersion
+)
+
+// String returns the label's name.
+func (l Label) String() string {
+	switch l {
+	case LabelKey:
+		return "key"
+	case LabelResource:
+		return "resource"
+	case LabelNamespace:
+		return "namespace"
+	case LabelVersion:
+		return "version"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultLabelOrder returns the standard label layout:
+// <key>.<resource>.<namespace>.<version>.
+func DefaultLabelOrder() []Label {
+	return []Label{LabelKey, LabelResource, LabelNamespace, LabelVersion}
+}
+
+// Encoding selects how binary data is encoded in query labels.
+type Encoding int
+
+// Label encodings.
+const (
+	// EncodingBase64 encodes data as base64url, prefixed "b64-" in data
+	// labels. It is the most compact encoding.
+	EncodingBase64 Encoding = iota
+
+	// EncodingBase32 encodes data as lowercase base32hex, prefixed "b32-".
+	// Its alphabet is case-insensitive and free of "_", so it survives
+	// resolvers and middleboxes that fold or randomize case or mangle
+	// unusual hostname characters, at the cost of longer labels.
+	EncodingBase32
+)
+
+// TokenPlacement selects where security token labels go in a query name.
+// SDKs and server versions have differed here; all placements parse the
+// same way, but a server may only accept one.
+type TokenPlacement int
+
+// Token placements.
+const (
+	// TokensAfterOperation writes tokens right after the operation:
+	// <op>.<tokens>.<auth>.<params>.<data>. It is the default.
+	TokensAfterOperation TokenPlacement = iota
+
+	// TokensAfterAuth writes tokens after the auth label:
+	// <op>.<auth>.<tokens>.<params>.<data>.
+	TokensAfterAuth
+)
+
+// String returns the placement name.
+func (p TokenPlacement) String() string {
+	switch p {
+	case TokensAfterOperation:
+		return "after_operation"
+	case TokensAfterAuth:
+		return "after_auth"
+	default:
+		return fmt.Sprintf("TokenPlacement(%d)", int(p))
+	}
+}
+
+// base32Label is base32hex without padding. Lowercased, its alphabet
+// (0-9, a-v) survives resolvers and middleboxes that fold or randomize the
+// case of names.
+var base32Label = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// DataLabel encodes data as a self-describing data label: b64-<base64url>,
+// or b32-<base32hex> with EncodingBase32.
+func (e Encoding) DataLabel(data []byte) string {
+	if e == EncodingBase32 {
+		return PrefixBase32 + strings.ToLower(base32Label.EncodeToString(data))
+	}
+	return PrefixBase64 + base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParamValue encodes data for a parameter label whose own prefix implies
+// base64url (fld-, q-, iv-). With EncodingBase32 the value is base32hex,
+// marked by a b32- prefix after the parameter prefix.
+func (e Encoding) ParamValue(data []byte) string {
+	if e == EncodingBase32 {
+		return PrefixBase32 + strings.ToLower(base32Label.EncodeToString(data))
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// EncodedLen returns the length of n bytes in the encoding, without prefix.
+func (e Encoding) EncodedLen(n int) int {
+	if e == EncodingBase32 {
+		return base32Label.EncodedLen(n)
+	}
+	return base64.RawURLEncoding.EncodedLen(n)
+}
+
+// DecodedLen returns the number of bytes that fit in n characters of the
+// encoding.
+func (e Encoding) DecodedLen(n int) int {
+	if e == EncodingBase32 {
+		return base32Label.DecodedLen(n)
+	}
+	return base64.RawURLEncoding.DecodedLen(n)
+}
+
+// DecodeValue decodes a data label or parameter value in either encoding:
+// b32- marks base32hex in any case, anything else is base64url with or
+// without padding, after an optional b64- prefix.
+func DecodeValue(s string) ([]byte, error) {
+	if v, ok := strings.CutPrefix(s, PrefixBase32); ok {
+		return base32Label.DecodeString(strings.TrimRight(strings.ToUpper(v), "="))
+	}
+	s = strings.TrimPrefix(s, PrefixBase64)
+	if strings.HasSuffix(s, "=") {
+		return base64.URLEncoding.DecodeString(s)
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// Sanitize makes s a valid DNS label the way key, resource and namespace
+// labels are written: it is lowercased, "_" and " " become "-", other
+// invalid characters are dropped, leading and trailing hyphens are
+// trimmed, and the result is cut to 63 characters.
+func Sanitize(s string) string {
+	s = strings.ToLower(s)
+	var result strings.Builder
+	for _, r := range s {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			result.WriteRune(r)
+		} else if r == '_' || r == ' ' {
+			result.WriteRune('-')
+		}
+	}
+	// DNS labels must start and end with alphanumeric
+	label := result.String()
+	label = strings.Trim(label, "-")
+	// Max label length is 63 characters
+	if len(label) > 63 {
+		label = label[:63]
+	}
+	return label
+}
@@ -0,0 +1,73 @@
+package resolvedb
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Usage reports quota consumption and rate-limit state for the account.
+type Usage struct {
+	QueriesUsed      int64     // Queries used in the current billing period
+	QueriesRemaining int64     // Queries remaining in the current billing period
+	StorageBytes     int64     // Bytes currently stored
+	StorageLimit     int64     // Maximum storable bytes (0 if unlimited)
+	RateLimit        int       // Queries allowed per rate-limit window
+	RateRemaining    int       // Queries remaining in the current window
+	RateReset        time.Time // Start of the next rate-limit window
+	PeriodReset      time.Time // Start of the next billing period
+}
+
+// UnmarshalJSON decodes usage from the server's usage format.
+func (u *Usage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		QueriesUsed      int64 `json:"queries_used"`
+		QueriesRemaining int64 `json:"queries_remaining"`
+		StorageBytes     int64 `json:"storage_bytes"`
+		StorageLimit     int64 `json:"storage_limit"`
+		RateLimit        int   `json:"rate_limit"`
+		RateRemaining    int   `json:"rate_remaining"`
+		RateReset        int64 `json:"rate_reset"`
+		PeriodReset      int64 `json:"period_reset"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	u.QueriesUsed = raw.QueriesUsed
+	u.QueriesRemaining = raw.QueriesRemaining
+	u.StorageBytes = raw.StorageBytes
+	u.StorageLimit = raw.StorageLimit
+	u.RateLimit = raw.RateLimit
+	u.RateRemaining = raw.RateRemaining
+	if raw.RateReset > 0 {
+		u.RateReset = time.Unix(raw.RateReset, 0)
+	}
+	if raw.PeriodReset > 0 {
+		u.PeriodReset = time.Unix(raw.PeriodReset, 0)
+	}
+	return nil
+}
+
+// Usage retrieves quota and rate-limit usage for the client's API key.
+//
+// Example:
+//
+//	usage, err := client.Usage(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if usage.RateRemaining < 10 {
+//	    time.Sleep(time.Until(usage.RateReset))
+//	}
+func (c *Client) Usage(ctx context.Context, opts ...RequestOption) (*Usage, error) {
+	resp, err := c.authQuery(ctx, "usage", "", "", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var usage Usage
+	if err := resp.Unmarshal(&usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
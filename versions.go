@@ -0,0 +1,95 @@
+package resolvedb
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// VersionInfo describes a stored version of a key.
+type VersionInfo struct {
+	Version   int       // Version number, starting at 1
+	Hash      string    // Content hash of the version
+	Size      int       // Stored size in bytes
+	Timestamp time.Time // Time the version was written
+}
+
+// UnmarshalJSON decodes a version entry from the server's list format.
+func (v *VersionInfo) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Version int    `json:"version"`
+		Hash    string `json:"hash"`
+		Size    int    `json:"size"`
+		TS      int64  `json:"ts"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	v.Version = raw.Version
+	v.Hash = raw.Hash
+	v.Size = raw.Size
+	if raw.TS > 0 {
+		v.Timestamp = time.Unix(raw.TS, 0)
+	}
+	return nil
+}
+
+// GetVersion retrieves a specific version of a key, unmarshaling into dst.
+// Versioned reads are immutable and are cached independently of the latest value.
+//
+// Example:
+//
+//	var cfg DeviceConfig
+//	err := client.GetVersion(ctx, "config", "device-42", 7, &cfg)
+func (c *Client) GetVersion(ctx context.Context, resource, key string, version int, dst any, opts ...RequestOption) error {
+	reqConfig := newRequestConfig(ctx, opts)
+	reqConfig.params = []string{PrefixVer + strconv.Itoa(version)}
+
+	resp, err := c.get(ctx, resource, key, reqConfig)
+	if err != nil {
+		return err
+	}
+	return c.unmarshal(resp, resource, key, dst)
+}
+
+// GetAt retrieves the version of a key that was current at time t,
+// unmarshaling into dst.
+//
+// Example:
+//
+//	lastTuesday := time.Date(2024, 3, 12, 9, 0, 0, 0, time.UTC)
+//	err := client.GetAt(ctx, "config", "device-42", lastTuesday, &cfg)
+func (c *Client) GetAt(ctx context.Context, resource, key string, t time.Time, dst any, opts ...RequestOption) error {
+	reqConfig := newRequestConfig(ctx, opts)
+	reqConfig.params = []string{PrefixAt + strconv.FormatInt(t.Unix(), 10)}
+
+	resp, err := c.get(ctx, resource, key, reqConfig)
+	if err != nil {
+		return err
+	}
+	return c.unmarshal(resp, resource, key, dst)
+}
+
+// ListVersions retrieves the version history of a key, oldest first.
+func (c *Client) ListVersions(ctx context.Context, resource, key string, opts ...RequestOption) ([]VersionInfo, error) {
+	reqConfig := newRequestConfig(ctx, opts)
+
+	queryName := c.buildQueryName("versions", resource, key, reqConfig)
+
+	resp, err := c.query(ctx, "versions", resource, key, queryName, reqConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resp.ToError(); err != nil {
+		return nil, c.queryError(err, "versions", resource, key, reqConfig, resp)
+	}
+
+	var versions []VersionInfo
+	if err := resp.Unmarshal(&versions); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,119 @@
+package resolvedb
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxReportedWarnings bounds the distinct warnings a client remembers
+// having reported; warnings beyond it are not reported to the handler.
+const maxReportedWarnings = 1024
+
+// WarningCode classifies a Warning.
+type WarningCode string
+
+// Warning codes.
+const (
+	// WarningTTLRaised reports an answer whose DNS TTL exceeds the TTL the
+	// server set, so a resolver on the path serves data longer than
+	// intended.
+	WarningTTLRaised WarningCode = "ttl_raised"
+
+	// WarningUnknownField reports a response field this SDK does not
+	// know, ignored because the response carries its data in d=. It
+	// usually means the server is newer than the SDK.
+	WarningUnknownField WarningCode = "unknown_field"
+
+	// WarningDeprecated reports a resource the server marks as
+	// deprecated; the message is the server's.
+	WarningDeprecated WarningCode = "deprecated"
+
+	// WarningPayloadNearLimit reports a query name or answer nearing its
+	// DNS size limit. See WithPayloadWarning.
+	WarningPayloadNearLimit WarningCode = "payload_near_limit"
+
+	// WarningCacheCardinality reports a resource with more distinct keys
+	// cached than WithCacheKeyLimit allows.
+	WarningCacheCardinality WarningCode = "cache_cardinality"
+
+	// WarningSDKDefect reports a critical defect the published SDK
+	// advisory lists for this SDK version. See WithVersionCheck.
+	WarningSDKDefect WarningCode = "sdk_defect"
+)
+
+// Warning is a soft protocol problem: the request succeeded, but something
+// is likely to fail later or behave unexpectedly.
+type Warning struct {
+	Code     WarningCode
+	Resource string
+	Message  string
+}
+
+// String returns the warning as "<code> <resource>: <message>".
+func (w Warning) String() string {
+	return fmt.Sprintf("%s %s: %s", w.Code, w.Resource, w.Message)
+}
+
+// WithWarningHandler calls fn with soft protocol problems, so operators
+// see them before they become failures. Each distinct warning is reported
+// once per client; warnings of a response are also in its
+// ResponseMeta.Warnings. Without a handler, warnings are logged. Panics in
+// fn are recovered (see SafeCall).
+//
+// Example:
+//
+//	client, err := resolvedb.New(
+//	    resolvedb.WithWarningHandler(func(w resolvedb.Warning) {
+//	        metrics.Inc("resolvedb_warning", string(w.Code), w.Resource)
+//	    }),
+//	)
+func WithWarningHandler(fn func(Warning)) Option {
+	return func(c *clientConfig) {
+		c.warningHandler = fn
+	}
+}
+
+// warn reports w to the warning handler, or logs it, unless it was
+// reported before.
+func (c *Client) warn(w Warning) {
+	c.stats.warnings.Add(1)
+	if _, seen := c.warned.Load(w); seen || c.warnedCount.Load() >= maxReportedWarnings {
+		return
+	}
+	if _, seen := c.warned.LoadOrStore(w, struct{}{}); seen {
+		return
+	}
+	c.warnedCount.Add(1)
+
+	if fn := c.config.warningHandler; fn != nil {
+		_ = SafeCall(c, "warning handler", func() { fn(w) })
+		return
+	}
+	c.logger().Warn("resolvedb: "+w.Message, "code", string(w.Code), "resource", w.Resource)
+}
+
+// unknownFieldWarning returns the warning about an unknown response field
+// and the number of others found with it.
+func unknownFieldWarning(key string, others int) Warning {
+	msg := fmt.Sprintf("unknown response field %q ignored", key)
+	if others > 0 {
+		msg = fmt.Sprintf("unknown response field %q and %d others ignored", key, others)
+	}
+	return Warning{Code: WarningUnknownField, Message: msg}
+}
+
+// responseWarnings adds the warnings about resp, an answer for resource
+// with DNS TTL dnsTTL, to its metadata and reports them.
+func (c *Client) responseWarnings(resource string, resp *Response, dnsTTL time.Duration) {
+	if resp.TTL > 0 && dnsTTL > resp.TTL {
+		resp.Meta.Warnings = append(resp.Meta.Warnings, Warning{
+			Code:    WarningTTLRaised,
+			Message: fmt.Sprintf("answer TTL %s exceeds response TTL %s", dnsTTL, resp.TTL),
+		})
+	}
+	for i := range resp.Meta.Warnings {
+		w := &resp.Meta.Warnings[i]
+		w.Resource = resource
+		c.warn(*w)
+	}
+}
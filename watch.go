@@ -0,0 +1,144 @@
+package resolvedb
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// Watch polling bounds.
+const (
+	minWatchInterval     = time.Second
+	maxWatchInterval     = 5 * time.Minute
+	defaultWatchInterval = 30 * time.Second
+)
+
+// WatchEvent is a value change or error observed by Watch.
+type WatchEvent struct {
+	Response *Response // Current value, nil on error
+	Err      error     // Lookup error, nil on success
+}
+
+// Watch polls a key and sends an event with the current value, then an
+// event each time the value changes. Changes are detected by content hash
+// when the server reports one, otherwise by comparing data. Lookup errors
+// are sent as events and polling continues.
+//
+// The key is re-read when its TTL expires, bounded to between 1s and 5m;
+// use WithPollInterval to poll at a fixed interval instead. The channel is
+// closed when ctx is done.
+//
+// Polling never waits for the consumer. The channel buffers one event, or
+// as many as WithWatchBuffer sets; when it is full, the oldest buffered
+// event is dropped in favor of the newest, so a slow consumer skips
+// intermediate values rather than falling behind. Stats.WatchDropped
+// counts dropped events.
+//
+// Example:
+//
+//	for ev := range client.Watch(ctx, "config", "app") {
+//	    if ev.Err != nil {
+//	        log.Printf("watch: %v", ev.Err)
+//	        continue
+//	    }
+//	    var cfg AppConfig
+//	    if err := ev.Response.Unmarshal(&cfg); err == nil {
+//	        apply(cfg)
+//	    }
+//	}
+func (c *Client) Watch(ctx context.Context, resource, key string, opts ...RequestOption) <-chan WatchEvent {
+	reqConfig := newRequestConfig(ctx, opts)
+	events := make(chan WatchEvent, watchBuffer(reqConfig, 1))
+	reqConfig.refresh = true
+
+	ctx, done, ok := c.background(ctx)
+	if !ok {
+		events <- WatchEvent{Err: ErrClosed}
+		close(events)
+		return events
+	}
+
+	go func() {
+		defer done()
+		defer close(events)
+
+		var last *Response
+		for {
+			resp, err := c.get(ctx, resource, key, reqConfig)
+			if ctx.Err() != nil {
+				return
+			}
+
+			var ev *WatchEvent
+			switch {
+			case err != nil:
+				ev = &WatchEvent{Err: err}
+			case last == nil || changed(last, resp):
+				ev = &WatchEvent{Response: resp}
+				last = resp
+			}
+			if ev != nil {
+				c.sendLatest(events, *ev)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.config.clock.After(watchInterval(resp, reqConfig)):
+			}
+		}
+	}()
+
+	return events
+}
+
+// sendLatest sends ev without blocking, dropping the oldest buffered
+// events to make room.
+func (c *Client) sendLatest(events chan WatchEvent, ev WatchEvent) {
+	for {
+		select {
+		case events <- ev:
+			return
+		default:
+		}
+		select {
+		case <-events:
+			c.stats.watchDropped.Add(1)
+		default:
+			// The consumer made room
+		}
+	}
+}
+
+// watchBuffer returns the event buffer size set by WithWatchBuffer, or def.
+func watchBuffer(reqConfig *requestConfig, def int) int {
+	if reqConfig.watchBuffer > 0 {
+		return reqConfig.watchBuffer
+	}
+	return def
+}
+
+// changed reports whether cur differs from prev.
+func changed(prev, cur *Response) bool {
+	if prev.Hash != "" && cur.Hash != "" {
+		return prev.Hash != cur.Hash
+	}
+	return !bytes.Equal(prev.Data, cur.Data)
+}
+
+// watchInterval returns the delay before the next poll.
+func watchInterval(resp *Response, reqConfig *requestConfig) time.Duration {
+	if reqConfig.pollInterval > 0 {
+		return reqConfig.pollInterval
+	}
+	if resp == nil || resp.TTL <= 0 {
+		return defaultWatchInterval
+	}
+	switch {
+	case resp.TTL < minWatchInterval:
+		return minWatchInterval
+	case resp.TTL > maxWatchInterval:
+		return maxWatchInterval
+	}
+	return resp.TTL
+}
@@ -0,0 +1,267 @@
+package resolvedb
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ChangeType classifies a ResourceEvent.
+type ChangeType int
+
+const (
+	// KeyAdded reports a key that did not exist at the previous poll.
+	KeyAdded ChangeType = iota + 1
+
+	// KeyModified reports a key whose value changed.
+	KeyModified
+
+	// KeyRemoved reports a key that no longer exists.
+	KeyRemoved
+)
+
+// String returns the change type name.
+func (t ChangeType) String() string {
+	switch t {
+	case KeyAdded:
+		return "added"
+	case KeyModified:
+		return "modified"
+	case KeyRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// ResourceEvent is a key change or error observed by WatchResource.
+type ResourceEvent struct {
+	Type ChangeType // Kind of change, zero on error
+	Key  string     // Changed key
+	Info KeyInfo    // Key metadata; for KeyRemoved, the last metadata seen
+	Err  error      // List error, nil on success
+}
+
+// WatchResource polls the key list of a resource and sends an event for
+// each added, modified and removed key. Keys present at the first poll are
+// sent as KeyAdded. Values are not fetched; use GetRaw on the keys that
+// matter. Modifications are detected by content hash, falling back to size
+// and update time, so servers that list bare keys only report additions and
+// removals. When the list itself carries a hash, unchanged lists are not
+// diffed.
+//
+// Servers that keep a change log report a cursor with the list (see
+// ResponseMeta.ChangeCursor). Later polls then only ask for the changes
+// since the cursor, so large resources cost one small query per poll. If
+// the server stops accepting the cursor, the full list is read again.
+//
+// The list is re-read when its TTL expires, bounded to between 1s and 5m;
+// use WithPollInterval to poll at a fixed interval instead. The channel is
+// closed when ctx is done.
+//
+// Polling never waits for the consumer. Once the channel's buffer of 16
+// events (see WithWatchBuffer) is full, further events are held back and
+// coalesced per key until the consumer catches up: a key added and then
+// modified is reported once as added, one added and then removed is not
+// reported, and only the latest error is kept. Stats.WatchDropped counts
+// events coalesced away.
+//
+// Example:
+//
+//	for ev := range client.WatchResource(ctx, "devices") {
+//	    switch ev.Type {
+//	    case resolvedb.KeyAdded, resolvedb.KeyModified:
+//	        refresh(ev.Key)
+//	    case resolvedb.KeyRemoved:
+//	        evict(ev.Key)
+//	    }
+//	}
+func (c *Client) WatchResource(ctx context.Context, resource string, opts ...RequestOption) <-chan ResourceEvent {
+	events := make(chan ResourceEvent, watchBuffer(newRequestConfig(ctx, opts), 16))
+
+	ctx, done, ok := c.background(ctx)
+	if !ok {
+		events <- ResourceEvent{Err: ErrClosed}
+		close(events)
+		return events
+	}
+
+	go func() {
+		defer done()
+		defer close(events)
+
+		var listHash, cursor string
+		known := make(map[string]KeyInfo)
+		backlog := newEventBacklog(&c.stats.watchDropped)
+		for {
+			reqConfig := newRequestConfig(ctx, opts)
+			var resp *Response
+			var err error
+			resync := cursor == ""
+			if !resync {
+				var changes []keyChange
+				resp, changes, err = c.changesSince(ctx, resource, cursor, reqConfig)
+				switch {
+				case err == nil:
+					cursor = resp.Meta.ChangeCursor
+					if len(changes) > 0 {
+						listHash = "" // known no longer matches the last list
+					}
+					for _, ev := range applyChanges(known, changes) {
+						backlog.add(ev)
+					}
+				case needsResync(err) && ctx.Err() == nil:
+					c.logger().Debug("resolvedb: change cursor unusable, listing keys", "resource", resource, "error", err)
+					resync = true
+				default:
+					backlog.add(ResourceEvent{Err: err})
+				}
+			}
+
+			if resync {
+				var keys []KeyInfo
+				resp, keys, err = c.listDetailed(ctx, resource, reqConfig)
+				switch {
+				case err != nil:
+					backlog.add(ResourceEvent{Err: err})
+				case resp.Hash != "" && resp.Hash == listHash:
+					// List unchanged
+					cursor = resp.Meta.ChangeCursor
+				default:
+					listHash = resp.Hash
+					cursor = resp.Meta.ChangeCursor
+					for _, ev := range diffKeys(known, keys) {
+						backlog.add(ev)
+					}
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			if !backlog.flush(ctx, events, c.config.clock.After(watchInterval(resp, reqConfig))) {
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// eventBacklog holds resource events a slow consumer has not yet received,
+// coalesced to at most one per key and one error.
+type eventBacklog struct {
+	order   []string                 // Keys in arrival order, possibly stale
+	pending map[string]ResourceEvent // Undelivered events by key; "" holds the error
+	dropped *atomic.Int64
+}
+
+func newEventBacklog(dropped *atomic.Int64) *eventBacklog {
+	return &eventBacklog{pending: make(map[string]ResourceEvent), dropped: dropped}
+}
+
+// add queues ev, merging it with an undelivered event for the same key.
+func (b *eventBacklog) add(ev ResourceEvent) {
+	key := ev.Key
+	if ev.Err != nil {
+		key = ""
+	}
+	prev, ok := b.pending[key]
+	if !ok {
+		b.order = append(b.order, key)
+		b.pending[key] = ev
+		return
+	}
+
+	b.dropped.Add(1)
+	switch {
+	case ev.Err != nil:
+	case prev.Type == KeyAdded && ev.Type == KeyRemoved:
+		// The consumer never saw the key
+		b.dropped.Add(1)
+		delete(b.pending, key)
+		return
+	case prev.Type == KeyAdded:
+		ev.Type = KeyAdded
+	case prev.Type == KeyRemoved && ev.Type == KeyAdded:
+		ev.Type = KeyModified
+	}
+	b.pending[key] = ev
+}
+
+// flush delivers queued events until wait fires, returning false if ctx
+// is done first.
+func (b *eventBacklog) flush(ctx context.Context, events chan<- ResourceEvent, wait <-chan time.Time) bool {
+	for {
+		var out chan<- ResourceEvent
+		next, ok := b.next()
+		if ok {
+			out = events
+		}
+		select {
+		case out <- next:
+			b.pop()
+		case <-wait:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// next returns the oldest queued event, skipping keys whose events were
+// cancelled or delivered.
+func (b *eventBacklog) next() (ResourceEvent, bool) {
+	for len(b.order) > 0 {
+		if ev, ok := b.pending[b.order[0]]; ok {
+			return ev, true
+		}
+		b.order = b.order[1:]
+	}
+	return ResourceEvent{}, false
+}
+
+// pop removes the event returned by next.
+func (b *eventBacklog) pop() {
+	delete(b.pending, b.order[0])
+	b.order = b.order[1:]
+}
+
+// diffKeys updates known to keys and returns the changes, removals last.
+func diffKeys(known map[string]KeyInfo, keys []KeyInfo) []ResourceEvent {
+	var events []ResourceEvent
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		seen[k.Key] = true
+		prev, ok := known[k.Key]
+		switch {
+		case !ok:
+			events = append(events, ResourceEvent{Type: KeyAdded, Key: k.Key, Info: k})
+		case keyChanged(prev, k):
+			events = append(events, ResourceEvent{Type: KeyModified, Key: k.Key, Info: k})
+		}
+		known[k.Key] = k
+	}
+
+	var removed []string
+	for key := range known {
+		if !seen[key] {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	for _, key := range removed {
+		events = append(events, ResourceEvent{Type: KeyRemoved, Key: key, Info: known[key]})
+		delete(known, key)
+	}
+	return events
+}
+
+// keyChanged reports whether a key's metadata indicates a new value.
+func keyChanged(prev, cur KeyInfo) bool {
+	if prev.Hash != "" && cur.Hash != "" {
+		return prev.Hash != cur.Hash
+	}
+	return prev.Size != cur.Size || !prev.Updated.Equal(cur.Updated)
+}